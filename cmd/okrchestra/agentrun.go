@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/guardrails"
+)
+
+// agentRunSummaryFile is written next to the transcript of an `agent run`.
+const agentRunSummaryFile = "agent_run_summary.json"
+
+// agentRunSummary is the outcome of an `agent run`: what the adapter
+// returned, whether its result.json is valid, and the files it left behind.
+type agentRunSummary struct {
+	Adapter     string                   `json:"adapter"`
+	ExitCode    int                      `json:"exit_code"`
+	Error       string                   `json:"error,omitempty"`
+	ResultValid bool                     `json:"result_valid"`
+	ResultError string                   `json:"result_error,omitempty"`
+	Result      *guardrails.ResultSchema `json:"result,omitempty"`
+	Usage       *adapters.Usage          `json:"usage,omitempty"`
+	Transcript  string                   `json:"transcript,omitempty"`
+	ResultPath  string                   `json:"result_json"`
+	Artifacts   string                   `json:"artifacts_dir"`
+	Files       []string                 `json:"files"`
+	SummaryPath string                   `json:"summary_path"`
+}
+
+// summarizeAgentRun validates the result.json of an adapter run and lists
+// the files in artifactsDir. result may be nil when the adapter failed to
+// start.
+func summarizeAgentRun(adapterName, artifactsDir string, result *adapters.RunResult, runErr error) *agentRunSummary {
+	summary := &agentRunSummary{
+		Adapter:    adapterName,
+		ExitCode:   -1,
+		ResultPath: filepath.Join(artifactsDir, "result.json"),
+		Artifacts:  artifactsDir,
+	}
+	if result != nil {
+		summary.ExitCode = result.ExitCode
+		summary.Transcript = result.TranscriptPath
+		summary.Usage = result.Usage
+		if result.SummaryPath != "" {
+			summary.ResultPath = result.SummaryPath
+		}
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+
+	if err := guardrails.ValidateResultJSON(summary.ResultPath); err != nil {
+		summary.ResultError = err.Error()
+	} else {
+		summary.ResultValid = true
+	}
+	// Show what the agent reported even when the result is not valid, as
+	// far as it parses.
+	if data, err := os.ReadFile(summary.ResultPath); err == nil {
+		var parsed guardrails.ResultSchema
+		if json.Unmarshal(data, &parsed) == nil {
+			summary.Result = &parsed
+		}
+	}
+
+	dir := artifactsDir
+	if summary.Transcript != "" {
+		dir = filepath.Dir(summary.Transcript)
+	}
+	summary.SummaryPath = filepath.Join(dir, agentRunSummaryFile)
+	summary.Files = artifactFiles(artifactsDir, summary.SummaryPath)
+	return summary
+}
+
+// artifactFiles lists the files under dir relative to it, sorted, leaving
+// out skip. A missing dir has none.
+func artifactFiles(dir, skip string) []string {
+	files := []string{}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path == skip {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func writeAgentRunSummary(summary *agentRunSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal agent run summary: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(summary.SummaryPath), 0o755); err != nil {
+		return fmt.Errorf("create summary dir: %w", err)
+	}
+	if err := os.WriteFile(summary.SummaryPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write agent run summary: %w", err)
+	}
+	return nil
+}
+
+func printAgentRunSummary(summary *agentRunSummary) {
+	fmt.Fprintf(os.Stdout, "Adapter: %s\n", summary.Adapter)
+	fmt.Fprintf(os.Stdout, "Exit code: %d\n", summary.ExitCode)
+	if summary.Error != "" {
+		fmt.Fprintf(os.Stdout, "Error: %s\n", summary.Error)
+	}
+	if summary.ResultValid {
+		fmt.Fprintln(os.Stdout, "Result: valid")
+	} else {
+		fmt.Fprintf(os.Stdout, "Result: invalid (%s)\n", summary.ResultError)
+	}
+	if r := summary.Result; r != nil {
+		if r.Summary != "" {
+			fmt.Fprintf(os.Stdout, "  Summary: %s\n", r.Summary)
+		}
+		if len(r.KRTargets) > 0 {
+			fmt.Fprintf(os.Stdout, "  KR targets: %s\n", strings.Join(r.KRTargets, ", "))
+		}
+		if r.KRImpactClaim != "" {
+			fmt.Fprintf(os.Stdout, "  KR impact claim: %s\n", r.KRImpactClaim)
+		}
+		for _, change := range r.ProposedChanges {
+			fmt.Fprintf(os.Stdout, "  Proposed change: %s\n", change)
+		}
+	}
+	if u := summary.Usage; u != nil && (u.TotalTokens > 0 || u.DurationSeconds > 0) {
+		fmt.Fprintf(os.Stdout, "Usage: %d tokens (%d in, %d out), agent time %s\n",
+			u.TotalTokens, u.InputTokens, u.OutputTokens,
+			time.Duration(u.DurationSeconds*float64(time.Second)).Round(time.Second))
+	}
+	if summary.Transcript != "" {
+		fmt.Fprintf(os.Stdout, "Transcript: %s\n", summary.Transcript)
+	}
+	fmt.Fprintf(os.Stdout, "Result JSON: %s\n", summary.ResultPath)
+	fmt.Fprintf(os.Stdout, "Artifacts: %s (%d files)\n", summary.Artifacts, len(summary.Files))
+	for _, file := range summary.Files {
+		fmt.Fprintf(os.Stdout, "  %s\n", file)
+	}
+	fmt.Fprintf(os.Stdout, "Run summary: %s\n", summary.SummaryPath)
+}
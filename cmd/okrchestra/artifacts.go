@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"okrchestra/internal/artifacts"
+)
+
+func runArtifacts(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s artifacts: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "find":
+		return runArtifactsFind(args[1:], workspacePath)
+	case "reindex":
+		return runArtifactsReindex(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s artifacts: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runArtifactsFind(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("artifacts find", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	runID := fs.String("run", "", "Run ID")
+	itemID := fs.String("item", "", "Plan item ID")
+	planID := fs.String("plan", "", "Plan ID")
+	krID := fs.String("kr", "", "KR ID")
+	kind := fs.String("kind", "", "Artifact kind (transcript, result, prompt, item, failure, violation, review, other)")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	idx, err := artifacts.Open(resolved.ArtifactsDir)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	entries, err := idx.Find(artifacts.Filter{RunID: *runID, ItemID: *itemID, PlanID: *planID, KRID: *krID, Kind: *kind})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal entries: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stdout, "No indexed artifacts match (run `%s artifacts reindex` to index existing runs).\n", appName)
+		return nil
+	}
+	l10n := outputLocale(resolved.Workspace)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tKIND\tITEM\tKR\tSIZE\tMODIFIED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Path, e.Kind, e.ItemID, e.KRID, l10n.Int(e.Size), l10n.FormatDateTime(e.ModTime.Local()))
+	}
+	return w.Flush()
+}
+
+func runArtifactsReindex(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("artifacts reindex", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	idx, err := artifacts.Open(resolved.ArtifactsDir)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	n, err := idx.Rebuild()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Indexed %d artifact file(s) in %s\n", n, artifacts.Path(resolved.ArtifactsDir))
+	return nil
+}
+
+// refreshArtifactsIndex re-indexes a run after files are added to it. Like
+// RunPlan's indexing it is best-effort; `artifacts reindex` repairs the index.
+func refreshArtifactsIndex(artifactsDir, runDir string) {
+	idx, err := artifacts.Open(artifactsDir)
+	if err != nil {
+		return
+	}
+	defer idx.Close()
+	_, _ = idx.Refresh(runDir)
+}
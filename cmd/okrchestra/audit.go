@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/audit"
+)
+
+func runAudit(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s audit: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "list":
+		return runAuditList(args[1:], workspacePath)
+	case "show":
+		return runAuditShow(args[1:], workspacePath)
+	case "export":
+		return runAuditExport(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s audit: unknown subcommand %q", appName, args[0])
+	}
+}
+
+// auditRecord is the JSON form of an event, with the payload inlined.
+type auditRecord struct {
+	ID      int64           `json:"id"`
+	TS      string          `json:"ts"`
+	Actor   string          `json:"actor"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newAuditRecord(ev audit.Event) auditRecord {
+	payload := json.RawMessage(ev.PayloadJSON)
+	if !json.Valid(payload) {
+		// Keep the record valid JSON even if a row was written by hand.
+		payload, _ = json.Marshal(ev.PayloadJSON)
+	}
+	return auditRecord{
+		ID:      ev.ID,
+		TS:      ev.TS.UTC().Format(time.RFC3339),
+		Actor:   ev.Actor,
+		Type:    ev.Type,
+		Payload: payload,
+	}
+}
+
+// auditFilterFlags registers the event filters shared by list and export.
+type auditFilterFlags struct {
+	auditDB  *string
+	actor    *string
+	typ      *string
+	since    *string
+	until    *string
+	contains *string
+}
+
+func addAuditFilterFlags(fs *flag.FlagSet) auditFilterFlags {
+	return auditFilterFlags{
+		auditDB:  fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)"),
+		actor:    fs.String("actor", "", "Only events from this actor (e.g. cli, daemon)"),
+		typ:      fs.String("type", "", "Only events of this type (e.g. okr_apply_finished)"),
+		since:    fs.String("since", "", "Only events at or after this time (YYYY-MM-DD or RFC3339, UTC)"),
+		until:    fs.String("until", "", "Only events at or before this time (YYYY-MM-DD for the end of that day, or RFC3339)"),
+		contains: fs.String("contains", "", "Only events whose payload contains this text (e.g. a run or job ID)"),
+	}
+}
+
+func (f auditFilterFlags) query() (audit.Query, error) {
+	q := audit.Query{Actor: *f.actor, Type: *f.typ, Contains: *f.contains}
+	var err error
+	if q.Since, err = parseAuditTime(*f.since, false); err != nil {
+		return q, fmt.Errorf("parse --since: %w", err)
+	}
+	if q.Until, err = parseAuditTime(*f.until, true); err != nil {
+		return q, fmt.Errorf("parse --until: %w", err)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Until.Before(q.Since) {
+		return q, fmt.Errorf("--until is before --since")
+	}
+	return q, nil
+}
+
+// parseAuditTime accepts a UTC date or an RFC3339 timestamp. A date used as
+// an upper bound covers the whole day.
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.UTC); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want YYYY-MM-DD or RFC3339, got %q", value)
+	}
+	return t, nil
+}
+
+func runAuditList(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("audit list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	filters := addAuditFilterFlags(fs)
+	limit := fs.Int("limit", 50, "Show at most this many of the newest matching events (0 for all)")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	switch *format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("--format must be table or json, got %q", *format)
+	}
+	q, err := filters.query()
+	if err != nil {
+		return err
+	}
+	q.Limit = *limit
+	q.Latest = true
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{AuditDB: *filters.auditDB})
+	if err != nil {
+		return err
+	}
+	events, err := audit.ReadEvents(resolved.AuditDB, q)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		records := make([]auditRecord, 0, len(events))
+		for _, ev := range events {
+			records = append(records, newAuditRecord(ev))
+		}
+		return printListJSON(records)
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(os.Stdout, "No audit events match.")
+		return nil
+	}
+	l10n := outputLocale(resolved.Workspace)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tACTOR\tTYPE\tPAYLOAD")
+	for _, ev := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", ev.ID, l10n.FormatDateTime(ev.TS), ev.Actor, ev.Type, truncateAuditPayload(ev.PayloadJSON, 80))
+	}
+	return w.Flush()
+}
+
+func truncateAuditPayload(payload string, max int) string {
+	runes := []rune(payload)
+	if len(runes) <= max {
+		return payload
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func runAuditShow(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("audit show", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
+	asJSON := fs.Bool("json", false, "Print the event as JSON")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: %s audit show <event-id> [--json]", appName)
+	}
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid event id %q", positional[0])
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{AuditDB: *auditDB})
+	if err != nil {
+		return err
+	}
+	ev, err := audit.ReadEvent(resolved.AuditDB, id)
+	if err != nil {
+		return err
+	}
+	record := newAuditRecord(ev)
+	if *asJSON {
+		return printListJSON(record)
+	}
+
+	var payload bytes.Buffer
+	if err := json.Indent(&payload, record.Payload, "", "  "); err != nil {
+		return fmt.Errorf("format payload: %w", err)
+	}
+	l10n := outputLocale(resolved.Workspace)
+	fmt.Fprintf(os.Stdout, "Event %d\n", ev.ID)
+	fmt.Fprintf(os.Stdout, "Time:  %s\n", l10n.FormatDateTime(ev.TS))
+	fmt.Fprintf(os.Stdout, "Actor: %s\n", ev.Actor)
+	fmt.Fprintf(os.Stdout, "Type:  %s\n", ev.Type)
+	fmt.Fprintf(os.Stdout, "Payload:\n%s\n", payload.String())
+	return nil
+}
+
+func runAuditExport(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("audit export", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	filters := addAuditFilterFlags(fs)
+	format := fs.String("format", "jsonl", "Export format: jsonl or csv")
+	outPath := fs.String("out", "", "Write the export to this file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "jsonl", "csv":
+	default:
+		return fmt.Errorf("--format must be jsonl or csv, got %q", *format)
+	}
+	q, err := filters.query()
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{AuditDB: *filters.auditDB})
+	if err != nil {
+		return err
+	}
+	events, err := audit.ReadEvents(resolved.AuditDB, q)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if *outPath != "" {
+		// Exports may hold sensitive payloads, so keep them owner-only.
+		file, err = os.OpenFile(*outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("create export: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	buffered := bufio.NewWriter(out)
+	if *format == "csv" {
+		err = writeAuditCSV(buffered, events)
+	} else {
+		err = writeAuditJSONL(buffered, events)
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	if file == nil {
+		return nil
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync export: %w", err)
+	}
+
+	logger := audit.NewLogger(resolved.AuditDB)
+	payload := map[string]any{
+		"file":   *outPath,
+		"format": *format,
+		"events": len(events),
+		"actor":  q.Actor,
+		"type":   q.Type,
+		"since":  *filters.since,
+		"until":  *filters.until,
+	}
+	if err := logger.LogEvent("cli", "audit_exported", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d event(s) to %s\n", len(events), *outPath)
+	return nil
+}
+
+func writeAuditJSONL(w io.Writer, events []audit.Event) error {
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(newAuditRecord(ev)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAuditCSV(w io.Writer, events []audit.Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "ts", "actor", "type", "payload_json"}); err != nil {
+		return err
+	}
+	for _, ev := range events {
+		row := []string{strconv.FormatInt(ev.ID, 10), ev.TS.UTC().Format(time.RFC3339), ev.Actor, ev.Type, ev.PayloadJSON}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
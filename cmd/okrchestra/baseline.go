@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+func runKRBaseline(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s kr baseline: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "detect":
+		return runKRBaselineDetect(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s kr baseline: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runKRBaselineDetect(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("kr baseline detect", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dryRun := fs.Bool("dry-run", false, "Print detected baselines without creating a proposal")
+	agentID := fs.String("agent", metrics.BaselineAgentID, "Agent ID proposing the detected baselines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	ws := resolved.effective()
+
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return err
+	}
+	detections, err := metrics.DetectBaselines(store, filepath.Join(ws.MetricsDir, "snapshots"))
+	if err != nil {
+		return err
+	}
+	if len(detections) == 0 {
+		fmt.Fprintln(os.Stdout, "No KRs with baseline: null.")
+		return nil
+	}
+
+	l10n := outputLocale(ws)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KR\tMETRIC\tBASELINE\tAS OF")
+	for _, d := range detections {
+		if d.Found {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.KRID, d.MetricKey, l10n.Number(d.Value), l10n.DateString(d.AsOf))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t-\tno snapshot records this metric; run `%s kr measure`\n", d.KRID, d.MetricKey, appName)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if *dryRun {
+		return nil
+	}
+
+	meta, err := metrics.ProposeBaselines(ws, detections, *agentID)
+	if err != nil {
+		return fmt.Errorf("%w (pass --agent with an agent permitted to write these KRs)", err)
+	}
+	if meta == nil {
+		return nil
+	}
+	payload := map[string]any{
+		"proposal_id": meta.ID,
+		"agent_id":    meta.AgentID,
+		"detections":  detections,
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "kr_baseline_proposed", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	fmt.Fprintf(os.Stdout, "Proposal created: %s\n", ws.RelPath(meta.ProposalDir))
+	fmt.Fprintf(os.Stdout, "Apply with: %s okr apply --proposal %s --i-understand\n", appName, ws.RelPath(meta.ProposalDir))
+	return nil
+}
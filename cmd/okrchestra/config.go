@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/config"
+	"okrchestra/internal/workspace"
+)
+
+func runConfig(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s config: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "show":
+		return runConfigShow(args[1:], workspacePath)
+	case "set":
+		return runConfigSet(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s config: unknown subcommand %q", appName, args[0])
+	}
+}
+
+// runConfigShow prints the effective configuration: okrchestra.yml over
+// the built-in defaults.
+func runConfigShow(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	path := config.Path(resolved.Workspace.Root)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(os.Stdout, "# %s over defaults\n", path)
+	} else {
+		fmt.Fprintf(os.Stdout, "# defaults (no %s)\n", path)
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	return enc.Close()
+}
+
+func runConfigSet(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("config set", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: %s config set <key> <value> (e.g. daemon.poll_interval 5s)", appName)
+	}
+	key, value := fs.Arg(0), fs.Arg(1)
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	if _, err := config.Set(resolved.Workspace.Root, key, value); err != nil {
+		return err
+	}
+	payload := map[string]any{"key": key, "value": value}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "config_set", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	fmt.Fprintf(os.Stdout, "Set %s = %s in %s\n", key, value, config.Path(resolved.Workspace.Root))
+	return nil
+}
+
+// loadConfig reads okrchestra.yml for use as flag defaults, so flags given
+// on the command line override it. Without --workspace it returns the
+// built-in defaults and leaves the error to workspace resolution.
+func loadConfig(workspacePath string) (config.Config, error) {
+	if strings.TrimSpace(workspacePath) == "" {
+		return config.Default(), nil
+	}
+	root, err := workspace.ResolveRoot(workspacePath)
+	if err != nil {
+		return config.Config{}, err
+	}
+	return config.Load(root)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/planner"
+)
+
+func runCost(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s cost: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "report":
+		return runCostReport(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s cost: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runCostReport(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("cost report", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	sinceStr := fs.String("since", "", "Only count items finished on or after this date (YYYY-MM-DD, UTC)")
+	untilStr := fs.String("until", "", "Only count items finished before the end of this date (YYYY-MM-DD, UTC)")
+	by := fs.String("by", planner.CostByObjective, "Group by objective or kr")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := planner.CostOptions{By: *by}
+	if *sinceStr != "" {
+		since, err := time.ParseInLocation("2006-01-02", *sinceStr, time.UTC)
+		if err != nil {
+			return fmt.Errorf("parse --since: %w", err)
+		}
+		opts.Since = since
+	}
+	if *untilStr != "" {
+		until, err := time.ParseInLocation("2006-01-02", *untilStr, time.UTC)
+		if err != nil {
+			return fmt.Errorf("parse --until: %w", err)
+		}
+		opts.Until = until.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	opts.AuditDB = resolved.AuditDB
+
+	report, err := planner.BuildCostReport(opts)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+	if len(report.Rows) == 0 {
+		fmt.Fprintln(os.Stdout, "No item runs with recorded usage.")
+		return nil
+	}
+
+	l10n := outputLocale(resolved.Workspace)
+	agentTime := func(seconds float64) string {
+		return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if report.By == planner.CostByKR {
+		fmt.Fprintln(tw, "OBJECTIVE\tKR\tITEMS\tTOKENS\tIN\tOUT\tAGENT TIME")
+	} else {
+		fmt.Fprintln(tw, "OBJECTIVE\tITEMS\tTOKENS\tIN\tOUT\tAGENT TIME")
+	}
+	for _, row := range report.Rows {
+		cols := []any{row.ObjectiveID}
+		format := "%s\t%s\t%s\t%s\t%s\t%s\n"
+		if report.By == planner.CostByKR {
+			cols = append(cols, row.KRID)
+			format = "%s\t" + format
+		}
+		cols = append(cols, l10n.Int(int64(row.Items)), l10n.Int(row.Usage.TotalTokens),
+			l10n.Int(row.Usage.InputTokens), l10n.Int(row.Usage.OutputTokens), agentTime(row.Usage.DurationSeconds))
+		fmt.Fprintf(tw, format, cols...)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "\nTotal: %s items, %s tokens, agent time %s\n",
+		l10n.Int(int64(report.Items)), l10n.Int(report.Total.TotalTokens), agentTime(report.Total.DurationSeconds))
+	if report.Total.CostUSD > 0 {
+		fmt.Fprintf(os.Stdout, "Estimated cost: $%s\n", l10n.Fixed(report.Total.CostUSD, 2))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/cycle"
+	"okrchestra/internal/planner"
+)
+
+func runCycle(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s cycle: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "run-once":
+		return runCycleRunOnce(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s cycle: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runCycleRunOnce(args []string, workspacePath string) error {
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("cycle run-once", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	asOfStr := fs.String("as-of", "", "As-of date (YYYY-MM-DD, default: today UTC)")
+	objectiveID := fs.String("objective-id", "", "Objective ID to target")
+	krID := fs.String("kr-id", "", "KR ID to target")
+	agentRole := fs.String("agent-role", "software_engineer", "Agent role for plan items")
+	adapterName := fs.String("adapter", conf.Adapter, "Adapter name")
+	approve := fs.Bool("approve", false, "Approve and execute the generated plan")
+	requireProgress := fs.Bool("require-progress", false, "Exit non-zero when the targeted KR does not move toward its target")
+	timeout := fs.Duration("timeout", 0, "Timeout per plan item (e.g. 30m)")
+	repoDir := fs.String("repo-dir", conf.Metrics.RepoDir, "Git repo directory for git metrics (default: <workspace>)")
+	workDir := fs.String("workdir", "", "Working directory for agent runs (default: <workspace>)")
+	strict := fs.Bool("strict", false, "Fail if any metric provider fails instead of skipping it")
+	successCriteria := addSuccessCriteriaFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	criteria, err := successCriteria()
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	if *repoDir != "" {
+		*repoDir, err = resolved.Workspace.ResolvePath(*repoDir)
+		if err != nil {
+			return fmt.Errorf("resolve --repo-dir: %w", err)
+		}
+	}
+	if *workDir != "" {
+		*workDir, err = resolved.Workspace.ResolvePath(*workDir)
+		if err != nil {
+			return fmt.Errorf("resolve --workdir: %w", err)
+		}
+	}
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if *asOfStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *asOfStr, time.UTC)
+		if err != nil {
+			return fmt.Errorf("parse --as-of: %w", err)
+		}
+		asOf = parsed.UTC().Truncate(24 * time.Hour)
+	}
+
+	adapter, err := adapters.Resolve(resolved.Workspace.Root, *adapterName)
+	if err != nil {
+		return err
+	}
+
+	logger := audit.NewLogger(resolved.AuditDB)
+	defer logger.Close()
+	startPayload := map[string]any{
+		"workspace":    resolved.Workspace.Root,
+		"as_of":        asOf.Format("2006-01-02"),
+		"objective_id": *objectiveID,
+		"kr_id":        *krID,
+		"adapter":      adapter.Name(),
+		"approve":      *approve,
+	}
+	if err := logger.LogEvent("cli", "cycle_started", startPayload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	report, runErr := cycle.RunOnce(context.Background(), cycle.Options{
+		Workspace:       resolved.Workspace,
+		RepoDir:         *repoDir,
+		WorkDir:         *workDir,
+		AsOf:            asOf,
+		ObjectiveID:     *objectiveID,
+		KRID:            *krID,
+		AgentRole:       *agentRole,
+		Adapter:         adapter,
+		Timeout:         *timeout,
+		Approve:         *approve,
+		RequireProgress: *requireProgress,
+		SuccessCriteria: criteria,
+		StrictMetrics:   *strict,
+		Git: planner.GitOptions{
+			Branches:     conf.Git.Branches,
+			PullRequests: conf.Git.PullRequests,
+			Remote:       conf.Git.Remote,
+			Base:         conf.Git.Base,
+		},
+		AuditLogger: logger,
+	})
+
+	finishPayload := map[string]any{}
+	if report != nil {
+		finishPayload["cycle_id"] = report.CycleID
+		finishPayload["outcome"] = report.Outcome
+		finishPayload["report"] = report.ReportPath
+		finishPayload["kr_id"] = report.KRID
+		if report.PercentDelta != nil {
+			finishPayload["percent_delta"] = *report.PercentDelta
+		}
+	}
+	if runErr != nil {
+		finishPayload["error"] = runErr.Error()
+	}
+	_ = logger.LogEvent("cli", "cycle_finished", finishPayload)
+
+	if report != nil {
+		for _, s := range report.Steps {
+			line := fmt.Sprintf("  %-10s %s", s.Name, s.Status)
+			if s.Error != "" {
+				line += ": " + s.Error
+			}
+			fmt.Fprintln(os.Stdout, line)
+		}
+		fmt.Fprintf(os.Stdout, "Cycle %s: %s\n", report.CycleID, report.Outcome)
+		fmt.Fprintf(os.Stdout, "Wrote cycle report: %s\n", report.ReportPath)
+	}
+	return runErr
+}
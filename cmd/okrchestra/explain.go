@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/explain"
+	"okrchestra/internal/locale"
+)
+
+func runExplain(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	lines := fs.Int("lines", explain.DefaultTailLines, "Transcript lines to show per item")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: %s explain <job-id|run-id|item-id> [--lines N] [--json]", appName)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	opts := explain.Options{Workspace: resolved.Workspace, TailLines: *lines}
+	// Only read an existing daemon DB; explaining must not create one.
+	if _, err := os.Stat(resolved.Workspace.StateDBPath); err == nil {
+		store, err := daemon.Open(resolved.Workspace.StateDBPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		opts.Store = store
+	}
+
+	report, err := explain.Explain(opts, positional[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal report: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+	printExplainReport(report, outputLocale(resolved.Workspace))
+	return nil
+}
+
+func printExplainReport(report *explain.Report, l10n locale.Locale) {
+	out := os.Stdout
+	outcome := "ok"
+	if report.Failed() {
+		outcome = "FAILED"
+	}
+	switch report.Kind {
+	case explain.KindJob:
+		fmt.Fprintf(out, "Job %s (%s): %s\n", report.Job.ID, report.Job.Type, report.Job.Status)
+	case explain.KindRun:
+		fmt.Fprintf(out, "Run %s: %s\n", report.RunID, outcome)
+	case explain.KindItem:
+		fmt.Fprintf(out, "Item %s in run %s: %s\n", report.ID, report.RunID, outcome)
+	}
+	if report.JobError != "" {
+		fmt.Fprintf(out, "Error: %s\n", report.JobError)
+	}
+	if report.RunDir != "" {
+		fmt.Fprintf(out, "Run dir: %s\n", report.RunDir)
+	}
+
+	fmt.Fprintln(out, "\nTimeline:")
+	if len(report.Timeline) == 0 {
+		fmt.Fprintln(out, "  (no events recorded)")
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, entry := range report.Timeline {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", l10n.FormatDateTime(entry.At.Local()), entry.Source, entry.Summary)
+		}
+		_ = w.Flush()
+	}
+
+	for _, item := range report.Items {
+		fmt.Fprintf(out, "\n%s", item.Dir)
+		if item.PlanItemID != "" {
+			fmt.Fprintf(out, " (%s, %s, %s)", item.PlanItemID, item.KRID, item.MetricKey)
+		}
+		fmt.Fprintln(out)
+		if item.Failure != nil {
+			fmt.Fprintf(out, "  failure: %s: %s\n", item.Failure.Class, item.Failure.Message)
+		} else {
+			fmt.Fprintln(out, "  succeeded")
+		}
+		if item.Violation != nil {
+			fmt.Fprintf(out, "  guardrail: %v\n", item.Violation["violation_type"])
+			if details, ok := item.Violation["details"].(map[string]any); ok {
+				if files, ok := details["changed_files"].([]any); ok {
+					for _, f := range files {
+						fmt.Fprintf(out, "    changed: %v\n", f)
+					}
+				}
+			}
+		}
+		for _, f := range item.Secrets {
+			fmt.Fprintf(out, "  secret: %s in %s:%d\n", f.Kind, f.File, f.Line)
+		}
+		for _, msg := range item.ResultErrors {
+			fmt.Fprintf(out, "  result.json: %s\n", msg)
+		}
+		for _, a := range item.Annotations {
+			fmt.Fprintf(out, "  note (%s): %s\n", l10n.DateString(a.Date), a.Note)
+		}
+		if len(item.TranscriptTail) > 0 {
+			fmt.Fprintf(out, "  transcript (last %d lines):\n", len(item.TranscriptTail))
+			for _, line := range item.TranscriptTail {
+				fmt.Fprintf(out, "    %s\n", strings.TrimRight(line, "\r"))
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"okrchestra/internal/artifacts"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/outcomes"
+)
+
+func runGC(args []string, workspacePath string) error {
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	policy, err := artifacts.LoadRetentionPolicy(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	keepRuns := fs.Int("keep-runs", policy.KeepRuns, "Remove runs beyond the newest N (0 = no limit)")
+	maxAge := fs.Duration("max-age", policy.MaxAge, "Remove runs and plans not touched for longer (0 = no limit)")
+	maxBytes := fs.Int64("max-bytes", policy.MaxBytes, "Remove the oldest runs until the rest fit (0 = no limit)")
+	compressAfter := fs.Duration("compress-after", policy.CompressAfter, "Gzip transcripts of kept runs not touched for longer (0 = never)")
+	dryRun := fs.Bool("dry-run", false, "Print what would be removed without changing anything")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keepRuns < 0 || *maxAge < 0 || *maxBytes < 0 || *compressAfter < 0 {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+	policy = artifacts.RetentionPolicy{
+		KeepRuns:      *keepRuns,
+		MaxAge:        *maxAge,
+		MaxBytes:      *maxBytes,
+		CompressAfter: *compressAfter,
+	}
+	if policy.Protect, err = outcomes.PendingRunIDs(resolved.effective()); err != nil {
+		return err
+	}
+
+	report, err := artifacts.GC(resolved.ArtifactsDir, policy, time.Now(), *dryRun)
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		logger := audit.NewLogger(resolved.AuditDB)
+		if err := logger.LogEvent("cli", "artifacts_gc", map[string]any{
+			"workspace": resolved.Workspace.Root,
+			"report":    report,
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, "audit log failed:", err)
+		}
+	}
+
+	if *asJSON {
+		return printListJSON(report)
+	}
+	l10n := outputLocale(resolved.Workspace)
+	removeVerb, compressVerb, compressLine := "Removed", "compressed", "Compressed"
+	if *dryRun {
+		removeVerb, compressVerb, compressLine = "Would remove", "compress", "Would compress"
+	}
+	for _, p := range append(append([]artifacts.Pruned{}, report.Runs...), report.Plans...) {
+		fmt.Fprintf(os.Stdout, "%s %s (%s bytes, %s)\n", removeVerb, p.Path, l10n.Int(p.Bytes), p.Reason)
+	}
+	for _, path := range report.Compressed {
+		fmt.Fprintf(os.Stdout, "%s %s\n", compressLine, path)
+	}
+	fmt.Fprintf(os.Stdout, "%s %d run(s) and %d plan(s), %s %d transcript(s): %s bytes freed, %d run(s) kept (%s bytes)\n",
+		removeVerb, len(report.Runs), len(report.Plans), compressVerb, len(report.Compressed),
+		l10n.Int(report.FreedBytes), report.KeptRuns, l10n.Int(report.KeptBytes))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/metrics"
+)
+
+func runKRHistory(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("kr history", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs (default: <workspace>/metrics)")
+	key := fs.String("metric", "", "Metric key to show (e.g. ci.pass_rate_30d)")
+	days := fs.Int("days", 30, "Number of days to show, ending today (0 for all history)")
+	format := fs.String("format", "table", "Output format: table, json, or csv")
+	rebuild := fs.Bool("rebuild", false, "Re-import every snapshot into the history first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" {
+		return fmt.Errorf("--metric is required")
+	}
+	if *days < 0 {
+		return fmt.Errorf("--days must not be negative")
+	}
+	switch *format {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("--format must be table, json, or csv, got %q", *format)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{MetricsDir: *metricsDir})
+	if err != nil {
+		return err
+	}
+	snapshotsDir := filepath.Join(resolved.MetricsDir, "snapshots")
+
+	history, err := metrics.OpenHistory(snapshotsDir)
+	if err != nil {
+		return err
+	}
+	defer history.Close()
+	if *rebuild {
+		n, err := history.Rebuild()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Imported %d snapshot(s) into %s\n", n, resolved.Workspace.RelPath(metrics.HistoryPath(snapshotsDir)))
+	}
+
+	since := ""
+	if *days > 0 {
+		since = time.Now().UTC().AddDate(0, 0, -(*days - 1)).Format("2006-01-02")
+	}
+	points, err := history.Query(*key, since)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		if points == nil {
+			points = []metrics.HistoryPoint{}
+		}
+		data, err := json.MarshalIndent(points, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal history: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	case "csv":
+		// CSV is for charting tools, so values stay canonical.
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"as_of", "key", "value", "unit", "source", "dimensions"})
+		for _, p := range points {
+			_ = w.Write([]string{p.AsOf, p.Key, strconv.FormatFloat(p.Value, 'f', -1, 64), p.Unit, p.Source, p.Dimensions})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if len(points) == 0 {
+		fmt.Fprintf(os.Stdout, "No history for %s.\n", *key)
+		return nil
+	}
+	l10n := outputLocale(resolved.Workspace)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tVALUE\tUNIT\tSOURCE\tDIMENSIONS")
+	for _, p := range points {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l10n.DateString(p.AsOf), l10n.Number(p.Value), p.Unit, p.Source, p.Dimensions)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"okrchestra/internal/config"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/scaffold"
+	"okrchestra/internal/workspace"
+)
+
+// importExisting drafts okrs/org.yml from the repository at repoDir.
+func importExisting(ws *workspace.Workspace, repoDir, ciReport string) (*scaffold.Import, error) {
+	orgPath := filepath.Join(ws.OKRsDir, "org.yml")
+	if _, err := os.Stat(orgPath); err == nil {
+		return nil, fmt.Errorf("%s already exists; remove it to draft OKRs from %s", orgPath, repoDir)
+	}
+	if ciReport != "" {
+		abs, err := filepath.Abs(ciReport)
+		if err != nil {
+			return nil, fmt.Errorf("resolve ci report: %w", err)
+		}
+		ciReport = abs
+	}
+	imp, err := scaffold.DraftFromRepo(context.Background(), scaffold.ImportOptions{RepoDir: repoDir, CIReportPath: ciReport})
+	if err != nil {
+		return nil, err
+	}
+	if err := okrstore.WriteDocument(imp.Document, orgPath); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// configureImport points okrchestra.yml at the imported repository's
+// inputs, so later measurements use the same sources as the baselines.
+func configureImport(ws *workspace.Workspace, imp *scaffold.Import) error {
+	settings := [][2]string{}
+	if rel := workspaceRel(ws.Root, imp.RepoDir); rel != "." {
+		settings = append(settings, [2]string{"metrics.repo_dir", rel})
+	}
+	if imp.CIReportPath != "" {
+		if rel := workspaceRel(ws.Root, imp.CIReportPath); rel != filepath.Join("metrics", "ci_report.json") {
+			settings = append(settings, [2]string{"metrics.ci_report", rel})
+		}
+	}
+	for _, kv := range settings {
+		if _, err := config.Set(ws.Root, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workspaceRel returns path relative to the workspace root when that is
+// possible, so okrchestra.yml stays valid if the workspace moves with the
+// repository.
+func workspaceRel(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func printImport(imp *scaffold.Import) {
+	fmt.Fprintf(os.Stdout, "Drafted okrs/org.yml from %s:\n", imp.RepoDir)
+	for _, key := range imp.SortedMeasurements() {
+		fmt.Fprintf(os.Stdout, "  measured %s = %g\n", key, imp.Measurements[key])
+	}
+	for _, obj := range imp.Document.Objectives {
+		for _, kr := range obj.KeyResults {
+			fmt.Fprintf(os.Stdout, "  %s %s: %s\n", obj.ID, kr.ID, kr.Description)
+		}
+	}
+	for _, note := range imp.Notes {
+		fmt.Fprintf(os.Stdout, "  note: %s\n", note)
+	}
+	fmt.Fprintln(os.Stdout, "Review the drafted targets in okrs/org.yml before planning.")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/daemon"
+)
+
+func runDaemonCancel(args []string, workspacePath string) error {
+	return runDaemonJobAction(args, workspacePath, "cancel", "job_canceled", (*daemon.Store).Cancel)
+}
+
+func runDaemonKill(args []string, workspacePath string) error {
+	return runDaemonJobAction(args, workspacePath, "kill", "job_kill_requested", (*daemon.Store).RequestCancel)
+}
+
+func runDaemonRetry(args []string, workspacePath string) error {
+	return runDaemonJobAction(args, workspacePath, "retry", "job_requeued", (*daemon.Store).Requeue)
+}
+
+// runDaemonJobAction applies a Store status change to the job named by the
+// first argument and records eventType in the audit log.
+func runDaemonJobAction(args []string, workspacePath, name, eventType string, action func(*daemon.Store, string) error) error {
+	fs := flag.NewFlagSet("daemon "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s daemon %s <job-id>", appName, name)
+	}
+	jobID := fs.Arg(0)
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	store, err := daemon.Open(resolved.Workspace.StateDBPath)
+	if err != nil {
+		return fmt.Errorf("open daemon store: %w", err)
+	}
+	defer store.Close()
+
+	if err := action(store, jobID); err != nil {
+		return err
+	}
+	job, err := store.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"source":   "cli",
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", eventType, payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	if job.CancelRequested {
+		fmt.Fprintf(os.Stdout, "Job %s [%s] is %s; the daemon will stop it at its next poll\n", job.ID, job.Type, job.Status)
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "Job %s [%s] is now %s\n", job.ID, job.Type, job.Status)
+	return nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"okrchestra/internal/okrstore"
+)
+
+// listFilter holds the filters shared by `okr list` and `kr list`.
+type listFilter struct {
+	format string
+	scope  string
+	owner  string
+	status string
+}
+
+func addListFlags(fs *flag.FlagSet) *listFilter {
+	f := &listFilter{}
+	fs.StringVar(&f.format, "format", "table", "Output format: table or json")
+	fs.StringVar(&f.scope, "scope", "", "Only show this scope (org, team, person)")
+	fs.StringVar(&f.owner, "owner", "", "Only show entries with this owner_id")
+	fs.StringVar(&f.status, "status", "", "Only show KRs with this status")
+	return f
+}
+
+func (f *listFilter) validate() error {
+	switch f.format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("--format must be table or json, got %q", f.format)
+	}
+	switch okrstore.Scope(f.scope) {
+	case "", okrstore.ScopeOrg, okrstore.ScopeTeam, okrstore.ScopePerson:
+	default:
+		return fmt.Errorf("--scope must be org, team, or person, got %q", f.scope)
+	}
+	return nil
+}
+
+func (f *listFilter) matchesKR(kr okrstore.KeyResult) bool {
+	if f.owner != "" && kr.OwnerID != f.owner {
+		return false
+	}
+	return f.status == "" || kr.Status == f.status
+}
+
+// loadListStore loads the workspace OKRs for the list commands.
+func loadListStore(workspacePath string) (*okrstore.Store, *resolvedWorkspace, error) {
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := okrstore.LoadFromDirCached(resolved.OKRsDir, filepath.Join(resolved.ArtifactsDir, "cache"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, resolved, nil
+}
+
+type scopedDocument struct {
+	scope okrstore.Scope
+	doc   okrstore.Document
+}
+
+func scopedDocuments(store *okrstore.Store, scope string) []scopedDocument {
+	var out []scopedDocument
+	for _, group := range []struct {
+		scope okrstore.Scope
+		docs  []okrstore.Document
+	}{
+		{okrstore.ScopeOrg, store.Org.Documents},
+		{okrstore.ScopeTeam, store.Team.Documents},
+		{okrstore.ScopePerson, store.Person.Documents},
+	} {
+		if scope != "" && string(group.scope) != scope {
+			continue
+		}
+		for _, doc := range group.docs {
+			out = append(out, scopedDocument{scope: group.scope, doc: doc})
+		}
+	}
+	return out
+}
+
+type objectiveListing struct {
+	ID         string   `json:"objective_id"`
+	Scope      string   `json:"scope"`
+	Objective  string   `json:"objective"`
+	OwnerID    string   `json:"owner_id,omitempty"`
+	Weight     *float64 `json:"weight,omitempty"`
+	KeyResults []string `json:"kr_ids"`
+	Source     string   `json:"source"`
+}
+
+func runOKRList(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	filter := addListFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	store, resolved, err := loadListStore(workspacePath)
+	if err != nil {
+		return err
+	}
+
+	var listings []objectiveListing
+	for _, sd := range scopedDocuments(store, filter.scope) {
+		for _, obj := range sd.doc.Objectives {
+			// An objective matches on its own owner or on any of its KRs.
+			var krIDs []string
+			for _, kr := range obj.KeyResults {
+				if filter.status == "" || kr.Status == filter.status {
+					krIDs = append(krIDs, kr.ID)
+				}
+			}
+			if filter.status != "" && len(krIDs) == 0 {
+				continue
+			}
+			if filter.owner != "" && obj.OwnerID != filter.owner {
+				owned := false
+				for _, kr := range obj.KeyResults {
+					owned = owned || filter.matchesKR(kr)
+				}
+				if !owned {
+					continue
+				}
+			}
+			listings = append(listings, objectiveListing{
+				ID:         obj.ID,
+				Scope:      string(sd.scope),
+				Objective:  obj.Objective,
+				OwnerID:    obj.OwnerID,
+				Weight:     obj.Weight,
+				KeyResults: append([]string{}, krIDs...),
+				Source:     resolved.Workspace.RelPath(sd.doc.Source),
+			})
+		}
+	}
+
+	if filter.format == "json" {
+		return printListJSON(listings)
+	}
+	if len(listings) == 0 {
+		fmt.Fprintln(os.Stdout, "No objectives match.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OBJECTIVE\tSCOPE\tOWNER\tKRS\tTITLE")
+	for _, l := range listings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", l.ID, l.Scope, dashIfEmpty(l.OwnerID), len(l.KeyResults), l.Objective)
+	}
+	return w.Flush()
+}
+
+type krListing struct {
+	ID          string   `json:"kr_id"`
+	ObjectiveID string   `json:"objective_id"`
+	Scope       string   `json:"scope"`
+	OwnerID     string   `json:"owner_id"`
+	Status      string   `json:"status"`
+	MetricKey   string   `json:"metric_key"`
+	Baseline    *float64 `json:"baseline"`
+	Current     *float64 `json:"current"`
+	Target      float64  `json:"target"`
+	Confidence  float64  `json:"confidence"`
+	Description string   `json:"description"`
+}
+
+func runKRList(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("kr list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	filter := addListFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	store, resolved, err := loadListStore(workspacePath)
+	if err != nil {
+		return err
+	}
+
+	var listings []krListing
+	for _, sd := range scopedDocuments(store, filter.scope) {
+		for _, obj := range sd.doc.Objectives {
+			for _, kr := range obj.KeyResults {
+				if !filter.matchesKR(kr) {
+					continue
+				}
+				listing := krListing{
+					ID:          kr.ID,
+					ObjectiveID: obj.ID,
+					Scope:       string(sd.scope),
+					OwnerID:     kr.OwnerID,
+					Status:      kr.Status,
+					MetricKey:   kr.MetricKey,
+					Current:     kr.Current,
+					Target:      kr.Target,
+					Confidence:  kr.Confidence,
+					Description: kr.Description,
+				}
+				if !kr.BaselinePending {
+					baseline := kr.Baseline
+					listing.Baseline = &baseline
+				}
+				listings = append(listings, listing)
+			}
+		}
+	}
+
+	if filter.format == "json" {
+		return printListJSON(listings)
+	}
+	if len(listings) == 0 {
+		fmt.Fprintln(os.Stdout, "No key results match.")
+		return nil
+	}
+	l10n := outputLocale(resolved.Workspace)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KR\tOBJECTIVE\tSCOPE\tOWNER\tSTATUS\tCURRENT/TARGET\tMETRIC")
+	for _, l := range listings {
+		current := "-"
+		if l.Current != nil {
+			current = l10n.Number(*l.Current)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s/%s\t%s\n",
+			l.ID, l.ObjectiveID, l.Scope, l.OwnerID, l.Status, current, l10n.Number(l.Target), dashIfEmpty(l.MetricKey))
+	}
+	return w.Flush()
+}
+
+func printListJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal list: %w", err)
+	}
+	// Empty results print [] rather than null.
+	if strings.TrimSpace(string(data)) == "null" {
+		data = []byte("[]")
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+	return nil
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -3,20 +3,30 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"okrchestra/internal/adapters"
 	"okrchestra/internal/audit"
+	"okrchestra/internal/badges"
 	"okrchestra/internal/daemon"
+	"okrchestra/internal/locale"
 	"okrchestra/internal/metrics"
 	"okrchestra/internal/okrstore"
+	"okrchestra/internal/outcomes"
 	"okrchestra/internal/planner"
+	"okrchestra/internal/scaffold"
+	"okrchestra/internal/secrets"
 	"okrchestra/internal/workspace"
 )
 
@@ -28,13 +38,30 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%s: OKR-driven agent orchestration\n\n", appName)
 		fmt.Fprintf(os.Stderr, "Usage:\n  %s [command] [flags]\n\n", appName)
 		fmt.Fprintln(os.Stderr, "Commands:")
-		fmt.Fprintln(os.Stderr, "  agent   Manage agents")
-		fmt.Fprintln(os.Stderr, "  daemon  Manage daemon")
-		fmt.Fprintln(os.Stderr, "  init    Initialize a new workspace")
-		fmt.Fprintln(os.Stderr, "  okr     Manage OKRs")
-		fmt.Fprintln(os.Stderr, "  kr      Manage key results")
-		fmt.Fprintln(os.Stderr, "  plan    Manage plans")
-		fmt.Fprintln(os.Stderr, "  help    Show this help")
+		fmt.Fprintln(os.Stderr, "  agent     Manage agents")
+		fmt.Fprintln(os.Stderr, "  artifacts Find indexed run artifacts")
+		fmt.Fprintln(os.Stderr, "  audit     List, show, or export audit log events")
+		fmt.Fprintln(os.Stderr, "  config    Show or set workspace defaults in okrchestra.yml")
+		fmt.Fprintln(os.Stderr, "  cost      Report agent token usage per objective or KR")
+		fmt.Fprintln(os.Stderr, "  cycle     Run a one-shot measure/plan/execute cycle")
+		fmt.Fprintln(os.Stderr, "  daemon    Manage daemon")
+		fmt.Fprintln(os.Stderr, "  explain   Explain a job, run, or item failure end to end")
+		fmt.Fprintln(os.Stderr, "  gc        Prune old runs and plans and compress transcripts")
+		fmt.Fprintln(os.Stderr, "  init      Initialize a new workspace")
+		fmt.Fprintln(os.Stderr, "  okr       Manage OKRs")
+		fmt.Fprintln(os.Stderr, "  kr        Manage key results")
+		fmt.Fprintln(os.Stderr, "  metrics   Annotate or ingest metric data points")
+		fmt.Fprintln(os.Stderr, "  migrate   Migrate workspace artifacts")
+		fmt.Fprintln(os.Stderr, "  plan      Manage plans")
+		fmt.Fprintln(os.Stderr, "  report    Generate weekly OKR review reports")
+		fmt.Fprintln(os.Stderr, "  result    Validate plan item result.json files")
+		fmt.Fprintln(os.Stderr, "  runs      List plan runs and review their output")
+		fmt.Fprintln(os.Stderr, "  schema    Export JSON Schemas for plans, results, snapshots, and scores")
+		fmt.Fprintln(os.Stderr, "  secrets   Manage secrets for {{secret:name}} job payload references")
+		fmt.Fprintln(os.Stderr, "  stats     Show local usage stats")
+		fmt.Fprintln(os.Stderr, "  tick      Run one scheduler tick and due jobs, for cron")
+		fmt.Fprintln(os.Stderr, "  ui        Browse OKR scores, daemon jobs, runs, and transcripts")
+		fmt.Fprintln(os.Stderr, "  help      Show this help")
 		fmt.Fprintln(os.Stderr, "\nFlags:")
 		flag.PrintDefaults()
 	}
@@ -51,42 +78,66 @@ func main() {
 		return
 	}
 
+	var run func(args []string, workspacePath string) error
 	switch args[0] {
 	case "agent":
-		if err := runAgent(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runAgent
+	case "artifacts":
+		run = runArtifacts
+	case "audit":
+		run = runAudit
+	case "config":
+		run = runConfig
+	case "cost":
+		run = runCost
+	case "cycle":
+		run = runCycle
 	case "daemon":
-		if err := runDaemon(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runDaemon
+	case "explain":
+		run = runExplain
+	case "gc":
+		run = runGC
 	case "init":
-		if err := runInit(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runInit
 	case "okr":
-		if err := runOKR(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runOKR
 	case "kr":
-		if err := runKR(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runKR
+	case "metrics":
+		run = runMetrics
+	case "migrate":
+		run = runMigrate
 	case "plan":
-		if err := runPlan(args[1:], workspacePath); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		run = runPlan
+	case "report":
+		run = runReport
+	case "result":
+		run = runResult
+	case "runs", "run":
+		run = runRuns
+	case "schema":
+		run = runSchema
+	case "secrets":
+		run = runSecrets
+	case "stats":
+		run = runStats
+	case "tick":
+		run = runTick
+	case "ui":
+		run = runUI
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
 		flag.Usage()
 		os.Exit(1)
 	}
+
+	err = run(args[1:], workspacePath)
+	recordUsage(workspacePath, args, err)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
 type workspaceOverrides struct {
@@ -106,6 +157,28 @@ type resolvedWorkspace struct {
 	AuditDB      string
 }
 
+// outputLocale returns the workspace locale for human-readable output,
+// warning and falling back to canonical formatting when misconfigured.
+func outputLocale(ws *workspace.Workspace) locale.Locale {
+	l, err := locale.Load(ws.Root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+		return locale.Canonical
+	}
+	return l
+}
+
+// effective returns the workspace with directory overrides applied.
+func (r *resolvedWorkspace) effective() *workspace.Workspace {
+	ws := *r.Workspace
+	ws.OKRsDir = r.OKRsDir
+	ws.CultureDir = r.CultureDir
+	ws.MetricsDir = r.MetricsDir
+	ws.ArtifactsDir = r.ArtifactsDir
+	ws.AuditDBPath = r.AuditDB
+	return &ws
+}
+
 func resolveWorkspaceAndOverrides(root string, overrides workspaceOverrides) (*resolvedWorkspace, error) {
 	root = strings.TrimSpace(root)
 	if root == "" {
@@ -191,16 +264,27 @@ func runAgent(args []string, workspacePath string) error {
 }
 
 func runAgentRun(args []string, workspacePath string) error {
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
 	fs := flag.NewFlagSet("agent run", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	adapterName := fs.String("adapter", "codex", "Adapter name")
+	adapterName := fs.String("adapter", conf.Adapter, "Adapter name")
 	promptPath := fs.String("prompt", "", "Path to prompt file")
 	workDir := fs.String("workdir", "", "Working directory (default: <workspace>)")
 	artifactsDir := fs.String("artifacts", "", "Artifacts directory")
+	format := fs.String("format", "text", "Output format: text or json")
+	follow := fs.Bool("follow", false, "Stream the agent's transcript.log while running")
+	followLines := fs.Int("follow-lines", 200, "When following, start from last N lines (0 = from start)")
+	role := fs.String("role", "", "Agent role whose env.yml variables the agent gets")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
 
 	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
 	if err != nil {
@@ -239,14 +323,29 @@ func runAgentRun(args []string, workspacePath string) error {
 		ArtifactsDir: absArtifactsDir,
 	}
 
-	var adapter adapters.AgentAdapter
-	switch *adapterName {
-	case "codex":
-		adapter = &adapters.CodexAdapter{}
-	case "mock":
-		adapter = &adapters.MockAdapter{}
-	default:
-		return fmt.Errorf("unknown adapter: %s", *adapterName)
+	adapter, err := adapters.Resolve(resolved.Workspace.Root, *adapterName)
+	if err != nil {
+		return err
+	}
+	if cfg.Language, err = locale.LoadLanguage(resolved.Workspace.Root); err != nil {
+		return err
+	}
+	sandbox, err := adapters.LoadSandbox(resolved.Workspace.Root, adapter.Name())
+	if err != nil {
+		return err
+	}
+	if sandbox != nil {
+		if cfg.Sandbox, err = sandbox.Resolve(absWorkDir, resolved.effective().StatePaths()...); err != nil {
+			return err
+		}
+	}
+	envCfg, err := adapters.LoadEnv(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	cfg.Scrubber = adapters.NewScrubber()
+	if cfg.Env, err = envCfg.Resolve(context.Background(), adapter.Name(), *role, cfg.Scrubber); err != nil {
+		return err
 	}
 
 	logger := audit.NewLogger(resolved.AuditDB)
@@ -262,7 +361,24 @@ func runAgentRun(args []string, workspacePath string) error {
 	}
 
 	ctx := context.Background()
-	result, runErr := adapter.Run(ctx, cfg)
+	var stopFollow func()
+	if *follow {
+		// Keep stdout to the summary when it is JSON.
+		followWriter := io.Writer(os.Stdout)
+		if *format == "json" {
+			followWriter = os.Stderr
+		}
+		scrubWriter := cfg.Scrubber.Writer(followWriter)
+		stopTranscript := planner.FollowTranscript(ctx, filepath.Join(absArtifactsDir, "transcript.log"), *followLines, scrubWriter, adapter.Name())
+		stopFollow = func() {
+			stopTranscript()
+			_ = scrubWriter.Close()
+		}
+	}
+	result, runErr := adapters.Run(ctx, adapter, cfg)
+	if stopFollow != nil {
+		stopFollow()
+	}
 
 	finishPayload := map[string]any{
 		"adapter":   adapter.Name(),
@@ -278,11 +394,39 @@ func runAgentRun(args []string, workspacePath string) error {
 	if runErr != nil {
 		finishPayload["error"] = runErr.Error()
 	}
-	if err := logger.LogEvent("cli", "agent_run_finished", finishPayload); err != nil {
+	var violation *adapters.SandboxViolation
+	if errors.As(runErr, &violation) {
+		finishPayload["sandbox_changes"] = violation.Changes
+	}
+
+	summary := summarizeAgentRun(adapter.Name(), absArtifactsDir, result, runErr)
+	finishPayload["result_valid"] = summary.ResultValid
+	if summary.ResultError != "" {
+		finishPayload["result_error"] = summary.ResultError
+	}
+	if err := writeAgentRunSummary(summary); err != nil {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	} else {
+		finishPayload["run_summary"] = summary.SummaryPath
+	}
+	if err := logger.LogEvent("cli", "agent_run_finished", cfg.Scrubber.Payload(finishPayload)); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	return runErr
+	if *format == "json" {
+		if err := printListJSON(summary); err != nil {
+			return err
+		}
+	} else {
+		printAgentRunSummary(summary)
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if !summary.ResultValid {
+		return fmt.Errorf("agent result invalid: %s", summary.ResultError)
+	}
+	return nil
 }
 
 func runOKR(args []string, workspacePath string) error {
@@ -295,6 +439,20 @@ func runOKR(args []string, workspacePath string) error {
 		return runOKRPropose(args[1:], workspacePath)
 	case "apply":
 		return runOKRApply(args[1:], workspacePath)
+	case "review":
+		return runOKRReview(args[1:], workspacePath)
+	case "proposals":
+		return runOKRProposals(args[1:], workspacePath)
+	case "list":
+		return runOKRList(args[1:], workspacePath)
+	case "status":
+		return runOKRStatus(args[1:], workspacePath)
+	case "validate":
+		return runOKRValidate(args[1:], workspacePath)
+	case "rollover":
+		return runOKRRollover(args[1:], workspacePath)
+	case "history":
+		return runOKRHistory(args[1:], workspacePath)
 	default:
 		return fmt.Errorf("%s okr: unknown subcommand %q", appName, args[0])
 	}
@@ -310,6 +468,12 @@ func runKR(args []string, workspacePath string) error {
 		return runKRMeasure(args[1:], workspacePath)
 	case "score":
 		return runKRScore(args[1:], workspacePath)
+	case "baseline":
+		return runKRBaseline(args[1:], workspacePath)
+	case "list":
+		return runKRList(args[1:], workspacePath)
+	case "history":
+		return runKRHistory(args[1:], workspacePath)
 	default:
 		return fmt.Errorf("%s kr: unknown subcommand %q", appName, args[0])
 	}
@@ -325,6 +489,18 @@ func runPlan(args []string, workspacePath string) error {
 		return runPlanGenerate(args[1:], workspacePath)
 	case "run":
 		return runPlanRun(args[1:], workspacePath)
+	case "outcomes":
+		return runPlanOutcomes(args[1:], workspacePath)
+	case "retro":
+		return runPlanRetro(args[1:], workspacePath)
+	case "validate":
+		return runPlanValidate(args[1:], workspacePath)
+	case "approve":
+		return runPlanApprove(args[1:], workspacePath)
+	case "edit":
+		return runPlanEdit(args[1:], workspacePath)
+	case "status":
+		return runPlanStatus(args[1:], workspacePath)
 	default:
 		return fmt.Errorf("%s plan: unknown subcommand %q", appName, args[0])
 	}
@@ -333,13 +509,12 @@ func runPlan(args []string, workspacePath string) error {
 func runInit(args []string, workspacePath string) error {
 	fs := flag.NewFlagSet("init", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	template := fs.String("template", "minimal", "Workspace template (default: minimal)")
+	template := fs.String("template", scaffold.DefaultTemplate, "Workspace template: a built-in name ("+builtinTemplateNames()+"), a directory, or a git URL")
+	fromExisting := fs.String("from-existing", "", "Draft okrs/org.yml from the measurements of an existing git repository")
+	ciReport := fs.String("ci-report", "", "CI report for --from-existing (default: metrics/ci_report.json or ci_report.json in the repository)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if *template != "minimal" {
-		return fmt.Errorf("unknown template: %s", *template)
-	}
 	if strings.TrimSpace(workspacePath) == "" {
 		return fmt.Errorf("--workspace is required")
 	}
@@ -361,15 +536,25 @@ func runInit(args []string, workspacePath string) error {
 		"workspace": ws.Root,
 		"template":  *template,
 	}
+	if *fromExisting != "" {
+		startPayload["from_existing"] = *fromExisting
+	}
 	if err := logger.LogEvent("cli", "workspace_init_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
-	var finishErr error
+	var (
+		finishErr error
+		imported  *scaffold.Import
+	)
 	defer func() {
 		finishPayload := map[string]any{
 			"workspace": ws.Root,
 			"template":  *template,
 		}
+		if imported != nil {
+			finishPayload["from_existing"] = imported.RepoDir
+			finishPayload["measurements"] = imported.Measurements
+		}
 		if finishErr != nil {
 			finishPayload["error"] = finishErr.Error()
 		}
@@ -394,32 +579,46 @@ func runInit(args []string, workspacePath string) error {
 		return finishErr
 	}
 
-	if err := writeFileIfMissing(filepath.Join(ws.CultureDir, "values.md"), minimalValuesTemplate); err != nil {
-		finishErr = err
-		return finishErr
+	// The drafted org.yml is written first so the template keeps the rest
+	// of its files but not its sample OKRs.
+	if *fromExisting != "" {
+		imported, err = importExisting(ws, *fromExisting, *ciReport)
+		if err != nil {
+			finishErr = err
+			return finishErr
+		}
 	}
-	if err := writeFileIfMissing(filepath.Join(ws.CultureDir, "standards.md"), minimalStandardsTemplate); err != nil {
+
+	res, err := scaffold.Apply(context.Background(), ws.Root, *template)
+	if err != nil {
 		finishErr = err
 		return finishErr
 	}
-	if err := writeFileIfMissing(filepath.Join(ws.OKRsDir, "org.yml"), minimalOrgTemplate); err != nil {
-		finishErr = err
-		return finishErr
+	if imported != nil {
+		if err := configureImport(ws, imported); err != nil {
+			finishErr = err
+			return finishErr
+		}
 	}
-	if err := writeFileIfMissing(filepath.Join(ws.OKRsDir, "permissions.yml"), minimalPermissionsTemplate); err != nil {
-		finishErr = err
+	// A template from a directory or repository may not hold valid OKRs.
+	if _, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache")); err != nil {
+		finishErr = fmt.Errorf("template %s: %w", *template, err)
 		return finishErr
 	}
-	if err := writeFileIfMissing(filepath.Join(ws.MetricsDir, "manual.yml"), minimalManualMetricsTemplate); err != nil {
-		finishErr = err
-		return finishErr
+
+	fmt.Fprintf(os.Stdout, "Initialized workspace: %s (template %s)\n", ws.Root, *template)
+	for _, name := range res.Written {
+		fmt.Fprintf(os.Stdout, "  wrote %s\n", name)
 	}
-	if err := writeFileIfMissing(filepath.Join(ws.MetricsDir, "ci_report.json"), minimalCIReportTemplate); err != nil {
-		finishErr = err
-		return finishErr
+	for _, name := range res.Skipped {
+		if imported != nil && name == "okrs/org.yml" {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "  kept existing %s\n", name)
+	}
+	if imported != nil {
+		printImport(imported)
 	}
-
-	fmt.Fprintf(os.Stdout, "Initialized workspace: %s\n", ws.Root)
 	fmt.Fprintln(os.Stdout, "Next steps:")
 	fmt.Fprintf(os.Stdout, "  %s kr measure --workspace %s\n", appName, ws.Root)
 	fmt.Fprintf(os.Stdout, "  %s plan generate --workspace %s\n", appName, ws.Root)
@@ -440,10 +639,23 @@ func runPlanGenerate(args []string, workspacePath string) error {
 	objectiveID := fs.String("objective-id", "", "Optional objective_id to target")
 	krID := fs.String("kr-id", "", "Optional kr_id to target")
 	agentRole := fs.String("agent-role", "software_engineer", "Agent role for generated items")
+	portfolio := fs.Bool("portfolio", false, "Allocate items across objectives by weight and remaining progress")
+	items := fs.Int("items", planner.DefaultPortfolioItems, "Number of items to allocate with --portfolio or --strategy at_risk|round_robin")
+	period := fs.String("period", "", "Only plan for objectives in this OKR period (e.g. 2025-Q3)")
+	strategy := fs.String("strategy", planner.StrategyFirst, "KR selection: first, at_risk (rank by the latest score report), or round_robin")
+	scoreReport := fs.String("score-report", "", "Score report for --strategy at_risk (default: latest kr_score_*.json in the artifacts dir)")
+	adapterName := fs.String("adapter", "", "Agent adapter that writes the plan items from the OKR context (default: template items)")
+	adapterTimeout := fs.Duration("adapter-timeout", 10*time.Minute, "Timeout for the --adapter run")
+	strictMetrics := fs.Bool("strict-metrics", false, "Fail when a KR to plan for has a metric_key no configured provider produces")
+	successCriteria := addSuccessCriteriaFlags(fs)
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	criteria, err := successCriteria()
+	if err != nil {
+		return err
+	}
 
 	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
 		OKRsDir:      *okrsDir,
@@ -476,6 +688,30 @@ func runPlanGenerate(args []string, workspacePath string) error {
 		}
 		asOf = parsed.UTC().Truncate(24 * time.Hour)
 	}
+	if *strategy == planner.StrategyAtRisk {
+		if *scoreReport == "" {
+			if *scoreReport, err = metrics.LatestScoreReportPath(resolved.ArtifactsDir); err != nil {
+				return err
+			}
+		} else if *scoreReport, err = resolved.Workspace.ResolvePath(*scoreReport); err != nil {
+			return fmt.Errorf("resolve --score-report: %w", err)
+		}
+	}
+
+	if err := checkPlannedMetrics(resolved, *period, *objectiveID, *krID, *strictMetrics); err != nil {
+		return err
+	}
+
+	var adapter adapters.AgentAdapter
+	var language string
+	if *adapterName != "" {
+		if adapter, err = adapters.Resolve(resolved.Workspace.Root, *adapterName); err != nil {
+			return err
+		}
+		if language, err = locale.LoadLanguage(resolved.Workspace.Root); err != nil {
+			return err
+		}
+	}
 
 	logger := audit.NewLogger(resolved.AuditDB)
 	startPayload := map[string]any{
@@ -486,19 +722,45 @@ func runPlanGenerate(args []string, workspacePath string) error {
 		"objective_id": *objectiveID,
 		"kr_id":        *krID,
 		"agent_role":   *agentRole,
+		"portfolio":    *portfolio,
+		"strategy":     *strategy,
 		"command":      "plan generate",
 	}
+	if *period != "" {
+		startPayload["period"] = *period
+	}
+	if adapter != nil {
+		startPayload["adapter"] = adapter.Name()
+	}
+	if criteria != nil {
+		startPayload["success_criteria"] = criteria
+	}
 	if err := logger.LogEvent("cli", "plan_generate_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
 	res, err := planner.GeneratePlan(planner.GenerateOptions{
-		OKRsDir:       *okrsDir,
-		OutputBaseDir: *outDir,
-		AsOf:          asOf,
-		ObjectiveID:   *objectiveID,
-		KRID:          *krID,
-		AgentRole:     *agentRole,
+		OKRsDir:         *okrsDir,
+		OutputBaseDir:   *outDir,
+		AsOf:            asOf,
+		ObjectiveID:     *objectiveID,
+		KRID:            *krID,
+		AgentRole:       *agentRole,
+		CacheDir:        filepath.Join(resolved.ArtifactsDir, "cache"),
+		RunsDir:         filepath.Join(resolved.ArtifactsDir, "runs"),
+		SnapshotsDir:    filepath.Join(resolved.MetricsDir, "snapshots"),
+		WorkspaceRoot:   resolved.Workspace.Root,
+		Portfolio:       *portfolio,
+		Items:           *items,
+		Strategy:        *strategy,
+		ScoreReportPath: *scoreReport,
+		SuccessCriteria: criteria,
+		Period:          *period,
+		Adapter:         adapter,
+		AdapterTimeout:  *adapterTimeout,
+		CultureDir:      resolved.CultureDir,
+		Language:        language,
+		PromptDir:       filepath.Join(resolved.Workspace.Root, planner.PromptDirName),
 	})
 
 	finishPayload := map[string]any{
@@ -513,15 +775,43 @@ func runPlanGenerate(args []string, workspacePath string) error {
 
 	finishPayload["plan_path"] = res.PlanPath
 	finishPayload["plan_id"] = res.Plan.ID
+	if res.Plan.Allocation != nil {
+		finishPayload["allocation"] = res.Plan.Allocation
+	}
+	if res.Plan.Prioritization != nil {
+		finishPayload["prioritization"] = res.Plan.Prioritization
+	}
+	if res.Plan.GeneratedBy != "" {
+		finishPayload["generated_by"] = res.Plan.GeneratedBy
+	}
 	_ = logger.LogEvent("cli", "plan_generate_finished", finishPayload)
 
 	fmt.Fprintf(os.Stdout, "Wrote plan: %s\n", res.PlanPath)
+	fmt.Fprintf(os.Stdout, "Draft; approve with: %s plan approve %s\n", appName, res.PlanPath)
+	if res.Plan.Allocation != nil {
+		for _, obj := range res.Plan.Allocation.Objectives {
+			fmt.Fprintf(os.Stdout, "  %s: %d item(s) - %s\n", obj.ObjectiveID, obj.Items, obj.Rationale)
+		}
+	}
+	if p := res.Plan.Prioritization; p != nil {
+		for _, kr := range p.KeyResults {
+			if !kr.Selected {
+				continue
+			}
+			if p.Strategy == planner.StrategyAtRisk {
+				fmt.Fprintf(os.Stdout, "  %s: priority %.3f (%.0f%% to target, confidence %.2f, %d day(s) left)\n",
+					kr.KRID, kr.Priority, kr.PercentToTarget, kr.Confidence, kr.DaysRemaining)
+			} else {
+				fmt.Fprintf(os.Stdout, "  %s\n", kr.KRID)
+			}
+		}
+	}
 	return nil
 }
 
 func runPlanRun(args []string, workspacePath string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("plan path is required")
+		return fmt.Errorf("plan path or --resume is required")
 	}
 
 	planArg := ""
@@ -531,9 +821,13 @@ func runPlanRun(args []string, workspacePath string) error {
 		remaining = remaining[1:]
 	}
 
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
 	fs := flag.NewFlagSet("plan run", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	adapterName := fs.String("adapter", "codex", "Adapter name")
+	adapterName := fs.String("adapter", conf.Adapter, "Adapter name")
 	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
 	cultureDir := fs.String("culture-dir", "", "Path to culture directory (default: <workspace>/culture)")
 	metricsDir := fs.String("metrics-dir", "", "Path to metrics directory (default: <workspace>/metrics)")
@@ -543,15 +837,36 @@ func runPlanRun(args []string, workspacePath string) error {
 	timeout := fs.Duration("timeout", 0, "Optional per-item timeout (e.g. 10m)")
 	follow := fs.Bool("follow", false, "Stream agent transcript.log while running")
 	followLines := fs.Int("follow-lines", 200, "When following, start from last N lines (0 = from start)")
+	parallel := fs.Int("parallel", 1, "Run up to N independent plan items at once")
+	continueOnError := fs.Bool("continue-on-error", false, "Keep running items after one fails, skipping only its dependents")
+	resume := fs.String("resume", "", "Continue a failed or interrupted run (run ID or dir), skipping items that already succeeded")
+	keepOKRsEdits := fs.Bool("keep-okrs-edits", false, "Leave an agent's direct okrs/ edits in place instead of reverting them (the item still fails)")
+	keepWorktrees := fs.Bool("keep-worktrees", false, "Leave scoped items' worktrees in place for inspection instead of removing them")
+	budget := fs.Float64("budget", 0, "Stop starting items once the estimated cost passes this many USD (0 = no limit)")
+	verify := fs.Bool("verify", true, "Measure each item's KR metric before and after it runs and mark it verified, unverified, or regressed")
+	dryRun := fs.Bool("dry-run", false, "Validate the plan against the current OKRs and render its prompts without running the adapter")
+	branches := fs.Bool("branches", conf.Git.Branches, "Run each item on its own okr/<plan-id>/<item-id> branch and commit its changes there")
+	pullRequests := fs.Bool("pr", conf.Git.PullRequests, "Push each item branch and open a pull request with gh (implies --branches)")
+	prBase := fs.String("pr-base", conf.Git.Base, "Branch pull requests target (default: the branch checked out)")
 	if err := fs.Parse(remaining); err != nil {
 		return err
 	}
+	if *dryRun && *resume != "" {
+		return fmt.Errorf("--dry-run cannot be combined with --resume")
+	}
+	if *parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	if *budget < 0 {
+		return fmt.Errorf("--budget must not be negative")
+	}
 	if planArg == "" {
 		rest := fs.Args()
-		if len(rest) == 0 {
+		if len(rest) > 0 {
+			planArg = rest[0]
+		} else if *resume == "" {
 			return fmt.Errorf("plan path is required")
 		}
-		planArg = rest[0]
 	}
 
 	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
@@ -571,6 +886,20 @@ func runPlanRun(args []string, workspacePath string) error {
 		return err
 	}
 
+	resumeDir := ""
+	if *resume != "" {
+		if resumeDir, err = resolveRunDir(resolved.ArtifactsDir, *resume); err != nil {
+			return err
+		}
+		state, err := planner.LoadRunState(resumeDir)
+		if err != nil {
+			return fmt.Errorf("cannot resume %s: %w", *resume, err)
+		}
+		if planArg == "" {
+			planArg = state.PlanPath
+		}
+	}
+
 	if !filepath.IsAbs(planArg) {
 		planArg, err = resolved.Workspace.ResolvePath(planArg)
 		if err != nil {
@@ -587,23 +916,74 @@ func runPlanRun(args []string, workspacePath string) error {
 		return fmt.Errorf("resolve workdir: %w", err)
 	}
 
-	var adapter adapters.AgentAdapter
-	switch *adapterName {
-	case "codex":
-		adapter = &adapters.CodexAdapter{}
-	case "mock":
-		adapter = &adapters.MockAdapter{}
-	default:
-		return fmt.Errorf("unknown adapter: %s", *adapterName)
+	adapter, err := adapters.Resolve(resolved.Workspace.Root, *adapterName)
+	if err != nil {
+		return err
+	}
+	language, err := locale.LoadLanguage(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		return runPlanDryRun(resolved, absPlan, adapter.Name(), language)
+	}
+	pricing, err := adapters.LoadPricing(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	hooks, err := planner.LoadHooks(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	sandbox, err := adapters.LoadSandbox(resolved.Workspace.Root, adapter.Name())
+	if err != nil {
+		return err
+	}
+	envCfg, err := adapters.LoadEnv(resolved.Workspace.Root)
+	if err != nil {
+		return err
+	}
+	scrubber := adapters.NewScrubber()
+	var measure planner.MeasureFunc
+	if *verify {
+		providerCfg := metrics.ProviderConfig{RepoDir: absWorkDir, MetricsDir: resolved.MetricsDir}
+		if err := providerCfg.LoadWorkspaceConfig(resolved.Workspace.Root); err != nil {
+			return err
+		}
+		measure = planner.MeasureWorkspace(providerCfg, filepath.Join(resolved.MetricsDir, "snapshots"))
+	}
+	if *budget > 0 && len(pricing) == 0 {
+		fmt.Fprintf(os.Stderr, "warning: no pricing in %s; --budget only counts costs the adapter reports\n", adapters.ConfigFileName)
 	}
 
-	logger := audit.NewLogger(resolved.AuditDB)
+	// Items log several events each, so queue them and write in batches.
+	logger := audit.NewBatchLogger(resolved.AuditDB, audit.BatchOptions{})
+	defer func() {
+		if err := logger.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "audit log failed:", err)
+		}
+	}()
 	startPayload := map[string]any{
 		"workspace": resolved.Workspace.Root,
 		"plan":      absPlan,
 		"adapter":   adapter.Name(),
 		"workdir":   absWorkDir,
 		"timeout":   timeout.String(),
+		"parallel":  *parallel,
+	}
+	if *continueOnError {
+		startPayload["continue_on_error"] = true
+	}
+	if resumeDir != "" {
+		startPayload["resume_run_dir"] = resumeDir
+	}
+	if *budget > 0 {
+		startPayload["budget_usd"] = *budget
+	}
+	gitOpts := planner.GitOptions{Branches: *branches, PullRequests: *pullRequests, Remote: conf.Git.Remote, Base: *prBase}
+	if gitOpts.Enabled() {
+		startPayload["branches"] = true
+		startPayload["pull_requests"] = gitOpts.PullRequests
 	}
 	if err := logger.LogEvent("cli", "plan_run_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
@@ -620,6 +1000,24 @@ func runPlanRun(args []string, workspacePath string) error {
 		FollowTranscripts: *follow,
 		FollowLines:       *followLines,
 		FollowWriter:      os.Stdout,
+		Parallel:          *parallel,
+		IndexArtifactsDir: resolved.ArtifactsDir,
+		Language:          language,
+		PromptDir:         filepath.Join(resolved.Workspace.Root, planner.PromptDirName),
+		RoleTemplateDir:   filepath.Join(resolved.Workspace.Root, planner.RoleTemplateDirName),
+		ResumeDir:         resumeDir,
+		ContinueOnError:   *continueOnError,
+		KeepOKRsEdits:     *keepOKRsEdits,
+		KeepWorktrees:     *keepWorktrees,
+		Pricing:           pricing,
+		Budget:            *budget,
+		Measure:           measure,
+		Hooks:             hooks,
+		Git:               gitOpts,
+		Sandbox:           sandbox,
+		SandboxIgnore:     resolved.effective().StatePaths(),
+		Env:               envCfg,
+		Scrubber:          scrubber,
 	})
 
 	finishPayload := map[string]any{
@@ -630,22 +1028,124 @@ func runPlanRun(args []string, workspacePath string) error {
 	if res != nil {
 		finishPayload["run_id"] = res.RunID
 		finishPayload["run_dir"] = res.RunDir
-		finishPayload["items_run"] = len(res.ItemRuns)
+		succeeded, failed, skipped := res.Counts()
+		finishPayload["items_run"] = succeeded
+		finishPayload["items_failed"] = failed
+		finishPayload["items_skipped"] = skipped
+		finishPayload["usage"] = res.Usage
+		if measure != nil {
+			verified, unverified, regressed := res.VerificationCounts()
+			finishPayload["items_verified"] = verified
+			finishPayload["items_unverified"] = unverified
+			finishPayload["items_regressed"] = regressed
+		}
 	}
 	if runErr != nil {
 		finishPayload["error"] = runErr.Error()
+		if class, ok := planner.ClassifyFailure(runErr); ok {
+			finishPayload["failure_class"] = class
+		}
 	}
-	if err := logger.LogEvent("cli", "plan_run_finished", finishPayload); err != nil {
+	if err := logger.LogEvent("cli", "plan_run_finished", scrubber.Payload(finishPayload)); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
+	if res != nil && (*continueOnError || runErr != nil) {
+		printPlanRunSummary(res)
+	}
 	if runErr != nil {
+		if res != nil && res.RunDir != "" {
+			fmt.Fprintf(os.Stderr, "Run summary: %s\n", filepath.Join(res.RunDir, planner.RunStateFileName))
+			fmt.Fprintf(os.Stderr, "Resume with: %s plan run --resume %s\n", appName, res.RunID)
+		}
 		return runErr
 	}
 	fmt.Fprintf(os.Stdout, "Plan run complete: %s\n", res.RunDir)
+	fmt.Fprintf(os.Stdout, "Run summary: %s\n", filepath.Join(res.RunDir, planner.RunStateFileName))
+	if measure != nil {
+		verified, unverified, regressed := res.VerificationCounts()
+		fmt.Fprintf(os.Stdout, "Verification: %d verified, %d unverified, %d regressed\n", verified, unverified, regressed)
+		for _, run := range res.ItemRuns {
+			if v := run.Verification; v != nil && v.Status != planner.VerificationVerified {
+				fmt.Fprintf(os.Stdout, "  %-10s %s (%s)%s\n", v.Status, run.ItemID, v.KRID, verificationDetail(v))
+			}
+		}
+	}
+	if gitOpts.Enabled() {
+		printItemBranches(res)
+	}
+	if res.Usage.TotalTokens > 0 || res.Usage.DurationSeconds > 0 {
+		l10n := outputLocale(resolved.Workspace)
+		fmt.Fprintf(os.Stdout, "Usage: %s tokens (%s in, %s out), agent time %s\n",
+			l10n.Int(res.Usage.TotalTokens), l10n.Int(res.Usage.InputTokens), l10n.Int(res.Usage.OutputTokens),
+			time.Duration(res.Usage.DurationSeconds*float64(time.Second)).Round(time.Second))
+	}
+	if res.Usage.CostUSD > 0 {
+		fmt.Fprintf(os.Stdout, "Estimated cost: $%s\n", outputLocale(resolved.Workspace).Fixed(res.Usage.CostUSD, 2))
+	}
+
+	outcome, err := outcomes.Track(resolved.effective(), absPlan, res)
+	if err != nil {
+		return fmt.Errorf("track outcome: %w", err)
+	}
+	if outcome != nil {
+		if err := logger.LogEvent("cli", "plan_outcome_tracked", map[string]any{
+			"plan_id":  outcome.PlanID,
+			"run_id":   outcome.RunID,
+			"deadline": outcome.Deadline,
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, "audit log failed:", err)
+		}
+		fmt.Fprintf(os.Stdout, "Tracking success criteria until %s\n", outcome.Deadline)
+	}
 	return nil
 }
 
+// verificationDetail describes an item's metric change, or why it could
+// not be checked.
+func verificationDetail(v *planner.Verification) string {
+	if v.Delta != nil {
+		return fmt.Sprintf(": %s %g -> %g", v.MetricKey, *v.Before, *v.After)
+	}
+	if v.Reason != "" {
+		return ": " + v.Reason
+	}
+	return ""
+}
+
+// printPlanRunSummary prints item outcome counts and each failed item.
+func printPlanRunSummary(res *planner.RunResult) {
+	succeeded, failed, skipped := res.Counts()
+	fmt.Fprintf(os.Stderr, "Items: %d succeeded, %d failed, %d skipped (of %d)\n", succeeded, failed, skipped, len(res.Plan.Items))
+
+	for _, failure := range res.Failures {
+		fmt.Fprintf(os.Stderr, "  failed  %s (%s): %s\n", failure.PlanItemID, failure.Class, failure.Message)
+	}
+	for _, id := range res.Skipped {
+		fmt.Fprintf(os.Stderr, "  skipped %s\n", id)
+	}
+}
+
+// printItemBranches lists where each succeeded item was committed.
+func printItemBranches(res *planner.RunResult) {
+	fmt.Fprintln(os.Stdout, "Branches:")
+	for _, run := range res.ItemRuns {
+		g := run.Git
+		switch {
+		case g == nil:
+			continue
+		case g.Commit == "":
+			fmt.Fprintf(os.Stdout, "  %s  %s (no changes)\n", run.ItemID, g.Branch)
+		case g.PRURL != "":
+			fmt.Fprintf(os.Stdout, "  %s  %s %.7s  %s\n", run.ItemID, g.Branch, g.Commit, g.PRURL)
+		case g.PRError != "":
+			fmt.Fprintf(os.Stdout, "  %s  %s %.7s  no pull request: %s\n", run.ItemID, g.Branch, g.Commit, g.PRError)
+		default:
+			fmt.Fprintf(os.Stdout, "  %s  %s %.7s\n", run.ItemID, g.Branch, g.Commit)
+		}
+	}
+}
+
 func runOKRPropose(args []string, workspacePath string) error {
 	fs := flag.NewFlagSet("okr propose", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
@@ -707,7 +1207,7 @@ func runOKRPropose(args []string, workspacePath string) error {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	meta, err := okrstore.CreateProposal(*agentID, absUpdatesDir, *okrsDir, *proposalsDir, *note)
+	meta, err := okrstore.CreateProposalIn(resolved.Workspace.Root, *agentID, absUpdatesDir, *okrsDir, *proposalsDir, *note)
 	finishPayload := map[string]any{
 		"agent_id": *agentID,
 		"from":     absUpdatesDir,
@@ -722,6 +1222,7 @@ func runOKRPropose(args []string, workspacePath string) error {
 
 	finishPayload["proposal_dir"] = meta.ProposalDir
 	finishPayload["files"] = meta.Files
+	finishPayload["status"] = meta.Status
 	_ = logger.LogEvent(*agentID, "okr_propose_finished", finishPayload)
 
 	fmt.Fprintf(os.Stdout, "Proposal created: %s\n", meta.ProposalDir)
@@ -731,6 +1232,7 @@ func runOKRPropose(args []string, workspacePath string) error {
 	if meta.DiffFile != "" {
 		fmt.Fprintf(os.Stdout, "Diff: %s\n", filepath.Join(meta.ProposalDir, meta.DiffFile))
 	}
+	fmt.Fprintf(os.Stdout, "Review with: %s okr review --proposal %s --approve|--reject --reviewer <id>\n", appName, resolved.Workspace.RelPath(meta.ProposalDir))
 	return nil
 }
 
@@ -744,6 +1246,8 @@ func runOKRApply(args []string, workspacePath string) error {
 	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
 	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
 	confirm := fs.Bool("i-understand", false, "Explicitly confirm applying OKR changes")
+	force := fs.Bool("force", false, "Apply even if the proposal is not approved")
+	overwrite := fs.Bool("overwrite", false, "Apply even if okrs/ changed since the proposal was created, discarding those changes")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -777,22 +1281,42 @@ func runOKRApply(args []string, workspacePath string) error {
 	startPayload := map[string]any{
 		"proposal": absProposalPath,
 	}
+	if *force {
+		startPayload["force"] = true
+	}
+	if *overwrite {
+		startPayload["overwrite"] = true
+	}
 	if err := logger.LogEvent("cli", "okr_apply_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	meta, err := okrstore.ApplyProposal(absProposalPath, *confirm)
+	previousStatus := ""
+	if prior, err := okrstore.ReadProposal(resolved.Workspace.Root, absProposalPath); err == nil {
+		previousStatus = prior.Status
+	}
+	meta, err := okrstore.ApplyProposalIn(resolved.Workspace.Root, absProposalPath, okrstore.ApplyOptions{
+		Confirm:   *confirm,
+		Force:     *force,
+		Overwrite: *overwrite,
+	})
 	finishPayload := map[string]any{
 		"proposal": absProposalPath,
 	}
 	if err != nil {
 		finishPayload["error"] = err.Error()
+		var conflict *okrstore.ConflictError
+		if errors.As(err, &conflict) {
+			finishPayload["conflicts"] = conflict.Conflicts
+		}
 		_ = logger.LogEvent("cli", "okr_apply_finished", finishPayload)
 		return err
 	}
 
 	finishPayload["okrs_dir"] = meta.OKRsDir
 	finishPayload["agent_id"] = meta.AgentID
+	finishPayload["from_status"] = previousStatus
+	finishPayload["status"] = meta.Status
 	_ = logger.LogEvent("cli", "okr_apply_finished", finishPayload)
 
 	fmt.Fprintf(os.Stdout, "Applied proposal %s to %s\n", meta.ID, meta.OKRsDir)
@@ -800,18 +1324,32 @@ func runOKRApply(args []string, workspacePath string) error {
 }
 
 func runKRMeasure(args []string, workspacePath string) error {
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
+	if conf.Metrics.OpenMetricsPrefix == "" {
+		conf.Metrics.OpenMetricsPrefix = "openmetrics."
+	}
 	fs := flag.NewFlagSet("kr measure", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	asOfStr := fs.String("as-of", "", "As-of date (YYYY-MM-DD, default: today UTC)")
-	repoDir := fs.String("repo-dir", "", "Git repo directory for git metrics (default: <workspace>)")
+	repoDir := fs.String("repo-dir", conf.Metrics.RepoDir, "Git repo directory for git metrics (default: <workspace>)")
 	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs/outputs (default: <workspace>/metrics)")
 	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
 	cultureDir := fs.String("culture-dir", "", "Path to culture directory (default: <workspace>/culture)")
 	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
 	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
 	snapshotsDir := fs.String("snapshots-dir", "", "Directory to write metric snapshots (default: <metrics-dir>/snapshots)")
-	ciReport := fs.String("ci-report", "", "Path to CI JSON report (default: <metrics-dir>/ci_report.json)")
-	manualPath := fs.String("manual", "", "Path to manual metrics YAML (default: <metrics-dir>/manual.yml)")
+	ciReport := fs.String("ci-report", conf.Metrics.CIReport, "Path to CI JSON report (default: <metrics-dir>/ci_report.json)")
+	manualPath := fs.String("manual", conf.Metrics.Manual, "Path to manual metrics YAML (default: <metrics-dir>/manual.yml)")
+	coveragePath := fs.String("coverage", conf.Metrics.Coverage, "Coverage or test report (Go cover profile, lcov, Cobertura, JUnit, go test -json), or a directory of them (default: <metrics-dir>/coverage)")
+	openMetricsDir := fs.String("openmetrics-dir", conf.Metrics.OpenMetricsDir, "Directory of OpenMetrics *.prom exports (default: <metrics-dir>/openmetrics)")
+	openMetricsPrefix := fs.String("openmetrics-prefix", conf.Metrics.OpenMetricsPrefix, "Key prefix for OpenMetrics samples")
+	strict := fs.Bool("strict", false, "Fail if any metric provider fails instead of skipping it")
+	providerTimeout := fs.Duration("provider-timeout", conf.Metrics.ProviderTimeout, "Fail a metric provider that runs longer than this (0: no limit)")
+	concurrency := fs.Int("concurrency", conf.Metrics.Concurrency, "Number of metric providers to run at once")
+	githubRepo := fs.String("github-repo", "", "GitHub repository (owner/name) for PR and issue metrics (default: repo in github.yml)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -830,6 +1368,13 @@ func runKRMeasure(args []string, workspacePath string) error {
 	if err := resolved.Workspace.EnsureDirs(); err != nil {
 		return err
 	}
+	var providerCfg metrics.ProviderConfig
+	if err := providerCfg.LoadWorkspaceConfig(resolved.Workspace.Root); err != nil {
+		return err
+	}
+	if *githubRepo != "" {
+		providerCfg.GitHub.Repo = *githubRepo
+	}
 	if *repoDir == "" {
 		*repoDir = resolved.Workspace.Root
 	} else {
@@ -863,6 +1408,22 @@ func runKRMeasure(args []string, workspacePath string) error {
 			return fmt.Errorf("resolve --manual: %w", err)
 		}
 	}
+	if *coveragePath == "" {
+		*coveragePath = filepath.Join(*metricsDir, "coverage")
+	} else {
+		*coveragePath, err = resolved.Workspace.ResolvePath(*coveragePath)
+		if err != nil {
+			return fmt.Errorf("resolve --coverage: %w", err)
+		}
+	}
+	if *openMetricsDir == "" {
+		*openMetricsDir = filepath.Join(*metricsDir, "openmetrics")
+	} else {
+		*openMetricsDir, err = resolved.Workspace.ResolvePath(*openMetricsDir)
+		if err != nil {
+			return fmt.Errorf("resolve --openmetrics-dir: %w", err)
+		}
+	}
 
 	asOf := time.Now().UTC().Truncate(24 * time.Hour)
 	if *asOfStr != "" {
@@ -882,19 +1443,31 @@ func runKRMeasure(args []string, workspacePath string) error {
 		"snapshots_dir": *snapshotsDir,
 		"ci_report":     *ciReport,
 		"manual_path":   *manualPath,
+		"coverage":      *coveragePath,
+		"openmetrics":   *openMetricsDir,
+	}
+	if providerCfg.GitHub.Repo != "" {
+		startPayload["github_repo"] = providerCfg.GitHub.Repo
+	}
+	if providerCfg.Tracker.Source != "" {
+		startPayload["tracker"] = providerCfg.Tracker.Source
 	}
 	if err := logger.LogEvent("cli", "kr_measure_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	providers := []metrics.Provider{
-		&metrics.GitProvider{RepoDir: *repoDir, AsOf: asOf},
-		&metrics.CIProvider{ReportPath: *ciReport, AsOf: asOf},
-		&metrics.ManualProvider{Path: *manualPath, AsOf: asOf},
-	}
+	providerCfg.RepoDir = *repoDir
+	providerCfg.MetricsDir = *metricsDir
+	providerCfg.CIReportPath = *ciReport
+	providerCfg.ManualPath = *manualPath
+	providerCfg.CoveragePath = *coveragePath
+	providerCfg.OpenMetricsDir = *openMetricsDir
+	providerCfg.OpenMetricsPrefix = *openMetricsPrefix
+	providerCfg.AsOf = asOf
+	providers := metrics.DefaultProviders(providerCfg)
 
 	ctx := context.Background()
-	points, err := metrics.CollectAll(ctx, providers)
+	points, collection, err := metrics.CollectReport(ctx, providers, metrics.CollectOptions{Strict: *strict, Timeout: *providerTimeout, Concurrency: *concurrency})
 	if err != nil {
 		finishPayload := map[string]any{
 			"error": err.Error(),
@@ -902,11 +1475,16 @@ func runKRMeasure(args []string, workspacePath string) error {
 		_ = logger.LogEvent("cli", "kr_measure_finished", finishPayload)
 		return err
 	}
+	providerErrors := collection.Failures()
+	for _, pe := range providerErrors {
+		fmt.Fprintf(os.Stderr, "Warning: %s provider skipped: %s\n", pe.Provider, pe.Error)
+	}
 
 	snapshotPath := metrics.SnapshotPathForDate(*snapshotsDir, asOf)
 	snapshot := metrics.Snapshot{
-		AsOf:   asOf.Format("2006-01-02"),
-		Points: points,
+		AsOf:           asOf.Format("2006-01-02"),
+		Points:         points,
+		ProviderErrors: providerErrors,
 	}
 	if err := metrics.WriteSnapshot(snapshotPath, snapshot); err != nil {
 		finishPayload := map[string]any{
@@ -916,16 +1494,20 @@ func runKRMeasure(args []string, workspacePath string) error {
 		_ = logger.LogEvent("cli", "kr_measure_finished", finishPayload)
 		return err
 	}
+	if err := metrics.RecordHistory(*snapshotsDir, &snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: metric history update failed: %v\n", err)
+	}
 
 	// Update KR status based on metrics
 	changes, err := metrics.UpdateKRStatus(resolved.OKRsDir, &snapshot)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: status update failed: %v\n", err)
 	} else if len(changes) > 0 {
+		l10n := outputLocale(resolved.Workspace)
 		for _, change := range changes {
-			fmt.Fprintf(os.Stdout, "Status updated: %s %s -> %s (%.0f/%.0f)\n",
-				change.KRID, change.OldStatus, change.NewStatus, change.Current, change.Target)
-			
+			fmt.Fprintf(os.Stdout, "Status updated: %s %s -> %s (%s/%s)\n",
+				change.KRID, change.OldStatus, change.NewStatus, l10n.Fixed(change.Current, 0), l10n.Fixed(change.Target, 0))
+
 			auditPayload := map[string]any{
 				"kr_id":        change.KRID,
 				"objective_id": change.ObjectiveID,
@@ -948,8 +1530,17 @@ func runKRMeasure(args []string, workspacePath string) error {
 	if len(changes) > 0 {
 		finishPayload["status_changes"] = len(changes)
 	}
+	if len(providerErrors) > 0 {
+		finishPayload["provider_errors"] = providerErrors
+	}
+	finishPayload["collection"] = collection.Providers
 	_ = logger.LogEvent("cli", "kr_measure_finished", finishPayload)
 
+	fmt.Fprintf(os.Stdout, "Collected %d points from %d providers", len(points), len(collection.Providers))
+	if len(providerErrors) > 0 {
+		fmt.Fprintf(os.Stdout, " (%d failed)", len(providerErrors))
+	}
+	fmt.Fprintln(os.Stdout)
 	fmt.Fprintf(os.Stdout, "Wrote snapshot: %s\n", snapshotPath)
 	return nil
 }
@@ -965,6 +1556,11 @@ func runKRScore(args []string, workspacePath string) error {
 	snapshotsDir := fs.String("snapshots-dir", "", "Directory to read metric snapshots (default: <metrics-dir>/snapshots)")
 	snapshotPath := fs.String("snapshot", "", "Path to snapshot JSON (default: latest in snapshots-dir)")
 	output := fs.String("output", "", "Output report path (default: <workspace>/artifacts/kr_score_<as-of>.json)")
+	writeBadges := fs.Bool("badges", false, "Also write SVG badges to <artifacts-dir>/badges")
+	trendDays := fs.Int("trend-days", metrics.DefaultTrendWindowDays, "Days of metric history to fit KR trends to")
+	period := fs.String("period", "", "Only score KRs of objectives in this OKR period (e.g. 2025-Q3)")
+	updateStatus := fs.Bool("update-status", false, "Also update org KR statuses from the snapshot")
+	agentID := fs.String("agent", "", "With --update-status, propose the status changes as this agent instead of writing okrs/")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -1008,6 +1604,9 @@ func runKRScore(args []string, workspacePath string) error {
 		"snapshots_dir": *snapshotsDir,
 		"snapshot":      startSnapshot,
 	}
+	if *period != "" {
+		startPayload["period"] = *period
+	}
 	if err := logger.LogEvent("cli", "kr_score_started", startPayload); err != nil {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
@@ -1046,7 +1645,7 @@ func runKRScore(args []string, workspacePath string) error {
 		return err
 	}
 
-	store, err := okrstore.LoadFromDir(*okrsDir)
+	store, err := okrstore.LoadFromDirCached(*okrsDir, filepath.Join(*artifactsDir, "cache"))
 	if err != nil {
 		finishPayload := map[string]any{
 			"okrs_dir": *okrsDir,
@@ -1055,8 +1654,9 @@ func runKRScore(args []string, workspacePath string) error {
 		_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
 		return err
 	}
+	store = store.InPeriod(*period)
 
-	report, err := metrics.ScoreKRs(store, snapshot, path)
+	report, err := metrics.ScoreKRs(store, snapshot, resolved.Workspace.RelPath(path))
 	if err != nil {
 		finishPayload := map[string]any{
 			"snapshot": path,
@@ -1065,6 +1665,28 @@ func runKRScore(args []string, workspacePath string) error {
 		_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
 		return err
 	}
+	report.Period = *period
+	if annotations, err := metrics.LoadAnnotations(filepath.Dir(path)); err != nil {
+		fmt.Fprintln(os.Stderr, "load annotations:", err)
+	} else {
+		metrics.AnnotateReport(report, annotations)
+	}
+	if policy, err := metrics.LoadFreshnessPolicy(resolved.Workspace.Root, *okrsDir); err != nil {
+		fmt.Fprintln(os.Stderr, "load freshness policy:", err)
+	} else if err := metrics.ApplyFreshness(report, store, snapshot, policy); err != nil {
+		fmt.Fprintln(os.Stderr, "check metric freshness:", err)
+	}
+	if err := metrics.ApplyTrends(report, filepath.Dir(path), *trendDays); err != nil {
+		fmt.Fprintln(os.Stderr, "compute trends:", err)
+	}
+	for _, pe := range report.ProviderErrors {
+		fmt.Fprintf(os.Stderr, "Warning: snapshot is missing %s metrics: %s\n", pe.Provider, pe.Error)
+	}
+	for _, result := range report.Results {
+		if result.Stale {
+			fmt.Fprintf(os.Stderr, "Warning: %s is stale: %s was last observed %s\n", result.KRID, result.MetricKey, result.ObservedAt)
+		}
+	}
 
 	outPath := *output
 	if outPath == "" {
@@ -1075,31 +1697,26 @@ func runKRScore(args []string, workspacePath string) error {
 			return fmt.Errorf("resolve --output: %w", err)
 		}
 	}
-	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+	if err := metrics.WriteScoreReport(outPath, report); err != nil {
 		finishPayload := map[string]any{
 			"output": outPath,
 			"error":  err.Error(),
 		}
 		_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
-		return fmt.Errorf("ensure artifacts dir: %w", err)
-	}
-	data, err := json.MarshalIndent(report, "", "  ")
-	if err != nil {
-		finishPayload := map[string]any{
-			"output": outPath,
-			"error":  err.Error(),
-		}
-		_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
-		return fmt.Errorf("marshal score report: %w", err)
+		return err
 	}
-	data = append(data, '\n')
-	if err := os.WriteFile(outPath, data, 0o644); err != nil {
-		finishPayload := map[string]any{
-			"output": outPath,
-			"error":  err.Error(),
+
+	var badgePaths []string
+	if *writeBadges {
+		badgePaths, err = badges.WriteAll(filepath.Join(*artifactsDir, "badges"), report)
+		if err != nil {
+			finishPayload := map[string]any{
+				"output": outPath,
+				"error":  err.Error(),
+			}
+			_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
+			return err
 		}
-		_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
-		return fmt.Errorf("write score report: %w", err)
 	}
 
 	finishPayload := map[string]any{
@@ -1107,75 +1724,85 @@ func runKRScore(args []string, workspacePath string) error {
 		"as_of":   report.AsOf,
 		"metrics": len(report.Results),
 	}
+	if len(badgePaths) > 0 {
+		finishPayload["badges"] = len(badgePaths)
+	}
 	_ = logger.LogEvent("cli", "kr_score_finished", finishPayload)
 
 	fmt.Fprintf(os.Stdout, "Wrote score report: %s\n", outPath)
+	if len(badgePaths) > 0 {
+		fmt.Fprintf(os.Stdout, "Wrote %d badges: %s\n", len(badgePaths), filepath.Join(*artifactsDir, "badges"))
+	}
+	if *updateStatus {
+		return updateKRStatusFromScore(resolved, logger, snapshot, path, *agentID)
+	}
 	return nil
 }
 
-func writeFileIfMissing(path string, contents string) error {
-	if _, err := os.Stat(path); err == nil {
+// updateKRStatusFromScore writes the KR status changes snapshot implies, or
+// proposes them as agentID when set.
+func updateKRStatusFromScore(resolved *resolvedWorkspace, logger *audit.Logger, snapshot *metrics.Snapshot, snapshotPath, agentID string) error {
+	var changes []metrics.StatusChange
+	var meta *okrstore.ProposalMetadata
+	var err error
+	if agentID != "" {
+		changes, meta, err = metrics.ProposeKRStatus(resolved.effective(), snapshot, agentID)
+	} else {
+		changes, err = metrics.UpdateKRStatus(resolved.OKRsDir, snapshot)
+	}
+	if err != nil {
+		return fmt.Errorf("update kr status: %w", err)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stdout, "No KR status changes.")
 		return nil
-	} else if !os.IsNotExist(err) {
-		return fmt.Errorf("stat %s: %w", path, err)
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("ensure dir for %s: %w", path, err)
+	l10n := outputLocale(resolved.Workspace)
+	verb := "Status updated"
+	if meta != nil {
+		verb = "Status proposed"
 	}
-	return os.WriteFile(path, []byte(contents), 0o644)
+	for _, change := range changes {
+		fmt.Fprintf(os.Stdout, "%s: %s %s -> %s (%s/%s)\n",
+			verb, change.KRID, change.OldStatus, change.NewStatus, l10n.Fixed(change.Current, 0), l10n.Fixed(change.Target, 0))
+		if meta != nil {
+			continue
+		}
+		_ = logger.LogEvent("okr", "kr_status_auto_updated", map[string]any{
+			"kr_id":        change.KRID,
+			"objective_id": change.ObjectiveID,
+			"old_status":   change.OldStatus,
+			"new_status":   change.NewStatus,
+			"current":      change.Current,
+			"target":       change.Target,
+			"evidence":     change.Evidence,
+			"trigger":      "kr_score_cli",
+			"snapshot":     snapshotPath,
+		})
+	}
+	if meta != nil {
+		if err := logger.LogEvent("cli", "kr_status_proposed", map[string]any{
+			"agent_id":     agentID,
+			"proposal_id":  meta.ID,
+			"proposal_dir": meta.ProposalDir,
+			"snapshot":     snapshotPath,
+			"changes":      len(changes),
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, "audit log failed:", err)
+		}
+		fmt.Fprintf(os.Stdout, "Proposal created: %s\n", meta.ProposalDir)
+	}
+	return nil
 }
 
-const minimalValuesTemplate = `# Values
-
-- Clarity over ambiguity.
-- Evidence over assumptions.
-`
-
-const minimalStandardsTemplate = `# Standards
-
-- Keep changes small and reversible.
-- Capture evidence for KR claims.
-`
-
-const minimalOrgTemplate = `scope: org
-objectives:
-  - objective_id: OBJ-INIT-1
-    objective: Establish a baseline OKR workspace.
-    owner_id: team-okr
-    key_results:
-      - kr_id: KR-INIT-1
-        description: Produce a baseline metric snapshot.
-        owner_id: team-okr
-        metric_key: manual.baseline_snapshot
-        baseline: 0
-        target: 1
-        confidence: 0.5
-        status: in_progress
-        evidence:
-          - init:baseline
-`
-
-const minimalPermissionsTemplate = `permissions:
-  read:
-    - all
-  write:
-    - owner_id_match
-`
-
-const minimalManualMetricsTemplate = `metrics:
-  - key: manual.baseline_snapshot
-    value: 0
-    unit: count
-    evidence:
-      - init:seed
-`
-
-const minimalCIReportTemplate = `{
-  "metrics": {
-    "pass_rate_30d": 1
-  }
+// builtinTemplateNames lists the built-in init templates for flag help.
+func builtinTemplateNames() string {
+	names := make([]string, len(scaffold.Builtin))
+	for i, t := range scaffold.Builtin {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
 }
-`
 
 func runDaemon(args []string, workspacePath string) error {
 	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
@@ -1189,6 +1816,14 @@ func runDaemon(args []string, workspacePath string) error {
 		return runDaemonStatus(args[1:], workspacePath)
 	case "enqueue":
 		return runDaemonEnqueue(args[1:], workspacePath)
+	case "cancel":
+		return runDaemonCancel(args[1:], workspacePath)
+	case "kill":
+		return runDaemonKill(args[1:], workspacePath)
+	case "retry":
+		return runDaemonRetry(args[1:], workspacePath)
+	case "queue":
+		return runDaemonQueue(args[1:], workspacePath)
 	case "install":
 		return runDaemonInstall(args[1:], workspacePath)
 	case "uninstall":
@@ -1205,12 +1840,23 @@ func runDaemon(args []string, workspacePath string) error {
 }
 
 func runDaemonRun(args []string, workspacePath string) error {
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
 	fs := flag.NewFlagSet("daemon run", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	pollInterval := fs.Duration("poll", 1*time.Second, "Poll interval for checking jobs")
-	leaseDuration := fs.Duration("lease", 30*time.Second, "Lease duration for claimed jobs")
-	tz := fs.String("tz", "America/Chicago", "Timezone for scheduling")
-	notifications := fs.Bool("notifications", true, "Enable macOS notifications for plan completion")
+	pollInterval := fs.Duration("poll", conf.Daemon.PollInterval, "Poll interval for checking jobs")
+	leaseDuration := fs.Duration("lease", conf.Daemon.Lease, "Lease duration for claimed jobs")
+	tz := fs.String("tz", conf.Timezone, "Timezone for scheduling")
+	notifications := fs.Bool("notifications", conf.Notifications.Desktop, "Enable desktop notifications (macOS and Windows)")
+	dashboardURL := fs.String("dashboard-url", conf.Notifications.DashboardURL, "Base URL of the dashboard to link from notifications")
+	dryRun := fs.Bool("dry-run", false, "Simulate scheduling and handlers without executing or writing anything")
+	dryRunFor := fs.Duration("for", 24*time.Hour, "Window to simulate with --dry-run")
+	listen := fs.String("listen", conf.Daemon.Listen, "Serve the daemon HTTP API on this address (e.g. :8723)")
+	dashboard := fs.String("dashboard", conf.Daemon.Dashboard, "Serve the read-only web dashboard on this address (e.g. :8724)")
+	watchMode := fs.String("watch", conf.Daemon.Watch, "Detect workspace changes with fsnotify events or by polling (fsnotify|poll)")
+	takeover := fs.Bool("takeover", false, "Break another daemon's lock on this workspace")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -1220,9 +1866,15 @@ func runDaemonRun(args []string, workspacePath string) error {
 	if err != nil {
 		return err
 	}
+	if *dryRun {
+		return runDaemonDryRun(resolved.Workspace, *tz, *dryRunFor, *watchMode)
+	}
 	if err := resolved.Workspace.EnsureDirs(); err != nil {
 		return err
 	}
+	if *dashboardURL == "" && *dashboard != "" {
+		*dashboardURL = localDashboardURL(*dashboard)
+	}
 
 	cfg := daemon.Config{
 		Workspace:     resolved.Workspace,
@@ -1231,6 +1883,11 @@ func runDaemonRun(args []string, workspacePath string) error {
 		PollInterval:  *pollInterval,
 		LeaseFor:      *leaseDuration,
 		Notifications: *notifications,
+		DashboardURL:  *dashboardURL,
+		Listen:        *listen,
+		Dashboard:     *dashboard,
+		WatchMode:     *watchMode,
+		Takeover:      *takeover,
 	}
 
 	d, err := daemon.New(cfg)
@@ -1241,11 +1898,87 @@ func runDaemonRun(args []string, workspacePath string) error {
 
 	fmt.Fprintf(os.Stdout, "Starting daemon for workspace: %s\n", resolved.Workspace.Root)
 	fmt.Fprintf(os.Stdout, "Poll interval: %s, Lease: %s\n", *pollInterval, *leaseDuration)
+	if *listen != "" {
+		fmt.Fprintf(os.Stdout, "HTTP API: %s\n", *listen)
+	}
+	if *dashboard != "" {
+		fmt.Fprintf(os.Stdout, "Dashboard: %s\n", *dashboardURL)
+	}
 
 	ctx := context.Background()
 	return d.Run(ctx)
 }
 
+// localDashboardURL is the URL notifications link to when the dashboard is
+// served on addr and no --dashboard-url is set.
+func localDashboardURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+func runDaemonDryRun(ws *workspace.Workspace, tz string, window time.Duration, watchMode string) error {
+	opts := daemon.DryRunOptions{
+		Workspace:   ws,
+		TimeZone:    tz,
+		Start:       time.Now(),
+		For:         window,
+		WatchEvents: watchMode != daemon.WatchModePoll,
+	}
+	// Read queued jobs and past durations from an existing store only;
+	// a dry run must not create daemon state.
+	if _, err := os.Stat(ws.StateDBPath); err == nil {
+		store, err := daemon.Open(ws.StateDBPath)
+		if err != nil {
+			return fmt.Errorf("open daemon store: %w", err)
+		}
+		defer store.Close()
+		opts.History = store
+	}
+
+	report, err := daemon.DryRun(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return fmt.Errorf("load timezone %s: %w", tz, err)
+	}
+	fmt.Fprintf(os.Stdout, "Dry run for workspace: %s\n", ws.Root)
+	fmt.Fprintf(os.Stdout, "Window: %s -> %s (%s)\n\n",
+		report.Start.In(loc).Format("2006-01-02 15:04"), report.End.In(loc).Format("2006-01-02 15:04"), tz)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WHEN\tJOB\tCOUNT\tEST. TIME\tAGENT CALLS\tWOULD")
+	for _, entry := range report.Entries {
+		when := entry.At.In(loc).Format("2006-01-02 15:04")
+		if entry.Count > 1 {
+			when += " .. " + entry.Last.In(loc).Format("15:04")
+		}
+		jobType := entry.JobType
+		if entry.Source == "queue" {
+			jobType += " (queued)"
+		}
+		detail := entry.Detail
+		if entry.Error != "" {
+			detail = "ERROR: " + entry.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\n", when, jobType, entry.Count, entry.Duration, entry.AgentCalls, detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "\nTotal: %d job(s), est. %s busy, %d agent call(s)\n", report.Jobs, report.Duration, report.AgentCalls)
+	fmt.Fprintln(os.Stdout, "Nothing was executed or written.")
+	return nil
+}
+
 func runDaemonStatus(args []string, workspacePath string) error {
 	fs := flag.NewFlagSet("daemon status", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
@@ -1273,8 +2006,21 @@ func runDaemonStatus(args []string, workspacePath string) error {
 
 	fmt.Fprintf(os.Stdout, "Running jobs: %d\n", len(running))
 	for _, job := range running {
-		fmt.Fprintf(os.Stdout, "  %s [%s] started=%s lease_expires=%s\n",
-			job.ID, job.Type, job.StartedAt.Format(time.RFC3339), job.LeaseExpiresAt.Format(time.RFC3339))
+		fmt.Fprintf(os.Stdout, "  %s [%s] started=%s lease_expires=%s attempt=%d/%d\n",
+			job.ID, job.Type, job.StartedAt.Format(time.RFC3339), job.LeaseExpiresAt.Format(time.RFC3339), job.Attempts, job.MaxAttempts)
+		progress, err := store.GetProgress(job.ID)
+		if err != nil {
+			return fmt.Errorf("get job progress: %w", err)
+		}
+		if progress != nil {
+			fmt.Fprintf(os.Stdout, "    progress: %.0f%% (%d/%d done) item %d/%d %s",
+				progress.Percent(), progress.ItemsDone, progress.ItemsTotal,
+				progress.ItemIndex, progress.ItemsTotal, progress.ItemID)
+			if progress.ItemStartedAt != nil {
+				fmt.Fprintf(os.Stdout, " elapsed=%s", time.Since(*progress.ItemStartedAt).Truncate(time.Second))
+			}
+			fmt.Fprintln(os.Stdout)
+		}
 	}
 	fmt.Fprintln(os.Stdout)
 
@@ -1286,8 +2032,14 @@ func runDaemonStatus(args []string, workspacePath string) error {
 
 	fmt.Fprintf(os.Stdout, "Queued jobs (next %d):\n", len(queued))
 	for _, job := range queued {
-		fmt.Fprintf(os.Stdout, "  %s [%s] scheduled=%s\n",
-			job.ID, job.Type, job.ScheduledAt.Format(time.RFC3339))
+		fmt.Fprintf(os.Stdout, "  %s [%s] scheduled=%s", job.ID, job.Type, job.ScheduledAt.Format(time.RFC3339))
+		if job.Priority != 0 {
+			fmt.Fprintf(os.Stdout, " priority=%d", job.Priority)
+		}
+		if job.NextRetryAt != nil {
+			fmt.Fprintf(os.Stdout, " retry=%d/%d next_retry=%s", job.Attempts+1, job.MaxAttempts, job.NextRetryAt.Format(time.RFC3339))
+		}
+		fmt.Fprintln(os.Stdout)
 	}
 	fmt.Fprintln(os.Stdout)
 
@@ -1303,8 +2055,8 @@ func runDaemonStatus(args []string, workspacePath string) error {
 		if job.FinishedAt != nil {
 			finishedStr = job.FinishedAt.Format(time.RFC3339)
 		}
-		fmt.Fprintf(os.Stdout, "  %s [%s] status=%s finished=%s\n",
-			job.ID, job.Type, job.Status, finishedStr)
+		fmt.Fprintf(os.Stdout, "  %s [%s] status=%s finished=%s attempts=%d\n",
+			job.ID, job.Type, job.Status, finishedStr, job.Attempts)
 		if job.ResultJSON != "" {
 			fmt.Fprintf(os.Stdout, "    result: %s\n", job.ResultJSON)
 		}
@@ -1325,6 +2077,7 @@ func runDaemonEnqueue(args []string, workspacePath string) error {
 	fs.SetOutput(os.Stderr)
 	atStr := fs.String("at", "", "Scheduled time (YYYY-MM-DDTHH:MM format)")
 	payloadJSON := fs.String("payload-json", "{}", "Job payload as JSON")
+	priority := fs.Int("priority", 0, "Job priority, added to the job type's priority from daemon.yml (higher runs first)")
 
 	if err := fs.Parse(remaining); err != nil {
 		return err
@@ -1355,7 +2108,18 @@ func runDaemonEnqueue(args []string, workspacePath string) error {
 	}
 	defer store.Close()
 
-	jobID, created, err := store.EnqueueUnique(jobType, scheduledAt, payload)
+	// References are resolved when the job runs; warn now about missing ones.
+	if refs := secrets.Refs(*payloadJSON); len(refs) > 0 {
+		if secretStore, err := secrets.Default(); err == nil {
+			for _, name := range refs {
+				if _, err := secretStore.Lookup(name); err != nil {
+					fmt.Fprintln(os.Stderr, "warning:", err)
+				}
+			}
+		}
+	}
+
+	jobID, created, err := store.EnqueueUniqueWithPriority(jobType, scheduledAt, payload, *priority)
 	if err != nil {
 		return fmt.Errorf("enqueue job: %w", err)
 	}
@@ -1400,7 +2164,7 @@ func runDaemonInstall(args []string, workspacePath string) error {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	// Install the LaunchAgent
+	// Install the LaunchAgent or systemd user unit
 	if err := daemon.Install(resolved.Workspace, binaryPath); err != nil {
 		finishPayload := map[string]any{
 			"workspace": resolved.Workspace.Root,
@@ -1410,14 +2174,14 @@ func runDaemonInstall(args []string, workspacePath string) error {
 		return err
 	}
 
-	plistPath, _ := daemon.PlistPath(resolved.Workspace.Root)
+	servicePath, _ := daemon.ServicePath(resolved.Workspace.Root)
 	finishPayload := map[string]any{
-		"workspace":  resolved.Workspace.Root,
-		"plist_path": plistPath,
+		"workspace":    resolved.Workspace.Root,
+		"service_path": servicePath,
 	}
 	_ = logger.LogEvent("cli", "daemon_install_finished", finishPayload)
 
-	fmt.Fprintf(os.Stdout, "Installed LaunchAgent: %s\n", plistPath)
+	fmt.Fprintf(os.Stdout, "Installed daemon service: %s\n", servicePath)
 	fmt.Fprintf(os.Stdout, "Next: %s daemon start --workspace %s\n", appName, resolved.Workspace.Root)
 	return nil
 }
@@ -1446,7 +2210,7 @@ func runDaemonUninstall(args []string, workspacePath string) error {
 	// Try to stop first (ignore errors if already stopped)
 	_ = daemon.Stop(resolved.Workspace)
 
-	// Uninstall the LaunchAgent
+	// Uninstall the LaunchAgent or systemd user unit
 	if err := daemon.Uninstall(resolved.Workspace); err != nil {
 		finishPayload := map[string]any{
 			"workspace": resolved.Workspace.Root,
@@ -1461,7 +2225,7 @@ func runDaemonUninstall(args []string, workspacePath string) error {
 	}
 	_ = logger.LogEvent("cli", "daemon_uninstall_finished", finishPayload)
 
-	fmt.Fprintf(os.Stdout, "Uninstalled LaunchAgent for workspace: %s\n", resolved.Workspace.Root)
+	fmt.Fprintf(os.Stdout, "Uninstalled daemon service for workspace: %s\n", resolved.Workspace.Root)
 	return nil
 }
 
@@ -1486,7 +2250,16 @@ func runDaemonStart(args []string, workspacePath string) error {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	// Start the LaunchAgent
+	if running, err := daemon.IsRunning(resolved.Workspace); err == nil && running {
+		_ = logger.LogEvent("cli", "daemon_start_finished", map[string]any{
+			"workspace":       resolved.Workspace.Root,
+			"already_running": true,
+		})
+		fmt.Fprintf(os.Stdout, "Daemon already running for workspace: %s\n", resolved.Workspace.Root)
+		return nil
+	}
+
+	// Start the LaunchAgent or systemd user unit
 	if err := daemon.Start(resolved.Workspace); err != nil {
 		finishPayload := map[string]any{
 			"workspace": resolved.Workspace.Root,
@@ -1527,7 +2300,7 @@ func runDaemonStop(args []string, workspacePath string) error {
 		fmt.Fprintln(os.Stderr, "audit log failed:", err)
 	}
 
-	// Stop the LaunchAgent
+	// Stop the LaunchAgent or systemd user unit
 	if err := daemon.Stop(resolved.Workspace); err != nil {
 		finishPayload := map[string]any{
 			"workspace": resolved.Workspace.Root,
@@ -1550,6 +2323,7 @@ func runDaemonLogs(args []string, workspacePath string) error {
 	fs := flag.NewFlagSet("daemon logs", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	lines := fs.Int("lines", 200, "Number of lines to show")
+	follow := fs.Bool("follow", false, "Keep printing lines as the daemon writes them")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -1570,11 +2344,28 @@ func runDaemonLogs(args []string, workspacePath string) error {
 	}
 
 	// Use tail command to show last N lines
-	cmd := exec.Command("tail", "-n", fmt.Sprintf("%d", *lines), logPath)
+	tailArgs := []string{"-n", fmt.Sprintf("%d", *lines)}
+	if *follow {
+		tailArgs = append(tailArgs, "-f")
+	}
+	cmd := exec.Command("tail", append(tailArgs, logPath)...)
+	if runtime.GOOS == "windows" {
+		// No tail on Windows; Get-Content reads the path from the environment
+		script := fmt.Sprintf("Get-Content -LiteralPath $env:OKRCHESTRA_LOG -Tail %d", *lines)
+		if *follow {
+			script += " -Wait"
+		}
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(os.Environ(), "OKRCHESTRA_LOG="+logPath)
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
+		// --follow ends when the user interrupts tail
+		if _, ok := err.(*exec.ExitError); ok && *follow {
+			return nil
+		}
 		return fmt.Errorf("tail logs: %w", err)
 	}
 
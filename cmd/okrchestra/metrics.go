@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+)
+
+func runMetrics(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s metrics: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "annotate":
+		return runMetricsAnnotate(args[1:], workspacePath)
+	case "annotations":
+		return runMetricsAnnotations(args[1:], workspacePath)
+	case "ingest":
+		return runMetricsIngest(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s metrics: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runMetricsAnnotate(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("metrics annotate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs (default: <workspace>/metrics)")
+	key := fs.String("key", "", "Metric key to annotate (e.g. ci.pass_rate_30d)")
+	date := fs.String("date", time.Now().UTC().Format("2006-01-02"), "Snapshot date the note applies to (YYYY-MM-DD)")
+	note := fs.String("note", "", "Human context for the data point")
+	author := fs.String("author", os.Getenv("USER"), "Annotation author")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{MetricsDir: *metricsDir})
+	if err != nil {
+		return err
+	}
+	snapshotsDir := filepath.Join(resolved.MetricsDir, "snapshots")
+
+	annotation, err := metrics.AddAnnotation(snapshotsDir, metrics.Annotation{
+		Key:    *key,
+		Date:   *date,
+		Note:   *note,
+		Author: *author,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := audit.NewLogger(resolved.AuditDB)
+	payload := map[string]any{
+		"key":    annotation.Key,
+		"date":   annotation.Date,
+		"note":   annotation.Note,
+		"author": annotation.Author,
+		"file":   resolved.Workspace.RelPath(metrics.AnnotationsPath(snapshotsDir)),
+	}
+	if err := logger.LogEvent("cli", "metric_annotated", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Annotated %s on %s: %s\n", annotation.Key, annotation.Date, annotation.Note)
+	return nil
+}
+
+func runMetricsAnnotations(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("metrics annotations", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs (default: <workspace>/metrics)")
+	key := fs.String("key", "", "Only show annotations for this metric key")
+	date := fs.String("date", "", "Only show annotations for this date (YYYY-MM-DD)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{MetricsDir: *metricsDir})
+	if err != nil {
+		return err
+	}
+
+	annotations, err := metrics.LoadAnnotations(filepath.Join(resolved.MetricsDir, "snapshots"))
+	if err != nil {
+		return err
+	}
+	for _, a := range metrics.AnnotationsFor(annotations, *key, *date) {
+		author := ""
+		if a.Author != "" {
+			author = " (" + a.Author + ")"
+		}
+		fmt.Fprintf(os.Stdout, "%s  %s  %s%s\n", a.Date, a.Key, a.Note, author)
+	}
+	return nil
+}
+
+func runMetricsIngest(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("metrics ingest", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs (default: <workspace>/metrics)")
+	file := fs.String("file", "-", "JSON array of metric points, or {\"points\": [...]} (- for stdin)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{MetricsDir: *metricsDir})
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("open metric points: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	points, err := metrics.DecodeIntake(in)
+	if err != nil {
+		return err
+	}
+	intakePath := metrics.IntakePath(resolved.MetricsDir)
+	stored, err := metrics.AppendIntake(intakePath, points, time.Now())
+	if err != nil {
+		return err
+	}
+
+	logger := audit.NewLogger(resolved.AuditDB)
+	payload := map[string]any{
+		"points": len(stored),
+		"file":   resolved.Workspace.RelPath(intakePath),
+		"source": "cli",
+	}
+	if err := logger.LogEvent("cli", "metrics_ingested", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Ingested %d metric point(s) into %s; they will be merged by the next kr measure\n", len(stored), resolved.Workspace.RelPath(intakePath))
+	return nil
+}
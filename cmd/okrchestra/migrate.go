@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/migrate"
+)
+
+func runMigrate(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s migrate: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "paths":
+		return runMigratePaths(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s migrate: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runMigratePaths(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("migrate paths", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dryRun := fs.Bool("dry-run", false, "List artifacts that would change without rewriting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: %s migrate paths [--dry-run]", appName)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+
+	changes, err := migrate.Paths(resolved.Workspace, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		logger := audit.NewLogger(resolved.AuditDB)
+		files := make([]string, 0, len(changes))
+		for _, change := range changes {
+			files = append(files, change.File)
+		}
+		payload := map[string]any{
+			"workspace": resolved.Workspace.Root,
+			"files":     files,
+		}
+		if err := logger.LogEvent("cli", "paths_migrated", payload); err != nil {
+			fmt.Fprintln(os.Stderr, "audit log failed:", err)
+		}
+	}
+
+	verb := "Rewrote"
+	if *dryRun {
+		verb = "Would rewrite"
+	}
+	for _, change := range changes {
+		fmt.Fprintf(os.Stdout, "%s %s (%s)\n", verb, change.File, strings.Join(change.Fields, ", "))
+	}
+	fmt.Fprintf(os.Stdout, "%d artifact(s) with absolute paths\n", len(changes))
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/okrstore"
+)
+
+// okrHistoryListing is one row of okr history.
+type okrHistoryListing struct {
+	Version    string    `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor,omitempty"`
+	ProposalID string    `json:"proposal_id,omitempty"`
+	Files      []string  `json:"files"`
+}
+
+func runOKRHistory(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr history", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "table", "Output format: table or json")
+	diff := fs.String("diff", "", "Show the changes made to okrs/ since this version")
+	rollbackTo := fs.String("rollback", "", "Restore okrs/ files to this version")
+	confirm := fs.Bool("i-understand", false, "Explicitly confirm rewriting OKR files")
+	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
+	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *diff != "" && *rollbackTo != "" {
+		return fmt.Errorf("--diff and --rollback are mutually exclusive")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("--format must be table or json")
+	}
+	if *rollbackTo != "" && !*confirm {
+		return fmt.Errorf("--i-understand flag is required to roll back")
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
+		OKRsDir: *okrsDir,
+		AuditDB: *auditDB,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *diff != "":
+		v, err := okrstore.ReadHistory(resolved.OKRsDir, *diff)
+		if err != nil {
+			return err
+		}
+		text, err := okrstore.DiffHistory(resolved.OKRsDir, v)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			fmt.Fprintf(os.Stdout, "No changes since %s.\n", v.Version)
+			return nil
+		}
+		fmt.Fprint(os.Stdout, text)
+		return nil
+	case *rollbackTo != "":
+		return rollbackOKRHistory(resolved, *rollbackTo)
+	}
+
+	versions, err := okrstore.ListHistory(resolved.OKRsDir)
+	if err != nil {
+		return err
+	}
+	listings := make([]okrHistoryListing, 0, len(versions))
+	for _, v := range versions {
+		l := okrHistoryListing{
+			Version:    v.Version,
+			CreatedAt:  v.CreatedAt,
+			Reason:     v.Reason,
+			Actor:      v.Actor,
+			ProposalID: v.ProposalID,
+			Files:      []string{},
+		}
+		for _, file := range v.Files {
+			l.Files = append(l.Files, file.Path)
+		}
+		listings = append(listings, l)
+	}
+	if *format == "json" {
+		return printListJSON(listings)
+	}
+	if len(listings) == 0 {
+		fmt.Fprintln(os.Stdout, "No OKR history.")
+		return nil
+	}
+	l10n := outputLocale(resolved.Workspace)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCREATED\tREASON\tACTOR\tPROPOSAL\tFILES")
+	for _, l := range listings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", l.Version, l10n.FormatDateTime(l.CreatedAt), l.Reason,
+			dashIfEmpty(l.Actor), dashIfEmpty(l.ProposalID), strings.Join(l.Files, ","))
+	}
+	return w.Flush()
+}
+
+func rollbackOKRHistory(resolved *resolvedWorkspace, version string) error {
+	saved, err := okrstore.RollbackHistory(resolved.OKRsDir, version, "cli")
+	payload := map[string]any{
+		"okrs_dir": resolved.OKRsDir,
+		"version":  version,
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+		_ = audit.NewLogger(resolved.AuditDB).LogEvent("cli", "okr_rollback_finished", payload)
+		return err
+	}
+	files := make([]string, 0, len(saved.Files))
+	for _, file := range saved.Files {
+		files = append(files, file.Path)
+	}
+	payload["files"] = files
+	payload["saved_version"] = saved.Version
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "okr_rollback_finished", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Rolled back %s to %s\n", strings.Join(files, ", "), version)
+	fmt.Fprintf(os.Stdout, "Previous state saved as %s\n", saved.Version)
+	return nil
+}
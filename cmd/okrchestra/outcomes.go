@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/outcomes"
+	"okrchestra/internal/planner"
+)
+
+// addSuccessCriteriaFlags registers --success-delta and --success-within on
+// fs. The returned func yields nil criteria when neither flag is set.
+func addSuccessCriteriaFlags(fs *flag.FlagSet) func() (*planner.SuccessCriteria, error) {
+	delta := fs.Float64("success-delta", 0, "Success criteria: metric delta each item must reach after the run")
+	within := fs.Int("success-within", 0, "Success criteria: days after the run to observe --success-delta")
+	return func() (*planner.SuccessCriteria, error) {
+		if *delta == 0 && *within == 0 {
+			return nil, nil
+		}
+		if *delta <= 0 || *within <= 0 {
+			return nil, fmt.Errorf("--success-delta and --success-within must both be positive")
+		}
+		return &planner.SuccessCriteria{MinDelta: *delta, WithinDays: *within}, nil
+	}
+}
+
+func runPlanOutcomes(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("plan outcomes", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	check := fs.Bool("check", false, "Evaluate pending outcomes against metric snapshots first")
+	agentID := fs.String("agent", outcomes.EvidenceAgentID, "Agent ID proposing evidence for met criteria")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	ws := resolved.effective()
+
+	if *check {
+		changed, err := outcomes.Check(ws, time.Now(), *agentID)
+		if err != nil {
+			return err
+		}
+		logger := audit.NewLogger(resolved.AuditDB)
+		for _, outcome := range changed {
+			payload := map[string]any{
+				"plan_id":     outcome.PlanID,
+				"run_id":      outcome.RunID,
+				"status":      outcome.Status,
+				"proposal_id": outcome.ProposalID,
+			}
+			if outcome.EvidenceError != "" {
+				payload["evidence_error"] = outcome.EvidenceError
+			}
+			if err := logger.LogEvent("cli", "plan_outcome_resolved", payload); err != nil {
+				fmt.Fprintln(os.Stderr, "audit log failed:", err)
+			}
+		}
+		fmt.Fprintf(os.Stdout, "Resolved %d outcome(s)\n", len(changed))
+	}
+
+	tracked, err := outcomes.Load(ws)
+	if err != nil {
+		return err
+	}
+	if len(tracked) == 0 {
+		fmt.Fprintln(os.Stdout, "No plan outcomes tracked yet.")
+		return nil
+	}
+	l10n := outputLocale(ws)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tPLAN\tSTATUS\tMET\tDEADLINE\tPROPOSAL")
+	for _, outcome := range tracked {
+		met := 0
+		for _, item := range outcome.Items {
+			if item.Met {
+				met++
+			}
+		}
+		proposal := outcome.ProposalID
+		if outcome.EvidenceError != "" {
+			proposal = "error: " + outcome.EvidenceError
+		}
+		if proposal == "" {
+			proposal = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", outcome.RunID, outcome.PlanID, outcome.Status, met, len(outcome.Items), l10n.DateString(outcome.Deadline), proposal)
+	}
+	return w.Flush()
+}
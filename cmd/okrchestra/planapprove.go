@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/schema"
+)
+
+func runPlanApprove(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("plan approve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	by := fs.String("by", os.Getenv("USER"), "Approver name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s plan approve [--by name] <plan.json|plan-dir>", appName)
+	}
+
+	resolved, planPath, err := resolvePlanArg(fs.Arg(0), workspacePath)
+	if err != nil {
+		return err
+	}
+	plan, err := planner.LoadPlan(planPath)
+	if err != nil {
+		return err
+	}
+	if plan.Status == planner.PlanApproved {
+		fmt.Fprintf(os.Stdout, "%s is already approved by %s\n", planPath, plan.ApprovedBy)
+		return nil
+	}
+	plan, err = planner.ApprovePlan(planPath, approverName(*by), time.Now())
+	if err != nil {
+		return err
+	}
+	logPlanApproved(resolved, planPath, plan, "plan approve")
+	fmt.Fprintf(os.Stdout, "Approved plan %s: %s\n", plan.ID, planPath)
+	return nil
+}
+
+// runPlanEdit opens a copy of the plan in $VISUAL or $EDITOR and, when the
+// edited copy is a valid plan, writes it back approved. An invalid edit
+// leaves the original untouched.
+func runPlanEdit(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("plan edit", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	by := fs.String("by", os.Getenv("USER"), "Approver name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s plan edit [--by name] <plan.json|plan-dir>", appName)
+	}
+
+	resolved, planPath, err := resolvePlanArg(fs.Arg(0), workspacePath)
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(planPath)
+	if err != nil {
+		return fmt.Errorf("read plan: %w", err)
+	}
+	tmp, err := os.CreateTemp("", "okrchestra-plan-*.json")
+	if err != nil {
+		return fmt.Errorf("create edit file: %w", err)
+	}
+	editPath := tmp.Name()
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(editPath)
+		return fmt.Errorf("write edit file: %w", err)
+	}
+
+	if err := runEditor(editPath); err != nil {
+		os.Remove(editPath)
+		return err
+	}
+	plan, err := validateEditedPlan(editPath)
+	if err != nil {
+		// Keep the edited copy so the changes are not lost.
+		return fmt.Errorf("%w\n%s was not changed; your edits are in %s", err, planPath, editPath)
+	}
+	os.Remove(editPath)
+
+	plan.Status = planner.PlanApproved
+	plan.ApprovedBy = approverName(*by)
+	plan.ApprovedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := planner.WritePlan(planPath, plan); err != nil {
+		return err
+	}
+	logPlanApproved(resolved, planPath, plan, "plan edit")
+	fmt.Fprintf(os.Stdout, "Approved plan %s: %s\n", plan.ID, planPath)
+	return nil
+}
+
+// validateEditedPlan checks the edited file against the plan schema and the
+// planner's own validation.
+func validateEditedPlan(path string) (planner.Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return planner.Plan{}, fmt.Errorf("read edited plan: %w", err)
+	}
+	errs, err := schema.Validate(schema.TypePlan, data)
+	if err != nil {
+		return planner.Plan{}, fmt.Errorf("edited plan: %w", err)
+	}
+	if len(errs) > 0 {
+		lines := make([]string, len(errs))
+		for i, e := range errs {
+			lines[i] = "  " + e.Error()
+		}
+		return planner.Plan{}, fmt.Errorf("edited plan is invalid:\n%s", strings.Join(lines, "\n"))
+	}
+	plan, err := planner.LoadPlan(path)
+	if err != nil {
+		return planner.Plan{}, fmt.Errorf("edited plan is invalid: %w", err)
+	}
+	return plan, nil
+}
+
+// runEditor opens path in $VISUAL, $EDITOR, or vi and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %s: %w", fields[0], err)
+	}
+	return nil
+}
+
+func approverName(by string) string {
+	if strings.TrimSpace(by) == "" {
+		return "cli"
+	}
+	return strings.TrimSpace(by)
+}
+
+func logPlanApproved(resolved *resolvedWorkspace, planPath string, plan planner.Plan, command string) {
+	payload := map[string]any{
+		"plan_id":     plan.ID,
+		"plan_path":   resolved.effective().RelPath(planPath),
+		"approved_by": plan.ApprovedBy,
+		"command":     command,
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "plan_approved", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+}
+
+func resolvePlanArg(arg, workspacePath string) (*resolvedWorkspace, string, error) {
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return nil, "", err
+	}
+	planPath, err := resolved.effective().ResolvePath(arg)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolve plan path: %w", err)
+	}
+	planPath, err = planner.ResolvePlanPath(planPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return resolved, planPath, nil
+}
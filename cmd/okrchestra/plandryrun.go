@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+)
+
+// runPlanDryRun prepares a plan run in artifacts/dry-runs and prints what
+// would be executed. It fails when an item no longer matches the OKRs.
+func runPlanDryRun(resolved *resolvedWorkspace, planPath, adapterName, language string) error {
+	store, err := okrstore.LoadFromDirCached(resolved.OKRsDir, filepath.Join(resolved.ArtifactsDir, "cache"))
+	if err != nil {
+		return fmt.Errorf("load okrs: %w", err)
+	}
+	registry, err := metricRegistry(resolved)
+	if err != nil {
+		return err
+	}
+
+	res, err := planner.DryRunPlan(planner.DryRunOptions{
+		PlanPath:        planPath,
+		Adapter:         adapterName,
+		DryRunBaseDir:   filepath.Join(resolved.ArtifactsDir, "dry-runs"),
+		Store:           store,
+		MetricKnown:     registry.Known,
+		Language:        language,
+		PromptDir:       filepath.Join(resolved.Workspace.Root, planner.PromptDirName),
+		RoleTemplateDir: filepath.Join(resolved.Workspace.Root, planner.RoleTemplateDirName),
+	})
+	if err != nil {
+		return err
+	}
+
+	ws := resolved.Workspace
+	fmt.Fprintf(os.Stdout, "Dry run of plan %s with adapter %s (%d items)\n", res.PlanID, res.Adapter, len(res.Items))
+	for i, item := range res.Items {
+		fmt.Fprintf(os.Stdout, "%d. %s [%s] %s/%s metric %s\n", i+1, item.ItemID, item.AgentRole, item.ObjectiveID, item.KRID, item.MetricKey)
+		if len(item.DependsOn) > 0 {
+			fmt.Fprintf(os.Stdout, "   depends on: %s\n", strings.Join(item.DependsOn, ", "))
+		}
+		if len(item.ScopePaths) > 0 {
+			fmt.Fprintf(os.Stdout, "   scope: %s\n", strings.Join(item.ScopePaths, ", "))
+		}
+		fmt.Fprintf(os.Stdout, "   prompt: %s\n", ws.RelPath(item.PromptPath))
+		for _, problem := range item.Problems {
+			fmt.Fprintf(os.Stdout, "   problem: %s\n", problem)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Prepared item directories in %s; no adapter was run\n", ws.RelPath(res.Dir))
+
+	if n := res.ProblemCount(); n > 0 {
+		return fmt.Errorf("plan %s does not match the current OKRs: %d problem(s)", res.PlanID, n)
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+// runListing is a plan run as `runs list` and `plan status` show it.
+type runListing struct {
+	RunID      string            `json:"run_id"`
+	PlanID     string            `json:"plan_id"`
+	PlanPath   string            `json:"plan_path"`
+	Adapter    string            `json:"adapter"`
+	Status     string            `json:"status"`
+	StartedAt  string            `json:"started_at"`
+	FinishedAt string            `json:"finished_at,omitempty"`
+	Counts     planner.RunCounts `json:"counts"`
+	CostUSD    float64           `json:"cost_usd,omitempty"`
+	RunDir     string            `json:"run_dir"`
+	Summary    string            `json:"summary"`
+	// JobID is the daemon job that ran it, if one did.
+	JobID string `json:"job_id,omitempty"`
+}
+
+// planListing is a generated plan with its runs, newest first, and the
+// daemon jobs waiting to run it.
+type planListing struct {
+	PlanID string          `json:"plan_id"`
+	AsOf   string          `json:"as_of"`
+	Status string          `json:"status"`
+	Items  int             `json:"items"`
+	Path   string          `json:"path"`
+	Runs   []runListing    `json:"runs"`
+	Jobs   []pendingRunJob `json:"jobs,omitempty"`
+}
+
+// pendingRunJob is a queued or running plan_execute job that has no run
+// yet.
+type pendingRunJob struct {
+	JobID       string    `json:"job_id"`
+	Status      string    `json:"status"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+func runRunsList(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("runs list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "table", "Output format: table or json")
+	planID := fs.String("plan", "", "Only list runs of this plan ID")
+	status := fs.String("status", "", "Only list runs with this status (running, succeeded, failed, budget_exceeded)")
+	limit := fs.Int("limit", 20, "List at most this many runs, newest first (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("--format must be table or json, got %q", *format)
+	}
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	ws := resolved.effective()
+	jobs, err := loadPlanJobs(ws)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: daemon jobs:", err)
+	}
+	runs, err := loadRunListings(ws, jobs)
+	if err != nil {
+		return err
+	}
+	var listings []runListing
+	for _, run := range runs {
+		if (*planID != "" && run.PlanID != *planID) || (*status != "" && run.Status != *status) {
+			continue
+		}
+		if *limit > 0 && len(listings) == *limit {
+			break
+		}
+		listings = append(listings, run)
+	}
+
+	if *format == "json" {
+		return printListJSON(listings)
+	}
+	if len(listings) == 0 {
+		fmt.Fprintln(os.Stdout, "No plan runs.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tPLAN\tSTATUS\tITEMS\tCOST\tSTARTED\tJOB\tSUMMARY")
+	for _, run := range listings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", run.RunID, run.PlanID, run.Status, itemCounts(run.Counts), formatRunCost(run.CostUSD), run.StartedAt, orDash(run.JobID), run.Summary)
+	}
+	return tw.Flush()
+}
+
+func runPlanStatus(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("plan status", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "table", "Output format: table or json")
+	limit := fs.Int("limit", 20, "List at most this many plans, or runs of one plan, newest first (0 = all)")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf("usage: %s plan status [plan] [--format table|json] [--limit N]", appName)
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("--format must be table or json, got %q", *format)
+	}
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	ws := resolved.effective()
+	jobs, err := loadPlanJobs(ws)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: daemon jobs:", err)
+	}
+	plans, err := loadPlanListings(ws, jobs)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) == 1 {
+		var match *planListing
+		for i, plan := range plans {
+			if plan.PlanID == positional[0] || filepath.Base(filepath.Dir(plan.Path)) == positional[0] || samePath(ws, plan.Path, positional[0]) {
+				match = &plans[i]
+				break
+			}
+		}
+		if match == nil {
+			return fmt.Errorf("plan not found: %s", positional[0])
+		}
+		if *limit > 0 && len(match.Runs) > *limit {
+			match.Runs = match.Runs[:*limit]
+		}
+		if *format == "json" {
+			return printListJSON(match)
+		}
+		printPlanStatus(*match)
+		return nil
+	}
+	if *limit > 0 && len(plans) > *limit {
+		plans = plans[:*limit]
+	}
+	if *format == "json" {
+		return printListJSON(plans)
+	}
+	if len(plans) == 0 {
+		fmt.Fprintln(os.Stdout, "No plans.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "AS OF\tPLAN\tSTATUS\tITEMS\tRUNS\tLAST RUN\tRESULT\tPATH")
+	for _, plan := range plans {
+		lastRun, result := "-", "-"
+		if len(plan.Runs) > 0 {
+			lastRun = plan.Runs[0].RunID
+			result = plan.Runs[0].Status + " " + itemCounts(plan.Runs[0].Counts)
+		}
+		if len(plan.Jobs) > 0 {
+			result = "job " + plan.Jobs[0].Status
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", plan.AsOf, plan.PlanID, plan.Status, plan.Items, len(plan.Runs), lastRun, result, plan.Path)
+	}
+	return tw.Flush()
+}
+
+func printPlanStatus(plan planListing) {
+	fmt.Fprintf(os.Stdout, "Plan %s (as of %s): %s, %d items\n", plan.PlanID, plan.AsOf, plan.Status, plan.Items)
+	fmt.Fprintf(os.Stdout, "  %s\n", plan.Path)
+	for _, job := range plan.Jobs {
+		fmt.Fprintf(os.Stdout, "Daemon job %s: %s since %s\n", job.JobID, job.Status, job.ScheduledAt.Format(time.RFC3339))
+	}
+	if len(plan.Runs) == 0 {
+		fmt.Fprintln(os.Stdout, "Never run.")
+		return
+	}
+	fmt.Fprintln(os.Stdout)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tSTATUS\tITEMS\tCOST\tSTARTED\tFINISHED\tSUMMARY")
+	for _, run := range plan.Runs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", run.RunID, run.Status, itemCounts(run.Counts), formatRunCost(run.CostUSD), run.StartedAt, orDash(run.FinishedAt), run.Summary)
+	}
+	_ = tw.Flush()
+}
+
+// itemCounts describes a run's items as "3/4 ok, 1 failed".
+func itemCounts(c planner.RunCounts) string {
+	parts := []string{fmt.Sprintf("%d/%d ok", c.Succeeded, c.Total)}
+	if c.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", c.Failed))
+	}
+	if c.Regressed > 0 {
+		parts = append(parts, fmt.Sprintf("%d regressed", c.Regressed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatRunCost(usd float64) string {
+	if usd == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// samePath reports whether the workspace-relative plan path names the
+// plan file or directory arg.
+func samePath(ws *workspace.Workspace, planPath, arg string) bool {
+	abs, err := ws.ResolvePath(arg)
+	if err != nil {
+		return false
+	}
+	if resolved, err := planner.ResolvePlanPath(abs); err == nil {
+		abs = resolved
+	}
+	return ws.RelPath(abs) == planPath
+}
+
+// loadRunListings reads every run's run.json under <artifacts>/runs,
+// newest first. Runs without one, from before it was written, are left
+// out.
+func loadRunListings(ws *workspace.Workspace, jobs *planJobs) ([]runListing, error) {
+	runsDir := filepath.Join(ws.ArtifactsDir, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read runs dir: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	// Run IDs are UTC timestamps, so the newest sort last.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	var listings []runListing
+	for _, name := range names {
+		dir := filepath.Join(runsDir, name)
+		state, err := planner.LoadRunState(dir)
+		if err != nil {
+			continue
+		}
+		listing := runListing{
+			RunID:      state.RunID,
+			PlanID:     state.PlanID,
+			PlanPath:   ws.RelPath(state.PlanPath),
+			Adapter:    state.Adapter,
+			Status:     runStatus(state),
+			StartedAt:  state.StartedAt,
+			FinishedAt: state.FinishedAt,
+			Counts:     state.CountItems(),
+			CostUSD:    state.Usage.CostUSD,
+			RunDir:     ws.RelPath(dir),
+			Summary:    ws.RelPath(filepath.Join(dir, planner.RunStateFileName)),
+		}
+		if job, ok := jobs.byRun[state.RunID]; ok {
+			listing.JobID = job.ID
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// runStatus is the run's recorded status, or for runs recorded before it
+// was, one inferred from its items.
+func runStatus(state *planner.RunState) string {
+	if state.Status != "" {
+		return state.Status
+	}
+	counts := state.CountItems()
+	switch {
+	case counts.Failed > 0:
+		return planner.RunFailed
+	case counts.Running > 0 || counts.Pending > 0:
+		return planner.RunRunning
+	}
+	return planner.RunSucceeded
+}
+
+// loadPlanListings reads every plan under <artifacts>/plans, newest first,
+// with its runs.
+func loadPlanListings(ws *workspace.Workspace, jobs *planJobs) ([]planListing, error) {
+	paths, err := filepath.Glob(filepath.Join(ws.ArtifactsDir, "plans", "*", "plan.json"))
+	if err != nil {
+		return nil, err
+	}
+	// Plan dirs are named by as-of date.
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	runs, err := loadRunListings(ws, jobs)
+	if err != nil {
+		return nil, err
+	}
+	var listings []planListing
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var plan planner.Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %v\n", ws.RelPath(path), err)
+			continue
+		}
+		listing := planListing{
+			PlanID: plan.ID,
+			AsOf:   plan.AsOf,
+			Status: planner.PlanApproved,
+			Items:  len(plan.Items),
+			Path:   ws.RelPath(path),
+			Runs:   []runListing{},
+		}
+		if !plan.Approved() {
+			listing.Status = planner.PlanDraft
+		}
+		for _, run := range runs {
+			if run.PlanPath == listing.Path {
+				listing.Runs = append(listing.Runs, run)
+			}
+		}
+		for _, job := range jobs.waiting {
+			// Jobs without a plan_path run the newest plan.
+			if job.planPath == listing.Path || (job.planPath == "" && i == 0) {
+				listing.Jobs = append(listing.Jobs, pendingRunJob{JobID: job.ID, Status: job.Status, ScheduledAt: job.ScheduledAt})
+			}
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// planJobs are the daemon's plan_execute jobs: those that started a run,
+// by run ID, and those still queued or running without one.
+type planJobs struct {
+	byRun   map[string]daemon.Job
+	waiting []waitingJob
+}
+
+type waitingJob struct {
+	daemon.Job
+	// planPath is the workspace-relative plan the job runs, empty for the
+	// newest plan.
+	planPath string
+}
+
+// planJobListLimit bounds the jobs read from the daemon store.
+const planJobListLimit = 1000
+
+// loadPlanJobs reads plan_execute jobs from the daemon store. A workspace
+// without one has none; the result is never nil.
+func loadPlanJobs(ws *workspace.Workspace) (*planJobs, error) {
+	jobs := &planJobs{byRun: map[string]daemon.Job{}}
+	if _, err := os.Stat(ws.StateDBPath); os.IsNotExist(err) {
+		return jobs, nil
+	}
+	store, err := daemon.Open(ws.StateDBPath)
+	if err != nil {
+		return jobs, err
+	}
+	defer store.Close()
+	all, err := store.ListJobs(planJobListLimit)
+	if err != nil {
+		return jobs, err
+	}
+	for _, job := range all {
+		if job.Type != "plan_execute" {
+			continue
+		}
+		var result struct {
+			RunID string `json:"run_id"`
+		}
+		if job.ResultJSON != "" {
+			_ = json.Unmarshal([]byte(job.ResultJSON), &result)
+		}
+		if result.RunID == "" && job.Status == "running" {
+			if progress, err := store.GetProgress(job.ID); err == nil && progress != nil {
+				result.RunID = progress.RunID
+			}
+		}
+		if result.RunID != "" {
+			jobs.byRun[result.RunID] = job
+			continue
+		}
+		if job.Status != "queued" && job.Status != "running" {
+			continue
+		}
+		var payload struct {
+			PlanPath string `json:"plan_path"`
+		}
+		_ = json.Unmarshal([]byte(job.PayloadJSON), &payload)
+		waiting := waitingJob{Job: job}
+		if payload.PlanPath != "" {
+			if abs, err := ws.ResolvePath(payload.PlanPath); err == nil {
+				if resolved, err := planner.ResolvePlanPath(abs); err == nil {
+					abs = resolved
+				}
+				waiting.planPath = ws.RelPath(abs)
+			}
+		}
+		jobs.waiting = append(jobs.waiting, waiting)
+	}
+	return jobs, nil
+}
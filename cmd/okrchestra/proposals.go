@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/okrstore"
+)
+
+func runOKRProposals(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s okr proposals: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "list":
+		return runOKRProposalsList(args[1:], workspacePath)
+	case "show":
+		return runOKRProposalsShow(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s okr proposals: unknown subcommand %q", appName, args[0])
+	}
+}
+
+// proposalListing is one row of okr proposals list.
+type proposalListing struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	Note      string    `json:"note,omitempty"`
+	Files     []string  `json:"files"`
+	Dir       string    `json:"dir"`
+}
+
+func runOKRProposalsList(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr proposals list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	status := fs.String("status", okrstore.ProposalPending, "Only list proposals with this status (pending, approved, rejected, applied, or all)")
+	format := fs.String("format", "table", "Output format: table or json")
+	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
+	proposalsDir := fs.String("proposals-dir", "", "Directory holding proposals (default: <workspace>/artifacts/proposals)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *status {
+	case "all", okrstore.ProposalPending, okrstore.ProposalApproved, okrstore.ProposalRejected, okrstore.ProposalApplied:
+	default:
+		return fmt.Errorf("--status must be pending, approved, rejected, applied, or all")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("--format must be table or json")
+	}
+
+	resolved, root, err := resolveProposalsDir(workspacePath, *artifactsDir, *proposalsDir)
+	if err != nil {
+		return err
+	}
+	proposals, err := okrstore.ListProposals(resolved.Workspace.Root, root)
+	if err != nil {
+		return err
+	}
+
+	l10n := outputLocale(resolved.Workspace)
+	var listings []proposalListing
+	for _, p := range proposals {
+		if *status != "all" && p.Status != *status {
+			continue
+		}
+		listings = append(listings, proposalListing{
+			ID:        p.ID,
+			AgentID:   p.AgentID,
+			CreatedAt: p.CreatedAt,
+			Status:    p.Status,
+			Note:      p.Note,
+			Files:     p.Files,
+			Dir:       resolved.Workspace.RelPath(p.ProposalDir),
+		})
+	}
+	if *format == "json" {
+		return printListJSON(listings)
+	}
+	if len(listings) == 0 {
+		if *status == "all" {
+			fmt.Fprintln(os.Stdout, "No proposals.")
+		} else {
+			fmt.Fprintf(os.Stdout, "No %s proposals.\n", *status)
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAGENT\tCREATED\tSTATUS\tFILES\tNOTE")
+	for _, l := range listings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", l.ID, l.AgentID, l10n.FormatDateTime(l.CreatedAt), l.Status,
+			strings.Join(l.Files, ","), dashIfEmpty(l.Note))
+	}
+	return w.Flush()
+}
+
+func runOKRReview(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr review", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	proposalPath := fs.String("proposal", "", "Path to proposal directory")
+	approve := fs.Bool("approve", false, "Approve the proposal")
+	reject := fs.Bool("reject", false, "Reject the proposal")
+	reviewer := fs.String("reviewer", "", "ID of the reviewer")
+	comment := fs.String("comment", "", "Optional review comment")
+	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
+	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *proposalPath == "" {
+		return fmt.Errorf("--proposal path is required")
+	}
+	if *approve == *reject {
+		return fmt.Errorf("exactly one of --approve or --reject is required")
+	}
+	if *reviewer == "" {
+		return fmt.Errorf("--reviewer is required")
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
+		ArtifactsDir: *artifactsDir,
+		AuditDB:      *auditDB,
+	})
+	if err != nil {
+		return err
+	}
+	absProposalPath, err := resolved.Workspace.ResolvePath(*proposalPath)
+	if err != nil {
+		return fmt.Errorf("resolve --proposal: %w", err)
+	}
+
+	prior, err := okrstore.ReadProposal(resolved.Workspace.Root, absProposalPath)
+	if err != nil {
+		return err
+	}
+	meta, err := okrstore.ReviewProposal(resolved.Workspace.Root, absProposalPath, *approve, *reviewer, *comment)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"proposal":    absProposalPath,
+		"proposal_id": meta.ID,
+		"agent_id":    meta.AgentID,
+		"reviewer":    *reviewer,
+		"from_status": prior.Status,
+		"status":      meta.Status,
+	}
+	if *comment != "" {
+		payload["comment"] = *comment
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent(*reviewer, "okr_proposal_reviewed", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Proposal %s %s by %s\n", meta.ID, meta.Status, *reviewer)
+	if meta.Status == okrstore.ProposalApproved {
+		fmt.Fprintf(os.Stdout, "Apply with: %s okr apply --proposal %s --i-understand\n", appName, resolved.Workspace.RelPath(absProposalPath))
+	}
+	return nil
+}
+
+func runOKRProposalsShow(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr proposals show", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
+	proposalsDir := fs.String("proposals-dir", "", "Directory holding proposals (default: <workspace>/artifacts/proposals)")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: %s okr proposals show <id|dir>", appName)
+	}
+
+	resolved, root, err := resolveProposalsDir(workspacePath, *artifactsDir, *proposalsDir)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(root, positional[0])
+	if _, err := os.Stat(filepath.Join(dir, "proposal.json")); err != nil {
+		// Not an ID; accept a path to the proposal dir too.
+		if dir, err = resolved.Workspace.ResolvePath(positional[0]); err != nil {
+			return fmt.Errorf("resolve proposal: %w", err)
+		}
+	}
+	meta, err := okrstore.ReadProposal(resolved.Workspace.Root, dir)
+	if err != nil {
+		return fmt.Errorf("proposal %s: %w", positional[0], err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "proposal.json"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, strings.TrimRight(string(data), "\n"))
+	fmt.Fprintln(os.Stdout)
+	if meta.DiffFile == "" {
+		fmt.Fprintln(os.Stdout, "No changes against okrs/.")
+		return nil
+	}
+	diff, err := os.ReadFile(filepath.Join(dir, meta.DiffFile))
+	if err != nil {
+		return fmt.Errorf("read diff: %w", err)
+	}
+	fmt.Fprint(os.Stdout, string(diff))
+	if len(diff) > 0 && diff[len(diff)-1] != '\n' {
+		fmt.Fprintln(os.Stdout)
+	}
+	return nil
+}
+
+// resolveProposalsDir returns the workspace and the directory proposals
+// are read from.
+func resolveProposalsDir(workspacePath, artifactsDir, proposalsDir string) (*resolvedWorkspace, string, error) {
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{ArtifactsDir: artifactsDir})
+	if err != nil {
+		return nil, "", err
+	}
+	if proposalsDir == "" {
+		return resolved, filepath.Join(resolved.ArtifactsDir, "proposals"), nil
+	}
+	dir, err := resolved.Workspace.ResolvePath(proposalsDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolve --proposals-dir: %w", err)
+	}
+	return resolved, dir, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/daemon"
+)
+
+func runDaemonQueue(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s daemon queue: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "export":
+		return runDaemonQueueExport(args[1:], workspacePath)
+	case "import":
+		return runDaemonQueueImport(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s daemon queue: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runDaemonQueueExport(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("daemon queue export", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	store, err := daemon.Open(resolved.Workspace.StateDBPath)
+	if err != nil {
+		return fmt.Errorf("open daemon store: %w", err)
+	}
+	defer store.Close()
+
+	export, err := store.ExportQueue()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal queue export: %w", err)
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+	} else {
+		if err := os.WriteFile(*out, data, 0o600); err != nil {
+			return fmt.Errorf("write queue export: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d job(s) to %s\n", len(export.Jobs), *out)
+	}
+
+	payload := map[string]any{
+		"jobs": len(export.Jobs),
+		"out":  *out,
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "daemon_queue_exported", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	return nil
+}
+
+func runDaemonQueueImport(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("daemon queue import", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	in := fs.String("in", "-", "Queue export file (- for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if *in == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return fmt.Errorf("read queue export: %w", err)
+	}
+	var export daemon.QueueExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("parse queue export: %w", err)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	store, err := daemon.Open(resolved.Workspace.StateDBPath)
+	if err != nil {
+		return fmt.Errorf("open daemon store: %w", err)
+	}
+	defer store.Close()
+
+	result, err := store.ImportQueue(&export)
+	if err != nil {
+		return err
+	}
+	payload := map[string]any{
+		"in":       *in,
+		"imported": result.Imported,
+		"skipped":  result.Skipped,
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "daemon_queue_imported", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+	for _, id := range result.Skipped {
+		fmt.Fprintf(os.Stdout, "Skipped existing job: %s\n", id)
+	}
+	fmt.Fprintf(os.Stdout, "Imported %d job(s), skipped %d\n", len(result.Imported), len(result.Skipped))
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/report"
+)
+
+func runReport(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s report: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "generate":
+		return runReportGenerate(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s report: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runReportGenerate(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("report generate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	days := fs.Int("days", report.DefaultDays, "Number of days the report covers, ending now")
+	withHTML := fs.Bool("html", false, "Also write an HTML version of the report")
+	scope := fs.String("scope", "", "Only report objectives in this scope (org, team, person)")
+	period := fs.String("period", "", "Only report objectives in this OKR period (e.g. 2025-Q3)")
+	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
+	artifactsDir := fs.String("artifacts-dir", "", "Path to artifacts directory (default: <workspace>/artifacts)")
+	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	switch okrstore.Scope(*scope) {
+	case "", okrstore.ScopeOrg, okrstore.ScopeTeam, okrstore.ScopePerson:
+	default:
+		return fmt.Errorf("--scope must be org, team, or person, got %q", *scope)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
+		OKRsDir:      *okrsDir,
+		ArtifactsDir: *artifactsDir,
+		AuditDB:      *auditDB,
+	})
+	if err != nil {
+		return err
+	}
+	r, err := report.Build(report.Options{
+		Workspace: resolved.effective(),
+		Days:      *days,
+		Scope:     okrstore.Scope(*scope),
+		OKRPeriod: *period,
+	})
+	if err != nil {
+		return err
+	}
+	paths, err := report.Write(report.Dir(resolved.ArtifactsDir), r, outputLocale(resolved.Workspace), *withHTML)
+	if err != nil {
+		return err
+	}
+
+	relPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		relPaths = append(relPaths, resolved.Workspace.RelPath(path))
+	}
+	logger := audit.NewLogger(resolved.AuditDB)
+	payload := map[string]any{
+		"since":    r.Since,
+		"until":    r.Until,
+		"files":    relPaths,
+		"runs":     len(r.Runs),
+		"deltas":   len(r.Deltas),
+		"previous": r.PreviousReport,
+	}
+	if err := logger.LogEvent("cli", "report_generated", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	for _, path := range relPaths {
+		fmt.Fprintf(os.Stdout, "Wrote %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/planner"
+)
+
+func runPlanRetro(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("plan retro", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s plan retro <plan.json|plan-dir>", appName)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+	ws := resolved.effective()
+	planPath, err := ws.ResolvePath(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("resolve plan path: %w", err)
+	}
+	planPath, err = planner.ResolvePlanPath(planPath)
+	if err != nil {
+		return err
+	}
+
+	retro, err := planner.BuildRetro(planner.RetroOptions{
+		PlanPath:     planPath,
+		ArtifactsDir: ws.ArtifactsDir,
+		SnapshotsDir: filepath.Join(ws.MetricsDir, "snapshots"),
+	})
+	if err != nil {
+		return err
+	}
+	l10n := outputLocale(ws)
+	jsonPath, mdPath, err := planner.WriteRetro(filepath.Dir(planPath), retro, l10n)
+	if err != nil {
+		return err
+	}
+
+	verdicts := map[string]int{}
+	for _, item := range retro.Items {
+		verdicts[item.Verdict]++
+	}
+	payload := map[string]any{
+		"plan_id":       retro.PlanID,
+		"runs":          retro.Cost.Runs,
+		"item_attempts": retro.Cost.ItemAttempts,
+		"agent_seconds": retro.Cost.AgentSeconds,
+		"verdicts":      verdicts,
+		"retro":         ws.RelPath(jsonPath),
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "plan_retro_written", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tKR\tATTEMPTS\tDELTA\tVERDICT")
+	for _, item := range retro.Items {
+		delta := "-"
+		if item.Delta != nil {
+			delta = l10n.Number(*item.Delta)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.ItemID, item.KRID, l10n.Int(int64(item.Attempts)), delta, item.Verdict)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Runs: %s, agent time: %s\n", l10n.Int(int64(retro.Cost.Runs)), time.Duration(retro.Cost.AgentSeconds*float64(time.Second)).Round(time.Second))
+	fmt.Fprintf(os.Stdout, "Wrote retrospective: %s\n", ws.RelPath(mdPath))
+	return nil
+}
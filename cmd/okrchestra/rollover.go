@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/okrstore"
+)
+
+func runOKRRollover(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr rollover", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	from := fs.String("from", "", "Period to close (e.g. 2025-Q3)")
+	to := fs.String("to", "", "Period to seed (default: the one after --from)")
+	start := fs.String("start", "", "First day of --to (YYYY-MM-DD; default: from its name)")
+	end := fs.String("end", "", "Last day of --to (YYYY-MM-DD; default: from its name)")
+	dropAchieved := fs.Bool("drop-achieved", false, "Leave achieved key results out of the next period")
+	force := fs.Bool("force", false, "Roll over a period that has not ended yet")
+	confirm := fs.Bool("i-understand", false, "Explicitly confirm rewriting OKR files")
+	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
+	auditDB := fs.String("audit-db", "", "Path to audit SQLite DB (default: <workspace>/audit/audit.sqlite)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		return fmt.Errorf("--from period is required")
+	}
+	if *to == "" && (*start != "" || *end != "") {
+		return fmt.Errorf("--start and --end need --to")
+	}
+	if !*confirm {
+		return fmt.Errorf("--i-understand flag is required to roll over")
+	}
+	var next okrstore.Period
+	if *to != "" {
+		var err error
+		if next, err = okrstore.ParsePeriod(*to, *start, *end); err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
+		OKRsDir: *okrsDir,
+		AuditDB: *auditDB,
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := okrstore.Rollover(resolved.OKRsDir, okrstore.RolloverOptions{
+		From:         *from,
+		To:           next,
+		Force:        *force,
+		DropAchieved: *dropAchieved,
+	})
+	payload := map[string]any{
+		"okrs_dir": resolved.OKRsDir,
+		"from":     *from,
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+		_ = audit.NewLogger(resolved.AuditDB).LogEvent("cli", "okr_rollover_finished", payload)
+		return err
+	}
+	payload["to"] = result.To.Name
+	payload["archive_dir"] = resolved.Workspace.RelPath(result.ArchiveDir)
+	payload["files"] = result.Files
+	payload["objectives"] = result.Objectives
+	payload["key_results"] = result.KeyResults
+	if len(result.Dropped) > 0 {
+		payload["dropped"] = result.Dropped
+	}
+	if err := audit.NewLogger(resolved.AuditDB).LogEvent("cli", "okr_rollover_finished", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Archived %s to %s\n", result.From, resolved.Workspace.RelPath(result.ArchiveDir))
+	fmt.Fprintf(os.Stdout, "Seeded %s: %d objective(s), %d key result(s) in %s\n",
+		result.To, result.Objectives, result.KeyResults, strings.Join(result.Files, ", "))
+	if len(result.Dropped) > 0 {
+		fmt.Fprintf(os.Stdout, "Dropped: %s\n", strings.Join(result.Dropped, ", "))
+	}
+	fmt.Fprintln(os.Stdout, "Review the new targets before the period starts.")
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/planner"
+)
+
+func runRuns(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s runs: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "list":
+		return runRunsList(args[1:], workspacePath)
+	case "review":
+		return runRunsReview(args[1:], workspacePath)
+	case "failures":
+		return runRunsFailures(args[1:], workspacePath)
+	default:
+		return fmt.Errorf("%s runs: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runRunsReview(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("runs review", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	approve := fs.Bool("approve", false, "Approve the item output")
+	reject := fs.Bool("reject", false, "Reject the item output (retried in the next plan)")
+	comment := fs.String("comment", "", "Review comment (passed to the retry item on rejection)")
+	reviewer := fs.String("reviewer", os.Getenv("USER"), "Reviewer name")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: %s runs review <run> <item> --approve|--reject [--comment text]", appName)
+	}
+	if *approve == *reject {
+		return fmt.Errorf("exactly one of --approve or --reject is required")
+	}
+	if *reject && strings.TrimSpace(*comment) == "" {
+		return fmt.Errorf("--comment is required when rejecting")
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+
+	runDir, err := resolveRunDir(resolved.ArtifactsDir, positional[0])
+	if err != nil {
+		return err
+	}
+	itemDir, err := planner.ResolveRunItemDir(runDir, positional[1])
+	if err != nil {
+		return err
+	}
+
+	review := planner.Review{
+		RunID:    filepath.Base(runDir),
+		ItemDir:  filepath.Base(itemDir),
+		Decision: planner.ReviewApproved,
+		Comment:  *comment,
+		Reviewer: *reviewer,
+	}
+	if *reject {
+		review.Decision = planner.ReviewRejected
+	}
+	if item, err := planner.LoadRunItem(itemDir); err == nil {
+		review.PlanItemID = item.ID
+		review.ObjectiveID = item.ObjectiveID
+		review.KRID = item.KRID
+	} else if *reject {
+		return fmt.Errorf("cannot reject %s: %w", itemDir, err)
+	}
+
+	reviewPath, err := planner.WriteReview(itemDir, review)
+	if err != nil {
+		return err
+	}
+	refreshArtifactsIndex(resolved.ArtifactsDir, filepath.Dir(itemDir))
+
+	logger := audit.NewLogger(resolved.AuditDB)
+	payload := map[string]any{
+		"run_id":       review.RunID,
+		"item_dir":     itemDir,
+		"plan_item_id": review.PlanItemID,
+		"kr_id":        review.KRID,
+		"decision":     review.Decision,
+		"comment":      review.Comment,
+		"reviewer":     review.Reviewer,
+		"review":       reviewPath,
+	}
+	if err := logger.LogEvent("cli", "run_item_reviewed", payload); err != nil {
+		fmt.Fprintln(os.Stderr, "audit log failed:", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Recorded %s review: %s\n", review.Decision, reviewPath)
+	return nil
+}
+
+func runRunsFailures(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("runs failures", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	class := fs.String("class", "", "Only list failures of this class")
+	list := fs.Bool("list", false, "List each failure, not just counts by class")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf("usage: %s runs failures [run] [--class C] [--list]", appName)
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	runsDir := filepath.Join(resolved.ArtifactsDir, "runs")
+
+	failures, err := planner.LoadFailures(runsDir)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		runDir, err := resolveRunDir(resolved.ArtifactsDir, positional[0])
+		if err != nil {
+			return err
+		}
+		var filtered []planner.ItemFailure
+		for _, failure := range failures {
+			if failure.RunID == filepath.Base(runDir) {
+				filtered = append(filtered, failure)
+			}
+		}
+		failures = filtered
+	}
+	if *class != "" {
+		var filtered []planner.ItemFailure
+		for _, failure := range failures {
+			if string(failure.Class) == *class {
+				filtered = append(filtered, failure)
+			}
+		}
+		failures = filtered
+	}
+
+	if len(failures) == 0 {
+		fmt.Fprintln(os.Stdout, "No failed plan items.")
+		return nil
+	}
+	counts := planner.CountFailures(failures)
+	for _, c := range planner.FailureClasses {
+		if counts[c] > 0 {
+			fmt.Fprintf(os.Stdout, "%-20s %d\n", c, counts[c])
+		}
+	}
+	if *list {
+		fmt.Fprintln(os.Stdout)
+		for _, failure := range failures {
+			fmt.Fprintf(os.Stdout, "%s/%s %s [%s] %s\n", failure.RunID, failure.ItemDir, failure.PlanItemID, failure.Class, failure.Message)
+		}
+	}
+	return nil
+}
+
+// resolveRunDir accepts a run ID under <artifacts>/runs or a path to a run directory.
+func resolveRunDir(artifactsDir, run string) (string, error) {
+	candidate := filepath.Join(artifactsDir, "runs", run)
+	if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+		return candidate, nil
+	}
+	if info, err := os.Stat(run); err == nil && info.IsDir() {
+		return filepath.Abs(run)
+	}
+	return "", fmt.Errorf("run not found: %s", run)
+}
+
+// parseInterspersed parses flags that may appear before, between, or after
+// positional arguments, returning the positionals in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"okrchestra/internal/planner"
+	"okrchestra/internal/schema"
+)
+
+func runSchema(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s schema: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "export":
+		return runSchemaExport(args[1:])
+	default:
+		return fmt.Errorf("%s schema: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runSchemaExport(args []string) error {
+	fs := flag.NewFlagSet("schema export", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	typ := fs.String("type", "", "Schema to export: "+strings.Join(schema.Types, ", "))
+	out := fs.String("out", "", "Write the schema to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *typ == "" {
+		return fmt.Errorf("usage: %s schema export --type %s [--out path]", appName, strings.Join(schema.Types, "|"))
+	}
+	data, err := schema.Export(*typ)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("write schema: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Wrote %s schema to %s\n", *typ, *out)
+	return nil
+}
+
+func runResult(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s result: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "validate":
+		return runSchemaValidate(args[1:], schema.TypeResult)
+	default:
+		return fmt.Errorf("%s result: unknown subcommand %q", appName, args[0])
+	}
+}
+
+func runPlanValidate(args []string, workspacePath string) error {
+	return runSchemaValidate(args, schema.TypePlan)
+}
+
+// fileValidation is the JSON form of `plan validate` and `result validate`.
+type fileValidation struct {
+	Path   string         `json:"path"`
+	Type   string         `json:"type"`
+	Valid  bool           `json:"valid"`
+	Errors []schema.Error `json:"errors"`
+}
+
+// runSchemaValidate validates the file named by the first argument against
+// the schema for typ and fails when it does not match.
+func runSchemaValidate(args []string, typ string) error {
+	fs := flag.NewFlagSet(typ+" validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s %s validate [--format text|json] <path>", appName, typ)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	path := fs.Arg(0)
+	if typ == schema.TypePlan {
+		resolved, err := planner.ResolvePlanPath(path)
+		if err != nil {
+			return err
+		}
+		path = resolved
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", typ, err)
+	}
+	errs, err := schema.Validate(typ, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	// Dependencies between items are beyond the schema; check them once
+	// the shape is right.
+	if len(errs) == 0 && typ == schema.TypePlan {
+		if _, err := planner.LoadPlan(path); err != nil {
+			errs = append(errs, schema.Error{Path: "$", Message: err.Error()})
+		}
+	}
+
+	result := fileValidation{Path: path, Type: typ, Valid: len(errs) == 0, Errors: errs}
+	if result.Errors == nil {
+		result.Errors = []schema.Error{}
+	}
+	if *format == "json" {
+		if err := printListJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stdout, "error %s\n", e.Error())
+		}
+		if result.Valid {
+			fmt.Fprintf(os.Stdout, "%s: valid %s\n", path, typ)
+		}
+	}
+	if !result.Valid {
+		return fmt.Errorf("%s validate failed: %s has %d error(s)", typ, path, len(errs))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"okrchestra/internal/secrets"
+)
+
+func runSecrets(args []string, workspacePath string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return fmt.Errorf("%s secrets: missing subcommand", appName)
+	}
+
+	switch args[0] {
+	case "set":
+		return runSecretsSet(args[1:])
+	case "list":
+		return runSecretsList(args[1:])
+	case "rm":
+		return runSecretsRemove(args[1:])
+	default:
+		return fmt.Errorf("%s secrets: unknown subcommand %q", appName, args[0])
+	}
+}
+
+// runSecretsSet reads the value from stdin so it never appears in shell
+// history or the process list.
+func runSecretsSet(args []string) error {
+	fs := flag.NewFlagSet("secrets set", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: %s secrets set <name> < value", appName)
+	}
+	store, err := secrets.Default()
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", positional[0])
+	}
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("read secret value: %w", err)
+	}
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return fmt.Errorf("secret value is empty")
+	}
+	if err := store.Set(positional[0], value); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Stored secret %s in %s\n", positional[0], store.Path)
+	return nil
+}
+
+func runSecretsList(args []string) error {
+	fs := flag.NewFlagSet("secrets list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store, err := secrets.Default()
+	if err != nil {
+		return err
+	}
+	names, err := store.Names()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stdout, "No secrets stored in %s\n", store.Path)
+		return nil
+	}
+	for _, name := range names {
+		fmt.Fprintln(os.Stdout, name)
+	}
+	return nil
+}
+
+func runSecretsRemove(args []string) error {
+	fs := flag.NewFlagSet("secrets rm", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: %s secrets rm <name>", appName)
+	}
+	store, err := secrets.Default()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(positional[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Removed secret %s\n", positional[0])
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"okrchestra/internal/planner"
+	"okrchestra/internal/stats"
+	"okrchestra/internal/workspace"
+)
+
+// recordUsage counts the invocation in the workspace DB. It is best-effort:
+// uninitialized workspaces are skipped and errors are ignored.
+func recordUsage(workspacePath string, args []string, runErr error) {
+	if stats.Disabled() || workspacePath == "" {
+		return
+	}
+	ws, err := workspace.Resolve(workspacePath)
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(ws.AuditDir); err != nil || !info.IsDir() {
+		return
+	}
+	inv := stats.ParseInvocation(args)
+	inv.User = os.Getenv("USER")
+	inv.Failed = runErr != nil
+	_ = stats.Record(ws.AuditDBPath, inv)
+}
+
+type runStatsSummary struct {
+	Runs     int                          `json:"runs"`
+	Items    int                          `json:"items"`
+	Failures map[planner.FailureClass]int `json:"failures"`
+	Cycles   int                          `json:"cycles"`
+}
+
+func runStats(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	asJSON := fs.Bool("json", false, "Print stats as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+
+	usage, err := stats.Load(resolved.AuditDB)
+	if err != nil {
+		return err
+	}
+	runs, err := collectRunStats(resolved.ArtifactsDir)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(map[string]any{
+			"commands": usage.Commands,
+			"features": usage.Features,
+			"runs":     runs,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal stats: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+
+	l10n := outputLocale(resolved.Workspace)
+	fmt.Fprintf(os.Stdout, "Local usage stats (never transmitted; set %s=1 to stop recording)\n\n", stats.DisableEnv)
+	if len(usage.Commands) == 0 {
+		fmt.Fprintln(os.Stdout, "No commands recorded yet.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMAND\tUSER\tCALLS\tFAILED\tLAST USED")
+		for _, c := range usage.Commands {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Command, c.User, l10n.Int(int64(c.Invocations)), l10n.Int(int64(c.Failures)), l10n.FormatDateTime(c.LastUsed.Local()))
+		}
+		_ = w.Flush()
+	}
+	if len(usage.Features) > 0 {
+		fmt.Fprintln(os.Stdout)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMAND\tFLAG\tUSES")
+		for _, f := range usage.Features {
+			fmt.Fprintf(w, "%s\t--%s\t%s\n", f.Command, f.Feature, l10n.Int(int64(f.Uses)))
+		}
+		_ = w.Flush()
+	}
+
+	fmt.Fprintf(os.Stdout, "\nPlan runs: %d (%d items)\n", runs.Runs, runs.Items)
+	for _, class := range planner.FailureClasses {
+		if n := runs.Failures[class]; n > 0 {
+			fmt.Fprintf(os.Stdout, "  %s: %d\n", class, n)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Cycles: %d\n", runs.Cycles)
+	return nil
+}
+
+// collectRunStats counts plan runs, items, failures, and cycles from artifacts.
+func collectRunStats(artifactsDir string) (runStatsSummary, error) {
+	summary := runStatsSummary{}
+	runsDir := filepath.Join(artifactsDir, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return summary, fmt.Errorf("read runs dir: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		items, err := filepath.Glob(filepath.Join(runsDir, entry.Name(), "item-*"))
+		if err != nil {
+			return summary, fmt.Errorf("scan run items: %w", err)
+		}
+		summary.Runs++
+		summary.Items += len(items)
+	}
+	failures, err := planner.LoadFailures(runsDir)
+	if err != nil {
+		return summary, err
+	}
+	summary.Failures = planner.CountFailures(failures)
+	cycles, err := filepath.Glob(filepath.Join(artifactsDir, "cycles", "*", "cycle.json"))
+	if err != nil {
+		return summary, fmt.Errorf("scan cycles: %w", err)
+	}
+	summary.Cycles = len(cycles)
+	return summary, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// okrStatus is the JSON form of `okr status`.
+type okrStatus struct {
+	AsOf       string                    `json:"as_of,omitempty"`
+	Report     string                    `json:"report,omitempty"`
+	Objectives []metrics.ObjectiveRollup `json:"objectives"`
+}
+
+func runOKRStatus(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr status", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "table", "Output format: table, json, or markdown")
+	scope := fs.String("scope", "", "Only show this scope (org, team, person)")
+	reportPath := fs.String("report", "", "Score report to roll up (default: latest kr_score_*.json in the artifacts dir)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "table", "json", "markdown":
+	default:
+		return fmt.Errorf("--format must be table, json, or markdown, got %q", *format)
+	}
+	switch okrstore.Scope(*scope) {
+	case "", okrstore.ScopeOrg, okrstore.ScopeTeam, okrstore.ScopePerson:
+	default:
+		return fmt.Errorf("--scope must be org, team, or person, got %q", *scope)
+	}
+
+	store, resolved, err := loadListStore(workspacePath)
+	if err != nil {
+		return err
+	}
+	path := *reportPath
+	if path == "" {
+		if path, err = metrics.LatestScoreReportPath(resolved.ArtifactsDir); err != nil {
+			return err
+		}
+	} else if path, err = resolved.Workspace.ResolvePath(path); err != nil {
+		return fmt.Errorf("resolve --report: %w", err)
+	}
+
+	status := okrStatus{}
+	var report *metrics.KRScoreReport
+	if path != "" {
+		if report, err = metrics.LoadScoreReport(path); err != nil {
+			return err
+		}
+		status.AsOf = report.AsOf
+		status.Report = resolved.Workspace.RelPath(path)
+	} else if *format != "json" {
+		fmt.Fprintf(os.Stderr, "No score report found; run `%s kr score` for progress.\n", appName)
+	}
+	status.Objectives = metrics.RollupObjectives(store, report, okrstore.Scope(*scope))
+	if status.Objectives == nil {
+		status.Objectives = []metrics.ObjectiveRollup{}
+	}
+
+	l10n := outputLocale(resolved.Workspace)
+	switch *format {
+	case "json":
+		return printListJSON(status)
+	case "markdown":
+		writeOKRStatusMarkdown(os.Stdout, l10n, status)
+		return nil
+	}
+	if len(status.Objectives) == 0 {
+		fmt.Fprintln(os.Stdout, "No objectives match.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OBJECTIVE\tSCOPE\tPROGRESS\tROLLUP\tKR STATUSES\tMISSING METRICS\tTITLE")
+	for _, o := range status.Objectives {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", o.ObjectiveID, o.Scope, formatPercent(l10n, o.PercentToTarget),
+			formatRollup(l10n, o), formatStatusCounts(o.StatusCounts), dashIfEmpty(strings.Join(o.MissingMetrics, ",")), o.Objective)
+	}
+	return w.Flush()
+}
+
+func writeOKRStatusMarkdown(w io.Writer, l10n locale.Locale, status okrStatus) {
+	fmt.Fprint(w, "# OKR Status")
+	if status.AsOf != "" {
+		fmt.Fprintf(w, " (as of %s)", l10n.DateString(status.AsOf))
+	}
+	fmt.Fprintln(w)
+	for _, o := range status.Objectives {
+		fmt.Fprintf(w, "\n## %s: %s — %s\n\n", o.ObjectiveID, o.Objective, formatPercent(l10n, o.PercentToTarget))
+		fmt.Fprintf(w, "_%s", o.Scope)
+		if o.OwnerID != "" {
+			fmt.Fprintf(w, ", owner %s", o.OwnerID)
+		}
+		fmt.Fprintf(w, "; %s_\n\n", formatStatusCounts(o.StatusCounts))
+		fmt.Fprintln(w, "| KR | Status | Progress | Current / Target | Projected | Description |")
+		fmt.Fprintln(w, "|----|--------|----------|------------------|-----------|-------------|")
+		for _, kr := range o.KeyResults {
+			current := "-"
+			if kr.Current != nil {
+				current = l10n.Number(*kr.Current)
+			}
+			fmt.Fprintf(w, "| %s | %s | %s | %s / %s | %s | %s |\n", kr.KRID, kr.Status, formatPercent(l10n, kr.PercentToTarget),
+				current, l10n.Number(kr.Target), dashIfEmpty(kr.ProjectedStatus), strings.ReplaceAll(kr.Description, "|", `\|`))
+		}
+		if len(o.AlignedKRIDs) > 0 {
+			fmt.Fprintf(w, "\nRollup with aligned KRs (%s): %s\n", strings.Join(o.AlignedKRIDs, ", "), formatPercent(l10n, o.RollupPercentToTarget))
+		}
+		if len(o.MissingMetrics) > 0 {
+			fmt.Fprintf(w, "\nMissing metrics: %s\n", strings.Join(o.MissingMetrics, ", "))
+		}
+	}
+}
+
+func formatPercent(l10n locale.Locale, pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return l10n.Fixed(*pct, 0) + "%"
+}
+
+// formatRollup renders an objective's progress including aligned KRs as
+// "40% (3 aligned)", or "-" when nothing is aligned to it.
+func formatRollup(l10n locale.Locale, o metrics.ObjectiveRollup) string {
+	if len(o.AlignedKRIDs) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%d aligned)", formatPercent(l10n, o.RollupPercentToTarget), len(o.AlignedKRIDs))
+}
+
+// formatStatusCounts renders counts as "achieved:1 in_progress:2".
+func formatStatusCounts(counts map[string]int) string {
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%s:%d", dashIfEmpty(status), counts[status]))
+	}
+	return dashIfEmpty(strings.Join(parts, " "))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"okrchestra/internal/daemon"
+)
+
+// runTick performs one daemon iteration for users who schedule okrchestra
+// from cron instead of running `daemon run`.
+func runTick(args []string, workspacePath string) error {
+	conf, err := loadConfig(workspacePath)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("tick", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	maxJobs := fs.Int("max-jobs", 1, "Run at most N due jobs before exiting")
+	leaseDuration := fs.Duration("lease", conf.Daemon.Lease, "Lease duration for claimed jobs")
+	tz := fs.String("tz", conf.Timezone, "Timezone for scheduling")
+	notifications := fs.Bool("notifications", conf.Notifications.Desktop, "Enable desktop notifications (macOS and Windows)")
+	dashboardURL := fs.String("dashboard-url", conf.Notifications.DashboardURL, "Base URL of the dashboard to link from notifications")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *maxJobs < 0 {
+		return fmt.Errorf("--max-jobs must not be negative")
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	if err := resolved.Workspace.EnsureDirs(); err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	d, err := daemon.New(daemon.Config{
+		Workspace:     resolved.Workspace,
+		StorePath:     resolved.Workspace.StateDBPath,
+		TimeZone:      *tz,
+		LeaseOwner:    fmt.Sprintf("tick-%s-%d", hostname, os.Getpid()),
+		LeaseFor:      *leaseDuration,
+		Notifications: *notifications,
+		DashboardURL:  *dashboardURL,
+	})
+	if err != nil {
+		return fmt.Errorf("create daemon: %w", err)
+	}
+	defer d.Close()
+
+	ran, err := d.Tick(context.Background(), *maxJobs)
+	if err != nil {
+		return err
+	}
+	if len(ran) == 0 {
+		fmt.Fprintln(os.Stdout, "No due jobs.")
+		return nil
+	}
+	failed := 0
+	for _, job := range ran {
+		if job.Error != "" {
+			failed++
+			fmt.Fprintf(os.Stdout, "%s (%s): failed: %s\n", job.ID, job.Type, job.Error)
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%s (%s): succeeded\n", job.ID, job.Type)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d job(s) failed", failed, len(ran))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"okrchestra/internal/tui"
+)
+
+func runUI(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("ui", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	refresh := fs.Duration("refresh", tui.DefaultRefresh, "How often to reload scores, jobs, and runs")
+	once := fs.Bool("once", false, "Print the OKR, job, and run screens once and exit")
+	width := fs.Int("width", 120, "Screen width for --once")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{})
+	if err != nil {
+		return err
+	}
+	src := tui.Source{
+		Workspace:    resolved.effective(),
+		OKRsDir:      resolved.OKRsDir,
+		MetricsDir:   resolved.MetricsDir,
+		ArtifactsDir: resolved.ArtifactsDir,
+	}
+	if *once {
+		return tui.RenderOnce(os.Stdout, src, *width)
+	}
+	return tui.Run(context.Background(), src, os.Stdin, os.Stdout, *refresh)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"okrchestra/internal/config"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// okrValidation is the JSON form of `okr validate`.
+type okrValidation struct {
+	OKRsDir  string             `json:"okrs_dir"`
+	Errors   int                `json:"errors"`
+	Warnings int                `json:"warnings"`
+	Findings []okrstore.Finding `json:"findings"`
+}
+
+func runOKRValidate(args []string, workspacePath string) error {
+	fs := flag.NewFlagSet("okr validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	format := fs.String("format", "text", "Output format: text or json")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	okrsDir := fs.String("okrs-dir", "", "Path to OKR YAML directory (default: <workspace>/okrs)")
+	metricsDir := fs.String("metrics-dir", "", "Base directory for metric inputs (default: <workspace>/metrics)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+
+	resolved, err := resolveWorkspaceAndOverrides(workspacePath, workspaceOverrides{
+		OKRsDir:    *okrsDir,
+		MetricsDir: *metricsDir,
+	})
+	if err != nil {
+		return err
+	}
+	registry, err := metricRegistry(resolved)
+	if err != nil {
+		return err
+	}
+	findings, err := okrstore.Lint(resolved.OKRsDir, okrstore.LintOptions{MetricKnown: registry.Known})
+	if err != nil {
+		return err
+	}
+	findings = append(findings, derivedFindings(resolved, registry)...)
+
+	result := okrValidation{OKRsDir: resolved.Workspace.RelPath(resolved.OKRsDir), Findings: findings}
+	for i := range result.Findings {
+		f := &result.Findings[i]
+		f.File = resolved.Workspace.RelPath(f.File)
+		if f.Severity == okrstore.SeverityError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	if result.Findings == nil {
+		result.Findings = []okrstore.Finding{}
+	}
+
+	if *format == "json" {
+		if err := printListJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range result.Findings {
+			location := f.File
+			if f.Field != "" {
+				location += ": " + f.Field
+			}
+			fmt.Fprintf(os.Stdout, "%s %s [%s] %s\n", f.Severity, location, f.Code, f.Message)
+		}
+		fmt.Fprintf(os.Stdout, "%s: %d error(s), %d warning(s)\n", result.OKRsDir, result.Errors, result.Warnings)
+	}
+
+	if result.Errors > 0 || (*strict && result.Warnings > 0) {
+		return fmt.Errorf("okr validate failed: %d error(s), %d warning(s)", result.Errors, result.Warnings)
+	}
+	return nil
+}
+
+// metricRegistry returns the metric keys known to be produced: those the
+// configured providers can produce, those declared under metrics.keys in
+// okrchestra.yml, the metric catalog, and the latest snapshot.
+func metricRegistry(resolved *resolvedWorkspace) (*metrics.KeyRegistry, error) {
+	root := resolved.Workspace.Root
+	providerCfg := metrics.ProviderConfig{RepoDir: root, MetricsDir: resolved.MetricsDir}
+	if err := providerCfg.LoadWorkspaceConfig(root); err != nil {
+		return nil, err
+	}
+	registry := metrics.NewKeyRegistry(metrics.DefaultProviders(providerCfg))
+	wsCfg, err := config.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range wsCfg.Metrics.Keys {
+		registry.Add(config.FileName, key)
+	}
+	rules, err := okrstore.LoadRules(resolved.OKRsDir)
+	if err != nil {
+		return nil, err
+	}
+	for key := range rules.Metrics {
+		registry.Add("catalog", key)
+	}
+	latest, err := metrics.LatestSnapshotPath(filepath.Join(resolved.MetricsDir, "snapshots"))
+	if err == nil {
+		snapshot, err := metrics.LoadSnapshot(latest)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range snapshot.Points {
+			registry.Add(point.Source, point.Key)
+		}
+	}
+	return registry, nil
+}
+
+// checkPlannedMetrics warns about the KRs plan generate may plan for whose
+// metric_key no configured provider produces, since no plan item could be
+// verified against them; with strict it fails instead.
+func checkPlannedMetrics(resolved *resolvedWorkspace, period, objectiveID, krID string, strict bool) error {
+	store, err := okrstore.LoadFromDirCached(resolved.OKRsDir, filepath.Join(resolved.ArtifactsDir, "cache"))
+	if err != nil {
+		return err
+	}
+	store = store.InPeriod(period)
+	registry, err := metricRegistry(resolved)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for _, docs := range [][]okrstore.Document{store.Org.Documents, store.Team.Documents, store.Person.Documents} {
+		for _, doc := range docs {
+			for _, obj := range doc.Objectives {
+				if objectiveID != "" && obj.ID != objectiveID {
+					continue
+				}
+				for _, kr := range obj.KeyResults {
+					if (krID == "" || kr.ID == krID) && !registry.Known(kr.MetricKey) {
+						unknown = append(unknown, fmt.Sprintf("%s (%s)", kr.ID, kr.MetricKey))
+					}
+				}
+			}
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("no metric provider produces the metric of %s", strings.Join(unknown, ", "))
+	if strict {
+		return errors.New(message)
+	}
+	fmt.Fprintln(os.Stderr, "Warning:", message)
+	return nil
+}
+
+// derivedFindings checks metrics/derived.yml: an invalid spec or cycle is
+// an error, and an input no provider is known to produce is a warning.
+func derivedFindings(resolved *resolvedWorkspace, registry *metrics.KeyRegistry) []okrstore.Finding {
+	path := filepath.Join(resolved.MetricsDir, metrics.DerivedFileName)
+	derived, err := metrics.LoadDerived(path)
+	if err != nil {
+		return []okrstore.Finding{{Code: "derived_invalid", Severity: okrstore.SeverityError, File: path, Message: err.Error()}}
+	}
+	var findings []okrstore.Finding
+	for _, m := range derived {
+		for _, input := range m.Inputs() {
+			if !registry.Known(input) {
+				findings = append(findings, okrstore.Finding{
+					Code:     "derived_input_unknown",
+					Severity: okrstore.SeverityWarning,
+					File:     path,
+					Field:    m.Key,
+					Message:  fmt.Sprintf("no metric provider or catalog entry produces %q, an input of %s", input, m.Key),
+				})
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,95 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tolerance is the absolute difference within which metric values and
+// scores compare equal.
+const Tolerance = 1e-9
+
+// SnapshotPath returns where `kr measure --as-of asOf` writes its snapshot.
+func SnapshotPath(workspace, asOf string) string {
+	return filepath.Join(workspace, "metrics", "snapshots", asOf+".json")
+}
+
+// ScoreReportPath returns where `kr score` writes the report for asOf.
+func ScoreReportPath(workspace, asOf string) string {
+	return filepath.Join(workspace, "artifacts", fmt.Sprintf("kr_score_%s.json", asOf))
+}
+
+// SnapshotMetrics reads a metric snapshot and returns its values by key.
+func SnapshotMetrics(t testing.TB, path string) map[string]float64 {
+	t.Helper()
+	var snapshot struct {
+		Points []struct {
+			Key   string  `json:"key"`
+			Value float64 `json:"value"`
+		} `json:"points"`
+	}
+	readJSON(t, path, &snapshot)
+	values := make(map[string]float64, len(snapshot.Points))
+	for _, point := range snapshot.Points {
+		values[point.Key] = point.Value
+	}
+	return values
+}
+
+// AssertSnapshotMetric fails the test unless the snapshot records key with
+// the wanted value.
+func AssertSnapshotMetric(t testing.TB, path, key string, want float64) {
+	t.Helper()
+	got, ok := SnapshotMetrics(t, path)[key]
+	if !ok {
+		t.Fatalf("snapshot %s has no metric %s", path, key)
+	}
+	if math.Abs(got-want) > Tolerance {
+		t.Fatalf("snapshot %s: %s = %g, want %g", path, key, got, want)
+	}
+}
+
+// KRScores reads a KR score report and returns percent-to-target by KR id.
+func KRScores(t testing.TB, path string) map[string]float64 {
+	t.Helper()
+	var report struct {
+		Results []struct {
+			KRID            string  `json:"kr_id"`
+			PercentToTarget float64 `json:"percent_to_target"`
+		} `json:"results"`
+	}
+	readJSON(t, path, &report)
+	scores := make(map[string]float64, len(report.Results))
+	for _, result := range report.Results {
+		scores[result.KRID] = result.PercentToTarget
+	}
+	return scores
+}
+
+// AssertKRScore fails the test unless the score report gives krID the
+// wanted percent-to-target.
+func AssertKRScore(t testing.TB, path, krID string, wantPercent float64) {
+	t.Helper()
+	got, ok := KRScores(t, path)[krID]
+	if !ok {
+		t.Fatalf("score report %s has no KR %s", path, krID)
+	}
+	if math.Abs(got-wantPercent) > Tolerance {
+		t.Fatalf("score report %s: %s = %g%%, want %g%%", path, krID, got, wantPercent)
+	}
+}
+
+func readJSON(t testing.TB, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+}
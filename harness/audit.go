@@ -1,13 +1,20 @@
-package integration_test
+package harness
 
 import (
 	"database/sql"
+	"path/filepath"
 	"testing"
 
 	_ "modernc.org/sqlite"
 )
 
-func loadAuditTypes(t *testing.T, dbPath string) map[string]int {
+// AuditDBPath returns the default audit database of a workspace.
+func AuditDBPath(workspace string) string {
+	return filepath.Join(workspace, "audit", "audit.sqlite")
+}
+
+// AuditEventCounts returns the number of audit events of each type.
+func AuditEventCounts(t testing.TB, dbPath string) map[string]int {
 	t.Helper()
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
@@ -40,10 +47,12 @@ func loadAuditTypes(t *testing.T, dbPath string) map[string]int {
 	return types
 }
 
-func requireAuditEvents(t *testing.T, dbPath string, want []string) {
+// RequireAuditEvents fails the test unless every event type was logged at
+// least once.
+func RequireAuditEvents(t testing.TB, dbPath string, eventTypes ...string) {
 	t.Helper()
-	types := loadAuditTypes(t, dbPath)
-	for _, eventType := range want {
+	types := AuditEventCounts(t, dbPath)
+	for _, eventType := range eventTypes {
 		if types[eventType] == 0 {
 			t.Fatalf("missing audit event %s in %s", eventType, dbPath)
 		}
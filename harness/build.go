@@ -11,16 +11,21 @@ import (
 	"testing"
 )
 
-var buildOnce sync.Once
-var buildPath string
-var buildErr error
-
 var repoRootOnce sync.Once
 var repoRoot string
 var repoRootErr error
 
+// builds caches compiled binaries by package path for the test process.
+var builds sync.Map // package path -> *build
+
+type build struct {
+	once sync.Once
+	path string
+	err  error
+}
+
 // RepoRoot returns the repository root for the current module.
-func RepoRoot(t *testing.T) string {
+func RepoRoot(t testing.TB) string {
 	t.Helper()
 	root, err := repoRootPath()
 	if err != nil {
@@ -37,7 +42,7 @@ func repoRootPath() (string, error) {
 			return
 		}
 
-		root := filepath.Dir(filepath.Dir(filepath.Dir(file)))
+		root := filepath.Dir(filepath.Dir(file))
 		if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
 			repoRootErr = fmt.Errorf("verify repo root: %w", err)
 			return
@@ -48,33 +53,47 @@ func repoRootPath() (string, error) {
 }
 
 // BuildBinary compiles the okrchestra CLI once per test run and returns the path.
-func BuildBinary(t *testing.T) string {
+func BuildBinary(t testing.TB) string {
+	t.Helper()
+	return buildPackage(t, "./cmd/okrchestra", "okrchestra")
+}
+
+// BuildFakeAgent compiles the fake agent used by FakeAdapter once per test
+// run and returns the path.
+func BuildFakeAgent(t testing.TB) string {
+	t.Helper()
+	return buildPackage(t, "./harness/fakeagent", "fakeagent")
+}
+
+func buildPackage(t testing.TB, pkg, name string) string {
 	t.Helper()
 	root := RepoRoot(t)
 
-	buildOnce.Do(func() {
+	entry, _ := builds.LoadOrStore(pkg, &build{})
+	b := entry.(*build)
+	b.once.Do(func() {
 		dir, err := os.MkdirTemp("", "okrchestra-bin-")
 		if err != nil {
-			buildErr = fmt.Errorf("create temp dir: %w", err)
+			b.err = fmt.Errorf("create temp dir: %w", err)
 			return
 		}
-		outPath := filepath.Join(dir, "okrchestra")
+		outPath := filepath.Join(dir, name)
 
-		cmd := exec.Command("go", "build", "-o", outPath, "./cmd/okrchestra")
+		cmd := exec.Command("go", "build", "-o", outPath, pkg)
 		cmd.Dir = root
 		var stdout bytes.Buffer
 		var stderr bytes.Buffer
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 		if err := cmd.Run(); err != nil {
-			buildErr = fmt.Errorf("go build failed: %w\nstderr:\n%s", err, stderr.String())
+			b.err = fmt.Errorf("go build failed: %w\nstderr:\n%s", err, stderr.String())
 			return
 		}
-		buildPath = outPath
+		b.path = outPath
 	})
 
-	if buildErr != nil {
-		t.Fatalf("build okrchestra binary: %v", buildErr)
+	if b.err != nil {
+		t.Fatalf("build %s binary: %v", name, b.err)
 	}
-	return buildPath
+	return b.path
 }
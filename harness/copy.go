@@ -8,7 +8,7 @@ import (
 )
 
 // CopyDir copies a fixture directory into a destination path.
-func CopyDir(t *testing.T, src, dst string) {
+func CopyDir(t testing.TB, src, dst string) {
 	t.Helper()
 	if err := copyDir(src, dst); err != nil {
 		t.Fatalf("copy dir %s to %s: %v", src, dst, err)
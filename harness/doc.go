@@ -0,0 +1,16 @@
+// Package harness helps test okrchestra and tools that wrap it. It builds
+// the CLI and runs it against throwaway workspaces:
+//
+//	bin := harness.BuildBinary(t)
+//	ws := harness.NewWorkspace(t, harness.MinimalFixture())
+//	stdout, stderr, code := harness.Run(t, bin, ws, []string{"kr", "measure", "--workspace", ws, "--as-of", "2025-01-15"})
+//	harness.AssertSnapshotMetric(t, harness.SnapshotPath(ws, "2025-01-15"), "manual.test_metric", 1.2)
+//	harness.RequireAuditEvents(t, harness.AuditDBPath(ws), "kr_measure_finished")
+//
+// FakeAdapter stands in for a coding agent during plan runs: it serves
+// scripted results to a small agent binary configured as an exec adapter
+// and records every call.
+//
+// The package is supported for use outside this repository; helpers take a
+// testing.TB and fail the test on error.
+package harness
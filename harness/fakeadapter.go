@@ -0,0 +1,146 @@
+package harness
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AgentCall is one agent invocation seen by a FakeAdapter.
+type AgentCall struct {
+	Prompt string `json:"prompt"`
+	// Env holds the OKRCHESTRA_* variables the agent was started with.
+	Env     map[string]string `json:"env"`
+	WorkDir string            `json:"workdir"`
+}
+
+// ItemID returns the plan item the call is for.
+func (c AgentCall) ItemID() string {
+	return c.Env["OKRCHESTRA_PLAN_ITEM_ID"]
+}
+
+// AgentResponse scripts what the fake agent does for a call.
+type AgentResponse struct {
+	// Result is written to result.json. Nil writes DefaultAgentResult;
+	// SkipResult writes nothing, as an agent that crashed would.
+	Result     any  `json:"-"`
+	SkipResult bool `json:"-"`
+	// Transcript is printed to the agent's transcript.log.
+	Transcript string `json:"transcript,omitempty"`
+	ExitCode   int    `json:"exit_code,omitempty"`
+	// Files are written relative to the agent's working directory, e.g. to
+	// simulate code changes.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// DefaultAgentResult is a valid result.json for the call's KR.
+func DefaultAgentResult(call AgentCall) map[string]any {
+	return map[string]any{
+		"schema_version":   "1.0",
+		"summary":          "fake agent run for " + call.ItemID(),
+		"proposed_changes": []string{},
+		"kr_targets":       []string{call.Env["OKRCHESTRA_KR_ID"]},
+		"kr_impact_claim":  "none (fake agent)",
+	}
+}
+
+// FakeAdapter serves scripted agent responses over HTTP to the fakeagent
+// binary, which Install configures as an exec adapter in a workspace.
+type FakeAdapter struct {
+	// Name is the adapter name passed to --adapter (default "fake").
+	Name string
+
+	respond func(AgentCall) AgentResponse
+	server  *httptest.Server
+	mu      sync.Mutex
+	calls   []AgentCall
+}
+
+// NewFakeAdapter starts a fake adapter server that answers each call with
+// respond; a nil respond succeeds with DefaultAgentResult. The server is
+// closed when the test ends.
+func NewFakeAdapter(t testing.TB, respond func(AgentCall) AgentResponse) *FakeAdapter {
+	t.Helper()
+	if respond == nil {
+		respond = func(AgentCall) AgentResponse { return AgentResponse{} }
+	}
+	f := &FakeAdapter{Name: "fake", respond: respond}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", f.handleRun)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// URL returns the server's base URL.
+func (f *FakeAdapter) URL() string {
+	return f.server.URL
+}
+
+// Calls returns the calls received so far, in arrival order.
+func (f *FakeAdapter) Calls() []AgentCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AgentCall(nil), f.calls...)
+}
+
+// Install adds the adapter to <workspace>/adapters.yml, keeping any other
+// adapters defined there, so `--adapter <Name>` runs the fake agent.
+func (f *FakeAdapter) Install(t testing.TB, workspace string) {
+	t.Helper()
+	path := filepath.Join(workspace, "adapters.yml")
+	config := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	adapters, _ := config["adapters"].(map[string]any)
+	if adapters == nil {
+		adapters = map[string]any{}
+	}
+	adapters[f.Name] = map[string]any{
+		"command": []string{BuildFakeAgent(t)},
+		"stdin":   "prompt",
+		"env":     map[string]string{"OKRCHESTRA_FAKE_AGENT_URL": f.URL()},
+		"result":  "file",
+	}
+	config["adapters"] = adapters
+	writeFixtureFile(t, workspace, "adapters.yml", marshalYAML(t, config))
+}
+
+func (f *FakeAdapter) handleRun(w http.ResponseWriter, r *http.Request) {
+	var call AgentCall
+	if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	resp := f.respond(call)
+	body := struct {
+		AgentResponse
+		Result any `json:"result,omitempty"`
+	}{AgentResponse: resp}
+	switch {
+	case resp.SkipResult:
+	case resp.Result != nil:
+		body.Result = resp.Result
+	default:
+		body.Result = DefaultAgentResult(call)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,97 @@
+// Command fakeagent is the agent side of harness.FakeAdapter. It posts the
+// prompt and its OKRCHESTRA_* environment to $OKRCHESTRA_FAKE_AGENT_URL and
+// acts out the scripted response: it writes the response files under the
+// working directory and the result to $OKRCHESTRA_AGENT_RESULT, prints the
+// transcript, and exits with the response's exit code.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const urlEnv = "OKRCHESTRA_FAKE_AGENT_URL"
+
+type call struct {
+	Prompt  string            `json:"prompt"`
+	Env     map[string]string `json:"env"`
+	WorkDir string            `json:"workdir"`
+}
+
+type response struct {
+	Result     json.RawMessage   `json:"result"`
+	Transcript string            `json:"transcript"`
+	ExitCode   int               `json:"exit_code"`
+	Files      map[string]string `json:"files"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "fakeagent:", err)
+		os.Exit(2)
+	}
+}
+
+func run() error {
+	url := os.Getenv(urlEnv)
+	if url == "" {
+		return fmt.Errorf("%s is not set", urlEnv)
+	}
+	prompt, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("read prompt: %w", err)
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	c := call{Prompt: string(prompt), Env: map[string]string{}, WorkDir: workDir}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(key, "OKRCHESTRA_") && key != urlEnv {
+			c.Env[key] = value
+		}
+	}
+
+	body, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(strings.TrimRight(url, "/")+"/run", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("call fake adapter: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("fake adapter returned %s: %s", resp.Status, msg)
+	}
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	for name, contents := range r.Files {
+		path := filepath.Join(workDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			return err
+		}
+	}
+	if resultPath := os.Getenv("OKRCHESTRA_AGENT_RESULT"); resultPath != "" && len(r.Result) > 0 && string(r.Result) != "null" {
+		if err := os.WriteFile(resultPath, append(r.Result, '\n'), 0o644); err != nil {
+			return fmt.Errorf("write result: %w", err)
+		}
+	}
+	fmt.Print(r.Transcript)
+	os.Exit(r.ExitCode)
+	return nil
+}
@@ -0,0 +1,215 @@
+package harness
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture describes a workspace for NewWorkspace. Zero fields are left out,
+// except that okrs/permissions.yml always allows owners to write.
+type Fixture struct {
+	// OKRs maps a file under okrs/ (e.g. "org.yml" or "team/api.yml") to
+	// its contents.
+	OKRs map[string]OKRFile
+	// Metrics are written to metrics/manual.yml.
+	Metrics []Metric
+	// CIReport is written to metrics/ci_report.json.
+	CIReport map[string]float64
+	// Values and Standards are bullet points for culture/values.md and
+	// culture/standards.md.
+	Values    []string
+	Standards []string
+	// Files are extra files, relative to the workspace root.
+	Files map[string]string
+	// Git initializes a git repository with an initial commit.
+	Git bool
+}
+
+// OKRFile is one OKR document.
+type OKRFile struct {
+	Scope      string      `yaml:"scope"`
+	Objectives []Objective `yaml:"objectives"`
+}
+
+// Objective is an objective in an OKRFile.
+type Objective struct {
+	ID         string      `yaml:"objective_id"`
+	Objective  string      `yaml:"objective"`
+	OwnerID    string      `yaml:"owner_id"`
+	KeyResults []KeyResult `yaml:"key_results"`
+}
+
+// KeyResult is a key result. OwnerID defaults to the objective's owner,
+// Confidence to 0.5, Status to in_progress, and Evidence to a fixture
+// reference.
+type KeyResult struct {
+	ID          string   `yaml:"kr_id"`
+	Description string   `yaml:"description"`
+	OwnerID     string   `yaml:"owner_id"`
+	MetricKey   string   `yaml:"metric_key"`
+	Baseline    float64  `yaml:"baseline"`
+	Target      float64  `yaml:"target"`
+	Confidence  float64  `yaml:"confidence"`
+	Status      string   `yaml:"status"`
+	Evidence    []string `yaml:"evidence"`
+}
+
+// Metric is a manual metric value.
+type Metric struct {
+	Key      string   `yaml:"key"`
+	Value    float64  `yaml:"value"`
+	Unit     string   `yaml:"unit,omitempty"`
+	Evidence []string `yaml:"evidence,omitempty"`
+}
+
+const fixturePermissions = `permissions:
+  read:
+    - all
+  write:
+    - owner_id_match
+`
+
+// MinimalFixture is a small valid workspace: an org objective and a team
+// objective, each with one KR backed by a manual metric.
+func MinimalFixture() Fixture {
+	return Fixture{
+		OKRs: map[string]OKRFile{
+			"org.yml": {Scope: "org", Objectives: []Objective{{
+				ID:        "OBJ-TEST-SMOKE",
+				Objective: "Validate OKRchestra smoke workflows.",
+				OwnerID:   "team-test",
+				KeyResults: []KeyResult{{
+					ID:          "KR-TEST-MANUAL",
+					Description: "Keep a manual test metric in range.",
+					MetricKey:   "manual.test_metric",
+					Baseline:    1,
+					Target:      2,
+					Confidence:  0.6,
+				}},
+			}}},
+			"team-test.yml": {Scope: "team", Objectives: []Objective{{
+				ID:        "OBJ-TEAM-HEALTH",
+				Objective: "Maintain healthy team delivery signals.",
+				OwnerID:   "team-test",
+				KeyResults: []KeyResult{{
+					ID:          "KR-TEAM-ONCALL",
+					Description: "Keep on-call coverage above target.",
+					MetricKey:   "manual.oncall_coverage",
+					Baseline:    0.9,
+					Target:      1.0,
+					Confidence:  0.7,
+				}},
+			}}},
+		},
+		Metrics: []Metric{
+			{Key: "manual.test_metric", Value: 1.2, Unit: "count"},
+			{Key: "manual.oncall_coverage", Value: 0.95, Unit: "ratio"},
+		},
+		CIReport:  map[string]float64{"pass_rate_30d": 0.98},
+		Values:    []string{"Clarity over ambiguity.", "Evidence over assumptions."},
+		Standards: []string{"Use explicit paths for repeatable CLI runs.", "Keep changes small and reversible."},
+	}
+}
+
+// NewWorkspace writes fx into a new temporary directory and returns its
+// path.
+func NewWorkspace(t testing.TB, fx Fixture) string {
+	t.Helper()
+	root := t.TempDir()
+	WriteWorkspace(t, root, fx)
+	return root
+}
+
+// WriteWorkspace writes fx into root, replacing files it names.
+func WriteWorkspace(t testing.TB, root string, fx Fixture) {
+	t.Helper()
+	writeFixtureFile(t, root, "okrs/permissions.yml", fixturePermissions)
+
+	names := make([]string, 0, len(fx.OKRs))
+	for name := range fx.OKRs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc := fx.OKRs[name]
+		for i := range doc.Objectives {
+			obj := &doc.Objectives[i]
+			krs := make([]KeyResult, len(obj.KeyResults))
+			for j, kr := range obj.KeyResults {
+				if kr.OwnerID == "" {
+					kr.OwnerID = obj.OwnerID
+				}
+				if kr.Confidence == 0 {
+					kr.Confidence = 0.5
+				}
+				if kr.Status == "" {
+					kr.Status = "in_progress"
+				}
+				if len(kr.Evidence) == 0 {
+					kr.Evidence = []string{"fixture:" + strings.ToLower(kr.ID)}
+				}
+				krs[j] = kr
+			}
+			obj.KeyResults = krs
+		}
+		writeFixtureFile(t, root, filepath.Join("okrs", name), marshalYAML(t, doc))
+	}
+
+	if len(fx.Metrics) > 0 {
+		writeFixtureFile(t, root, "metrics/manual.yml", marshalYAML(t, map[string]any{"metrics": fx.Metrics}))
+	}
+	if len(fx.CIReport) > 0 {
+		data, err := json.MarshalIndent(map[string]any{"metrics": fx.CIReport}, "", "  ")
+		if err != nil {
+			t.Fatalf("marshal ci report: %v", err)
+		}
+		writeFixtureFile(t, root, "metrics/ci_report.json", string(data)+"\n")
+	}
+	if len(fx.Values) > 0 {
+		writeFixtureFile(t, root, "culture/values.md", bulletList("Values", fx.Values))
+	}
+	if len(fx.Standards) > 0 {
+		writeFixtureFile(t, root, "culture/standards.md", bulletList("Standards", fx.Standards))
+	}
+	for name, contents := range fx.Files {
+		writeFixtureFile(t, root, name, contents)
+	}
+	if fx.Git {
+		InitGitRepo(t, root)
+	}
+}
+
+func marshalYAML(t testing.TB, v any) string {
+	t.Helper()
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal fixture: %v", err)
+	}
+	return string(data)
+}
+
+func bulletList(title string, items []string) string {
+	var b strings.Builder
+	b.WriteString("# " + title + "\n\n")
+	for _, item := range items {
+		b.WriteString("- " + item + "\n")
+	}
+	return b.String()
+}
+
+func writeFixtureFile(t testing.TB, root, name, contents string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 // InitGitRepo creates a minimal git repository in dir if one does not exist.
-func InitGitRepo(t *testing.T, dir string) {
+func InitGitRepo(t testing.TB, dir string) {
 	t.Helper()
 
 	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
@@ -25,7 +25,7 @@ func InitGitRepo(t *testing.T, dir string) {
 	runGit(t, dir, "-c", "user.name=okrchestra-test", "-c", "user.email=okrchestra-test@example.com", "commit", "-m", "init")
 }
 
-func runGit(t *testing.T, dir string, args ...string) {
+func runGit(t testing.TB, dir string, args ...string) {
 	t.Helper()
 
 	cmd := exec.Command("git", args...)
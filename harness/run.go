@@ -10,18 +10,18 @@ import (
 )
 
 // Run executes the CLI in the provided working directory.
-func Run(t *testing.T, binPath, workDir string, args []string) (string, string, int) {
+func Run(t testing.TB, binPath, workDir string, args []string) (string, string, int) {
 	t.Helper()
 	return run(t, binPath, workDir, args, nil)
 }
 
 // RunWithEnv executes the CLI with environment overrides.
-func RunWithEnv(t *testing.T, binPath, workDir string, args []string, env map[string]string) (string, string, int) {
+func RunWithEnv(t testing.TB, binPath, workDir string, args []string, env map[string]string) (string, string, int) {
 	t.Helper()
 	return run(t, binPath, workDir, args, env)
 }
 
-func run(t *testing.T, binPath, workDir string, args []string, env map[string]string) (string, string, int) {
+func run(t testing.TB, binPath, workDir string, args []string, env map[string]string) (string, string, int) {
 	t.Helper()
 
 	cmd := exec.Command(binPath, args...)
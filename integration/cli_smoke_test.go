@@ -6,7 +6,7 @@ import (
 	"strings"
 	"testing"
 
-	"okrchestra/integration/harness"
+	"okrchestra/harness"
 )
 
 const testAsOf = "2025-01-15"
@@ -48,10 +48,7 @@ func TestCLISmoke(t *testing.T) {
 	if _, err := os.Stat(auditPath); err != nil {
 		t.Fatalf("audit db not written at %s: %v", auditPath, err)
 	}
-	requireAuditEvents(t, auditPath, []string{
-		"kr_measure_started",
-		"kr_measure_finished",
-	})
+	harness.RequireAuditEvents(t, auditPath, "kr_measure_started", "kr_measure_finished")
 
 	engineSnapshot := filepath.Join(harness.RepoRoot(t), "metrics", "snapshots", testAsOf+".json")
 	if _, err := os.Stat(engineSnapshot); err == nil {
@@ -0,0 +1,95 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"okrchestra/harness"
+)
+
+func TestCycleRunOnceSmoke(t *testing.T) {
+	binPath := harness.BuildBinary(t)
+	workspace := t.TempDir()
+	runDir := t.TempDir()
+
+	fixture := filepath.Join(harness.RepoRoot(t), "integration", "fixtures", "workspace-min")
+	harness.CopyDir(t, fixture, workspace)
+	harness.InitGitRepo(t, workspace)
+
+	args := []string{
+		"cycle", "run-once",
+		"--workspace", workspace,
+		"--as-of", testAsOf,
+		"--adapter", "mock",
+	}
+	stdout, stderr, code := harness.Run(t, binPath, runDir, args)
+	if code != 0 {
+		t.Fatalf("okrchestra cycle run-once exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	report := readCycleReport(t, workspace)
+	if report.Outcome != "awaiting_approval" {
+		t.Fatalf("expected awaiting_approval without --approve, got %q", report.Outcome)
+	}
+
+	args = append(args, "--approve")
+	stdout, stderr, code = harness.Run(t, binPath, runDir, args)
+	if code != 0 {
+		t.Fatalf("okrchestra cycle run-once --approve exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	report = readCycleReport(t, workspace)
+	if report.Outcome == "failed" || report.Outcome == "awaiting_approval" {
+		t.Fatalf("unexpected outcome %q", report.Outcome)
+	}
+	if entries, err := os.ReadDir(filepath.Join(workspace, "artifacts", "cycles")); err != nil || len(entries) != 2 {
+		t.Fatalf("expected a cycle dir per run, got %d (err %v)", len(entries), err)
+	}
+	want := []string{"measure", "score", "generate", "approve", "execute", "remeasure"}
+	if len(report.Steps) != len(want) {
+		t.Fatalf("expected %d steps, got %d", len(want), len(report.Steps))
+	}
+	for i, name := range want {
+		if report.Steps[i].Name != name || report.Steps[i].Status != "succeeded" {
+			t.Fatalf("step %d: expected %s succeeded, got %s %s", i, name, report.Steps[i].Name, report.Steps[i].Status)
+		}
+	}
+
+	harness.RequireAuditEvents(t, harness.AuditDBPath(workspace),
+		"cycle_started",
+		"cycle_finished",
+		"plan_item_started",
+		"plan_item_finished",
+	)
+}
+
+type cycleReport struct {
+	Outcome string `json:"outcome"`
+	Steps   []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"steps"`
+}
+
+// readCycleReport loads the most recent cycle.json under artifacts/cycles.
+func readCycleReport(t *testing.T, workspace string) cycleReport {
+	t.Helper()
+	cyclesDir := filepath.Join(workspace, "artifacts", "cycles")
+	entries, err := os.ReadDir(cyclesDir)
+	if err != nil {
+		t.Fatalf("read cycles dir: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatalf("no cycle directories in %s", cyclesDir)
+	}
+	latest := entries[len(entries)-1].Name()
+	data, err := os.ReadFile(filepath.Join(cyclesDir, latest, "cycle.json"))
+	if err != nil {
+		t.Fatalf("read cycle report: %v", err)
+	}
+	var report cycleReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("parse cycle report: %v", err)
+	}
+	return report
+}
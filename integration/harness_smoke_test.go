@@ -0,0 +1,56 @@
+package integration_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/harness"
+)
+
+func TestHarnessFixtureAndFakeAdapter(t *testing.T) {
+	binPath := harness.BuildBinary(t)
+	workspace := harness.NewWorkspace(t, harness.MinimalFixture())
+	runDir := t.TempDir()
+
+	fake := harness.NewFakeAdapter(t, func(call harness.AgentCall) harness.AgentResponse {
+		return harness.AgentResponse{
+			Transcript: "working on " + call.ItemID() + "\n",
+			Files:      map[string]string{"notes/" + call.ItemID() + ".md": "done\n"},
+		}
+	})
+	fake.Install(t, workspace)
+
+	run := func(args ...string) string {
+		t.Helper()
+		stdout, stderr, code := harness.Run(t, binPath, runDir, append(args, "--workspace", workspace))
+		if code != 0 {
+			t.Fatalf("okrchestra %s exit code %d\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), code, stdout, stderr)
+		}
+		return stdout
+	}
+
+	run("kr", "measure", "--as-of", testAsOf)
+	harness.AssertSnapshotMetric(t, harness.SnapshotPath(workspace, testAsOf), "manual.test_metric", 1.2)
+
+	run("kr", "score")
+	scores := harness.KRScores(t, harness.ScoreReportPath(workspace, testAsOf))
+	if len(scores) != 2 {
+		t.Fatalf("expected 2 scored KRs, got %v", scores)
+	}
+	harness.AssertKRScore(t, harness.ScoreReportPath(workspace, testAsOf), "KR-TEST-MANUAL", 20)
+
+	run("plan", "generate", "--as-of", testAsOf)
+	run("plan", "run", "--adapter", fake.Name, filepath.Join("artifacts", "plans", testAsOf, "plan.json"))
+
+	calls := fake.Calls()
+	if len(calls) == 0 {
+		t.Fatal("fake adapter was not called")
+	}
+	for _, call := range calls {
+		if !strings.Contains(call.Prompt, "# OKRchestra Plan Item") || call.Env["OKRCHESTRA_KR_ID"] == "" {
+			t.Fatalf("unexpected call: %+v", call)
+		}
+	}
+	harness.RequireAuditEvents(t, harness.AuditDBPath(workspace), "plan_run_finished", "plan_item_finished")
+}
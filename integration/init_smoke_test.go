@@ -3,9 +3,10 @@ package integration_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
-	"okrchestra/integration/harness"
+	"okrchestra/harness"
 )
 
 func TestInitSmoke(t *testing.T) {
@@ -49,8 +50,37 @@ func TestInitSmoke(t *testing.T) {
 	if _, err := os.Stat(auditPath); err != nil {
 		t.Fatalf("audit db not written at %s: %v", auditPath, err)
 	}
-	requireAuditEvents(t, auditPath, []string{
-		"workspace_init_started",
-		"workspace_init_finished",
-	})
+	harness.RequireAuditEvents(t, auditPath, "workspace_init_started", "workspace_init_finished")
+}
+
+func TestInitTemplates(t *testing.T) {
+	binPath := harness.BuildBinary(t)
+	runDir := t.TempDir()
+	workspaceRoot := filepath.Join(t.TempDir(), "workspace-platform")
+
+	stdout, stderr, code := harness.Run(t, binPath, runDir, []string{"init", "--workspace", workspaceRoot, "--template", "platform-team"})
+	if code != 0 {
+		t.Fatalf("okrchestra init exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	for _, name := range []string{"okrs/teams/sre.yml", "okrs/.okrs.yml", "schedules.yml", "adapters.yml", "daemon.yml", "metrics/openmetrics/api.prom"} {
+		if _, err := os.Stat(filepath.Join(workspaceRoot, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("platform-team template did not write %s: %v", name, err)
+		}
+	}
+
+	// A user template is copied as-is but must hold valid OKRs.
+	custom := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(custom, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(custom, "okrs", "org.yml"), []byte("scope: galaxy\nobjectives: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, code = harness.Run(t, binPath, runDir, []string{"init", "--workspace", filepath.Join(t.TempDir(), "ws"), "--template", custom})
+	if code == 0 {
+		t.Fatal("init accepted a template with invalid OKRs")
+	}
+	if !strings.Contains(stderr, "template") {
+		t.Fatalf("stderr = %s", stderr)
+	}
 }
@@ -0,0 +1,50 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/harness"
+)
+
+func TestListSmoke(t *testing.T) {
+	binPath := harness.BuildBinary(t)
+	workspace := t.TempDir()
+	runDir := t.TempDir()
+
+	fixture := filepath.Join(harness.RepoRoot(t), "integration", "fixtures", "workspace-min")
+	harness.CopyDir(t, fixture, workspace)
+
+	stdout, stderr, code := harness.Run(t, binPath, runDir, []string{"okr", "list", "--workspace", workspace})
+	if code != 0 {
+		t.Fatalf("okr list exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	for _, id := range []string{"OBJ-TEST-SMOKE", "OBJ-TEAM-HEALTH"} {
+		if !strings.Contains(stdout, id) {
+			t.Fatalf("okr list missing %s:\n%s", id, stdout)
+		}
+	}
+
+	stdout, stderr, code = harness.Run(t, binPath, runDir, []string{"kr", "list", "--workspace", workspace, "--format", "json", "--scope", "team"})
+	if code != 0 {
+		t.Fatalf("kr list exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	var krs []struct {
+		ID     string  `json:"kr_id"`
+		Scope  string  `json:"scope"`
+		Target float64 `json:"target"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &krs); err != nil {
+		t.Fatalf("parse kr list json: %v\n%s", err, stdout)
+	}
+	if len(krs) != 1 || krs[0].ID != "KR-TEAM-ONCALL" || krs[0].Scope != "team" || krs[0].Target != 1 {
+		t.Fatalf("unexpected team KRs: %+v", krs)
+	}
+
+	stdout, _, code = harness.Run(t, binPath, runDir, []string{"kr", "list", "--workspace", workspace, "--status", "achieved"})
+	if code != 0 || !strings.Contains(stdout, "No key results match.") {
+		t.Fatalf("expected no achieved KRs, got exit %d:\n%s", code, stdout)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 	"testing"
 
-	"okrchestra/integration/harness"
+	"okrchestra/harness"
 )
 
 func TestPlanSmoke(t *testing.T) {
@@ -86,14 +86,14 @@ func TestPlanSmoke(t *testing.T) {
 	if _, err := os.Stat(auditPath); err != nil {
 		t.Fatalf("audit db not written at %s: %v", auditPath, err)
 	}
-	requireAuditEvents(t, auditPath, []string{
+	harness.RequireAuditEvents(t, auditPath,
 		"plan_generate_started",
 		"plan_generate_finished",
 		"plan_run_started",
 		"plan_run_finished",
 		"plan_item_started",
 		"plan_item_finished",
-	})
+	)
 
 	enginePlan := filepath.Join(harness.RepoRoot(t), "artifacts", "plans", testAsOf, "plan.json")
 	if _, err := os.Stat(enginePlan); err == nil {
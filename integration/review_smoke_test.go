@@ -0,0 +1,105 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/harness"
+)
+
+func TestRunsReviewRejectionSmoke(t *testing.T) {
+	binPath := harness.BuildBinary(t)
+	workspace := t.TempDir()
+	runDir := t.TempDir()
+
+	fixture := filepath.Join(harness.RepoRoot(t), "integration", "fixtures", "workspace-min")
+	harness.CopyDir(t, fixture, workspace)
+
+	planRel := filepath.Join("artifacts", "plans", testAsOf, "plan.json")
+	generate := func() planFile {
+		t.Helper()
+		args := []string{"plan", "generate", "--workspace", workspace, "--as-of", testAsOf}
+		stdout, stderr, code := harness.Run(t, binPath, runDir, args)
+		if code != 0 {
+			t.Fatalf("plan generate exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+		}
+		return readPlanFile(t, filepath.Join(workspace, planRel))
+	}
+	run := func() string {
+		t.Helper()
+		args := []string{"plan", "run", "--adapter", "mock", "--workspace", workspace, planRel}
+		stdout, stderr, code := harness.Run(t, binPath, runDir, args)
+		if code != 0 {
+			t.Fatalf("plan run exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+		}
+		return latestRunID(t, workspace)
+	}
+
+	if plan := generate(); len(plan.Items) != 1 {
+		t.Fatalf("expected 1 item before review, got %d", len(plan.Items))
+	}
+	runID := run()
+
+	reviewArgs := []string{"runs", "review", runID, "1", "--reject", "--comment", "missing tests", "--workspace", workspace}
+	stdout, stderr, code := harness.Run(t, binPath, runDir, reviewArgs)
+	if code != 0 {
+		t.Fatalf("runs review exit code %d\nstdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "artifacts", "runs", runID, "item-0001", "review.json")); err != nil {
+		t.Fatalf("review.json not written: %v", err)
+	}
+
+	plan := generate()
+	if len(plan.Items) != 2 {
+		t.Fatalf("expected original + retry item, got %d", len(plan.Items))
+	}
+	retry := plan.Items[1]
+	if retry.RetryOf != runID+"/item-0001" || retry.ReviewFeedback != "missing tests" {
+		t.Fatalf("unexpected retry item %+v", retry)
+	}
+
+	// Run IDs have second resolution; avoid reusing the first run dir.
+	time.Sleep(1100 * time.Millisecond)
+	run()
+	if plan := generate(); len(plan.Items) != 1 {
+		t.Fatalf("expected retried rejection to be consumed, got %d items", len(plan.Items))
+	}
+
+	harness.RequireAuditEvents(t, harness.AuditDBPath(workspace), "run_item_reviewed")
+}
+
+type planFile struct {
+	Items []struct {
+		ID             string `json:"id"`
+		RetryOf        string `json:"retry_of"`
+		ReviewFeedback string `json:"review_feedback"`
+	} `json:"items"`
+}
+
+func readPlanFile(t *testing.T, path string) planFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read plan: %v", err)
+	}
+	var plan planFile
+	if err := json.Unmarshal(data, &plan); err != nil {
+		t.Fatalf("parse plan: %v", err)
+	}
+	return plan
+}
+
+func latestRunID(t *testing.T, workspace string) string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(workspace, "artifacts", "runs"))
+	if err != nil {
+		t.Fatalf("read runs dir: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatalf("no runs recorded")
+	}
+	return entries[len(entries)-1].Name()
+}
@@ -11,6 +11,9 @@ type AgentAdapter interface {
 	Run(ctx context.Context, cfg RunConfig) (*RunResult, error)
 }
 
+// LanguageEnv is set in the agent's environment to RunConfig.Language.
+const LanguageEnv = "OKRCHESTRA_LANGUAGE"
+
 // RunConfig configures an agent execution.
 type RunConfig struct {
 	PromptPath   string
@@ -18,6 +21,19 @@ type RunConfig struct {
 	ArtifactsDir string
 	Env          map[string]string
 	Timeout      time.Duration
+	// Language is the workspace language the prompt is written in (ISO
+	// 639-1, e.g. "de"); empty means English.
+	Language string
+	// ResultSchema is the JSON schema the agent's result must follow;
+	// empty means the plan item result.json schema.
+	ResultSchema string
+	// Sandbox, when set, makes Run report the agent's changes outside it
+	// as a *SandboxViolation. Adapters themselves ignore it.
+	Sandbox *Sandbox
+	// Scrubber, when set, makes Run scrub secret values from the
+	// transcript, summary, and result once the agent exits. Adapters
+	// themselves ignore it.
+	Scrubber *Scrubber
 }
 
 // RunResult captures the result of a run.
@@ -26,4 +42,22 @@ type RunResult struct {
 	TranscriptPath string
 	ArtifactsDir   string
 	SummaryPath    string
+	// Usage is nil when the adapter does not measure it.
+	Usage *Usage
+}
+
+// envWithLanguage returns cfg.Env plus LanguageEnv when a language is set.
+// cfg.Env is not modified.
+func (cfg RunConfig) envWithLanguage() map[string]string {
+	if cfg.Language == "" {
+		return cfg.Env
+	}
+	env := make(map[string]string, len(cfg.Env)+1)
+	for key, value := range cfg.Env {
+		env[key] = value
+	}
+	if env[LanguageEnv] == "" {
+		env[LanguageEnv] = cfg.Language
+	}
+	return env
 }
@@ -83,7 +83,11 @@ func (a *CodexAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, erro
 		}
 	}
 	schemaPath := filepath.Join(artifactsDir, "result.schema.json")
-	if err := os.WriteFile(schemaPath, []byte(defaultResultSchema), 0o644); err != nil {
+	schema := defaultResultSchema
+	if cfg.ResultSchema != "" {
+		schema = cfg.ResultSchema
+	}
+	if err := os.WriteFile(schemaPath, []byte(schema), 0o644); err != nil {
 		return nil, fmt.Errorf("write result schema: %w", err)
 	}
 
@@ -109,6 +113,11 @@ func (a *CodexAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, erro
 		ArtifactsDir:   artifactsDir,
 		SummaryPath:    resultPath,
 	}
+	started := time.Now()
+	defer func() {
+		usage := ParseCodexUsage(transcriptPath, time.Since(started))
+		result.Usage = &usage
+	}()
 
 	runOnce := func(env map[string]string) error {
 		transcriptFile, err := os.OpenFile(transcriptPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
@@ -142,6 +151,7 @@ func (a *CodexAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, erro
 		return cmd.Run()
 	}
 
+	cfg.Env = cfg.envWithLanguage()
 	envAttempts := []map[string]string{cfg.Env}
 	if cfg.Env == nil {
 		envAttempts = []map[string]string{nil}
@@ -152,6 +162,11 @@ func (a *CodexAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, erro
 		tryEnv := env
 		for attempt := 0; attempt < 2; attempt++ {
 			if err := runOnce(tryEnv); err != nil {
+				// A killed process reports "signal: killed"; surface the
+				// deadline so callers can tell timeouts from crashes.
+				if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+					err = fmt.Errorf("codex timed out after %s: %w (%w)", cfg.Timeout, context.DeadlineExceeded, err)
+				}
 				lastErr = err
 				result.ExitCode = exitCodeFromError(err)
 
@@ -269,12 +284,12 @@ func indexEnvKey(entry string) int {
 }
 
 func exitCodeFromError(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return 124
+	}
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) {
 		return exitErr.ExitCode()
 	}
-	if errors.Is(err, context.DeadlineExceeded) {
-		return 124
-	}
 	return 1
 }
@@ -0,0 +1,209 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvFileName is the workspace file declaring variables for agent runs.
+const EnvFileName = "env.yml"
+
+// EnvSource is where one variable's value comes from. Exactly one of
+// Value, Env, File, and Keychain is set. Values read from the environment,
+// a file, or the keychain are secrets: they are scrubbed from transcripts,
+// results, and audit events.
+type EnvSource struct {
+	// Value is the literal value; it is not a secret.
+	Value string `yaml:"value"`
+	// Env names a variable in okrchestra's own environment.
+	Env string `yaml:"env"`
+	// File is a file holding the value, relative to the workspace root or
+	// to the home directory with ~/. A trailing newline is dropped.
+	File string `yaml:"file"`
+	// Keychain is a service in the system keychain: the macOS keychain via
+	// security, elsewhere the Secret Service via secret-tool.
+	Keychain string `yaml:"keychain"`
+	// Account narrows a Keychain lookup.
+	Account string `yaml:"account"`
+}
+
+// EnvConfig is <root>/env.yml: variables for every agent run, for runs of
+// one adapter, and for plan items of one agent role:
+//
+//	env:
+//	  LOG_LEVEL: {value: debug}
+//	adapters:
+//	  codex:
+//	    OPENAI_API_KEY: {env: OPENAI_API_KEY}
+//	roles:
+//	  release_engineer:
+//	    NPM_TOKEN: {file: ~/.config/npm/token}
+//	    GITHUB_TOKEN: {keychain: okrchestra-github, account: ci}
+//
+// Role variables override adapter variables, which override env.
+type EnvConfig struct {
+	Env      map[string]EnvSource            `yaml:"env"`
+	Adapters map[string]map[string]EnvSource `yaml:"adapters"`
+	Roles    map[string]map[string]EnvSource `yaml:"roles"`
+
+	root string
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// keychainLookup reads a secret from the system keychain; tests replace it.
+var keychainLookup = func(ctx context.Context, service, account string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		args := []string{"find-generic-password", "-s", service, "-w"}
+		if account != "" {
+			args = append(args, "-a", account)
+		}
+		cmd = exec.CommandContext(ctx, "security", args...)
+	} else {
+		args := []string{"lookup", "service", service}
+		if account != "" {
+			args = append(args, "account", account)
+		}
+		cmd = exec.CommandContext(ctx, "secret-tool", args...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: %w", cmd.Args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// LoadEnv reads <root>/env.yml. It returns nil when the file does not
+// exist, and agents then get no variables from it.
+func LoadEnv(root string) (*EnvConfig, error) {
+	data, err := os.ReadFile(filepath.Join(root, EnvFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", EnvFileName, err)
+	}
+	cfg := &EnvConfig{root: root}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", EnvFileName, err)
+	}
+	check := func(section string, vars map[string]EnvSource) error {
+		for name, src := range vars {
+			if !envNamePattern.MatchString(name) {
+				return fmt.Errorf("%s: %s: invalid variable name %q", EnvFileName, section, name)
+			}
+			if strings.HasPrefix(strings.ToUpper(name), "OKRCHESTRA_") {
+				return fmt.Errorf("%s: %s: %s: OKRCHESTRA_ variables are set by okrchestra", EnvFileName, section, name)
+			}
+			set := 0
+			for _, v := range []string{src.Value, src.Env, src.File, src.Keychain} {
+				if v != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("%s: %s: %s: set exactly one of value, env, file, keychain", EnvFileName, section, name)
+			}
+			if src.Account != "" && src.Keychain == "" {
+				return fmt.Errorf("%s: %s: %s: account requires keychain", EnvFileName, section, name)
+			}
+		}
+		return nil
+	}
+	if err := check("env", cfg.Env); err != nil {
+		return nil, err
+	}
+	for name, vars := range cfg.Adapters {
+		if err := check("adapters."+name, vars); err != nil {
+			return nil, err
+		}
+	}
+	for name, vars := range cfg.Roles {
+		if err := check("roles."+name, vars); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// Resolve reads the variables for a run of adapter for an agent role,
+// which may be empty. Secret values are added to scrub when it is not nil.
+// A nil config resolves to no variables.
+func (c *EnvConfig) Resolve(ctx context.Context, adapter, role string, scrub *Scrubber) (map[string]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+	sources := map[string]EnvSource{}
+	for _, vars := range []map[string]EnvSource{c.Env, c.Adapters[adapter], c.Roles[role]} {
+		for name, src := range vars {
+			sources[name] = src
+		}
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make(map[string]string, len(sources))
+	for _, name := range names {
+		value, secret, err := c.read(ctx, sources[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", EnvFileName, name, err)
+		}
+		env[name] = value
+		if secret {
+			scrub.Add(name, value)
+		}
+	}
+	return env, nil
+}
+
+func (c *EnvConfig) read(ctx context.Context, src EnvSource) (string, bool, error) {
+	switch {
+	case src.Env != "":
+		value, ok := os.LookupEnv(src.Env)
+		if !ok {
+			return "", true, fmt.Errorf("environment variable %s is not set", src.Env)
+		}
+		return value, true, nil
+	case src.File != "":
+		path := src.File
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", true, err
+			}
+			path = filepath.Join(home, path[1:])
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(c.root, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", true, err
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	case src.Keychain != "":
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		value, err := keychainLookup(ctx, src.Keychain, src.Account)
+		if err != nil {
+			return "", true, fmt.Errorf("keychain %s: %w", src.Keychain, err)
+		}
+		return value, true, nil
+	}
+	return src.Value, false, nil
+}
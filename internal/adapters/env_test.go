@@ -0,0 +1,146 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	root := t.TempDir()
+	if cfg, err := LoadEnv(root); err != nil || cfg != nil {
+		t.Fatalf("without env.yml = %+v, %v", cfg, err)
+	}
+	for _, tc := range []struct{ name, config, want string }{
+		{"two sources", "env:\n  TOKEN: {env: A, file: b}\n", "exactly one"},
+		{"no source", "adapters:\n  codex:\n    TOKEN: {}\n", "adapters.codex: TOKEN"},
+		{"reserved", "env:\n  OKRCHESTRA_PLAN_ID: {value: x}\n", "set by okrchestra"},
+		{"bad name", "roles:\n  dev:\n    MY-TOKEN: {value: x}\n", "invalid variable name"},
+		{"account", "env:\n  TOKEN: {env: A, account: me}\n", "account requires keychain"},
+	} {
+		writeTestFile(t, filepath.Join(root, EnvFileName), tc.config)
+		if _, err := LoadEnv(root); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestEnvResolve(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "secrets", "npm"), "npm-secret-token\n")
+	t.Setenv("TEST_OPENAI_KEY", "sk-from-the-environment")
+	lookup := keychainLookup
+	t.Cleanup(func() { keychainLookup = lookup })
+	keychainLookup = func(ctx context.Context, service, account string) (string, error) {
+		if service != "gh" || account != "ci" {
+			return "", errors.New("no such item")
+		}
+		return "ghp-from-the-keychain", nil
+	}
+	config := `env:
+  LOG_LEVEL: {value: debug}
+  REGION: {value: eu}
+adapters:
+  codex:
+    OPENAI_API_KEY: {env: TEST_OPENAI_KEY}
+    REGION: {value: us}
+roles:
+  release_engineer:
+    NPM_TOKEN: {file: secrets/npm}
+    GITHUB_TOKEN: {keychain: gh, account: ci}
+    REGION: {value: ap}
+`
+	writeTestFile(t, filepath.Join(root, EnvFileName), config)
+	cfg, err := LoadEnv(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scrub := NewScrubber()
+	env, err := cfg.Resolve(context.Background(), "codex", "release_engineer", scrub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"LOG_LEVEL":      "debug",
+		"REGION":         "ap",
+		"OPENAI_API_KEY": "sk-from-the-environment",
+		"NPM_TOKEN":      "npm-secret-token",
+		"GITHUB_TOKEN":   "ghp-from-the-keychain",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("env = %v, want %v", env, want)
+	}
+	got := string(scrub.Scrub([]byte("key=sk-from-the-environment token=ghp-from-the-keychain level=debug")))
+	if got != "key=[REDACTED:OPENAI_API_KEY] token=[REDACTED:GITHUB_TOKEN] level=debug" {
+		t.Errorf("scrubbed = %q", got)
+	}
+
+	env, err = cfg.Resolve(context.Background(), "mock", "", nil)
+	if err != nil || !reflect.DeepEqual(env, map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"}) {
+		t.Errorf("mock env = %v, %v", env, err)
+	}
+	os.Unsetenv("TEST_OPENAI_KEY")
+	if _, err := cfg.Resolve(context.Background(), "codex", "", nil); err == nil || !strings.Contains(err.Error(), "TEST_OPENAI_KEY is not set") {
+		t.Errorf("unset variable: err = %v", err)
+	}
+}
+
+func TestScrubber(t *testing.T) {
+	var none *Scrubber
+	if got := none.Scrub([]byte("abc")); string(got) != "abc" {
+		t.Errorf("nil scrubber = %q", got)
+	}
+	scrub := NewScrubber()
+	scrub.Add("SHORT", "abc")
+	scrub.Add("TOKEN", `s3cr"et-value`)
+	scrub.Add("LONG", `s3cr"et-value-2`)
+	data, err := json.Marshal(scrub.Payload(map[string]any{"error": `agent printed s3cr"et-value-2 and s3cr"et-value`, "n": "abc"}))
+	if err != nil || string(data) != `{"error":"agent printed [REDACTED:LONG] and [REDACTED:TOKEN]","n":"abc"}` {
+		t.Errorf("payload = %s, %v", data, err)
+	}
+
+	var out bytes.Buffer
+	w := scrub.Writer(&out)
+	for _, chunk := range []string{"line one s3cr\"e", "t-value\nline two s3", "cr\"et-value"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "line one [REDACTED:TOKEN]\nline two [REDACTED:TOKEN]" {
+		t.Errorf("writer output = %q", out.String())
+	}
+}
+
+func TestRunScrubsOutputs(t *testing.T) {
+	artifactsDir := t.TempDir()
+	scrub := NewScrubber()
+	scrub.Add("API_KEY", "key-1234567890")
+	agent := funcAdapter(func(cfg RunConfig) error {
+		writeTestFile(t, filepath.Join(cfg.ArtifactsDir, "transcript.log"), "using "+cfg.Env["API_KEY"]+"\n")
+		writeTestFile(t, filepath.Join(cfg.ArtifactsDir, "result.json"), `{"summary":"key-1234567890"}`)
+		return nil
+	})
+	cfg := RunConfig{ArtifactsDir: artifactsDir, Env: map[string]string{"API_KEY": "key-1234567890"}, Scrubber: scrub}
+	if _, err := Run(context.Background(), agent, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"transcript.log": "using [REDACTED:API_KEY]\n",
+		"result.json":    `{"summary":"[REDACTED:API_KEY]"}`,
+	} {
+		data, err := os.ReadFile(filepath.Join(artifactsDir, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+}
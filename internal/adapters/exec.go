@@ -0,0 +1,329 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the workspace file defining exec adapters.
+const ConfigFileName = "adapters.yml"
+
+// Result modes for exec adapters.
+const (
+	// ResultFile means the command writes result.json itself, to {{result}}
+	// (also exported as OKRCHESTRA_AGENT_RESULT).
+	ResultFile = "file"
+	// ResultStdout means the command prints result.json on stdout.
+	ResultStdout = "stdout"
+)
+
+// ExecConfig describes how to run an external agent CLI.
+//
+//	adapters:
+//	  my-agent:
+//	    command: ["my-agent", "--cwd", "{{workdir}}", "--out", "{{result}}"]
+//	    stdin: prompt
+//	    env:
+//	      MY_AGENT_TASK: "{{env:OKRCHESTRA_PLAN_ITEM_ID}}"
+//	    result: file
+//
+// Command arguments and env values may use {{prompt}}, {{workdir}},
+// {{artifacts}}, {{result}}, {{transcript}}, {{usage}}, {{language}} (the
+// workspace language, "en" when unset), {{schema}} (a JSON schema file the
+// result must follow, or empty when it is the usual result.json), and
+// {{env:NAME}}.
+//
+// A command may report what it consumed by writing usage JSON
+// (model, input_tokens, output_tokens, total_tokens, cost_usd) to {{usage}},
+// also exported as OKRCHESTRA_AGENT_USAGE.
+type ExecConfig struct {
+	Command []string          `yaml:"command"`
+	Stdin   string            `yaml:"stdin"`
+	Env     map[string]string `yaml:"env"`
+	Result  string            `yaml:"result"`
+}
+
+type execConfigFile struct {
+	Adapters map[string]ExecConfig `yaml:"adapters"`
+}
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z]+)(?::([A-Za-z0-9_]+))?\s*\}\}`)
+	envKeyPattern      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// LoadExecConfigs reads <root>/adapters.yml. A missing file yields no
+// adapters.
+func LoadExecConfigs(root string) (map[string]ExecConfig, error) {
+	path := filepath.Join(root, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]ExecConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ConfigFileName, err)
+	}
+	var file execConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ConfigFileName, err)
+	}
+	names := make([]string, 0, len(file.Adapters))
+	for name := range file.Adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if Default.Has(name) {
+			return nil, fmt.Errorf("%s: adapter %q is registered and cannot be redefined", ConfigFileName, name)
+		}
+		if err := file.Adapters[name].Validate(); err != nil {
+			return nil, fmt.Errorf("%s: adapter %q: %w", ConfigFileName, name, err)
+		}
+	}
+	if file.Adapters == nil {
+		file.Adapters = map[string]ExecConfig{}
+	}
+	return file.Adapters, nil
+}
+
+// Validate checks the command, stdin and result modes, env keys, and
+// placeholders.
+func (c ExecConfig) Validate() error {
+	if len(c.Command) == 0 || strings.TrimSpace(c.Command[0]) == "" {
+		return errors.New("command is required")
+	}
+	switch c.Stdin {
+	case "", "prompt", "none":
+	default:
+		return fmt.Errorf("stdin must be prompt or none, got %q", c.Stdin)
+	}
+	switch c.Result {
+	case "", ResultFile, ResultStdout:
+	default:
+		return fmt.Errorf("result must be %s or %s, got %q", ResultFile, ResultStdout, c.Result)
+	}
+	check := func(s string) error {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+			switch m[1] {
+			case "prompt", "workdir", "artifacts", "result", "transcript", "usage", "language":
+				if m[2] != "" {
+					return fmt.Errorf("placeholder %s takes no argument", m[0])
+				}
+			case "env":
+				if m[2] == "" {
+					return fmt.Errorf("placeholder %s needs a variable name", m[0])
+				}
+			default:
+				return fmt.Errorf("unknown placeholder %s", m[0])
+			}
+		}
+		return nil
+	}
+	for _, arg := range c.Command {
+		if err := check(arg); err != nil {
+			return err
+		}
+	}
+	for key, value := range c.Env {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid env var name %q", key)
+		}
+		if err := check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecAdapter runs an external command configured in adapters.yml.
+type ExecAdapter struct {
+	AdapterName string
+	Config      ExecConfig
+}
+
+// FromWorkspace returns the exec adapter named in <root>/adapters.yml.
+func FromWorkspace(root, name string) (AgentAdapter, error) {
+	configs, err := LoadExecConfigs(root)
+	if err != nil {
+		return nil, err
+	}
+	cfg, ok := configs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown adapter: %s (not registered and not defined in %s)", name, ConfigFileName)
+	}
+	return &ExecAdapter{AdapterName: name, Config: cfg}, nil
+}
+
+func (a *ExecAdapter) Name() string {
+	return a.AdapterName
+}
+
+func (a *ExecAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, error) {
+	if err := a.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.WorkDir == "" {
+		return nil, errors.New("workdir is required")
+	}
+	if cfg.ArtifactsDir == "" {
+		return nil, errors.New("artifacts dir is required")
+	}
+	workDir, err := filepath.Abs(cfg.WorkDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workdir: %w", err)
+	}
+	artifactsDir, err := filepath.Abs(cfg.ArtifactsDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve artifacts dir: %w", err)
+	}
+	if err := os.MkdirAll(artifactsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create artifacts dir: %w", err)
+	}
+
+	transcriptPath := filepath.Join(artifactsDir, "transcript.log")
+	resultPath := filepath.Join(artifactsDir, "result.json")
+	usagePath := filepath.Join(artifactsDir, "usage.json")
+	if override := cfg.Env["OKRCHESTRA_AGENT_RESULT"]; override != "" {
+		resultPath = override
+	}
+	promptPath := cfg.PromptPath
+	if promptPath != "" {
+		if promptPath, err = filepath.Abs(promptPath); err != nil {
+			return nil, fmt.Errorf("resolve prompt path: %w", err)
+		}
+	}
+
+	language := cfg.Language
+	if language == "" {
+		language = "en"
+	}
+	schemaPath := ""
+	if cfg.ResultSchema != "" {
+		schemaPath = filepath.Join(artifactsDir, "result.schema.json")
+		if err := os.WriteFile(schemaPath, []byte(cfg.ResultSchema), 0o644); err != nil {
+			return nil, fmt.Errorf("write result schema: %w", err)
+		}
+	}
+	values := map[string]string{
+		"prompt":     promptPath,
+		"workdir":    workDir,
+		"artifacts":  artifactsDir,
+		"result":     resultPath,
+		"transcript": transcriptPath,
+		"usage":      usagePath,
+		"language":   language,
+		"schema":     schemaPath,
+	}
+	expand := func(s string) string {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(ph string) string {
+			m := placeholderPattern.FindStringSubmatch(ph)
+			if m[1] == "env" {
+				if v, ok := cfg.Env[m[2]]; ok {
+					return v
+				}
+				return os.Getenv(m[2])
+			}
+			return values[m[1]]
+		})
+	}
+	args := make([]string, len(a.Config.Command))
+	for i, arg := range a.Config.Command {
+		args[i] = expand(arg)
+	}
+	env := map[string]string{"OKRCHESTRA_AGENT_RESULT": resultPath, "OKRCHESTRA_AGENT_USAGE": usagePath}
+	for key, value := range cfg.envWithLanguage() {
+		env[key] = value
+	}
+	for key, value := range a.Config.Env {
+		env[key] = expand(value)
+	}
+
+	runCtx := ctx
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	transcriptFile, err := os.OpenFile(transcriptPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer func() {
+		_ = transcriptFile.Close()
+	}()
+
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = mergeEnv(os.Environ(), env)
+	var stdout bytes.Buffer
+	if a.Config.Result == ResultStdout {
+		cmd.Stdout = io.MultiWriter(transcriptFile, &stdout)
+	} else {
+		cmd.Stdout = transcriptFile
+	}
+	cmd.Stderr = transcriptFile
+	if a.Config.Stdin != "none" && promptPath != "" {
+		promptFile, err := os.Open(promptPath)
+		if err != nil {
+			return nil, fmt.Errorf("open prompt: %w", err)
+		}
+		defer func() {
+			_ = promptFile.Close()
+		}()
+		cmd.Stdin = promptFile
+	}
+
+	result := &RunResult{
+		TranscriptPath: transcriptPath,
+		ArtifactsDir:   artifactsDir,
+		SummaryPath:    resultPath,
+	}
+	// A usage.json left by an earlier attempt must not be counted again.
+	_ = os.Remove(usagePath)
+	started := time.Now()
+	err = cmd.Run()
+	result.Usage = readReportedUsage(usagePath, time.Since(started))
+	if err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%s timed out after %s: %w (%w)", a.AdapterName, cfg.Timeout, context.DeadlineExceeded, err)
+		}
+		result.ExitCode = exitCodeFromError(err)
+		return result, err
+	}
+	if a.Config.Result == ResultStdout {
+		if err := os.WriteFile(resultPath, stdout.Bytes(), 0o644); err != nil {
+			return result, fmt.Errorf("write result: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// readReportedUsage returns the usage a command wrote to path, with the
+// measured duration. A missing or unreadable file reports duration only.
+func readReportedUsage(path string, duration time.Duration) *Usage {
+	usage := Usage{}
+	if data, err := os.ReadFile(path); err == nil {
+		if json.Unmarshal(data, &usage) != nil {
+			usage = Usage{}
+		}
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	usage.DurationSeconds = duration.Seconds()
+	return &usage
+}
@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecAdapterFromWorkspace(t *testing.T) {
+	root := t.TempDir()
+	config := `adapters:
+  file-agent:
+    command: ["sh", "-c", "cat > \"$PROMPT_COPY\"; printf '{\"summary\":\"%s\"}' \"$ITEM\" > {{result}}"]
+    env:
+      PROMPT_COPY: "{{artifacts}}/prompt-copy.md"
+      ITEM: "{{env:OKRCHESTRA_PLAN_ITEM_ID}}"
+  stdout-agent:
+    command: ["sh", "-c", "echo working >&2; echo '{\"summary\":\"from stdout\"}'"]
+    stdin: none
+    result: stdout
+  language-agent:
+    command: ["sh", "-c", "printf '{\"summary\":\"%s/%s\"}' {{language}} \"$OKRCHESTRA_LANGUAGE\""]
+    stdin: none
+    result: stdout
+`
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	promptPath := filepath.Join(root, "prompt.md")
+	if err := os.WriteFile(promptPath, []byte("do the thing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name        string
+		language    string
+		wantResult  string
+		wantOutFile string
+	}{
+		{name: "file-agent", wantResult: `{"summary":"ITEM-7"}`, wantOutFile: "prompt-copy.md"},
+		{name: "stdout-agent", wantResult: `{"summary":"from stdout"}`},
+		{name: "language-agent", language: "de", wantResult: `{"summary":"de/de"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := FromWorkspace(root, tc.name)
+			if err != nil {
+				t.Fatalf("FromWorkspace: %v", err)
+			}
+			artifactsDir := filepath.Join(root, tc.name)
+			res, err := adapter.Run(context.Background(), RunConfig{
+				PromptPath:   promptPath,
+				WorkDir:      root,
+				ArtifactsDir: artifactsDir,
+				Env:          map[string]string{"OKRCHESTRA_PLAN_ITEM_ID": "ITEM-7"},
+				Language:     tc.language,
+			})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			data, err := os.ReadFile(res.SummaryPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(data)); got != tc.wantResult {
+				t.Fatalf("result.json = %s, want %s", got, tc.wantResult)
+			}
+			if tc.wantOutFile != "" {
+				copied, err := os.ReadFile(filepath.Join(artifactsDir, tc.wantOutFile))
+				if err != nil || string(copied) != "do the thing\n" {
+					t.Fatalf("prompt not piped to stdin: %q, %v", copied, err)
+				}
+			}
+		})
+	}
+
+	if _, err := FromWorkspace(root, "missing"); err == nil || !strings.Contains(err.Error(), "unknown adapter") {
+		t.Fatalf("missing adapter err = %v", err)
+	}
+}
+
+func TestLoadExecConfigsValidates(t *testing.T) {
+	cases := map[string]string{
+		"adapters:\n  codex:\n    command: [x]\n":                           "is registered",
+		"adapters:\n  a:\n    command: []\n":                                "command is required",
+		"adapters:\n  a:\n    command: [x, '{{bogus}}']\n":                  "unknown placeholder",
+		"adapters:\n  a:\n    command: [x]\n    result: transcript\n":       "result must be",
+		"adapters:\n  a:\n    command: [x]\n    env:\n      'BAD-KEY': v\n": "invalid env var",
+	}
+	for config, want := range cases {
+		root := t.TempDir()
+		if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadExecConfigs(root); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("config %q: err = %v, want %q", config, err, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPriceKey is the pricing entry used for models without their own.
+const DefaultPriceKey = "default"
+
+// ModelPrice is what a model charges, in USD per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `yaml:"input_per_million" json:"input_per_million"`
+	OutputPerMillion float64 `yaml:"output_per_million" json:"output_per_million"`
+}
+
+// Pricing maps model names to prices, read from the pricing section of
+// adapters.yml:
+//
+//	pricing:
+//	  gpt-5-codex:
+//	    input_per_million: 1.25
+//	    output_per_million: 10
+//	  default:
+//	    input_per_million: 3
+//	    output_per_million: 15
+//
+// The default entry prices models without their own entry, including runs
+// whose adapter does not report a model.
+type Pricing map[string]ModelPrice
+
+type pricingFile struct {
+	Pricing Pricing `yaml:"pricing"`
+}
+
+// LoadPricing reads the pricing section of <root>/adapters.yml. A missing
+// file or section yields empty pricing, which estimates nothing.
+func LoadPricing(root string) (Pricing, error) {
+	data, err := os.ReadFile(filepath.Join(root, ConfigFileName))
+	if os.IsNotExist(err) {
+		return Pricing{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ConfigFileName, err)
+	}
+	var file pricingFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ConfigFileName, err)
+	}
+	for model, price := range file.Pricing {
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			return nil, fmt.Errorf("%s: pricing for %q must not be negative", ConfigFileName, model)
+		}
+	}
+	if file.Pricing == nil {
+		file.Pricing = Pricing{}
+	}
+	return file.Pricing, nil
+}
+
+// Estimate returns the USD cost of u, and false when no price applies.
+// When only a total token count is known it is priced at the output rate,
+// so estimates err on the high side.
+func (p Pricing) Estimate(u Usage) (float64, bool) {
+	price, ok := p[u.Model]
+	if !ok || u.Model == "" {
+		price, ok = p[DefaultPriceKey]
+	}
+	if !ok {
+		return 0, false
+	}
+	if u.InputTokens == 0 && u.OutputTokens == 0 {
+		return float64(u.TotalTokens) * price.OutputPerMillion / 1e6, true
+	}
+	return (float64(u.InputTokens)*price.InputPerMillion + float64(u.OutputTokens)*price.OutputPerMillion) / 1e6, true
+}
+
+// Apply fills in u.CostUSD from p unless the adapter already reported a
+// cost.
+func (p Pricing) Apply(u *Usage) {
+	if u == nil || u.CostUSD > 0 {
+		return
+	}
+	if cost, ok := p.Estimate(*u); ok {
+		u.CostUSD = cost
+	}
+}
@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPricing(t *testing.T) {
+	root := t.TempDir()
+	config := `adapters:
+  metered-agent:
+    command: ["sh", "-c", "echo '{\"model\":\"small\",\"input_tokens\":1000,\"output_tokens\":500}' > {{usage}}; echo '{}'"]
+    stdin: none
+    result: stdout
+pricing:
+  big:
+    input_per_million: 10
+    output_per_million: 30
+  default:
+    input_per_million: 1
+    output_per_million: 2
+`
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pricing, err := LoadPricing(root)
+	if err != nil {
+		t.Fatalf("LoadPricing: %v", err)
+	}
+
+	adapter, err := FromWorkspace(root, "metered-agent")
+	if err != nil {
+		t.Fatalf("FromWorkspace: %v", err)
+	}
+	res, err := adapter.Run(context.Background(), RunConfig{WorkDir: root, ArtifactsDir: filepath.Join(root, "out")})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Usage == nil || res.Usage.Model != "small" || res.Usage.TotalTokens != 1500 {
+		t.Fatalf("reported usage = %+v", res.Usage)
+	}
+	pricing.Apply(res.Usage)
+	if math.Abs(res.Usage.CostUSD-0.002) > 1e-9 {
+		t.Fatalf("default-priced cost = %v, want 0.002", res.Usage.CostUSD)
+	}
+
+	cases := []struct {
+		usage Usage
+		want  float64
+	}{
+		{Usage{Model: "big", InputTokens: 1_000_000, OutputTokens: 100_000}, 13},
+		{Usage{Model: "big", TotalTokens: 1_000_000}, 30},
+		{Usage{TotalTokens: 500_000}, 1},
+	}
+	for _, tc := range cases {
+		got, ok := pricing.Estimate(tc.usage)
+		if !ok || math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("Estimate(%+v) = %v, %v; want %v", tc.usage, got, ok, tc.want)
+		}
+	}
+	if _, ok := (Pricing{}).Estimate(Usage{TotalTokens: 10}); ok {
+		t.Fatal("expected empty pricing to estimate nothing")
+	}
+
+	reported := &Usage{Model: "big", TotalTokens: 1_000_000, CostUSD: 0.5}
+	pricing.Apply(reported)
+	if reported.CostUSD != 0.5 {
+		t.Fatalf("Apply overrode the adapter's cost: %v", reported.CostUSD)
+	}
+}
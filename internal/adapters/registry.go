@@ -0,0 +1,111 @@
+package adapters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates a fresh adapter for one run.
+type Factory func() (AgentAdapter, error)
+
+// Registry maps adapter names to factories.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds a named adapter. Names are unique; registering a name twice
+// is an error.
+func (r *Registry) Register(name string, factory Factory) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("adapter name is required")
+	}
+	if factory == nil {
+		return fmt.Errorf("adapter %q: factory is required", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("adapter %q is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Lookup creates the named adapter.
+func (r *Registry) Lookup(name string) (AgentAdapter, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown adapter: %s (registered: %s)", name, strings.Join(r.Names(), ", "))
+	}
+	adapter, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("create adapter %s: %w", name, err)
+	}
+	return adapter, nil
+}
+
+// Has reports whether name is registered.
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.factories[name]
+	return ok
+}
+
+// Names lists the registered adapters, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default holds the built-in adapters plus any registered by code compiled
+// into the binary, e.g. from an init func in a file added to cmd/okrchestra.
+var Default = NewRegistry()
+
+func init() {
+	MustRegister("codex", func() (AgentAdapter, error) { return &CodexAdapter{}, nil })
+	MustRegister("mock", func() (AgentAdapter, error) { return &MockAdapter{}, nil })
+}
+
+// Register adds an adapter to Default.
+func Register(name string, factory Factory) error {
+	return Default.Register(name, factory)
+}
+
+// MustRegister is Register for init funcs; it panics on error.
+func MustRegister(name string, factory Factory) {
+	if err := Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup creates an adapter from Default.
+func Lookup(name string) (AgentAdapter, error) {
+	return Default.Lookup(name)
+}
+
+// Resolve returns the named adapter for a workspace: a registered adapter
+// if there is one, else an exec adapter from <root>/adapters.yml.
+func Resolve(root, name string) (AgentAdapter, error) {
+	if Default.Has(name) {
+		return Default.Lookup(name)
+	}
+	return FromWorkspace(root, name)
+}
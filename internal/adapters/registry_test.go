@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type namedAdapter struct{ name string }
+
+func (a *namedAdapter) Name() string { return a.name }
+
+func (a *namedAdapter) Run(context.Context, RunConfig) (*RunResult, error) { return &RunResult{}, nil }
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	factory := func() (AgentAdapter, error) { return &namedAdapter{name: "custom"}, nil }
+	if err := r.Register("custom", factory); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := r.Register("custom", factory); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("duplicate Register error = %v", err)
+	}
+	if err := r.Register(" ", factory); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+	if err := r.Register("nil", nil); err == nil {
+		t.Fatal("expected error for nil factory")
+	}
+
+	adapter, err := r.Lookup("custom")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if adapter.Name() != "custom" {
+		t.Fatalf("Name() = %q", adapter.Name())
+	}
+	if _, err := r.Lookup("missing"); err == nil || !strings.Contains(err.Error(), "registered: custom") {
+		t.Fatalf("Lookup(missing) error = %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	config := "adapters:\n  my-agent:\n    command: [\"true\"]\n"
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"codex": "codex", "mock": "mock", "my-agent": "my-agent"} {
+		adapter, err := Resolve(root, name)
+		if err != nil {
+			t.Fatalf("Resolve(%s): %v", name, err)
+		}
+		if adapter.Name() != want {
+			t.Fatalf("Resolve(%s).Name() = %q", name, adapter.Name())
+		}
+	}
+	if _, err := Resolve(root, "nope"); err == nil || !strings.Contains(err.Error(), "unknown adapter") {
+		t.Fatalf("Resolve(nope) error = %v", err)
+	}
+}
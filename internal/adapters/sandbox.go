@@ -0,0 +1,379 @@
+package adapters
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSandboxKey is the sandbox entry used for adapters without their
+// own.
+const DefaultSandboxKey = "default"
+
+// SandboxConfig is an adapter's entry in the sandbox section of
+// adapters.yml:
+//
+//	sandbox:
+//	  default:
+//	    deny: [".git", ".env"]
+//	  codex:
+//	    watch: ["..", "~/.ssh"]
+//	    allow: [".", "~/.codex"]
+//	    deny: [".git/hooks"]
+//
+// Paths are relative to the work dir, or to the home directory with ~/.
+// Watch defaults to the work dir and allow to the work dir.
+type SandboxConfig struct {
+	// Watch are the directories compared before and after a run.
+	Watch []string `yaml:"watch"`
+	// Allow are the paths under which the agent may change files.
+	Allow []string `yaml:"allow"`
+	// Deny are paths the agent may not change, even under Allow.
+	Deny []string `yaml:"deny"`
+}
+
+type sandboxFile struct {
+	Sandbox map[string]SandboxConfig `yaml:"sandbox"`
+}
+
+// LoadSandbox reads the sandbox for adapter from <root>/adapters.yml: its
+// own entry, else the default entry. It returns nil when neither exists, and
+// runs are then not sandboxed.
+func LoadSandbox(root, adapter string) (*SandboxConfig, error) {
+	data, err := os.ReadFile(filepath.Join(root, ConfigFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ConfigFileName, err)
+	}
+	var file sandboxFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ConfigFileName, err)
+	}
+	for name, cfg := range file.Sandbox {
+		for _, p := range append(append(append([]string{}, cfg.Watch...), cfg.Allow...), cfg.Deny...) {
+			if strings.TrimSpace(p) == "" {
+				return nil, fmt.Errorf("%s: sandbox for %q: paths must not be empty", ConfigFileName, name)
+			}
+		}
+	}
+	cfg, ok := file.Sandbox[adapter]
+	if !ok {
+		cfg, ok = file.Sandbox[DefaultSandboxKey]
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &cfg, nil
+}
+
+// Resolve makes the config's paths absolute for a run in workDir. Paths in
+// ignore, such as files okrchestra itself writes during the run, are never
+// checked.
+func (c SandboxConfig) Resolve(workDir string, ignore ...string) (*Sandbox, error) {
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workdir: %w", err)
+	}
+	home, homeErr := os.UserHomeDir()
+	resolve := func(paths []string, fallback ...string) ([]string, error) {
+		if len(paths) == 0 {
+			paths = fallback
+		}
+		out := make([]string, 0, len(paths))
+		for _, p := range paths {
+			p = strings.TrimSpace(p)
+			switch {
+			case p == "~" || strings.HasPrefix(p, "~/"):
+				if homeErr != nil {
+					return nil, fmt.Errorf("sandbox path %s: %w", p, homeErr)
+				}
+				p = filepath.Join(home, p[1:])
+			case !filepath.IsAbs(p):
+				p = filepath.Join(workDir, p)
+			}
+			out = append(out, filepath.Clean(p))
+		}
+		return out, nil
+	}
+	s := &Sandbox{}
+	if s.Watch, err = resolve(c.Watch, "."); err != nil {
+		return nil, err
+	}
+	if s.Allow, err = resolve(c.Allow, "."); err != nil {
+		return nil, err
+	}
+	if s.Deny, err = resolve(c.Deny); err != nil {
+		return nil, err
+	}
+	for _, p := range ignore {
+		if p == "" {
+			continue
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", p, err)
+		}
+		s.Ignore = append(s.Ignore, abs)
+	}
+	return s, nil
+}
+
+// Sandbox confines the files an agent run may change. It does not stop
+// the agent; Run compares the watched directories before and after the
+// run and reports what changed outside Allow or inside Deny.
+type Sandbox struct {
+	Watch  []string `json:"watch"`
+	Allow  []string `json:"allow"`
+	Deny   []string `json:"deny,omitempty"`
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// SandboxChange is a path an agent changed: added, modified, or deleted.
+type SandboxChange struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// SandboxViolation is returned by Run when the agent changed paths its
+// sandbox does not allow. It wraps the adapter's own error, if any.
+type SandboxViolation struct {
+	Sandbox *Sandbox
+	Changes []SandboxChange
+	// TreeBefore and TreeAfter hash the watched directories' file
+	// listings before and after the run.
+	TreeBefore string
+	TreeAfter  string
+	Err        error
+}
+
+func (v *SandboxViolation) Error() string {
+	paths := make([]string, 0, 3)
+	for _, c := range v.Changes {
+		if len(paths) == cap(paths) {
+			paths = append(paths, "...")
+			break
+		}
+		paths = append(paths, c.Path)
+	}
+	return fmt.Sprintf("agent changed %d path(s) outside its sandbox: %s", len(v.Changes), strings.Join(paths, ", "))
+}
+
+func (v *SandboxViolation) Unwrap() error {
+	return v.Err
+}
+
+// Run runs adapter with cfg. When cfg.Sandbox is set, changes the sandbox
+// does not allow are returned as a *SandboxViolation. cfg.ArtifactsDir is
+// always allowed. When cfg.Scrubber is set, secrets are scrubbed from the
+// run's output files.
+func Run(ctx context.Context, adapter AgentAdapter, cfg RunConfig) (*RunResult, error) {
+	result, err := runSandboxed(ctx, adapter, cfg)
+	if cfg.Scrubber.Empty() {
+		return result, err
+	}
+	files := []string{filepath.Join(cfg.ArtifactsDir, "transcript.log"), filepath.Join(cfg.ArtifactsDir, "result.json")}
+	if path := cfg.Env["OKRCHESTRA_AGENT_RESULT"]; path != "" {
+		files = append(files, path)
+	}
+	if result != nil {
+		files = append(files, result.TranscriptPath, result.SummaryPath)
+	}
+	seen := map[string]bool{}
+	for _, path := range files {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if scrubErr := cfg.Scrubber.ScrubFile(path); scrubErr != nil {
+			err = errors.Join(err, scrubErr)
+		}
+	}
+	return result, err
+}
+
+func runSandboxed(ctx context.Context, adapter AgentAdapter, cfg RunConfig) (*RunResult, error) {
+	if cfg.Sandbox == nil {
+		return adapter.Run(ctx, cfg)
+	}
+	box := *cfg.Sandbox
+	if cfg.ArtifactsDir != "" {
+		if abs, err := filepath.Abs(cfg.ArtifactsDir); err == nil {
+			box.Ignore = append(append([]string{}, box.Ignore...), abs)
+		}
+	}
+	before, err := box.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot sandbox: %w", err)
+	}
+	result, runErr := adapter.Run(ctx, cfg)
+	after, err := box.snapshot()
+	if err != nil {
+		return result, errors.Join(runErr, fmt.Errorf("snapshot sandbox: %w", err))
+	}
+	treeBefore, treeAfter := treeHash(before), treeHash(after)
+	if treeBefore == treeAfter {
+		return result, runErr
+	}
+	var changes []SandboxChange
+	for path, state := range after {
+		if prev, ok := before[path]; !ok {
+			changes = append(changes, SandboxChange{Path: path, Change: "added"})
+		} else if prev != state {
+			changes = append(changes, SandboxChange{Path: path, Change: "modified"})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, SandboxChange{Path: path, Change: "deleted"})
+		}
+	}
+	violations := changes[:0]
+	for _, c := range changes {
+		if box.denied(c.Path) || !box.allowed(c.Path) {
+			violations = append(violations, c)
+		}
+	}
+	if len(violations) == 0 {
+		return result, runErr
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return result, &SandboxViolation{
+		Sandbox:    cfg.Sandbox,
+		Changes:    violations,
+		TreeBefore: treeBefore,
+		TreeAfter:  treeAfter,
+		Err:        runErr,
+	}
+}
+
+// snapshot lists every watched path that could be a violation with its
+// mode, size, and modification time. Subtrees the agent may change freely
+// are not walked.
+func (s *Sandbox) snapshot() (map[string]string, error) {
+	files := map[string]string{}
+	for _, root := range s.Watch {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable and vanishing paths cannot be compared.
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+					if d != nil && d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				return err
+			}
+			if s.ignored(path) || (d.IsDir() && s.unchecked(path)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// A directory's mtime moves with its entries, which are
+				// compared themselves.
+				files[path] = info.Mode().String()
+				return nil
+			}
+			files[path] = fmt.Sprintf("%s %d %d", info.Mode(), info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// unchecked reports whether every change under dir would be allowed.
+func (s *Sandbox) unchecked(dir string) bool {
+	if !s.allowed(dir) || s.denied(dir) {
+		return false
+	}
+	for _, deny := range s.Deny {
+		if under(deny, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Sandbox) allowed(path string) bool {
+	return underAny(path, s.Allow)
+}
+
+func (s *Sandbox) denied(path string) bool {
+	return underAny(path, s.Deny)
+}
+
+// ignored reports whether path is under an ignored dir, unless a watched
+// dir inside that ignored dir holds it, as when a scoped item's worktree
+// is in its artifacts dir.
+func (s *Sandbox) ignored(path string) bool {
+	for _, dir := range s.Ignore {
+		if !under(path, dir) {
+			continue
+		}
+		exempt := false
+		for _, root := range s.Watch {
+			if root != dir && under(root, dir) && under(path, root) {
+				exempt = true
+				break
+			}
+		}
+		if !exempt {
+			return true
+		}
+	}
+	return false
+}
+
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if under(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func under(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+func treeHash(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%s\n", path, files[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
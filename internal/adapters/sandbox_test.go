@@ -0,0 +1,120 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// funcAdapter runs fn as the agent.
+type funcAdapter func(cfg RunConfig) error
+
+func (f funcAdapter) Name() string { return "func" }
+
+func (f funcAdapter) Run(ctx context.Context, cfg RunConfig) (*RunResult, error) {
+	return &RunResult{ArtifactsDir: cfg.ArtifactsDir}, f(cfg)
+}
+
+func TestLoadSandbox(t *testing.T) {
+	root := t.TempDir()
+	if box, err := LoadSandbox(root, "codex"); err != nil || box != nil {
+		t.Fatalf("without adapters.yml = %+v, %v", box, err)
+	}
+	config := "sandbox:\n  default:\n    deny: [.git]\n  codex:\n    allow: [src]\n"
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	codex, err := LoadSandbox(root, "codex")
+	if err != nil || codex == nil || !reflect.DeepEqual(codex.Allow, []string{"src"}) || len(codex.Deny) != 0 {
+		t.Fatalf("codex sandbox = %+v, %v", codex, err)
+	}
+	other, err := LoadSandbox(root, "my-agent")
+	if err != nil || other == nil || !reflect.DeepEqual(other.Deny, []string{".git"}) {
+		t.Fatalf("default sandbox = %+v, %v", other, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte("sandbox:\n  codex:\n    allow: [\"\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSandbox(root, "codex"); err == nil {
+		t.Fatal("accepted an empty path")
+	}
+}
+
+func TestRunSandbox(t *testing.T) {
+	base := t.TempDir()
+	workDir := filepath.Join(base, "repo")
+	artifactsDir := filepath.Join(base, "repo", "artifacts", "item-0001")
+	for _, path := range []string{"repo/src/main.go", "repo/.env", "repo/vendor/lib.go", "sibling/notes.txt", "repo/artifacts/item-0001/prompt.md"} {
+		writeTestFile(t, filepath.Join(base, path), "original\n")
+	}
+	box, err := SandboxConfig{Watch: []string{".."}, Deny: []string{".env"}}.Resolve(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		agent func(cfg RunConfig) error
+		want  []SandboxChange
+	}{
+		{
+			name: "inside the work dir",
+			agent: func(cfg RunConfig) error {
+				writeTestFile(t, filepath.Join(cfg.WorkDir, "src", "main.go"), "changed\n")
+				writeTestFile(t, filepath.Join(cfg.WorkDir, "src", "new.go"), "new\n")
+				writeTestFile(t, filepath.Join(cfg.ArtifactsDir, "result.json"), "{}\n")
+				return os.Remove(filepath.Join(cfg.WorkDir, "vendor", "lib.go"))
+			},
+		},
+		{
+			name: "outside and denied",
+			agent: func(cfg RunConfig) error {
+				writeTestFile(t, filepath.Join(base, "sibling", "notes.txt"), "changed by the agent\n")
+				writeTestFile(t, filepath.Join(base, "stray.txt"), "new\n")
+				writeTestFile(t, filepath.Join(cfg.WorkDir, ".env"), "SECRET=hunter2\n")
+				return errors.New("agent exited 1")
+			},
+			want: []SandboxChange{
+				{Path: filepath.Join(base, "repo", ".env"), Change: "modified"},
+				{Path: filepath.Join(base, "sibling", "notes.txt"), Change: "modified"},
+				{Path: filepath.Join(base, "stray.txt"), Change: "added"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := RunConfig{WorkDir: workDir, ArtifactsDir: artifactsDir, Sandbox: box}
+			_, err := Run(context.Background(), funcAdapter(tc.agent), cfg)
+			var violation *SandboxViolation
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("Run = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &violation) {
+				t.Fatalf("Run = %v, want a sandbox violation", err)
+			}
+			if !reflect.DeepEqual(violation.Changes, tc.want) {
+				t.Errorf("changes = %+v, want %+v", violation.Changes, tc.want)
+			}
+			if violation.Err == nil || violation.TreeBefore == violation.TreeAfter {
+				t.Errorf("violation = %+v", violation)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
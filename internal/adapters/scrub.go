@@ -0,0 +1,159 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// maxHeldLine is the longest unfinished line a scrubbing writer holds
+// back before writing it anyway.
+const maxHeldLine = 64 << 10
+
+// minScrubLength is the shortest secret value scrubbed; shorter values
+// would match ordinary text.
+const minScrubLength = 4
+
+// Scrubber replaces secret values with [REDACTED:<name>]. It is safe for
+// concurrent use, and a nil Scrubber scrubs nothing.
+type Scrubber struct {
+	mu sync.RWMutex
+	// secrets maps each value, and its JSON-escaped form, to its name.
+	secrets map[string]string
+}
+
+// NewScrubber returns an empty Scrubber.
+func NewScrubber() *Scrubber {
+	return &Scrubber{secrets: map[string]string{}}
+}
+
+// Add scrubs value, named name, from now on.
+func (s *Scrubber) Add(name, value string) {
+	if s == nil || len(value) < minScrubLength {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[value] = name
+	if quoted, err := json.Marshal(value); err == nil {
+		s.secrets[string(quoted[1:len(quoted)-1])] = name
+	}
+}
+
+// Empty reports whether there is nothing to scrub.
+func (s *Scrubber) Empty() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.secrets) == 0
+}
+
+// Scrub returns data with every secret replaced.
+func (s *Scrubber) Scrub(data []byte) []byte {
+	if s.Empty() {
+		return data
+	}
+	s.mu.RLock()
+	values := make([]string, 0, len(s.secrets))
+	for value := range s.secrets {
+		values = append(values, value)
+	}
+	// Longer values first, so a secret containing another is replaced
+	// whole.
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	for _, value := range values {
+		if bytes.Contains(data, []byte(value)) {
+			data = bytes.ReplaceAll(data, []byte(value), []byte("[REDACTED:"+s.secrets[value]+"]"))
+		}
+	}
+	s.mu.RUnlock()
+	return data
+}
+
+// ScrubFile rewrites the file at path with its secrets scrubbed. A missing
+// file is left alone.
+func (s *Scrubber) ScrubFile(path string) error {
+	if s.Empty() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	scrubbed := s.Scrub(data)
+	if bytes.Equal(scrubbed, data) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, scrubbed, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("scrub %s: %w", path, err)
+	}
+	return nil
+}
+
+// Payload returns an audit event payload with its secrets scrubbed, as
+// JSON, or payload itself when there is nothing to scrub.
+func (s *Scrubber) Payload(payload any) any {
+	if s.Empty() {
+		return payload
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return payload
+	}
+	return json.RawMessage(s.Scrub(data))
+}
+
+// Writer returns a writer that scrubs what it copies to w. It holds back
+// an unfinished line, so a secret split across writes is still scrubbed;
+// Close writes it.
+func (s *Scrubber) Writer(w io.Writer) io.WriteCloser {
+	return &scrubWriter{scrub: s, w: w}
+}
+
+type scrubWriter struct {
+	scrub *Scrubber
+	w     io.Writer
+	line  []byte
+}
+
+func (sw *scrubWriter) Write(p []byte) (int, error) {
+	sw.line = append(sw.line, p...)
+	i := bytes.LastIndexByte(sw.line, '\n')
+	if i < 0 && len(sw.line) > maxHeldLine {
+		i = len(sw.line) - 1
+	}
+	if i >= 0 {
+		if _, err := sw.w.Write(sw.scrub.Scrub(sw.line[:i+1])); err != nil {
+			return 0, err
+		}
+		sw.line = append(sw.line[:0], sw.line[i+1:]...)
+	}
+	return len(p), nil
+}
+
+func (sw *scrubWriter) Close() error {
+	if len(sw.line) == 0 {
+		return nil
+	}
+	_, err := sw.w.Write(sw.scrub.Scrub(sw.line))
+	sw.line = nil
+	return err
+}
@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Usage is what one agent run consumed. Token counts are zero when the
+// agent does not report them.
+type Usage struct {
+	Model           string  `json:"model,omitempty"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// CostUSD is the estimated cost, reported by the adapter or priced
+	// from adapters.yml (see Pricing).
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// Add accumulates other's counts, duration, and cost into u. Model is left
+// as is.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.TotalTokens += other.TotalTokens
+	u.DurationSeconds += other.DurationSeconds
+	u.CostUSD += other.CostUSD
+}
+
+var (
+	codexModelPattern      = regexp.MustCompile(`^\s*model:\s*(\S+)\s*$`)
+	codexTokensUsedPattern = regexp.MustCompile(`(?i)tokens used:?\s*([\d,]*)\s*$`)
+	tokenCountPattern      = regexp.MustCompile(`^[\d,]+$`)
+)
+
+// ParseCodexUsage reads token usage from a codex transcript. It understands
+// the plain output ("model: ..." in the header, "tokens used" at the end)
+// and --json output (usage objects with input_tokens/output_tokens, summed
+// across turns). Anything it cannot find is left zero.
+func ParseCodexUsage(transcriptPath string, duration time.Duration) Usage {
+	usage := Usage{DurationSeconds: duration.Seconds()}
+	f, err := os.Open(transcriptPath)
+	if err != nil {
+		return usage
+	}
+	defer f.Close()
+
+	var plainTotal int64
+	expectCount := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if expectCount {
+			expectCount = false
+			if tokenCountPattern.MatchString(line) {
+				plainTotal = parseTokenCount(line)
+				continue
+			}
+		}
+		if strings.HasPrefix(line, "{") {
+			var event struct {
+				Model string `json:"model"`
+				Usage *struct {
+					InputTokens  int64 `json:"input_tokens"`
+					OutputTokens int64 `json:"output_tokens"`
+				} `json:"usage"`
+			}
+			if json.Unmarshal([]byte(line), &event) == nil {
+				if event.Model != "" {
+					usage.Model = event.Model
+				}
+				if event.Usage != nil {
+					usage.InputTokens += event.Usage.InputTokens
+					usage.OutputTokens += event.Usage.OutputTokens
+				}
+			}
+			continue
+		}
+		if m := codexModelPattern.FindStringSubmatch(line); m != nil && usage.Model == "" {
+			usage.Model = m[1]
+			continue
+		}
+		if m := codexTokensUsedPattern.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				expectCount = true
+			} else {
+				plainTotal = parseTokenCount(m[1])
+			}
+		}
+	}
+
+	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = plainTotal
+	}
+	return usage
+}
+
+func parseTokenCount(s string) int64 {
+	n, err := strconv.ParseInt(strings.ReplaceAll(s, ",", ""), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
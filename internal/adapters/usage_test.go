@@ -0,0 +1,59 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCodexUsage(t *testing.T) {
+	cases := []struct {
+		name       string
+		transcript string
+		want       Usage
+	}{
+		{
+			name: "plain",
+			transcript: `--------
+workdir: /repo
+model: gpt-5-codex
+provider: openai
+--------
+[2025-01-01T00:00:00] thinking
+tokens used
+12,345
+`,
+			want: Usage{Model: "gpt-5-codex", TotalTokens: 12345, DurationSeconds: 2},
+		},
+		{
+			name:       "plain inline",
+			transcript: "model: o4-mini\n[2025-01-01T00:00:05] tokens used: 987\n",
+			want:       Usage{Model: "o4-mini", TotalTokens: 987, DurationSeconds: 2},
+		},
+		{
+			name: "json",
+			transcript: `{"type":"thread.started","thread_id":"t1"}
+{"type":"turn.completed","usage":{"input_tokens":1000,"cached_input_tokens":200,"output_tokens":150}}
+{"type":"turn.completed","usage":{"input_tokens":500,"output_tokens":50}}
+`,
+			want: Usage{InputTokens: 1500, OutputTokens: 200, TotalTokens: 1700, DurationSeconds: 2},
+		},
+		{
+			name:       "nothing reported",
+			transcript: "error: something failed\n",
+			want:       Usage{DurationSeconds: 2},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transcript.log")
+			if err := os.WriteFile(path, []byte(tc.transcript), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := ParseCodexUsage(path, 2*time.Second); got != tc.want {
+				t.Fatalf("ParseCodexUsage = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
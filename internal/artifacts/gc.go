@@ -0,0 +1,418 @@
+package artifacts
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/config"
+)
+
+// Reasons a run or plan dir is removed by GC.
+const (
+	ReasonKeepRuns = "keep_runs"
+	ReasonMaxAge   = "max_age"
+	ReasonMaxBytes = "max_bytes"
+)
+
+// RetentionPolicy decides what GC removes from the runs/ and plans/ dirs.
+// Zero limits are not applied.
+type RetentionPolicy struct {
+	// KeepRuns removes runs beyond the newest KeepRuns.
+	KeepRuns int
+	// MaxAge removes runs and plans not touched for longer.
+	MaxAge time.Duration
+	// MaxBytes removes the oldest runs until the rest fit.
+	MaxBytes int64
+	// CompressAfter gzips the transcripts of kept runs not touched for
+	// longer.
+	CompressAfter time.Duration
+	// Protect names runs that are never removed, such as runs whose
+	// outcome is still pending.
+	Protect map[string]bool
+}
+
+// LoadRetentionPolicy reads the retention section of the workspace's
+// okrchestra.yml.
+func LoadRetentionPolicy(root string) (RetentionPolicy, error) {
+	cfg, err := config.Load(root)
+	if err != nil {
+		return RetentionPolicy{}, err
+	}
+	return RetentionPolicy{
+		KeepRuns:      cfg.Retention.KeepRuns,
+		MaxAge:        cfg.Retention.MaxAge,
+		MaxBytes:      cfg.Retention.MaxBytes,
+		CompressAfter: cfg.Retention.CompressAfter,
+	}, nil
+}
+
+// Pruned is a run or plan dir removed by GC. Path is slash-separated and
+// relative to the artifacts dir.
+type Pruned struct {
+	Path    string    `json:"path"`
+	Bytes   int64     `json:"bytes"`
+	ModTime time.Time `json:"mod_time"`
+	Reason  string    `json:"reason"`
+}
+
+// GCReport is what GC removed and compressed, or with DryRun would have.
+type GCReport struct {
+	DryRun bool     `json:"dry_run"`
+	Runs   []Pruned `json:"runs"`
+	Plans  []Pruned `json:"plans"`
+	// Compressed lists the transcripts gzipped in place.
+	Compressed []string `json:"compressed"`
+	// FreedBytes counts removed dirs, including the checkouts in scoped
+	// items' worktrees, and the space saved by compression.
+	FreedBytes int64 `json:"freed_bytes"`
+	KeptRuns   int   `json:"kept_runs"`
+	KeptBytes  int64 `json:"kept_bytes"`
+}
+
+type gcDir struct {
+	name    string
+	path    string
+	bytes   int64
+	modTime time.Time
+}
+
+// GC applies policy to the run and plan dirs under artifactsDir as of now.
+// The newest run and plan are always kept, as are plans a kept run was made
+// from. Removed runs are dropped from the index when there is one, and
+// their scoped items' worktrees from their git repository. With dryRun
+// nothing is changed.
+func GC(artifactsDir string, policy RetentionPolicy, now time.Time, dryRun bool) (*GCReport, error) {
+	report := &GCReport{DryRun: dryRun, Runs: []Pruned{}, Plans: []Pruned{}, Compressed: []string{}}
+
+	runs, err := listGCDirs(filepath.Join(artifactsDir, "runs"))
+	if err != nil {
+		return nil, err
+	}
+	removed := map[string]string{}
+	for i, run := range runs {
+		if i == 0 || policy.Protect[run.name] {
+			continue
+		}
+		switch {
+		case policy.KeepRuns > 0 && i >= policy.KeepRuns:
+			removed[run.name] = ReasonKeepRuns
+		case policy.MaxAge > 0 && now.Sub(run.modTime) > policy.MaxAge:
+			removed[run.name] = ReasonMaxAge
+		}
+	}
+	if policy.MaxBytes > 0 {
+		var total int64
+		for _, run := range runs {
+			if removed[run.name] == "" {
+				total += run.bytes
+			}
+		}
+		for i := len(runs) - 1; i > 0 && total > policy.MaxBytes; i-- {
+			run := runs[i]
+			if removed[run.name] != "" || policy.Protect[run.name] {
+				continue
+			}
+			removed[run.name] = ReasonMaxBytes
+			total -= run.bytes
+		}
+	}
+
+	usedPlans := map[string]bool{}
+	var kept []gcDir
+	for _, run := range runs {
+		reason := removed[run.name]
+		if reason == "" {
+			kept = append(kept, run)
+			if plan := runPlanDir(run.path); plan != "" {
+				usedPlans[plan] = true
+			}
+			continue
+		}
+		report.Runs = append(report.Runs, Pruned{Path: "runs/" + run.name, Bytes: run.bytes, ModTime: run.modTime, Reason: reason})
+		report.FreedBytes += run.bytes
+	}
+
+	plans, err := listGCDirs(filepath.Join(artifactsDir, "plans"))
+	if err != nil {
+		return nil, err
+	}
+	for i, plan := range plans {
+		if i == 0 || usedPlans[plan.name] || policy.MaxAge <= 0 || now.Sub(plan.modTime) <= policy.MaxAge {
+			continue
+		}
+		report.Plans = append(report.Plans, Pruned{Path: "plans/" + plan.name, Bytes: plan.bytes, ModTime: plan.modTime, Reason: ReasonMaxAge})
+		report.FreedBytes += plan.bytes
+	}
+
+	compressed := map[string]bool{}
+	for _, run := range kept {
+		saved, paths, err := compressTranscripts(run.path, policy.CompressAfter, now, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			rel, err := filepath.Rel(artifactsDir, p)
+			if err != nil {
+				return nil, fmt.Errorf("relativize %s: %w", p, err)
+			}
+			report.Compressed = append(report.Compressed, filepath.ToSlash(rel))
+		}
+		if len(paths) > 0 {
+			compressed[run.path] = true
+		}
+		report.FreedBytes += saved
+		report.KeptRuns++
+		report.KeptBytes += run.bytes - saved
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	for _, p := range report.Runs {
+		if err := removeRunDir(filepath.Join(artifactsDir, filepath.FromSlash(p.Path))); err != nil {
+			return nil, fmt.Errorf("remove %s: %w", p.Path, err)
+		}
+	}
+	for _, p := range report.Plans {
+		if err := os.RemoveAll(filepath.Join(artifactsDir, filepath.FromSlash(p.Path))); err != nil {
+			return nil, fmt.Errorf("remove %s: %w", p.Path, err)
+		}
+	}
+	if err := updateIndexAfterGC(artifactsDir, report, compressed); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// listGCDirs returns the dirs directly under dir, newest first by their
+// newest file.
+func listGCDirs(dir string) ([]gcDir, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", dir, err)
+	}
+	var dirs []gcDir
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		d := gcDir{name: entry.Name(), path: filepath.Join(dir, entry.Name())}
+		if info, err := entry.Info(); err == nil {
+			d.modTime = info.ModTime()
+		}
+		err := filepath.WalkDir(d.path, func(p string, e fs.DirEntry, err error) error {
+			if err != nil || e.IsDir() {
+				return err
+			}
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			d.bytes += info.Size()
+			if info.ModTime().After(d.modTime) {
+				d.modTime = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", d.path, err)
+		}
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if !dirs[i].modTime.Equal(dirs[j].modTime) {
+			return dirs[i].modTime.After(dirs[j].modTime)
+		}
+		return dirs[i].name > dirs[j].name
+	})
+	return dirs, nil
+}
+
+// removeRunDir removes runDir. The git worktrees of its scoped items are
+// removed with git first, so their repository keeps no .git/worktrees
+// entries for them; a worktree git fails to remove is pruned once deleted.
+func removeRunDir(runDir string) error {
+	var prune []string
+	err := filepath.WalkDir(runDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || d.Name() != "worktree" {
+			return err
+		}
+		if info, err := os.Lstat(filepath.Join(p, ".git")); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if git(p, "worktree", "remove", "--force", p) == nil {
+			return filepath.SkipDir
+		}
+		if out, err := exec.Command("git", "-C", p, "rev-parse", "--path-format=absolute", "--git-common-dir").Output(); err == nil {
+			prune = append(prune, strings.TrimSpace(string(out)))
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return fmt.Errorf("find worktrees: %w", err)
+	}
+	if err := os.RemoveAll(runDir); err != nil {
+		return err
+	}
+	for _, gitDir := range prune {
+		if err := git(gitDir, "worktree", "prune"); err != nil {
+			return fmt.Errorf("prune worktrees of %s: %w", gitDir, err)
+		}
+	}
+	return nil
+}
+
+// git runs a git command in dir.
+func git(dir string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// runPlanDir returns the name of the plans/ dir a run was made from, per
+// its run.json.
+func runPlanDir(runDir string) string {
+	data, err := os.ReadFile(filepath.Join(runDir, "run.json"))
+	if err != nil {
+		return ""
+	}
+	var state struct {
+		PlanPath string `json:"plan_path"`
+	}
+	if json.Unmarshal(data, &state) != nil || state.PlanPath == "" {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(state.PlanPath))
+}
+
+// compressTranscripts gzips each transcript.log under runDir older than
+// after into transcript.log.gz with the same modification time. It returns
+// the bytes saved and the transcripts compressed. Worktrees are skipped.
+func compressTranscripts(runDir string, after time.Duration, now time.Time, dryRun bool) (int64, []string, error) {
+	if after <= 0 {
+		return 0, nil, nil
+	}
+	var saved int64
+	var paths []string
+	err := filepath.WalkDir(runDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "worktree" && p != runDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "transcript.log" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if now.Sub(info.ModTime()) <= after {
+			return nil
+		}
+		if dryRun {
+			paths = append(paths, p)
+			return nil
+		}
+		size, err := gzipFile(p, info.ModTime())
+		if err != nil {
+			return err
+		}
+		// Tiny transcripts grow when gzipped; keep those as they are.
+		if size >= info.Size() {
+			return os.Remove(p + ".gz")
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		saved += info.Size() - size
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("compress transcripts in %s: %w", runDir, err)
+	}
+	return saved, paths, nil
+}
+
+// gzipFile writes path to path.gz with path's modTime and returns the
+// compressed size.
+func gzipFile(path string, modTime time.Time) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	gzPath := path + ".gz"
+	out, err := os.Create(gzPath)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = modTime
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(gzPath)
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(gzPath)
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(gzPath)
+		return 0, err
+	}
+	if err := os.Chtimes(gzPath, modTime, modTime); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(gzPath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// updateIndexAfterGC drops removed runs from an existing index and
+// re-indexes runs with compressed transcripts.
+func updateIndexAfterGC(artifactsDir string, report *GCReport, compressed map[string]bool) error {
+	if _, err := os.Stat(Path(artifactsDir)); err != nil {
+		return nil
+	}
+	idx, err := Open(artifactsDir)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	for _, run := range report.Runs {
+		if err := idx.RemoveRun(filepath.Base(run.Path)); err != nil {
+			return err
+		}
+	}
+	for runDir := range compressed {
+		if _, err := idx.Refresh(runDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package artifacts
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ageTree sets the modification time of dir and everything under it.
+func ageTree(t *testing.T, dir string, mtime time.Time) {
+	t.Helper()
+	err := filepath.Walk(dir, func(p string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(p, mtime, mtime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGC(t *testing.T) {
+	artifactsDir := t.TempDir()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// Five runs from 40 days to a day old, each with its own plan. run-b is
+	// protected, so its old plan stays too.
+	runAges := map[string]time.Duration{"run-a": 40 * day, "run-b": 30 * day, "run-c": 20 * day, "run-d": 10 * day, "run-e": day}
+	for name, age := range runAges {
+		runDir := filepath.Join(artifactsDir, "runs", name)
+		writeRunFile(t, filepath.Join(runDir, "item-0001", "transcript.log"), strings.Repeat("agent output\n", 100))
+		writeRunFile(t, filepath.Join(runDir, "run.json"), `{"plan_path":"artifacts/plans/`+name+`/plan.json"}`)
+		ageTree(t, runDir, now.Add(-age))
+		planDir := filepath.Join(artifactsDir, "plans", name)
+		writeRunFile(t, filepath.Join(planDir, "plan.json"), `{}`)
+		ageTree(t, planDir, now.Add(-age))
+	}
+	policy := RetentionPolicy{
+		KeepRuns:      4,
+		MaxAge:        25 * day,
+		CompressAfter: 7 * day,
+		Protect:       map[string]bool{"run-b": true},
+	}
+
+	report, err := GC(artifactsDir, policy, now, true)
+	if err != nil {
+		t.Fatalf("GC dry run: %v", err)
+	}
+	if len(report.Runs) != 1 || report.Runs[0].Path != "runs/run-a" || report.Runs[0].Reason != ReasonKeepRuns {
+		t.Fatalf("dry run runs = %+v", report.Runs)
+	}
+	if len(report.Plans) != 1 || report.Plans[0].Path != "plans/run-a" {
+		t.Fatalf("dry run plans = %+v", report.Plans)
+	}
+	if len(report.Compressed) != 3 {
+		t.Fatalf("dry run compressed = %v", report.Compressed)
+	}
+	if _, err := os.Stat(filepath.Join(artifactsDir, "runs", "run-a")); err != nil {
+		t.Fatalf("dry run removed run-a: %v", err)
+	}
+
+	report, err = GC(artifactsDir, policy, now, false)
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(artifactsDir, "runs", "run-a")); !os.IsNotExist(err) {
+		t.Fatalf("run-a kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(artifactsDir, "plans", "run-b")); err != nil {
+		t.Fatalf("plan of protected run-b removed: %v", err)
+	}
+	transcript := filepath.Join(artifactsDir, "runs", "run-c", "item-0001", "transcript.log")
+	if _, err := os.Stat(transcript); !os.IsNotExist(err) {
+		t.Fatalf("transcript not compressed: %v", err)
+	}
+	f, err := os.Open(transcript + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || !strings.HasPrefix(string(data), "agent output\n") {
+		t.Fatalf("decompressed = %q, %v", data, err)
+	}
+	if report.KeptRuns != 4 || report.FreedBytes <= report.Runs[0].Bytes {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// The byte limit removes the oldest unprotected runs first.
+	report, err = GC(artifactsDir, RetentionPolicy{MaxBytes: 1, Protect: map[string]bool{"run-b": true}}, now, false)
+	if err != nil {
+		t.Fatalf("GC by bytes: %v", err)
+	}
+	var removed []string
+	for _, p := range report.Runs {
+		removed = append(removed, p.Path+":"+p.Reason)
+	}
+	if strings.Join(removed, ",") != "runs/run-d:max_bytes,runs/run-c:max_bytes" {
+		t.Fatalf("removed by bytes = %v", removed)
+	}
+}
+
+func TestGCRemovesWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeRunFile(t, filepath.Join(root, "README.md"), "readme\n")
+	git("init", "-q")
+	git("add", "README.md")
+	git("commit", "-q", "-m", "init")
+
+	artifactsDir := filepath.Join(root, "artifacts")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"run-new", "run-old"} {
+		runDir := filepath.Join(artifactsDir, "runs", name)
+		writeRunFile(t, filepath.Join(runDir, "run.json"), `{}`)
+		git("worktree", "add", "-q", "--detach", filepath.Join(runDir, "item-0001", "worktree"))
+		ageTree(t, runDir, now.Add(-time.Duration(i+1)*time.Hour))
+	}
+
+	report, err := GC(artifactsDir, RetentionPolicy{KeepRuns: 1}, now, false)
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if len(report.Runs) != 1 || report.Runs[0].Path != "runs/run-old" {
+		t.Fatalf("runs = %+v", report.Runs)
+	}
+	if report.FreedBytes < int64(len("readme\n")) {
+		t.Fatalf("freed %d bytes, want the worktree checkout counted", report.FreedBytes)
+	}
+	if _, err := os.Stat(filepath.Join(artifactsDir, "runs", "run-old")); !os.IsNotExist(err) {
+		t.Fatalf("run-old kept: %v", err)
+	}
+	list := git("worktree", "list", "--porcelain")
+	if strings.Contains(list, "run-old") || !strings.Contains(list, "run-new") {
+		t.Fatalf("worktrees after GC:\n%s", list)
+	}
+}
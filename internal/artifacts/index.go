@@ -0,0 +1,343 @@
+// Package artifacts maintains a SQLite index of run artifacts so lookups by
+// run, item, plan, or KR do not have to walk the artifacts dir.
+package artifacts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// IndexFileName is the index DB inside the artifacts dir.
+const IndexFileName = "index.sqlite"
+
+// Entry is one indexed artifact file. Path is slash-separated and relative
+// to the artifacts dir.
+type Entry struct {
+	Path      string    `json:"path"`
+	Kind      string    `json:"kind"`
+	RunID     string    `json:"run_id"`
+	ItemDir   string    `json:"item_dir,omitempty"`
+	ItemID    string    `json:"item_id,omitempty"`
+	PlanID    string    `json:"plan_id,omitempty"`
+	KRID      string    `json:"kr_id,omitempty"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// Filter selects entries in Find. Empty fields match everything.
+type Filter struct {
+	RunID  string
+	ItemID string
+	PlanID string
+	KRID   string
+	Kind   string
+}
+
+// Index is an open artifacts index.
+type Index struct {
+	ArtifactsDir string
+	db           *sql.DB
+}
+
+// Path returns the index DB for an artifacts dir.
+func Path(artifactsDir string) string {
+	return filepath.Join(artifactsDir, IndexFileName)
+}
+
+// Open opens (creating if needed) the index for artifactsDir.
+func Open(artifactsDir string) (*Index, error) {
+	abs, err := filepath.Abs(artifactsDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve artifacts dir: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure artifacts dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", Path(abs))
+	if err != nil {
+		return nil, fmt.Errorf("open artifacts index: %w", err)
+	}
+	idx := &Index{ArtifactsDir: abs, db: db}
+	if err := idx.ensureSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Close closes the index DB.
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+func (idx *Index) ensureSchema() error {
+	_, err := idx.db.Exec(`
+		CREATE TABLE IF NOT EXISTS artifacts (
+			path TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			run_id TEXT NOT NULL,
+			item_dir TEXT NOT NULL DEFAULT '',
+			item_id TEXT NOT NULL DEFAULT '',
+			plan_id TEXT NOT NULL DEFAULT '',
+			kr_id TEXT NOT NULL DEFAULT '',
+			size INTEGER NOT NULL,
+			mod_time TEXT NOT NULL,
+			indexed_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_artifacts_run ON artifacts(run_id);
+		CREATE INDEX IF NOT EXISTS idx_artifacts_item ON artifacts(item_id);
+		CREATE INDEX IF NOT EXISTS idx_artifacts_plan ON artifacts(plan_id);
+		CREATE INDEX IF NOT EXISTS idx_artifacts_kr ON artifacts(kr_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("create artifacts index schema: %w", err)
+	}
+	return nil
+}
+
+// IndexRun replaces the entries for a run dir with its current files. Item
+// IDs and KR IDs come from each item's item.json; sparse worktrees are not
+// indexed. It returns the number of files indexed.
+func (idx *Index) IndexRun(runDir, planID string) (int, error) {
+	runRel, err := idx.rel(runDir)
+	if err != nil {
+		return 0, err
+	}
+	runID := filepath.Base(runDir)
+
+	var entries []Entry
+	items := map[string]itemMeta{}
+	err = filepath.WalkDir(runDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "worktree" && p != runDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := idx.rel(p)
+		if err != nil {
+			return err
+		}
+		entry := Entry{
+			Path:    rel,
+			Kind:    kindOf(d.Name()),
+			RunID:   runID,
+			PlanID:  planID,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		}
+		if inner := strings.TrimPrefix(rel, runRel+"/"); strings.HasPrefix(inner, "item-") {
+			itemDir, _, _ := strings.Cut(inner, "/")
+			meta, ok := items[itemDir]
+			if !ok {
+				meta = readItemMeta(filepath.Join(runDir, itemDir))
+				items[itemDir] = meta
+			}
+			entry.ItemDir = itemDir
+			entry.ItemID = meta.ID
+			entry.KRID = meta.KRID
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan run dir: %w", err)
+	}
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin index update: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`DELETE FROM artifacts WHERE run_id = ?`, runID); err != nil {
+		return 0, fmt.Errorf("clear run entries: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range entries {
+		_, err := tx.Exec(`
+			INSERT INTO artifacts (path, kind, run_id, item_dir, item_id, plan_id, kr_id, size, mod_time, indexed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.Path, e.Kind, e.RunID, e.ItemDir, e.ItemID, e.PlanID, e.KRID, e.Size, e.ModTime.Format(time.RFC3339Nano), now)
+		if err != nil {
+			return 0, fmt.Errorf("index %s: %w", e.Path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit index update: %w", err)
+	}
+	return len(entries), nil
+}
+
+// Refresh re-indexes a run dir, keeping the plan ID already recorded for
+// it. Use it after adding files to a finished run, such as a review.
+func (idx *Index) Refresh(runDir string) (int, error) {
+	var planID string
+	err := idx.db.QueryRow(`SELECT plan_id FROM artifacts WHERE run_id = ? AND plan_id != '' LIMIT 1`, filepath.Base(runDir)).Scan(&planID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("read run plan: %w", err)
+	}
+	return idx.IndexRun(runDir, planID)
+}
+
+// RemoveRun drops a run's entries, e.g. after its dir is deleted.
+func (idx *Index) RemoveRun(runID string) error {
+	if _, err := idx.db.Exec(`DELETE FROM artifacts WHERE run_id = ?`, runID); err != nil {
+		return fmt.Errorf("remove run entries: %w", err)
+	}
+	return nil
+}
+
+// Prune drops entries whose files no longer exist and returns how many.
+func (idx *Index) Prune() (int, error) {
+	entries, err := idx.Find(Filter{})
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(idx.ArtifactsDir, filepath.FromSlash(e.Path))); !os.IsNotExist(err) {
+			continue
+		}
+		if _, err := idx.db.Exec(`DELETE FROM artifacts WHERE path = ?`, e.Path); err != nil {
+			return removed, fmt.Errorf("prune %s: %w", e.Path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Rebuild prunes missing files and re-indexes every dir under runs/.
+func (idx *Index) Rebuild() (int, error) {
+	if _, err := idx.Prune(); err != nil {
+		return 0, err
+	}
+	runsDir := filepath.Join(idx.ArtifactsDir, "runs")
+	dirs, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read runs dir: %w", err)
+	}
+	total := 0
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		n, err := idx.Refresh(filepath.Join(runsDir, d.Name()))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Find returns matching entries ordered by path.
+func (idx *Index) Find(f Filter) ([]Entry, error) {
+	query := `SELECT path, kind, run_id, item_dir, item_id, plan_id, kr_id, size, mod_time, indexed_at FROM artifacts WHERE 1=1`
+	var args []any
+	for _, cond := range []struct {
+		column string
+		value  string
+	}{
+		{"run_id", f.RunID},
+		{"item_id", f.ItemID},
+		{"plan_id", f.PlanID},
+		{"kr_id", f.KRID},
+		{"kind", f.Kind},
+	} {
+		if cond.value != "" {
+			query += " AND " + cond.column + " = ?"
+			args = append(args, cond.value)
+		}
+	}
+	query += " ORDER BY path"
+
+	rows, err := idx.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query artifacts index: %w", err)
+	}
+	defer rows.Close()
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var modTime, indexedAt string
+		if err := rows.Scan(&e.Path, &e.Kind, &e.RunID, &e.ItemDir, &e.ItemID, &e.PlanID, &e.KRID, &e.Size, &modTime, &indexedAt); err != nil {
+			return nil, fmt.Errorf("scan artifact entry: %w", err)
+		}
+		e.ModTime, _ = time.Parse(time.RFC3339Nano, modTime)
+		e.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read artifacts index: %w", err)
+	}
+	return entries, nil
+}
+
+func (idx *Index) rel(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(idx.ArtifactsDir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the artifacts dir", p)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+type itemMeta struct {
+	ID   string `json:"id"`
+	KRID string `json:"kr_id"`
+}
+
+func readItemMeta(itemDir string) itemMeta {
+	var meta itemMeta
+	if data, err := os.ReadFile(filepath.Join(itemDir, "item.json")); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+// kindOf classifies a file by the names RunPlan writes.
+func kindOf(name string) string {
+	switch name {
+	case "transcript.log", "transcript.log.gz":
+		return "transcript"
+	case "result.json":
+		return "result"
+	case "prompt.md":
+		return "prompt"
+	case "item.json":
+		return "item"
+	case "failure.json":
+		return "failure"
+	case "violation.json":
+		return "violation"
+	case "secrets.json":
+		return "secrets"
+	case "review.json":
+		return "review"
+	}
+	return "other"
+}
@@ -0,0 +1,75 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRunFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexRunAndFind(t *testing.T) {
+	artifactsDir := t.TempDir()
+	runDir := filepath.Join(artifactsDir, "runs", "20250115T020000Z")
+	writeRunFile(t, filepath.Join(runDir, "item-0001", "item.json"), `{"id":"PI-1","kr_id":"KR-1"}`)
+	writeRunFile(t, filepath.Join(runDir, "item-0001", "transcript.log"), "hello\n")
+	writeRunFile(t, filepath.Join(runDir, "item-0001", "worktree", "main.go"), "package main\n")
+	writeRunFile(t, filepath.Join(runDir, "item-0002", "item.json"), `{"id":"PI-2","kr_id":"KR-2"}`)
+	writeRunFile(t, filepath.Join(runDir, "item-0002", "failure.json"), `{}`)
+
+	idx, err := Open(artifactsDir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer idx.Close()
+
+	n, err := idx.IndexRun(runDir, "plan-1")
+	if err != nil {
+		t.Fatalf("IndexRun: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("indexed %d files, want 4 (worktree skipped)", n)
+	}
+
+	entries, err := idx.Find(Filter{KRID: "KR-1", Kind: "transcript"})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	e := entries[0]
+	if e.Path != "runs/20250115T020000Z/item-0001/transcript.log" || e.ItemID != "PI-1" || e.PlanID != "plan-1" || e.Size != 6 {
+		t.Fatalf("entry = %+v", e)
+	}
+
+	if err := os.Remove(filepath.Join(runDir, "item-0002", "failure.json")); err != nil {
+		t.Fatal(err)
+	}
+	writeRunFile(t, filepath.Join(runDir, "item-0001", "review.json"), `{}`)
+	if _, err := idx.Rebuild(); err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if failures, _ := idx.Find(Filter{Kind: "failure"}); len(failures) != 0 {
+		t.Fatalf("deleted file still indexed: %+v", failures)
+	}
+	reviews, err := idx.Find(Filter{PlanID: "plan-1", Kind: "review"})
+	if err != nil || len(reviews) != 1 {
+		t.Fatalf("rebuild should keep the plan ID and pick up new files: %+v %v", reviews, err)
+	}
+
+	if err := idx.RemoveRun("20250115T020000Z"); err != nil {
+		t.Fatalf("RemoveRun: %v", err)
+	}
+	if all, _ := idx.Find(Filter{}); len(all) != 0 {
+		t.Fatalf("entries after RemoveRun = %+v", all)
+	}
+}
@@ -6,46 +6,287 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"okrchestra/internal/sqlitex"
 )
 
 const defaultAuditPath = "audit/events.db"
 
-// Logger writes audit events to a specific SQLite DB path.
+const insertEventSQL = "INSERT INTO events (ts, actor, type, payload_json) VALUES (?, ?, ?, ?)"
+
+// Logger writes audit events to a specific SQLite DB path. The DB is opened
+// on first use; the connection and prepared insert are then reused until
+// Close.
 type Logger struct {
 	DBPath string
+
+	// writeMu serializes use of db and insert, and keeps batches in order.
+	writeMu sync.Mutex
+	db      *sql.DB
+	insert  *sql.Stmt
+
+	// Batching state, used by loggers from NewBatchLogger.
+	mu       sync.Mutex
+	batched  bool
+	maxBatch int
+	pending  []pendingEvent
+	flushErr error
+	wake     chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	closed   bool
+}
+
+type pendingEvent struct {
+	ts          time.Time
+	actor       string
+	eventType   string
+	payloadJSON string
+}
+
+// BatchOptions configures NewBatchLogger.
+type BatchOptions struct {
+	// MaxBatch writes pending events as soon as this many are queued
+	// (default 64).
+	MaxBatch int
+	// FlushInterval is the longest an event waits before it is written
+	// (default 1s).
+	FlushInterval time.Duration
 }
 
-// NewLogger returns a Logger bound to the provided DB path.
+// NewLogger returns a Logger bound to the provided DB path. Each LogEvent
+// is written before it returns.
 func NewLogger(dbPath string) *Logger {
 	return &Logger{DBPath: dbPath}
 }
 
+// NewBatchLogger returns a Logger whose LogEvent only queues the event;
+// queued events are written in one transaction when the batch fills, on
+// every FlushInterval, on Flush, and on Close. Write errors are reported by
+// the next Flush or Close. Close must be called to avoid losing events.
+func NewBatchLogger(dbPath string, opts BatchOptions) *Logger {
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = 64
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	l := &Logger{
+		DBPath:   dbPath,
+		batched:  true,
+		maxBatch: opts.MaxBatch,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go l.flushLoop(opts.FlushInterval)
+	return l
+}
+
 // LogEvent writes an audit event to the SQLite-backed log.
 func LogEvent(actor string, eventType string, payload any) error {
-	return logEvent("", actor, eventType, payload)
+	return (*Logger)(nil).LogEvent(actor, eventType, payload)
 }
 
-// LogEvent writes an audit event to the configured SQLite-backed log.
+// LogEvent writes an audit event to the configured SQLite-backed log, or
+// queues it on a batch logger.
 func (l *Logger) LogEvent(actor string, eventType string, payload any) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	ev := pendingEvent{ts: time.Now().UTC(), actor: actor, eventType: eventType, payloadJSON: string(payloadJSON)}
+
 	if l == nil {
-		return logEvent("", actor, eventType, payload)
+		oneShot := NewLogger("")
+		err := oneShot.write([]pendingEvent{ev})
+		if closeErr := oneShot.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	l.mu.Lock()
+	if l.batched && !l.closed {
+		l.pending = append(l.pending, ev)
+		full := len(l.pending) >= l.maxBatch
+		l.mu.Unlock()
+		if full {
+			select {
+			case l.wake <- struct{}{}:
+			default:
+			}
+		}
+		return nil
+	}
+	l.mu.Unlock()
+	return l.write([]pendingEvent{ev})
+}
+
+// Flush writes any queued events. It returns the first write error since
+// the previous Flush, including errors from background writes.
+func (l *Logger) Flush() error {
+	if l == nil {
+		return nil
+	}
+	err := l.writePending()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil {
+		err = l.flushErr
+	}
+	l.flushErr = nil
+	return err
+}
+
+// Close flushes queued events and releases the DB connection. A Logger
+// used after Close reopens the DB and writes synchronously.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	wasRunning := l.batched && !l.closed
+	l.closed = true
+	l.mu.Unlock()
+	if wasRunning {
+		close(l.stop)
+		<-l.stopped
+	}
+	err := l.Flush()
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	if l.insert != nil {
+		_ = l.insert.Close()
+		l.insert = nil
+	}
+	if l.db != nil {
+		if closeErr := l.db.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close audit db: %w", closeErr)
+		}
+		l.db = nil
+	}
+	return err
+}
+
+func (l *Logger) flushLoop(interval time.Duration) {
+	defer close(l.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		case <-l.wake:
+		}
+		if err := l.writePending(); err != nil {
+			l.mu.Lock()
+			if l.flushErr == nil {
+				l.flushErr = err
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// writePending writes and clears the queued events. Holding writeMu while
+// taking the queue keeps batches in the order they were logged.
+func (l *Logger) writePending() error {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	l.mu.Lock()
+	events := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	return l.writeLocked(events)
+}
+
+func (l *Logger) write(events []pendingEvent) error {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	return l.writeLocked(events)
+}
+
+// writeLocked inserts events in one transaction. writeMu must be held.
+func (l *Logger) writeLocked(events []pendingEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := l.openLocked(); err != nil {
+		return err
 	}
-	return logEvent(l.DBPath, actor, eventType, payload)
+	if len(events) == 1 {
+		ev := events[0]
+		if _, err := l.insert.Exec(ev.ts, ev.actor, ev.eventType, ev.payloadJSON); err != nil {
+			return fmt.Errorf("insert audit event: %w", err)
+		}
+		return nil
+	}
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin audit batch: %w", err)
+	}
+	stmt := tx.Stmt(l.insert)
+	for _, ev := range events {
+		if _, err := stmt.Exec(ev.ts, ev.actor, ev.eventType, ev.payloadJSON); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert audit event: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit audit batch: %w", err)
+	}
+	return nil
 }
 
-func logEvent(dbPath string, actor string, eventType string, payload any) error {
-	resolved, err := resolveDBPath(dbPath)
+// openLocked opens the DB, creates the schema, and prepares the insert the
+// first time it is called. writeMu must be held.
+func (l *Logger) openLocked() error {
+	if l.db != nil {
+		return nil
+	}
+	resolved, err := resolveDBPath(l.DBPath)
+	if err != nil {
+		return err
+	}
+	db, err := openDB(resolved)
 	if err != nil {
 		return err
 	}
-	return writeEvent(resolved, actor, eventType, payload)
+	insert, err := db.Prepare(insertEventSQL)
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("prepare audit insert: %w", err)
+	}
+	l.db = db
+	l.insert = insert
+	return nil
 }
 
-func ensureSchema(db *sql.DB) error {
-	_, err := db.Exec(`
+// openDB opens the audit DB with a single connection and ensures its schema.
+func openDB(path string) (*sql.DB, error) {
+	db, err := sqlitex.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit db: %w", err)
+	}
+	// One connection keeps the prepared insert on the connection that
+	// prepared it and avoids writers in this process contending.
+	db.SetMaxOpenConns(1)
+	if err := ensureSchema(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrations is the audit schema history. Version 1 matches the table
+// older binaries created without recording a version.
+var migrations = []sqlitex.Migration{
+	{Version: 1, Name: "create events", SQL: `
 		CREATE TABLE IF NOT EXISTS events (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			ts DATETIME NOT NULL,
@@ -53,9 +294,12 @@ func ensureSchema(db *sql.DB) error {
 			type TEXT NOT NULL,
 			payload_json TEXT NOT NULL
 		)
-	`)
-	if err != nil {
-		return fmt.Errorf("create audit schema: %w", err)
+	`},
+}
+
+func ensureSchema(db *sql.DB) error {
+	if err := sqlitex.Migrate(db, migrations); err != nil {
+		return fmt.Errorf("migrate audit schema: %w", err)
 	}
 	return nil
 }
@@ -76,35 +320,3 @@ func resolveDBPath(dbPath string) (string, error) {
 	}
 	return absPath, nil
 }
-
-func writeEvent(dbPath string, actor string, eventType string, payload any) error {
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("open audit db: %w", err)
-	}
-	defer func() {
-		_ = db.Close()
-	}()
-
-	if err := ensureSchema(db); err != nil {
-		return err
-	}
-
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("marshal payload: %w", err)
-	}
-
-	_, err = db.Exec(
-		"INSERT INTO events (ts, actor, type, payload_json) VALUES (?, ?, ?, ?)",
-		time.Now().UTC(),
-		actor,
-		eventType,
-		string(payloadJSON),
-	)
-	if err != nil {
-		return fmt.Errorf("insert audit event: %w", err)
-	}
-
-	return nil
-}
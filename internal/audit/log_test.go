@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoggerReusesConnection(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.sqlite")
+	logger := NewLogger(dbPath)
+	defer logger.Close()
+	for i := 0; i < 3; i++ {
+		if err := logger.LogEvent("cli", "test_event", map[string]int{"n": i}); err != nil {
+			t.Fatalf("log event: %v", err)
+		}
+	}
+	first := logger.db
+
+	// Synchronous writes are visible immediately.
+	events, err := ReadEvents(dbPath, Query{})
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 3 || logger.db != first {
+		t.Fatalf("expected 3 events on one connection, got %d", len(events))
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	// A closed logger reopens on demand.
+	if err := logger.LogEvent("cli", "after_close", nil); err != nil {
+		t.Fatalf("log after close: %v", err)
+	}
+	if events, _ := ReadEvents(dbPath, Query{Type: "after_close"}); len(events) != 1 {
+		t.Fatalf("expected event logged after close, got %d", len(events))
+	}
+}
+
+func TestBatchLoggerFlushesOnClose(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.sqlite")
+	logger := NewBatchLogger(dbPath, BatchOptions{MaxBatch: 1000, FlushInterval: time.Hour})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if err := logger.LogEvent("scheduler", "plan_item_started", map[string]string{"item": fmt.Sprintf("%d-%d", w, i)}); err != nil {
+					t.Errorf("log event: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if events, _ := ReadEvents(dbPath, Query{}); len(events) != 0 {
+		t.Fatalf("expected events to be queued, found %d written", len(events))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	events, err := ReadEvents(dbPath, Query{})
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 100 {
+		t.Fatalf("expected 100 events after close, got %d", len(events))
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].TS.Before(events[i-1].TS) {
+			t.Fatalf("events out of order at %d", i)
+		}
+	}
+}
+
+func TestBatchLoggerFlushesFullBatch(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.sqlite")
+	logger := NewBatchLogger(dbPath, BatchOptions{MaxBatch: 2, FlushInterval: time.Hour})
+	defer logger.Close()
+	for i := 0; i < 2; i++ {
+		if err := logger.LogEvent("cli", "test_event", nil); err != nil {
+			t.Fatalf("log event: %v", err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, err := ReadEvents(dbPath, Query{})
+		if err != nil {
+			t.Fatalf("read events: %v", err)
+		}
+		if len(events) == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("full batch not written, found %d events", len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Event is one row of the audit log.
+type Event struct {
+	ID          int64     `json:"id"`
+	TS          time.Time `json:"ts"`
+	Actor       string    `json:"actor"`
+	Type        string    `json:"type"`
+	PayloadJSON string    `json:"payload_json"`
+}
+
+// ErrEventNotFound is returned by ReadEvent for an unknown event ID.
+var ErrEventNotFound = errors.New("audit event not found")
+
+// Query filters ReadEvents. Zero fields match everything.
+type Query struct {
+	Since time.Time
+	Until time.Time
+	Actor string
+	Type  string
+	// Contains matches events whose payload JSON contains the substring,
+	// such as a run or job ID.
+	Contains string
+	Limit    int
+	// Latest makes Limit keep the newest matching events instead of the
+	// oldest. Events are still returned oldest first.
+	Latest bool
+}
+
+// ReadEvents returns matching events oldest first. A missing DB yields no
+// events rather than creating one.
+func ReadEvents(dbPath string, q Query) ([]Event, error) {
+	resolved, err := resolveDBPath(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(resolved); os.IsNotExist(err) {
+		return nil, nil
+	}
+	db, err := openDB(resolved)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	query := "SELECT id, ts, actor, type, payload_json FROM events WHERE 1=1"
+	var args []any
+	if !q.Since.IsZero() {
+		query += " AND ts >= ?"
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		query += " AND ts <= ?"
+		args = append(args, q.Until.UTC())
+	}
+	if q.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, q.Actor)
+	}
+	if q.Type != "" {
+		query += " AND type = ?"
+		args = append(args, q.Type)
+	}
+	if q.Contains != "" {
+		query += " AND instr(payload_json, ?) > 0"
+		args = append(args, q.Contains)
+	}
+	if q.Latest {
+		query += " ORDER BY id DESC"
+	} else {
+		query += " ORDER BY id"
+	}
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit events: %w", err)
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		var ev Event
+		if err := rows.Scan(&ev.ID, &ev.TS, &ev.Actor, &ev.Type, &ev.PayloadJSON); err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read audit events: %w", err)
+	}
+	if q.Latest {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	return events, nil
+}
+
+// ReadEvent returns the event with the given ID.
+func ReadEvent(dbPath string, id int64) (Event, error) {
+	resolved, err := resolveDBPath(dbPath)
+	if err != nil {
+		return Event{}, err
+	}
+	if _, err := os.Stat(resolved); os.IsNotExist(err) {
+		return Event{}, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+	}
+	db, err := openDB(resolved)
+	if err != nil {
+		return Event{}, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var ev Event
+	err = db.QueryRow("SELECT id, ts, actor, type, payload_json FROM events WHERE id = ?", id).
+		Scan(&ev.ID, &ev.TS, &ev.Actor, &ev.Type, &ev.PayloadJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, fmt.Errorf("%w: %d", ErrEventNotFound, id)
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("read audit event: %w", err)
+	}
+	return ev, nil
+}
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEventsFilters(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.sqlite")
+	logger := NewLogger(dbPath)
+	for _, ev := range []struct{ actor, typ, run string }{
+		{"cli", "plan_run_started", "run-1"},
+		{"daemon", "job_failed", "run-2"},
+		{"cli", "plan_run_started", "run-3"},
+		{"cli", "okr_apply_finished", ""},
+	} {
+		if err := logger.LogEvent(ev.actor, ev.typ, map[string]string{"run_id": ev.run}); err != nil {
+			t.Fatalf("log event: %v", err)
+		}
+	}
+
+	events, err := ReadEvents(dbPath, Query{Actor: "cli", Type: "plan_run_started"})
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != 1 || events[1].ID != 3 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	latest, err := ReadEvents(dbPath, Query{Actor: "cli", Limit: 2, Latest: true})
+	if err != nil {
+		t.Fatalf("read latest: %v", err)
+	}
+	if len(latest) != 2 || latest[0].ID != 3 || latest[1].ID != 4 {
+		t.Fatalf("unexpected latest events: %+v", latest)
+	}
+
+	ev, err := ReadEvent(dbPath, 2)
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	if ev.Actor != "daemon" || ev.Type != "job_failed" || ev.PayloadJSON != `{"run_id":"run-2"}` {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if _, err := ReadEvent(dbPath, 99); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound, got %v", err)
+	}
+}
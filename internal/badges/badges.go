@@ -0,0 +1,161 @@
+package badges
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"okrchestra/internal/metrics"
+)
+
+// Badge is a shields-style label/message pair.
+type Badge struct {
+	Name    string
+	Label   string
+	Message string
+	Color   string
+}
+
+// FromReport builds per-KR, per-objective, and overall health badges.
+// Objective and health values are the mean percent-to-target of KRs that
+// have a current value; KRs without data are shown as "no data".
+func FromReport(report *metrics.KRScoreReport) []Badge {
+	if report == nil {
+		return nil
+	}
+	var out []Badge
+	objSum := make(map[string]float64)
+	objCount := make(map[string]int)
+	var objIDs []string
+	var totalSum float64
+	var totalCount int
+
+	for _, result := range report.Results {
+		if _, seen := objCount[result.ObjectiveID]; !seen {
+			objIDs = append(objIDs, result.ObjectiveID)
+			objCount[result.ObjectiveID] = 0
+		}
+		if result.Current == nil {
+			out = append(out, Badge{Name: "kr-" + fileSafe(result.KRID), Label: result.KRID, Message: "no data", Color: colorFor(-1)})
+			continue
+		}
+		pct := clampPercent(result.PercentToTarget)
+		out = append(out, percentBadge("kr-"+fileSafe(result.KRID), result.KRID, pct))
+		objSum[result.ObjectiveID] += pct
+		objCount[result.ObjectiveID]++
+		totalSum += pct
+		totalCount++
+	}
+
+	sort.Strings(objIDs)
+	for _, id := range objIDs {
+		name := "objective-" + fileSafe(id)
+		if objCount[id] == 0 {
+			out = append(out, Badge{Name: name, Label: id, Message: "no data", Color: colorFor(-1)})
+			continue
+		}
+		out = append(out, percentBadge(name, id, objSum[id]/float64(objCount[id])))
+	}
+
+	if totalCount == 0 {
+		out = append(out, Badge{Name: "health", Label: "OKR health", Message: "no data", Color: colorFor(-1)})
+	} else {
+		out = append(out, percentBadge("health", "OKR health", totalSum/float64(totalCount)))
+	}
+	return out
+}
+
+// WriteAll renders badges from the report into dir as <name>.svg and
+// returns the written paths.
+func WriteAll(dir string, report *metrics.KRScoreReport) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure badges dir: %w", err)
+	}
+	var paths []string
+	for _, badge := range FromReport(report) {
+		path := filepath.Join(dir, badge.Name+".svg")
+		if err := os.WriteFile(path, []byte(SVG(badge)), 0o644); err != nil {
+			return paths, fmt.Errorf("write badge %s: %w", badge.Name, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// SVG renders a flat shields-style badge.
+func SVG(b Badge) string {
+	labelWidth := textWidth(b.Label)
+	messageWidth := textWidth(b.Message)
+	total := labelWidth + messageWidth
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<title>%s: %s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="14">%s</text>
+<text x="%d" y="14">%s</text>
+</g>
+</svg>
+`, total, label, message, label, message,
+		total, labelWidth, labelWidth, messageWidth, b.Color, total,
+		labelWidth/2, label, labelWidth+messageWidth/2, message)
+}
+
+func percentBadge(name, label string, pct float64) Badge {
+	return Badge{
+		Name:    name,
+		Label:   label,
+		Message: fmt.Sprintf("%.0f%%", pct),
+		Color:   colorFor(pct),
+	}
+}
+
+func colorFor(pct float64) string {
+	switch {
+	case pct < 0:
+		return "#9f9f9f"
+	case pct >= 100:
+		return "#4c1"
+	case pct >= 70:
+		return "#97ca00"
+	case pct >= 40:
+		return "#dfb317"
+	default:
+		return "#e05d44"
+	}
+}
+
+func clampPercent(pct float64) float64 {
+	if math.IsNaN(pct) || pct < 0 {
+		return 0
+	}
+	if pct > 100 {
+		return 100
+	}
+	return pct
+}
+
+// textWidth approximates rendered width for 11px Verdana plus padding.
+func textWidth(s string) int {
+	return len([]rune(s))*7 + 10
+}
+
+func fileSafe(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
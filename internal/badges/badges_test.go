@@ -0,0 +1,67 @@
+package badges
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/metrics"
+)
+
+func TestFromReport(t *testing.T) {
+	v := 1.0
+	report := &metrics.KRScoreReport{
+		Results: []metrics.KRScore{
+			{ObjectiveID: "OBJ-1", KRID: "KR-1", Current: &v, PercentToTarget: 100},
+			{ObjectiveID: "OBJ-1", KRID: "KR-2", Current: &v, PercentToTarget: 50},
+			{ObjectiveID: "OBJ-2", KRID: "KR/3"},
+		},
+	}
+
+	got := make(map[string]Badge)
+	for _, b := range FromReport(report) {
+		got[b.Name] = b
+	}
+
+	cases := map[string]string{
+		"kr-KR-1":         "100%",
+		"kr-KR-2":         "50%",
+		"kr-KR_3":         "no data",
+		"objective-OBJ-1": "75%",
+		"objective-OBJ-2": "no data",
+		"health":          "75%",
+	}
+	for name, want := range cases {
+		b, ok := got[name]
+		if !ok {
+			t.Errorf("missing badge %s", name)
+			continue
+		}
+		if b.Message != want {
+			t.Errorf("%s: expected %q, got %q", name, want, b.Message)
+		}
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	v := 3.0
+	report := &metrics.KRScoreReport{
+		Results: []metrics.KRScore{{ObjectiveID: "OBJ-1", KRID: "KR-1", Current: &v, PercentToTarget: 30}},
+	}
+	dir := filepath.Join(t.TempDir(), "badges")
+	paths, err := WriteAll(dir, report)
+	if err != nil {
+		t.Fatalf("write badges: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 badges, got %d", len(paths))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "health.svg"))
+	if err != nil {
+		t.Fatalf("read health badge: %v", err)
+	}
+	if !strings.Contains(string(data), "OKR health: 30%") {
+		t.Fatalf("unexpected badge contents:\n%s", data)
+	}
+}
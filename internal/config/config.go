@@ -0,0 +1,319 @@
+// Package config loads okrchestra.yml, the workspace defaults for settings
+// that are otherwise given as CLI flags. Flags always win over the file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the workspace configuration file at the workspace root.
+const FileName = "okrchestra.yml"
+
+// Config is the contents of okrchestra.yml:
+//
+//	timezone: Europe/Berlin
+//	adapter: claude
+//	daemon:
+//	  poll_interval: 5s
+//	  lease: 1m
+//	  listen: 127.0.0.1:8723
+//	  dashboard: 127.0.0.1:8724
+//	  watch: poll
+//	notifications:
+//	  desktop: false
+//	  dashboard_url: https://okrs.example.com
+//	metrics:
+//	  repo_dir: ../app
+//	  ci_report: build/ci_report.json
+//	  coverage: build/reports
+//	  manual: metrics/manual.yml
+//	  openmetrics_dir: exports/prom
+//	  openmetrics_prefix: svc.
+//	  provider_timeout: 30s
+//	  concurrency: 8
+//	  max_age: 168h
+//	  keys: [deploy.lead_time_hours, survey.*]
+//	  stale: exclude
+//	retention:
+//	  keep_runs: 50
+//	  max_age: 2160h
+//	  max_bytes: 5000000000
+//	  compress_after: 72h
+//	git:
+//	  branches: true
+//	  pull_requests: true
+//	  remote: origin
+//	  base: main
+//
+// Omitted settings keep the values of Default.
+type Config struct {
+	// Timezone is the IANA zone the daemon and tick schedule in.
+	Timezone string `yaml:"timezone"`
+	// Adapter runs agents when a command or job names none.
+	Adapter       string        `yaml:"adapter"`
+	Daemon        Daemon        `yaml:"daemon"`
+	Notifications Notifications `yaml:"notifications"`
+	Metrics       Metrics       `yaml:"metrics"`
+	Retention     Retention     `yaml:"retention"`
+	Git           Git           `yaml:"git"`
+}
+
+// Daemon holds the `daemon run` and `tick` settings.
+type Daemon struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Lease        time.Duration `yaml:"lease"`
+	// Listen serves the HTTP API on this address; empty disables it.
+	Listen string `yaml:"listen"`
+	// Dashboard serves the web dashboard on this address; empty disables
+	// it.
+	Dashboard string `yaml:"dashboard"`
+	// Watch is fsnotify or poll.
+	Watch string `yaml:"watch"`
+}
+
+// Notifications holds the daemon's notification settings. Routing lives
+// in notify.yml.
+type Notifications struct {
+	Desktop      bool   `yaml:"desktop"`
+	DashboardURL string `yaml:"dashboard_url"`
+}
+
+// Metrics holds provider input paths, relative to the workspace root.
+// Empty paths default to the workspace root or files under the metrics dir.
+type Metrics struct {
+	// RepoDir is the git repository measured by the git provider;
+	// default the workspace root.
+	RepoDir  string `yaml:"repo_dir"`
+	CIReport string `yaml:"ci_report"`
+	// Coverage is a coverage or test report, or a directory of them.
+	Coverage          string `yaml:"coverage"`
+	Manual            string `yaml:"manual"`
+	OpenMetricsDir    string `yaml:"openmetrics_dir"`
+	OpenMetricsPrefix string `yaml:"openmetrics_prefix"`
+	// ProviderTimeout bounds each metric provider's collection; 0 means no
+	// limit.
+	ProviderTimeout time.Duration `yaml:"provider_timeout"`
+	// Concurrency is how many metric providers run at once.
+	Concurrency int `yaml:"concurrency"`
+	// MaxAge is how old a metric's observation may be before KRs measured
+	// by it are stale; 0 disables the check.
+	MaxAge time.Duration `yaml:"max_age"`
+	// Stale is flag, to mark stale KRs but score them, or exclude, to
+	// leave their current value out of the score.
+	Stale string `yaml:"stale"`
+	// Keys declares metric keys produced outside the built-in providers,
+	// such as by scripts pushing to the intake; "prefix.*" declares every
+	// key with the prefix.
+	Keys []string `yaml:"keys"`
+}
+
+// Retention is what `gc` prunes from artifacts/runs and artifacts/plans.
+// Zero limits are not applied.
+type Retention struct {
+	// KeepRuns removes runs beyond the newest KeepRuns.
+	KeepRuns int `yaml:"keep_runs"`
+	// MaxAge removes runs and plans not touched for longer.
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxBytes removes the oldest runs until the rest fit.
+	MaxBytes int64 `yaml:"max_bytes"`
+	// CompressAfter gzips transcripts of kept runs not touched for longer.
+	CompressAfter time.Duration `yaml:"compress_after"`
+}
+
+// Git is how plan runs commit their items' changes.
+type Git struct {
+	// Branches commits each succeeded item to okr/<plan-id>/<item-id>.
+	Branches bool `yaml:"branches"`
+	// PullRequests also pushes each item branch and opens a pull request
+	// with gh. It implies Branches.
+	PullRequests bool   `yaml:"pull_requests"`
+	Remote       string `yaml:"remote"`
+	// Base is the branch pull requests target; empty means the branch
+	// checked out when the run starts.
+	Base string `yaml:"base"`
+}
+
+// Default returns the settings used when okrchestra.yml is absent.
+func Default() Config {
+	return Config{
+		Timezone: "America/Chicago",
+		Adapter:  "codex",
+		Daemon: Daemon{
+			PollInterval: time.Second,
+			Lease:        30 * time.Second,
+			Watch:        "fsnotify",
+		},
+		Notifications: Notifications{Desktop: true},
+		Metrics: Metrics{
+			ProviderTimeout: 2 * time.Minute,
+			Concurrency:     4,
+			MaxAge:          14 * 24 * time.Hour,
+			Stale:           "flag",
+		},
+		Retention: Retention{CompressAfter: 7 * 24 * time.Hour},
+		Git:       Git{Remote: "origin"},
+	}
+}
+
+// Path returns the location of okrchestra.yml in the workspace at root.
+func Path(root string) string {
+	return filepath.Join(root, FileName)
+}
+
+// Load reads okrchestra.yml from the workspace root over Default. A
+// missing file is not an error; unknown keys are.
+func Load(root string) (Config, error) {
+	data, err := os.ReadFile(Path(root))
+	if os.IsNotExist(err) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("read %s: %w", FileName, err)
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (Config, error) {
+	cfg := Default()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse %s: %w", FileName, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", FileName, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the settings that would otherwise fail much later, when
+// the daemon starts or a provider runs.
+func (c Config) Validate() error {
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	if strings.TrimSpace(c.Adapter) == "" {
+		return fmt.Errorf("adapter must not be empty")
+	}
+	if c.Daemon.PollInterval <= 0 {
+		return fmt.Errorf("daemon.poll_interval must be positive")
+	}
+	if c.Daemon.Lease <= 0 {
+		return fmt.Errorf("daemon.lease must be positive")
+	}
+	if c.Daemon.Watch != "fsnotify" && c.Daemon.Watch != "poll" {
+		return fmt.Errorf("daemon.watch must be fsnotify or poll, got %q", c.Daemon.Watch)
+	}
+	if c.Metrics.ProviderTimeout < 0 {
+		return fmt.Errorf("metrics.provider_timeout must not be negative")
+	}
+	if c.Metrics.Concurrency <= 0 {
+		return fmt.Errorf("metrics.concurrency must be positive")
+	}
+	if c.Metrics.MaxAge < 0 {
+		return fmt.Errorf("metrics.max_age must not be negative")
+	}
+	if c.Metrics.Stale != "flag" && c.Metrics.Stale != "exclude" {
+		return fmt.Errorf("metrics.stale must be flag or exclude, got %q", c.Metrics.Stale)
+	}
+	if c.Retention.KeepRuns < 0 {
+		return fmt.Errorf("retention.keep_runs must not be negative")
+	}
+	if c.Retention.MaxAge < 0 {
+		return fmt.Errorf("retention.max_age must not be negative")
+	}
+	if c.Retention.MaxBytes < 0 {
+		return fmt.Errorf("retention.max_bytes must not be negative")
+	}
+	if c.Retention.CompressAfter < 0 {
+		return fmt.Errorf("retention.compress_after must not be negative")
+	}
+	if strings.TrimSpace(c.Git.Remote) == "" {
+		return fmt.Errorf("git.remote must not be empty")
+	}
+	return nil
+}
+
+// Set writes value at key (dotted, e.g. daemon.poll_interval) into the
+// workspace's okrchestra.yml, creating the file if needed. Other keys and
+// comments are kept. The file is only written when the result loads.
+func Set(root, key, value string) (Config, error) {
+	path := Path(root)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return Config{}, fmt.Errorf("read %s: %w", FileName, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", FileName, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return Config{}, fmt.Errorf("%s: top level must be a mapping", FileName)
+	}
+
+	parts := strings.Split(key, ".")
+	node := doc.Content[0]
+	for i, part := range parts {
+		if part == "" {
+			return Config{}, fmt.Errorf("invalid key %q", key)
+		}
+		child := lookup(node, part)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			if i == len(parts)-1 {
+				child = &yaml.Node{Kind: yaml.ScalarNode}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+		if i < len(parts)-1 && child.Kind != yaml.MappingNode {
+			return Config{}, fmt.Errorf("%s is not a section", strings.Join(parts[:i+1], "."))
+		}
+		node = child
+	}
+	if node.Kind != yaml.ScalarNode {
+		return Config{}, fmt.Errorf("%s is a section; set one of its keys", key)
+	}
+	node.Tag = ""
+	node.Style = 0
+	node.Value = value
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return Config{}, fmt.Errorf("encode %s: %w", FileName, err)
+	}
+	if err := enc.Close(); err != nil {
+		return Config{}, fmt.Errorf("encode %s: %w", FileName, err)
+	}
+	cfg, err := parse(buf.Bytes())
+	if err != nil {
+		return Config{}, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return Config{}, fmt.Errorf("write %s: %w", FileName, err)
+	}
+	return cfg, nil
+}
+
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefaultsAndOverrides(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatalf("load without file: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("config without file = %+v", cfg)
+	}
+
+	content := "timezone: Europe/Berlin\ndaemon:\n  poll_interval: 5s\nnotifications:\n  desktop: false\n"
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Timezone != "Europe/Berlin" || cfg.Daemon.PollInterval != 5*time.Second || cfg.Notifications.Desktop {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if cfg.Adapter != "codex" || cfg.Daemon.Lease != 30*time.Second || cfg.Daemon.Watch != "fsnotify" {
+		t.Fatalf("omitted settings lost their defaults: %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		"timezone: Mars/Olympus\n",
+		"daemon:\n  watch: inotify\n",
+		"daemon:\n  poll_interval: 0s\n",
+		"adapterr: codex\n",
+		"metrics:\n  stale: drop\n",
+		"metrics:\n  max_age: -1h\n",
+		"retention:\n  keep_runs: -1\n",
+		"git:\n  remote: \"\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, FileName), []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(root); err == nil {
+			t.Fatalf("accepted %q", bad)
+		}
+	}
+}
+
+func TestSetKeepsCommentsAndValidates(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Set(root, "daemon.listen", ":8723"); err != nil {
+		t.Fatalf("set in new file: %v", err)
+	}
+	path := filepath.Join(root, FileName)
+	if err := os.WriteFile(path, []byte("# team settings\nadapter: codex # default agent\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Set(root, "adapter", "claude")
+	if err != nil {
+		t.Fatalf("set adapter: %v", err)
+	}
+	if cfg.Adapter != "claude" {
+		t.Fatalf("adapter = %q", cfg.Adapter)
+	}
+	if _, err := Set(root, "notifications.desktop", "false"); err != nil {
+		t.Fatalf("set desktop: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# team settings") || !strings.Contains(string(data), "# default agent") {
+		t.Fatalf("comments lost:\n%s", data)
+	}
+	cfg, err = Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Adapter != "claude" || cfg.Notifications.Desktop {
+		t.Fatalf("reloaded config = %+v", cfg)
+	}
+
+	for _, tc := range [][2]string{
+		{"daemon.poll_interval", "soon"},
+		{"daemon.pol_interval", "5s"},
+		{"daemon", "x"},
+		{"adapter.name", "x"},
+	} {
+		if _, err := Set(root, tc[0], tc[1]); err == nil {
+			t.Fatalf("set %s=%s accepted", tc[0], tc[1])
+		}
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Fatalf("rejected set rewrote the file:\n%s", after)
+	}
+}
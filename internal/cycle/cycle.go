@@ -0,0 +1,470 @@
+package cycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/outcomes"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+const (
+	OutcomeSucceeded        = "succeeded"
+	OutcomeAwaitingApproval = "awaiting_approval"
+	OutcomeNoProgress       = "no_progress"
+	OutcomeFailed           = "failed"
+)
+
+const (
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+// Options configures a single measure → score → generate → execute → re-measure cycle.
+type Options struct {
+	Workspace   *workspace.Workspace
+	RepoDir     string
+	WorkDir     string
+	AsOf        time.Time
+	ObjectiveID string
+	KRID        string
+	AgentRole   string
+	Adapter     adapters.AgentAdapter
+	Timeout     time.Duration
+	// Approve allows the generated plan to execute. Without it the cycle
+	// stops after generation with OutcomeAwaitingApproval.
+	Approve bool
+	// RequireProgress fails the cycle when the targeted KR did not move
+	// toward its target after execution.
+	RequireProgress bool
+	// SuccessCriteria, when set, is recorded on the plan and the run is
+	// tracked in the plan outcomes ledger.
+	SuccessCriteria *planner.SuccessCriteria
+	// StrictMetrics fails the measure steps when any metric provider fails
+	// instead of skipping it.
+	StrictMetrics bool
+	// Git commits the executed plan's items to branches; the re-measure
+	// step then sees their changes only once the branches are merged.
+	Git         planner.GitOptions
+	AuditLogger *audit.Logger
+}
+
+// StepReport records the outcome of one pipeline step.
+type StepReport struct {
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	StartedAt  string         `json:"started_at,omitempty"`
+	FinishedAt string         `json:"finished_at,omitempty"`
+	Outputs    map[string]any `json:"outputs,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Report is the consolidated cycle report written to artifacts/cycles/<cycle-id>/cycle.json.
+type Report struct {
+	SchemaVersion int          `json:"schema_version"`
+	CycleID       string       `json:"cycle_id"`
+	AsOf          string       `json:"as_of"`
+	StartedAt     string       `json:"started_at"`
+	FinishedAt    string       `json:"finished_at"`
+	Outcome       string       `json:"outcome"`
+	ObjectiveID   string       `json:"objective_id,omitempty"`
+	KRID          string       `json:"kr_id,omitempty"`
+	MetricKey     string       `json:"metric_key,omitempty"`
+	PercentBefore *float64     `json:"percent_before,omitempty"`
+	PercentAfter  *float64     `json:"percent_after,omitempty"`
+	PercentDelta  *float64     `json:"percent_delta,omitempty"`
+	PlanPath      string       `json:"plan_path,omitempty"`
+	RunDir        string       `json:"run_dir,omitempty"`
+	Steps         []StepReport `json:"steps"`
+	ReportPath    string       `json:"-"`
+	CycleDir      string       `json:"-"`
+}
+
+// RunOnce executes one full cycle and writes the consolidated report.
+// The report is returned even when a step fails so callers can surface it.
+func RunOnce(ctx context.Context, opts Options) (*Report, error) {
+	ws := opts.Workspace
+	if ws == nil {
+		return nil, fmt.Errorf("workspace is required")
+	}
+	if opts.Approve && opts.Adapter == nil {
+		return nil, fmt.Errorf("adapter is required")
+	}
+	if opts.AsOf.IsZero() {
+		opts.AsOf = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if opts.RepoDir == "" {
+		opts.RepoDir = ws.Root
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir = ws.Root
+	}
+
+	started := time.Now().UTC()
+	cyclesDir := filepath.Join(ws.ArtifactsDir, "cycles")
+	if err := os.MkdirAll(cyclesDir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure cycles dir: %w", err)
+	}
+	// Cycles started in the same second get a -2, -3, ... suffix rather
+	// than sharing a directory.
+	base := started.Format("20060102T150405Z")
+	cycleID := base
+	var cycleDir string
+	for i := 2; ; i++ {
+		cycleDir = filepath.Join(cyclesDir, cycleID)
+		if err := os.Mkdir(cycleDir, 0o755); err == nil {
+			break
+		} else if !os.IsExist(err) {
+			return nil, fmt.Errorf("create cycle dir: %w", err)
+		}
+		cycleID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	report := &Report{
+		SchemaVersion: 1,
+		CycleID:       cycleID,
+		AsOf:          opts.AsOf.Format("2006-01-02"),
+		StartedAt:     started.Format(time.RFC3339),
+		ReportPath:    filepath.Join(cycleDir, "cycle.json"),
+		CycleDir:      cycleDir,
+	}
+
+	runErr := runSteps(ctx, opts, report)
+	report.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	if runErr != nil {
+		report.Outcome = OutcomeFailed
+	}
+
+	if err := writeReport(report); err != nil {
+		if runErr != nil {
+			return report, runErr
+		}
+		return report, err
+	}
+	if runErr != nil {
+		return report, runErr
+	}
+	if report.Outcome == OutcomeNoProgress && opts.RequireProgress {
+		return report, fmt.Errorf("cycle %s: KR %s made no progress", report.CycleID, report.KRID)
+	}
+	return report, nil
+}
+
+func runSteps(ctx context.Context, opts Options, report *Report) error {
+	ws := opts.Workspace
+	cycleDir := report.CycleDir
+
+	var before *metrics.KRScoreReport
+	var snapshotPath string
+	err := step(report, "measure", func(out map[string]any) error {
+		var changes int
+		var err error
+		snapshotPath, changes, err = measure(ctx, opts)
+		out["snapshot_path"] = ws.RelPath(snapshotPath)
+		out["status_changes"] = changes
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = step(report, "score", func(out map[string]any) error {
+		var err error
+		before, err = score(ws, snapshotPath, filepath.Join(cycleDir, "score_before.json"))
+		if before != nil {
+			out["score_report"] = ws.RelPath(filepath.Join(cycleDir, "score_before.json"))
+			out["results"] = len(before.Results)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var generated planner.GenerateResult
+	err = step(report, "generate", func(out map[string]any) error {
+		var err error
+		generated, err = planner.GeneratePlan(planner.GenerateOptions{
+			OKRsDir:         ws.OKRsDir,
+			OutputBaseDir:   filepath.Join(ws.ArtifactsDir, "plans"),
+			AsOf:            opts.AsOf,
+			ObjectiveID:     opts.ObjectiveID,
+			KRID:            opts.KRID,
+			AgentRole:       opts.AgentRole,
+			CacheDir:        filepath.Join(ws.ArtifactsDir, "cache"),
+			RunsDir:         filepath.Join(ws.ArtifactsDir, "runs"),
+			SnapshotsDir:    filepath.Join(ws.MetricsDir, "snapshots"),
+			WorkspaceRoot:   ws.Root,
+			SuccessCriteria: opts.SuccessCriteria,
+		})
+		if err != nil {
+			return err
+		}
+		out["plan_path"] = ws.RelPath(generated.PlanPath)
+		out["items"] = len(generated.Plan.Items)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report.PlanPath = ws.RelPath(generated.PlanPath)
+	if len(generated.Plan.Items) > 0 {
+		item := generated.Plan.Items[0]
+		report.ObjectiveID = item.ObjectiveID
+		report.KRID = item.KRID
+		report.MetricKey = item.ExpectedMetricChange.MetricKey
+	}
+	report.PercentBefore = percentFor(before, report.KRID)
+
+	if !opts.Approve {
+		skip(report, "approve", "plan requires approval (rerun with --approve)")
+		skip(report, "execute", "plan not approved")
+		skip(report, "remeasure", "plan not approved")
+		report.Outcome = OutcomeAwaitingApproval
+		return nil
+	}
+	err = step(report, "approve", func(out map[string]any) error {
+		out["approved_by"] = "flag"
+		_, err := planner.ApprovePlan(generated.PlanPath, "cycle --approve", time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = step(report, "execute", func(out map[string]any) error {
+		language, err := locale.LoadLanguage(ws.Root)
+		if err != nil {
+			return err
+		}
+		hooks, err := planner.LoadHooks(ws.Root)
+		if err != nil {
+			return err
+		}
+		sandbox, err := adapters.LoadSandbox(ws.Root, opts.Adapter.Name())
+		if err != nil {
+			return err
+		}
+		envCfg, err := adapters.LoadEnv(ws.Root)
+		if err != nil {
+			return err
+		}
+		runResult, err := planner.RunPlan(ctx, planner.RunOptions{
+			PlanPath:          generated.PlanPath,
+			WorkDir:           opts.WorkDir,
+			Adapter:           opts.Adapter,
+			Timeout:           opts.Timeout,
+			AuditLogger:       opts.AuditLogger,
+			RunBaseDir:        filepath.Join(ws.ArtifactsDir, "runs"),
+			IndexArtifactsDir: ws.ArtifactsDir,
+			Language:          language,
+			PromptDir:         filepath.Join(ws.Root, planner.PromptDirName),
+			RoleTemplateDir:   filepath.Join(ws.Root, planner.RoleTemplateDirName),
+			Hooks:             hooks,
+			Git:               opts.Git,
+			Sandbox:           sandbox,
+			SandboxIgnore:     ws.StatePaths(),
+			Env:               envCfg,
+		})
+		if runResult != nil {
+			report.RunDir = ws.RelPath(runResult.RunDir)
+			out["run_id"] = runResult.RunID
+			out["run_dir"] = ws.RelPath(runResult.RunDir)
+			succeeded, _, _ := runResult.Counts()
+			out["items_succeeded"] = succeeded
+			out["usage"] = runResult.Usage
+		}
+		if class, ok := planner.ClassifyFailure(err); ok {
+			out["failure_class"] = string(class)
+		}
+		if err != nil {
+			return err
+		}
+		outcome, err := outcomes.Track(ws, generated.PlanPath, runResult)
+		if outcome != nil {
+			out["outcome_deadline"] = outcome.Deadline
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var after *metrics.KRScoreReport
+	err = step(report, "remeasure", func(out map[string]any) error {
+		snapshotPath, changes, err := measure(ctx, opts)
+		out["snapshot_path"] = ws.RelPath(snapshotPath)
+		out["status_changes"] = changes
+		if err != nil {
+			return err
+		}
+		after, err = score(ws, snapshotPath, filepath.Join(cycleDir, "score_after.json"))
+		if after != nil {
+			out["score_report"] = ws.RelPath(filepath.Join(cycleDir, "score_after.json"))
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	report.PercentAfter = percentFor(after, report.KRID)
+	report.Outcome = OutcomeSucceeded
+	if report.PercentBefore != nil && report.PercentAfter != nil {
+		delta := *report.PercentAfter - *report.PercentBefore
+		report.PercentDelta = &delta
+		if delta <= 0 {
+			report.Outcome = OutcomeNoProgress
+		}
+	}
+	return nil
+}
+
+func measure(ctx context.Context, opts Options) (string, int, error) {
+	ws := opts.Workspace
+	providerCfg := metrics.ProviderConfig{
+		RepoDir:    opts.RepoDir,
+		MetricsDir: ws.MetricsDir,
+		AsOf:       opts.AsOf,
+	}
+	if err := providerCfg.LoadWorkspaceConfig(ws.Root); err != nil {
+		return "", 0, err
+	}
+	providers := metrics.DefaultProviders(providerCfg)
+	points, collection, err := metrics.CollectReport(ctx, providers, metrics.CollectOptions{Strict: opts.StrictMetrics, Timeout: providerCfg.ProviderTimeout, Concurrency: providerCfg.Concurrency})
+	if err != nil {
+		return "", 0, fmt.Errorf("collect metrics: %w", err)
+	}
+	providerErrors := collection.Failures()
+	snapshotPath := metrics.SnapshotPathForDate(filepath.Join(ws.MetricsDir, "snapshots"), opts.AsOf)
+	snapshot := metrics.Snapshot{
+		AsOf:           opts.AsOf.Format("2006-01-02"),
+		Points:         points,
+		ProviderErrors: providerErrors,
+	}
+	if err := metrics.WriteSnapshot(snapshotPath, snapshot); err != nil {
+		return snapshotPath, 0, err
+	}
+	// Best-effort: `kr history --rebuild` restores the history from snapshots.
+	_ = metrics.RecordHistory(filepath.Dir(snapshotPath), &snapshot)
+	changes, err := metrics.UpdateKRStatus(ws.OKRsDir, &snapshot)
+	if err != nil {
+		return snapshotPath, 0, fmt.Errorf("update kr status: %w", err)
+	}
+	if opts.AuditLogger != nil {
+		for _, change := range changes {
+			_ = opts.AuditLogger.LogEvent("okr", "kr_status_auto_updated", map[string]any{
+				"kr_id":        change.KRID,
+				"objective_id": change.ObjectiveID,
+				"old_status":   change.OldStatus,
+				"new_status":   change.NewStatus,
+				"current":      change.Current,
+				"target":       change.Target,
+				"evidence":     change.Evidence,
+				"trigger":      "cycle",
+				"snapshot":     snapshotPath,
+			})
+		}
+	}
+	return snapshotPath, len(changes), nil
+}
+
+func score(ws *workspace.Workspace, snapshotPath string, outPath string) (*metrics.KRScoreReport, error) {
+	snapshot, err := metrics.LoadSnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return nil, err
+	}
+	report, err := metrics.ScoreKRs(store, snapshot, ws.RelPath(snapshotPath))
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := metrics.LoadAnnotations(filepath.Dir(snapshotPath))
+	if err != nil {
+		return nil, err
+	}
+	metrics.AnnotateReport(report, annotations)
+	policy, err := metrics.LoadFreshnessPolicy(ws.Root, ws.OKRsDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := metrics.ApplyFreshness(report, store, snapshot, policy); err != nil {
+		return nil, err
+	}
+	// Trends are advisory; a broken history must not fail the cycle.
+	_ = metrics.ApplyTrends(report, filepath.Dir(snapshotPath), metrics.DefaultTrendWindowDays)
+	if err := metrics.WriteScoreReport(outPath, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func percentFor(report *metrics.KRScoreReport, krID string) *float64 {
+	if report == nil || krID == "" {
+		return nil
+	}
+	for _, result := range report.Results {
+		if result.KRID == krID && result.Current != nil {
+			pct := result.PercentToTarget
+			return &pct
+		}
+	}
+	return nil
+}
+
+func step(report *Report, name string, fn func(out map[string]any) error) error {
+	s := StepReport{
+		Name:      name,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Outputs:   map[string]any{},
+	}
+	err := fn(s.Outputs)
+	s.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	s.Status = StepSucceeded
+	if err != nil {
+		s.Status = StepFailed
+		s.Error = err.Error()
+	}
+	if len(s.Outputs) == 0 {
+		s.Outputs = nil
+	}
+	report.Steps = append(report.Steps, s)
+	if err != nil {
+		return fmt.Errorf("cycle %s: %w", name, err)
+	}
+	return nil
+}
+
+func skip(report *Report, name string, reason string) {
+	report.Steps = append(report.Steps, StepReport{
+		Name:    name,
+		Status:  StepSkipped,
+		Outputs: map[string]any{"reason": reason},
+	})
+}
+
+func writeReport(report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cycle report: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(report.ReportPath, data, 0o644); err != nil {
+		return fmt.Errorf("write cycle report: %w", err)
+	}
+	return nil
+}
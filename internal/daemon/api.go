@@ -0,0 +1,392 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"okrchestra/internal/dashboard"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/planner"
+)
+
+// DefaultAPIJobLimit caps GET /jobs when no limit is given.
+const DefaultAPIJobLimit = 50
+
+// APIHandler returns the daemon HTTP API:
+//
+//	GET  /health            daemon and queue health
+//	GET  /jobs              recent jobs (?status=queued&limit=N)
+//	POST /jobs              enqueue {"type", "scheduled_at", "payload"}
+//	GET  /jobs/{id}         one job, with progress while it runs
+//	GET  /jobs/{id}/transcript  the running item's transcript (?lines=N)
+//	POST /jobs/{id}/cancel  cancel a queued job
+//	POST /jobs/{id}/kill    stop a running job, or cancel a queued one
+//	POST /jobs/{id}/retry   requeue a failed or canceled job
+//	POST /metrics           push metric points for the next kr_measure
+//	GET  /badges/{name}.svg a badge written by kr score --badges
+//
+// Jobs are encoded as the Store's Job type. Pushed points are a JSON array
+// of metric points or {"points": [...]}; they are appended to the
+// workspace's metrics intake.
+func (d *Daemon) APIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", d.handleHealth)
+	mux.HandleFunc("GET /jobs", d.handleListJobs)
+	mux.HandleFunc("POST /jobs", d.handleEnqueueJob)
+	mux.HandleFunc("GET /jobs/{id}", d.handleGetJob)
+	mux.HandleFunc("GET /jobs/{id}/transcript", d.handleJobTranscript)
+	mux.HandleFunc("POST /jobs/{id}/cancel", d.handleCancelJob)
+	mux.HandleFunc("POST /jobs/{id}/kill", d.handleKillJob)
+	mux.HandleFunc("POST /jobs/{id}/retry", d.handleRetryJob)
+	mux.HandleFunc("POST /metrics", d.handleIngestMetrics)
+	mux.HandleFunc("GET /badges/{file}", d.handleBadge)
+	return mux
+}
+
+// serveAPI serves the API on addr until ctx is done.
+func (d *Daemon) serveAPI(ctx context.Context, addr string) error {
+	return serveHTTP(ctx, "api", addr, d.APIHandler())
+}
+
+// serveDashboard serves the web dashboard on addr until ctx is done.
+func (d *Daemon) serveDashboard(ctx context.Context, addr string) error {
+	return serveHTTP(ctx, "dashboard", addr, dashboard.Handler(d.Workspace))
+}
+
+// serveHTTP serves handler on addr in the background until ctx is done;
+// name labels errors.
+func serveHTTP(ctx context.Context, name, addr string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%s: listen on %s: %w", name, addr, err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "daemon %s stopped: %v\n", name, err)
+		}
+	}()
+	return nil
+}
+
+// HealthResponse is the body of GET /health.
+type HealthResponse struct {
+	Status     string     `json:"status"`
+	Workspace  string     `json:"workspace"`
+	LeaseOwner string     `json:"lease_owner"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	Queued     int        `json:"queued"`
+	Running    int        `json:"running"`
+}
+
+// JobDetail is the body of GET /jobs/{id}.
+type JobDetail struct {
+	Job
+	Progress *JobProgress `json:"progress,omitempty"`
+}
+
+// DefaultAPITranscriptLines is how much of a transcript GET
+// /jobs/{id}/transcript returns when no lines are given.
+const DefaultAPITranscriptLines = 200
+
+// TranscriptResponse is the body of GET /jobs/{id}/transcript: the end of
+// the transcript of the plan item a running plan_execute job started last.
+type TranscriptResponse struct {
+	JobID      string `json:"job_id"`
+	RunID      string `json:"run_id"`
+	ItemID     string `json:"item_id"`
+	Path       string `json:"path"`
+	Transcript string `json:"transcript"`
+}
+
+// EnqueueRequest is the body of POST /jobs. ScheduledAt defaults to now.
+type EnqueueRequest struct {
+	Type        string          `json:"type"`
+	ScheduledAt *time.Time      `json:"scheduled_at,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Priority    int             `json:"priority,omitempty"`
+}
+
+func (d *Daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+	queued, err := d.Store.ListQueued(-1)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	running, err := d.Store.ListRunning()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := HealthResponse{
+		Status:     "ok",
+		Workspace:  d.Workspace.Root,
+		LeaseOwner: d.LeaseOwner,
+		Queued:     len(queued),
+		Running:    len(running),
+	}
+	if !d.startedAt.IsZero() {
+		startedAt := d.startedAt
+		resp.StartedAt = &startedAt
+	}
+	writeAPIJSON(w, http.StatusOK, resp)
+}
+
+func (d *Daemon) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultAPIJobLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("limit must be a positive integer"))
+			return
+		}
+		limit = n
+	}
+	var jobs []Job
+	var err error
+	if status := r.URL.Query().Get("status"); status != "" {
+		jobs, err = d.Store.ListJobsByStatus(status, limit)
+	} else {
+		jobs, err = d.Store.ListJobs(limit)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []Job{}
+	}
+	writeAPIJSON(w, http.StatusOK, jobs)
+}
+
+func (d *Daemon) handleEnqueueJob(w http.ResponseWriter, r *http.Request) {
+	var req EnqueueRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("parse request: %w", err))
+		return
+	}
+	if req.Type == "" {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("type is required"))
+		return
+	}
+	if _, ok := d.Handlers[req.Type]; !ok {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("no handler for job type: %s", req.Type))
+		return
+	}
+	payload := map[string]any{}
+	if len(req.Payload) > 0 {
+		if err := json.Unmarshal(req.Payload, &payload); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("payload must be a JSON object: %w", err))
+			return
+		}
+	}
+	scheduledAt := time.Now()
+	if req.ScheduledAt != nil {
+		scheduledAt = *req.ScheduledAt
+	}
+
+	jobID, created, err := d.Store.EnqueueUniqueWithPriority(req.Type, scheduledAt, payload, req.Priority)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	job, err := d.Store.GetJob(jobID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		_ = d.AuditLogger.LogEvent("daemon", "job_enqueued", map[string]any{
+			"job_id":   job.ID,
+			"job_type": job.Type,
+			"source":   "api",
+		})
+	}
+	writeAPIJSON(w, status, job)
+}
+
+func (d *Daemon) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := d.Store.GetJob(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+		return
+	}
+	detail := JobDetail{Job: *job}
+	if job.Status == "running" {
+		if detail.Progress, err = d.Store.GetProgress(job.ID); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeAPIJSON(w, http.StatusOK, detail)
+}
+
+func (d *Daemon) handleJobTranscript(w http.ResponseWriter, r *http.Request) {
+	lines := DefaultAPITranscriptLines
+	if raw := r.URL.Query().Get("lines"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("lines must be a non-negative integer"))
+			return
+		}
+		lines = n
+	}
+	job, err := d.Store.GetJob(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+		return
+	}
+	if job.Status != "running" {
+		writeAPIError(w, http.StatusConflict, fmt.Errorf("job %s is not running", job.ID))
+		return
+	}
+	progress, err := d.Store.GetProgress(job.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if progress == nil || progress.RunID == "" || progress.ItemIndex < 1 {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("job %s has not started a plan item", job.ID))
+		return
+	}
+	path := filepath.Join(d.Workspace.ArtifactsDir, "runs", progress.RunID, fmt.Sprintf("item-%04d", progress.ItemIndex), "transcript.log")
+	data, err := planner.TailTranscript(path, lines)
+	if os.IsNotExist(err) {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no transcript yet for item %s", progress.ItemID))
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, TranscriptResponse{
+		JobID:      job.ID,
+		RunID:      progress.RunID,
+		ItemID:     progress.ItemID,
+		Path:       d.Workspace.RelPath(path),
+		Transcript: string(data),
+	})
+}
+
+// handleBadge serves an SVG badge from <artifacts>/badges. Only .svg files
+// directly inside it are served.
+func (d *Daemon) handleBadge(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	if name, ok := strings.CutSuffix(file, ".svg"); !ok || name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no badge %s", file))
+		return
+	}
+	f, err := os.OpenInRoot(filepath.Join(d.Workspace.ArtifactsDir, "badges"), file)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no badge %s", file))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no badge %s", file))
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, file, info.ModTime(), f)
+}
+
+func (d *Daemon) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	d.handleJobAction(w, r, d.Store.Cancel, "job_canceled")
+}
+
+func (d *Daemon) handleKillJob(w http.ResponseWriter, r *http.Request) {
+	d.handleJobAction(w, r, d.Store.RequestCancel, "job_kill_requested")
+}
+
+func (d *Daemon) handleRetryJob(w http.ResponseWriter, r *http.Request) {
+	d.handleJobAction(w, r, d.Store.Requeue, "job_requeued")
+}
+
+// handleJobAction applies a status change to the job in the path and
+// responds with the updated job.
+func (d *Daemon) handleJobAction(w http.ResponseWriter, r *http.Request, action func(string) error, eventType string) {
+	jobID := r.PathValue("id")
+	if err := action(jobID); err != nil {
+		writeAPIError(w, apiErrorStatus(err), err)
+		return
+	}
+	job, err := d.Store.GetJob(jobID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_ = d.AuditLogger.LogEvent("daemon", eventType, map[string]any{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"source":   "api",
+	})
+	writeAPIJSON(w, http.StatusOK, job)
+}
+
+// IngestResponse is the body of POST /metrics.
+type IngestResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+func (d *Daemon) handleIngestMetrics(w http.ResponseWriter, r *http.Request) {
+	points, err := metrics.DecodeIntake(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	intakePath := metrics.IntakePath(d.Workspace.MetricsDir)
+	stored, err := metrics.AppendIntake(intakePath, points, time.Now())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, metrics.ErrInvalidPoint) {
+			status = http.StatusBadRequest
+		}
+		writeAPIError(w, status, err)
+		return
+	}
+	_ = d.AuditLogger.LogEvent("daemon", "metrics_ingested", map[string]any{
+		"points": len(stored),
+		"source": "api",
+	})
+	writeAPIJSON(w, http.StatusOK, IngestResponse{Accepted: len(stored)})
+}
+
+func apiErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJobNotQueued), errors.Is(err, ErrJobNotRunning), errors.Is(err, ErrJobNotRetryable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/workspace"
+)
+
+func newAPITestServer(t *testing.T) (*Daemon, *httptest.Server) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir, MetricsDir: filepath.Join(tmpDir, "metrics")},
+		Store:       store,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "test",
+		LeaseFor:    time.Minute,
+		Handlers: map[string]HandlerFunc{
+			"echo": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				return map[string]any{}, nil
+			},
+		},
+	}
+	server := httptest.NewServer(d.APIHandler())
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+func apiRequest(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPIEnqueueInspectAndCancel(t *testing.T) {
+	_, server := newAPITestServer(t)
+
+	var job Job
+	body := `{"type":"echo","scheduled_at":"2030-01-02T03:04:00Z","payload":{"note":"hi"}}`
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs", body, &job); code != http.StatusCreated {
+		t.Fatalf("enqueue status = %d, want 201", code)
+	}
+	if job.Type != "echo" || job.Status != "queued" || !strings.Contains(job.PayloadJSON, `"note":"hi"`) {
+		t.Fatalf("unexpected enqueued job: %+v", job)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs", body, nil); code != http.StatusOK {
+		t.Fatalf("duplicate enqueue status = %d, want 200", code)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs", `{"type":"nope"}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown type status = %d, want 400", code)
+	}
+
+	var detail JobDetail
+	if code := apiRequest(t, http.MethodGet, server.URL+"/jobs/"+job.ID, "", &detail); code != http.StatusOK {
+		t.Fatalf("get status = %d, want 200", code)
+	}
+	if detail.ID != job.ID {
+		t.Fatalf("get returned %q, want %q", detail.ID, job.ID)
+	}
+
+	var queued []Job
+	apiRequest(t, http.MethodGet, server.URL+"/jobs?status=queued", "", &queued)
+	if len(queued) != 1 {
+		t.Fatalf("expected 1 queued job, got %d", len(queued))
+	}
+
+	var health HealthResponse
+	apiRequest(t, http.MethodGet, server.URL+"/health", "", &health)
+	if health.Status != "ok" || health.Queued != 1 || health.Running != 0 {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+
+	var canceled Job
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs/"+job.ID+"/cancel", "", &canceled); code != http.StatusOK {
+		t.Fatalf("cancel status = %d, want 200", code)
+	}
+	if canceled.Status != "canceled" {
+		t.Fatalf("canceled job status = %q", canceled.Status)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs/"+job.ID+"/cancel", "", nil); code != http.StatusConflict {
+		t.Fatalf("second cancel status = %d, want 409", code)
+	}
+
+	var requeued Job
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs/"+job.ID+"/retry", "", &requeued); code != http.StatusOK {
+		t.Fatalf("retry status = %d, want 200", code)
+	}
+	if requeued.Status != "queued" {
+		t.Fatalf("retried job status = %q", requeued.Status)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/jobs/"+job.ID+"/retry", "", nil); code != http.StatusConflict {
+		t.Fatalf("retry of queued job status = %d, want 409", code)
+	}
+	if code := apiRequest(t, http.MethodGet, server.URL+"/jobs/missing", "", nil); code != http.StatusNotFound {
+		t.Fatalf("missing job status = %d, want 404", code)
+	}
+}
+
+func TestAPIIngestMetrics(t *testing.T) {
+	d, server := newAPITestServer(t)
+
+	var resp IngestResponse
+	body := `{"points":[{"key":"sales.signups","value":12,"timestamp":"2025-01-06T10:00:00Z"},{"key":"ops.pages","value":3}]}`
+	if code := apiRequest(t, http.MethodPost, server.URL+"/metrics", body, &resp); code != http.StatusOK {
+		t.Fatalf("ingest status = %d, want 200", code)
+	}
+	if resp.Accepted != 2 {
+		t.Fatalf("accepted = %d, want 2", resp.Accepted)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/metrics", `[{"value":1}]`, nil); code != http.StatusBadRequest {
+		t.Fatalf("missing key status = %d, want 400", code)
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/metrics", `not json`, nil); code != http.StatusBadRequest {
+		t.Fatalf("malformed body status = %d, want 400", code)
+	}
+
+	provider := &metrics.IntakeProvider{Path: metrics.IntakePath(d.Workspace.MetricsDir), AsOf: time.Now()}
+	points, err := provider.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect intake: %v", err)
+	}
+	if len(points) != 2 || points[0].Key != "sales.signups" || points[1].Source != metrics.IntakeSource {
+		t.Fatalf("unexpected intake points: %+v", points)
+	}
+}
+
+func TestCanceledJobsAreNotClaimed(t *testing.T) {
+	d, _ := newAPITestServer(t)
+	jobID, _, err := d.Store.EnqueueUnique("echo", time.Now().Add(-time.Minute), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := d.Store.Cancel(jobID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	job, err := d.claimAndExecute(context.Background())
+	if err != nil || job != nil {
+		t.Fatalf("expected no job to run, got %v, %v", job, err)
+	}
+}
+
+func TestAPIJobTranscript(t *testing.T) {
+	d, server := newAPITestServer(t)
+	d.Workspace.ArtifactsDir = filepath.Join(d.Workspace.Root, "artifacts")
+
+	jobID, _, err := d.Store.EnqueueUnique("echo", time.Now().Add(-time.Minute), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	url := server.URL + "/jobs/" + jobID + "/transcript"
+	if code := apiRequest(t, http.MethodGet, url, "", nil); code != http.StatusConflict {
+		t.Fatalf("queued job status = %d, want 409", code)
+	}
+	if _, err := d.Store.ClaimNext(time.Now(), "test", time.Minute); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if code := apiRequest(t, http.MethodGet, url, "", nil); code != http.StatusNotFound {
+		t.Fatalf("job without progress status = %d, want 404", code)
+	}
+
+	itemDir := filepath.Join(d.Workspace.ArtifactsDir, "runs", "run-1", "item-0002")
+	if err := os.MkdirAll(itemDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(itemDir, "transcript.log"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Store.SetProgress(JobProgress{JobID: jobID, RunID: "run-1", ItemIndex: 2, ItemID: "item-b", ItemsDone: 1, ItemsTotal: 3}); err != nil {
+		t.Fatalf("set progress: %v", err)
+	}
+
+	var resp TranscriptResponse
+	if code := apiRequest(t, http.MethodGet, url+"?lines=2", "", &resp); code != http.StatusOK {
+		t.Fatalf("transcript status = %d, want 200", code)
+	}
+	if resp.ItemID != "item-b" || resp.Transcript != "two\nthree\n" || resp.Path != "artifacts/runs/run-1/item-0002/transcript.log" {
+		t.Fatalf("unexpected transcript: %+v", resp)
+	}
+}
+
+func TestAPIBadges(t *testing.T) {
+	d, server := newAPITestServer(t)
+	d.Workspace.ArtifactsDir = filepath.Join(d.Workspace.Root, "artifacts")
+	badgesDir := filepath.Join(d.Workspace.ArtifactsDir, "badges")
+	if err := os.MkdirAll(badgesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(badgesDir, "health.svg"), []byte("<svg/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(d.Workspace.ArtifactsDir, "secret.svg"), []byte("<svg/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(server.URL + "/badges/health.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("badge: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	for _, path := range []string{"/badges/missing.svg", "/badges/health.json", "/badges/..%2Fsecret.svg", "/badges/.svg"} {
+		if code := apiRequest(t, http.MethodGet, server.URL+path, "", nil); code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, code)
+		}
+	}
+	if code := apiRequest(t, http.MethodPost, server.URL+"/badges/health.svg", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST badge = %d, want 405", code)
+	}
+}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 
 	"okrchestra/internal/audit"
 	"okrchestra/internal/notify"
+	"okrchestra/internal/secrets"
 	"okrchestra/internal/workspace"
 )
 
@@ -23,21 +25,45 @@ type Daemon struct {
 	Scheduler    *Scheduler
 	Handlers     map[string]HandlerFunc
 	AuditLogger  *audit.Logger
-	Notifier     *notify.Notifier
+	Notifier     notify.Sender
 	LeaseOwner   string
 	LeaseFor     time.Duration
 	PollInterval time.Duration
+	// Secrets resolves {{secret:name}} references in job payloads.
+	Secrets secrets.Resolver
+	// DashboardURL is linked from notifications when the dashboard is served.
+	DashboardURL string
+	// Retry decides which failed jobs are run again (see retry.yml).
+	Retry RetryConfig
+	// Pool sets worker count, per-type concurrency, and priorities (see
+	// daemon.yml).
+	Pool PoolConfig
+	// Listen is the address of the HTTP API; empty disables it.
+	Listen string
+	// Dashboard is the address of the web dashboard; empty disables it.
+	Dashboard string
+	// WatchMode is WatchModeFSNotify (the default) or WatchModePoll.
+	WatchMode string
+	// Takeover breaks another daemon's lock on the workspace.
+	Takeover bool
+
+	startedAt time.Time
 }
 
 // Config holds daemon configuration.
 type Config struct {
-	Workspace      *workspace.Workspace
-	StorePath      string
-	TimeZone       string
-	LeaseOwner     string
-	LeaseFor       time.Duration
-	PollInterval   time.Duration
-	Notifications  bool
+	Workspace     *workspace.Workspace
+	StorePath     string
+	TimeZone      string
+	LeaseOwner    string
+	LeaseFor      time.Duration
+	PollInterval  time.Duration
+	Notifications bool
+	DashboardURL  string
+	Listen        string
+	Dashboard     string
+	WatchMode     string
+	Takeover      bool
 }
 
 // New creates a new daemon with default handlers.
@@ -47,11 +73,37 @@ func New(cfg Config) (*Daemon, error) {
 		return nil, fmt.Errorf("open store: %w", err)
 	}
 
+	retry, err := LoadRetryConfig(cfg.Workspace.Root)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	pool, err := LoadPoolConfig(cfg.Workspace.Root)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
 	scheduler, err := NewScheduler(store, cfg.TimeZone)
 	if err != nil {
 		store.Close()
 		return nil, fmt.Errorf("create scheduler: %w", err)
 	}
+	if scheduler.Schedules, err = LoadSchedules(cfg.Workspace.Root); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	router, err := notify.LoadRouter(cfg.Workspace.Root, notify.RouterOptions{
+		Desktop:    &notify.Notifier{Enabled: cfg.Notifications},
+		DigestPath: DigestQueuePath(cfg.Workspace),
+		Location:   scheduler.location,
+	})
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
 
 	if cfg.LeaseOwner == "" {
 		hostname, _ := os.Hostname()
@@ -66,16 +118,36 @@ func New(cfg Config) (*Daemon, error) {
 		cfg.PollInterval = 1 * time.Second
 	}
 
+	switch cfg.WatchMode {
+	case "":
+		cfg.WatchMode = WatchModeFSNotify
+	case WatchModeFSNotify, WatchModePoll:
+	default:
+		store.Close()
+		return nil, fmt.Errorf("unknown watch mode %q (want %s or %s)", cfg.WatchMode, WatchModeFSNotify, WatchModePoll)
+	}
+
 	d := &Daemon{
 		Workspace:    cfg.Workspace,
 		Store:        store,
 		Scheduler:    scheduler,
 		Handlers:     DefaultHandlers(),
 		AuditLogger:  audit.NewLogger(cfg.Workspace.AuditDBPath),
-		Notifier:     &notify.Notifier{Enabled: cfg.Notifications},
+		Notifier:     router,
 		LeaseOwner:   cfg.LeaseOwner,
 		LeaseFor:     cfg.LeaseFor,
 		PollInterval: cfg.PollInterval,
+		DashboardURL: cfg.DashboardURL,
+		Retry:        retry,
+		Pool:         pool,
+		Listen:       cfg.Listen,
+		Dashboard:    cfg.Dashboard,
+		WatchMode:    cfg.WatchMode,
+		Takeover:     cfg.Takeover,
+	}
+	// A missing store only matters to jobs that reference secrets.
+	if secretStore, err := secrets.Default(); err == nil {
+		d.Secrets = secretStore
 	}
 
 	return d, nil
@@ -100,12 +172,47 @@ func (d *Daemon) Run(ctx context.Context) error {
 		cancel()
 	}()
 
+	lock, err := d.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	d.startedAt = time.Now().UTC()
+	if d.Listen != "" {
+		if err := d.serveAPI(ctx, d.Listen); err != nil {
+			return err
+		}
+	}
+	if d.Dashboard != "" {
+		if err := d.serveDashboard(ctx, d.Dashboard); err != nil {
+			return err
+		}
+	}
+
+	watchMode := WatchModePoll
+	if d.WatchMode != WatchModePoll {
+		if err := d.startWatcher(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "file watcher unavailable, polling every 30s instead: %v\n", err)
+		} else {
+			watchMode = WatchModeFSNotify
+		}
+	}
+
 	// Log daemon start
 	startPayload := map[string]any{
 		"workspace":     d.Workspace.Root,
 		"lease_owner":   d.LeaseOwner,
 		"lease_for":     d.LeaseFor.String(),
 		"poll_interval": d.PollInterval.String(),
+		"watch_mode":    watchMode,
+		"workers":       d.Pool.WorkerCount(),
+	}
+	if d.Listen != "" {
+		startPayload["listen"] = d.Listen
+	}
+	if d.Dashboard != "" {
+		startPayload["dashboard"] = d.Dashboard
 	}
 	if err := d.AuditLogger.LogEvent("daemon", "daemon_started", startPayload); err != nil {
 		fmt.Fprintf(os.Stderr, "audit log failed: %v\n", err)
@@ -114,11 +221,13 @@ func (d *Daemon) Run(ctx context.Context) error {
 	// Run loop
 	ticker := time.NewTicker(d.PollInterval)
 	defer ticker.Stop()
+	pool := newWorkerPool()
 
 	for {
 		select {
 		case <-ctx.Done():
-			// Graceful shutdown
+			// Graceful shutdown; running handlers see the canceled context
+			pool.wg.Wait()
 			stopPayload := map[string]any{
 				"workspace": d.Workspace.Root,
 			}
@@ -126,29 +235,104 @@ func (d *Daemon) Run(ctx context.Context) error {
 			return nil
 
 		case <-ticker.C:
+			// Another daemon took the workspace over with --takeover
+			if !lock.Held() {
+				_ = d.AuditLogger.LogEvent("daemon", "daemon_lock_lost", map[string]any{
+					"workspace":   d.Workspace.Root,
+					"lease_owner": d.LeaseOwner,
+				})
+				cancel()
+				pool.wg.Wait()
+				return fmt.Errorf("daemon lock %s was taken over by another daemon", lock.Path)
+			}
+
 			// Tick scheduler before claiming
 			if err := d.Scheduler.Tick(time.Now()); err != nil {
 				fmt.Fprintf(os.Stderr, "scheduler tick failed: %v\n", err)
 			}
 
-			// Try to claim and execute a job
-			if err := d.claimAndExecute(ctx); err != nil {
-				fmt.Fprintf(os.Stderr, "job execution failed: %v\n", err)
+			// Hand due jobs to idle workers
+			if err := d.dispatch(ctx, pool); err != nil {
+				fmt.Fprintf(os.Stderr, "job dispatch failed: %v\n", err)
+			}
+
+		case <-pool.done:
+			// A worker is free; claim without waiting for the next poll
+			if err := d.dispatch(ctx, pool); err != nil {
+				fmt.Fprintf(os.Stderr, "job dispatch failed: %v\n", err)
 			}
 		}
 	}
 }
 
-func (d *Daemon) claimAndExecute(ctx context.Context) error {
-	job, err := d.Store.ClaimNext(time.Now(), d.LeaseOwner, d.LeaseFor)
+// acquireLock takes the workspace's single-instance lock, recording in the
+// audit log whether it was free or broken.
+func (d *Daemon) acquireLock() (*Lock, error) {
+	path := LockPath(d.Workspace)
+	lock, previous, err := AcquireLock(path, d.LeaseOwner, d.Takeover)
 	if err != nil {
-		return fmt.Errorf("claim job: %w", err)
+		return nil, err
+	}
+	payload := map[string]any{
+		"workspace":   d.Workspace.Root,
+		"lock_path":   path,
+		"pid":         lock.Info.PID,
+		"lease_owner": d.LeaseOwner,
 	}
+	event := "daemon_lock_acquired"
+	if previous != nil {
+		event = "daemon_lock_stolen"
+		payload["previous_pid"] = previous.PID
+		payload["previous_hostname"] = previous.Hostname
+		payload["previous_lease_owner"] = previous.LeaseOwner
+		payload["takeover"] = d.Takeover
+	}
+	if err := d.AuditLogger.LogEvent("daemon", event, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "audit log failed: %v\n", err)
+	}
+	return lock, nil
+}
+
+// claimAndExecute runs the next due job, returning it (nil when none is
+// due) along with its error.
+func (d *Daemon) claimAndExecute(ctx context.Context) (*Job, error) {
+	job, err := d.claim(d.Pool.claimOptions(nil))
+	if err != nil || job == nil {
+		return nil, err
+	}
+	return job, d.execute(ctx, job, nil)
+}
 
-	if job == nil {
-		// No jobs available
-		return nil
+// claim claims the next due job allowed by opts, or returns nil when none
+// is due.
+func (d *Daemon) claim(opts ClaimOptions) (*Job, error) {
+	job, err := d.Store.ClaimNextWith(time.Now(), d.LeaseOwner, d.LeaseFor, opts)
+	if err != nil {
+		return nil, fmt.Errorf("claim job: %w", err)
 	}
+	return job, nil
+}
+
+// execute runs a claimed job and records its outcome. release, when set,
+// is called once the job's handler has returned: for a handler abandoned
+// after its grace period, that is after execute returns, so its worker
+// slot stays taken while it still runs.
+func (d *Daemon) execute(ctx context.Context, job *Job, release func()) error {
+	var handlerDone <-chan struct{} = closedChan
+	defer func() {
+		if release == nil {
+			return
+		}
+		select {
+		case <-handlerDone:
+			release()
+		default:
+			go func() {
+				<-handlerDone
+				release()
+			}()
+		}
+	}()
 
 	// Log job start
 	startPayload := map[string]any{
@@ -164,35 +348,62 @@ func (d *Daemon) claimAndExecute(ctx context.Context) error {
 	handler, ok := d.Handlers[job.Type]
 	if !ok {
 		err := fmt.Errorf("no handler for job type: %s", job.Type)
-		_ = d.Store.Fail(job.ID, err)
-		
-		failPayload := map[string]any{
-			"job_id":   job.ID,
-			"job_type": job.Type,
-			"error":    err.Error(),
-		}
-		_ = d.AuditLogger.LogEvent("daemon", "job_failed", failPayload)
+		d.failJob(job, err, false)
+		return err
+	}
+
+	// Secret references are expanded only in the copy handed to the handler;
+	// the stored payload keeps the references and anything persisted or
+	// logged from the run is redacted.
+	resolved, err := secrets.ResolvePayload(job.PayloadJSON, d.Secrets)
+	if err != nil {
+		err = fmt.Errorf("resolve secrets: %w", err)
+		d.failJob(job, err, false)
+		return err
+	}
+	execJob := *job
+	execJob.PayloadJSON = resolved.PayloadJSON
+
+	timeout, err := d.Pool.Timeout(job.Type, job.PayloadJSON)
+	if err != nil {
+		d.failJob(job, err, false)
 		return err
 	}
+	jobCtx, kill := context.WithCancelCause(ctx)
+	defer kill(nil)
+	jobCtx, cancelTimeout := context.WithTimeoutCause(jobCtx, timeout, fmt.Errorf("%w after %s", errJobTimedOut, timeout))
+	defer cancelTimeout()
+	go d.watchCancel(jobCtx, job.ID, kill)
 
 	// Add store, notifier, and audit logger to context for handlers that need them
-	ctxWithStore := context.WithValue(ctx, "daemon_store", d.Store)
+	ctxWithStore := context.WithValue(jobCtx, "daemon_store", d.Store)
 	ctxWithNotifier := context.WithValue(ctxWithStore, "daemon_notifier", d.Notifier)
 	ctxWithAudit := context.WithValue(ctxWithNotifier, "daemon_audit_logger", d.AuditLogger)
-	result, execErr := handler(ctxWithAudit, d.Workspace, job)
+	ctxWithDashboard := context.WithValue(ctxWithAudit, "daemon_dashboard_url", d.DashboardURL)
+	result, handlerDone, execErr := runHandler(ctxWithDashboard, handler, d.Workspace, &execJob)
 
-	if execErr != nil {
-		_ = d.Store.Fail(job.ID, execErr)
-		
-		failPayload := map[string]any{
-			"job_id":   job.ID,
-			"job_type": job.Type,
-			"error":    execErr.Error(),
+	if execErr != nil && ctx.Err() == nil && jobCtx.Err() != nil {
+		// The handler failed because it was killed or timed out; record
+		// why rather than the context error it returned
+		if cause := context.Cause(jobCtx); !errors.Is(execErr, cause) {
+			execErr = cause
 		}
-		_ = d.AuditLogger.LogEvent("daemon", "job_failed", failPayload)
+		if errors.Is(execErr, errJobKilled) {
+			return d.killJob(job)
+		}
+	}
+	if execErr != nil {
+		execErr = errors.New(resolved.Redact(execErr.Error()))
+		d.failJob(job, execErr, true)
 		return execErr
 	}
 
+	redacted, err := resolved.RedactValue(result)
+	if err != nil {
+		return fmt.Errorf("marshal job result: %w", err)
+	}
+	result = redacted
+
 	// Mark success
 	if err := d.Store.Succeed(job.ID, result); err != nil {
 		return fmt.Errorf("mark job succeeded: %w", err)
@@ -208,7 +419,168 @@ func (d *Daemon) claimAndExecute(ctx context.Context) error {
 	return nil
 }
 
-// Close closes the daemon's store.
+// errJobKilled and errJobTimedOut are the causes execute cancels a
+// handler's context with.
+var (
+	errJobKilled   = errors.New("job killed")
+	errJobTimedOut = errors.New("job timed out")
+)
+
+// handlerGracePeriod is how long a handler may keep running after its
+// context is canceled before the daemon stops waiting for it.
+var handlerGracePeriod = 30 * time.Second
+
+// closedChan is a channel that is already closed.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// runHandler calls handler, returning early if it ignores the cancellation
+// of ctx for longer than handlerGracePeriod. An abandoned handler keeps
+// running in the background until it returns; finished is closed then.
+func runHandler(ctx context.Context, handler HandlerFunc, ws *workspace.Workspace, job *Job) (result any, finished <-chan struct{}, err error) {
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		result, err := handler(ctx, ws, job)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, returned, out.err
+	case <-ctx.Done():
+	}
+	select {
+	case out := <-done:
+		return out.result, returned, out.err
+	case <-time.After(handlerGracePeriod):
+		return nil, returned, fmt.Errorf("%w; handler did not stop within %s", context.Cause(ctx), handlerGracePeriod)
+	}
+}
+
+// watchCancel polls the store until ctx is done and kills the job once
+// daemon kill has requested it.
+func (d *Daemon) watchCancel(ctx context.Context, jobID string, kill context.CancelCauseFunc) {
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			requested, err := d.Store.CancelRequested(jobID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "check job cancel: %v\n", err)
+				continue
+			}
+			if requested {
+				kill(errJobKilled)
+				return
+			}
+		}
+	}
+}
+
+// killJob records a job stopped by daemon kill. Killed jobs are not retried.
+func (d *Daemon) killJob(job *Job) error {
+	if err := d.Store.MarkCanceled(job.ID, errJobKilled); err != nil {
+		return fmt.Errorf("mark job canceled: %w", err)
+	}
+	_ = d.AuditLogger.LogEvent("daemon", "job_killed", map[string]any{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+	})
+	return errJobKilled
+}
+
+// failJob records a failed attempt. Retryable failures go back to the queue
+// while the job type's retry policy has attempts left; configuration errors
+// such as a missing handler fail the job outright.
+func (d *Daemon) failJob(job *Job, jobErr error, retryable bool) {
+	policy := RetryPolicy{MaxAttempts: 1}
+	if retryable {
+		policy = d.Retry.Policy(job.Type)
+	}
+	retryAt, err := d.Store.FailOrRetry(job.ID, jobErr, policy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "record job failure: %v\n", err)
+	}
+
+	payload := map[string]any{
+		"job_id":       job.ID,
+		"job_type":     job.Type,
+		"error":        jobErr.Error(),
+		"attempt":      job.Attempts,
+		"max_attempts": policy.MaxAttempts,
+	}
+	if retryAt != nil {
+		payload["next_retry_at"] = retryAt.Format(time.RFC3339)
+		_ = d.AuditLogger.LogEvent("daemon", "job_retry_scheduled", payload)
+		return
+	}
+	_ = d.AuditLogger.LogEvent("daemon", "job_failed", payload)
+}
+
+// TickJob is a job run by Tick.
+type TickJob struct {
+	ID    string
+	Type  string
+	Error string
+}
+
+// Tick performs a single iteration of the run loop for external schedulers
+// such as cron: it enqueues due scheduled jobs, then runs up to maxJobs due
+// jobs synchronously. Job failures are recorded on the job and in the
+// returned list; only store errors are returned.
+func (d *Daemon) Tick(ctx context.Context, maxJobs int) ([]TickJob, error) {
+	if err := d.Scheduler.Tick(time.Now()); err != nil {
+		return nil, fmt.Errorf("scheduler tick: %w", err)
+	}
+
+	var ran []TickJob
+	for len(ran) < maxJobs && ctx.Err() == nil {
+		job, err := d.claimAndExecute(ctx)
+		if job == nil {
+			if err != nil {
+				return ran, err
+			}
+			break
+		}
+		tickJob := TickJob{ID: job.ID, Type: job.Type}
+		if err != nil {
+			tickJob.Error = err.Error()
+		}
+		ran = append(ran, tickJob)
+	}
+
+	tickPayload := map[string]any{
+		"workspace":   d.Workspace.Root,
+		"lease_owner": d.LeaseOwner,
+		"jobs_run":    len(ran),
+	}
+	if err := d.AuditLogger.LogEvent("daemon", "daemon_tick", tickPayload); err != nil {
+		fmt.Fprintf(os.Stderr, "audit log failed: %v\n", err)
+	}
+	return ran, nil
+}
+
+// Close closes the daemon's store and audit logger.
 func (d *Daemon) Close() error {
-	return d.Store.Close()
+	err := d.Store.Close()
+	if auditErr := d.AuditLogger.Close(); err == nil {
+		err = auditErr
+	}
+	return err
 }
@@ -0,0 +1,476 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/notify"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Lookup(name string) (string, error) {
+	if v, ok := s[name]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("secret %q not found", name)
+}
+
+func TestSecretPayloadsResolvedOnlyForHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	var seen string
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir},
+		Store:       store,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "test",
+		LeaseFor:    time.Minute,
+		Secrets:     staticSecrets{"github_token": "ghp_supersecret"},
+		Handlers: map[string]HandlerFunc{
+			"echo": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				var payload map[string]string
+				_ = json.Unmarshal([]byte(job.PayloadJSON), &payload)
+				seen = payload["token"]
+				if payload["fail"] != "" {
+					return nil, fmt.Errorf("request with %s rejected", seen)
+				}
+				return map[string]any{"echo": seen}, nil
+			},
+		},
+	}
+
+	okID, _, err := store.EnqueueUnique("echo", time.Now().Add(-time.Minute), map[string]any{"token": "{{secret:github_token}}"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.claimAndExecute(context.Background()); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if seen != "ghp_supersecret" {
+		t.Fatalf("handler saw %q", seen)
+	}
+	failID, _, err := store.EnqueueUnique("echo", time.Now().Add(-time.Second), map[string]any{"token": "{{secret:github_token}}", "fail": "1"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.claimAndExecute(context.Background()); err == nil {
+		t.Fatalf("expected handler error")
+	}
+
+	for _, id := range []string{okID, failID} {
+		job, err := store.GetJob(id)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if strings.Contains(job.PayloadJSON, "ghp_") || strings.Contains(job.ResultJSON, "ghp_") {
+			t.Fatalf("secret persisted in job %s: payload=%s result=%s", id, job.PayloadJSON, job.ResultJSON)
+		}
+		if !strings.Contains(job.ResultJSON, "{{secret:github_token}}") {
+			t.Fatalf("result should keep the reference: %s", job.ResultJSON)
+		}
+	}
+	events, err := audit.ReadEvents(d.AuditLogger.DBPath, audit.Query{Contains: "ghp_"})
+	if err != nil {
+		t.Fatalf("read audit: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("secret logged in audit events: %+v", events)
+	}
+
+	missing, _, _ := store.EnqueueUnique("echo", time.Now(), map[string]any{"token": "{{secret:nope}}"})
+	if _, err := d.claimAndExecute(context.Background()); err == nil {
+		t.Fatalf("expected missing secret to fail the job")
+	}
+	if job, _ := store.GetJob(missing); job.Status != "failed" {
+		t.Fatalf("job status = %s", job.Status)
+	}
+}
+
+func TestTickRunsAtMostMaxJobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	scheduler, err := NewScheduler(store, "UTC")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+
+	var ran []string
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir},
+		Store:       store,
+		Scheduler:   scheduler,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "tick-test",
+		LeaseFor:    time.Minute,
+		Handlers: map[string]HandlerFunc{
+			"echo": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				ran = append(ran, job.ID)
+				if strings.Contains(job.PayloadJSON, "fail") {
+					return nil, fmt.Errorf("boom")
+				}
+				return map[string]any{}, nil
+			},
+		},
+	}
+
+	now := time.Now()
+	for i, payload := range []map[string]any{{}, {"fail": true}, {}} {
+		if _, _, err := store.EnqueueUnique("echo", now.Add(time.Duration(i-5)*time.Minute), payload); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	if _, _, err := store.EnqueueUnique("echo", now.Add(time.Hour), map[string]any{}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	jobs, err := d.Tick(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Error != "" || jobs[1].Error != "boom" {
+		t.Fatalf("first tick = %+v", jobs)
+	}
+	jobs, err = d.Tick(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if len(jobs) != 1 || len(ran) != 3 {
+		t.Fatalf("second tick = %+v, ran %v; the future job must not run", jobs, ran)
+	}
+}
+
+func TestFailedJobsRetryWithBackoff(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	scheduler, err := NewScheduler(store, "UTC")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir},
+		Store:       store,
+		Scheduler:   scheduler,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "retry-test",
+		LeaseFor:    time.Minute,
+		Retry:       RetryConfig{Jobs: map[string]RetryPolicy{"flaky": {MaxAttempts: 2, Backoff: time.Hour}}},
+		Handlers: map[string]HandlerFunc{
+			"flaky": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				return nil, fmt.Errorf("boom")
+			},
+		},
+	}
+
+	jobID, _, err := store.EnqueueUnique("flaky", time.Now().Add(-time.Minute), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.Tick(context.Background(), 5); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	job, err := store.GetJob(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "queued" || job.Attempts != 1 || job.MaxAttempts != 2 || job.NextRetryAt == nil {
+		t.Fatalf("after first failure: %+v", job)
+	}
+	if wait := time.Until(*job.NextRetryAt); wait < 59*time.Minute || wait > time.Hour {
+		t.Fatalf("next retry in %s, want about 1h", wait)
+	}
+
+	// Not due yet.
+	if jobs, err := d.Tick(context.Background(), 5); err != nil || len(jobs) != 0 {
+		t.Fatalf("tick before backoff ran %+v (err %v)", jobs, err)
+	}
+
+	if _, err := store.db.Exec("UPDATE daemon_jobs SET next_retry_at = ? WHERE id = ?", time.Now().Add(-time.Second).UTC().Format(time.RFC3339), jobID); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, err := d.Tick(context.Background(), 5); err != nil || len(jobs) != 1 {
+		t.Fatalf("retry tick ran %+v (err %v)", jobs, err)
+	}
+	job, err = store.GetJob(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "failed" || job.Attempts != 2 || job.NextRetryAt != nil {
+		t.Fatalf("after last attempt: %+v", job)
+	}
+}
+
+func TestJobTimeoutAndKill(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	oldGrace := handlerGracePeriod
+	handlerGracePeriod = 50 * time.Millisecond
+	defer func() { handlerGracePeriod = oldGrace }()
+
+	started := make(chan string, 2)
+	d := &Daemon{
+		Workspace:    &workspace.Workspace{Root: tmpDir},
+		Store:        store,
+		AuditLogger:  audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:   "timeout-test",
+		LeaseFor:     time.Minute,
+		PollInterval: 10 * time.Millisecond,
+		Handlers: map[string]HandlerFunc{
+			"hang": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				started <- job.ID
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			"stubborn": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				time.Sleep(time.Second)
+				return nil, nil
+			},
+		},
+	}
+
+	// Timed-out jobs fail like any other handler error.
+	timedOut, _, err := store.EnqueueUnique("hang", time.Now().Add(-time.Minute), map[string]any{"timeout": "20ms"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := d.claimAndExecute(context.Background()); err == nil || !strings.Contains(err.Error(), "timed out after 20ms") {
+		t.Fatalf("timeout error = %v", err)
+	}
+	<-started
+	if job, _ := store.GetJob(timedOut); job.Status != "failed" {
+		t.Fatalf("timed-out job status = %s", job.Status)
+	}
+
+	// Handlers that ignore cancellation are abandoned after the grace period.
+	if _, _, err := store.EnqueueUnique("stubborn", time.Now().Add(-time.Minute), map[string]any{"timeout": "10ms"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	begin := time.Now()
+	if _, err := d.claimAndExecute(context.Background()); err == nil || !strings.Contains(err.Error(), "did not stop") {
+		t.Fatalf("abandoned handler error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Fatalf("waited %s for a stubborn handler", elapsed)
+	}
+
+	// daemon kill cancels the running handler and the job is not retried.
+	d.Retry = RetryConfig{Default: RetryPolicy{MaxAttempts: 3}}
+	killed, _, err := store.EnqueueUnique("hang", time.Now().Add(-time.Second), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	go func() {
+		id := <-started
+		if err := store.RequestCancel(id); err != nil {
+			t.Errorf("request cancel: %v", err)
+		}
+	}()
+	if _, err := d.claimAndExecute(context.Background()); !errors.Is(err, errJobKilled) {
+		t.Fatalf("kill error = %v", err)
+	}
+	job, err := store.GetJob(killed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "canceled" || job.LeaseOwner != "" || !strings.Contains(job.ResultJSON, "job killed") {
+		t.Fatalf("killed job: %+v", job)
+	}
+	if err := store.RequestCancel(killed); !errors.Is(err, ErrJobNotRunning) {
+		t.Fatalf("kill finished job = %v", err)
+	}
+	events, err := audit.ReadEvents(d.AuditLogger.DBPath, audit.Query{Type: "job_killed"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("job_killed events = %d (err %v)", len(events), err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	cfg := RetryConfig{
+		Default: RetryPolicy{MaxAttempts: 2},
+		Jobs:    map[string]RetryPolicy{"kr_measure": {MaxAttempts: 4, Backoff: time.Minute, MaxBackoff: 3 * time.Minute}},
+	}
+	if p := cfg.Policy("plan_execute"); p.MaxAttempts != 2 || p.Backoff != DefaultRetryBackoff {
+		t.Fatalf("default policy = %+v", p)
+	}
+	p := cfg.Policy("kr_measure")
+	var delays []time.Duration
+	for attempt := 1; attempt <= 4; attempt++ {
+		delays = append(delays, p.Delay(attempt))
+	}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	if fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	if p := (RetryConfig{}).Policy("x"); p.MaxAttempts != 1 {
+		t.Fatalf("retries must be off by default, got %+v", p)
+	}
+}
+
+func TestPlanExecuteSkipsDraftPlans(t *testing.T) {
+	root := t.TempDir()
+	ws := &workspace.Workspace{Root: root, ArtifactsDir: filepath.Join(root, "artifacts")}
+	planDir := filepath.Join(ws.ArtifactsDir, "plans", "2025-03-03")
+	if err := os.MkdirAll(planDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := planner.Plan{ID: "plan-draft", AsOf: "2025-03-03", Status: planner.PlanDraft, Items: []planner.PlanItem{
+		{ID: "ITEM-1", ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do it", AgentRole: "engineer",
+			ExpectedMetricChange: planner.ExpectedMetricChange{MetricKey: "m", Direction: "increase", Target: 1}},
+	}}
+	if err := planner.WritePlan(filepath.Join(planDir, "plan.json"), plan); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &Job{ID: "job-1", Type: "plan_execute", PayloadJSON: `{"adapter":"mock"}`}
+	out, err := handlePlanExecute(context.Background(), ws, job)
+	if err != nil {
+		t.Fatalf("plan_execute: %v", err)
+	}
+	if result, _ := out.(map[string]any); result["status"] != "awaiting_approval" || result["plan_id"] != "plan-draft" {
+		t.Fatalf("result = %v", out)
+	}
+
+	// Neither a payload nor a missing status skips approval.
+	job = &Job{ID: "job-2", Type: "plan_execute", PayloadJSON: `{"adapter":"mock","auto_approve":true}`}
+	out, err = handlePlanExecute(context.Background(), ws, job)
+	if result, _ := out.(map[string]any); err != nil || result["status"] != "awaiting_approval" {
+		t.Fatalf("auto_approve payload: result = %v (err %v)", out, err)
+	}
+	plan.Status = ""
+	if err := planner.WritePlan(filepath.Join(planDir, "plan.json"), plan); err != nil {
+		t.Fatal(err)
+	}
+	out, err = handlePlanExecute(context.Background(), ws, job)
+	if result, _ := out.(map[string]any); err != nil || result["status"] != "awaiting_approval" {
+		t.Fatalf("plan without status: result = %v (err %v)", out, err)
+	}
+
+	if ok, err := planAutoApproved(ws); err != nil || ok {
+		t.Fatalf("auto-approve without config = %v (err %v)", ok, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "daemon.yml"), []byte("auto_approve_plans: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := planAutoApproved(ws); err != nil || !ok {
+		t.Fatalf("auto_approve_plans ignored (err %v)", err)
+	}
+}
+
+type recordingSender struct {
+	messages []notify.Message
+}
+
+func (s *recordingSender) SendMessage(msg notify.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestPlanExecuteNotifiesPullRequests(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gh is an sh script")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	for name, data := range map[string]string{
+		"okrs/org.yml": "objectives: []\n",
+		".gitignore":   "artifacts/\n",
+		// The mock adapter changes nothing, so a hook leaves something
+		// to commit.
+		"hooks.yml": "pre_item:\n  - command: [\"sh\", \"-c\", \"echo feature > feature.txt\"]\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git("init", "-q", "--bare", remote)
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	git("remote", "add", "origin", remote)
+
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "gh"), []byte("#!/bin/sh\necho https://github.com/example/app/pull/7\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ws := &workspace.Workspace{Root: root, OKRsDir: filepath.Join(root, "okrs"), ArtifactsDir: filepath.Join(root, "artifacts")}
+	planDir := filepath.Join(ws.ArtifactsDir, "plans", "2025-03-03")
+	if err := os.MkdirAll(planDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := planner.Plan{ID: "plan-pr", AsOf: "2025-03-03", Status: planner.PlanApproved, Items: []planner.PlanItem{
+		{ID: "ITEM-1", ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do it", AgentRole: "engineer",
+			ExpectedMetricChange: planner.ExpectedMetricChange{MetricKey: "m", Direction: "increase", Target: 1}},
+	}}
+	if err := planner.WritePlan(filepath.Join(planDir, "plan.json"), plan); err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &recordingSender{}
+	ctx := context.WithValue(context.Background(), "daemon_notifier", notify.Sender(sender))
+	job := &Job{ID: "job-1", Type: "plan_execute", PayloadJSON: `{"adapter":"mock","pull_requests":true,"no_verify":true}`}
+	if _, err := handlePlanExecute(ctx, ws, job); err != nil {
+		t.Fatalf("plan_execute: %v", err)
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("sent %d messages", len(sender.messages))
+	}
+	for _, link := range sender.messages[0].Links {
+		if link.Label == "Pull request" && link.URL == "https://github.com/example/app/pull/7" {
+			return
+		}
+	}
+	t.Fatalf("links = %+v", sender.messages[0].Links)
+}
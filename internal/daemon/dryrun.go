@@ -0,0 +1,400 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/notify"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/outcomes"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+// DryRunEstimate is what a handler would do for a job, without doing it.
+type DryRunEstimate struct {
+	Duration   time.Duration
+	AgentCalls int
+	Detail     string
+}
+
+// DryRunHandlerFunc inspects the workspace read-only and estimates a job.
+// It must not invoke adapters or write to the workspace.
+type DryRunHandlerFunc func(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error)
+
+// DefaultDryRunHandlers returns no-op counterparts of DefaultHandlers.
+func DefaultDryRunHandlers() map[string]DryRunHandlerFunc {
+	return map[string]DryRunHandlerFunc{
+		"kr_measure":       dryRunKRMeasure,
+		"plan_generate":    dryRunPlanGenerate,
+		"plan_execute":     dryRunPlanExecute,
+		"watch_tick":       dryRunWatchTick,
+		"outcome_check":    dryRunOutcomeCheck,
+		"kr_status_update": dryRunKRStatusUpdate,
+		"notify_digest":    dryRunNotifyDigest,
+		"gc":               dryRunGC,
+	}
+}
+
+// Default duration estimates used when the daemon has no job history.
+var defaultDryRunDurations = map[string]time.Duration{
+	"kr_measure":       10 * time.Second,
+	"plan_generate":    2 * time.Second,
+	"watch_tick":       0,
+	"outcome_check":    time.Second,
+	"kr_status_update": time.Second,
+	"notify_digest":    time.Second,
+	"gc":               time.Second,
+}
+
+// defaultItemDuration estimates one agent run when no history exists.
+const defaultItemDuration = 15 * time.Minute
+
+// dryRunState carries simulated effects between jobs, such as the plan a
+// simulated plan_generate would have written for a later plan_execute.
+type dryRunState struct {
+	plannedItems int
+	planned      bool
+}
+
+// DryRunOptions configures a simulated daemon run.
+type DryRunOptions struct {
+	Workspace *workspace.Workspace
+	TimeZone  string
+	Start     time.Time
+	For       time.Duration
+	// History, when set, supplies queued jobs and past durations. It is only read.
+	History *Store
+	// WatchEvents simulates the fsnotify watcher: no watch_tick polls are
+	// scheduled.
+	WatchEvents bool
+}
+
+// DryRunEntry is one line of the simulated timeline. Consecutive jobs of the
+// same type and outcome are collapsed into a single entry with Count > 1.
+type DryRunEntry struct {
+	At         time.Time
+	Last       time.Time
+	JobType    string
+	Source     string
+	Count      int
+	Duration   time.Duration
+	AgentCalls int
+	Detail     string
+	Error      string
+}
+
+// DryRunReport is the simulated timeline for a window.
+type DryRunReport struct {
+	Start      time.Time
+	End        time.Time
+	Entries    []DryRunEntry
+	Jobs       int
+	Duration   time.Duration
+	AgentCalls int
+}
+
+// DryRun simulates the scheduler and handlers over [Start, Start+For]. Jobs
+// are scheduled into a throwaway store outside the workspace and each is
+// passed to its dry-run handler; nothing is executed or written.
+func DryRun(ctx context.Context, opts DryRunOptions) (*DryRunReport, error) {
+	if opts.Workspace == nil {
+		return nil, fmt.Errorf("workspace is required")
+	}
+	if opts.For <= 0 {
+		return nil, fmt.Errorf("dry-run window must be positive")
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Now()
+	}
+	end := opts.Start.Add(opts.For)
+
+	tmpDir, err := os.MkdirTemp("", "okrchestra-dryrun-")
+	if err != nil {
+		return nil, fmt.Errorf("create dry-run state dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sim, err := Open(filepath.Join(tmpDir, "daemon.sqlite"))
+	if err != nil {
+		return nil, err
+	}
+	defer sim.Close()
+	// The simulation store is discarded; skip fsyncs for the thousands of
+	// watch_tick rows a day produces.
+	if _, err := sim.db.Exec(`PRAGMA synchronous = OFF`); err != nil {
+		return nil, fmt.Errorf("configure dry-run store: %w", err)
+	}
+
+	scheduler, err := NewScheduler(sim, opts.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	if scheduler.Schedules, err = LoadSchedules(opts.Workspace.Root); err != nil {
+		return nil, err
+	}
+	scheduler.WatchPolling = !opts.WatchEvents
+	if err := scheduler.Tick(opts.Start); err != nil {
+		return nil, fmt.Errorf("simulate scheduler: %w", err)
+	}
+	if err := scheduler.Tick(end); err != nil {
+		return nil, fmt.Errorf("simulate scheduler: %w", err)
+	}
+
+	type simJob struct {
+		job    Job
+		source string
+	}
+	var jobs []simJob
+	scheduled, err := sim.ListQueued(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range scheduled {
+		jobs = append(jobs, simJob{job: job, source: "schedule"})
+	}
+
+	durations := map[string]time.Duration{}
+	for jobType, d := range defaultDryRunDurations {
+		durations[jobType] = d
+	}
+	if opts.History != nil {
+		queued, err := opts.History.ListQueued(-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range queued {
+			if job.ScheduledAt.After(end) {
+				continue
+			}
+			jobs = append(jobs, simJob{job: job, source: "queue"})
+		}
+		completed, err := opts.History.ListRecentCompleted(200)
+		if err != nil {
+			return nil, err
+		}
+		for jobType, d := range averageDurations(completed) {
+			durations[jobType] = d
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].job.ScheduledAt.Before(jobs[j].job.ScheduledAt)
+	})
+
+	handlers := DefaultDryRunHandlers()
+	report := &DryRunReport{Start: opts.Start, End: end}
+	ctx = context.WithValue(ctx, "daemon_dry_run_state", &dryRunState{})
+	for _, sj := range jobs {
+		job := sj.job
+		estimate := dryRunOne(ctx, opts.Workspace, handlers, &job, durations)
+
+		report.Jobs++
+		report.Duration += estimate.Duration
+		report.AgentCalls += estimate.AgentCalls
+
+		if n := len(report.Entries); n > 0 {
+			last := &report.Entries[n-1]
+			if last.JobType == job.Type && last.Source == sj.source && last.Detail == estimate.Detail && last.Error == estimate.Error {
+				last.Count++
+				last.Last = job.ScheduledAt
+				last.Duration += estimate.Duration
+				last.AgentCalls += estimate.AgentCalls
+				continue
+			}
+		}
+		entry := estimate
+		entry.At = job.ScheduledAt
+		entry.Last = job.ScheduledAt
+		entry.JobType = job.Type
+		entry.Source = sj.source
+		entry.Count = 1
+		report.Entries = append(report.Entries, entry)
+	}
+	return report, nil
+}
+
+func dryRunOne(ctx context.Context, ws *workspace.Workspace, handlers map[string]DryRunHandlerFunc, job *Job, durations map[string]time.Duration) DryRunEntry {
+	handler, ok := handlers[job.Type]
+	if !ok {
+		return DryRunEntry{Error: fmt.Sprintf("no handler for job type: %s", job.Type)}
+	}
+	estimate, err := handler(ctx, ws, job)
+	if err != nil {
+		return DryRunEntry{Error: err.Error()}
+	}
+	if estimate.Duration == 0 {
+		if d, ok := durations[job.Type]; ok {
+			estimate.Duration = d
+		} else if estimate.AgentCalls > 0 {
+			estimate.Duration = time.Duration(estimate.AgentCalls) * defaultItemDuration
+		}
+	}
+	return DryRunEntry{Duration: estimate.Duration, AgentCalls: estimate.AgentCalls, Detail: estimate.Detail}
+}
+
+// averageDurations returns the mean wall time per job type for finished jobs.
+func averageDurations(jobs []Job) map[string]time.Duration {
+	sum := map[string]time.Duration{}
+	count := map[string]int{}
+	for _, job := range jobs {
+		if job.StartedAt == nil || job.FinishedAt == nil {
+			continue
+		}
+		sum[job.Type] += job.FinishedAt.Sub(*job.StartedAt)
+		count[job.Type]++
+	}
+	out := map[string]time.Duration{}
+	for jobType, total := range sum {
+		out[jobType] = (total / time.Duration(count[jobType])).Round(time.Second)
+	}
+	return out
+}
+
+func dryRunKRMeasure(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	providerCfg := metrics.ProviderConfig{
+		RepoDir:    ws.Root,
+		MetricsDir: ws.MetricsDir,
+	}
+	if err := providerCfg.LoadWorkspaceConfig(ws.Root); err != nil {
+		return DryRunEstimate{}, err
+	}
+	providers := metrics.DefaultProviders(providerCfg)
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	return DryRunEstimate{
+		Detail: fmt.Sprintf("collect metrics from %s; write snapshot; update KR status", strings.Join(names, ", ")),
+	}, nil
+}
+
+func dryRunPlanGenerate(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	store, err := okrstore.LoadFromDir(ws.OKRsDir)
+	if err != nil {
+		return DryRunEstimate{}, fmt.Errorf("load okrs: %w", err)
+	}
+	objectives, krs := 0, 0
+	for _, ids := range store.ListObjectiveIDs() {
+		for _, id := range ids {
+			objectives++
+			if rec, ok := store.ObjectiveLookup(id); ok {
+				krs += len(rec.Objective.KeyResults)
+			}
+		}
+	}
+	rejections, err := planner.PendingRejections(filepath.Join(ws.ArtifactsDir, "runs"))
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	var payload struct {
+		Portfolio bool `json:"portfolio"`
+		Items     int  `json:"items"`
+	}
+	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
+		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
+			return DryRunEstimate{}, fmt.Errorf("parse payload: %w", err)
+		}
+	}
+	items := 1 + len(rejections)
+	if payload.Portfolio {
+		items = payload.Items + len(rejections)
+		if payload.Items <= 0 {
+			items = planner.DefaultPortfolioItems + len(rejections)
+		}
+	}
+	if state, ok := ctx.Value("daemon_dry_run_state").(*dryRunState); ok {
+		state.plannedItems = items
+		state.planned = true
+	}
+	detail := fmt.Sprintf("write a %d-item plan from %d objectives / %d KRs", items, objectives, krs)
+	if len(rejections) > 0 {
+		detail += fmt.Sprintf(" (%d rejected item retries)", len(rejections))
+	}
+	return DryRunEstimate{Detail: detail}, nil
+}
+
+func dryRunPlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	autoApprove, err := planAutoApproved(ws)
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	if state, ok := ctx.Value("daemon_dry_run_state").(*dryRunState); ok && state.planned {
+		// Generated plans are drafts
+		if !autoApprove {
+			return DryRunEstimate{Detail: "skip: the simulated plan_generate writes a draft plan awaiting approval"}, nil
+		}
+		return DryRunEstimate{
+			AgentCalls: state.plannedItems,
+			Detail:     fmt.Sprintf("run %d item(s) from the simulated plan_generate", state.plannedItems),
+		}, nil
+	}
+	planPath, err := findMostRecentPlan(filepath.Join(ws.ArtifactsDir, "plans"))
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	plan, err := planner.LoadPlan(planPath)
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	if !plan.Approved() && !autoApprove {
+		return DryRunEstimate{Detail: fmt.Sprintf("skip: %s is a draft awaiting approval", ws.RelPath(planPath))}, nil
+	}
+	return DryRunEstimate{
+		AgentCalls: len(plan.Items),
+		Detail:     fmt.Sprintf("run %d item(s) from %s", len(plan.Items), ws.RelPath(planPath)),
+	}, nil
+}
+
+func dryRunOutcomeCheck(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	tracked, err := outcomes.Load(ws)
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	pending := 0
+	for _, outcome := range tracked {
+		if outcome.Status == outcomes.StatusPending {
+			pending++
+		}
+	}
+	return DryRunEstimate{Detail: fmt.Sprintf("check %d pending plan outcome(s)", pending)}, nil
+}
+
+func dryRunKRStatusUpdate(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	return DryRunEstimate{Detail: "propose KR status changes from the latest metric snapshot"}, nil
+}
+
+func dryRunNotifyDigest(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	cfg, err := notify.LoadConfig(ws.Root)
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	if cfg.Email == nil || cfg.Email.Mode != notify.EmailModeDigest {
+		return DryRunEstimate{Detail: "email digest not configured; skip"}, nil
+	}
+	queued, err := notify.NewEmailSender(*cfg.Email, DigestQueuePath(ws)).QueuedMessages()
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	return DryRunEstimate{Detail: fmt.Sprintf("email the day's job results and %d queued notification(s) to %s",
+		len(queued), strings.Join(cfg.Email.RecipientsFor(notify.CategoryDigest), ", "))}, nil
+}
+
+func dryRunGC(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	report, err := collectGarbage(ws, time.Now(), true)
+	if err != nil {
+		return DryRunEstimate{}, err
+	}
+	return DryRunEstimate{Detail: fmt.Sprintf("remove %d run(s) and %d plan(s), compress %d transcript(s)",
+		len(report.Runs), len(report.Plans), len(report.Compressed))}, nil
+}
+
+func dryRunWatchTick(ctx context.Context, ws *workspace.Workspace, job *Job) (DryRunEstimate, error) {
+	return DryRunEstimate{Detail: "check watched files; enqueue kr_measure on change"}, nil
+}
@@ -0,0 +1,126 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/workspace"
+)
+
+func TestDryRunSimulatesWeekWithoutWrites(t *testing.T) {
+	root := t.TempDir()
+	okrsDir := filepath.Join(root, "okrs")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatalf("mkdir okrs: %v", err)
+	}
+	org := `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Ship
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: 2
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+	if err := os.WriteFile(filepath.Join(okrsDir, "org.yml"), []byte(org), 0o644); err != nil {
+		t.Fatalf("write org.yml: %v", err)
+	}
+	ws, err := workspace.Resolve(root)
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// Sunday afternoon: the window covers two 02:00 kr_measure runs and
+	// Monday's plan_generate/plan_execute.
+	start := time.Date(2025, 3, 2, 15, 0, 0, 0, loc)
+	report, err := DryRun(context.Background(), DryRunOptions{
+		Workspace: ws,
+		TimeZone:  "America/Chicago",
+		Start:     start,
+		For:       36 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, entry := range report.Entries {
+		if entry.Error != "" {
+			t.Fatalf("unexpected dry-run error for %s: %s", entry.JobType, entry.Error)
+		}
+		counts[entry.JobType] += entry.Count
+	}
+	if counts["kr_measure"] != 2 || counts["plan_generate"] != 1 || counts["plan_execute"] != 1 {
+		t.Fatalf("unexpected job counts: %v", counts)
+	}
+	if counts["watch_tick"] != int((36*time.Hour)/(30*time.Second)) {
+		t.Fatalf("unexpected watch_tick count: %d", counts["watch_tick"])
+	}
+	// The simulated plan is a draft, so plan_execute waits for approval.
+	if report.AgentCalls != 0 {
+		t.Fatalf("expected no agent calls for a draft plan, got %d", report.AgentCalls)
+	}
+	for _, entry := range report.Entries {
+		if entry.JobType == "plan_execute" && !strings.Contains(entry.Detail, "awaiting approval") {
+			t.Fatalf("plan_execute detail = %q", entry.Detail)
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read workspace: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "okrs" {
+		t.Fatalf("dry run wrote to the workspace: %v", entries)
+	}
+}
+
+func TestSchedulerDailyJobWithFrequentTicks(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "daemon.sqlite"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	scheduler, err := NewScheduler(store, "America/Chicago")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+
+	// Tick every minute across 02:00 local; kr_measure must be enqueued once.
+	start := time.Date(2025, 3, 4, 1, 50, 0, 0, scheduler.location)
+	for now := start; now.Before(start.Add(20 * time.Minute)); now = now.Add(time.Minute) {
+		if err := scheduler.Tick(now); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+
+	queued, err := store.ListQueued(-1)
+	if err != nil {
+		t.Fatalf("list queued: %v", err)
+	}
+	measures := 0
+	for _, job := range queued {
+		if job.Type == "kr_measure" {
+			measures++
+		}
+	}
+	if measures != 1 {
+		t.Fatalf("expected 1 kr_measure job, got %d", measures)
+	}
+}
@@ -0,0 +1,295 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"okrchestra/internal/workspace"
+)
+
+// Watch modes for Config.WatchMode.
+const (
+	// WatchModeFSNotify reacts to file system events and falls back to
+	// polling when the watcher cannot start.
+	WatchModeFSNotify = "fsnotify"
+	// WatchModePoll hashes watched files in a watch_tick job every 30 seconds.
+	WatchModePoll = "poll"
+)
+
+// watchDebounce batches bursts of events, such as an editor saving a file
+// in several writes, into one set of follow-up jobs.
+var watchDebounce = 500 * time.Millisecond
+
+// fsWatcher turns file system events under the paths watch_tick polls into
+// the same follow-up jobs.
+type fsWatcher struct {
+	ws      *workspace.Workspace
+	store   *Store
+	watcher *fsnotify.Watcher
+	roots   []*watchRoot
+}
+
+type watchRoot struct {
+	path      string
+	recursive bool
+	watched   bool
+}
+
+// newFSWatcher watches the okrs directory, metrics/manual.yml,
+// metrics/openmetrics, and artifacts/plans. A path that does not exist yet
+// is picked up when it is created.
+func newFSWatcher(ws *workspace.Workspace, store *Store) (*fsWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher: %w", err)
+	}
+	w := &fsWatcher{
+		ws:      ws,
+		store:   store,
+		watcher: watcher,
+		roots: []*watchRoot{
+			{path: ws.OKRsDir, recursive: true},
+			{path: ws.MetricsDir},
+			{path: filepath.Join(ws.MetricsDir, "openmetrics"), recursive: true},
+			{path: filepath.Join(ws.ArtifactsDir, "plans"), recursive: true},
+		},
+	}
+	if _, err := w.refresh(nil); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// refresh adds watches for roots that are not watched yet. A missing root
+// is waited for by watching its nearest existing parent. When pending is
+// set, files already in a newly watched root are recorded as created; it
+// reports whether pending changed.
+func (w *fsWatcher) refresh(pending *watchChanges) (bool, error) {
+	changed := false
+	for _, root := range w.roots {
+		if root.watched {
+			continue
+		}
+		if isDir(root.path) {
+			if err := w.addDir(root.path, root.recursive); err != nil {
+				return changed, err
+			}
+			root.watched = true
+			if pending != nil && w.recordTree(root.path, pending) {
+				changed = true
+			}
+			continue
+		}
+		parent := filepath.Dir(root.path)
+		for parent != w.ws.Root && !isDir(parent) && parent != filepath.Dir(parent) {
+			parent = filepath.Dir(parent)
+		}
+		if err := w.watcher.Add(parent); err != nil {
+			return changed, fmt.Errorf("watch %s: %w", parent, err)
+		}
+	}
+	return changed, nil
+}
+
+func (w *fsWatcher) addDir(dir string, recursive bool) error {
+	if !recursive {
+		if err := w.watcher.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// run handles events until ctx is done. Each debounced batch of changes is
+// enqueued and passed to report.
+func (w *fsWatcher) run(ctx context.Context, report func(changes []string)) {
+	defer w.watcher.Close()
+
+	var pending watchChanges
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.handleEvent(event, &pending) {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "file watcher: %v\n", err)
+		case <-timer.C:
+			changes, err := enqueueWatchJobs(w.store, time.Now(), pending)
+			pending = watchChanges{}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "file watcher: %v\n", err)
+				continue
+			}
+			if len(changes) > 0 && report != nil {
+				report(changes)
+			}
+		}
+	}
+}
+
+// handleEvent records the change an event makes to pending, keeping the
+// watch set in step with created and removed directories. It reports
+// whether pending changed.
+func (w *fsWatcher) handleEvent(event fsnotify.Event, pending *watchChanges) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	path := event.Name
+	if event.Has(fsnotify.Create) && isDir(path) {
+		if w.inRecursiveRoot(path) {
+			if err := w.addDir(path, true); err != nil {
+				fmt.Fprintf(os.Stderr, "file watcher: %v\n", err)
+			}
+			// Files written before the watch was added produce no events.
+			return w.recordTree(path, pending)
+		}
+		changed, err := w.refresh(pending)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "file watcher: %v\n", err)
+		}
+		return changed
+	}
+	removed := event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+	if removed {
+		for _, root := range w.roots {
+			if root.path == path && root.watched {
+				root.watched = false
+				if _, err := w.refresh(nil); err != nil {
+					fmt.Fprintf(os.Stderr, "file watcher: %v\n", err)
+				}
+			}
+		}
+	}
+	return w.record(path, removed, pending)
+}
+
+// recordTree records every file under dir as created.
+func (w *fsWatcher) recordTree(dir string, pending *watchChanges) bool {
+	changed := false
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && w.record(p, false, pending) {
+			changed = true
+		}
+		return nil
+	})
+	return changed
+}
+
+// record classifies a changed file the way watch_tick's polls do.
+func (w *fsWatcher) record(path string, removed bool, pending *watchChanges) bool {
+	name := path
+	if removed {
+		name += " (deleted)"
+	}
+	switch {
+	case isWithin(w.ws.OKRsDir, path):
+		rel, ok := okrFileRel(w.ws.OKRsDir, path)
+		if !ok {
+			return false
+		}
+		pending.OKRs = appendNew(pending.OKRs, rel)
+	case path == filepath.Join(w.ws.MetricsDir, "manual.yml"):
+		pending.Manual = true
+	case isWithin(filepath.Join(w.ws.MetricsDir, "openmetrics"), path) && isWatchedFile(path):
+		pending.OpenMetrics = appendNew(pending.OpenMetrics, name)
+	case isWithin(filepath.Join(w.ws.ArtifactsDir, "plans"), path) && isWatchedFile(path):
+		pending.Plans = appendNew(pending.Plans, name)
+	default:
+		return false
+	}
+	return true
+}
+
+// inRecursiveRoot reports whether path is inside a watched recursive root.
+func (w *fsWatcher) inRecursiveRoot(path string) bool {
+	for _, root := range w.roots {
+		if root.recursive && root.watched && isWithin(root.path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is strictly inside dir.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func appendNew(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// startWatcher starts the fsnotify watcher for the life of ctx and turns
+// off watch_tick polling. A watch_tick is enqueued once to catch changes
+// made while the daemon was stopped.
+func (d *Daemon) startWatcher(ctx context.Context) error {
+	w, err := newFSWatcher(d.Workspace, d.Store)
+	if err != nil {
+		return err
+	}
+	if _, _, err := d.Store.EnqueueUnique("watch_tick", time.Now(), map[string]any{
+		"trigger": "watcher_started",
+	}); err != nil {
+		w.watcher.Close()
+		return fmt.Errorf("enqueue watch_tick: %w", err)
+	}
+	d.Scheduler.WatchPolling = false
+	go w.run(ctx, func(changes []string) {
+		if err := d.AuditLogger.LogEvent("daemon", "watch_changes_detected", map[string]any{
+			"source":  WatchModeFSNotify,
+			"changes": changes,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "audit log failed: %v\n", err)
+		}
+	})
+	return nil
+}
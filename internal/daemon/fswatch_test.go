@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/workspace"
+)
+
+// waitForChange waits for the watcher to report a change matching want.
+func waitForChange(t *testing.T, reported <-chan []string, want string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case changes := <-reported:
+			for _, change := range changes {
+				if change == want {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no %q change reported", want)
+		}
+	}
+}
+
+// requireJob fails unless a job of jobType whose payload contains want is
+// queued. Jobs are unique per type and second, so it checks the first
+// trigger of each type.
+func requireJob(t *testing.T, store *Store, jobType, want string) {
+	t.Helper()
+	jobs, err := store.ListJobs(-1)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.Type == jobType && strings.Contains(job.PayloadJSON, want) {
+			return
+		}
+	}
+	t.Fatalf("no %s job with %q enqueued", jobType, want)
+}
+
+func TestFSWatcherEnqueuesFollowUpJobs(t *testing.T) {
+	oldDebounce := watchDebounce
+	watchDebounce = 20 * time.Millisecond
+	t.Cleanup(func() { watchDebounce = oldDebounce })
+
+	tmpDir := t.TempDir()
+	ws, err := workspace.Resolve(tmpDir)
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	for _, dir := range []string{ws.OKRsDir, ws.MetricsDir, filepath.Join(ws.ArtifactsDir, "plans")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	w, err := newFSWatcher(ws, store)
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reported := make(chan []string, 10)
+	go w.run(ctx, func(changes []string) { reported <- changes })
+
+	if err := os.MkdirAll(filepath.Join(ws.OKRsDir, "teams"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.OKRsDir, "teams", "platform.yml"), []byte("objectives: []\n"), 0o644); err != nil {
+		t.Fatalf("write okrs: %v", err)
+	}
+	waitForChange(t, reported, "okrs: 1 files changed")
+	requireJob(t, store, "kr_measure", `"teams/platform.yml"`)
+	requireJob(t, store, "plan_generate", `"okrs_changed"`)
+
+	if err := os.WriteFile(filepath.Join(ws.MetricsDir, "manual.yml"), []byte("metrics: []\n"), 0o644); err != nil {
+		t.Fatalf("write manual.yml: %v", err)
+	}
+	waitForChange(t, reported, "manual.yml changed")
+
+	// Neither directory exists when the watcher starts.
+	openMetricsDir := filepath.Join(ws.MetricsDir, "openmetrics")
+	if err := os.MkdirAll(openMetricsDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(openMetricsDir, "ci.prom"), []byte("ci_builds 3\n"), 0o644); err != nil {
+		t.Fatalf("write prom: %v", err)
+	}
+	waitForChange(t, reported, "openmetrics: 1 files changed")
+
+	planDir := filepath.Join(ws.ArtifactsDir, "plans", "2025-01-06", "plan-1")
+	if err := os.MkdirAll(planDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(planDir, "plan.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write plan: %v", err)
+	}
+	waitForChange(t, reported, "plans: 1 files changed")
+	requireJob(t, store, "plan_execute", "plan.json")
+
+	// Files outside the watched paths are ignored.
+	if err := os.WriteFile(filepath.Join(ws.MetricsDir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+	select {
+	case changes := <-reported:
+		t.Fatalf("unexpected changes reported: %v", changes)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -11,9 +11,14 @@ import (
 	"time"
 
 	"okrchestra/internal/adapters"
+	"okrchestra/internal/artifacts"
 	"okrchestra/internal/audit"
+	"okrchestra/internal/config"
+	"okrchestra/internal/locale"
 	"okrchestra/internal/metrics"
 	"okrchestra/internal/notify"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/outcomes"
 	"okrchestra/internal/planner"
 	"okrchestra/internal/workspace"
 )
@@ -21,10 +26,14 @@ import (
 // DefaultHandlers returns the map of built-in daemon handlers.
 func DefaultHandlers() map[string]HandlerFunc {
 	return map[string]HandlerFunc{
-		"kr_measure":    handleKRMeasure,
-		"plan_generate": handlePlanGenerate,
-		"plan_execute":  handlePlanExecute,
-		"watch_tick":    handleWatchTick,
+		"kr_measure":       handleKRMeasure,
+		"plan_generate":    handlePlanGenerate,
+		"plan_execute":     handlePlanExecute,
+		"watch_tick":       handleWatchTick,
+		"outcome_check":    handleOutcomeCheck,
+		"kr_status_update": handleKRStatusUpdate,
+		"notify_digest":    handleNotifyDigest,
+		"gc":               handleGC,
 	}
 }
 
@@ -36,6 +45,8 @@ func handleKRMeasure(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 		AsOf       string `json:"as_of"`
 		RepoDir    string `json:"repo_dir"`
 		MetricsDir string `json:"metrics_dir"`
+		Strict     bool   `json:"strict"`
+		GitHubRepo string `json:"github_repo"`
 	}
 	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
 		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
@@ -53,9 +64,18 @@ func handleKRMeasure(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 		asOf = parsed.UTC().Truncate(24 * time.Hour)
 	}
 
+	wsCfg, err := config.Load(ws.Root)
+	if err != nil {
+		return nil, err
+	}
 	repoDir := ws.Root
 	if payload.RepoDir != "" {
 		repoDir = payload.RepoDir
+	} else if wsCfg.Metrics.RepoDir != "" {
+		repoDir, err = ws.ResolvePath(wsCfg.Metrics.RepoDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolve metrics.repo_dir: %w", err)
+		}
 	}
 
 	metricsDir := ws.MetricsDir
@@ -64,30 +84,49 @@ func handleKRMeasure(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 	}
 
 	snapshotsDir := filepath.Join(metricsDir, "snapshots")
-	ciReportPath := filepath.Join(metricsDir, "ci_report.json")
-	manualPath := filepath.Join(metricsDir, "manual.yml")
 
-	// Collect metrics using same logic as CLI
-	providers := []metrics.Provider{
-		&metrics.GitProvider{RepoDir: repoDir, AsOf: asOf},
-		&metrics.CIProvider{ReportPath: ciReportPath, AsOf: asOf},
-		&metrics.ManualProvider{Path: manualPath, AsOf: asOf},
+	providerCfg := metrics.ProviderConfig{
+		RepoDir:    repoDir,
+		MetricsDir: metricsDir,
+		AsOf:       asOf,
+	}
+	if err := providerCfg.LoadWorkspaceConfig(ws.Root); err != nil {
+		return nil, err
+	}
+	if payload.GitHubRepo != "" {
+		providerCfg.GitHub.Repo = payload.GitHubRepo
 	}
 
-	points, err := metrics.CollectAll(ctx, providers)
+	// Collect metrics using same logic as CLI
+	providers := metrics.DefaultProviders(providerCfg)
+
+	// Failing providers are skipped unless the payload asks for strict
+	points, collection, err := metrics.CollectReport(ctx, providers, metrics.CollectOptions{Strict: payload.Strict, Timeout: providerCfg.ProviderTimeout, Concurrency: providerCfg.Concurrency})
 	if err != nil {
 		return nil, fmt.Errorf("collect metrics: %w", err)
 	}
+	providerErrors := collection.Failures()
+
+	// The latest snapshot before this one tells which KRs just went stale
+	var previous *metrics.Snapshot
+	if latest, err := metrics.LatestSnapshotPath(snapshotsDir); err == nil {
+		previous, _ = metrics.LoadSnapshot(latest)
+	}
 
 	snapshotPath := metrics.SnapshotPathForDate(snapshotsDir, asOf)
 	snapshot := metrics.Snapshot{
-		AsOf:   asOf.Format("2006-01-02"),
-		Points: points,
+		AsOf:           asOf.Format("2006-01-02"),
+		Points:         points,
+		ProviderErrors: providerErrors,
 	}
 
 	if err := metrics.WriteSnapshot(snapshotPath, snapshot); err != nil {
 		return nil, fmt.Errorf("write snapshot: %w", err)
 	}
+	// The history is rebuilt from snapshots, so a failure must not fail the job
+	if err := metrics.RecordHistory(snapshotsDir, &snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "record metric history failed: %v\n", err)
+	}
 
 	// Update KR status based on metrics
 	changes, err := metrics.UpdateKRStatus(ws.OKRsDir, &snapshot)
@@ -112,32 +151,26 @@ func handleKRMeasure(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 				_ = auditLogger.LogEvent("okr", "kr_status_auto_updated", auditPayload)
 			}
 		}
-		
-		// Send notifications for status changes
-		if notifier, ok := ctx.Value("daemon_notifier").(*notify.Notifier); ok && notifier != nil {
-			for _, change := range changes {
-				title, message := notify.FormatKRStatusChange(
-					change.KRID,
-					change.KRDesc,
-					change.OldStatus,
-					change.NewStatus,
-					change.Current,
-					change.Target,
-				)
-				// Send notification (ignore errors - notifications are best-effort)
-				_ = notifier.Send(title, message)
-			}
-		}
+
+		// Send one grouped notification per measure cycle; achieved/blocked
+		// transitions are also sent individually
+		notifyKRStatusChanges(ctx, ws, job.ID, snapshotsDir, &snapshot, changes)
 	}
 
+	notifyStaleKRs(ctx, ws, job.ID, previous, &snapshot)
+
 	result := map[string]any{
-		"snapshot_path": snapshotPath,
+		"snapshot_path": ws.RelPath(snapshotPath),
 		"metric_count":  len(points),
 	}
-	
+
 	if len(changes) > 0 {
 		result["status_changes"] = len(changes)
 	}
+	if len(providerErrors) > 0 {
+		result["provider_errors"] = providerErrors
+	}
+	result["collection"] = collection.Providers
 
 	return result, nil
 }
@@ -151,6 +184,11 @@ func handlePlanGenerate(ctx context.Context, ws *workspace.Workspace, job *Job)
 		ObjectiveID string `json:"objective_id"`
 		KRID        string `json:"kr_id"`
 		AgentRole   string `json:"agent_role"`
+		Portfolio   bool   `json:"portfolio"`
+		Items       int    `json:"items"`
+		// Success criteria are set when both fields are positive.
+		SuccessMinDelta   float64 `json:"success_min_delta"`
+		SuccessWithinDays int     `json:"success_within_days"`
 	}
 	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
 		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
@@ -173,25 +211,55 @@ func handlePlanGenerate(ctx context.Context, ws *workspace.Workspace, job *Job)
 		agentRole = payload.AgentRole
 	}
 
+	var criteria *planner.SuccessCriteria
+	if payload.SuccessMinDelta > 0 && payload.SuccessWithinDays > 0 {
+		criteria = &planner.SuccessCriteria{
+			MinDelta:   payload.SuccessMinDelta,
+			WithinDays: payload.SuccessWithinDays,
+		}
+	}
+
 	outDir := filepath.Join(ws.ArtifactsDir, "plans")
 
 	// Generate plan using same logic as CLI
 	result, err := planner.GeneratePlan(planner.GenerateOptions{
-		OKRsDir:       ws.OKRsDir,
-		OutputBaseDir: outDir,
-		AsOf:          asOf,
-		ObjectiveID:   payload.ObjectiveID,
-		KRID:          payload.KRID,
-		AgentRole:     agentRole,
+		OKRsDir:         ws.OKRsDir,
+		OutputBaseDir:   outDir,
+		AsOf:            asOf,
+		ObjectiveID:     payload.ObjectiveID,
+		KRID:            payload.KRID,
+		AgentRole:       agentRole,
+		CacheDir:        filepath.Join(ws.ArtifactsDir, "cache"),
+		RunsDir:         filepath.Join(ws.ArtifactsDir, "runs"),
+		SnapshotsDir:    filepath.Join(ws.MetricsDir, "snapshots"),
+		WorkspaceRoot:   ws.Root,
+		Portfolio:       payload.Portfolio,
+		Items:           payload.Items,
+		SuccessCriteria: criteria,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("generate plan: %w", err)
 	}
 
-	return map[string]any{
-		"plan_path": result.PlanPath,
+	out := map[string]any{
+		"plan_path": ws.RelPath(result.PlanPath),
 		"plan_date": result.Plan.AsOf,
-	}, nil
+	}
+	if result.Plan.Allocation != nil {
+		out["allocation"] = result.Plan.Allocation
+	}
+	return out, nil
+}
+
+// planAutoApproved reports whether plan_execute runs draft plans, per
+// auto_approve_plans in daemon.yml. Job payloads cannot turn it on: anyone
+// who can reach the HTTP API can enqueue jobs.
+func planAutoApproved(ws *workspace.Workspace) (bool, error) {
+	cfg, err := LoadPoolConfig(ws.Root)
+	if err != nil {
+		return false, err
+	}
+	return cfg.AutoApprovePlans, nil
 }
 
 // handlePlanExecute implements the plan_execute job handler.
@@ -204,6 +272,25 @@ func handlePlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (
 		Timeout  string `json:"timeout"`
 		Follow   bool   `json:"follow"`
 		PlanPath string `json:"plan_path"`
+		Parallel int    `json:"parallel"`
+		// ContinueOnError keeps running items after one fails.
+		ContinueOnError bool `json:"continue_on_error"`
+		// Budget stops starting items once the estimated cost passes
+		// this many USD.
+		Budget float64 `json:"budget"`
+		// KeepOKRsEdits leaves an agent's direct okrs/ edits in place
+		// instead of reverting them.
+		KeepOKRsEdits bool `json:"keep_okrs_edits"`
+		// KeepWorktrees leaves scoped items' worktrees in place for
+		// inspection.
+		KeepWorktrees bool `json:"keep_worktrees"`
+		// NoVerify skips measuring each item's KR metric before and
+		// after it runs.
+		NoVerify bool `json:"no_verify"`
+		// Branches and PullRequests turn on the okrchestra.yml git
+		// settings for this run.
+		Branches     bool `json:"branches"`
+		PullRequests bool `json:"pull_requests"`
 	}
 	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
 		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
@@ -212,7 +299,11 @@ func handlePlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (
 	}
 
 	// Defaults
-	adapterName := "codex"
+	wsCfg, err := config.Load(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+	adapterName := wsCfg.Adapter
 	if payload.Adapter != "" {
 		adapterName = payload.Adapter
 	}
@@ -227,14 +318,17 @@ func handlePlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (
 	}
 
 	// Resolve adapter
-	var adapter adapters.AgentAdapter
-	switch adapterName {
-	case "codex":
-		adapter = &adapters.CodexAdapter{}
-	case "mock":
-		adapter = &adapters.MockAdapter{}
-	default:
-		return nil, fmt.Errorf("unknown adapter: %s", adapterName)
+	adapter, err := adapters.Resolve(ws.Root, adapterName)
+	if err != nil {
+		return nil, err
+	}
+	language, err := locale.LoadLanguage(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+	pricing, err := adapters.LoadPricing(ws.Root)
+	if err != nil {
+		return nil, err
 	}
 
 	// Resolve plan path
@@ -254,9 +348,92 @@ func handlePlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (
 		planPath = filepath.Join(ws.Root, planPath)
 	}
 
+	// Draft plans wait for `plan approve` unless auto-approval is on
+	resolvedPlan, err := planner.ResolvePlanPath(planPath)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planner.LoadPlan(resolvedPlan)
+	if err != nil {
+		return nil, err
+	}
+	approvedBy := ""
+	if !plan.Approved() {
+		autoApprove, err := planAutoApproved(ws)
+		if err != nil {
+			return nil, err
+		}
+		if !autoApprove {
+			if auditLogger, ok := ctx.Value("daemon_audit_logger").(*audit.Logger); ok && auditLogger != nil {
+				_ = auditLogger.LogEvent("daemon", "plan_awaiting_approval", map[string]any{
+					"job_id":    job.ID,
+					"plan_id":   plan.ID,
+					"plan_path": ws.RelPath(resolvedPlan),
+				})
+			}
+			return map[string]any{
+				"status":    "awaiting_approval",
+				"plan_id":   plan.ID,
+				"plan_path": ws.RelPath(resolvedPlan),
+			}, nil
+		}
+		// The plan file is left a draft: rewriting it would retrigger
+		// the plans dir watch and run the plan again.
+		approvedBy = "auto_approve"
+	}
+
 	// Set run base dir to workspace artifacts/runs
 	runBaseDir := filepath.Join(ws.ArtifactsDir, "runs")
 
+	follow := payload.Follow
+	if !follow {
+		poolCfg, err := LoadPoolConfig(ws.Root)
+		if err != nil {
+			return nil, err
+		}
+		follow = poolCfg.StreamTranscripts
+	}
+
+	hooks, err := planner.LoadHooks(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, err := adapters.LoadSandbox(ws.Root, adapter.Name())
+	if err != nil {
+		return nil, err
+	}
+	envCfg, err := adapters.LoadEnv(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var measure planner.MeasureFunc
+	if !payload.NoVerify {
+		providerCfg := metrics.ProviderConfig{RepoDir: ws.Root, MetricsDir: ws.MetricsDir}
+		if err := providerCfg.LoadWorkspaceConfig(ws.Root); err != nil {
+			return nil, err
+		}
+		measure = planner.MeasureWorkspace(providerCfg, filepath.Join(ws.MetricsDir, "snapshots"))
+	}
+
+	// Publish per-item progress so daemon status can show more than "running"
+	var progress func(planner.Progress)
+	if store, ok := ctx.Value("daemon_store").(*Store); ok && store != nil {
+		progress = func(p planner.Progress) {
+			itemStarted := p.ItemStartedAt
+			// Progress is best-effort; a failed write must not fail the run
+			_ = store.SetProgress(JobProgress{
+				JobID:         job.ID,
+				RunID:         p.RunID,
+				ItemIndex:     p.ItemIndex,
+				ItemID:        p.ItemID,
+				ItemsDone:     p.ItemsDone,
+				ItemsTotal:    p.ItemsTotal,
+				ItemStartedAt: &itemStarted,
+			})
+		}
+	}
+
 	// Run plan
 	runResult, err := planner.RunPlan(ctx, planner.RunOptions{
 		PlanPath:          planPath,
@@ -265,43 +442,424 @@ func handlePlanExecute(ctx context.Context, ws *workspace.Workspace, job *Job) (
 		Timeout:           timeout,
 		AuditLogger:       nil, // daemon has its own audit logger
 		RunBaseDir:        runBaseDir,
-		FollowTranscripts: false, // daemon doesn't follow output
+		FollowTranscripts: follow,
+		FollowWriter:      os.Stdout, // the workspace log under a supervisor
+		Progress:          progress,
+		Parallel:          payload.Parallel,
+		IndexArtifactsDir: ws.ArtifactsDir,
+		Language:          language,
+		PromptDir:         filepath.Join(ws.Root, planner.PromptDirName),
+		RoleTemplateDir:   filepath.Join(ws.Root, planner.RoleTemplateDirName),
+		ContinueOnError:   payload.ContinueOnError,
+		KeepOKRsEdits:     payload.KeepOKRsEdits,
+		KeepWorktrees:     payload.KeepWorktrees,
+		Pricing:           pricing,
+		Budget:            payload.Budget,
+		Measure:           measure,
+		Hooks:             hooks,
+		Git: planner.GitOptions{
+			Branches:     wsCfg.Git.Branches || payload.Branches,
+			PullRequests: wsCfg.Git.PullRequests || payload.PullRequests,
+			Remote:       wsCfg.Git.Remote,
+			Base:         wsCfg.Git.Base,
+		},
+		Sandbox:       sandbox,
+		SandboxIgnore: ws.StatePaths(),
+		Env:           envCfg,
 	})
 
 	if err != nil {
+		if class, ok := planner.ClassifyFailure(err); ok {
+			return nil, fmt.Errorf("run plan (%s): %w", class, err)
+		}
 		return nil, fmt.Errorf("run plan: %w", err)
 	}
 
-	itemsSucceeded := len(runResult.ItemRuns)
-	itemsFailed := len(runResult.Plan.Items) - itemsSucceeded
+	itemsSucceeded, itemsFailed, itemsSkipped := runResult.Counts()
 
 	// Send notification if notifier is available in context
-	if notifier, ok := ctx.Value("daemon_notifier").(*notify.Notifier); ok && notifier != nil {
+	if notifier, ok := ctx.Value("daemon_notifier").(notify.Sender); ok && notifier != nil {
 		// Get KR ID from first plan item (if available)
 		krID := "Plan"
 		if len(runResult.Plan.Items) > 0 {
 			krID = runResult.Plan.Items[0].KRID
 		}
-		
-		title, message := notify.FormatPlanComplete(
-			runResult.Plan.ID,
-			len(runResult.Plan.Items),
-			itemsSucceeded,
-			itemsFailed,
-			krID,
-		)
-		
+		dashboardURL, _ := ctx.Value("daemon_dashboard_url").(string)
+		var prURLs []string
+		for _, itemRun := range runResult.ItemRuns {
+			if itemRun.Git != nil && itemRun.Git.PRURL != "" {
+				prURLs = append(prURLs, itemRun.Git.PRURL)
+			}
+		}
+
+		msg := notify.PlanCompleteMessage(notify.PlanRun{
+			PlanID:         runResult.Plan.ID,
+			RunID:          runResult.RunID,
+			KRID:           krID,
+			ItemsTotal:     len(runResult.Plan.Items),
+			ItemsSucceeded: itemsSucceeded,
+			ItemsFailed:    itemsFailed,
+			RunDir:         runResult.RunDir,
+			DashboardURL:   dashboardURL,
+			PRURLs:         prURLs,
+		})
+
 		// Send notification (ignore errors - notifications are best-effort)
-		_ = notifier.Send(title, message)
+		_ = notifier.SendMessage(msg)
 	}
 
-	return map[string]any{
+	out := map[string]any{
+		"status":          "executed",
 		"run_id":          runResult.RunID,
-		"run_dir":         runResult.RunDir,
+		"run_dir":         ws.RelPath(runResult.RunDir),
 		"items_total":     len(runResult.Plan.Items),
 		"items_succeeded": itemsSucceeded,
 		"items_failed":    itemsFailed,
-	}, nil
+		"items_skipped":   itemsSkipped,
+		"usage":           runResult.Usage,
+	}
+	if approvedBy != "" {
+		out["approved_by"] = approvedBy
+	}
+	// The run's run.json summary, as plan run writes it
+	if state, err := planner.LoadRunState(runResult.RunDir); err == nil {
+		out["run"] = state
+	}
+	if measure != nil {
+		verified, unverified, regressed := runResult.VerificationCounts()
+		out["items_verified"] = verified
+		out["items_unverified"] = unverified
+		out["items_regressed"] = regressed
+	}
+
+	// Propose the KR statuses the run's fresh measurements imply
+	if store, ok := ctx.Value("daemon_store").(*Store); ok && store != nil {
+		if _, _, err := store.EnqueueUnique("kr_status_update", time.Now().UTC(), map[string]any{}); err != nil {
+			return nil, fmt.Errorf("enqueue kr_status_update: %w", err)
+		}
+	}
+
+	// Plans with success criteria are followed up by outcome_check
+	outcome, err := outcomes.Track(ws, planPath, runResult)
+	if err != nil {
+		return nil, fmt.Errorf("track outcome: %w", err)
+	}
+	if outcome != nil {
+		out["outcome_deadline"] = outcome.Deadline
+	}
+	return out, nil
+}
+
+// handleOutcomeCheck implements the outcome_check job handler.
+// It evaluates pending plan outcomes against metric snapshots and proposes
+// evidence for runs that met their success criteria.
+func handleOutcomeCheck(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+	var payload struct {
+		AgentID string `json:"agent_id"`
+	}
+	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
+		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
+			return nil, fmt.Errorf("parse payload: %w", err)
+		}
+	}
+
+	changed, err := outcomes.Check(ws, time.Now(), payload.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("check outcomes: %w", err)
+	}
+
+	resolved := make([]map[string]any, 0, len(changed))
+	for _, outcome := range changed {
+		entry := map[string]any{
+			"plan_id": outcome.PlanID,
+			"run_id":  outcome.RunID,
+			"status":  outcome.Status,
+		}
+		if outcome.ProposalID != "" {
+			entry["proposal_id"] = outcome.ProposalID
+		}
+		if outcome.EvidenceError != "" {
+			entry["evidence_error"] = outcome.EvidenceError
+		}
+		resolved = append(resolved, entry)
+	}
+	return map[string]any{"resolved": resolved}, nil
+}
+
+// handleGC implements the gc job handler. It applies the retention section
+// of okrchestra.yml to artifacts/runs and artifacts/plans, keeping runs
+// whose outcome is pending, and logs what it removed.
+func handleGC(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+	var payload struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
+		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
+			return nil, fmt.Errorf("parse payload: %w", err)
+		}
+	}
+
+	report, err := collectGarbage(ws, time.Now(), payload.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	if auditLogger, ok := ctx.Value("daemon_audit_logger").(*audit.Logger); ok && auditLogger != nil && !payload.DryRun {
+		_ = auditLogger.LogEvent("daemon", "artifacts_gc", map[string]any{
+			"job_id": job.ID,
+			"report": report,
+		})
+	}
+	return report, nil
+}
+
+// collectGarbage runs artifacts.GC with the workspace's retention policy.
+func collectGarbage(ws *workspace.Workspace, now time.Time, dryRun bool) (*artifacts.GCReport, error) {
+	policy, err := artifacts.LoadRetentionPolicy(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+	if policy.Protect, err = outcomes.PendingRunIDs(ws); err != nil {
+		return nil, err
+	}
+	return artifacts.GC(ws.ArtifactsDir, policy, now, dryRun)
+}
+
+// handleKRStatusUpdate implements the kr_status_update job handler.
+// It proposes the org KR status changes the latest metric snapshot implies,
+// as agent_id (default metrics.StatusAgentID), and notifies about them.
+func handleKRStatusUpdate(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+	var payload struct {
+		AgentID string `json:"agent_id"`
+	}
+	if job.PayloadJSON != "" && job.PayloadJSON != "{}" {
+		if err := json.Unmarshal([]byte(job.PayloadJSON), &payload); err != nil {
+			return nil, fmt.Errorf("parse payload: %w", err)
+		}
+	}
+	agentID := payload.AgentID
+	if agentID == "" {
+		agentID = metrics.StatusAgentID
+	}
+
+	snapshotsDir := filepath.Join(ws.MetricsDir, "snapshots")
+	snapshotPath, err := metrics.LatestSnapshotPath(snapshotsDir)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := metrics.LoadSnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	changes, meta, err := metrics.ProposeKRStatus(ws, snapshot, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"snapshot_path":  ws.RelPath(snapshotPath),
+		"status_changes": len(changes),
+	}
+	if meta == nil {
+		return result, nil
+	}
+	result["proposal_id"] = meta.ID
+	result["proposal_dir"] = ws.RelPath(meta.ProposalDir)
+	if auditLogger, ok := ctx.Value("daemon_audit_logger").(*audit.Logger); ok && auditLogger != nil {
+		_ = auditLogger.LogEvent("daemon", "kr_status_proposed", map[string]any{
+			"agent_id":     agentID,
+			"proposal_id":  meta.ID,
+			"proposal_dir": meta.ProposalDir,
+			"snapshot":     snapshotPath,
+			"changes":      len(changes),
+		})
+	}
+	notifyKRStatusChanges(ctx, ws, job.ID, snapshotsDir, snapshot, changes)
+	return result, nil
+}
+
+// handleNotifyDigest implements the notify_digest job handler.
+// In email digest mode it sends one email with the jobs finished since the
+// last digest and the notifications queued meanwhile.
+func handleNotifyDigest(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+	cfg, err := notify.LoadConfig(ws.Root)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Email == nil || cfg.Email.Mode != notify.EmailModeDigest {
+		return map[string]any{"skipped": "email digest not configured"}, nil
+	}
+	store, ok := ctx.Value("daemon_store").(*Store)
+	if !ok || store == nil {
+		return nil, fmt.Errorf("notify_digest requires the daemon store")
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-24 * time.Hour)
+	if last, err := store.GetKV(digestWatermarkKey); err != nil {
+		return nil, err
+	} else if parsed, err := time.Parse(time.RFC3339, last); err == nil {
+		since = parsed
+	}
+	jobs, err := digestJobs(store, since, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := notify.NewEmailSender(*cfg.Email, DigestQueuePath(ws))
+	sent, err := sender.SendDigest(now.Format("2006-01-02"), jobs)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SetKV(digestWatermarkKey, now.Format(time.RFC3339)); err != nil {
+		return nil, err
+	}
+	return map[string]any{"sent": sent, "jobs": len(jobs)}, nil
+}
+
+// digestWatermarkKey records when the last digest was sent.
+const digestWatermarkKey = "notify_digest_watermark"
+
+// DigestQueuePath is where email digest mode queues notifications.
+func DigestQueuePath(ws *workspace.Workspace) string {
+	return filepath.Join(ws.AuditDir, "notify_digest.jsonl")
+}
+
+// digestJobs lists the jobs finished after since, oldest first, leaving out
+// the digest job itself.
+func digestJobs(store *Store, since time.Time, digestJobID string) ([]notify.DigestJob, error) {
+	completed, err := store.ListRecentCompleted(1000)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []notify.DigestJob
+	for i := len(completed) - 1; i >= 0; i-- {
+		job := completed[i]
+		if job.ID == digestJobID || job.FinishedAt == nil || !job.FinishedAt.After(since) {
+			continue
+		}
+		entry := notify.DigestJob{ID: job.ID, Type: job.Type, Status: job.Status}
+		if job.Status == "failed" {
+			var result struct {
+				Error string `json:"error"`
+			}
+			_ = json.Unmarshal([]byte(job.ResultJSON), &result)
+			entry.Error = result.Error
+		}
+		jobs = append(jobs, entry)
+	}
+	return jobs, nil
+}
+
+// notifyKRStatusChanges sends one grouped notification for changes, with
+// annotations and projections for context, when the daemon has a notifier.
+func notifyKRStatusChanges(ctx context.Context, ws *workspace.Workspace, jobID, snapshotsDir string, snapshot *metrics.Snapshot, changes []metrics.StatusChange) {
+	notifier, ok := ctx.Value("daemon_notifier").(notify.Sender)
+	if !ok || notifier == nil || len(changes) == 0 {
+		return
+	}
+	asOf, _ := time.Parse("2006-01-02", snapshot.AsOf)
+	// Annotations are best-effort context; a bad file must not block notifications
+	annotations, _ := metrics.LoadAnnotations(snapshotsDir)
+	// Projections are likewise best-effort
+	history, _ := metrics.OpenHistory(snapshotsDir)
+	krChanges := make([]notify.KRChange, 0, len(changes))
+	for _, change := range changes {
+		var notes []string
+		for _, a := range metrics.AnnotationsFor(annotations, change.MetricKey, snapshot.AsOf) {
+			notes = append(notes, a.Note)
+		}
+		krChange := notify.KRChange{
+			KRID:        change.KRID,
+			Description: change.KRDesc,
+			OldStatus:   change.OldStatus,
+			NewStatus:   change.NewStatus,
+			Current:     change.Current,
+			Target:      change.Target,
+			Notes:       notes,
+		}
+		if history != nil {
+			if trend, ok, err := history.Trend(change.MetricKey, change.Baseline, change.Target, change.Current, asOf, metrics.DefaultTrendWindowDays); err == nil && ok {
+				krChange.ProjectedStatus = trend.ProjectedStatus
+				krChange.ForecastDate = trend.ForecastDate
+			}
+		}
+		krChanges = append(krChanges, krChange)
+	}
+	if history != nil {
+		history.Close()
+	}
+	// A bad locale config falls back to canonical formatting
+	loc, err := locale.Load(ws.Root)
+	if err != nil {
+		loc = locale.Canonical
+	}
+	for _, msg := range notify.GroupKRStatusChanges(loc, jobID, krChanges) {
+		// Send notification (ignore errors - notifications are best-effort)
+		_ = notifier.SendMessage(msg)
+	}
+}
+
+// notifyStaleKRs warns about KRs whose metric is stale in snapshot but was
+// not in previous, when the daemon has a notifier.
+func notifyStaleKRs(ctx context.Context, ws *workspace.Workspace, jobID string, previous, snapshot *metrics.Snapshot) {
+	notifier, ok := ctx.Value("daemon_notifier").(notify.Sender)
+	if !ok || notifier == nil {
+		return
+	}
+	policy, err := metrics.LoadFreshnessPolicy(ws.Root, ws.OKRsDir)
+	if err != nil {
+		return
+	}
+	stale, err := metrics.StaleMetrics(snapshot, policy)
+	if err != nil || len(stale) == 0 {
+		return
+	}
+	wasStale := map[string]bool{}
+	if previous != nil {
+		before, _ := metrics.StaleMetrics(previous, policy)
+		for _, metric := range before {
+			wasStale[metric.Key] = true
+		}
+	}
+	newlyStale := map[string]metrics.StaleMetric{}
+	for _, metric := range stale {
+		if !wasStale[metric.Key] {
+			newlyStale[metric.Key] = metric
+		}
+	}
+	if len(newlyStale) == 0 {
+		return
+	}
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return
+	}
+	var krs []notify.StaleKR
+	for _, docs := range [][]okrstore.Document{store.Org.Documents, store.Team.Documents, store.Person.Documents} {
+		for _, doc := range docs {
+			for _, obj := range doc.Objectives {
+				for _, kr := range obj.KeyResults {
+					if metric, ok := newlyStale[kr.MetricKey]; ok {
+						krs = append(krs, notify.StaleKR{
+							KRID:        kr.ID,
+							Description: kr.Description,
+							MetricKey:   kr.MetricKey,
+							ObservedAt:  metric.ObservedAt.Format(time.RFC3339),
+						})
+					}
+				}
+			}
+		}
+	}
+	// A bad locale config falls back to canonical formatting
+	loc, err := locale.Load(ws.Root)
+	if err != nil {
+		loc = locale.Canonical
+	}
+	if msg, ok := notify.StaleKRsMessage(loc, jobID, krs); ok {
+		// Notifications are best-effort
+		_ = notifier.SendMessage(msg)
+	}
 }
 
 // findMostRecentPlan searches for the most recent plan.json in the plans directory structure.
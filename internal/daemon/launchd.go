@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"okrchestra/internal/workspace"
@@ -32,6 +33,46 @@ func PlistPath(wsRoot string) (string, error) {
 	return filepath.Join(homeDir, "Library", "LaunchAgents", label+".plist"), nil
 }
 
+// Service managers the daemon can be installed under.
+const (
+	SupervisorLaunchd = "launchd"
+	SupervisorSystemd = "systemd"
+	// SupervisorSchtasks is the Windows Task Scheduler.
+	SupervisorSchtasks = "schtasks"
+)
+
+// Supervisor returns the service manager for this platform: launchd on
+// macOS, systemd on Linux, and Task Scheduler on Windows.
+func Supervisor() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return SupervisorLaunchd, nil
+	case "linux":
+		return SupervisorSystemd, nil
+	case "windows":
+		return SupervisorSchtasks, nil
+	default:
+		return "", fmt.Errorf("daemon supervision is not supported on %s; run 'okrchestra daemon run' under your own supervisor", runtime.GOOS)
+	}
+}
+
+// ServicePath returns the path of the service definition Install writes for
+// the workspace: a LaunchAgent plist on macOS, a systemd user unit on Linux,
+// or a Scheduled Task definition on Windows.
+func ServicePath(wsRoot string) (string, error) {
+	supervisor, err := Supervisor()
+	if err != nil {
+		return "", err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return UnitPath(wsRoot)
+	case SupervisorSchtasks:
+		return TaskPath(wsRoot)
+	}
+	return PlistPath(wsRoot)
+}
+
 // GeneratePlist creates a plist XML string for the okrchestra daemon.
 func GeneratePlist(ws *workspace.Workspace, binaryPath string) (string, error) {
 	if ws == nil {
@@ -88,7 +129,8 @@ func GeneratePlist(ws *workspace.Workspace, binaryPath string) (string, error) {
 	return plist, nil
 }
 
-// Install writes the LaunchAgent plist for the workspace.
+// Install writes the LaunchAgent plist for the workspace, or on Linux and
+// Windows its systemd user unit or Scheduled Task.
 func Install(ws *workspace.Workspace, binaryPath string) error {
 	if ws == nil {
 		return fmt.Errorf("workspace is nil")
@@ -99,6 +141,17 @@ func Install(ws *workspace.Workspace, binaryPath string) error {
 		return fmt.Errorf("ensure log dir: %w", err)
 	}
 
+	supervisor, err := Supervisor()
+	if err != nil {
+		return err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return installSystemd(ws, binaryPath)
+	case SupervisorSchtasks:
+		return installTask(ws, binaryPath)
+	}
+
 	// Generate plist
 	plistContent, err := GeneratePlist(ws, binaryPath)
 	if err != nil {
@@ -124,12 +177,23 @@ func Install(ws *workspace.Workspace, binaryPath string) error {
 	return nil
 }
 
-// Uninstall removes the LaunchAgent plist for the workspace.
+// Uninstall removes what Install wrote for the workspace.
 func Uninstall(ws *workspace.Workspace) error {
 	if ws == nil {
 		return fmt.Errorf("workspace is nil")
 	}
 
+	supervisor, err := Supervisor()
+	if err != nil {
+		return err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return uninstallSystemd(ws)
+	case SupervisorSchtasks:
+		return uninstallTask(ws)
+	}
+
 	plistPath, err := PlistPath(ws.Root)
 	if err != nil {
 		return fmt.Errorf("resolve plist path: %w", err)
@@ -148,12 +212,24 @@ func Uninstall(ws *workspace.Workspace) error {
 	return nil
 }
 
-// Start loads the LaunchAgent using launchctl.
+// Start loads the LaunchAgent using launchctl, enables and starts the
+// systemd user unit on Linux, or runs the Scheduled Task on Windows.
 func Start(ws *workspace.Workspace) error {
 	if ws == nil {
 		return fmt.Errorf("workspace is nil")
 	}
 
+	supervisor, err := Supervisor()
+	if err != nil {
+		return err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return startSystemd(ws)
+	case SupervisorSchtasks:
+		return startTask(ws)
+	}
+
 	plistPath, err := PlistPath(ws.Root)
 	if err != nil {
 		return fmt.Errorf("resolve plist path: %w", err)
@@ -174,12 +250,24 @@ func Start(ws *workspace.Workspace) error {
 	return nil
 }
 
-// Stop unloads the LaunchAgent using launchctl.
+// Stop unloads the LaunchAgent using launchctl, stops and disables the
+// systemd user unit on Linux, or ends the Scheduled Task on Windows.
 func Stop(ws *workspace.Workspace) error {
 	if ws == nil {
 		return fmt.Errorf("workspace is nil")
 	}
 
+	supervisor, err := Supervisor()
+	if err != nil {
+		return err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return stopSystemd(ws)
+	case SupervisorSchtasks:
+		return stopTask(ws)
+	}
+
 	plistPath, err := PlistPath(ws.Root)
 	if err != nil {
 		return fmt.Errorf("resolve plist path: %w", err)
@@ -213,6 +301,17 @@ func IsRunning(ws *workspace.Workspace) (bool, error) {
 		return false, fmt.Errorf("workspace is nil")
 	}
 
+	supervisor, err := Supervisor()
+	if err != nil {
+		return false, err
+	}
+	switch supervisor {
+	case SupervisorSystemd:
+		return isRunningSystemd(ws)
+	case SupervisorSchtasks:
+		return isRunningTask(ws)
+	}
+
 	label := PlistLabel(ws.Root)
 	cmd := exec.Command("launchctl", "list")
 	output, err := cmd.CombinedOutput()
@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"okrchestra/internal/workspace"
+)
+
+// LockFileName is the daemon's single-instance lock, kept in the audit dir.
+const LockFileName = "daemon.lock"
+
+// LockPath returns the path of the workspace's daemon lock.
+func LockPath(ws *workspace.Workspace) string {
+	return filepath.Join(ws.AuditDir, LockFileName)
+}
+
+// LockInfo identifies the daemon holding a workspace lock.
+type LockInfo struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname"`
+	LeaseOwner string    `json:"lease_owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// LockedError reports that another live daemon holds the workspace lock.
+type LockedError struct {
+	Path   string
+	Holder LockInfo
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("another daemon holds %s (pid %d on %s, since %s); stop it or pass --takeover",
+		e.Path, e.Holder.PID, e.Holder.Hostname, e.Holder.AcquiredAt.Format(time.RFC3339))
+}
+
+// Lock is a held workspace lock.
+type Lock struct {
+	Path string
+	Info LockInfo
+}
+
+// AcquireLock takes the lock at path for leaseOwner. A lock left by a
+// process that is no longer running on this host is broken automatically;
+// one held by a live process, or by a process on another host whose
+// liveness cannot be checked, is broken only with takeover. The previous
+// holder is returned when a lock was broken.
+func AcquireLock(path, leaseOwner string, takeover bool) (*Lock, *LockInfo, error) {
+	hostname, _ := os.Hostname()
+	lock := &Lock{Path: path, Info: LockInfo{
+		PID:        os.Getpid(),
+		Hostname:   hostname,
+		LeaseOwner: leaseOwner,
+		AcquiredAt: time.Now().UTC(),
+	}}
+
+	err := lock.create()
+	if err == nil {
+		return lock, nil, nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		return nil, nil, err
+	}
+
+	holder, err := readLock(path)
+	if err != nil {
+		// An unreadable lock file cannot name a live holder
+		holder = &LockInfo{}
+	}
+	if !takeover && holder.PID != 0 && (holder.Hostname != hostname || processAlive(holder.PID)) {
+		return nil, nil, &LockedError{Path: path, Holder: *holder}
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("break daemon lock: %w", err)
+	}
+	if err := lock.create(); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Another daemon won the race to replace it
+			if current, readErr := readLock(path); readErr == nil {
+				return nil, nil, &LockedError{Path: path, Holder: *current}
+			}
+		}
+		return nil, nil, err
+	}
+	return lock, holder, nil
+}
+
+func (l *Lock) create() error {
+	data, err := json.MarshalIndent(l.Info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode daemon lock: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return err
+		}
+		return fmt.Errorf("create daemon lock: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		os.Remove(l.Path)
+		return fmt.Errorf("write daemon lock: %w", err)
+	}
+	return f.Close()
+}
+
+func readLock(path string) (*LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("parse daemon lock: %w", err)
+	}
+	return &info, nil
+}
+
+// Held reports whether the lock file still names this holder, i.e. it has
+// not been taken over by another daemon.
+func (l *Lock) Held() bool {
+	current, err := readLock(l.Path)
+	if err != nil {
+		return false
+	}
+	return current.PID == l.Info.PID && current.Hostname == l.Info.Hostname && current.LeaseOwner == l.Info.LeaseOwner
+}
+
+// Release removes the lock file if it is still held.
+func (l *Lock) Release() error {
+	if !l.Held() {
+		return nil
+	}
+	if err := os.Remove(l.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("release daemon lock: %w", err)
+	}
+	return nil
+}
+
+// processAlive reports whether a process with pid is running on this host.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens a handle, which fails for exited processes
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
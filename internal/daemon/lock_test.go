@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFileName)
+
+	first, previous, err := AcquireLock(path, "daemon-a", false)
+	if err != nil || previous != nil {
+		t.Fatalf("first acquire: %v, previous %+v", err, previous)
+	}
+
+	_, _, err = AcquireLock(path, "daemon-b", false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder.LeaseOwner != "daemon-a" {
+		t.Fatalf("second acquire: got %v, want LockedError held by daemon-a", err)
+	}
+
+	second, previous, err := AcquireLock(path, "daemon-b", true)
+	if err != nil || previous == nil || previous.LeaseOwner != "daemon-a" {
+		t.Fatalf("takeover: %v, previous %+v", err, previous)
+	}
+	if first.Held() || !second.Held() {
+		t.Fatalf("after takeover: first held %v, second held %v", first.Held(), second.Held())
+	}
+
+	// Releasing a lost lock must not remove the new holder's lock
+	if err := first.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if !second.Held() {
+		t.Fatal("first.Release removed the second daemon's lock")
+	}
+	if err := second.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file still present after release: %v", err)
+	}
+}
+
+func TestAcquireLockBreaksStaleLock(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	// A process that has exited stands in for a crashed daemon
+	cmd := exec.Command(exe, "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	hostname, _ := os.Hostname()
+	data, _ := json.Marshal(LockInfo{PID: cmd.Process.Pid, Hostname: hostname, LeaseOwner: "crashed"})
+	path := filepath.Join(t.TempDir(), LockFileName)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, previous, err := AcquireLock(path, "daemon-a", false)
+	if err != nil || previous == nil || previous.LeaseOwner != "crashed" {
+		t.Fatalf("stale lock: %v, previous %+v", err, previous)
+	}
+	if !lock.Held() {
+		t.Fatal("lock not held after breaking stale lock")
+	}
+
+	// A holder on another host cannot be checked, so it needs --takeover
+	data, _ = json.Marshal(LockInfo{PID: 1, Hostname: hostname + "-other", LeaseOwner: "remote"})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var locked *LockedError
+	if _, _, err := AcquireLock(path, "daemon-a", false); !errors.As(err, &locked) {
+		t.Fatalf("remote lock: got %v, want LockedError", err)
+	}
+}
@@ -0,0 +1,230 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PoolConfigFileName is the workspace file configuring daemon workers.
+const PoolConfigFileName = "daemon.yml"
+
+// DefaultJobConcurrency limits job types that must not overlap even when
+// daemon.yml allows several workers. plan_execute runs agents against the
+// same work tree.
+var DefaultJobConcurrency = map[string]int{
+	"plan_execute": 1,
+}
+
+// DefaultJobTimeout bounds jobs whose type and payload set no timeout.
+const DefaultJobTimeout = 6 * time.Hour
+
+// JobTypeConfig tunes one job type.
+type JobTypeConfig struct {
+	// Concurrency caps how many jobs of the type run at once; 0 means the
+	// DefaultJobConcurrency entry, else no cap beyond the worker count.
+	Concurrency int `yaml:"concurrency"`
+	// Priority is added to each job's own priority when claiming.
+	Priority int `yaml:"priority"`
+	// Timeout cancels the handler's context; 0 means DefaultTimeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// PoolConfig is the contents of daemon.yml:
+//
+//	workers: 4
+//	default_timeout: 2h
+//	jobs:
+//	  plan_execute:
+//	    concurrency: 1
+//	    priority: 10
+//	    timeout: 4h
+//	  kr_measure:
+//	    concurrency: 4
+//
+// Due jobs are claimed highest priority first, then oldest first. A job
+// payload's "timeout" (e.g. "30m") overrides the configured timeout.
+// auto_approve_plans: true runs generated plans without approval, and
+// stream_transcripts: true copies the transcripts of running plan_execute
+// items to the daemon's output.
+type PoolConfig struct {
+	// Workers is how many jobs run at once; default 1.
+	Workers int `yaml:"workers"`
+	// DefaultTimeout applies to job types without a timeout; default
+	// DefaultJobTimeout.
+	DefaultTimeout time.Duration            `yaml:"default_timeout"`
+	Jobs           map[string]JobTypeConfig `yaml:"jobs"`
+	// AutoApprovePlans lets plan_execute run draft plans without `plan
+	// approve`.
+	AutoApprovePlans bool `yaml:"auto_approve_plans"`
+	// StreamTranscripts follows every plan_execute item's transcript
+	// into the daemon's output, which supervised daemons write to the
+	// workspace log.
+	StreamTranscripts bool `yaml:"stream_transcripts"`
+}
+
+// LoadPoolConfig reads <root>/daemon.yml. A missing file runs one job at a
+// time.
+func LoadPoolConfig(root string) (PoolConfig, error) {
+	var cfg PoolConfig
+	data, err := os.ReadFile(filepath.Join(root, PoolConfigFileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", PoolConfigFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", PoolConfigFileName, err)
+	}
+	if cfg.Workers < 0 {
+		return cfg, fmt.Errorf("%s: workers must be >= 0", PoolConfigFileName)
+	}
+	if cfg.DefaultTimeout < 0 {
+		return cfg, fmt.Errorf("%s: default_timeout must be >= 0", PoolConfigFileName)
+	}
+	for jobType, jobCfg := range cfg.Jobs {
+		if jobCfg.Concurrency < 0 {
+			return cfg, fmt.Errorf("%s: jobs.%s: concurrency must be >= 0", PoolConfigFileName, jobType)
+		}
+		if jobCfg.Timeout < 0 {
+			return cfg, fmt.Errorf("%s: jobs.%s: timeout must be >= 0", PoolConfigFileName, jobType)
+		}
+	}
+	return cfg, nil
+}
+
+// WorkerCount returns the number of workers, at least 1.
+func (c PoolConfig) WorkerCount() int {
+	if c.Workers < 1 {
+		return 1
+	}
+	return c.Workers
+}
+
+// Limit returns how many jobs of jobType may run at once.
+func (c PoolConfig) Limit(jobType string) int {
+	limit := DefaultJobConcurrency[jobType]
+	if jobCfg, ok := c.Jobs[jobType]; ok && jobCfg.Concurrency > 0 {
+		limit = jobCfg.Concurrency
+	}
+	if limit < 1 || limit > c.WorkerCount() {
+		limit = c.WorkerCount()
+	}
+	return limit
+}
+
+// Timeout returns how long a job may run: the payload's "timeout" if set,
+// else the job type's, else the default.
+func (c PoolConfig) Timeout(jobType, payloadJSON string) (time.Duration, error) {
+	var payload struct {
+		Timeout string `json:"timeout"`
+	}
+	if payloadJSON != "" {
+		_ = json.Unmarshal([]byte(payloadJSON), &payload)
+	}
+	if payload.Timeout != "" {
+		timeout, err := time.ParseDuration(payload.Timeout)
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("payload timeout %q must be a positive duration", payload.Timeout)
+		}
+		return timeout, nil
+	}
+	if jobCfg, ok := c.Jobs[jobType]; ok && jobCfg.Timeout > 0 {
+		return jobCfg.Timeout, nil
+	}
+	if c.DefaultTimeout > 0 {
+		return c.DefaultTimeout, nil
+	}
+	return DefaultJobTimeout, nil
+}
+
+// Priorities returns the configured priority of each job type that has one.
+func (c PoolConfig) Priorities() map[string]int {
+	priorities := map[string]int{}
+	for jobType, jobCfg := range c.Jobs {
+		if jobCfg.Priority != 0 {
+			priorities[jobType] = jobCfg.Priority
+		}
+	}
+	return priorities
+}
+
+// claimOptions returns the claim options for the running counts by type.
+func (c PoolConfig) claimOptions(running map[string]int) ClaimOptions {
+	opts := ClaimOptions{TypePriorities: c.Priorities()}
+	for jobType, n := range running {
+		if n >= c.Limit(jobType) {
+			opts.ExcludeTypes = append(opts.ExcludeTypes, jobType)
+		}
+	}
+	sort.Strings(opts.ExcludeTypes)
+	return opts
+}
+
+// workerPool tracks the jobs the run loop has handed to workers.
+type workerPool struct {
+	mu      sync.Mutex
+	running map[string]int
+	total   int
+	wg      sync.WaitGroup
+	// done receives a value each time a worker finishes, so the run loop
+	// can claim the next job without waiting for the poll interval.
+	done chan struct{}
+}
+
+func newWorkerPool() *workerPool {
+	return &workerPool{running: map[string]int{}, done: make(chan struct{}, 1)}
+}
+
+// dispatch claims due jobs and starts a worker for each until every worker
+// is busy or no claimable job is due.
+func (d *Daemon) dispatch(ctx context.Context, pool *workerPool) error {
+	for ctx.Err() == nil {
+		pool.mu.Lock()
+		if pool.total >= d.Pool.WorkerCount() {
+			pool.mu.Unlock()
+			return nil
+		}
+		opts := d.Pool.claimOptions(pool.running)
+		pool.mu.Unlock()
+
+		job, err := d.claim(opts)
+		if err != nil || job == nil {
+			return err
+		}
+
+		pool.mu.Lock()
+		pool.running[job.Type]++
+		pool.total++
+		pool.mu.Unlock()
+		// The slot is freed once the handler returns, even when execute
+		// gave up on it earlier, so an abandoned handler still counts
+		// against its type's limit.
+		release := func() {
+			pool.mu.Lock()
+			pool.running[job.Type]--
+			pool.total--
+			pool.mu.Unlock()
+			select {
+			case pool.done <- struct{}{}:
+			default:
+			}
+		}
+		pool.wg.Add(1)
+		go func(job *Job) {
+			defer pool.wg.Done()
+			if err := d.execute(ctx, job, release); err != nil {
+				fmt.Fprintf(os.Stderr, "job execution failed: %v\n", err)
+			}
+		}(job)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/workspace"
+)
+
+func TestLoadPoolConfig(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := LoadPoolConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WorkerCount() != 1 || cfg.Limit("kr_measure") != 1 {
+		t.Fatalf("defaults: workers %d, kr_measure limit %d", cfg.WorkerCount(), cfg.Limit("kr_measure"))
+	}
+
+	content := "workers: 4\njobs:\n  kr_measure:\n    concurrency: 2\n  plan_execute:\n    priority: 10\n"
+	if err := os.WriteFile(filepath.Join(root, PoolConfigFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadPoolConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Limit("kr_measure"); got != 2 {
+		t.Fatalf("kr_measure limit = %d, want 2", got)
+	}
+	if got := cfg.Limit("plan_execute"); got != 1 {
+		t.Fatalf("plan_execute limit = %d, want the default 1", got)
+	}
+	if got := cfg.Limit("outcome_check"); got != 4 {
+		t.Fatalf("outcome_check limit = %d, want the worker count", got)
+	}
+	if got := cfg.Priorities(); len(got) != 1 || got["plan_execute"] != 10 {
+		t.Fatalf("priorities = %v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, PoolConfigFileName), []byte("workers: -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPoolConfig(root); err == nil {
+		t.Fatal("negative workers accepted")
+	}
+}
+
+func TestPoolConfigTimeout(t *testing.T) {
+	root := t.TempDir()
+	content := "default_timeout: 2h\njobs:\n  plan_execute:\n    timeout: 4h\n"
+	if err := os.WriteFile(filepath.Join(root, PoolConfigFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadPoolConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		jobType, payload string
+		want             time.Duration
+	}{
+		{"plan_execute", `{}`, 4 * time.Hour},
+		{"kr_measure", `{}`, 2 * time.Hour},
+		{"plan_execute", `{"timeout":"45m"}`, 45 * time.Minute},
+	}
+	for _, tc := range cases {
+		got, err := cfg.Timeout(tc.jobType, tc.payload)
+		if err != nil || got != tc.want {
+			t.Fatalf("Timeout(%s, %s) = %s, %v; want %s", tc.jobType, tc.payload, got, err, tc.want)
+		}
+	}
+	if got, _ := (PoolConfig{}).Timeout("kr_measure", ""); got != DefaultJobTimeout {
+		t.Fatalf("default timeout = %s", got)
+	}
+	if _, err := cfg.Timeout("kr_measure", `{"timeout":"soon"}`); err == nil {
+		t.Fatal("invalid payload timeout accepted")
+	}
+}
+
+func TestClaimNextWithPriorities(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	oldest, _, _ := store.EnqueueUnique("kr_measure", now.Add(-3*time.Minute), map[string]any{})
+	urgent, _, _ := store.EnqueueUniqueWithPriority("kr_measure", now.Add(-time.Minute), map[string]any{}, 5)
+	execute, _, _ := store.EnqueueUnique("plan_execute", now.Add(-2*time.Minute), map[string]any{})
+
+	opts := ClaimOptions{TypePriorities: map[string]int{"plan_execute": 10}}
+	var claimed []string
+	for i := 0; i < 3; i++ {
+		job, err := store.ClaimNextWith(now, "test", time.Minute, opts)
+		if err != nil || job == nil {
+			t.Fatalf("claim %d: %v, %v", i, job, err)
+		}
+		claimed = append(claimed, job.ID)
+	}
+	if want := []string{execute, urgent, oldest}; claimed[0] != want[0] || claimed[1] != want[1] || claimed[2] != want[2] {
+		t.Fatalf("claim order = %v, want %v", claimed, want)
+	}
+
+	store.EnqueueUnique("plan_execute", now.Add(-time.Minute), map[string]any{})
+	job, err := store.ClaimNextWith(now, "test", time.Minute, ClaimOptions{ExcludeTypes: []string{"plan_execute"}})
+	if err != nil || job != nil {
+		t.Fatalf("excluded type claimed: %+v, %v", job, err)
+	}
+}
+
+func TestDispatchRespectsConcurrencyLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	started := map[string]int{}
+	block := func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+		mu.Lock()
+		started[job.Type]++
+		mu.Unlock()
+		<-release
+		return map[string]any{}, nil
+	}
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir},
+		Store:       store,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "test",
+		LeaseFor:    time.Minute,
+		Pool:        PoolConfig{Workers: 3},
+		Handlers:    map[string]HandlerFunc{"plan_execute": block, "kr_measure": block},
+	}
+	defer d.AuditLogger.Close()
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		store.EnqueueUnique("plan_execute", now.Add(-time.Duration(i+10)*time.Minute), map[string]any{})
+		store.EnqueueUnique("kr_measure", now.Add(-time.Duration(i+1)*time.Minute), map[string]any{})
+	}
+
+	pool := newWorkerPool()
+	if err := d.dispatch(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+	pool.mu.Lock()
+	running := map[string]int{"plan_execute": pool.running["plan_execute"], "kr_measure": pool.running["kr_measure"]}
+	pool.mu.Unlock()
+	if running["plan_execute"] != 1 || running["kr_measure"] != 2 {
+		t.Fatalf("running = %v, want one plan_execute and two kr_measure", running)
+	}
+
+	close(release)
+	pool.wg.Wait()
+	// The second plan_execute runs once the first has finished
+	if err := d.dispatch(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+	pool.wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if started["plan_execute"] != 2 || started["kr_measure"] != 2 {
+		t.Fatalf("started = %v", started)
+	}
+}
+
+func TestDispatchHoldsSlotOfAbandonedHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	oldGrace := handlerGracePeriod
+	handlerGracePeriod = 20 * time.Millisecond
+	defer func() { handlerGracePeriod = oldGrace }()
+
+	release := make(chan struct{})
+	started := make(chan string, 2)
+	d := &Daemon{
+		Workspace:   &workspace.Workspace{Root: tmpDir},
+		Store:       store,
+		AuditLogger: audit.NewLogger(filepath.Join(tmpDir, "audit.sqlite")),
+		LeaseOwner:  "test",
+		LeaseFor:    time.Minute,
+		Pool:        PoolConfig{Workers: 2},
+		Handlers: map[string]HandlerFunc{
+			// Ignores cancellation until released.
+			"plan_execute": func(ctx context.Context, ws *workspace.Workspace, job *Job) (any, error) {
+				started <- job.ID
+				<-release
+				return map[string]any{}, nil
+			},
+		},
+	}
+	defer d.AuditLogger.Close()
+
+	now := time.Now()
+	first, _, _ := store.EnqueueUnique("plan_execute", now.Add(-2*time.Minute), map[string]any{"timeout": "10ms"})
+	second, _, _ := store.EnqueueUnique("plan_execute", now.Add(-time.Minute), map[string]any{"timeout": "10ms"})
+
+	pool := newWorkerPool()
+	if err := d.dispatch(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-started; id != first {
+		t.Fatalf("started %s first, want %s", id, first)
+	}
+	// execute gives up on the handler and fails the job...
+	pool.wg.Wait()
+	if job, _ := store.GetJob(first); job.Status != "failed" {
+		t.Fatalf("abandoned job status = %s", job.Status)
+	}
+	// ...but the handler still holds the only plan_execute slot.
+	if err := d.dispatch(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ := store.GetJob(second); job.Status != "queued" {
+		t.Fatalf("second job %s while an abandoned handler was still running", job.Status)
+	}
+
+	close(release)
+	<-pool.done
+	if err := d.dispatch(context.Background(), pool); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-started; id != second {
+		t.Fatalf("started %s, want %s", id, second)
+	}
+	pool.wg.Wait()
+}
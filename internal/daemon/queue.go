@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const QueueExportSchemaVersion = 1
+
+// QueueExport is the portable form of the pending daemon queue, used to move
+// a workspace to another machine or to restore a corrupted state database.
+type QueueExport struct {
+	SchemaVersion int           `json:"schema_version"`
+	ExportedAt    string        `json:"exported_at"`
+	Jobs          []ExportedJob `json:"jobs"`
+}
+
+// ExportedJob is a queued or running job. Running jobs are exported as
+// queued with their lease cleared so they run again after import.
+type ExportedJob struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	ScheduledAt string          `json:"scheduled_at"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	WasRunning  bool            `json:"was_running,omitempty"`
+	Attempts    int             `json:"attempts,omitempty"`
+	NextRetryAt string          `json:"next_retry_at,omitempty"`
+	Priority    int             `json:"priority,omitempty"`
+}
+
+// ImportResult reports what ImportQueue did with each exported job.
+type ImportResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+// ExportQueue returns every queued and running job, oldest first.
+func (s *Store) ExportQueue() (*QueueExport, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + jobColumns + `
+		FROM daemon_jobs
+		WHERE status IN ('queued', 'running')
+		ORDER BY scheduled_at ASC, id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query pending jobs: %w", err)
+	}
+	defer rows.Close()
+	jobs, err := s.scanJobs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &QueueExport{
+		SchemaVersion: QueueExportSchemaVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Jobs:          []ExportedJob{},
+	}
+	for _, job := range jobs {
+		exported := ExportedJob{
+			ID:          job.ID,
+			Type:        job.Type,
+			ScheduledAt: job.ScheduledAt.UTC().Format(time.RFC3339),
+			WasRunning:  job.Status == "running",
+			Attempts:    job.Attempts,
+			Priority:    job.Priority,
+		}
+		if job.NextRetryAt != nil {
+			exported.NextRetryAt = job.NextRetryAt.UTC().Format(time.RFC3339)
+		}
+		if job.PayloadJSON != "" {
+			if !json.Valid([]byte(job.PayloadJSON)) {
+				return nil, fmt.Errorf("job %s has an invalid payload", job.ID)
+			}
+			exported.Payload = json.RawMessage(job.PayloadJSON)
+		}
+		export.Jobs = append(export.Jobs, exported)
+	}
+	return export, nil
+}
+
+// ImportQueue enqueues the exported jobs in one transaction. Jobs whose ID,
+// or type and scheduled time, already exist are skipped, so importing the
+// same export twice is harmless.
+func (s *Store) ImportQueue(export *QueueExport) (*ImportResult, error) {
+	if export == nil {
+		return nil, fmt.Errorf("queue export is required")
+	}
+	if export.SchemaVersion != QueueExportSchemaVersion {
+		return nil, fmt.Errorf("unsupported queue export schema_version %d (want %d)", export.SchemaVersion, QueueExportSchemaVersion)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{Imported: []string{}, Skipped: []string{}}
+	for _, job := range export.Jobs {
+		if job.ID == "" || job.Type == "" {
+			return nil, fmt.Errorf("exported job is missing id or type")
+		}
+		scheduledAt, err := time.Parse(time.RFC3339, job.ScheduledAt)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: parse scheduled_at: %w", job.ID, err)
+		}
+		scheduledAtStr := scheduledAt.UTC().Format(time.RFC3339)
+		var nextRetryAt any
+		if job.NextRetryAt != "" {
+			retryAt, err := time.Parse(time.RFC3339, job.NextRetryAt)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: parse next_retry_at: %w", job.ID, err)
+			}
+			nextRetryAt = retryAt.UTC().Format(time.RFC3339)
+		}
+
+		var existingID string
+		err = tx.QueryRow(
+			"SELECT id FROM daemon_jobs WHERE id = ? OR (type = ? AND scheduled_at = ?)",
+			job.ID, job.Type, scheduledAtStr,
+		).Scan(&existingID)
+		if err == nil {
+			result.Skipped = append(result.Skipped, job.ID)
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("check existing job: %w", err)
+		}
+
+		payload := string(job.Payload)
+		if payload == "" {
+			payload = "{}"
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO daemon_jobs (id, type, status, scheduled_at, payload_json, attempts, next_retry_at, priority)
+			VALUES (?, ?, 'queued', ?, ?, ?, ?, ?)
+		`, job.ID, job.Type, scheduledAtStr, payload, job.Attempts, nextRetryAt, job.Priority); err != nil {
+			return nil, fmt.Errorf("insert job %s: %w", job.ID, err)
+		}
+		result.Imported = append(result.Imported, job.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return result, nil
+}
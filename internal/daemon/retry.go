@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetryConfigFileName is the workspace file configuring job retries.
+const RetryConfigFileName = "retry.yml"
+
+// Backoff defaults for policies that enable retries without setting them.
+const (
+	DefaultRetryBackoff    = 30 * time.Second
+	DefaultRetryMaxBackoff = time.Hour
+)
+
+// RetryPolicy controls how often a failed job is run again. The delay
+// before attempt n+1 is Backoff * 2^(n-1), capped at MaxBackoff.
+type RetryPolicy struct {
+	// MaxAttempts counts the first run; 1 (the default) never retries.
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+}
+
+// RetryConfig is the contents of retry.yml:
+//
+//	default:
+//	  max_attempts: 2
+//	jobs:
+//	  kr_measure:
+//	    max_attempts: 5
+//	    backoff: 1m
+//	    max_backoff: 30m
+//
+// Job type entries override the default field by field.
+type RetryConfig struct {
+	Default RetryPolicy            `yaml:"default"`
+	Jobs    map[string]RetryPolicy `yaml:"jobs"`
+}
+
+// LoadRetryConfig reads <root>/retry.yml. A missing file disables retries.
+func LoadRetryConfig(root string) (RetryConfig, error) {
+	var cfg RetryConfig
+	data, err := os.ReadFile(filepath.Join(root, RetryConfigFileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", RetryConfigFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", RetryConfigFileName, err)
+	}
+	if err := cfg.Default.validate(); err != nil {
+		return cfg, fmt.Errorf("%s: default: %w", RetryConfigFileName, err)
+	}
+	for jobType, policy := range cfg.Jobs {
+		if err := policy.validate(); err != nil {
+			return cfg, fmt.Errorf("%s: jobs.%s: %w", RetryConfigFileName, jobType, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must be >= 0")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("backoff and max_backoff must be >= 0")
+	}
+	return nil
+}
+
+// Policy returns the effective policy for a job type.
+func (c RetryConfig) Policy(jobType string) RetryPolicy {
+	policy := c.Default
+	if override, ok := c.Jobs[jobType]; ok {
+		if override.MaxAttempts > 0 {
+			policy.MaxAttempts = override.MaxAttempts
+		}
+		if override.Backoff > 0 {
+			policy.Backoff = override.Backoff
+		}
+		if override.MaxBackoff > 0 {
+			policy.MaxBackoff = override.MaxBackoff
+		}
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Backoff == 0 {
+		policy.Backoff = DefaultRetryBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	return policy
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based)
+// before the next one.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
@@ -9,6 +9,13 @@ import (
 type Scheduler struct {
 	store    *Store
 	location *time.Location
+
+	// Schedules are the recurring jobs to enqueue; NewScheduler sets
+	// DefaultSchedules.
+	Schedules []Schedule
+	// WatchPolling schedules watch_tick every 30 seconds. NewScheduler
+	// enables it; the daemon turns it off while the fsnotify watcher runs.
+	WatchPolling bool
 }
 
 // NewScheduler creates a scheduler with the given timezone location.
@@ -18,8 +25,10 @@ func NewScheduler(store *Store, tzName string) (*Scheduler, error) {
 		return nil, fmt.Errorf("load timezone %s: %w", tzName, err)
 	}
 	return &Scheduler{
-		store:    store,
-		location: loc,
+		store:        store,
+		location:     loc,
+		Schedules:    DefaultSchedules(),
+		WatchPolling: true,
 	}, nil
 }
 
@@ -47,24 +56,17 @@ func (s *Scheduler) Tick(now time.Time) error {
 		return nil
 	}
 
-	// Schedule kr_measure daily at 02:00 America/Chicago
-	if err := s.scheduleDailyAt(lastWatermark, now, "kr_measure", 2, 0); err != nil {
-		return fmt.Errorf("schedule kr_measure: %w", err)
-	}
-
-	// Schedule plan_generate weekly Monday at 09:00 America/Chicago
-	if err := s.scheduleWeeklyAt(lastWatermark, now, "plan_generate", time.Monday, 9, 0); err != nil {
-		return fmt.Errorf("schedule plan_generate: %w", err)
-	}
-
-	// Schedule plan_execute weekly Monday at 09:15 America/Chicago
-	if err := s.scheduleWeeklyAt(lastWatermark, now, "plan_execute", time.Monday, 9, 15); err != nil {
-		return fmt.Errorf("schedule plan_execute: %w", err)
+	for _, sched := range s.Schedules {
+		if err := s.scheduleRuns(lastWatermark, now, sched); err != nil {
+			return fmt.Errorf("schedule %s: %w", sched.Job, err)
+		}
 	}
 
 	// Schedule watch_tick every 30 seconds
-	if err := s.scheduleWatchTicks(lastWatermark, now); err != nil {
-		return fmt.Errorf("schedule watch_tick: %w", err)
+	if s.WatchPolling {
+		if err := s.scheduleWatchTicks(lastWatermark, now); err != nil {
+			return fmt.Errorf("schedule watch_tick: %w", err)
+		}
 	}
 
 	// Update watermark
@@ -75,55 +77,13 @@ func (s *Scheduler) Tick(now time.Time) error {
 	return nil
 }
 
-// scheduleDailyAt schedules a job daily at the specified hour and minute.
-func (s *Scheduler) scheduleDailyAt(lastWatermark, now time.Time, jobType string, hour, minute int) error {
-	// Start from the day after lastWatermark
-	start := lastWatermark.In(s.location).Truncate(24 * time.Hour).Add(24 * time.Hour)
-
-	for current := start; !current.After(now); current = current.Add(24 * time.Hour) {
-		scheduledTime := time.Date(
-			current.Year(), current.Month(), current.Day(),
-			hour, minute, 0, 0, s.location,
-		)
-
-		if scheduledTime.After(lastWatermark) && !scheduledTime.After(now) {
-			payload := map[string]any{
-				"scheduled_time": scheduledTime.Format(time.RFC3339),
-			}
-			_, _, err := s.store.EnqueueUnique(jobType, scheduledTime, payload)
-			if err != nil {
-				return fmt.Errorf("enqueue %s at %s: %w", jobType, scheduledTime, err)
-			}
-		}
-	}
-
-	return nil
-}
-
-// scheduleWeeklyAt schedules a job weekly on the specified weekday at hour and minute.
-func (s *Scheduler) scheduleWeeklyAt(lastWatermark, now time.Time, jobType string, weekday time.Weekday, hour, minute int) error {
-	// Find the first occurrence of the target weekday after lastWatermark
-	start := lastWatermark.In(s.location).Truncate(24 * time.Hour)
-	
-	// Advance to the next target weekday
-	for start.Weekday() != weekday {
-		start = start.Add(24 * time.Hour)
-	}
-
-	for current := start; !current.After(now); current = current.Add(7 * 24 * time.Hour) {
-		scheduledTime := time.Date(
-			current.Year(), current.Month(), current.Day(),
-			hour, minute, 0, 0, s.location,
-		)
-
-		if scheduledTime.After(lastWatermark) && !scheduledTime.After(now) {
-			payload := map[string]any{
-				"scheduled_time": scheduledTime.Format(time.RFC3339),
-			}
-			_, _, err := s.store.EnqueueUnique(jobType, scheduledTime, payload)
-			if err != nil {
-				return fmt.Errorf("enqueue %s at %s: %w", jobType, scheduledTime, err)
-			}
+// scheduleRuns enqueues every run of sched after lastWatermark and at or
+// before now.
+func (s *Scheduler) scheduleRuns(lastWatermark, now time.Time, sched Schedule) error {
+	for scheduledTime := sched.Next(lastWatermark, s.location); !scheduledTime.IsZero() && !scheduledTime.After(now); scheduledTime = sched.Next(scheduledTime, s.location) {
+		_, _, err := s.store.EnqueueUnique(sched.Job, scheduledTime, sched.payload(scheduledTime))
+		if err != nil {
+			return fmt.Errorf("enqueue %s at %s: %w", sched.Job, scheduledTime, err)
 		}
 	}
 
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchedulesFileName is the workspace file defining recurring jobs.
+const SchedulesFileName = "schedules.yml"
+
+// Schedule is one recurring job. Schedule is a five-field cron expression
+// ("15 9 * * mon") or a shorthand: "daily 02:00" or "weekly monday 09:00".
+type Schedule struct {
+	Job      string         `yaml:"job"`
+	Schedule string         `yaml:"schedule"`
+	TimeZone string         `yaml:"timezone,omitempty"`
+	Payload  map[string]any `yaml:"payload,omitempty"`
+
+	spec *cronSpec
+	loc  *time.Location
+}
+
+// SchedulesConfig is the contents of schedules.yml:
+//
+//	timezone: America/Chicago
+//	schedules:
+//	  - job: kr_measure
+//	    schedule: daily 02:00
+//	  - job: plan_generate
+//	    schedule: "0 9 * * mon"
+//	    timezone: Europe/Berlin
+//	    payload:
+//	      note: weekly planning
+//
+// A schedule's timezone overrides the file's, which overrides the daemon's
+// --tz. The file replaces the defaults entirely.
+type SchedulesConfig struct {
+	TimeZone  string     `yaml:"timezone"`
+	Schedules []Schedule `yaml:"schedules"`
+}
+
+// DefaultSchedules returns the schedules used when the workspace has no
+// schedules.yml.
+func DefaultSchedules() []Schedule {
+	schedules := []Schedule{
+		{Job: "kr_measure", Schedule: "daily 02:00"},
+		{Job: "plan_generate", Schedule: "weekly monday 09:00"},
+		{Job: "plan_execute", Schedule: "weekly monday 09:15"},
+		// After kr_measure, so outcomes see fresh snapshots.
+		{Job: "outcome_check", Schedule: "daily 03:00"},
+		// A no-op unless notify.yml sets email mode: digest.
+		{Job: "notify_digest", Schedule: "daily 18:00"},
+		// Only compresses old transcripts unless okrchestra.yml sets
+		// retention limits.
+		{Job: "gc", Schedule: "daily 04:00"},
+	}
+	for i := range schedules {
+		spec, err := parseSchedule(schedules[i].Schedule)
+		if err != nil {
+			panic(err)
+		}
+		schedules[i].spec = spec
+	}
+	return schedules
+}
+
+// LoadSchedules reads <root>/schedules.yml. A missing file yields
+// DefaultSchedules.
+func LoadSchedules(root string) ([]Schedule, error) {
+	data, err := os.ReadFile(filepath.Join(root, SchedulesFileName))
+	if os.IsNotExist(err) {
+		return DefaultSchedules(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", SchedulesFileName, err)
+	}
+	var cfg SchedulesConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", SchedulesFileName, err)
+	}
+	var fileLoc *time.Location
+	if cfg.TimeZone != "" {
+		if fileLoc, err = time.LoadLocation(cfg.TimeZone); err != nil {
+			return nil, fmt.Errorf("%s: load timezone %s: %w", SchedulesFileName, cfg.TimeZone, err)
+		}
+	}
+	schedules := make([]Schedule, 0, len(cfg.Schedules))
+	for i, sched := range cfg.Schedules {
+		if sched.Job == "" {
+			return nil, fmt.Errorf("%s: schedules[%d]: job is required", SchedulesFileName, i)
+		}
+		if sched.spec, err = parseSchedule(sched.Schedule); err != nil {
+			return nil, fmt.Errorf("%s: schedules[%d] (%s): %w", SchedulesFileName, i, sched.Job, err)
+		}
+		sched.loc = fileLoc
+		if sched.TimeZone != "" {
+			if sched.loc, err = time.LoadLocation(sched.TimeZone); err != nil {
+				return nil, fmt.Errorf("%s: schedules[%d] (%s): load timezone %s: %w", SchedulesFileName, i, sched.Job, sched.TimeZone, err)
+			}
+		}
+		schedules = append(schedules, sched)
+	}
+	return schedules, nil
+}
+
+// Next returns the first run time of the schedule after t, using loc unless
+// the schedule sets its own timezone. It is zero if the schedule never fires.
+func (s Schedule) Next(t time.Time, loc *time.Location) time.Time {
+	if s.loc != nil {
+		loc = s.loc
+	}
+	return s.spec.Next(t, loc)
+}
+
+// payload returns the job payload for a run at scheduledTime.
+func (s Schedule) payload(scheduledTime time.Time) map[string]any {
+	payload := make(map[string]any, len(s.Payload)+1)
+	for k, v := range s.Payload {
+		payload[k] = v
+	}
+	payload["scheduled_time"] = scheduledTime.Format(time.RFC3339)
+	return payload
+}
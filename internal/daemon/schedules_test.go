@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// Sunday 2025-03-09 is the US spring-forward day; 02:30 does not exist.
+	from := time.Date(2025, 3, 8, 23, 0, 0, 0, chicago)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		// 02:00 is skipped that day; the run lands where time.Date puts it.
+		{"daily 02:00", time.Date(2025, 3, 9, 2, 0, 0, 0, chicago)},
+		{"daily 23:00", time.Date(2025, 3, 9, 23, 0, 0, 0, chicago)},
+		{"weekly monday 09:15", time.Date(2025, 3, 10, 9, 15, 0, 0, chicago)},
+		{"*/20 * * * *", time.Date(2025, 3, 8, 23, 20, 0, 0, chicago)},
+		{"0 9 1 * *", time.Date(2025, 4, 1, 9, 0, 0, 0, chicago)},
+		{"30 8 * jan-jun mon-fri", time.Date(2025, 3, 10, 8, 30, 0, 0, chicago)},
+		{"0 6 * * 7", time.Date(2025, 3, 9, 6, 0, 0, 0, chicago)},
+		// Day-of-month and weekday both restricted: either matches.
+		{"0 12 15 * sat", time.Date(2025, 3, 15, 12, 0, 0, 0, chicago)},
+		{"0 0 30 feb *", time.Time{}},
+	}
+	for _, tt := range tests {
+		spec, err := parseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.expr, err)
+		}
+		got := spec.Next(from, chicago)
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: next = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "daily", "daily 25:00", "weekly funday 09:00", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := parseSchedule(bad); err == nil {
+			t.Errorf("parse %q: expected error", bad)
+		}
+	}
+}
+
+func TestLoadSchedules(t *testing.T) {
+	root := t.TempDir()
+	schedules, err := LoadSchedules(root)
+	if err != nil {
+		t.Fatalf("load defaults: %v", err)
+	}
+	if len(schedules) != len(DefaultSchedules()) {
+		t.Fatalf("missing file: got %d schedules, want defaults", len(schedules))
+	}
+
+	content := `timezone: Europe/Berlin
+schedules:
+  - job: kr_measure
+    schedule: "0 */6 * * *"
+  - job: plan_generate
+    schedule: weekly friday 16:00
+    timezone: UTC
+    payload:
+      note: friday planning
+`
+	if err := os.WriteFile(filepath.Join(root, SchedulesFileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("write schedules: %v", err)
+	}
+	schedules, err = LoadSchedules(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(schedules) != 2 {
+		t.Fatalf("got %d schedules, want 2", len(schedules))
+	}
+	if got := schedules[0].loc.String(); got != "Europe/Berlin" {
+		t.Fatalf("file timezone not applied: %s", got)
+	}
+	if got := schedules[1].loc.String(); got != "UTC" {
+		t.Fatalf("schedule timezone not applied: %s", got)
+	}
+
+	for _, bad := range []string{
+		"schedules:\n  - schedule: daily 02:00\n",
+		"schedules:\n  - job: kr_measure\n    schedule: sometimes\n",
+		"schedules:\n  - job: kr_measure\n    schedule: daily 02:00\n    timezone: Nowhere/Special\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, SchedulesFileName), []byte(bad), 0o644); err != nil {
+			t.Fatalf("write schedules: %v", err)
+		}
+		if _, err := LoadSchedules(root); err == nil || !strings.Contains(err.Error(), SchedulesFileName) {
+			t.Errorf("expected %s error for %q, got %v", SchedulesFileName, bad, err)
+		}
+	}
+}
+
+func TestSchedulerUsesConfiguredSchedules(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	content := `schedules:
+  - job: kr_measure
+    schedule: "0 */6 * * *"
+    payload:
+      source: custom
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, SchedulesFileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("write schedules: %v", err)
+	}
+	scheduler, err := NewScheduler(store, "UTC")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	if scheduler.Schedules, err = LoadSchedules(tmpDir); err != nil {
+		t.Fatalf("load schedules: %v", err)
+	}
+
+	// Monday, so the default plan_generate would fire if it were still set.
+	start := time.Date(2025, 3, 3, 1, 0, 0, 0, time.UTC)
+	if err := scheduler.Tick(start); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if err := scheduler.Tick(start.Add(24 * time.Hour)); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+
+	jobs, err := store.ListJobs(-1)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	var measured int
+	for _, job := range jobs {
+		switch job.Type {
+		case "kr_measure":
+			measured++
+			if !strings.Contains(job.PayloadJSON, `"source":"custom"`) || !strings.Contains(job.PayloadJSON, `"scheduled_time"`) {
+				t.Fatalf("unexpected payload: %s", job.PayloadJSON)
+			}
+		case "watch_tick":
+		default:
+			t.Fatalf("unexpected job type %s", job.Type)
+		}
+	}
+	// 06:00, 12:00, 18:00 and 00:00 within (01:00, 01:00 next day].
+	if measured != 4 {
+		t.Fatalf("expected 4 kr_measure jobs, got %d", measured)
+	}
+}
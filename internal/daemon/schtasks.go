@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"okrchestra/internal/workspace"
+)
+
+// TaskName returns the Windows Scheduled Task name for a workspace.
+func TaskName(wsRoot string) string {
+	return fmt.Sprintf("OKRchestra-%s", WorkspaceHash(wsRoot))
+}
+
+// TaskPath returns where the Scheduled Task definition for a workspace is
+// kept, under %LOCALAPPDATA%\okrchestra\tasks.
+func TaskPath(wsRoot string) (string, error) {
+	dataDir := os.Getenv("LOCALAPPDATA")
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+		dataDir = filepath.Join(homeDir, "AppData", "Local")
+	}
+	return filepath.Join(dataDir, "okrchestra", "tasks", TaskName(wsRoot)+".xml"), nil
+}
+
+// GenerateTask creates a Task Scheduler XML definition that runs the
+// okrchestra daemon at logon, restarting it if it exits with an error.
+// cmd.exe appends its output to the workspace log, as launchd and systemd
+// do.
+func GenerateTask(ws *workspace.Workspace, binaryPath string) (string, error) {
+	if ws == nil {
+		return "", fmt.Errorf("workspace is nil")
+	}
+
+	// Ensure binary path is absolute
+	absBinaryPath, err := filepath.Abs(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("resolve binary path: %w", err)
+	}
+
+	// cmd /s /c strips the outer quotes and runs the rest verbatim
+	arguments := fmt.Sprintf(`/s /c ""%s" daemon run --workspace "%s" >> "%s" 2>&1"`,
+		absBinaryPath, ws.Root, GetLogPath(ws))
+
+	task := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>OKRchestra daemon for %s</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>999</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>cmd.exe</Command>
+      <Arguments>%s</Arguments>
+      <WorkingDirectory>%s</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`, xmlEscape(ws.Root), xmlEscape(arguments), xmlEscape(ws.Root))
+
+	return task, nil
+}
+
+func xmlEscape(value string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
+
+// encodeUTF16 encodes s as little-endian UTF-16 with a byte order mark,
+// the encoding schtasks expects for /XML files.
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 0, 2+2*len(units))
+	out = append(out, 0xFF, 0xFE)
+	for _, u := range units {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+// installTask writes the task definition and registers it with schtasks.
+func installTask(ws *workspace.Workspace, binaryPath string) error {
+	taskContent, err := GenerateTask(ws, binaryPath)
+	if err != nil {
+		return fmt.Errorf("generate task: %w", err)
+	}
+
+	taskPath, err := TaskPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve task path: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(taskPath), 0o755); err != nil {
+		return fmt.Errorf("ensure tasks dir: %w", err)
+	}
+
+	if err := os.WriteFile(taskPath, encodeUTF16(taskContent), 0o644); err != nil {
+		return fmt.Errorf("write task: %w", err)
+	}
+
+	return schtasks("/Create", "/TN", TaskName(ws.Root), "/XML", taskPath, "/F")
+}
+
+// uninstallTask unregisters the task and removes its definition.
+func uninstallTask(ws *workspace.Workspace) error {
+	taskPath, err := TaskPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve task path: %w", err)
+	}
+
+	if _, err := os.Stat(taskPath); os.IsNotExist(err) {
+		return fmt.Errorf("task not found: %s", taskPath)
+	}
+
+	if err := schtasks("/Delete", "/TN", TaskName(ws.Root), "/F"); err != nil {
+		return err
+	}
+
+	if err := os.Remove(taskPath); err != nil {
+		return fmt.Errorf("remove task: %w", err)
+	}
+
+	return nil
+}
+
+// startTask runs the registered task now; the logon trigger starts it
+// after later sign-ins.
+func startTask(ws *workspace.Workspace) error {
+	taskPath, err := TaskPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve task path: %w", err)
+	}
+
+	if _, err := os.Stat(taskPath); os.IsNotExist(err) {
+		return fmt.Errorf("task not found: %s (run 'okrchestra daemon install' first)", taskPath)
+	}
+
+	return schtasks("/Run", "/TN", TaskName(ws.Root))
+}
+
+// stopTask ends the running task.
+func stopTask(ws *workspace.Workspace) error {
+	return schtasks("/End", "/TN", TaskName(ws.Root))
+}
+
+// isRunningTask reports whether the task's status is Running.
+func isRunningTask(ws *workspace.Workspace) (bool, error) {
+	cmd := exec.Command("schtasks", "/Query", "/TN", TaskName(ws.Root), "/FO", "CSV", "/NH")
+	output, err := cmd.Output()
+	if err != nil {
+		// schtasks /Query fails for tasks that are not registered
+		if _, ok := err.(*exec.ExitError); ok {
+			return false, nil
+		}
+		return false, fmt.Errorf("schtasks /Query failed: %w", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	if err != nil {
+		return false, fmt.Errorf("parse schtasks output: %w", err)
+	}
+	// Columns are TaskName, Next Run Time, Status
+	for _, record := range records {
+		if len(record) >= 3 && record[2] == "Running" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func schtasks(args ...string) error {
+	cmd := exec.Command("schtasks", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("schtasks %s failed: %w\nOutput: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
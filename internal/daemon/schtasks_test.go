@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"okrchestra/internal/workspace"
+)
+
+func TestGenerateTask(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "R&D ws")
+	ws := &workspace.Workspace{Root: root, LogDir: filepath.Join(root, "logs")}
+	binary := filepath.Join(t.TempDir(), "okrchestra")
+	task, err := GenerateTask(ws, binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	escapedRoot := strings.ReplaceAll(root, "&", "&amp;")
+	for _, want := range []string{
+		"<Command>cmd.exe</Command>",
+		`<Arguments>/s /c &#34;&#34;` + binary + `&#34; daemon run --workspace &#34;` + escapedRoot + `&#34;`,
+		"<WorkingDirectory>" + escapedRoot + "</WorkingDirectory>",
+		"<LogonTrigger>",
+	} {
+		if !strings.Contains(task, want) {
+			t.Errorf("task missing %q:\n%s", want, task)
+		}
+	}
+}
+
+func TestEncodeUTF16(t *testing.T) {
+	got := encodeUTF16("a→")
+	if len(got) != 6 || got[0] != 0xFF || got[1] != 0xFE {
+		t.Fatalf("encodeUTF16 = %x", got)
+	}
+	if r := utf16.Decode([]uint16{uint16(got[4]) | uint16(got[5])<<8}); string(r) != "→" {
+		t.Fatalf("decoded %q", string(r))
+	}
+}
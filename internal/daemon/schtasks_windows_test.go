@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+
+	"okrchestra/internal/workspace"
+)
+
+func TestTaskPathWindows(t *testing.T) {
+	t.Setenv("LOCALAPPDATA", `C:\Users\me\AppData\Local`)
+	path, err := TaskPath(`C:\Users\me\My Workspace`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `C:\Users\me\AppData\Local\okrchestra\tasks\` + TaskName(`C:\Users\me\My Workspace`) + ".xml"
+	if path != want {
+		t.Fatalf("TaskPath = %s, want %s", path, want)
+	}
+	if service, err := ServicePath(`C:\Users\me\My Workspace`); err != nil || service != want {
+		t.Fatalf("ServicePath = %s, %v", service, err)
+	}
+}
+
+func TestGenerateTaskWindowsPaths(t *testing.T) {
+	ws := &workspace.Workspace{Root: `D:\okrs\team ws`, LogDir: `D:\okrs\team ws\audit\logs`}
+	task, err := GenerateTask(ws, `C:\Program Files\okrchestra\okrchestra.exe`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `/s /c &#34;&#34;C:\Program Files\okrchestra\okrchestra.exe&#34; daemon run --workspace &#34;D:\okrs\team ws&#34; &gt;&gt; &#34;D:\okrs\team ws\audit\logs\okrchestra.log&#34; 2&gt;&amp;1&#34;`
+	if !strings.Contains(task, want) {
+		t.Fatalf("task arguments not found:\n%s", task)
+	}
+}
@@ -1,14 +1,18 @@
 package daemon
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"okrchestra/internal/sqlitex"
 )
 
 // Store manages daemon state in SQLite.
@@ -17,18 +21,63 @@ type Store struct {
 	db     *sql.DB
 }
 
+// ErrJobNotFound is returned for job IDs the store does not know.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobNotQueued is returned when cancelling a job that already started.
+var ErrJobNotQueued = errors.New("job is not queued")
+
+// ErrJobNotRunning is returned when killing a job that is neither queued
+// nor running.
+var ErrJobNotRunning = errors.New("job is not queued or running")
+
+// ErrJobNotRetryable is returned when requeueing a job that has not failed
+// or been canceled.
+var ErrJobNotRetryable = errors.New("job is not failed or canceled")
+
 // Job represents a queued or running daemon job.
 type Job struct {
-	ID             string
-	Type           string
-	Status         string
-	ScheduledAt    time.Time
-	StartedAt      *time.Time
-	FinishedAt     *time.Time
-	PayloadJSON    string
-	ResultJSON     string
-	LeaseOwner     string
-	LeaseExpiresAt *time.Time
+	ID             string     `json:"id"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	ScheduledAt    time.Time  `json:"scheduled_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	PayloadJSON    string     `json:"payload_json,omitempty"`
+	ResultJSON     string     `json:"result_json,omitempty"`
+	LeaseOwner     string     `json:"lease_owner,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+	// Attempts counts claims of the job, including the current one.
+	Attempts    int `json:"attempts"`
+	MaxAttempts int `json:"max_attempts"`
+	// NextRetryAt is when a failed job being retried becomes due again.
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	// Priority is added to the job type's priority from daemon.yml; due
+	// jobs with a higher sum are claimed first.
+	Priority int `json:"priority"`
+	// CancelRequested is set by daemon kill; the daemon cancels the
+	// handler's context and marks the job canceled.
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+}
+
+// JobProgress records how far a running job has advanced.
+type JobProgress struct {
+	JobID         string     `json:"job_id"`
+	RunID         string     `json:"run_id,omitempty"`
+	ItemIndex     int        `json:"item_index"`
+	ItemID        string     `json:"item_id,omitempty"`
+	ItemsDone     int        `json:"items_done"`
+	ItemsTotal    int        `json:"items_total"`
+	ItemStartedAt *time.Time `json:"item_started_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Percent returns the share of completed items, 0-100.
+func (p *JobProgress) Percent() float64 {
+	if p == nil || p.ItemsTotal <= 0 {
+		return 0
+	}
+	return float64(p.ItemsDone) / float64(p.ItemsTotal) * 100
 }
 
 // Run represents a daemon run record.
@@ -50,7 +99,10 @@ func Open(path string) (*Store, error) {
 		return nil, fmt.Errorf("ensure daemon db dir: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", absPath)
+	// The run loop, the API server, and the file watcher share the store;
+	// WAL and the busy timeout let them wait out each other's writes
+	// instead of failing with SQLITE_BUSY.
+	db, err := sqlitex.Open(absPath)
 	if err != nil {
 		return nil, fmt.Errorf("open daemon db: %w", err)
 	}
@@ -76,8 +128,11 @@ func (s *Store) Close() error {
 	return nil
 }
 
-func (s *Store) ensureSchema() error {
-	schema := `
+// storeMigrations is the daemon schema history. Version 1 matches the
+// tables older binaries created without recording a version, so it and the
+// column backfill in version 2 are written to be idempotent.
+var storeMigrations = []sqlitex.Migration{
+	{Version: 1, Name: "create daemon tables", SQL: `
 CREATE TABLE IF NOT EXISTS daemon_runs (
 	id TEXT PRIMARY KEY,
 	started_at TEXT NOT NULL,
@@ -102,14 +157,44 @@ CREATE TABLE IF NOT EXISTS daemon_jobs (
 CREATE INDEX IF NOT EXISTS idx_jobs_status_scheduled ON daemon_jobs(status, scheduled_at);
 CREATE INDEX IF NOT EXISTS idx_jobs_type_scheduled ON daemon_jobs(type, scheduled_at);
 
+CREATE TABLE IF NOT EXISTS daemon_job_progress (
+	job_id TEXT PRIMARY KEY,
+	run_id TEXT,
+	item_index INTEGER NOT NULL,
+	item_id TEXT,
+	items_done INTEGER NOT NULL,
+	items_total INTEGER NOT NULL,
+	item_started_at TEXT,
+	updated_at TEXT NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS daemon_kv (
 	key TEXT PRIMARY KEY,
 	value TEXT
 );
-`
-	_, err := s.db.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("create daemon schema: %w", err)
+`},
+	{Version: 2, Name: "add job retry columns", Apply: func(ctx context.Context, q sqlitex.Querier) error {
+		return sqlitex.AddMissingColumns(ctx, q, "daemon_jobs", []sqlitex.Column{
+			{Name: "attempts", Def: "INTEGER NOT NULL DEFAULT 0"},
+			{Name: "max_attempts", Def: "INTEGER NOT NULL DEFAULT 1"},
+			{Name: "next_retry_at", Def: "TEXT"},
+		})
+	}},
+	{Version: 3, Name: "add job priority", SQL: `
+ALTER TABLE daemon_jobs ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+`},
+	{Version: 4, Name: "add job cancel_requested", SQL: `
+ALTER TABLE daemon_jobs ADD COLUMN cancel_requested INTEGER NOT NULL DEFAULT 0;
+`},
+	{Version: 5, Name: "drop progress of stopped jobs", SQL: `
+DELETE FROM daemon_job_progress
+WHERE job_id NOT IN (SELECT id FROM daemon_jobs WHERE status = 'running');
+`},
+}
+
+func (s *Store) ensureSchema() error {
+	if err := sqlitex.Migrate(s.db, storeMigrations); err != nil {
+		return fmt.Errorf("migrate daemon schema: %w", err)
 	}
 	return nil
 }
@@ -117,6 +202,12 @@ CREATE TABLE IF NOT EXISTS daemon_kv (
 // EnqueueUnique enqueues a job if no job with the same type and scheduled_at exists.
 // Returns (jobID, created, error). created is true if a new job was inserted.
 func (s *Store) EnqueueUnique(jobType string, scheduledAt time.Time, payload any) (string, bool, error) {
+	return s.EnqueueUniqueWithPriority(jobType, scheduledAt, payload, 0)
+}
+
+// EnqueueUniqueWithPriority is EnqueueUnique for a job with its own
+// priority. An existing job keeps its priority.
+func (s *Store) EnqueueUniqueWithPriority(jobType string, scheduledAt time.Time, payload any, priority int) (string, bool, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return "", false, fmt.Errorf("marshal payload: %w", err)
@@ -142,9 +233,9 @@ func (s *Store) EnqueueUnique(jobType string, scheduledAt time.Time, payload any
 
 	// Insert new job
 	_, err = s.db.Exec(`
-		INSERT INTO daemon_jobs (id, type, status, scheduled_at, payload_json)
-		VALUES (?, ?, ?, ?, ?)
-	`, jobID, jobType, "queued", scheduledAtStr, string(payloadJSON))
+		INSERT INTO daemon_jobs (id, type, status, scheduled_at, payload_json, priority)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, jobID, jobType, "queued", scheduledAtStr, string(payloadJSON), priority)
 
 	if err != nil {
 		return "", false, fmt.Errorf("insert job: %w", err)
@@ -155,6 +246,21 @@ func (s *Store) EnqueueUnique(jobType string, scheduledAt time.Time, payload any
 
 // ClaimNext atomically claims the next queued job that is ready to run.
 func (s *Store) ClaimNext(now time.Time, leaseOwner string, leaseFor time.Duration) (*Job, error) {
+	return s.ClaimNextWith(now, leaseOwner, leaseFor, ClaimOptions{})
+}
+
+// ClaimOptions narrows and orders the jobs ClaimNextWith considers.
+type ClaimOptions struct {
+	// TypePriorities are added to each job's own priority.
+	TypePriorities map[string]int
+	// ExcludeTypes are job types that must not be claimed, such as types
+	// at their concurrency limit.
+	ExcludeTypes []string
+}
+
+// ClaimNextWith claims the due job with the highest priority, oldest first
+// among equals.
+func (s *Store) ClaimNextWith(now time.Time, leaseOwner string, leaseFor time.Duration, opts ClaimOptions) (*Job, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
@@ -164,14 +270,37 @@ func (s *Store) ClaimNext(now time.Time, leaseOwner string, leaseFor time.Durati
 	nowStr := now.UTC().Format(time.RFC3339)
 	leaseExpiresAt := now.Add(leaseFor).UTC().Format(time.RFC3339)
 
+	args := []any{nowStr}
+	var exclude string
+	if len(opts.ExcludeTypes) > 0 {
+		exclude = " AND type NOT IN (?" + strings.Repeat(", ?", len(opts.ExcludeTypes)-1) + ")"
+		for _, jobType := range opts.ExcludeTypes {
+			args = append(args, jobType)
+		}
+	}
+	priority := "priority"
+	if len(opts.TypePriorities) > 0 {
+		jobTypes := make([]string, 0, len(opts.TypePriorities))
+		for jobType := range opts.TypePriorities {
+			jobTypes = append(jobTypes, jobType)
+		}
+		sort.Strings(jobTypes)
+		priority += " + CASE type"
+		for _, jobType := range jobTypes {
+			priority += " WHEN ? THEN ?"
+			args = append(args, jobType, opts.TypePriorities[jobType])
+		}
+		priority += " ELSE 0 END"
+	}
+
 	// Find next queued job that is ready to run
 	var jobID string
 	err = tx.QueryRow(`
 		SELECT id FROM daemon_jobs
-		WHERE status = 'queued' AND scheduled_at <= ?
-		ORDER BY scheduled_at ASC
+		WHERE status = 'queued' AND COALESCE(next_retry_at, scheduled_at) <= ?`+exclude+`
+		ORDER BY `+priority+` DESC, scheduled_at ASC
 		LIMIT 1
-	`, nowStr).Scan(&jobID)
+	`, args...).Scan(&jobID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // No jobs available
@@ -187,7 +316,9 @@ func (s *Store) ClaimNext(now time.Time, leaseOwner string, leaseFor time.Durati
 		SET status = 'running',
 		    started_at = ?,
 		    lease_owner = ?,
-		    lease_expires_at = ?
+		    lease_expires_at = ?,
+		    attempts = attempts + 1,
+		    cancel_requested = 0
 		WHERE id = ?
 	`, startedAt, leaseOwner, leaseExpiresAt, jobID)
 
@@ -205,54 +336,18 @@ func (s *Store) ClaimNext(now time.Time, leaseOwner string, leaseFor time.Durati
 
 // GetJob retrieves a job by ID.
 func (s *Store) GetJob(jobID string) (*Job, error) {
-	var job Job
-	var scheduledAt, startedAt, finishedAt, leaseExpiresAt sql.NullString
-	var payloadJSON, resultJSON, leaseOwner sql.NullString
-
-	err := s.db.QueryRow(`
-		SELECT id, type, status, scheduled_at, started_at, finished_at,
-		       payload_json, result_json, lease_owner, lease_expires_at
+	job, err := scanJob(s.db.QueryRow(`
+		SELECT `+jobColumns+`
 		FROM daemon_jobs
 		WHERE id = ?
-	`, jobID).Scan(
-		&job.ID, &job.Type, &job.Status, &scheduledAt,
-		&startedAt, &finishedAt, &payloadJSON, &resultJSON,
-		&leaseOwner, &leaseExpiresAt,
-	)
-
+	`, jobID))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("job not found: %s", jobID)
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get job: %w", err)
 	}
-
-	if scheduledAt.Valid {
-		job.ScheduledAt, _ = time.Parse(time.RFC3339, scheduledAt.String)
-	}
-	if startedAt.Valid {
-		t, _ := time.Parse(time.RFC3339, startedAt.String)
-		job.StartedAt = &t
-	}
-	if finishedAt.Valid {
-		t, _ := time.Parse(time.RFC3339, finishedAt.String)
-		job.FinishedAt = &t
-	}
-	if leaseExpiresAt.Valid {
-		t, _ := time.Parse(time.RFC3339, leaseExpiresAt.String)
-		job.LeaseExpiresAt = &t
-	}
-	if payloadJSON.Valid {
-		job.PayloadJSON = payloadJSON.String
-	}
-	if resultJSON.Valid {
-		job.ResultJSON = resultJSON.String
-	}
-	if leaseOwner.Valid {
-		job.LeaseOwner = leaseOwner.String
-	}
-
-	return &job, nil
+	return job, nil
 }
 
 // Succeed marks a job as succeeded.
@@ -267,14 +362,15 @@ func (s *Store) Succeed(jobID string, result any) error {
 		UPDATE daemon_jobs
 		SET status = 'succeeded',
 		    finished_at = ?,
-		    result_json = ?
+		    result_json = ?,
+		    next_retry_at = NULL
 		WHERE id = ?
 	`, finishedAt, string(resultJSON), jobID)
 
 	if err != nil {
 		return fmt.Errorf("update job: %w", err)
 	}
-	return nil
+	return s.clearProgress(jobID)
 }
 
 // Fail marks a job as failed.
@@ -289,21 +385,143 @@ func (s *Store) Fail(jobID string, jobErr error) error {
 		UPDATE daemon_jobs
 		SET status = 'failed',
 		    finished_at = ?,
-		    result_json = ?
+		    result_json = ?,
+		    next_retry_at = NULL
 		WHERE id = ?
 	`, finishedAt, string(resultJSON), jobID)
 
 	if err != nil {
 		return fmt.Errorf("update job: %w", err)
 	}
-	return nil
+	return s.clearProgress(jobID)
+}
+
+// FailOrRetry records a failed attempt under policy. While attempts remain
+// the job goes back to the queue, due after the policy's backoff, and the
+// returned time is when it will run again; otherwise it is marked failed and
+// the returned time is nil.
+func (s *Store) FailOrRetry(jobID string, jobErr error, policy RetryPolicy) (*time.Time, error) {
+	var attempts int
+	if err := s.db.QueryRow("SELECT attempts FROM daemon_jobs WHERE id = ?", jobID).Scan(&attempts); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found: %s", jobID)
+		}
+		return nil, fmt.Errorf("get job attempts: %w", err)
+	}
+	if attempts >= policy.MaxAttempts {
+		if _, err := s.db.Exec("UPDATE daemon_jobs SET max_attempts = ? WHERE id = ?", policy.MaxAttempts, jobID); err != nil {
+			return nil, fmt.Errorf("update job: %w", err)
+		}
+		return nil, s.Fail(jobID, jobErr)
+	}
+
+	resultJSON, _ := json.Marshal(map[string]string{"error": jobErr.Error()})
+	retryAt := time.Now().Add(policy.Delay(attempts)).UTC().Truncate(time.Second)
+	_, err := s.db.Exec(`
+		UPDATE daemon_jobs
+		SET status = 'queued',
+		    result_json = ?,
+		    max_attempts = ?,
+		    next_retry_at = ?,
+		    lease_owner = NULL,
+		    lease_expires_at = NULL
+		WHERE id = ?
+	`, string(resultJSON), policy.MaxAttempts, retryAt.Format(time.RFC3339), jobID)
+	if err != nil {
+		return nil, fmt.Errorf("requeue job: %w", err)
+	}
+	if err := s.clearProgress(jobID); err != nil {
+		return nil, err
+	}
+	return &retryAt, nil
+}
+
+// Cancel marks a queued job as canceled so it is never claimed. Jobs that
+// have started cannot be canceled.
+func (s *Store) Cancel(jobID string) error {
+	finishedAt := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.Exec(`
+		UPDATE daemon_jobs
+		SET status = 'canceled',
+		    finished_at = ?,
+		    next_retry_at = NULL
+		WHERE id = ? AND status = 'queued'
+	`, finishedAt, jobID)
+	if err != nil {
+		return fmt.Errorf("cancel job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	job, err := s.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s is %s", ErrJobNotQueued, jobID, job.Status)
+}
+
+// RequestCancel asks the daemon running a job to stop it. A queued job is
+// canceled outright.
+func (s *Store) RequestCancel(jobID string) error {
+	res, err := s.db.Exec(`
+		UPDATE daemon_jobs
+		SET cancel_requested = 1
+		WHERE id = ? AND status = 'running'
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("request job cancel: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	job, err := s.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == "queued" {
+		return s.Cancel(jobID)
+	}
+	return fmt.Errorf("%w: %s is %s", ErrJobNotRunning, jobID, job.Status)
+}
+
+// CancelRequested reports whether RequestCancel was called for a running
+// job.
+func (s *Store) CancelRequested(jobID string) (bool, error) {
+	var requested bool
+	err := s.db.QueryRow("SELECT cancel_requested FROM daemon_jobs WHERE id = ?", jobID).Scan(&requested)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("get job cancel_requested: %w", err)
+	}
+	return requested, nil
+}
+
+// MarkCanceled records that a running job was stopped before finishing.
+func (s *Store) MarkCanceled(jobID string, reason error) error {
+	resultJSON, _ := json.Marshal(map[string]string{"error": reason.Error()})
+	finishedAt := time.Now().UTC().Format(time.RFC3339)
+	_, err := s.db.Exec(`
+		UPDATE daemon_jobs
+		SET status = 'canceled',
+		    finished_at = ?,
+		    result_json = ?,
+		    next_retry_at = NULL,
+		    lease_owner = NULL,
+		    lease_expires_at = NULL
+		WHERE id = ?
+	`, finishedAt, string(resultJSON), jobID)
+	if err != nil {
+		return fmt.Errorf("update job: %w", err)
+	}
+	return s.clearProgress(jobID)
 }
 
 // ListJobs returns up to limit jobs ordered by scheduled_at.
 func (s *Store) ListJobs(limit int) ([]Job, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, status, scheduled_at, started_at, finished_at,
-		       payload_json, result_json, lease_owner, lease_expires_at
+		SELECT `+jobColumns+`
 		FROM daemon_jobs
 		ORDER BY scheduled_at DESC
 		LIMIT ?
@@ -316,11 +534,58 @@ func (s *Store) ListJobs(limit int) ([]Job, error) {
 	return s.scanJobs(rows)
 }
 
+// Requeue puts a failed or canceled job back in the queue to run as soon
+// as a daemon polls. Attempts restart at zero so the job gets its full retry
+// policy again.
+func (s *Store) Requeue(jobID string) error {
+	res, err := s.db.Exec(`
+		UPDATE daemon_jobs
+		SET status = 'queued',
+		    started_at = NULL,
+		    finished_at = NULL,
+		    result_json = NULL,
+		    lease_owner = NULL,
+		    lease_expires_at = NULL,
+		    attempts = 0,
+		    next_retry_at = NULL,
+		    cancel_requested = 0
+		WHERE id = ? AND status IN ('failed', 'canceled')
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("requeue job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	job, err := s.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s is %s", ErrJobNotRetryable, jobID, job.Status)
+}
+
+// ListJobsByStatus returns up to limit jobs with the given status, most
+// recently scheduled first.
+func (s *Store) ListJobsByStatus(status string, limit int) ([]Job, error) {
+	rows, err := s.db.Query(`
+		SELECT `+jobColumns+`
+		FROM daemon_jobs
+		WHERE status = ?
+		ORDER BY scheduled_at DESC
+		LIMIT ?
+	`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query %s jobs: %w", status, err)
+	}
+	defer rows.Close()
+
+	return s.scanJobs(rows)
+}
+
 // ListRunning returns all jobs with status 'running'.
 func (s *Store) ListRunning() ([]Job, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, status, scheduled_at, started_at, finished_at,
-		       payload_json, result_json, lease_owner, lease_expires_at
+		SELECT ` + jobColumns + `
 		FROM daemon_jobs
 		WHERE status = 'running'
 		ORDER BY scheduled_at ASC
@@ -336,8 +601,7 @@ func (s *Store) ListRunning() ([]Job, error) {
 // ListQueued returns all jobs with status 'queued' ordered by scheduled_at.
 func (s *Store) ListQueued(limit int) ([]Job, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, status, scheduled_at, started_at, finished_at,
-		       payload_json, result_json, lease_owner, lease_expires_at
+		SELECT `+jobColumns+`
 		FROM daemon_jobs
 		WHERE status = 'queued'
 		ORDER BY scheduled_at ASC
@@ -351,13 +615,13 @@ func (s *Store) ListQueued(limit int) ([]Job, error) {
 	return s.scanJobs(rows)
 }
 
-// ListRecentCompleted returns recently completed jobs (succeeded or failed).
+// ListRecentCompleted returns recently completed jobs (succeeded, failed,
+// or canceled).
 func (s *Store) ListRecentCompleted(limit int) ([]Job, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, status, scheduled_at, started_at, finished_at,
-		       payload_json, result_json, lease_owner, lease_expires_at
+		SELECT `+jobColumns+`
 		FROM daemon_jobs
-		WHERE status IN ('succeeded', 'failed')
+		WHERE status IN ('succeeded', 'failed', 'canceled')
 		ORDER BY finished_at DESC
 		LIMIT ?
 	`, limit)
@@ -369,48 +633,73 @@ func (s *Store) ListRecentCompleted(limit int) ([]Job, error) {
 	return s.scanJobs(rows)
 }
 
+// jobColumns are the daemon_jobs columns scanJob reads, in order.
+const jobColumns = `id, type, status, scheduled_at, started_at, finished_at,
+		       payload_json, result_json, lease_owner, lease_expires_at,
+		       attempts, max_attempts, next_retry_at, priority, cancel_requested`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob reads a job selected with jobColumns.
+func scanJob(row rowScanner) (*Job, error) {
+	var job Job
+	var scheduledAt, startedAt, finishedAt, leaseExpiresAt, nextRetryAt sql.NullString
+	var payloadJSON, resultJSON, leaseOwner sql.NullString
+
+	err := row.Scan(
+		&job.ID, &job.Type, &job.Status, &scheduledAt,
+		&startedAt, &finishedAt, &payloadJSON, &resultJSON,
+		&leaseOwner, &leaseExpiresAt,
+		&job.Attempts, &job.MaxAttempts, &nextRetryAt, &job.Priority,
+		&job.CancelRequested,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduledAt.Valid {
+		job.ScheduledAt, _ = time.Parse(time.RFC3339, scheduledAt.String)
+	}
+	if startedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, startedAt.String)
+		job.StartedAt = &t
+	}
+	if finishedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, finishedAt.String)
+		job.FinishedAt = &t
+	}
+	if leaseExpiresAt.Valid {
+		t, _ := time.Parse(time.RFC3339, leaseExpiresAt.String)
+		job.LeaseExpiresAt = &t
+	}
+	if payloadJSON.Valid {
+		job.PayloadJSON = payloadJSON.String
+	}
+	if resultJSON.Valid {
+		job.ResultJSON = resultJSON.String
+	}
+	if leaseOwner.Valid {
+		job.LeaseOwner = leaseOwner.String
+	}
+	if nextRetryAt.Valid {
+		t, _ := time.Parse(time.RFC3339, nextRetryAt.String)
+		job.NextRetryAt = &t
+	}
+
+	return &job, nil
+}
+
 func (s *Store) scanJobs(rows *sql.Rows) ([]Job, error) {
 	var jobs []Job
 	for rows.Next() {
-		var job Job
-		var scheduledAt, startedAt, finishedAt, leaseExpiresAt sql.NullString
-		var payloadJSON, resultJSON, leaseOwner sql.NullString
-
-		err := rows.Scan(
-			&job.ID, &job.Type, &job.Status, &scheduledAt,
-			&startedAt, &finishedAt, &payloadJSON, &resultJSON,
-			&leaseOwner, &leaseExpiresAt,
-		)
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, fmt.Errorf("scan job: %w", err)
 		}
-
-		if scheduledAt.Valid {
-			job.ScheduledAt, _ = time.Parse(time.RFC3339, scheduledAt.String)
-		}
-		if startedAt.Valid {
-			t, _ := time.Parse(time.RFC3339, startedAt.String)
-			job.StartedAt = &t
-		}
-		if finishedAt.Valid {
-			t, _ := time.Parse(time.RFC3339, finishedAt.String)
-			job.FinishedAt = &t
-		}
-		if leaseExpiresAt.Valid {
-			t, _ := time.Parse(time.RFC3339, leaseExpiresAt.String)
-			job.LeaseExpiresAt = &t
-		}
-		if payloadJSON.Valid {
-			job.PayloadJSON = payloadJSON.String
-		}
-		if resultJSON.Valid {
-			job.ResultJSON = resultJSON.String
-		}
-		if leaseOwner.Valid {
-			job.LeaseOwner = leaseOwner.String
-		}
-
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -444,3 +733,59 @@ func (s *Store) SetKV(key, value string) error {
 	}
 	return nil
 }
+
+// SetProgress records progress for a job, replacing any previous value.
+func (s *Store) SetProgress(p JobProgress) error {
+	var itemStartedAt sql.NullString
+	if p.ItemStartedAt != nil {
+		itemStartedAt = sql.NullString{String: p.ItemStartedAt.UTC().Format(time.RFC3339), Valid: true}
+	}
+	updatedAt := p.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO daemon_job_progress
+			(job_id, run_id, item_index, item_id, items_done, items_total, item_started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.JobID, p.RunID, p.ItemIndex, p.ItemID, p.ItemsDone, p.ItemsTotal, itemStartedAt, updatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("set progress: %w", err)
+	}
+	return nil
+}
+
+// clearProgress drops a job's progress once it stops running, so only
+// running jobs keep a daemon_job_progress row.
+func (s *Store) clearProgress(jobID string) error {
+	if _, err := s.db.Exec("DELETE FROM daemon_job_progress WHERE job_id = ?", jobID); err != nil {
+		return fmt.Errorf("clear progress: %w", err)
+	}
+	return nil
+}
+
+// GetProgress returns the recorded progress for a job, or nil if none was published.
+func (s *Store) GetProgress(jobID string) (*JobProgress, error) {
+	var p JobProgress
+	var runID, itemID, itemStartedAt sql.NullString
+	var updatedAt string
+	err := s.db.QueryRow(`
+		SELECT job_id, run_id, item_index, item_id, items_done, items_total, item_started_at, updated_at
+		FROM daemon_job_progress
+		WHERE job_id = ?
+	`, jobID).Scan(&p.JobID, &runID, &p.ItemIndex, &itemID, &p.ItemsDone, &p.ItemsTotal, &itemStartedAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get progress: %w", err)
+	}
+	p.RunID = runID.String
+	p.ItemID = itemID.String
+	if itemStartedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, itemStartedAt.String)
+		p.ItemStartedAt = &t
+	}
+	p.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &p, nil
+}
@@ -0,0 +1,301 @@
+package daemon
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJobProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	// No progress published yet
+	progress, err := store.GetProgress("plan_execute_x")
+	if err != nil {
+		t.Fatalf("get progress: %v", err)
+	}
+	if progress != nil {
+		t.Fatalf("expected nil progress, got %+v", progress)
+	}
+
+	started := time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)
+	if err := store.SetProgress(JobProgress{
+		JobID:         "plan_execute_x",
+		RunID:         "20240101T091500Z",
+		ItemIndex:     1,
+		ItemID:        "item-1",
+		ItemsDone:     0,
+		ItemsTotal:    4,
+		ItemStartedAt: &started,
+	}); err != nil {
+		t.Fatalf("set progress: %v", err)
+	}
+	if err := store.SetProgress(JobProgress{
+		JobID:         "plan_execute_x",
+		RunID:         "20240101T091500Z",
+		ItemIndex:     2,
+		ItemID:        "item-2",
+		ItemsDone:     1,
+		ItemsTotal:    4,
+		ItemStartedAt: &started,
+	}); err != nil {
+		t.Fatalf("update progress: %v", err)
+	}
+
+	progress, err = store.GetProgress("plan_execute_x")
+	if err != nil {
+		t.Fatalf("get progress: %v", err)
+	}
+	if progress == nil {
+		t.Fatal("expected progress")
+	}
+	if progress.ItemIndex != 2 || progress.ItemID != "item-2" || progress.ItemsDone != 1 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+	if progress.Percent() != 25 {
+		t.Errorf("expected 25%%, got %v", progress.Percent())
+	}
+	if progress.ItemStartedAt == nil || !progress.ItemStartedAt.Equal(started) {
+		t.Errorf("expected item start %s, got %v", started, progress.ItemStartedAt)
+	}
+}
+
+func TestJobProgressClearedWhenJobStops(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	stops := map[string]func(jobID string) error{
+		"succeeded": func(jobID string) error { return store.Succeed(jobID, nil) },
+		"failed":    func(jobID string) error { return store.Fail(jobID, errors.New("boom")) },
+		"canceled":  func(jobID string) error { return store.MarkCanceled(jobID, errors.New("stopped")) },
+		"retried": func(jobID string) error {
+			_, err := store.FailOrRetry(jobID, errors.New("boom"), RetryPolicy{MaxAttempts: 3, Backoff: time.Minute})
+			return err
+		},
+	}
+	scheduled := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for name, stop := range stops {
+		scheduled = scheduled.Add(time.Hour)
+		jobID, _, err := store.EnqueueUnique("plan_execute", scheduled, nil)
+		if err != nil {
+			t.Fatalf("%s: enqueue: %v", name, err)
+		}
+		if err := store.SetProgress(JobProgress{JobID: jobID, RunID: "run-1", ItemIndex: 1, ItemsTotal: 2}); err != nil {
+			t.Fatalf("%s: set progress: %v", name, err)
+		}
+		if err := stop(jobID); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if progress, err := store.GetProgress(jobID); err != nil || progress != nil {
+			t.Fatalf("%s: progress = %+v (err %v), want it cleared", name, progress, err)
+		}
+	}
+}
+
+func TestQueueExportImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	src, err := Open(filepath.Join(tmpDir, "src.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer src.Close()
+
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, _, err := src.EnqueueUnique("kr_measure", base, map[string]any{"as_of": "2024-01-01"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := src.EnqueueUnique("plan_generate", base.Add(time.Hour), map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	doneID, _, err := src.EnqueueUnique("outcome_check", base.Add(-time.Hour), map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// outcome_check runs and succeeds; kr_measure is left running.
+	if _, err := src.ClaimNext(base, "worker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Succeed(doneID, map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	running, err := src.ClaimNext(base, "worker", time.Minute)
+	if err != nil || running == nil || running.Type != "kr_measure" {
+		t.Fatalf("claim kr_measure: %v %+v", err, running)
+	}
+
+	export, err := src.ExportQueue()
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(export.Jobs) != 2 || !export.Jobs[0].WasRunning || string(export.Jobs[0].Payload) != `{"as_of":"2024-01-01"}` {
+		t.Fatalf("unexpected export %+v", export.Jobs)
+	}
+
+	dst, err := Open(filepath.Join(tmpDir, "dst.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer dst.Close()
+	result, err := dst.ImportQueue(export)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(result.Imported) != 2 || len(result.Skipped) != 0 {
+		t.Fatalf("unexpected import result %+v", result)
+	}
+	job, err := dst.GetJob(running.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "queued" || job.LeaseOwner != "" || job.LeaseExpiresAt != nil || job.StartedAt != nil {
+		t.Errorf("imported running job not reset: %+v", job)
+	}
+
+	result, err = dst.ImportQueue(export)
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if len(result.Imported) != 0 || len(result.Skipped) != 2 {
+		t.Fatalf("re-import should skip existing jobs, got %+v", result)
+	}
+}
+
+func TestOpenAddsRetryColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`
+CREATE TABLE daemon_jobs (
+	id TEXT PRIMARY KEY, type TEXT NOT NULL, status TEXT NOT NULL, scheduled_at TEXT NOT NULL,
+	started_at TEXT, finished_at TEXT, payload_json TEXT, result_json TEXT, lease_owner TEXT, lease_expires_at TEXT
+);
+INSERT INTO daemon_jobs (id, type, status, scheduled_at, payload_json) VALUES ('old', 'kr_measure', 'queued', '2024-01-01T00:00:00Z', '{}');
+`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open old store: %v", err)
+	}
+	defer store.Close()
+	job, err := store.GetJob("old")
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.Attempts != 0 || job.MaxAttempts != 1 || job.NextRetryAt != nil {
+		t.Fatalf("migrated job = %+v", job)
+	}
+}
+
+func TestCancelAndRequeue(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	jobID, _, err := store.EnqueueUnique("kr_measure", now.Add(-time.Minute), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if err := store.Requeue(jobID); !errors.Is(err, ErrJobNotRetryable) {
+		t.Fatalf("requeue queued job: err = %v, want ErrJobNotRetryable", err)
+	}
+	if err := store.Cancel(jobID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := store.Cancel(jobID); !errors.Is(err, ErrJobNotQueued) {
+		t.Fatalf("cancel twice: err = %v, want ErrJobNotQueued", err)
+	}
+	if job, _ := store.ClaimNext(now, "test", time.Minute); job != nil {
+		t.Fatalf("canceled job was claimed: %+v", job)
+	}
+
+	if err := store.Requeue(jobID); err != nil {
+		t.Fatalf("requeue canceled job: %v", err)
+	}
+	job, err := store.ClaimNext(now, "test", time.Minute)
+	if err != nil || job == nil || job.ID != jobID {
+		t.Fatalf("claim requeued job = %+v, %v", job, err)
+	}
+	if err := store.Fail(jobID, fmt.Errorf("boom")); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+
+	if err := store.Requeue(jobID); err != nil {
+		t.Fatalf("requeue failed job: %v", err)
+	}
+	job, err = store.GetJob(jobID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.Status != "queued" || job.Attempts != 0 || job.ResultJSON != "" || job.FinishedAt != nil {
+		t.Fatalf("requeued job not reset: %+v", job)
+	}
+
+	if err := store.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("cancel missing: err = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestRequestCancel(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	queuedID, _, _ := store.EnqueueUnique("kr_measure", now.Add(time.Hour), map[string]any{})
+	if err := store.RequestCancel(queuedID); err != nil {
+		t.Fatalf("kill queued job: %v", err)
+	}
+	if job, _ := store.GetJob(queuedID); job.Status != "canceled" || job.CancelRequested {
+		t.Fatalf("killed queued job: %+v", job)
+	}
+
+	runningID, _, _ := store.EnqueueUnique("kr_measure", now.Add(-time.Minute), map[string]any{})
+	if _, err := store.ClaimNext(now, "test", time.Minute); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if requested, err := store.CancelRequested(runningID); err != nil || requested {
+		t.Fatalf("cancel requested before kill = %v, %v", requested, err)
+	}
+	if err := store.RequestCancel(runningID); err != nil {
+		t.Fatalf("kill running job: %v", err)
+	}
+	if requested, err := store.CancelRequested(runningID); err != nil || !requested {
+		t.Fatalf("cancel requested after kill = %v, %v", requested, err)
+	}
+	if err := store.MarkCanceled(runningID, fmt.Errorf("job killed")); err != nil {
+		t.Fatalf("mark canceled: %v", err)
+	}
+
+	// Requeueing clears the request so the next run is not killed at once.
+	if err := store.Requeue(runningID); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if job, _ := store.GetJob(runningID); job.Status != "queued" || job.CancelRequested {
+		t.Fatalf("requeued killed job: %+v", job)
+	}
+	if err := store.RequestCancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("kill missing: err = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"okrchestra/internal/workspace"
+)
+
+// UnitName returns the systemd user unit name for a workspace.
+func UnitName(wsRoot string) string {
+	return fmt.Sprintf("okrchestra-%s.service", WorkspaceHash(wsRoot))
+}
+
+// UnitPath returns the full path to the systemd user unit for a workspace,
+// under $XDG_CONFIG_HOME (default ~/.config).
+func UnitPath(wsRoot string) (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "systemd", "user", UnitName(wsRoot)), nil
+}
+
+// GenerateUnit creates a systemd user unit for the okrchestra daemon.
+func GenerateUnit(ws *workspace.Workspace, binaryPath string) (string, error) {
+	if ws == nil {
+		return "", fmt.Errorf("workspace is nil")
+	}
+
+	// Ensure binary path is absolute
+	absBinaryPath, err := filepath.Abs(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("resolve binary path: %w", err)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	goBinPath := filepath.Join(homeDir, "go", "bin")
+
+	logPath := GetLogPath(ws)
+
+	unit := fmt.Sprintf(`[Unit]
+Description=OKRchestra daemon for %s
+
+[Service]
+ExecStart=%s daemon run --workspace %s
+Environment=%s
+StandardOutput=append:%s
+StandardError=append:%s
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`, systemdEscape(ws.Root), systemdArg(absBinaryPath), systemdArg(ws.Root),
+		systemdArg("PATH="+goBinPath+":/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin"),
+		systemdEscape(logPath), systemdEscape(logPath))
+
+	return unit, nil
+}
+
+// systemdEscape escapes % so systemd does not read it as a specifier.
+func systemdEscape(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// systemdArg escapes and, when it contains whitespace or quotes, quotes a
+// command line argument.
+func systemdArg(value string) string {
+	value = systemdEscape(value)
+	if strings.ContainsAny(value, " \t\"'\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// installSystemd writes the systemd user unit for the workspace.
+func installSystemd(ws *workspace.Workspace, binaryPath string) error {
+	unitContent, err := GenerateUnit(ws, binaryPath)
+	if err != nil {
+		return fmt.Errorf("generate unit: %w", err)
+	}
+
+	unitPath, err := UnitPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve unit path: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0o755); err != nil {
+		return fmt.Errorf("ensure systemd user dir: %w", err)
+	}
+
+	if err := os.WriteFile(unitPath, []byte(unitContent), 0o644); err != nil {
+		return fmt.Errorf("write unit: %w", err)
+	}
+
+	return systemctl("daemon-reload")
+}
+
+// uninstallSystemd removes the systemd user unit for the workspace.
+func uninstallSystemd(ws *workspace.Workspace) error {
+	unitPath, err := UnitPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve unit path: %w", err)
+	}
+
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return fmt.Errorf("unit not found: %s", unitPath)
+	}
+
+	if err := os.Remove(unitPath); err != nil {
+		return fmt.Errorf("remove unit: %w", err)
+	}
+
+	// The unit is gone either way; a failed reload only leaves systemd's
+	// cache stale until the next one.
+	_ = systemctl("daemon-reload")
+	return nil
+}
+
+// startSystemd enables and starts the unit, so it also starts at login.
+func startSystemd(ws *workspace.Workspace) error {
+	unitPath, err := UnitPath(ws.Root)
+	if err != nil {
+		return fmt.Errorf("resolve unit path: %w", err)
+	}
+
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return fmt.Errorf("unit not found: %s (run 'okrchestra daemon install' first)", unitPath)
+	}
+
+	return systemctl("enable", "--now", UnitName(ws.Root))
+}
+
+// stopSystemd stops and disables the unit.
+func stopSystemd(ws *workspace.Workspace) error {
+	return systemctl("disable", "--now", UnitName(ws.Root))
+}
+
+// isRunningSystemd reports whether the unit is active.
+func isRunningSystemd(ws *workspace.Workspace) (bool, error) {
+	cmd := exec.Command("systemctl", "--user", "is-active", UnitName(ws.Root))
+	output, err := cmd.Output()
+	state := strings.TrimSpace(string(output))
+	if err != nil {
+		// is-active exits non-zero for inactive units
+		if _, ok := err.(*exec.ExitError); ok && state != "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("systemctl is-active failed: %w", err)
+	}
+	return state == "active", nil
+}
+
+func systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s failed: %w\nOutput: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/workspace"
+)
+
+func TestGenerateUnit(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "my ws")
+	ws := &workspace.Workspace{Root: root, LogDir: filepath.Join(root, "logs")}
+	unit, err := GenerateUnit(ws, "/usr/local/bin/okrchestra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/okrchestra daemon run --workspace "` + root + `"`,
+		"StandardOutput=append:" + filepath.Join(root, "logs", "okrchestra.log"),
+		"Restart=always",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestUnitPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	path, err := UnitPath("/work/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join("/tmp/config", "systemd", "user", "okrchestra-"+WorkspaceHash("/work/ws")+".service")
+	if path != want {
+		t.Fatalf("UnitPath = %s, want %s", path, want)
+	}
+}
@@ -2,15 +2,13 @@ package daemon
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"time"
 
+	"okrchestra/internal/okrstore"
 	"okrchestra/internal/workspace"
 )
 
@@ -32,40 +30,108 @@ func handleWatchTick(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 		return nil, fmt.Errorf("daemon store not available in context")
 	}
 
-	changes := []string{}
 	now := time.Now()
+	var found watchChanges
 
 	// Watch 1: okrs directory (human applied proposals)
-	okrsChanges, err := watchDirectory(store, ws.OKRsDir, "watch_okrs_dir")
+	okrsChanged, err := watchDirectory(store, ws.OKRsDir, "watch_okrs_dir")
 	if err != nil {
 		return nil, fmt.Errorf("watch okrs dir: %w", err)
 	}
-	if len(okrsChanges) > 0 {
-		changes = append(changes, fmt.Sprintf("okrs: %d files changed", len(okrsChanges)))
-		// Enqueue kr_measure and plan_generate
+	for _, path := range okrsChanged {
+		if rel, ok := okrFileRel(ws.OKRsDir, path); ok {
+			found.OKRs = append(found.OKRs, rel)
+		}
+	}
+
+	// Watch 2: metrics/manual.yml
+	manualMetricsPath := filepath.Join(ws.MetricsDir, "manual.yml")
+	found.Manual, err = watchFile(store, manualMetricsPath, "watch_manual_yml")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("watch manual.yml: %w", err)
+	}
+
+	// Watch 2b: metrics/openmetrics/*.prom exports
+	openMetricsDir := filepath.Join(ws.MetricsDir, "openmetrics")
+	found.OpenMetrics, err = watchDirectory(store, openMetricsDir, "watch_openmetrics_dir")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("watch openmetrics dir: %w", err)
+	}
+
+	// Watch 3: new plans generated
+	plansDir := filepath.Join(ws.ArtifactsDir, "plans")
+	found.Plans, err = watchDirectory(store, plansDir, "watch_plans_dir")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("watch plans dir: %w", err)
+	}
+
+	changes, err := enqueueWatchJobs(store, now, found)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"checked_at":     now.Format(time.RFC3339),
+		"changes_count":  len(changes),
+		"changes_detail": changes,
+	}
+
+	if len(changes) > 0 {
+		result["status"] = "changes_detected"
+	} else {
+		result["status"] = "no_changes"
+	}
+
+	return result, nil
+}
+
+// watchChanges are the changes found by one poll, or one batch of file
+// system events, that trigger follow-up jobs.
+type watchChanges struct {
+	// OKRs are changed OKR files, relative to the okrs directory
+	// (e.g. "teams/platform/alice.yaml").
+	OKRs        []string
+	Manual      bool
+	OpenMetrics []string
+	Plans       []string
+}
+
+// okrFileRel reports whether path is a file the OKR loader reads, and its
+// okrs-relative slash path.
+func okrFileRel(okrsDir, path string) (string, bool) {
+	if !okrstore.IsOKRFile(okrsDir, path) {
+		return "", false
+	}
+	if rel, err := filepath.Rel(okrsDir, path); err == nil {
+		path = filepath.ToSlash(rel)
+	}
+	return path, true
+}
+
+// enqueueWatchJobs enqueues the follow-up jobs for found and describes the
+// changes: kr_measure and plan_generate for OKR edits, kr_measure for metric
+// inputs, and plan_execute for each new plan.json.
+func enqueueWatchJobs(store *Store, now time.Time, found watchChanges) ([]string, error) {
+	changes := []string{}
+
+	if len(found.OKRs) > 0 {
+		changes = append(changes, fmt.Sprintf("okrs: %d files changed", len(found.OKRs)))
 		if _, _, err := store.EnqueueUnique("kr_measure", now, map[string]any{
 			"trigger": "okrs_changed",
-			"files":   okrsChanges,
+			"files":   found.OKRs,
 		}); err != nil {
 			return nil, fmt.Errorf("enqueue kr_measure: %w", err)
 		}
 		if _, _, err := store.EnqueueUnique("plan_generate", now, map[string]any{
 			"trigger": "okrs_changed",
-			"files":   okrsChanges,
+			"files":   found.OKRs,
 		}); err != nil {
 			return nil, fmt.Errorf("enqueue plan_generate: %w", err)
 		}
 	}
 
-	// Watch 2: metrics/manual.yml
-	manualMetricsPath := filepath.Join(ws.MetricsDir, "manual.yml")
-	manualChanged, err := watchFile(store, manualMetricsPath, "watch_manual_yml")
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("watch manual.yml: %w", err)
-	}
-	if manualChanged {
+	if found.Manual {
 		changes = append(changes, "manual.yml changed")
-		// Enqueue kr_measure
 		if _, _, err := store.EnqueueUnique("kr_measure", now, map[string]any{
 			"trigger": "manual_yml_changed",
 		}); err != nil {
@@ -73,16 +139,20 @@ func handleWatchTick(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 		}
 	}
 
-	// Watch 3: new plans generated
-	plansDir := filepath.Join(ws.ArtifactsDir, "plans")
-	plansChanges, err := watchDirectory(store, plansDir, "watch_plans_dir")
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("watch plans dir: %w", err)
+	if len(found.OpenMetrics) > 0 {
+		changes = append(changes, fmt.Sprintf("openmetrics: %d files changed", len(found.OpenMetrics)))
+		if _, _, err := store.EnqueueUnique("kr_measure", now, map[string]any{
+			"trigger": "openmetrics_changed",
+			"files":   found.OpenMetrics,
+		}); err != nil {
+			return nil, fmt.Errorf("enqueue kr_measure: %w", err)
+		}
 	}
-	if len(plansChanges) > 0 {
-		changes = append(changes, fmt.Sprintf("plans: %d files changed", len(plansChanges)))
+
+	if len(found.Plans) > 0 {
+		changes = append(changes, fmt.Sprintf("plans: %d files changed", len(found.Plans)))
 		// Enqueue plan_execute for newly generated plans
-		for _, planFile := range plansChanges {
+		for _, planFile := range found.Plans {
 			if filepath.Base(planFile) == "plan.json" {
 				if _, _, err := store.EnqueueUnique("plan_execute", now, map[string]any{
 					"trigger":   "new_plan_generated",
@@ -94,19 +164,17 @@ func handleWatchTick(ctx context.Context, ws *workspace.Workspace, job *Job) (an
 		}
 	}
 
-	result := map[string]any{
-		"checked_at":     now.Format(time.RFC3339),
-		"changes_count":  len(changes),
-		"changes_detail": changes,
-	}
+	return changes, nil
+}
 
-	if len(changes) > 0 {
-		result["status"] = "changes_detected"
-	} else {
-		result["status"] = "no_changes"
+// isWatchedFile reports whether a file in a watched directory can trigger
+// jobs.
+func isWatchedFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml", ".json", ".prom":
+		return true
 	}
-
-	return result, nil
+	return false
 }
 
 // watchFile checks if a single file has changed since last check.
@@ -188,8 +256,7 @@ func watchDirectory(store *Store, dirPath, kvKeyPrefix string) ([]string, error)
 		}
 
 		// Only watch certain file types
-		ext := filepath.Ext(path)
-		if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+		if !isWatchedFile(path) {
 			return nil
 		}
 
@@ -244,7 +311,7 @@ func watchDirectory(store *Store, dirPath, kvKeyPrefix string) ([]string, error)
 	// Check for deleted files
 	for path := range prevFiles {
 		if _, exists := currentFiles[path]; !exists {
-			changedFiles = append(changedFiles, path + " (deleted)")
+			changedFiles = append(changedFiles, path+" (deleted)")
 		}
 	}
 
@@ -261,19 +328,9 @@ func watchDirectory(store *Store, dirPath, kvKeyPrefix string) ([]string, error)
 }
 
 // hashFile computes SHA256 hash of a file's contents.
+// It shares okrstore's hashing so watch changes and store cache invalidation agree.
 func hashFile(path string) (string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return okrstore.HashFile(path)
 }
 
 // scheduleWatchTicks schedules watch_tick jobs every 30 seconds.
@@ -281,10 +338,10 @@ func hashFile(path string) (string, error) {
 func (s *Scheduler) scheduleWatchTicks(lastWatermark, now time.Time) error {
 	// Schedule a watch_tick for every 30-second interval between lastWatermark and now
 	interval := 30 * time.Second
-	
+
 	// Start from the next 30-second boundary after lastWatermark
 	start := lastWatermark.Truncate(interval).Add(interval)
-	
+
 	for current := start; !current.After(now); current = current.Add(interval) {
 		payload := map[string]any{
 			"scheduled_time": current.Format(time.RFC3339),
@@ -294,6 +351,6 @@ func (s *Scheduler) scheduleWatchTicks(lastWatermark, now time.Time) error {
 			return fmt.Errorf("enqueue watch_tick at %s: %w", current, err)
 		}
 	}
-	
+
 	return nil
 }
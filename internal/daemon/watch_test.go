@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"okrchestra/internal/okrstore"
 	"okrchestra/internal/workspace"
 )
 
@@ -281,3 +282,60 @@ func TestScheduleWatchTicks(t *testing.T) {
 		t.Errorf("expected %d watch_tick jobs, got %d", expectedCount, actualCount)
 	}
 }
+
+func TestWatchChangesInvalidateOKRStoreCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	okrsDir := filepath.Join(tmpDir, "okrs")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatalf("create okrs dir: %v", err)
+	}
+	orgPath := filepath.Join(okrsDir, "org.yml")
+	if err := os.WriteFile(orgPath, []byte("scope: org\n"), 0o644); err != nil {
+		t.Fatalf("write org.yml: %v", err)
+	}
+
+	if _, err := watchDirectory(store, okrsDir, "okrs_watch"); err != nil {
+		t.Fatalf("baseline watch: %v", err)
+	}
+	hashBefore, err := okrstore.DirHash(okrsDir)
+	if err != nil {
+		t.Fatalf("dir hash: %v", err)
+	}
+
+	steps := []struct {
+		name  string
+		apply func() error
+	}{
+		{"modify", func() error { return os.WriteFile(orgPath, []byte("scope: org\nobjectives: []\n"), 0o644) }},
+		{"add", func() error {
+			return os.WriteFile(filepath.Join(okrsDir, "team.yml"), []byte("scope: team\n"), 0o644)
+		}},
+		{"delete", func() error { return os.Remove(filepath.Join(okrsDir, "team.yml")) }},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		changes, err := watchDirectory(store, okrsDir, "okrs_watch")
+		if err != nil {
+			t.Fatalf("%s watch: %v", step.name, err)
+		}
+		hashAfter, err := okrstore.DirHash(okrsDir)
+		if err != nil {
+			t.Fatalf("%s dir hash: %v", step.name, err)
+		}
+		if len(changes) == 0 {
+			t.Fatalf("%s: expected watcher to report a change", step.name)
+		}
+		if hashAfter == hashBefore {
+			t.Fatalf("%s: watcher saw a change but the store cache key did not move", step.name)
+		}
+		hashBefore = hashAfter
+	}
+}
@@ -0,0 +1,309 @@
+// Package dashboard is the read-only web dashboard the daemon serves with
+// --dashboard: objective and KR scorecards with trend charts from the
+// metric history, plan run timelines, and recent audit events. Its pages
+// are rendered from templates embedded in the binary.
+package dashboard
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/report"
+	"okrchestra/internal/workspace"
+)
+
+// Page sizes.
+const (
+	// DefaultEventLimit caps /events when no limit is given.
+	DefaultEventLimit = 100
+	// RunLimit caps /runs.
+	RunLimit = 100
+	// ChartDays is how much metric history a KR's trend chart covers.
+	ChartDays = 90
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var pages = parsePages("overview.html", "runs.html", "run.html", "events.html")
+
+// parsePages parses each page together with layout.html, which renders the
+// page's "content" template.
+func parsePages(names ...string) map[string]*template.Template {
+	base := template.Must(template.ParseFS(templateFS, "templates/layout.html"))
+	out := map[string]*template.Template{}
+	for _, name := range names {
+		out[name] = template.Must(template.Must(base.Clone()).ParseFS(templateFS, "templates/"+name))
+	}
+	return out
+}
+
+// Handler returns the dashboard for a workspace:
+//
+//	GET /            objective and KR scorecards, latest runs and events
+//	GET /runs        recent plan runs
+//	GET /runs/{id}   one run's item timeline
+//	GET /events      recent audit events (?type=T&limit=N)
+//
+// Every page reads the workspace afresh, so it reflects the daemon's latest
+// jobs without a restart. Nothing is written.
+func Handler(ws *workspace.Workspace) http.Handler {
+	s := &server{ws: ws}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleOverview)
+	mux.HandleFunc("GET /runs", s.handleRuns)
+	mux.HandleFunc("GET /runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return mux
+}
+
+type server struct {
+	ws *workspace.Workspace
+}
+
+// page is what layout.html renders: the nav, a title, and the page's data.
+type page struct {
+	Title     string
+	Nav       string
+	Workspace string
+	Generated string
+	Data      any
+}
+
+func (s *server) render(w http.ResponseWriter, name, nav, title string, data any) {
+	loc := s.locale()
+	var buf bytes.Buffer
+	err := pages[name].ExecuteTemplate(&buf, "layout", page{
+		Title:     title,
+		Nav:       nav,
+		Workspace: s.ws.Root,
+		Generated: loc.FormatDateTime(time.Now()),
+		Data:      data,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("render %s: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *server) locale() locale.Locale {
+	loc, err := locale.Load(s.ws.Root)
+	if err != nil {
+		return locale.Canonical
+	}
+	return loc
+}
+
+func (s *server) handleOverview(w http.ResponseWriter, r *http.Request) {
+	loc := s.locale()
+	scorecards, err := s.loadScorecards(loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	runs, err := s.loadRuns(loc, 5)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	events, err := s.loadEvents(loc, "", 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scorecards.Runs = runs
+	scorecards.Events = events
+	s.render(w, "overview.html", "overview", "OKRs", scorecards)
+}
+
+func (s *server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.loadRuns(s.locale(), RunLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "runs.html", "runs", "Plan runs", runs)
+}
+
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("id")
+	// Run IDs are single path elements; anything else is not a run.
+	if runID == "" || runID != filepath.Base(runID) || runID == "." || runID == ".." {
+		http.NotFound(w, r)
+		return
+	}
+	state, err := planner.LoadRunState(filepath.Join(s.ws.ArtifactsDir, "runs", runID))
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "run.html", "runs", "Run "+runID, newTimeline(state, s.locale(), time.Now()))
+}
+
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultEventLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	eventType := r.URL.Query().Get("type")
+	events, err := s.loadEvents(s.locale(), eventType, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "events.html", "events", "Audit events", eventsView{Type: eventType, Events: events})
+}
+
+// loadScorecards rolls up the objectives against the latest kr score
+// report, with a trend chart for each KR that has metric history.
+func (s *server) loadScorecards(loc locale.Locale) (*overviewView, error) {
+	store, err := okrstore.LoadFromDirCached(s.ws.OKRsDir, filepath.Join(s.ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return nil, err
+	}
+	view := &overviewView{}
+	var score *metrics.KRScoreReport
+	path, err := metrics.LatestScoreReportPath(s.ws.ArtifactsDir)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if score, err = metrics.LoadScoreReport(path); err != nil {
+			return nil, err
+		}
+		view.AsOf = loc.DateString(score.AsOf)
+	}
+
+	history, err := s.openHistory()
+	if err != nil {
+		return nil, err
+	}
+	if history != nil {
+		defer history.Close()
+	}
+	since := time.Now().UTC().AddDate(0, 0, -ChartDays).Format("2006-01-02")
+	for _, obj := range metrics.RollupObjectives(store, score, "") {
+		card := objectiveCard{
+			Scope:    obj.Scope,
+			ID:       obj.ObjectiveID,
+			Title:    obj.Objective,
+			Percent:  percent(loc, obj.PercentToTarget),
+			Statuses: statusCounts(obj.StatusCounts),
+		}
+		for _, kr := range obj.KeyResults {
+			krc := krCard{
+				ID:          kr.KRID,
+				Description: kr.Description,
+				Status:      kr.Status,
+				Projected:   kr.ProjectedStatus,
+				MetricKey:   kr.MetricKey,
+				Percent:     percent(loc, kr.PercentToTarget),
+				Current:     "-",
+				Target:      loc.Number(kr.Target),
+			}
+			if kr.PercentToTarget != nil {
+				krc.Bar = clampPercent(*kr.PercentToTarget)
+			}
+			if kr.Current != nil {
+				krc.Current = loc.Number(*kr.Current)
+			}
+			if history != nil && kr.MetricKey != "" {
+				points, err := history.Query(kr.MetricKey, since)
+				if err != nil {
+					return nil, err
+				}
+				krc.Chart = newChart(points, kr.Target, loc)
+			}
+			card.KRs = append(card.KRs, krc)
+		}
+		view.Objectives = append(view.Objectives, card)
+	}
+	return view, nil
+}
+
+// openHistory opens the metric history if kr measure has created one; the
+// dashboard never creates it.
+func (s *server) openHistory() (*metrics.History, error) {
+	snapshotsDir := filepath.Join(s.ws.MetricsDir, "snapshots")
+	if _, err := os.Stat(metrics.HistoryPath(snapshotsDir)); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return metrics.OpenHistory(snapshotsDir)
+}
+
+// loadRuns returns up to limit runs with a run.json, newest first.
+func (s *server) loadRuns(loc locale.Locale, limit int) ([]runRow, error) {
+	runsDir := filepath.Join(s.ws.ArtifactsDir, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read runs dir: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	// Run IDs are UTC timestamps, so the newest sort last.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	var rows []runRow
+	for _, name := range names {
+		if len(rows) == limit {
+			break
+		}
+		state, err := planner.LoadRunState(filepath.Join(runsDir, name))
+		if err != nil {
+			// Runs from before run.json, and dry runs, have nothing to show.
+			continue
+		}
+		rows = append(rows, newRunRow(state, loc))
+	}
+	return rows, nil
+}
+
+// loadEvents returns up to limit of the latest audit events, newest first.
+func (s *server) loadEvents(loc locale.Locale, eventType string, limit int) ([]eventRow, error) {
+	events, err := audit.ReadEvents(s.ws.AuditDBPath, audit.Query{Type: eventType, Limit: limit, Latest: true})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]eventRow, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		rows = append(rows, eventRow{
+			TS:      loc.FormatDateTime(ev.TS.Local()),
+			Actor:   ev.Actor,
+			Type:    ev.Type,
+			Summary: report.SummarizePayload(ev.PayloadJSON),
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,166 @@
+package dashboard
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/workspace"
+)
+
+const testOKRs = `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Ship reliably
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: Raise pass rate
+        owner_id: team-alpha
+        metric_key: ci.pass_rate
+        baseline: 50
+        target: 100
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+
+const testRun = `{
+  "schema_version": 1,
+  "run_id": "20250601T100000Z",
+  "plan_id": "plan-1",
+  "adapter": "mock",
+  "started_at": "2025-06-01T10:00:00Z",
+  "updated_at": "2025-06-01T10:30:00Z",
+  "items": [
+    {"item_id": "raise-pass-rate", "item_dir": "item-0001", "status": "succeeded", "attempts": 1,
+     "started_at": "2025-06-01T10:00:00Z", "finished_at": "2025-06-01T10:10:00Z"},
+    {"item_id": "fix-flaky-test", "item_dir": "item-0002", "status": "failed", "attempts": 2,
+     "started_at": "2025-06-01T10:10:00Z", "finished_at": "2025-06-01T10:30:00Z", "error": "exit status 1"},
+    {"item_id": "write-docs", "item_dir": "item-0003", "status": "pending"}
+  ]
+}`
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testWorkspace(t *testing.T) *workspace.Workspace {
+	t.Helper()
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatalf("ensure dirs: %v", err)
+	}
+	writeFile(t, filepath.Join(ws.OKRsDir, "org.yml"), testOKRs)
+	today := time.Now().UTC()
+	asOf := today.Format("2006-01-02")
+	writeFile(t, filepath.Join(ws.ArtifactsDir, "kr_score_"+asOf+".json"),
+		`{"schema_version": 1, "as_of": "`+asOf+`", "results": [{"objective_id": "OBJ-1", "kr_id": "KR-1", "metric_key": "ci.pass_rate", "current": 80, "target": 100, "percent_to_target": 60}]}`)
+
+	history, err := metrics.OpenHistory(filepath.Join(ws.MetricsDir, "snapshots"))
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	defer history.Close()
+	for i, value := range []float64{60, 70, 80} {
+		snapshot := &metrics.Snapshot{
+			AsOf:   today.AddDate(0, 0, i-2).Format("2006-01-02"),
+			Points: []metrics.MetricPoint{{Key: "ci.pass_rate", Value: value, Source: "ci"}},
+		}
+		if _, err := history.Record(snapshot); err != nil {
+			t.Fatalf("record history: %v", err)
+		}
+	}
+
+	writeFile(t, filepath.Join(ws.ArtifactsDir, "runs", "20250601T100000Z", "run.json"), testRun)
+	logger := audit.NewLogger(ws.AuditDBPath)
+	if err := logger.LogEvent("daemon", "kr_status_auto_updated", map[string]any{"kr_id": "KR-1", "new_status": "in_progress"}); err != nil {
+		t.Fatalf("log event: %v", err)
+	}
+	if err := logger.LogEvent("cli", "plan_run_started", map[string]any{"run_id": "20250601T100000Z"}); err != nil {
+		t.Fatalf("log event: %v", err)
+	}
+	return ws
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestDashboardPages(t *testing.T) {
+	ws := testWorkspace(t)
+	h := Handler(ws)
+
+	code, body := get(t, h, "/")
+	if code != http.StatusOK {
+		t.Fatalf("GET / = %d: %s", code, body)
+	}
+	for _, want := range []string{"OBJ-1: Ship reliably", "KR-1", "60%", "<polyline points=", `href="/runs/20250601T100000Z"`, "kr_status_auto_updated"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET / lacks %q", want)
+		}
+	}
+
+	code, body = get(t, h, "/runs/20250601T100000Z")
+	if code != http.StatusOK {
+		t.Fatalf("GET /runs/{id} = %d: %s", code, body)
+	}
+	// The failed item spans the last two thirds of the run.
+	for _, want := range []string{"fix-flaky-test", "exit status 1", "left: 33.3", "1 item(s) not started"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /runs/{id} lacks %q", want)
+		}
+	}
+
+	code, body = get(t, h, "/events?type=plan_run_started")
+	if code != http.StatusOK || !strings.Contains(body, "plan_run_started") || strings.Contains(body, "kr_status_auto_updated") {
+		t.Fatalf("GET /events?type= = %d: %s", code, body)
+	}
+
+	if code, _ := get(t, h, "/runs/20990101T000000Z"); code != http.StatusNotFound {
+		t.Errorf("unknown run = %d, want 404", code)
+	}
+	if code, _ := get(t, h, "/events?limit=0"); code != http.StatusBadRequest {
+		t.Errorf("bad limit = %d, want 400", code)
+	}
+}
+
+func TestDashboardEmptyWorkspace(t *testing.T) {
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(ws.OKRsDir, "org.yml"), testOKRs)
+	code, body := get(t, Handler(ws), "/")
+	if code != http.StatusOK || !strings.Contains(body, "No KRs have been scored yet") || !strings.Contains(body, "No plan runs yet") {
+		t.Fatalf("GET / = %d: %s", code, body)
+	}
+	// The dashboard reads; it must not create the history or audit DBs.
+	if _, err := os.Stat(metrics.HistoryPath(filepath.Join(ws.MetricsDir, "snapshots"))); !os.IsNotExist(err) {
+		t.Fatalf("history created: %v", err)
+	}
+	if _, err := os.Stat(ws.AuditDBPath); !os.IsNotExist(err) {
+		t.Fatalf("audit DB created: %v", err)
+	}
+}
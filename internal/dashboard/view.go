@@ -0,0 +1,264 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/planner"
+)
+
+// The page data below holds display-ready strings; the templates only lay
+// them out.
+
+type overviewView struct {
+	AsOf       string
+	Objectives []objectiveCard
+	Runs       []runRow
+	Events     []eventRow
+}
+
+type objectiveCard struct {
+	Scope, ID, Title, Percent, Statuses string
+	KRs                                 []krCard
+}
+
+type krCard struct {
+	ID, Description, Status, Projected, MetricKey string
+	Percent, Current, Target                      string
+	// Bar is the percent-to-target clamped to 0-100, for the progress bar.
+	Bar   float64
+	Chart *chart
+}
+
+type runRow struct {
+	ID, Plan, Adapter, Started, Duration, Items string
+}
+
+type eventsView struct {
+	Type   string
+	Events []eventRow
+}
+
+type eventRow struct {
+	TS, Actor, Type, Summary string
+}
+
+// Chart dimensions, in SVG user units.
+const (
+	chartWidth  = 240
+	chartHeight = 48
+	chartPad    = 3
+)
+
+// chart is a KR's metric history as an SVG polyline, with its target as a
+// horizontal line.
+type chart struct {
+	Width, Height int
+	Points        string
+	// TargetY is the target line's height; the drawn range always
+	// includes the target.
+	TargetY          float64
+	From, To, Latest string
+	Samples          int
+}
+
+// newChart draws the undimensioned points of a metric; fewer than two days
+// of history draw nothing.
+func newChart(points []metrics.HistoryPoint, target float64, loc locale.Locale) *chart {
+	var dates []time.Time
+	var values []float64
+	for _, p := range points {
+		if p.Dimensions != "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", p.AsOf)
+		if err != nil {
+			continue
+		}
+		dates = append(dates, day)
+		values = append(values, p.Value)
+	}
+	if len(values) < 2 {
+		return nil
+	}
+	lo, hi := target, target
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	span := dates[len(dates)-1].Sub(dates[0]).Hours()
+	if span == 0 {
+		span = 1
+	}
+	x := func(t time.Time) float64 {
+		return chartPad + (chartWidth-2*chartPad)*t.Sub(dates[0]).Hours()/span
+	}
+	y := func(v float64) float64 {
+		return chartPad + (chartHeight-2*chartPad)*(hi-v)/(hi-lo)
+	}
+	coords := make([]string, len(values))
+	for i := range values {
+		coords[i] = fmt.Sprintf("%.1f,%.1f", x(dates[i]), y(values[i]))
+	}
+	return &chart{
+		Width:   chartWidth,
+		Height:  chartHeight,
+		Points:  strings.Join(coords, " "),
+		TargetY: y(target),
+		From:    loc.FormatDate(dates[0]),
+		To:      loc.FormatDate(dates[len(dates)-1]),
+		Latest:  loc.Number(values[len(values)-1]),
+		Samples: len(values),
+	}
+}
+
+func newRunRow(state *planner.RunState, loc locale.Locale) runRow {
+	row := runRow{
+		ID:       state.RunID,
+		Plan:     orDash(state.PlanID),
+		Adapter:  orDash(state.Adapter),
+		Started:  dateTime(loc, state.StartedAt),
+		Duration: "-",
+	}
+	counts := map[string]int{}
+	for _, item := range state.Items {
+		counts[item.Status]++
+	}
+	row.Items = statusCounts(counts)
+	start, errStart := time.Parse(time.RFC3339, state.StartedAt)
+	end, errEnd := time.Parse(time.RFC3339, state.UpdatedAt)
+	if errStart == nil && errEnd == nil && !end.Before(start) {
+		row.Duration = end.Sub(start).Round(time.Second).String()
+	}
+	return row
+}
+
+// timeline is a run's items as bars on a shared time axis.
+type timeline struct {
+	Run     runRow
+	Start   string
+	End     string
+	Items   []timelineItem
+	Pending int
+}
+
+type timelineItem struct {
+	ID, Dir, Status, Started, Duration, Detail string
+	Attempts                                   int
+	// Left and Width place the bar, as percentages of the axis.
+	Left, Width float64
+	Drawn       bool
+}
+
+// newTimeline lays out the items that have started between the run's first
+// start and its last finish; running items extend to now.
+func newTimeline(state *planner.RunState, loc locale.Locale, now time.Time) timeline {
+	tl := timeline{Run: newRunRow(state, loc)}
+	type span struct{ start, end time.Time }
+	spans := make([]*span, len(state.Items))
+	var first, last time.Time
+	for i, item := range state.Items {
+		start, err := time.Parse(time.RFC3339, item.StartedAt)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, item.FinishedAt)
+		if err != nil || end.Before(start) {
+			end = start
+			if item.Status == planner.ItemRunning {
+				end = now
+			}
+		}
+		spans[i] = &span{start, end}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	total := last.Sub(first)
+	if !first.IsZero() {
+		tl.Start = loc.FormatDateTime(first.Local())
+		tl.End = loc.FormatDateTime(last.Local())
+	}
+	for i, item := range state.Items {
+		ti := timelineItem{
+			ID:       item.ItemID,
+			Dir:      item.ItemDir,
+			Status:   item.Status,
+			Started:  dateTime(loc, item.StartedAt),
+			Duration: "-",
+			Attempts: item.Attempts,
+			Detail:   strings.TrimSpace(string(item.FailureClass) + " " + item.Error),
+		}
+		if item.Verification != nil {
+			ti.Detail = strings.TrimSpace("verification " + item.Verification.Status + " " + ti.Detail)
+		}
+		if sp := spans[i]; sp != nil {
+			ti.Duration = sp.end.Sub(sp.start).Round(time.Second).String()
+			ti.Drawn = true
+			ti.Left, ti.Width = 0, 100
+			if total > 0 {
+				ti.Left = 100 * float64(sp.start.Sub(first)) / float64(total)
+				ti.Width = 100 * float64(sp.end.Sub(sp.start)) / float64(total)
+			}
+			// Keep instant items visible.
+			ti.Width = max(ti.Width, 0.5)
+		} else {
+			tl.Pending++
+		}
+		tl.Items = append(tl.Items, ti)
+	}
+	return tl
+}
+
+func percent(loc locale.Locale, pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return loc.Fixed(*pct, 0) + "%"
+}
+
+func clampPercent(pct float64) float64 {
+	return min(max(pct, 0), 100)
+}
+
+// statusCounts renders counts as "2 achieved, 1 at_risk".
+func statusCounts(counts map[string]int) string {
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		label := status
+		if label == "" {
+			label = "no status"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[status], label))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dateTime(loc locale.Locale, ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return orDash(ts)
+	}
+	return loc.FormatDateTime(t.Local())
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
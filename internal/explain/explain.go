@@ -0,0 +1,401 @@
+// Package explain assembles everything recorded about a daemon job, plan
+// run, or plan item into one chronological narrative for diagnosing
+// failures.
+package explain
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/guardrails"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+const (
+	KindJob  = "job"
+	KindRun  = "run"
+	KindItem = "item"
+)
+
+// DefaultTailLines is how much of each transcript a report includes.
+const DefaultTailLines = 20
+
+// Options configures Explain.
+type Options struct {
+	Workspace *workspace.Workspace
+	// Store is the daemon job store; when nil only runs and items resolve.
+	Store     *daemon.Store
+	TailLines int
+}
+
+// Entry is one line of the timeline.
+type Entry struct {
+	At      time.Time `json:"at"`
+	Source  string    `json:"source"`
+	Summary string    `json:"summary"`
+}
+
+// ItemReport collects the artifacts of one plan item.
+type ItemReport struct {
+	Dir        string               `json:"dir"`
+	PlanItemID string               `json:"plan_item_id,omitempty"`
+	KRID       string               `json:"kr_id,omitempty"`
+	MetricKey  string               `json:"metric_key,omitempty"`
+	Failure    *planner.ItemFailure `json:"failure,omitempty"`
+	Violation  map[string]any       `json:"violation,omitempty"`
+	// Secrets lists secrets found in the item's outputs and changes.
+	Secrets        []guardrails.SecretFinding `json:"secrets,omitempty"`
+	ResultErrors   []string                   `json:"result_errors,omitempty"`
+	TranscriptTail []string                   `json:"transcript_tail,omitempty"`
+	Annotations    []metrics.Annotation       `json:"annotations,omitempty"`
+}
+
+// Report is the assembled narrative for one ID.
+type Report struct {
+	Kind     string       `json:"kind"`
+	ID       string       `json:"id"`
+	Job      *daemon.Job  `json:"job,omitempty"`
+	JobError string       `json:"job_error,omitempty"`
+	RunID    string       `json:"run_id,omitempty"`
+	RunDir   string       `json:"run_dir,omitempty"`
+	Items    []ItemReport `json:"items,omitempty"`
+	Timeline []Entry      `json:"timeline"`
+}
+
+// Failed reports whether the job or any item failed.
+func (r *Report) Failed() bool {
+	if r.Job != nil && r.Job.Status == "failed" {
+		return true
+	}
+	for _, item := range r.Items {
+		if item.Failure != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain resolves id as a daemon job ID, a run ID, or a plan item ID (the
+// most recent run containing it), in that order.
+func Explain(opts Options, id string) (*Report, error) {
+	if opts.Workspace == nil {
+		return nil, fmt.Errorf("workspace is required")
+	}
+	if opts.TailLines <= 0 {
+		opts.TailLines = DefaultTailLines
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("job, run, or item ID is required")
+	}
+	runsDir := filepath.Join(opts.Workspace.ArtifactsDir, "runs")
+
+	if opts.Store != nil {
+		if job, err := opts.Store.GetJob(id); err == nil {
+			return explainJob(opts, job)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(runsDir, id)); err == nil && info.IsDir() {
+		report := &Report{Kind: KindRun, ID: id}
+		if err := addRun(opts, report, id, ""); err != nil {
+			return nil, err
+		}
+		return finish(report), nil
+	}
+	runID, itemDir, err := findItem(runsDir, id)
+	if err != nil {
+		return nil, err
+	}
+	if runID == "" {
+		return nil, fmt.Errorf("no job, run, or item found for %q", id)
+	}
+	report := &Report{Kind: KindItem, ID: id}
+	if err := addRun(opts, report, runID, itemDir); err != nil {
+		return nil, err
+	}
+	return finish(report), nil
+}
+
+func explainJob(opts Options, job *daemon.Job) (*Report, error) {
+	report := &Report{Kind: KindJob, ID: job.ID, Job: job}
+	report.add(job.ScheduledAt, "daemon", fmt.Sprintf("%s job scheduled", job.Type))
+
+	var result map[string]any
+	if job.ResultJSON != "" {
+		_ = json.Unmarshal([]byte(job.ResultJSON), &result)
+	}
+	if msg, ok := result["error"].(string); ok {
+		report.JobError = msg
+	}
+
+	events, err := audit.ReadEvents(opts.Workspace.AuditDBPath, audit.Query{Contains: job.ID})
+	if err != nil {
+		return nil, err
+	}
+	report.addEvents(events)
+
+	// Runs started by the job are found by their run_id in the result or,
+	// for failed jobs, in audit events logged while the job was running.
+	runID, _ := result["run_id"].(string)
+	if runID == "" && job.StartedAt != nil {
+		until := time.Now()
+		if job.FinishedAt != nil {
+			// Timestamps are stored at second precision.
+			until = job.FinishedAt.Add(time.Second)
+		}
+		window, err := audit.ReadEvents(opts.Workspace.AuditDBPath, audit.Query{Since: *job.StartedAt, Until: until, Contains: `"run_id"`})
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range window {
+			if id := payloadString(ev.PayloadJSON, "run_id"); id != "" {
+				runID = id
+				break
+			}
+		}
+	}
+	if runID != "" {
+		if err := addRun(opts, report, runID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return finish(report), nil
+}
+
+// addRun adds a run's audit events and item artifacts. When onlyItem is
+// set, only that item dir is included.
+func addRun(opts Options, report *Report, runID, onlyItem string) error {
+	ws := opts.Workspace
+	runDir := filepath.Join(ws.ArtifactsDir, "runs", runID)
+	report.RunID = runID
+	report.RunDir = ws.RelPath(runDir)
+
+	events, err := audit.ReadEvents(ws.AuditDBPath, audit.Query{Contains: runID})
+	if err != nil {
+		return err
+	}
+	if onlyItem != "" {
+		var filtered []audit.Event
+		for _, ev := range events {
+			if itemDir := payloadString(ev.PayloadJSON, "item_dir"); itemDir == "" || filepath.Base(itemDir) == onlyItem {
+				filtered = append(filtered, ev)
+			}
+		}
+		events = filtered
+	}
+	report.addEvents(events)
+
+	annotations, err := metrics.LoadAnnotations(filepath.Join(ws.MetricsDir, "snapshots"))
+	if err != nil {
+		return err
+	}
+	runDate := ""
+	if started, err := time.Parse("20060102T150405Z", runID); err == nil {
+		runDate = started.Format("2006-01-02")
+	}
+
+	itemDirs, err := filepath.Glob(filepath.Join(runDir, "item-*"))
+	if err != nil {
+		return fmt.Errorf("scan run items: %w", err)
+	}
+	sort.Strings(itemDirs)
+	for _, dir := range itemDirs {
+		if onlyItem != "" && filepath.Base(dir) != onlyItem {
+			continue
+		}
+		item, err := loadItem(dir, opts.TailLines)
+		if err != nil {
+			return err
+		}
+		item.Dir = ws.RelPath(dir)
+		if item.MetricKey != "" && runDate != "" {
+			item.Annotations = metrics.AnnotationsFor(annotations, item.MetricKey, runDate)
+		}
+		if item.Failure != nil {
+			if failedAt, err := time.Parse(time.RFC3339, item.Failure.FailedAt); err == nil {
+				report.add(failedAt, "run", fmt.Sprintf("%s failed (%s): %s", filepath.Base(dir), item.Failure.Class, item.Failure.Message))
+			}
+		}
+		report.Items = append(report.Items, item)
+	}
+	return nil
+}
+
+func loadItem(dir string, tailLines int) (ItemReport, error) {
+	var item ItemReport
+	var planItem planner.PlanItem
+	if data, err := os.ReadFile(filepath.Join(dir, "item.json")); err == nil {
+		if err := json.Unmarshal(data, &planItem); err != nil {
+			return item, fmt.Errorf("parse %s: %w", filepath.Join(dir, "item.json"), err)
+		}
+		item.PlanItemID = planItem.ID
+		item.KRID = planItem.KRID
+		item.MetricKey = planItem.ExpectedMetricChange.MetricKey
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "failure.json")); err == nil {
+		var failure planner.ItemFailure
+		if err := json.Unmarshal(data, &failure); err != nil {
+			return item, fmt.Errorf("parse %s: %w", filepath.Join(dir, "failure.json"), err)
+		}
+		item.Failure = &failure
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "violation.json")); err == nil {
+		_ = json.Unmarshal(data, &item.Violation)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, guardrails.SecretsFileName)); err == nil {
+		var record struct {
+			Findings []guardrails.SecretFinding `json:"findings"`
+		}
+		if json.Unmarshal(data, &record) == nil {
+			item.Secrets = record.Findings
+		}
+	}
+	if item.Failure != nil {
+		if ok, errs := guardrails.ValidateResultJSONWithDetails(filepath.Join(dir, "result.json")); !ok {
+			item.ResultErrors = errs
+		}
+	}
+	tail, err := tailFile(filepath.Join(dir, "transcript.log"), tailLines)
+	if err != nil {
+		return item, err
+	}
+	item.TranscriptTail = tail
+	return item, nil
+}
+
+// findItem searches runs newest first for an item dir whose plan item ID
+// is id. "<run-id>/item-NNNN" is accepted too.
+func findItem(runsDir, id string) (string, string, error) {
+	if run, dir, ok := strings.Cut(id, "/"); ok {
+		if info, err := os.Stat(filepath.Join(runsDir, run, dir)); err == nil && info.IsDir() {
+			return run, dir, nil
+		}
+	}
+	entries, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("read runs dir: %w", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].IsDir() {
+			continue
+		}
+		run := entries[i].Name()
+		itemDirs, err := filepath.Glob(filepath.Join(runsDir, run, "item-*"))
+		if err != nil {
+			return "", "", fmt.Errorf("scan run items: %w", err)
+		}
+		for _, dir := range itemDirs {
+			data, err := os.ReadFile(filepath.Join(dir, "item.json"))
+			if err != nil {
+				continue
+			}
+			var item planner.PlanItem
+			if json.Unmarshal(data, &item) == nil && item.ID == id {
+				return run, filepath.Base(dir), nil
+			}
+		}
+	}
+	return "", "", nil
+}
+
+func (r *Report) add(at time.Time, source, summary string) {
+	r.Timeline = append(r.Timeline, Entry{At: at.UTC(), Source: source, Summary: summary})
+}
+
+func (r *Report) addEvents(events []audit.Event) {
+	seen := map[string]bool{}
+	for _, entry := range r.Timeline {
+		seen[entry.Source+"|"+entry.At.String()+"|"+entry.Summary] = true
+	}
+	for _, ev := range events {
+		entry := Entry{At: ev.TS.UTC(), Source: "audit:" + ev.Actor, Summary: describeEvent(ev)}
+		key := entry.Source + "|" + entry.At.String() + "|" + entry.Summary
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r.Timeline = append(r.Timeline, entry)
+	}
+}
+
+func finish(r *Report) *Report {
+	sort.SliceStable(r.Timeline, func(i, j int) bool {
+		return r.Timeline[i].At.Before(r.Timeline[j].At)
+	})
+	return r
+}
+
+// describeEvent summarizes an audit event by its type and the payload fields
+// that matter when tracing a failure.
+func describeEvent(ev audit.Event) string {
+	var payload map[string]any
+	_ = json.Unmarshal([]byte(ev.PayloadJSON), &payload)
+	parts := []string{ev.Type}
+	for _, key := range []string{"job_type", "plan_item_id", "violation_type", "failure_class", "exit_code", "error", "result_error", "adapter_error"} {
+		if v, ok := payload[key]; ok && v != nil && v != "" {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, v))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func payloadString(payloadJSON, key string) string {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+		return ""
+	}
+	s, _ := payload[key].(string)
+	return s
+}
+
+// tailFile returns the last n lines of path, or of path.gz once gc has
+// compressed it.
+func tailFile(path string, n int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = readGzip(path + ".gz")
+	}
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+func readGzip(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,139 @@
+package explain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+const testRunID = "20250115T020000Z"
+
+func setupRun(t *testing.T) *workspace.Workspace {
+	t.Helper()
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatalf("ensure dirs: %v", err)
+	}
+	runDir := filepath.Join(ws.ArtifactsDir, "runs", testRunID)
+	for i, id := range []string{"PI-1", "PI-2"} {
+		itemDir := filepath.Join(runDir, fmt.Sprintf("item-%04d", i+1))
+		if err := os.MkdirAll(itemDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		item := planner.PlanItem{ID: id, KRID: "KR-1", ExpectedMetricChange: planner.ExpectedMetricChange{MetricKey: "ci.pass_rate"}}
+		data, _ := json.Marshal(item)
+		if err := os.WriteFile(filepath.Join(itemDir, "item.json"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	failedDir := filepath.Join(runDir, "item-0002")
+	var transcript strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&transcript, "line %d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(failedDir, "transcript.log"), []byte(transcript.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planner.WriteFailure(failedDir, planner.ItemFailure{
+		Class:      planner.FailureTimeout,
+		RunID:      testRunID,
+		PlanItemID: "PI-2",
+		Message:    "codex timed out",
+		FailedAt:   "2025-01-15T02:30:00Z",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := audit.NewLogger(ws.AuditDBPath)
+	_ = logger.LogEvent("scheduler", "plan_item_started", map[string]any{"run_id": testRunID, "plan_item_id": "PI-2", "item_dir": failedDir})
+	_ = logger.LogEvent("scheduler", "plan_item_finished", map[string]any{"run_id": testRunID, "plan_item_id": "PI-2", "item_dir": failedDir, "failure_class": "timeout"})
+	_ = logger.LogEvent("scheduler", "plan_item_finished", map[string]any{"run_id": "other-run", "plan_item_id": "PI-9"})
+	return ws
+}
+
+func TestExplainRunAndItem(t *testing.T) {
+	ws := setupRun(t)
+
+	report, err := Explain(Options{Workspace: ws, TailLines: 5}, testRunID)
+	if err != nil {
+		t.Fatalf("Explain run: %v", err)
+	}
+	if report.Kind != KindRun || len(report.Items) != 2 || !report.Failed() {
+		t.Fatalf("report = %+v", report)
+	}
+	failed := report.Items[1]
+	if failed.Failure == nil || failed.Failure.Class != planner.FailureTimeout {
+		t.Fatalf("failure = %+v", failed.Failure)
+	}
+	if len(failed.TranscriptTail) != 5 || failed.TranscriptTail[4] != "line 30" {
+		t.Fatalf("tail = %v", failed.TranscriptTail)
+	}
+	if len(failed.ResultErrors) == 0 {
+		t.Fatalf("missing result.json should be reported")
+	}
+	if len(report.Timeline) != 3 {
+		t.Fatalf("timeline = %+v", report.Timeline)
+	}
+	for i := 1; i < len(report.Timeline); i++ {
+		if report.Timeline[i].At.Before(report.Timeline[i-1].At) {
+			t.Fatalf("timeline out of order: %+v", report.Timeline)
+		}
+	}
+
+	report, err = Explain(Options{Workspace: ws}, "PI-2")
+	if err != nil {
+		t.Fatalf("Explain item: %v", err)
+	}
+	if report.Kind != KindItem || len(report.Items) != 1 || report.Items[0].PlanItemID != "PI-2" {
+		t.Fatalf("item report = %+v", report)
+	}
+
+	if _, err := Explain(Options{Workspace: ws}, "nope"); err == nil {
+		t.Fatalf("expected error for unknown id")
+	}
+}
+
+func TestExplainFailedJobFindsRun(t *testing.T) {
+	ws := setupRun(t)
+	store, err := daemon.Open(ws.StateDBPath)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	jobID, _, err := store.EnqueueUnique("plan_execute", time.Now().Add(-time.Minute), map[string]any{})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := store.ClaimNext(time.Now(), "test", time.Minute); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	_ = audit.NewLogger(ws.AuditDBPath).LogEvent("scheduler", "plan_item_started", map[string]any{"run_id": testRunID, "plan_item_id": "PI-1"})
+	if err := store.Fail(jobID, errors.New("run plan (timeout): codex timed out")); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+
+	report, err := Explain(Options{Workspace: ws, Store: store}, jobID)
+	if err != nil {
+		t.Fatalf("Explain job: %v", err)
+	}
+	if report.Kind != KindJob || report.RunID != testRunID || !report.Failed() {
+		t.Fatalf("job report = %+v", report)
+	}
+	if !strings.Contains(report.JobError, "timeout") {
+		t.Fatalf("job error = %q", report.JobError)
+	}
+}
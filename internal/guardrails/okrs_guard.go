@@ -71,6 +71,76 @@ func SnapshotDirHash(dir string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// SnapshotDirFiles hashes each file under dir, keyed by its slash-separated
+// path relative to dir. Returns an empty map if directory doesn't exist.
+func SnapshotDirFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", relPath, err)
+		}
+		defer f.Close()
+		fh := sha256.New()
+		if _, err := io.Copy(fh, f); err != nil {
+			return fmt.Errorf("hash %s: %w", relPath, err)
+		}
+		files[filepath.ToSlash(relPath)] = hex.EncodeToString(fh.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk dir: %w", err)
+	}
+	return files, nil
+}
+
+// File change kinds reported by DiffFiles.
+const (
+	FileAdded    = "added"
+	FileModified = "modified"
+	FileDeleted  = "deleted"
+)
+
+// FileChange is one file that differs between two snapshots.
+type FileChange struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+}
+
+// DiffFiles compares two SnapshotDirFiles results, sorted by path.
+func DiffFiles(before, after map[string]string) []FileChange {
+	var changes []FileChange
+	for path, hash := range after {
+		prev, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: path, Change: FileAdded})
+		case prev != hash:
+			changes = append(changes, FileChange{Path: path, Change: FileModified})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, FileChange{Path: path, Change: FileDeleted})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
 // DiffDir compares two directory hashes and returns a list of changed files.
 // This is a simplified implementation that just indicates a change occurred.
 func DiffDir(beforeHash, afterHash string) ([]string, error) {
@@ -108,7 +178,7 @@ func RevertOKRs(wsRoot string) error {
 // WriteViolation writes a guardrail violation record to the artifacts directory.
 func WriteViolation(artifactsDir string, violation map[string]any) error {
 	violationPath := filepath.Join(artifactsDir, "violation.json")
-	
+
 	data, err := json.MarshalIndent(violation, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal violation: %w", err)
@@ -126,6 +196,10 @@ type OKRsIntegrityCheck struct {
 	BeforeHash string
 	AfterHash  string
 	OKRsDir    string
+
+	// Per-file hashes, for reporting and reverting individual files.
+	BeforeFiles map[string]string
+	AfterFiles  map[string]string
 }
 
 // NewIntegrityCheck creates a new integrity check for the given workspace root.
@@ -135,10 +209,15 @@ func NewIntegrityCheck(wsRoot string) (*OKRsIntegrityCheck, error) {
 	if err != nil {
 		return nil, fmt.Errorf("capture before snapshot: %w", err)
 	}
+	beforeFiles, err := SnapshotDirFiles(okrsDir)
+	if err != nil {
+		return nil, fmt.Errorf("capture before snapshot: %w", err)
+	}
 
 	return &OKRsIntegrityCheck{
-		BeforeHash: beforeHash,
-		OKRsDir:    okrsDir,
+		BeforeHash:  beforeHash,
+		OKRsDir:     okrsDir,
+		BeforeFiles: beforeFiles,
 	}, nil
 }
 
@@ -149,6 +228,11 @@ func (c *OKRsIntegrityCheck) CaptureAfter() error {
 		return fmt.Errorf("capture after snapshot: %w", err)
 	}
 	c.AfterHash = afterHash
+	afterFiles, err := SnapshotDirFiles(c.OKRsDir)
+	if err != nil {
+		return fmt.Errorf("capture after snapshot: %w", err)
+	}
+	c.AfterFiles = afterFiles
 	return nil
 }
 
@@ -157,9 +241,62 @@ func (c *OKRsIntegrityCheck) HasChanges() bool {
 	return c.BeforeHash != c.AfterHash
 }
 
-// GetChangedFiles returns a list of changed files (simplified).
+// Changes returns each file under okrs/ that was added, modified, or
+// deleted, with paths relative to the okrs/ directory.
+func (c *OKRsIntegrityCheck) Changes() []FileChange {
+	if c.BeforeFiles == nil || c.AfterFiles == nil {
+		return nil
+	}
+	return DiffFiles(c.BeforeFiles, c.AfterFiles)
+}
+
+// GetChangedFiles returns the changed files as okrs/-prefixed paths.
 func (c *OKRsIntegrityCheck) GetChangedFiles() ([]string, error) {
-	return DiffDir(c.BeforeHash, c.AfterHash)
+	changes := c.Changes()
+	if changes == nil {
+		return DiffDir(c.BeforeHash, c.AfterHash)
+	}
+	files := make([]string, len(changes))
+	for i, change := range changes {
+		files[i] = "okrs/" + change.Path
+	}
+	return files, nil
+}
+
+// Revert undoes the changes since the before snapshot: added files are
+// removed and modified or deleted files are restored with git checkout.
+// Unlike RevertOKRs it leaves files that were already changed before the
+// snapshot alone.
+func (c *OKRsIntegrityCheck) Revert(wsRoot string) error {
+	changes := c.Changes()
+	if changes == nil {
+		return RevertOKRs(wsRoot)
+	}
+	var restore []string
+	for _, change := range changes {
+		if change.Change == FileAdded {
+			continue
+		}
+		restore = append(restore, filepath.Join(c.OKRsDir, filepath.FromSlash(change.Path)))
+	}
+	if len(restore) > 0 {
+		if !IsGitRepo(wsRoot) {
+			return fmt.Errorf("workspace is not a git repository, cannot revert okrs/ changes")
+		}
+		args := append([]string{"-C", wsRoot, "checkout", "--"}, restore...)
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("git checkout failed: %w (output: %s)", err, string(output))
+		}
+	}
+	for _, change := range changes {
+		if change.Change != FileAdded {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.OKRsDir, filepath.FromSlash(change.Path))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove added file %s: %w", change.Path, err)
+		}
+	}
+	return nil
 }
 
 // BuildViolation creates a violation record map.
@@ -0,0 +1,243 @@
+package guardrails
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SecretsFileName is the record of secrets found in a plan item's outputs.
+const SecretsFileName = "secrets.json"
+
+// maxScanFileSize bounds the untracked files read when scanning a diff.
+const maxScanFileSize = 1 << 20
+
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// secretPatterns are the credential formats the scanner knows. They aim
+// for few false positives: each has a distinctive prefix or framing.
+var secretPatterns = []secretPattern{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws_access_key_id", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_access_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{"github_token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"stripe_key", regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{24,}\b`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"openai_key", regexp.MustCompile(`\bsk-(proj-|ant-)?[A-Za-z0-9_-]{20,}\b`)},
+}
+
+// SecretFinding is one secret found by the scanner. The secret itself is
+// never recorded; Fingerprint identifies it without revealing it.
+type SecretFinding struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Kind        string `json:"kind"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type secretMatch struct {
+	start, end int
+	kind       string
+}
+
+// findSecrets returns non-overlapping matches in data, in order. Earlier
+// patterns win where matches overlap.
+func findSecrets(data []byte) []secretMatch {
+	var matches []secretMatch
+	for _, p := range secretPatterns {
+		for _, loc := range p.re.FindAllIndex(data, -1) {
+			overlaps := false
+			for _, m := range matches {
+				if loc[0] < m.end && m.start < loc[1] {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				matches = append(matches, secretMatch{start: loc[0], end: loc[1], kind: p.kind})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+func fingerprint(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// ScanSecrets returns the secrets in data, attributed to file.
+func ScanSecrets(file string, data []byte) []SecretFinding {
+	var findings []SecretFinding
+	for _, m := range findSecrets(data) {
+		findings = append(findings, SecretFinding{
+			File:        file,
+			Line:        bytes.Count(data[:m.start], []byte("\n")) + 1,
+			Kind:        m.kind,
+			Fingerprint: fingerprint(data[m.start:m.end]),
+		})
+	}
+	return findings
+}
+
+// RedactSecrets replaces each secret in data with [REDACTED:<kind>].
+func RedactSecrets(data []byte) []byte {
+	matches := findSecrets(data)
+	if len(matches) == 0 {
+		return data
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		out.Write(data[last:m.start])
+		out.WriteString("[REDACTED:" + m.kind + "]")
+		last = m.end
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+// RedactFile scans the file at path and rewrites it with any secrets
+// redacted. Findings are attributed to name. A missing file has none.
+func RedactFile(path, name string) ([]SecretFinding, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	findings := ScanSecrets(name, data)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, RedactSecrets(data), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("redact %s: %w", name, err)
+	}
+	return findings, nil
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanGitDiff scans what changed in the git work tree at dir: lines added
+// to tracked files since HEAD, and untracked files that are not ignored.
+// It returns no findings when dir is not a git repository.
+func ScanGitDiff(dir string) ([]SecretFinding, error) {
+	if !IsGitRepo(dir) {
+		return nil, nil
+	}
+	diff, err := exec.Command("git", "-C", dir, "diff", "HEAD", "--no-color", "--no-ext-diff", "-U0").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	var findings []SecretFinding
+	var added bytes.Buffer
+	var addedLines []int
+	file := ""
+	flush := func() {
+		if file == "" || added.Len() == 0 {
+			return
+		}
+		for _, f := range ScanSecrets(file, added.Bytes()) {
+			f.Line = addedLines[f.Line-1]
+			findings = append(findings, f)
+		}
+		added.Reset()
+		addedLines = addedLines[:0]
+	}
+	line := 0
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			flush()
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@"):
+			if m := hunkHeaderPattern.FindStringSubmatch(text); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+		case strings.HasPrefix(text, "+"):
+			added.WriteString(text[1:] + "\n")
+			addedLines = append(addedLines, line)
+			line++
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read git diff: %w", err)
+	}
+
+	untracked, err := exec.Command("git", "-C", dir, "ls-files", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	for _, name := range strings.Split(string(untracked), "\x00") {
+		if name == "" {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxScanFileSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		findings = append(findings, ScanSecrets(name, data)...)
+	}
+	return findings, nil
+}
+
+// NewSecretFindings returns the findings in after that are not in before,
+// matching by file, kind, and fingerprint, so secrets already present
+// before an agent ran are not attributed to it.
+func NewSecretFindings(before, after []SecretFinding) []SecretFinding {
+	seen := map[[3]string]bool{}
+	for _, f := range before {
+		seen[[3]string{f.File, f.Kind, f.Fingerprint}] = true
+	}
+	var fresh []SecretFinding
+	for _, f := range after {
+		if !seen[[3]string{f.File, f.Kind, f.Fingerprint}] {
+			fresh = append(fresh, f)
+		}
+	}
+	return fresh
+}
+
+// WriteSecretFindings records findings in secrets.json in dir.
+func WriteSecretFindings(dir string, findings []SecretFinding) error {
+	data, err := json.MarshalIndent(map[string]any{"findings": findings}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal secret findings: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SecretsFileName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", SecretsFileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+// Package locale formats numbers and dates for human-readable output (CLI
+// tables, notifications, reports). JSON artifacts always stay canonical and
+// never go through a Locale.
+package locale
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the workspace locale config at the workspace root.
+const FileName = "locale.yml"
+
+// Env overrides the workspace locale, e.g. OKRCHESTRA_LOCALE=de-DE.
+const Env = "OKRCHESTRA_LOCALE"
+
+// LanguageEnv overrides the workspace language, e.g. OKRCHESTRA_LANGUAGE=de.
+const LanguageEnv = "OKRCHESTRA_LANGUAGE"
+
+// DefaultLanguage is the language agents are instructed in when none is
+// configured.
+const DefaultLanguage = "en"
+
+// Locale holds the separators and layouts used to render values. The zero
+// value is the canonical format: '.' decimals, no grouping, ISO dates.
+type Locale struct {
+	Name     string
+	Decimal  string
+	Group    string
+	Date     string
+	DateTime string
+}
+
+// Canonical is the format used when no locale is configured.
+var Canonical = Locale{Decimal: ".", Date: time.DateOnly, DateTime: time.DateTime}
+
+var known = map[string]Locale{
+	"en-US": {Decimal: ".", Group: ",", Date: "01/02/2006", DateTime: "01/02/2006 3:04 PM"},
+	"en-GB": {Decimal: ".", Group: ",", Date: "02/01/2006", DateTime: "02/01/2006 15:04"},
+	"de-DE": {Decimal: ",", Group: ".", Date: "02.01.2006", DateTime: "02.01.2006 15:04"},
+	"de-CH": {Decimal: ".", Group: "’", Date: "02.01.2006", DateTime: "02.01.2006 15:04"},
+	"fr-FR": {Decimal: ",", Group: " ", Date: "02/01/2006", DateTime: "02/01/2006 15:04"},
+	"es-ES": {Decimal: ",", Group: ".", Date: "02/01/2006", DateTime: "02/01/2006 15:04"},
+	"it-IT": {Decimal: ",", Group: ".", Date: "02/01/2006", DateTime: "02/01/2006 15:04"},
+	"nl-NL": {Decimal: ",", Group: ".", Date: "02-01-2006", DateTime: "02-01-2006 15:04"},
+	"pt-BR": {Decimal: ",", Group: ".", Date: "02/01/2006", DateTime: "02/01/2006 15:04"},
+	"sv-SE": {Decimal: ",", Group: " ", Date: "2006-01-02", DateTime: "2006-01-02 15:04"},
+	"ja-JP": {Decimal: ".", Group: ",", Date: "2006/01/02", DateTime: "2006/01/02 15:04"},
+}
+
+// languageDefaults maps a bare language to its most common region.
+var languageDefaults = map[string]string{
+	"en": "en-US",
+	"de": "de-DE",
+	"fr": "fr-FR",
+	"es": "es-ES",
+	"it": "it-IT",
+	"nl": "nl-NL",
+	"pt": "pt-BR",
+	"sv": "sv-SE",
+	"ja": "ja-JP",
+}
+
+// Names lists the supported locale names.
+func Names() []string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named locale. Names are matched loosely: "de_DE.UTF-8",
+// "de-de", and "de" all select de-DE. An empty name is Canonical.
+func Lookup(name string) (Locale, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "C" || name == "POSIX" {
+		return Canonical, nil
+	}
+	base, _, _ := strings.Cut(name, ".")
+	lang, region, _ := strings.Cut(strings.ReplaceAll(base, "_", "-"), "-")
+	key := strings.ToLower(lang)
+	if region != "" {
+		key += "-" + strings.ToUpper(region)
+	} else if def, ok := languageDefaults[key]; ok {
+		key = def
+	}
+	l, ok := known[key]
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	l.Name = key
+	return l, nil
+}
+
+type fileConfig struct {
+	Locale   string `yaml:"locale"`
+	Language string `yaml:"language"`
+}
+
+func readFileConfig(root string) (fileConfig, error) {
+	var cfg fileConfig
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", FileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", FileName, err)
+	}
+	return cfg, nil
+}
+
+// Load returns the locale for a workspace: $OKRCHESTRA_LOCALE if set, else
+// the `locale:` in <root>/locale.yml, else Canonical.
+func Load(root string) (Locale, error) {
+	if name := strings.TrimSpace(os.Getenv(Env)); name != "" {
+		return Lookup(name)
+	}
+	cfg, err := readFileConfig(root)
+	if err != nil {
+		return Locale{}, err
+	}
+	return Lookup(cfg.Locale)
+}
+
+// LoadLanguage returns the working language of a workspace as a lowercase
+// ISO 639-1 code: $OKRCHESTRA_LANGUAGE if set, else the `language:` in
+// <root>/locale.yml, else the language of the configured locale, else
+// DefaultLanguage. Unlike locales, any language is accepted; callers fall
+// back to English where they have no translation.
+func LoadLanguage(root string) (string, error) {
+	if name := strings.TrimSpace(os.Getenv(LanguageEnv)); name != "" {
+		return normalizeLanguage(name), nil
+	}
+	cfg, err := readFileConfig(root)
+	if err != nil {
+		return "", err
+	}
+	name := cfg.Language
+	if name == "" {
+		name = strings.TrimSpace(os.Getenv(Env))
+	}
+	if name == "" {
+		name = cfg.Locale
+	}
+	if lang := normalizeLanguage(name); lang != "" && lang != "c" && lang != "posix" {
+		return lang, nil
+	}
+	return DefaultLanguage, nil
+}
+
+// normalizeLanguage reduces "de_DE.UTF-8", "de-DE", or "DE" to "de".
+func normalizeLanguage(name string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(name), ".")
+	lang, _, _ := strings.Cut(strings.ReplaceAll(base, "_", "-"), "-")
+	return strings.ToLower(lang)
+}
+
+// Number formats v with as many decimals as needed, like %g without
+// exponents.
+func (l Locale) Number(v float64) string {
+	return l.format(v, -1)
+}
+
+// Fixed formats v with exactly decimals digits after the separator.
+func (l Locale) Fixed(v float64, decimals int) string {
+	return l.format(v, decimals)
+}
+
+// Int formats an integer with group separators.
+func (l Locale) Int(n int64) string {
+	return l.format(float64(n), 0)
+}
+
+func (l Locale) format(v float64, decimals int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	if l.Group != "" && len(intPart) > 3 {
+		var b strings.Builder
+		lead := len(intPart) % 3
+		if lead > 0 {
+			b.WriteString(intPart[:lead])
+		}
+		for i := lead; i < len(intPart); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.Group)
+			}
+			b.WriteString(intPart[i : i+3])
+		}
+		intPart = b.String()
+	}
+	if !hasFrac {
+		return sign + intPart
+	}
+	dec := l.Decimal
+	if dec == "" {
+		dec = "."
+	}
+	return sign + intPart + dec + frac
+}
+
+// FormatDate formats t as a date.
+func (l Locale) FormatDate(t time.Time) string {
+	if l.Date == "" {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(l.Date)
+}
+
+// FormatDateTime formats t as a date and time of day.
+func (l Locale) FormatDateTime(t time.Time) string {
+	if l.DateTime == "" {
+		return t.Format(time.DateTime)
+	}
+	return t.Format(l.DateTime)
+}
+
+// DateString reformats a stored YYYY-MM-DD or RFC3339 date; anything else is
+// returned unchanged.
+func (l Locale) DateString(s string) string {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return l.FormatDate(t)
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return l.FormatDate(t)
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package locale
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLocaleFormatting(t *testing.T) {
+	de, err := Lookup("de_DE.UTF-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	us, err := Lookup("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"canonical number", Canonical.Number(1500.25), "1500.25"},
+		{"canonical date", Canonical.FormatDate(day), "2026-03-04"},
+		{"de number", de.Number(1500.25), "1.500,25"},
+		{"de fixed", de.Fixed(-1234567, 0), "-1.234.567"},
+		{"de date", de.DateString("2026-03-04"), "04.03.2026"},
+		{"us number", us.Number(1500), "1,500"},
+		{"us small", us.Number(0.5), "0.5"},
+		{"us date", us.DateString("2026-03-04T10:00:00Z"), "03/04/2026"},
+		{"us datetime", us.FormatDateTime(day), "03/04/2026 3:30 PM"},
+		{"unparsed date", de.DateString("soon"), "soon"},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if _, err := Lookup("xx-YY"); err == nil {
+		t.Errorf("expected unsupported locale error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(Env, "")
+	l, err := Load(root)
+	if err != nil || l.Name != "" {
+		t.Fatalf("missing config should be canonical, got %+v, %v", l, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("locale: fr-FR\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = Load(root); err != nil || l.Name != "fr-FR" {
+		t.Fatalf("Load = %+v, %v; want fr-FR", l, err)
+	}
+
+	t.Setenv(Env, "en-GB")
+	if l, err = Load(root); err != nil || l.Name != "en-GB" {
+		t.Fatalf("env override = %+v, %v; want en-GB", l, err)
+	}
+}
+
+func TestLoadLanguage(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(Env, "")
+	t.Setenv(LanguageEnv, "")
+	if lang, err := LoadLanguage(root); err != nil || lang != DefaultLanguage {
+		t.Fatalf("missing config = %q, %v; want %q", lang, err, DefaultLanguage)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("locale: fr-FR\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if lang, err := LoadLanguage(root); err != nil || lang != "fr" {
+		t.Fatalf("language from locale = %q, %v; want fr", lang, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("locale: en-US\nlanguage: de_DE\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if lang, err := LoadLanguage(root); err != nil || lang != "de" {
+		t.Fatalf("language setting = %q, %v; want de", lang, err)
+	}
+
+	t.Setenv(LanguageEnv, "ES")
+	if lang, err := LoadLanguage(root); err != nil || lang != "es" {
+		t.Fatalf("env override = %q, %v; want es", lang, err)
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AnnotationsFileName is stored in the snapshots dir. It is YAML so it is
+// never mistaken for a YYYY-MM-DD.json snapshot.
+const AnnotationsFileName = "annotations.yml"
+
+// Annotation is human context attached to one metric on one snapshot date.
+type Annotation struct {
+	Key       string `json:"key" yaml:"key"`
+	Date      string `json:"date" yaml:"date"`
+	Note      string `json:"note" yaml:"note"`
+	Author    string `json:"author,omitempty" yaml:"author,omitempty"`
+	CreatedAt string `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+}
+
+type annotationsFile struct {
+	Annotations []Annotation `yaml:"annotations"`
+}
+
+// AnnotationsPath returns the annotations file for a snapshots dir.
+func AnnotationsPath(snapshotsDir string) string {
+	return filepath.Join(snapshotsDir, AnnotationsFileName)
+}
+
+// LoadAnnotations reads annotations from the snapshots dir, sorted by date
+// then key. A missing file yields no annotations.
+func LoadAnnotations(snapshotsDir string) ([]Annotation, error) {
+	data, err := os.ReadFile(AnnotationsPath(snapshotsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read annotations: %w", err)
+	}
+	var file annotationsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse annotations: %w", err)
+	}
+	sortAnnotations(file.Annotations)
+	return file.Annotations, nil
+}
+
+// AddAnnotation validates and appends an annotation to the snapshots dir.
+func AddAnnotation(snapshotsDir string, a Annotation) (Annotation, error) {
+	a.Key = strings.TrimSpace(a.Key)
+	a.Note = strings.TrimSpace(a.Note)
+	if a.Key == "" {
+		return a, fmt.Errorf("annotation key is required")
+	}
+	if a.Note == "" {
+		return a, fmt.Errorf("annotation note is required")
+	}
+	if _, err := time.Parse("2006-01-02", a.Date); err != nil {
+		return a, fmt.Errorf("annotation date must be YYYY-MM-DD: %q", a.Date)
+	}
+	if a.CreatedAt == "" {
+		a.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	existing, err := LoadAnnotations(snapshotsDir)
+	if err != nil {
+		return a, err
+	}
+	file := annotationsFile{Annotations: append(existing, a)}
+	sortAnnotations(file.Annotations)
+
+	if err := os.MkdirAll(snapshotsDir, 0o755); err != nil {
+		return a, fmt.Errorf("ensure snapshots dir: %w", err)
+	}
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return a, fmt.Errorf("marshal annotations: %w", err)
+	}
+	path := AnnotationsPath(snapshotsDir)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return a, fmt.Errorf("write annotations: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return a, fmt.Errorf("write annotations: %w", err)
+	}
+	return a, nil
+}
+
+// AnnotationsFor returns the annotations for key on date. An empty key or
+// date matches any.
+func AnnotationsFor(annotations []Annotation, key, date string) []Annotation {
+	var out []Annotation
+	for _, a := range annotations {
+		if key != "" && a.Key != key {
+			continue
+		}
+		if date != "" && a.Date != date {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// AnnotateReport attaches annotations for each KR's metric on the report's
+// as-of date, so score explanations carry the human context.
+func AnnotateReport(report *KRScoreReport, annotations []Annotation) {
+	if report == nil || len(annotations) == 0 {
+		return
+	}
+	for i := range report.Results {
+		result := &report.Results[i]
+		if result.MetricKey == "" {
+			continue
+		}
+		result.Annotations = AnnotationsFor(annotations, result.MetricKey, report.AsOf)
+	}
+}
+
+func sortAnnotations(annotations []Annotation) {
+	sort.SliceStable(annotations, func(i, j int) bool {
+		if annotations[i].Date != annotations[j].Date {
+			return annotations[i].Date < annotations[j].Date
+		}
+		return annotations[i].Key < annotations[j].Key
+	})
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAnnotationsRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+
+	if got, err := LoadAnnotations(dir); err != nil || got != nil {
+		t.Fatalf("expected no annotations for missing file, got %v, %v", got, err)
+	}
+	if _, err := AddAnnotation(dir, Annotation{Key: "ci.pass_rate_30d", Date: "15/01/2025", Note: "x"}); err == nil {
+		t.Fatalf("expected invalid date error")
+	}
+
+	for _, a := range []Annotation{
+		{Key: "ci.pass_rate_30d", Date: "2025-01-15", Note: "flaky suite quarantined"},
+		{Key: "git.commits_7d", Date: "2025-01-10", Note: "holiday week"},
+	} {
+		if _, err := AddAnnotation(dir, a); err != nil {
+			t.Fatalf("add annotation: %v", err)
+		}
+	}
+
+	annotations, err := LoadAnnotations(dir)
+	if err != nil {
+		t.Fatalf("load annotations: %v", err)
+	}
+	if len(annotations) != 2 || annotations[0].Date != "2025-01-10" {
+		t.Fatalf("expected annotations sorted by date, got %+v", annotations)
+	}
+
+	// The annotations file must not shadow snapshots.
+	if err := WriteSnapshot(filepath.Join(dir, "2025-01-15.json"), Snapshot{AsOf: "2025-01-15"}); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+	latest, err := LatestSnapshotPath(dir)
+	if err != nil || filepath.Base(latest) != "2025-01-15.json" {
+		t.Fatalf("unexpected latest snapshot %q, %v", latest, err)
+	}
+
+	report := &KRScoreReport{
+		AsOf: "2025-01-15",
+		Results: []KRScore{
+			{KRID: "KR-1", MetricKey: "ci.pass_rate_30d"},
+			{KRID: "KR-2", MetricKey: "git.commits_7d"},
+		},
+	}
+	AnnotateReport(report, annotations)
+	if len(report.Results[0].Annotations) != 1 || report.Results[0].Annotations[0].Note != "flaky suite quarantined" {
+		t.Fatalf("expected annotation on KR-1, got %+v", report.Results[0].Annotations)
+	}
+	if len(report.Results[1].Annotations) != 0 {
+		t.Fatalf("annotation from another date attached to KR-2: %+v", report.Results[1].Annotations)
+	}
+}
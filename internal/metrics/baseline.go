@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
+)
+
+// BaselineAgentID is the default agent that proposes detected baselines.
+const BaselineAgentID = "okrchestra-baseline"
+
+// BaselineDetection is the proposed baseline for a KR with `baseline: null`.
+// Found is false when no snapshot records the KR's metric yet.
+type BaselineDetection struct {
+	KRID         string  `json:"kr_id"`
+	ObjectiveID  string  `json:"objective_id"`
+	MetricKey    string  `json:"metric_key"`
+	Value        float64 `json:"value"`
+	Found        bool    `json:"found"`
+	AsOf         string  `json:"as_of,omitempty"`
+	SnapshotPath string  `json:"snapshot_path,omitempty"`
+}
+
+// DetectBaselines looks up the current value of every pending-baseline KR's
+// metric in the newest snapshot that records it.
+func DetectBaselines(store *okrstore.Store, snapshotsDir string) ([]BaselineDetection, error) {
+	if store == nil {
+		return nil, fmt.Errorf("okr store is required")
+	}
+	var detections []BaselineDetection
+	for _, doc := range allDocuments(store) {
+		for _, obj := range doc.Objectives {
+			for _, kr := range obj.KeyResults {
+				if kr.BaselinePending {
+					detections = append(detections, BaselineDetection{KRID: kr.ID, ObjectiveID: obj.ID, MetricKey: kr.MetricKey})
+				}
+			}
+		}
+	}
+	if len(detections) == 0 {
+		return nil, nil
+	}
+
+	paths, err := SnapshotPaths(snapshotsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		pending := false
+		for _, d := range detections {
+			pending = pending || !d.Found
+		}
+		if !pending {
+			break
+		}
+		snapshot, err := LoadSnapshot(paths[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := range detections {
+			d := &detections[j]
+			if d.Found {
+				continue
+			}
+			if v, ok := snapshot.Value(d.MetricKey); ok {
+				d.Value = v
+				d.Found = true
+				d.AsOf = snapshot.AsOf
+				d.SnapshotPath = paths[i]
+			}
+		}
+	}
+	return detections, nil
+}
+
+// ProposeBaselines writes the OKR documents holding found detections, with
+// their baselines filled in, and creates a proposal for them. It returns
+// nil when nothing was found.
+func ProposeBaselines(ws *workspace.Workspace, detections []BaselineDetection, agentID string) (*okrstore.ProposalMetadata, error) {
+	found := map[string]BaselineDetection{}
+	for _, d := range detections {
+		if d.Found {
+			found[d.KRID] = d
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return nil, fmt.Errorf("load okrs: %w", err)
+	}
+	updatesDir := filepath.Join(ws.ArtifactsDir, "baselines", time.Now().UTC().Format("20060102T150405Z"), "updates")
+
+	var notes []string
+	for _, doc := range allDocuments(store) {
+		touched := false
+		for i := range doc.Objectives {
+			for j := range doc.Objectives[i].KeyResults {
+				kr := &doc.Objectives[i].KeyResults[j]
+				d, ok := found[kr.ID]
+				if !ok || !kr.BaselinePending {
+					continue
+				}
+				kr.Baseline = d.Value
+				kr.BaselinePending = false
+				touched = true
+				notes = append(notes, fmt.Sprintf("%s=%g (%s as of %s)", kr.ID, d.Value, d.MetricKey, d.AsOf))
+			}
+		}
+		if !touched {
+			continue
+		}
+		rel, err := filepath.Rel(ws.OKRsDir, doc.Source)
+		if err != nil {
+			return nil, fmt.Errorf("locate %s: %w", doc.Source, err)
+		}
+		dst := filepath.Join(updatesDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("ensure baseline updates dir: %w", err)
+		}
+		if err := okrstore.WriteDocument(doc, dst); err != nil {
+			return nil, err
+		}
+	}
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	if data, err := os.ReadFile(filepath.Join(ws.OKRsDir, "permissions.yml")); err == nil {
+		if err := os.WriteFile(filepath.Join(updatesDir, "permissions.yml"), data, 0o644); err != nil {
+			return nil, fmt.Errorf("copy permissions: %w", err)
+		}
+	}
+
+	note := "Detected baselines from the latest metric snapshots: " + strings.Join(notes, ", ")
+	meta, err := okrstore.CreateProposalIn(ws.Root, agentID, updatesDir, ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "proposals"), note)
+	if err != nil {
+		return nil, fmt.Errorf("propose baselines: %w", err)
+	}
+	return meta, nil
+}
+
+func allDocuments(store *okrstore.Store) []okrstore.Document {
+	var docs []okrstore.Document
+	docs = append(docs, store.Org.Documents...)
+	docs = append(docs, store.Team.Documents...)
+	docs = append(docs, store.Person.Documents...)
+	return docs
+}
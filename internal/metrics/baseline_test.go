@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
+)
+
+func TestDetectAndProposeBaselines(t *testing.T) {
+	root := t.TempDir()
+	ws, err := workspace.Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ws.OKRsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	okrs := `scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Objective
+    key_results:
+      - kr_id: KR-NEW
+        description: New KR
+        owner_id: team
+        metric_key: m.new
+        baseline: null
+        target: 90
+        confidence: 0.5
+        status: not_started
+        evidence: []
+      - kr_id: KR-UNMEASURED
+        description: Not collected yet
+        owner_id: team
+        metric_key: m.missing
+        baseline: null
+        target: 5
+        confidence: 0.5
+        status: not_started
+        evidence: []
+`
+	if err := os.WriteFile(filepath.Join(ws.OKRsDir, "org.yml"), []byte(okrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	perms := "permissions:\n  write: [delegated_explicitly]\ndelegations:\n  team: [" + BaselineAgentID + "]\n"
+	if err := os.WriteFile(filepath.Join(ws.OKRsDir, "permissions.yml"), []byte(perms), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshotsDir := filepath.Join(ws.MetricsDir, "snapshots")
+	for _, s := range []struct {
+		day   int
+		value float64
+	}{{1, 60}, {2, 72}} {
+		asOf := time.Date(2026, 3, s.day, 0, 0, 0, 0, time.UTC)
+		snap := Snapshot{AsOf: AsOfTimestamp(asOf), Points: []MetricPoint{{Key: "m.new", Value: s.value, Timestamp: AsOfTimestamp(asOf), Source: "manual"}}}
+		if err := WriteSnapshot(SnapshotPathForDate(snapshotsDir, asOf), snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := okrstore.LoadFromDir(ws.OKRsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	detections, err := DetectBaselines(store, snapshotsDir)
+	if err != nil {
+		t.Fatalf("DetectBaselines: %v", err)
+	}
+	if len(detections) != 2 {
+		t.Fatalf("detections = %+v, want 2", detections)
+	}
+	if d := detections[0]; !d.Found || d.Value != 72 {
+		t.Fatalf("KR-NEW detection = %+v, want latest value 72", d)
+	}
+	if detections[1].Found {
+		t.Fatalf("KR-UNMEASURED should not be found: %+v", detections[1])
+	}
+
+	meta, err := ProposeBaselines(ws, detections, BaselineAgentID)
+	if err != nil {
+		t.Fatalf("ProposeBaselines: %v", err)
+	}
+	if meta == nil {
+		t.Fatalf("expected a proposal")
+	}
+	data, err := os.ReadFile(filepath.Join(meta.ProposalDir, "org.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed, err := okrstore.ParseAndValidateDocument(data, "org.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	krs := proposed.Objectives[0].KeyResults
+	if krs[0].BaselinePending || krs[0].Baseline != 72 {
+		t.Fatalf("KR-NEW baseline = %v (pending %v), want 72", krs[0].Baseline, krs[0].BaselinePending)
+	}
+	if !krs[1].BaselinePending {
+		t.Fatalf("KR-UNMEASURED should stay pending")
+	}
+	if !strings.Contains(meta.Note, "KR-NEW=72") {
+		t.Fatalf("note = %q", meta.Note)
+	}
+}
@@ -2,7 +2,13 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"okrchestra/internal/config"
 )
 
 type ProviderResult struct {
@@ -10,18 +16,337 @@ type ProviderResult struct {
 	Points   []MetricPoint
 }
 
-// CollectAll runs providers and merges their points.
+// ProviderConfig describes the inputs used to build the built-in providers.
+type ProviderConfig struct {
+	RepoDir      string
+	MetricsDir   string
+	CIReportPath string
+	ManualPath   string
+	// CoveragePath is a coverage or test report, or a directory of them
+	// (default: <MetricsDir>/coverage).
+	CoveragePath string
+	// OpenMetricsDir holds *.prom exposition files (default: <MetricsDir>/openmetrics).
+	OpenMetricsDir    string
+	OpenMetricsPrefix string
+	// IntakePath is the log of pushed points (default: <MetricsDir>/intake.jsonl).
+	IntakePath string
+	// DerivedPath defines metrics computed from the others (default:
+	// <MetricsDir>/derived.yml).
+	DerivedPath string
+	// GitHub enables the GitHub provider when GitHub.Repo is set.
+	GitHub GitHubConfig
+	// Prometheus enables the Prometheus provider when it has queries.
+	Prometheus PrometheusConfig
+	// Tracker configures the issue tracker provider; by default it reads
+	// <MetricsDir>/tracker.json when present.
+	Tracker TrackerConfig
+	// ProviderTimeout bounds each provider's collection; 0 means no limit.
+	ProviderTimeout time.Duration
+	// Concurrency is how many providers run at once (default
+	// DefaultCollectConcurrency).
+	Concurrency int
+	AsOf        time.Time
+}
+
+// LoadWorkspaceConfig fills the GitHub, Prometheus, and issue tracker
+// settings from github.yml, prometheus.yml, and tracker.yml at the
+// workspace root, and input paths left empty and the provider timeout from
+// the metrics section of okrchestra.yml, along with the concurrency when
+// unset.
+func (cfg *ProviderConfig) LoadWorkspaceConfig(root string) error {
+	var err error
+	if cfg.GitHub, err = LoadGitHubConfig(root); err != nil {
+		return err
+	}
+	if cfg.Prometheus, err = LoadPrometheusConfig(root); err != nil {
+		return err
+	}
+	if cfg.Tracker, err = LoadTrackerConfig(root); err != nil {
+		return err
+	}
+	wsCfg, err := config.Load(root)
+	if err != nil {
+		return err
+	}
+	fill := func(dst *string, path string) {
+		if *dst != "" || path == "" {
+			return
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		*dst = path
+	}
+	fill(&cfg.CIReportPath, wsCfg.Metrics.CIReport)
+	fill(&cfg.CoveragePath, wsCfg.Metrics.Coverage)
+	fill(&cfg.ManualPath, wsCfg.Metrics.Manual)
+	fill(&cfg.OpenMetricsDir, wsCfg.Metrics.OpenMetricsDir)
+	if cfg.OpenMetricsPrefix == "" {
+		cfg.OpenMetricsPrefix = wsCfg.Metrics.OpenMetricsPrefix
+	}
+	cfg.ProviderTimeout = wsCfg.Metrics.ProviderTimeout
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = wsCfg.Metrics.Concurrency
+	}
+	return nil
+}
+
+// DefaultProviders returns the built-in providers in collection order.
+// Empty paths default to files under MetricsDir.
+func DefaultProviders(cfg ProviderConfig) []Provider {
+	if cfg.MetricsDir == "" {
+		cfg.MetricsDir = "metrics"
+	}
+	if cfg.CIReportPath == "" {
+		cfg.CIReportPath = filepath.Join(cfg.MetricsDir, "ci_report.json")
+	}
+	if cfg.ManualPath == "" {
+		cfg.ManualPath = filepath.Join(cfg.MetricsDir, "manual.yml")
+	}
+	if cfg.CoveragePath == "" {
+		cfg.CoveragePath = filepath.Join(cfg.MetricsDir, "coverage")
+	}
+	if cfg.OpenMetricsDir == "" {
+		cfg.OpenMetricsDir = filepath.Join(cfg.MetricsDir, "openmetrics")
+	}
+	if cfg.IntakePath == "" {
+		cfg.IntakePath = IntakePath(cfg.MetricsDir)
+	}
+	if cfg.DerivedPath == "" {
+		cfg.DerivedPath = filepath.Join(cfg.MetricsDir, DerivedFileName)
+	}
+	if cfg.Tracker.Export == "" {
+		cfg.Tracker.Export = filepath.Join(cfg.MetricsDir, "tracker.json")
+	}
+	providers := []Provider{
+		&GitProvider{RepoDir: cfg.RepoDir, AsOf: cfg.AsOf},
+		&CIProvider{ReportPath: cfg.CIReportPath, AsOf: cfg.AsOf},
+		&CoverageProvider{Path: cfg.CoveragePath, AsOf: cfg.AsOf},
+		&ManualProvider{Path: cfg.ManualPath, AsOf: cfg.AsOf},
+		&OpenMetricsProvider{Dir: cfg.OpenMetricsDir, Prefix: cfg.OpenMetricsPrefix, AsOf: cfg.AsOf},
+		&IntakeProvider{Path: cfg.IntakePath, AsOf: cfg.AsOf},
+		&TrackerProvider{Config: cfg.Tracker, AsOf: cfg.AsOf},
+	}
+	if cfg.GitHub.Repo != "" {
+		providers = append(providers, &GitHubProvider{
+			Repo:   cfg.GitHub.Repo,
+			Token:  cfg.GitHub.Token,
+			APIURL: cfg.GitHub.APIURL,
+			AsOf:   cfg.AsOf,
+		})
+	}
+	if len(cfg.Prometheus.Metrics) > 0 {
+		providers = append(providers, &PrometheusProvider{Config: cfg.Prometheus, AsOf: cfg.AsOf})
+	}
+	providers = append(providers, &DerivedProvider{Path: cfg.DerivedPath, AsOf: cfg.AsOf})
+	return providers
+}
+
+// ProviderError records a provider that failed during collection.
+type ProviderError struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+// CollectAll runs providers and merges their points, stopping at the first
+// provider that fails.
 func CollectAll(ctx context.Context, providers []Provider) ([]MetricPoint, error) {
-	var all []MetricPoint
+	points, _, err := Collect(ctx, providers, true)
+	return points, err
+}
+
+// Collect runs providers and merges their points. Unless strict, a failing
+// provider is skipped and reported in the returned errors, so one broken
+// source (e.g. git not installed) does not block the snapshot. Derivers
+// run last, over the points of the others; the points a failing deriver
+// did compute are kept.
+func Collect(ctx context.Context, providers []Provider, strict bool) ([]MetricPoint, []ProviderError, error) {
+	points, report, err := CollectReport(ctx, providers, CollectOptions{Strict: strict})
+	if err != nil {
+		return nil, nil, err
+	}
+	return points, report.Failures(), nil
+}
+
+// CollectOptions tune CollectReport.
+type CollectOptions struct {
+	// Strict fails collection at the first failing provider.
+	Strict bool
+	// Timeout bounds each provider; 0 means no limit. A provider that
+	// overruns it fails, and its points are dropped.
+	Timeout time.Duration
+	// Concurrency is how many providers run at once (default
+	// DefaultCollectConcurrency).
+	Concurrency int
+}
+
+// DefaultCollectConcurrency is how many providers CollectReport runs at
+// once by default.
+const DefaultCollectConcurrency = 4
+
+// ProviderRun is how one provider fared during collection.
+type ProviderRun struct {
+	Provider   string `json:"provider"`
+	Points     int    `json:"points"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+}
+
+// CollectionReport lists the providers run, in run order.
+type CollectionReport struct {
+	Providers []ProviderRun `json:"providers"`
+}
+
+// Failures returns the providers that failed, for Snapshot.ProviderErrors.
+func (r *CollectionReport) Failures() []ProviderError {
+	var failures []ProviderError
+	for _, run := range r.Providers {
+		if run.Error != "" {
+			failures = append(failures, ProviderError{Provider: run.Provider, Error: run.Error})
+		}
+	}
+	return failures
+}
+
+// CollectReport is Collect with per-provider timeouts, returning a report
+// of every provider's outcome alongside the points. Providers run
+// concurrently, up to opts.Concurrency at a time, each under its own
+// context; their points and runs are merged in provider order, so the
+// result does not depend on which finishes first.
+func CollectReport(ctx context.Context, providers []Provider, opts CollectOptions) ([]MetricPoint, *CollectionReport, error) {
+	var collectors []Provider
+	var derivers []Deriver
 	for _, provider := range providers {
 		if provider == nil {
 			continue
 		}
-		points, err := provider.Collect(ctx)
+		if deriver, ok := provider.(Deriver); ok {
+			derivers = append(derivers, deriver)
+			continue
+		}
+		collectors = append(collectors, provider)
+	}
+
+	var all []MetricPoint
+	report := &CollectionReport{}
+	record := func(provider Provider, points []MetricPoint, elapsed time.Duration, err error) error {
+		run := ProviderRun{
+			Provider:   provider.Name(),
+			Points:     len(points),
+			DurationMS: elapsed.Milliseconds(),
+		}
 		if err != nil {
-			return nil, fmt.Errorf("%s provider: %w", provider.Name(), err)
+			if opts.Strict {
+				return fmt.Errorf("%s provider: %w", provider.Name(), err)
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			run.Error = err.Error()
+			run.TimedOut = errors.Is(err, errProviderTimeout)
 		}
+		report.Providers = append(report.Providers, run)
 		all = append(all, points...)
+		return nil
+	}
+
+	type result struct {
+		points  []MetricPoint
+		elapsed time.Duration
+		err     error
+	}
+	limit := opts.Concurrency
+	if limit <= 0 {
+		limit = DefaultCollectConcurrency
+	}
+	results := make([]result, len(collectors))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, provider := range collectors {
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			started := time.Now()
+			points, err := withTimeout(ctx, opts.Timeout, provider.Collect)
+			if err != nil {
+				points = nil
+			}
+			results[i] = result{points: points, elapsed: time.Since(started), err: err}
+		}(i, provider)
+	}
+	wg.Wait()
+	for i, provider := range collectors {
+		if err := record(provider, results[i].points, results[i].elapsed, results[i].err); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, deriver := range derivers {
+		started := time.Now()
+		inputs := CanonicalizePoints(all)
+		points, err := withTimeout(ctx, opts.Timeout, func(ctx context.Context) ([]MetricPoint, error) {
+			return deriver.Derive(ctx, inputs)
+		})
+		if err := record(deriver, points, time.Since(started), err); err != nil {
+			return nil, nil, err
+		}
+	}
+	return CanonicalizePoints(all), report, nil
+}
+
+var errProviderTimeout = errors.New("timed out")
+
+// withTimeout runs collect under timeout. Providers that ignore their
+// context are abandoned when it expires rather than waited for.
+func withTimeout(ctx context.Context, timeout time.Duration, collect func(context.Context) ([]MetricPoint, error)) ([]MetricPoint, error) {
+	if timeout <= 0 {
+		return collect(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type result struct {
+		points []MetricPoint
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		points, err := collect(ctx)
+		done <- result{points, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s", errProviderTimeout, timeout)
+		}
+		return r.points, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s", errProviderTimeout, timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Measure collects the default providers for cfg and writes the result as
+// the cfg.AsOf snapshot in snapshotsDir, as `kr measure` does, without
+// updating KR statuses.
+func Measure(ctx context.Context, cfg ProviderConfig, snapshotsDir string, strict bool) (*Snapshot, error) {
+	points, report, err := CollectReport(ctx, DefaultProviders(cfg), CollectOptions{Strict: strict, Timeout: cfg.ProviderTimeout, Concurrency: cfg.Concurrency})
+	if err != nil {
+		return nil, fmt.Errorf("collect metrics: %w", err)
+	}
+	snapshot := Snapshot{
+		AsOf:           cfg.AsOf.UTC().Format("2006-01-02"),
+		Points:         points,
+		ProviderErrors: report.Failures(),
+	}
+	if err := WriteSnapshot(SnapshotPathForDate(snapshotsDir, cfg.AsOf), snapshot); err != nil {
+		return nil, err
 	}
-	return CanonicalizePoints(all), nil
+	// Best-effort: `kr history --rebuild` restores the history from snapshots.
+	_ = RecordHistory(snapshotsDir, &snapshot)
+	return &snapshot, nil
 }
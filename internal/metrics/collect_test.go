@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name   string
+	points []MetricPoint
+	err    error
+}
+
+func (p fakeProvider) Name() string { return p.name }
+
+func (p fakeProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	return p.points, p.err
+}
+
+func TestCollectSkipsFailingProviders(t *testing.T) {
+	providers := []Provider{
+		fakeProvider{name: "git", err: errors.New("git: executable file not found in $PATH")},
+		fakeProvider{name: "manual", points: []MetricPoint{{Key: "manual.x", Value: 1, Timestamp: "2026-01-17T00:00:00Z", Source: "manual"}}},
+	}
+
+	points, failures, err := Collect(context.Background(), providers, false)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(points) != 1 || points[0].Key != "manual.x" {
+		t.Fatalf("points = %#v, want manual.x only", points)
+	}
+	if len(failures) != 1 || failures[0].Provider != "git" || !strings.Contains(failures[0].Error, "not found") {
+		t.Fatalf("failures = %#v, want git failure", failures)
+	}
+
+	if _, _, err := Collect(context.Background(), providers, true); err == nil || !strings.Contains(err.Error(), "git provider") {
+		t.Fatalf("strict Collect err = %v, want git provider error", err)
+	}
+}
+
+// blockingProvider ignores its context and never returns on its own.
+type blockingProvider struct{ release chan struct{} }
+
+func (p blockingProvider) Name() string { return "slow" }
+
+func (p blockingProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	<-p.release
+	return []MetricPoint{{Key: "slow.x", Value: 1}}, nil
+}
+
+func TestCollectReportTimesOutProviders(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	providers := []Provider{
+		blockingProvider{release: release},
+		fakeProvider{name: "git", err: errors.New("not a git repository")},
+		fakeProvider{name: "manual", points: []MetricPoint{{Key: "manual.x", Value: 1, Timestamp: "2026-01-17T00:00:00Z", Source: "manual"}}},
+	}
+
+	points, report, err := CollectReport(context.Background(), providers, CollectOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("CollectReport: %v", err)
+	}
+	if len(points) != 1 || points[0].Key != "manual.x" {
+		t.Fatalf("points = %#v, want manual.x only", points)
+	}
+	if len(report.Providers) != 3 {
+		t.Fatalf("report = %#v", report.Providers)
+	}
+	slow, git, manual := report.Providers[0], report.Providers[1], report.Providers[2]
+	if !slow.TimedOut || !strings.Contains(slow.Error, "timed out after 20ms") || slow.Points != 0 {
+		t.Fatalf("slow run = %#v", slow)
+	}
+	if git.TimedOut || git.Error == "" || manual.Error != "" || manual.Points != 1 {
+		t.Fatalf("runs = %#v, %#v", git, manual)
+	}
+	if failures := report.Failures(); len(failures) != 2 || failures[0].Provider != "slow" || failures[1].Provider != "git" {
+		t.Fatalf("failures = %#v", failures)
+	}
+
+	if _, _, err := CollectReport(context.Background(), providers, CollectOptions{Strict: true, Timeout: 20 * time.Millisecond}); err == nil || !strings.Contains(err.Error(), "slow provider") {
+		t.Fatalf("strict CollectReport err = %v", err)
+	}
+}
+
+// countingProvider tracks how many providers sharing active run at once.
+type countingProvider struct {
+	name   string
+	delay  time.Duration
+	active *int32
+	peak   *int32
+}
+
+func (p countingProvider) Name() string { return p.name }
+
+func (p countingProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	n := atomic.AddInt32(p.active, 1)
+	defer atomic.AddInt32(p.active, -1)
+	for {
+		peak := atomic.LoadInt32(p.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(p.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(p.delay)
+	return []MetricPoint{{Key: "shared.x", Value: float64(len(p.name)), Timestamp: "2026-01-17T00:00:00Z", Source: p.name}}, nil
+}
+
+func TestCollectReportRunsProvidersConcurrently(t *testing.T) {
+	var active, peak int32
+	var providers []Provider
+	// Later providers finish first.
+	for i, name := range []string{"a", "bb", "ccc", "dddd", "eeeee"} {
+		providers = append(providers, countingProvider{name: name, delay: time.Duration(50-10*i) * time.Millisecond, active: &active, peak: &peak})
+	}
+
+	points, report, err := CollectReport(context.Background(), providers, CollectOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("CollectReport: %v", err)
+	}
+	if peak > 2 {
+		t.Fatalf("%d providers ran at once, want at most 2", peak)
+	}
+	for i, run := range report.Providers {
+		if run.Provider != providers[i].Name() {
+			t.Fatalf("report order = %#v", report.Providers)
+		}
+	}
+	// The merge does not depend on which provider finished first.
+	serial, _, err := CollectReport(context.Background(), providers, CollectOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(points)
+	want, _ := json.Marshal(serial)
+	if string(got) != string(want) {
+		t.Fatalf("concurrent points differ from serial:\n%s\n%s", got, want)
+	}
+}
@@ -0,0 +1,448 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DerivedFileName is the spec of derived metrics in the metrics dir.
+const DerivedFileName = "derived.yml"
+
+// DerivedMetric is a metric computed from other metrics:
+//
+//	metrics:
+//	  - key: delivery.deploys_per_day
+//	    expr: ci.deploys_30d / 30
+//	    unit: per_day
+//	  - key: quality.escaped_bug_ratio
+//	    expr: tracker.bug_backlog / max(tracker.issues_closed_30d, 1)
+//
+// Expressions combine numbers and metric keys with + - * / and
+// parentheses, and the functions min, max, and abs. Inputs are the
+// undimensioned points collected by the other providers, or other derived
+// metrics.
+type DerivedMetric struct {
+	Key         string `yaml:"key"`
+	Expr        string `yaml:"expr"`
+	Unit        string `yaml:"unit,omitempty"`
+	Description string `yaml:"description,omitempty"`
+
+	expr derivedExpr
+}
+
+// Inputs returns the metric keys the expression reads, sorted.
+func (m DerivedMetric) Inputs() []string {
+	seen := map[string]bool{}
+	m.expr.refs(seen)
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoadDerived reads a derived metrics spec and returns its metrics in
+// evaluation order, so each comes after the derived metrics it reads. A
+// missing file defines none. Duplicate keys, unparsable expressions, and
+// cycles are errors.
+func LoadDerived(path string) ([]DerivedMetric, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", DerivedFileName, err)
+	}
+	var spec struct {
+		Metrics []DerivedMetric `yaml:"metrics"`
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", DerivedFileName, err)
+	}
+
+	byKey := map[string]int{}
+	for i := range spec.Metrics {
+		m := &spec.Metrics[i]
+		m.Key = strings.TrimSpace(m.Key)
+		if m.Key == "" {
+			return nil, fmt.Errorf("%s: metrics[%d]: key is required", DerivedFileName, i)
+		}
+		if _, dup := byKey[m.Key]; dup {
+			return nil, fmt.Errorf("%s: %s is defined more than once", DerivedFileName, m.Key)
+		}
+		byKey[m.Key] = i
+		if m.expr, err = parseDerivedExpr(m.Expr); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", DerivedFileName, m.Key, err)
+		}
+	}
+
+	// Depth-first topological sort over the derived-to-derived references.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(spec.Metrics))
+	ordered := make([]DerivedMetric, 0, len(spec.Metrics))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		m := spec.Metrics[i]
+		path = append(path, m.Key)
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("%s: cycle: %s", DerivedFileName, strings.Join(path, " -> "))
+		case done:
+			return nil
+		}
+		state[i] = visiting
+		for _, input := range m.Inputs() {
+			if j, ok := byKey[input]; ok {
+				if err := visit(j, path); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = done
+		ordered = append(ordered, m)
+		return nil
+	}
+	for i := range spec.Metrics {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Deriver is a provider computed from the points of the others. Collect
+// runs derivers after every other provider, passing what they collected.
+type Deriver interface {
+	Provider
+	Derive(ctx context.Context, points []MetricPoint) ([]MetricPoint, error)
+}
+
+// DerivedProvider evaluates the derived metrics defined at Path. Each
+// point's evidence lists its inputs and their values.
+type DerivedProvider struct {
+	Path string
+	AsOf time.Time
+}
+
+func (p *DerivedProvider) Name() string { return "derived" }
+
+// Collect returns nothing: derived metrics need the other providers'
+// points, which Collect passes to Derive.
+func (p *DerivedProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	return nil, nil
+}
+
+// Derive evaluates every derived metric it can. Metrics with a missing
+// input, or whose value is not a finite number, are left out and reported
+// together in the error alongside the points that were computed.
+func (p *DerivedProvider) Derive(ctx context.Context, points []MetricPoint) ([]MetricPoint, error) {
+	_ = ctx
+	defs, err := LoadDerived(p.Path)
+	if err != nil || len(defs) == 0 {
+		return nil, err
+	}
+
+	values := map[string]float64{}
+	sources := map[string]string{}
+	observed := map[string]string{}
+	for _, point := range points {
+		if len(point.Dimensions) == 0 {
+			values[point.Key] = point.Value
+			sources[point.Key] = point.Source
+			observed[point.Key] = point.ObservedAt
+		}
+	}
+
+	ts := AsOfTimestamp(p.AsOf.UTC().Truncate(24 * time.Hour))
+	var derived []MetricPoint
+	var problems []string
+	for _, m := range defs {
+		if source, ok := sources[m.Key]; ok {
+			problems = append(problems, fmt.Sprintf("%s is already produced by the %s provider", m.Key, source))
+			continue
+		}
+		var missing []string
+		evidence := []string{"expr:" + m.Expr}
+		// A derived value is as old as its oldest input.
+		oldest := ""
+		for _, input := range m.Inputs() {
+			value, ok := values[input]
+			if !ok {
+				missing = append(missing, input)
+				continue
+			}
+			if at := observed[input]; at != "" && (oldest == "" || at < oldest) {
+				oldest = at
+			}
+			evidence = append(evidence, fmt.Sprintf("input:%s=%s", input, strconv.FormatFloat(value, 'g', -1, 64)))
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: missing input %s", m.Key, strings.Join(missing, ", ")))
+			continue
+		}
+		value := m.expr.eval(values)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			problems = append(problems, fmt.Sprintf("%s: %s is not a number (division by zero?)", m.Key, m.Expr))
+			continue
+		}
+		values[m.Key] = value
+		sources[m.Key] = p.Name()
+		observed[m.Key] = oldest
+		derived = append(derived, MetricPoint{
+			Key:        m.Key,
+			Value:      value,
+			Unit:       m.Unit,
+			Timestamp:  ts,
+			Source:     p.Name(),
+			Evidence:   evidence,
+			ObservedAt: oldest,
+		})
+	}
+	if len(problems) > 0 {
+		return derived, errors.New(strings.Join(problems, "; "))
+	}
+	return derived, nil
+}
+
+// derivedExpr is a parsed expression.
+type derivedExpr interface {
+	eval(values map[string]float64) float64
+	refs(seen map[string]bool)
+}
+
+type (
+	numberExpr float64
+	refExpr    string
+	negExpr    struct{ x derivedExpr }
+	binaryExpr struct {
+		op   byte
+		l, r derivedExpr
+	}
+	callExpr struct {
+		fn   string
+		args []derivedExpr
+	}
+)
+
+func (e numberExpr) eval(map[string]float64) float64 { return float64(e) }
+func (e numberExpr) refs(map[string]bool)            {}
+
+func (e refExpr) eval(values map[string]float64) float64 { return values[string(e)] }
+func (e refExpr) refs(seen map[string]bool)              { seen[string(e)] = true }
+
+func (e negExpr) eval(values map[string]float64) float64 { return -e.x.eval(values) }
+func (e negExpr) refs(seen map[string]bool)              { e.x.refs(seen) }
+
+func (e binaryExpr) eval(values map[string]float64) float64 {
+	l, r := e.l.eval(values), e.r.eval(values)
+	switch e.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	default:
+		if r == 0 {
+			return math.NaN()
+		}
+		return l / r
+	}
+}
+
+func (e binaryExpr) refs(seen map[string]bool) {
+	e.l.refs(seen)
+	e.r.refs(seen)
+}
+
+func (e callExpr) eval(values map[string]float64) float64 {
+	result := e.args[0].eval(values)
+	for _, arg := range e.args[1:] {
+		v := arg.eval(values)
+		if e.fn == "min" {
+			result = math.Min(result, v)
+		} else {
+			result = math.Max(result, v)
+		}
+	}
+	if e.fn == "abs" {
+		return math.Abs(result)
+	}
+	return result
+}
+
+func (e callExpr) refs(seen map[string]bool) {
+	for _, arg := range e.args {
+		arg.refs(seen)
+	}
+}
+
+// derivedParser is a recursive-descent parser for
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | key | func "(" expr { "," expr } ")" | "(" expr ")"
+type derivedParser struct {
+	src string
+	pos int
+}
+
+func parseDerivedExpr(src string) (derivedExpr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("expr is required")
+	}
+	p := &derivedParser{src: src}
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+	}
+	return e, nil
+}
+
+func (p *derivedParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end.
+func (p *derivedParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *derivedParser) expr() (derivedExpr, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *derivedParser) term() (derivedExpr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *derivedParser) unary() (derivedExpr, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negExpr{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *derivedParser) primary() (derivedExpr, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at offset %d", p.pos)
+		}
+		p.pos++
+		return e, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return numberExpr(v), nil
+	case isKeyByte(c) && !(c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.src) && isKeyByte(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() != '(' {
+			return refExpr(name), nil
+		}
+		if name != "min" && name != "max" && name != "abs" {
+			return nil, fmt.Errorf("unknown function %s (use min, max, or abs)", name)
+		}
+		p.pos++
+		var args []derivedExpr
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) after %s arguments", name)
+		}
+		p.pos++
+		if name == "abs" && len(args) != 1 {
+			return nil, fmt.Errorf("abs takes one argument")
+		}
+		return callExpr{fn: name, args: args}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+	}
+}
+
+// isKeyByte reports whether c may appear in a metric key.
+func isKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeDerived(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DerivedFileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseDerivedExpr(t *testing.T) {
+	values := map[string]float64{"ci.deploys_30d": 12, "a_b.c": 3}
+	cases := map[string]float64{
+		"ci.deploys_30d / 30":          0.4,
+		"1 + 2 * 3":                    7,
+		"(1 + 2) * 3":                  9,
+		"-a_b.c - -1":                  -2,
+		"max(a_b.c, 10, 2) / min(4,8)": 2.5,
+		"abs(1 - a_b.c)":               2,
+		"1.5*2":                        3,
+	}
+	for src, want := range cases {
+		e, err := parseDerivedExpr(src)
+		if err != nil {
+			t.Errorf("parse %q: %v", src, err)
+			continue
+		}
+		if got := e.eval(values); got != want {
+			t.Errorf("%q = %v, want %v", src, got, want)
+		}
+	}
+	for _, src := range []string{"", "1 +", "(1", "sqrt(4)", "1 $ 2", "abs(1, 2)", "1 2"} {
+		if _, err := parseDerivedExpr(src); err == nil {
+			t.Errorf("parse %q: expected error", src)
+		}
+	}
+}
+
+func TestLoadDerivedOrdersAndRejectsCycles(t *testing.T) {
+	defs, err := LoadDerived(writeDerived(t, `metrics:
+  - key: b
+    expr: a * 2
+  - key: a
+    expr: x + 1
+`))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(defs) != 2 || defs[0].Key != "a" || defs[1].Key != "b" {
+		t.Fatalf("order = %+v", defs)
+	}
+
+	_, err = LoadDerived(writeDerived(t, `metrics:
+  - key: a
+    expr: c + 1
+  - key: b
+    expr: a * 2
+  - key: c
+    expr: b / 2
+`))
+	if err == nil || !strings.Contains(err.Error(), "cycle: a -> c -> b -> a") {
+		t.Fatalf("cycle error = %v", err)
+	}
+	if _, err := LoadDerived(writeDerived(t, "metrics:\n  - key: a\n    expr: a + 1\n")); err == nil {
+		t.Fatal("expected error for a self-reference")
+	}
+	if _, err := LoadDerived(writeDerived(t, "metrics:\n  - key: a\n    expr: 1\n  - key: a\n    expr: 2\n")); err == nil {
+		t.Fatal("expected error for a duplicate key")
+	}
+	if defs, err := LoadDerived(filepath.Join(t.TempDir(), "missing.yml")); err != nil || defs != nil {
+		t.Fatalf("missing spec = %v, %v", defs, err)
+	}
+}
+
+func TestCollectDerivedMetrics(t *testing.T) {
+	path := writeDerived(t, `metrics:
+  - key: delivery.deploys_per_week
+    expr: delivery.deploys_per_day * 7
+  - key: delivery.deploys_per_day
+    expr: ci.deploys_30d / 30
+    unit: per_day
+  - key: quality.bug_ratio
+    expr: tracker.bug_backlog / tracker.issues_closed_30d
+  - key: quality.escapes
+    expr: tracker.escaped_bugs + 1
+`)
+	source := staticProvider{name: "ci", points: []MetricPoint{
+		{Key: "ci.deploys_30d", Value: 15, Source: "ci"},
+		{Key: "tracker.bug_backlog", Value: 4, Source: "tracker"},
+		{Key: "tracker.issues_closed_30d", Value: 0, Source: "tracker"},
+	}}
+	derived := &DerivedProvider{Path: path, AsOf: time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC)}
+
+	points, failures, err := Collect(context.Background(), []Provider{derived, source}, false)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	values := map[string]MetricPoint{}
+	for _, p := range points {
+		values[p.Key] = p
+	}
+	perDay := values["delivery.deploys_per_day"]
+	if perDay.Value != 0.5 || perDay.Unit != "per_day" || perDay.Source != "derived" {
+		t.Fatalf("deploys_per_day = %+v", perDay)
+	}
+	if strings.Join(perDay.Evidence, ",") != "expr:ci.deploys_30d / 30,input:ci.deploys_30d=15" {
+		t.Fatalf("evidence = %v", perDay.Evidence)
+	}
+	if values["delivery.deploys_per_week"].Value != 3.5 {
+		t.Fatalf("deploys_per_week = %+v", values["delivery.deploys_per_week"])
+	}
+	if _, ok := values["quality.bug_ratio"]; ok {
+		t.Fatal("division by zero produced a point")
+	}
+	if len(failures) != 1 || failures[0].Provider != "derived" ||
+		!strings.Contains(failures[0].Error, "quality.escapes: missing input tracker.escaped_bugs") ||
+		!strings.Contains(failures[0].Error, "quality.bug_ratio") {
+		t.Fatalf("failures = %+v", failures)
+	}
+
+	if _, _, err := Collect(context.Background(), []Provider{source, derived}, true); err == nil {
+		t.Fatal("expected strict collection to fail")
+	}
+}
+
+type staticProvider struct {
+	name   string
+	points []MetricPoint
+}
+
+func (p staticProvider) Name() string { return p.name }
+
+func (p staticProvider) Collect(context.Context) ([]MetricPoint, error) { return p.points, nil }
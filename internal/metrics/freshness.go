@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"okrchestra/internal/config"
+	"okrchestra/internal/okrstore"
+)
+
+// FreshnessPolicy decides when a metric is too old to score a KR by.
+type FreshnessPolicy struct {
+	// MaxAge is the default age limit; 0 disables the check.
+	MaxAge time.Duration
+	// MaxAgeByKey overrides MaxAge for single metrics, from the metric
+	// catalog.
+	MaxAgeByKey map[string]time.Duration
+	// Exclude leaves stale values out of the score instead of only
+	// flagging them.
+	Exclude bool
+}
+
+// LoadFreshnessPolicy reads the policy from the metrics section of the
+// workspace's okrchestra.yml and the max_age overrides of the metric
+// catalog in okrsDir.
+func LoadFreshnessPolicy(root, okrsDir string) (FreshnessPolicy, error) {
+	wsCfg, err := config.Load(root)
+	if err != nil {
+		return FreshnessPolicy{}, err
+	}
+	rules, err := okrstore.LoadRules(okrsDir)
+	if err != nil {
+		return FreshnessPolicy{}, err
+	}
+	policy := FreshnessPolicy{MaxAge: wsCfg.Metrics.MaxAge, Exclude: wsCfg.Metrics.Stale == "exclude"}
+	for key, spec := range rules.Metrics {
+		if spec.MaxAge > 0 {
+			if policy.MaxAgeByKey == nil {
+				policy.MaxAgeByKey = map[string]time.Duration{}
+			}
+			policy.MaxAgeByKey[key] = spec.MaxAge
+		}
+	}
+	return policy, nil
+}
+
+func (p FreshnessPolicy) maxAge(key string) time.Duration {
+	if age, ok := p.MaxAgeByKey[key]; ok && age > 0 {
+		return age
+	}
+	return p.MaxAge
+}
+
+// StaleMetric is a metric observed longer ago than its max age allows.
+type StaleMetric struct {
+	Key        string
+	ObservedAt time.Time
+	Age        time.Duration
+	MaxAge     time.Duration
+}
+
+// StaleMetrics returns the undimensioned metrics in snapshot that are stale
+// under policy, sorted by key. Ages are measured to the end of the
+// snapshot's as-of day, so points taken that day are always fresh.
+func StaleMetrics(snapshot *Snapshot, policy FreshnessPolicy) ([]StaleMetric, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	asOf, err := time.Parse("2006-01-02", snapshot.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot as_of: %w", err)
+	}
+	end := asOf.AddDate(0, 0, 1)
+
+	var stale []StaleMetric
+	for _, point := range snapshot.Points {
+		if point.Key == "" || len(point.Dimensions) > 0 {
+			continue
+		}
+		maxAge := policy.maxAge(point.Key)
+		if maxAge <= 0 {
+			continue
+		}
+		observed, ok := point.Observed()
+		if !ok {
+			continue
+		}
+		if age := end.Sub(observed); age > maxAge {
+			stale = append(stale, StaleMetric{Key: point.Key, ObservedAt: observed.UTC(), Age: age, MaxAge: maxAge})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Key < stale[j].Key })
+	return stale, nil
+}
+
+// ApplyFreshness marks the KRs in report whose metric is stale in snapshot
+// and lists the stale keys in the report. Under an Exclude policy their
+// current value and percent-to-target are cleared, as for a missing metric,
+// and the alignment rollups over store are recomputed without them.
+func ApplyFreshness(report *KRScoreReport, store *okrstore.Store, snapshot *Snapshot, policy FreshnessPolicy) error {
+	if report == nil {
+		return nil
+	}
+	stale, err := StaleMetrics(snapshot, policy)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]StaleMetric, len(stale))
+	for _, metric := range stale {
+		byKey[metric.Key] = metric
+	}
+
+	used := map[string]bool{}
+	for i := range report.Results {
+		result := &report.Results[i]
+		metric, ok := byKey[result.MetricKey]
+		if !ok || result.Current == nil {
+			continue
+		}
+		result.Stale = true
+		result.ObservedAt = metric.ObservedAt.Format(time.RFC3339)
+		if policy.Exclude {
+			result.Current = nil
+			result.PercentToTarget = 0
+		}
+		used[metric.Key] = true
+	}
+	report.StaleMetricKeys = nil
+	for _, metric := range stale {
+		if used[metric.Key] {
+			report.StaleMetricKeys = append(report.StaleMetricKeys, metric.Key)
+		}
+	}
+	if policy.Exclude && len(used) > 0 && store != nil {
+		report.Rollups = alignmentRollups(store, report.Results)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/internal/okrstore"
+)
+
+func TestManualProviderObservedAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manual.yml")
+	content := `metrics:
+  - key: manual.nps
+    value: 41
+    observed_at: 2025-01-02
+  - key: manual.csat
+    value: 4.2
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	points, err := (&ManualProvider{Path: path, AsOf: time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC)}).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	observed := map[string]string{}
+	for _, p := range points {
+		observed[p.Key] = p.ObservedAt
+	}
+	if observed["manual.nps"] != "2025-01-02T00:00:00Z" || observed["manual.csat"] != "2025-01-20T08:00:00Z" {
+		t.Fatalf("observed_at = %v", observed)
+	}
+
+	if err := os.WriteFile(path, []byte("metrics:\n  - key: manual.nps\n    value: 1\n    observed_at: last week\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&ManualProvider{Path: path}).Collect(context.Background()); err == nil {
+		t.Fatal("expected error for an unparsable observed_at")
+	}
+}
+
+func TestDerivedObservedAtIsOldestInput(t *testing.T) {
+	derived := &DerivedProvider{Path: writeDerived(t, "metrics:\n  - key: ratio\n    expr: a / b\n")}
+	points, err := derived.Derive(context.Background(), []MetricPoint{
+		{Key: "a", Value: 1, ObservedAt: "2025-01-20T00:00:00Z"},
+		{Key: "b", Value: 2, ObservedAt: "2025-01-05T00:00:00Z"},
+	})
+	if err != nil || len(points) != 1 || points[0].ObservedAt != "2025-01-05T00:00:00Z" {
+		t.Fatalf("derived = %+v, %v", points, err)
+	}
+}
+
+func TestApplyFreshness(t *testing.T) {
+	okrsDir := filepath.Join(t.TempDir(), "okrs")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	okrsYAML := `scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Objective
+    key_results:
+      - kr_id: KR-1
+        description: Coverage
+        owner_id: team
+        metric_key: coverage.pct
+        baseline: 50
+        target: 80
+        confidence: 0.5
+        status: in_progress
+        evidence: []
+      - kr_id: KR-2
+        description: NPS
+        owner_id: team
+        metric_key: manual.nps
+        baseline: 20
+        target: 40
+        confidence: 0.5
+        status: in_progress
+        evidence: []
+      - kr_id: KR-3
+        description: Commits
+        owner_id: team
+        metric_key: git.commits_30d
+        baseline: 10
+        target: 20
+        confidence: 0.5
+        status: in_progress
+        evidence: []
+`
+	if err := os.WriteFile(filepath.Join(okrsDir, "org.yml"), []byte(okrsYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := okrstore.LoadFromDir(okrsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &Snapshot{
+		SchemaVersion: SnapshotSchemaVersion,
+		AsOf:          "2025-01-30",
+		Points: []MetricPoint{
+			// 20 days old.
+			{Key: "coverage.pct", Value: 65, Timestamp: "2025-01-30T00:00:00Z", ObservedAt: "2025-01-11T00:00:00Z", Source: "coverage"},
+			// 5 days old.
+			{Key: "manual.nps", Value: 30, Timestamp: "2025-01-30T00:00:00Z", ObservedAt: "2025-01-26T00:00:00Z", Source: "manual"},
+			// Computed for the snapshot.
+			{Key: "git.commits_30d", Value: 15, Timestamp: "2025-01-30T00:00:00Z", Source: "git"},
+		},
+	}
+	policy := FreshnessPolicy{MaxAge: 14 * 24 * time.Hour, MaxAgeByKey: map[string]time.Duration{"manual.nps": 72 * time.Hour}}
+
+	report, err := ScoreKRs(store, snapshot, "snap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyFreshness(report, store, snapshot, policy); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.StaleMetricKeys) != 2 || report.StaleMetricKeys[0] != "coverage.pct" || report.StaleMetricKeys[1] != "manual.nps" {
+		t.Fatalf("stale keys = %v", report.StaleMetricKeys)
+	}
+	kr1, kr3 := report.Results[0], report.Results[2]
+	if !kr1.Stale || kr1.ObservedAt != "2025-01-11T00:00:00Z" || kr1.Current == nil || kr1.PercentToTarget != 50 {
+		t.Fatalf("flagged KR-1 = %+v", kr1)
+	}
+	if kr3.Stale {
+		t.Fatalf("KR-3 measured on the as-of day is stale: %+v", kr3)
+	}
+
+	policy.Exclude = true
+	report, _ = ScoreKRs(store, snapshot, "snap.json")
+	if err := ApplyFreshness(report, store, snapshot, policy); err != nil {
+		t.Fatal(err)
+	}
+	if kr1 := report.Results[0]; !kr1.Stale || kr1.Current != nil || kr1.PercentToTarget != 0 {
+		t.Fatalf("excluded KR-1 = %+v", kr1)
+	}
+
+	report, _ = ScoreKRs(store, snapshot, "snap.json")
+	if err := ApplyFreshness(report, store, snapshot, FreshnessPolicy{}); err != nil || report.StaleMetricKeys != nil {
+		t.Fatalf("disabled policy = %v, %v", report.StaleMetricKeys, err)
+	}
+}
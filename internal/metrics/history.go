@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// HistoryFileName is the metric history DB inside the snapshots dir.
+const HistoryFileName = "history.sqlite"
+
+// HistoryPoint is one snapshot value of a metric. Dimensions is the
+// canonical "key=value;key=value" form, empty for the undimensioned point
+// KRs are scored on.
+type HistoryPoint struct {
+	AsOf       string  `json:"as_of"`
+	Key        string  `json:"key"`
+	Value      float64 `json:"value"`
+	Unit       string  `json:"unit,omitempty"`
+	Source     string  `json:"source"`
+	Dimensions string  `json:"dimensions,omitempty"`
+}
+
+// History is a queryable copy of the daily snapshots, so values can be
+// compared across days without reading every snapshot file. The snapshot
+// files stay the source of truth; Rebuild re-imports them.
+type History struct {
+	SnapshotsDir string
+	db           *sql.DB
+}
+
+// HistoryPath returns the history DB for a snapshots dir.
+func HistoryPath(snapshotsDir string) string {
+	return filepath.Join(snapshotsDir, HistoryFileName)
+}
+
+// OpenHistory opens the history for snapshotsDir. A new history imports
+// the snapshots already in the dir.
+func OpenHistory(snapshotsDir string) (*History, error) {
+	abs, err := filepath.Abs(snapshotsDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve snapshots dir: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure snapshots dir: %w", err)
+	}
+	_, statErr := os.Stat(HistoryPath(abs))
+	db, err := sql.Open("sqlite", HistoryPath(abs)+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open metric history: %w", err)
+	}
+	h := &History{SnapshotsDir: abs, db: db}
+	if err := h.ensureSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		if _, err := h.Rebuild(); err != nil {
+			db.Close()
+			// Leave no empty history behind, so the import is retried.
+			_ = os.Remove(HistoryPath(abs))
+			return nil, fmt.Errorf("import snapshots into history: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// Close closes the history DB.
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+func (h *History) ensureSchema() error {
+	_, err := h.db.Exec(`
+		CREATE TABLE IF NOT EXISTS metric_history (
+			as_of TEXT NOT NULL,
+			key TEXT NOT NULL,
+			dimensions TEXT NOT NULL DEFAULT '',
+			value REAL NOT NULL,
+			unit TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_metric_history_key ON metric_history(key, as_of);
+		CREATE INDEX IF NOT EXISTS idx_metric_history_as_of ON metric_history(as_of);
+	`)
+	if err != nil {
+		return fmt.Errorf("create metric history schema: %w", err)
+	}
+	return nil
+}
+
+// Record replaces the history for the snapshot's as-of date with its
+// points and returns how many were recorded.
+func (h *History) Record(snapshot *Snapshot) (int, error) {
+	if snapshot == nil || snapshot.AsOf == "" {
+		return 0, fmt.Errorf("snapshot as_of is required")
+	}
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin history update: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`DELETE FROM metric_history WHERE as_of = ?`, snapshot.AsOf); err != nil {
+		return 0, fmt.Errorf("clear %s history: %w", snapshot.AsOf, err)
+	}
+	for _, point := range snapshot.Points {
+		_, err := tx.Exec(`
+			INSERT INTO metric_history (as_of, key, dimensions, value, unit, source)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, snapshot.AsOf, point.Key, dimensionsKey(CanonicalizeDimensions(point.Dimensions)), point.Value, point.Unit, point.Source)
+		if err != nil {
+			return 0, fmt.Errorf("record %s: %w", point.Key, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit history update: %w", err)
+	}
+	return len(snapshot.Points), nil
+}
+
+// Rebuild re-imports every snapshot in the snapshots dir and returns how
+// many snapshots were recorded. Dates whose snapshot file is gone are
+// dropped.
+func (h *History) Rebuild() (int, error) {
+	paths, err := SnapshotPaths(h.SnapshotsDir)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := h.db.Exec(`DELETE FROM metric_history`); err != nil {
+		return 0, fmt.Errorf("clear metric history: %w", err)
+	}
+	count := 0
+	for _, path := range paths {
+		snapshot, err := LoadSnapshot(path)
+		if err != nil {
+			return count, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if _, err := h.Record(snapshot); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Query returns a metric's values from since (YYYY-MM-DD, inclusive; empty
+// for all history), oldest first. Dimensioned series follow the
+// undimensioned value within each date.
+func (h *History) Query(key, since string) ([]HistoryPoint, error) {
+	if strings.TrimSpace(key) == "" {
+		return nil, fmt.Errorf("metric key is required")
+	}
+	rows, err := h.db.Query(`
+		SELECT as_of, key, dimensions, value, unit, source FROM metric_history
+		WHERE key = ? AND as_of >= ?
+		ORDER BY as_of, dimensions, source
+	`, key, since)
+	if err != nil {
+		return nil, fmt.Errorf("query metric history: %w", err)
+	}
+	defer rows.Close()
+	var points []HistoryPoint
+	for rows.Next() {
+		var p HistoryPoint
+		if err := rows.Scan(&p.AsOf, &p.Key, &p.Dimensions, &p.Value, &p.Unit, &p.Source); err != nil {
+			return nil, fmt.Errorf("scan metric history: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read metric history: %w", err)
+	}
+	return points, nil
+}
+
+// RecordHistory adds a freshly written snapshot to the history in its
+// snapshots dir.
+func RecordHistory(snapshotsDir string, snapshot *Snapshot) error {
+	h, err := OpenHistory(snapshotsDir)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	_, err = h.Record(snapshot)
+	return err
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordQueryAndRebuild(t *testing.T) {
+	dir := t.TempDir()
+	for i, value := range []float64{80, 85, 90} {
+		asOf := time.Date(2025, 1, 5+i, 0, 0, 0, 0, time.UTC)
+		snapshot := Snapshot{
+			AsOf: asOf.Format("2006-01-02"),
+			Points: []MetricPoint{
+				{Key: "ci.pass_rate", Value: value, Unit: "percent", Timestamp: AsOfTimestamp(asOf), Source: "ci"},
+				{Key: "ci.pass_rate", Value: value - 10, Timestamp: AsOfTimestamp(asOf), Source: "ci", Dimensions: []Dimension{{Key: "suite", Value: "e2e"}}},
+				{Key: "git.commits", Value: 3, Timestamp: AsOfTimestamp(asOf), Source: "git"},
+			},
+		}
+		if err := WriteSnapshot(SnapshotPathForDate(dir, asOf), snapshot); err != nil {
+			t.Fatalf("write snapshot: %v", err)
+		}
+		if i < 2 {
+			if err := RecordHistory(dir, &snapshot); err != nil {
+				t.Fatalf("record: %v", err)
+			}
+		}
+	}
+
+	history, err := OpenHistory(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer history.Close()
+
+	points, err := history.Query("ci.pass_rate", "")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(points) != 4 {
+		t.Fatalf("expected 4 points before rebuild, got %+v", points)
+	}
+	if points[0].AsOf != "2025-01-05" || points[0].Value != 80 || points[0].Dimensions != "" || points[1].Dimensions != "suite=e2e" {
+		t.Fatalf("unexpected ordering: %+v", points)
+	}
+
+	// Re-recording a date replaces it rather than duplicating points.
+	if _, err := history.Record(&Snapshot{AsOf: "2025-01-06", Points: []MetricPoint{{Key: "ci.pass_rate", Value: 86, Source: "ci"}}}); err != nil {
+		t.Fatalf("re-record: %v", err)
+	}
+	points, _ = history.Query("ci.pass_rate", "2025-01-06")
+	if len(points) != 1 || points[0].Value != 86 {
+		t.Fatalf("expected replaced date, got %+v", points)
+	}
+
+	n, err := history.Rebuild()
+	if err != nil || n != 3 {
+		t.Fatalf("rebuild = %d, %v", n, err)
+	}
+	points, _ = history.Query("ci.pass_rate", "2025-01-06")
+	if len(points) != 4 || points[0].Value != 85 || points[2].Value != 90 || points[2].Unit != "percent" {
+		t.Fatalf("unexpected rebuilt history: %+v", points)
+	}
+	if _, err := history.Query("", ""); err == nil {
+		t.Fatal("expected error for empty key")
+	}
+	if got := HistoryPath(dir); got != filepath.Join(dir, HistoryFileName) {
+		t.Fatalf("history path = %s", got)
+	}
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IntakeFileName is the append-only log of pushed metric points, stored in
+// the metrics dir.
+const IntakeFileName = "intake.jsonl"
+
+// IntakeSource is the source recorded for pushed points that name none.
+const IntakeSource = "ingest"
+
+// IntakePath returns the intake log for a metrics dir.
+func IntakePath(metricsDir string) string {
+	return filepath.Join(metricsDir, IntakeFileName)
+}
+
+// ErrInvalidPoint marks pushed points rejected by validation.
+var ErrInvalidPoint = errors.New("invalid metric point")
+
+// intakeMu serializes appends within a process; each batch is also written
+// with a single O_APPEND write so concurrent processes do not interleave.
+var intakeMu sync.Mutex
+
+// AppendIntake validates points and appends them to the intake log. A
+// point without a timestamp is stamped with now; one without a source gets
+// IntakeSource. It returns the stored points.
+func AppendIntake(path string, points []MetricPoint, now time.Time) ([]MetricPoint, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: no metric points", ErrInvalidPoint)
+	}
+	var buf bytes.Buffer
+	stored := make([]MetricPoint, 0, len(points))
+	for i, point := range points {
+		point, err := normalizeIntakePoint(point, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: point %d: %v", ErrInvalidPoint, i, err)
+		}
+		line, err := json.Marshal(point)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %w", i, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		stored = append(stored, point)
+	}
+
+	intakeMu.Lock()
+	defer intakeMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ensure metrics dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open intake: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("append intake: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("sync intake: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("close intake: %w", err)
+	}
+	return stored, nil
+}
+
+// DecodeIntake reads a push request body: either a JSON array of metric
+// points or an object of the form {"points": [...]}.
+func DecodeIntake(r io.Reader) ([]MetricPoint, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	var points []MetricPoint
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &points)
+	} else {
+		var body struct {
+			Points []MetricPoint `json:"points"`
+		}
+		err = json.Unmarshal(data, &body)
+		points = body.Points
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode metric points: %w", err)
+	}
+	return points, nil
+}
+
+func normalizeIntakePoint(point MetricPoint, now time.Time) (MetricPoint, error) {
+	point.Key = strings.TrimSpace(point.Key)
+	if point.Key == "" {
+		return point, fmt.Errorf("key is required")
+	}
+	if math.IsNaN(point.Value) || math.IsInf(point.Value, 0) {
+		return point, fmt.Errorf("%s: value must be a finite number", point.Key)
+	}
+	if point.Timestamp == "" {
+		point.Timestamp = now.UTC().Format(time.RFC3339)
+	} else {
+		ts, err := time.Parse(time.RFC3339, point.Timestamp)
+		if err != nil {
+			return point, fmt.Errorf("%s: timestamp must be RFC3339: %q", point.Key, point.Timestamp)
+		}
+		point.Timestamp = ts.UTC().Format(time.RFC3339)
+	}
+	if strings.TrimSpace(point.Source) == "" {
+		point.Source = IntakeSource
+	}
+	point.Evidence = canonicalizeStrings(point.Evidence)
+	point.Dimensions = CanonicalizeDimensions(point.Dimensions)
+	return point, nil
+}
+
+// IntakeProvider merges pushed points into snapshots: for each key and
+// dimension set, the latest point stamped no later than the end of the
+// as-of day. Points keep their pushed timestamps.
+type IntakeProvider struct {
+	Path string
+	AsOf time.Time
+}
+
+func (p *IntakeProvider) Name() string { return "intake" }
+
+func (p *IntakeProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	f, err := os.Open(p.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open intake: %w", err)
+	}
+	defer f.Close()
+
+	cutoff := p.AsOf.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	latest := map[string]MetricPoint{}
+	var order []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var point MetricPoint
+		if err := json.Unmarshal(raw, &point); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", IntakeFileName, line, err)
+		}
+		ts, err := time.Parse(time.RFC3339, point.Timestamp)
+		if err != nil || !ts.Before(cutoff) {
+			continue
+		}
+		series := point.Key + "\x00" + dimensionsKey(point.Dimensions)
+		prev, seen := latest[series]
+		if !seen {
+			order = append(order, series)
+		}
+		// Later lines win ties, so a correction pushed for the same time
+		// replaces the original.
+		if !seen || prev.Timestamp <= point.Timestamp {
+			latest[series] = point
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read intake: %w", err)
+	}
+
+	points := make([]MetricPoint, 0, len(order))
+	for _, series := range order {
+		points = append(points, latest[series])
+	}
+	return points, nil
+}
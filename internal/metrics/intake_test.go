@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntakeAppendAndCollect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics", IntakeFileName)
+	now := time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC)
+
+	points, err := DecodeIntake(strings.NewReader(`{"points":[
+		{"key":"sales.signups","value":10,"timestamp":"2025-01-05T10:00:00Z"},
+		{"key":"sales.signups","value":12,"timestamp":"2025-01-06T10:00:00+02:00","dimensions":[{"key":"region","value":"emea"}]},
+		{"key":"sales.signups","value":15,"timestamp":"2025-01-06T23:00:00Z","source":"crm"}]}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, err := AppendIntake(path, points, now); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	later, err := DecodeIntake(strings.NewReader(`[{"key":"sales.signups","value":99},{"key":"ops.pages","value":3}]`))
+	if err != nil {
+		t.Fatalf("decode array: %v", err)
+	}
+	stored, err := AppendIntake(path, later, now)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if stored[0].Timestamp != "2025-01-07T12:00:00Z" || stored[0].Source != IntakeSource {
+		t.Fatalf("defaults not applied: %+v", stored[0])
+	}
+
+	// As of Jan 6 the points pushed on Jan 7 are excluded, and the later
+	// un-dimensioned point wins.
+	got, err := (&IntakeProvider{Path: path, AsOf: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)}).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 points, got %+v", got)
+	}
+	if got[0].Value != 15 || got[0].Source != "crm" || got[0].Timestamp != "2025-01-06T23:00:00Z" {
+		t.Fatalf("unexpected latest point: %+v", got[0])
+	}
+	if got[1].Value != 12 || got[1].Timestamp != "2025-01-06T08:00:00Z" || len(got[1].Dimensions) != 1 {
+		t.Fatalf("unexpected dimensioned point: %+v", got[1])
+	}
+
+	got, err = (&IntakeProvider{Path: path, AsOf: now}).Collect(context.Background())
+	if err != nil || len(got) != 3 || got[0].Value != 99 {
+		t.Fatalf("collect as of push day: %+v, %v", got, err)
+	}
+
+	if got, err := (&IntakeProvider{Path: filepath.Join(t.TempDir(), "missing.jsonl"), AsOf: now}).Collect(context.Background()); err != nil || got != nil {
+		t.Fatalf("missing intake = %v, %v", got, err)
+	}
+}
+
+func TestAppendIntakeRejectsInvalidPoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IntakeFileName)
+	now := time.Now()
+	for name, points := range map[string][]MetricPoint{
+		"empty":     nil,
+		"no key":    {{Value: 1}},
+		"timestamp": {{Key: "a", Value: 1, Timestamp: "yesterday"}},
+	} {
+		if _, err := AppendIntake(path, points, now); !errors.Is(err, ErrInvalidPoint) {
+			t.Errorf("%s: expected ErrInvalidPoint, got %v", name, err)
+		}
+	}
+	if got, err := (&IntakeProvider{Path: path, AsOf: now}).Collect(context.Background()); err != nil || len(got) != 0 {
+		t.Fatalf("rejected batches were written: %+v, %v", got, err)
+	}
+}
@@ -61,6 +61,7 @@ func (p *CIProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
 	}
 	sort.Strings(keys)
 
+	observed := fileObservedAt(p.ReportPath)
 	points := make([]MetricPoint, 0, len(keys))
 	for _, k := range keys {
 		value, ok := toFloat64(metrics[k])
@@ -68,11 +69,12 @@ func (p *CIProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
 			continue
 		}
 		points = append(points, MetricPoint{
-			Key:       "ci." + k,
-			Value:     value,
-			Unit:      inferCIUnit(k),
-			Timestamp: ts,
-			Source:    p.Name(),
+			Key:        "ci." + k,
+			Value:      value,
+			Unit:       inferCIUnit(k),
+			Timestamp:  ts,
+			Source:     p.Name(),
+			ObservedAt: observed,
 		})
 	}
 	return points, nil
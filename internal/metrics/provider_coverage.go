@@ -0,0 +1,347 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CoverageProvider reads test reports from Path, a report file or a
+// directory of them, and reports:
+//
+//	coverage.pct  covered statements or lines as a percentage (0-100)
+//	tests.count   tests run, skipped ones excluded
+//
+// Coverage comes from Go cover profiles (`go test -coverprofile`), lcov
+// tracefiles, and Cobertura XML; test counts from `go test -json` output
+// and JUnit XML. Formats are detected from file contents, totals are
+// summed across files, and the files read are the points' evidence.
+type CoverageProvider struct {
+	Path string
+	AsOf time.Time
+}
+
+func (p *CoverageProvider) Name() string { return "coverage" }
+
+// coverageTotals accumulates one or more reports.
+type coverageTotals struct {
+	covered, total float64
+	tests          int
+	coverageFiles  []string
+	testFiles      []string
+	hasCoverage    bool
+	hasTests       bool
+}
+
+func (p *CoverageProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	_ = ctx
+
+	path := p.Path
+	if path == "" {
+		path = filepath.Join("metrics", "coverage")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stat coverage path: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read coverage dir: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	var totals coverageTotals
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read coverage report: %w", err)
+		}
+		if err := totals.add(file, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	ts := AsOfTimestamp(p.AsOf.UTC().Truncate(24 * time.Hour))
+	var points []MetricPoint
+	if totals.hasCoverage && totals.total > 0 {
+		points = append(points, MetricPoint{
+			Key:        "coverage.pct",
+			Value:      math.Round(10000*totals.covered/totals.total) / 100,
+			Unit:       "percent",
+			Timestamp:  ts,
+			Source:     p.Name(),
+			Evidence:   totals.coverageFiles,
+			ObservedAt: oldestObservedAt(totals.coverageFiles),
+		})
+	}
+	if totals.hasTests {
+		points = append(points, MetricPoint{
+			Key:        "tests.count",
+			Value:      float64(totals.tests),
+			Unit:       "count",
+			Timestamp:  ts,
+			Source:     p.Name(),
+			Evidence:   totals.testFiles,
+			ObservedAt: oldestObservedAt(totals.testFiles),
+		})
+	}
+	return points, nil
+}
+
+// oldestObservedAt returns the modification time of the oldest file, so a
+// stale report is not hidden by a fresh one next to it.
+func oldestObservedAt(files []string) string {
+	oldest := ""
+	for _, file := range files {
+		if observed := fileObservedAt(file); observed != "" && (oldest == "" || observed < oldest) {
+			oldest = observed
+		}
+	}
+	return oldest
+}
+
+// add detects the format of one report and adds it to the totals. Files
+// in no known format are ignored, so a reports directory may hold others.
+func (t *coverageTotals) add(file string, data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		covered, total, err := parseGoCoverProfile(trimmed)
+		if err != nil {
+			return err
+		}
+		t.addCoverage(file, covered, total)
+	case bytes.HasPrefix(trimmed, []byte("TN:")) || bytes.HasPrefix(trimmed, []byte("SF:")):
+		covered, total, err := parseLcov(trimmed)
+		if err != nil {
+			return err
+		}
+		t.addCoverage(file, covered, total)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return t.addXML(file, trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		tests, ok, err := parseGoTestJSON(trimmed)
+		if err != nil || !ok {
+			return err
+		}
+		t.addTests(file, tests)
+	}
+	return nil
+}
+
+func (t *coverageTotals) addCoverage(file string, covered, total float64) {
+	t.covered += covered
+	t.total += total
+	t.hasCoverage = true
+	t.coverageFiles = append(t.coverageFiles, file)
+}
+
+func (t *coverageTotals) addTests(file string, tests int) {
+	t.tests += tests
+	t.hasTests = true
+	t.testFiles = append(t.testFiles, file)
+}
+
+// parseGoCoverProfile counts statements in a cover profile. A block listed
+// more than once, as in profiles merged from several packages' runs, is
+// covered if any run covered it.
+func parseGoCoverProfile(data []byte) (float64, float64, error) {
+	type block struct {
+		stmts   float64
+		covered bool
+	}
+	blocks := map[string]*block{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 || text == "" {
+			continue
+		}
+		// name.go:line.col,line.col numStmts count
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return 0, 0, fmt.Errorf("cover profile line %d: want 3 fields", line)
+		}
+		stmts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cover profile line %d: statements: %w", line, err)
+		}
+		count, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cover profile line %d: count: %w", line, err)
+		}
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{stmts: stmts}
+			blocks[fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	var covered, total float64
+	for _, b := range blocks {
+		total += b.stmts
+		if b.covered {
+			covered += b.stmts
+		}
+	}
+	return covered, total, nil
+}
+
+// parseLcov sums the LH (lines hit) and LF (lines found) records.
+func parseLcov(data []byte) (float64, float64, error) {
+	var covered, total float64
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "LH" && key != "LF") {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("lcov line %d: %w", i+1, err)
+		}
+		if key == "LH" {
+			covered += n
+		} else {
+			total += n
+		}
+	}
+	return covered, total, nil
+}
+
+// addXML reads Cobertura coverage or JUnit results, told apart by their
+// root element.
+func (t *coverageTotals) addXML(file string, data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse xml: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "coverage":
+			var report struct {
+				LineRate     *float64 `xml:"line-rate,attr"`
+				LinesCovered *float64 `xml:"lines-covered,attr"`
+				LinesValid   *float64 `xml:"lines-valid,attr"`
+			}
+			if err := dec.DecodeElement(&report, &start); err != nil {
+				return fmt.Errorf("parse cobertura: %w", err)
+			}
+			switch {
+			case report.LinesCovered != nil && report.LinesValid != nil:
+				t.addCoverage(file, *report.LinesCovered, *report.LinesValid)
+			case report.LineRate != nil:
+				// Without line counts the rate is weighted as 100 lines.
+				t.addCoverage(file, *report.LineRate*100, 100)
+			default:
+				return fmt.Errorf("cobertura report has no line-rate or lines-valid")
+			}
+		case "testsuites", "testsuite":
+			var suite junitSuite
+			if err := dec.DecodeElement(&suite, &start); err != nil {
+				return fmt.Errorf("parse junit: %w", err)
+			}
+			t.addTests(file, suite.count())
+		}
+		return nil
+	}
+}
+
+type junitSuite struct {
+	Tests   *int         `xml:"tests,attr"`
+	Skipped int          `xml:"skipped,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+	Cases   []struct {
+		Skipped *struct{} `xml:"skipped"`
+	} `xml:"testcase"`
+}
+
+// count returns the tests run in a suite, from its attributes when set and
+// otherwise from its test cases and nested suites.
+func (s junitSuite) count() int {
+	if s.Tests != nil {
+		return *s.Tests - s.Skipped
+	}
+	n := 0
+	for _, c := range s.Cases {
+		if c.Skipped == nil {
+			n++
+		}
+	}
+	for _, child := range s.Suites {
+		n += child.count()
+	}
+	return n
+}
+
+// parseGoTestJSON counts the tests and subtests that passed or failed in
+// `go test -json` output. It reports false for other JSON.
+func parseGoTestJSON(data []byte) (int, bool, error) {
+	type event struct {
+		Action  string `json:"Action"`
+		Package string `json:"Package"`
+		Test    string `json:"Test"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	seen := map[string]bool{}
+	isTestJSON := false
+	for {
+		var ev event
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			if !isTestJSON {
+				return 0, false, nil
+			}
+			return 0, false, fmt.Errorf("parse go test -json: %w", err)
+		}
+		if ev.Action == "" {
+			if !isTestJSON {
+				return 0, false, nil
+			}
+			continue
+		}
+		isTestJSON = true
+		if ev.Test != "" && (ev.Action == "pass" || ev.Action == "fail") {
+			seen[ev.Package+"\x00"+ev.Test] = true
+		}
+	}
+	return len(seen), isTestJSON, nil
+}
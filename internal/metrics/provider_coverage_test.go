@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCoverageProviderCollect(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// 10 statements, 6 covered; the a.go:1 block is covered by the second run.
+		"cover.out": "mode: set\n" +
+			"example.com/x/a.go:1.1,3.2 4 0\n" +
+			"example.com/x/a.go:5.1,7.2 4 0\n" +
+			"example.com/x/b.go:1.1,2.2 2 1\n" +
+			"example.com/x/a.go:1.1,3.2 4 1\n",
+		// 20 lines, 14 hit.
+		"lcov.info": "TN:\nSF:src/app.ts\nDA:1,1\nLF:12\nLH:8\nend_of_record\nSF:src/util.ts\nLF:8\nLH:6\nend_of_record\n",
+		// 70 lines, 35 covered.
+		"cobertura.xml": `<?xml version="1.0" ?>
+<coverage line-rate="0.5" lines-covered="35" lines-valid="70" version="1"><packages/></coverage>`,
+		// 4 tests run, skipped ones excluded.
+		"junit.xml": `<testsuites>
+  <testsuite name="a"><testcase name="one"/><testcase name="two"><skipped/></testcase></testsuite>
+  <testsuite name="b" tests="4" skipped="1"/>
+</testsuites>`,
+		// TestA, TestA/sub, and TestB ran; the output line is not a test.
+		"go-test.json": `{"Action":"run","Package":"x","Test":"TestA"}
+{"Action":"output","Package":"x","Test":"TestA","Output":"ok\n"}
+{"Action":"pass","Package":"x","Test":"TestA/sub"}
+{"Action":"pass","Package":"x","Test":"TestA"}
+{"Action":"fail","Package":"x","Test":"TestB"}
+{"Action":"skip","Package":"x","Test":"TestC"}
+{"Action":"pass","Package":"x"}
+`,
+		"README.md":   "# reports\n",
+		"config.json": `{"threshold": 80}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	points, err := (&CoverageProvider{Path: dir, AsOf: time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC)}).Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	values := map[string]MetricPoint{}
+	for _, point := range points {
+		values[point.Key] = point
+	}
+	// (6 + 14 + 35) / (10 + 20 + 70)
+	if got := values["coverage.pct"]; got.Value != 55 || got.Unit != "percent" || len(got.Evidence) != 3 {
+		t.Fatalf("coverage.pct = %+v", got)
+	}
+	if got := values["tests.count"]; got.Value != 7 || len(got.Evidence) != 2 {
+		t.Fatalf("tests.count = %+v", got)
+	}
+
+	// A single file works too.
+	points, err = (&CoverageProvider{Path: filepath.Join(dir, "cover.out")}).Collect(context.Background())
+	if err != nil || len(points) != 1 || points[0].Value != 60 {
+		t.Fatalf("single profile = %+v, %v", points, err)
+	}
+
+	if points, err := (&CoverageProvider{Path: filepath.Join(dir, "missing")}).Collect(context.Background()); err != nil || points != nil {
+		t.Fatalf("missing path = %v, %v", points, err)
+	}
+	bad := filepath.Join(t.TempDir(), "cover.out")
+	if err := os.WriteFile(bad, []byte("mode: set\nbroken line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&CoverageProvider{Path: bad}).Collect(context.Background()); err == nil {
+		t.Fatal("expected error for a malformed profile")
+	}
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -50,6 +51,11 @@ func (p *GitProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
 		return nil, err
 	}
 
+	todos, err := gitTODOCount(ctx, p.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
 	ts := AsOfTimestamp(asOf)
 	return []MetricPoint{
 		{
@@ -66,9 +72,52 @@ func (p *GitProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
 			Timestamp: ts,
 			Source:    p.Name(),
 		},
+		{
+			Key:       "git.todo_count",
+			Value:     float64(todos),
+			Unit:      "count",
+			Timestamp: ts,
+			Source:    p.Name(),
+		},
 	}, nil
 }
 
+// todoPattern matches the markers counted as open TODOs.
+const todoPattern = `\b(TODO|FIXME)\b`
+
+// gitTODOCount counts lines with a TODO or FIXME marker in the tracked text
+// files of the whole work tree, as it is now rather than as of a date.
+func gitTODOCount(ctx context.Context, dir string) (int64, error) {
+	args := []string{"grep", "-I", "-c", "-E", todoPattern, "--", ":/"}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// git grep exits 1 when nothing matches.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("git grep: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+	var total int64
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		i := strings.LastIndex(line, ":")
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(line[i+1:], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse git grep output %q: %w", line, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
 func gitCount(ctx context.Context, dir string, args []string) (int64, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = dir
@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"okrchestra/internal/secrets"
+)
+
+const (
+	// GitHubConfigFileName is the workspace file configuring the GitHub provider.
+	GitHubConfigFileName = "github.yml"
+	// GitHubTokenEnv supplies the API token, taking precedence over github.yml.
+	GitHubTokenEnv = "GITHUB_TOKEN"
+	// DefaultGitHubAPIURL is the public GitHub REST API.
+	DefaultGitHubAPIURL = "https://api.github.com"
+)
+
+// githubReviewSample caps the merged PRs whose reviews are fetched for the
+// review latency point, keeping a measure to a few dozen API calls.
+const githubReviewSample = 50
+
+// GitHubConfig is the contents of github.yml:
+//
+//	repo: acme/widgets
+//	token_secret: github_token  # name in the okrchestra secrets store
+//	api_url: https://github.example.com/api/v3  # GitHub Enterprise only
+//
+// LoadGitHubConfig fills Token from GITHUB_TOKEN or the named secret.
+type GitHubConfig struct {
+	Repo        string `yaml:"repo"`
+	TokenSecret string `yaml:"token_secret"`
+	APIURL      string `yaml:"api_url"`
+	Token       string `yaml:"-"`
+}
+
+// LoadGitHubConfig reads <root>/github.yml, if present, and resolves the
+// API token. Without a token the provider makes unauthenticated requests,
+// which only work for public repositories at a low rate limit.
+func LoadGitHubConfig(root string) (GitHubConfig, error) {
+	var cfg GitHubConfig
+	data, err := os.ReadFile(filepath.Join(root, GitHubConfigFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return cfg, fmt.Errorf("read %s: %w", GitHubConfigFileName, err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse %s: %w", GitHubConfigFileName, err)
+		}
+	}
+	if token := strings.TrimSpace(os.Getenv(GitHubTokenEnv)); token != "" {
+		cfg.Token = token
+		return cfg, nil
+	}
+	if cfg.TokenSecret != "" {
+		store, err := secrets.Default()
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", GitHubConfigFileName, err)
+		}
+		if cfg.Token, err = store.Lookup(cfg.TokenSecret); err != nil {
+			return cfg, fmt.Errorf("%s: %w", GitHubConfigFileName, err)
+		}
+	}
+	return cfg, nil
+}
+
+// GitHubProvider reports pull request and issue metrics for one repository
+// over the 30 days ending at AsOf:
+//
+//	github.prs_merged_30d              merged pull requests
+//	github.pr_review_latency_p50_hours median hours from opening to first review
+//	github.open_issues                 open issues (pull requests excluded)
+type GitHubProvider struct {
+	// Repo is "owner/name"; an empty Repo collects nothing.
+	Repo   string
+	Token  string
+	APIURL string
+	Client *http.Client
+	AsOf   time.Time
+}
+
+func (p *GitHubProvider) Name() string { return "github" }
+
+func (p *GitHubProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	if p.Repo == "" {
+		return nil, nil
+	}
+	if owner, name, ok := strings.Cut(p.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("repo %q must be owner/name", p.Repo)
+	}
+
+	asOf := p.AsOf.UTC().Truncate(24 * time.Hour)
+	since := asOf.Add(-29 * 24 * time.Hour)
+	window := since.Format("2006-01-02") + ".." + asOf.Format("2006-01-02")
+	ts := AsOfTimestamp(asOf)
+
+	mergedQuery := fmt.Sprintf("repo:%s is:pr is:merged merged:%s", p.Repo, window)
+	merged, err := p.search(ctx, mergedQuery, githubReviewSample)
+	if err != nil {
+		return nil, fmt.Errorf("search merged pull requests: %w", err)
+	}
+	issuesQuery := fmt.Sprintf("repo:%s is:issue is:open", p.Repo)
+	issues, err := p.search(ctx, issuesQuery, 1)
+	if err != nil {
+		return nil, fmt.Errorf("search open issues: %w", err)
+	}
+
+	points := []MetricPoint{
+		{
+			Key:       "github.prs_merged_30d",
+			Value:     float64(merged.TotalCount),
+			Unit:      "count",
+			Timestamp: ts,
+			Source:    p.Name(),
+			Evidence:  []string{p.webSearchURL("pulls", mergedQuery)},
+		},
+		{
+			Key:       "github.open_issues",
+			Value:     float64(issues.TotalCount),
+			Unit:      "count",
+			Timestamp: ts,
+			Source:    p.Name(),
+			Evidence:  []string{p.webSearchURL("issues", issuesQuery)},
+		},
+	}
+
+	var latencies []float64
+	var reviewed []string
+	for _, pr := range merged.Items {
+		first, err := p.firstReview(ctx, pr.Number)
+		if err != nil {
+			return nil, fmt.Errorf("reviews for #%d: %w", pr.Number, err)
+		}
+		if first.IsZero() || first.Before(pr.CreatedAt) {
+			continue
+		}
+		latencies = append(latencies, first.Sub(pr.CreatedAt).Hours())
+		reviewed = append(reviewed, pr.HTMLURL)
+	}
+	if len(latencies) > 0 {
+		points = append(points, MetricPoint{
+			Key:       "github.pr_review_latency_p50_hours",
+			Value:     median(latencies),
+			Unit:      "hours",
+			Timestamp: ts,
+			Source:    p.Name(),
+			Evidence:  reviewed,
+		})
+	}
+	return points, nil
+}
+
+type githubSearchResult struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Number    int       `json:"number"`
+		HTMLURL   string    `json:"html_url"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"items"`
+}
+
+type githubReview struct {
+	State       string     `json:"state"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+func (p *GitHubProvider) search(ctx context.Context, query string, perPage int) (*githubSearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", fmt.Sprint(perPage))
+	params.Set("sort", "updated")
+	var result githubSearchResult
+	if err := p.get(ctx, "/search/issues?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// firstReview returns when the first review of a pull request was
+// submitted, or the zero time if it has none.
+func (p *GitHubProvider) firstReview(ctx context.Context, number int) (time.Time, error) {
+	var reviews []githubReview
+	if err := p.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100", p.Repo, number), &reviews); err != nil {
+		return time.Time{}, err
+	}
+	var first time.Time
+	for _, review := range reviews {
+		if review.SubmittedAt == nil || review.State == "PENDING" {
+			continue
+		}
+		if first.IsZero() || review.SubmittedAt.Before(first) {
+			first = *review.SubmittedAt
+		}
+	}
+	return first, nil
+}
+
+func (p *GitHubProvider) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL()+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *GitHubProvider) apiURL() string {
+	if p.APIURL == "" {
+		return DefaultGitHubAPIURL
+	}
+	return strings.TrimSuffix(p.APIURL, "/")
+}
+
+// webSearchURL links evidence to the same search in the GitHub UI.
+func (p *GitHubProvider) webSearchURL(tab, query string) string {
+	base := "https://github.com"
+	if api := p.apiURL(); api != DefaultGitHubAPIURL {
+		base = strings.TrimSuffix(api, "/api/v3")
+	}
+	return fmt.Sprintf("%s/%s/%s?%s", base, p.Repo, tab, url.Values{"q": {query}}.Encode())
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubProviderCollect(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("authorization = %q", got)
+		}
+		var body any
+		switch r.URL.Path {
+		case "/search/issues":
+			q := r.URL.Query().Get("q")
+			queries = append(queries, q)
+			if strings.Contains(q, "is:merged") {
+				body = map[string]any{
+					"total_count": 12,
+					"items": []map[string]any{
+						{"number": 1, "html_url": "https://github.com/acme/widgets/pull/1", "created_at": "2025-01-10T00:00:00Z"},
+						{"number": 2, "html_url": "https://github.com/acme/widgets/pull/2", "created_at": "2025-01-11T00:00:00Z"},
+						{"number": 3, "html_url": "https://github.com/acme/widgets/pull/3", "created_at": "2025-01-12T00:00:00Z"},
+					},
+				}
+			} else {
+				body = map[string]any{"total_count": 7, "items": []any{}}
+			}
+		case "/repos/acme/widgets/pulls/1/reviews":
+			body = []map[string]any{
+				{"state": "COMMENTED", "submitted_at": "2025-01-10T10:00:00Z"},
+				{"state": "APPROVED", "submitted_at": "2025-01-10T04:00:00Z"},
+			}
+		case "/repos/acme/widgets/pulls/2/reviews":
+			body = []map[string]any{{"state": "APPROVED", "submitted_at": "2025-01-12T00:00:00Z"}}
+		case "/repos/acme/widgets/pulls/3/reviews":
+			body = []map[string]any{{"state": "PENDING"}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	provider := &GitHubProvider{
+		Repo:   "acme/widgets",
+		Token:  "test-token",
+		APIURL: server.URL,
+		AsOf:   time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+	}
+	points, err := provider.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	values := map[string]MetricPoint{}
+	for _, point := range points {
+		values[point.Key] = point
+	}
+	if got := values["github.prs_merged_30d"].Value; got != 12 {
+		t.Fatalf("prs_merged_30d = %v, want 12", got)
+	}
+	if got := values["github.open_issues"].Value; got != 7 {
+		t.Fatalf("open_issues = %v, want 7", got)
+	}
+	// PR 1 first reviewed after 4h, PR 2 after 24h, PR 3 never.
+	latency := values["github.pr_review_latency_p50_hours"]
+	if latency.Value != 14 || len(latency.Evidence) != 2 {
+		t.Fatalf("review latency = %+v", latency)
+	}
+	if !strings.Contains(queries[0], "merged:2025-01-01..2025-01-30") {
+		t.Fatalf("merged query = %q", queries[0])
+	}
+	if evidence := values["github.open_issues"].Evidence; len(evidence) != 1 || !strings.HasPrefix(evidence[0], server.URL+"/acme/widgets/issues?q=") {
+		t.Fatalf("open_issues evidence = %v", evidence)
+	}
+
+	if _, err := (&GitHubProvider{Repo: "widgets", APIURL: server.URL}).Collect(context.Background()); err == nil {
+		t.Fatal("expected error for repo without owner")
+	}
+	if points, err := (&GitHubProvider{}).Collect(context.Background()); err != nil || points != nil {
+		t.Fatalf("unconfigured provider = %v, %v", points, err)
+	}
+}
+
+func TestLoadGitHubConfig(t *testing.T) {
+	root := t.TempDir()
+	secretsPath := filepath.Join(t.TempDir(), "secrets.yml")
+	if err := os.WriteFile(secretsPath, []byte("gh: from-secret\n"), 0o600); err != nil {
+		t.Fatalf("write secrets: %v", err)
+	}
+	t.Setenv("OKRCHESTRA_SECRETS_FILE", secretsPath)
+	t.Setenv(GitHubTokenEnv, "")
+	if err := os.WriteFile(filepath.Join(root, GitHubConfigFileName), []byte("repo: acme/widgets\ntoken_secret: gh\n"), 0o644); err != nil {
+		t.Fatalf("write github.yml: %v", err)
+	}
+
+	cfg, err := LoadGitHubConfig(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Repo != "acme/widgets" || cfg.Token != "from-secret" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv(GitHubTokenEnv, "from-env")
+	if cfg, err = LoadGitHubConfig(root); err != nil || cfg.Token != "from-env" {
+		t.Fatalf("env token not preferred: %+v, %v", cfg, err)
+	}
+}
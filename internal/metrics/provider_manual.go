@@ -28,6 +28,9 @@ type manualMetric struct {
 	Unit       string            `yaml:"unit"`
 	Evidence   []string          `yaml:"evidence"`
 	Dimensions map[string]string `yaml:"dimensions"`
+	// ObservedAt is when the value was taken, YYYY-MM-DD or RFC3339;
+	// default the file's modification time.
+	ObservedAt string `yaml:"observed_at"`
 }
 
 func (p *ManualProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
@@ -47,18 +50,18 @@ func (p *ManualProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
 
 	var file manualFile
 	if err := yaml.Unmarshal(data, &file); err == nil && file.Metrics != nil {
-		return p.pointsFrom(file.Metrics)
+		return p.pointsFrom(file.Metrics, fileObservedAt(p.Path))
 	}
 
 	var list []manualMetric
 	if err := yaml.Unmarshal(data, &list); err == nil && list != nil {
-		return p.pointsFrom(list)
+		return p.pointsFrom(list, fileObservedAt(p.Path))
 	}
 
 	return nil, fmt.Errorf("manual metrics file must contain `metrics:` list or a top-level list")
 }
 
-func (p *ManualProvider) pointsFrom(metrics []manualMetric) ([]MetricPoint, error) {
+func (p *ManualProvider) pointsFrom(metrics []manualMetric, fileObserved string) ([]MetricPoint, error) {
 	asOf := p.AsOf.UTC().Truncate(24 * time.Hour)
 	ts := AsOfTimestamp(asOf)
 
@@ -81,6 +84,17 @@ func (p *ManualProvider) pointsFrom(metrics []manualMetric) ([]MetricPoint, erro
 		}
 		dims = CanonicalizeDimensions(dims)
 
+		observed := fileObserved
+		if metric.ObservedAt != "" {
+			t, err := time.Parse(time.RFC3339, metric.ObservedAt)
+			if err != nil {
+				if t, err = time.Parse("2006-01-02", metric.ObservedAt); err != nil {
+					return nil, fmt.Errorf("manual metric %s: observed_at must be YYYY-MM-DD or RFC3339: %q", metric.Key, metric.ObservedAt)
+				}
+			}
+			observed = t.UTC().Format(time.RFC3339)
+		}
+
 		points = append(points, MetricPoint{
 			Key:        metric.Key,
 			Value:      metric.Value,
@@ -89,6 +103,7 @@ func (p *ManualProvider) pointsFrom(metrics []manualMetric) ([]MetricPoint, erro
 			Source:     p.Name(),
 			Evidence:   metric.Evidence,
 			Dimensions: dims,
+			ObservedAt: observed,
 		})
 	}
 
@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenMetricsProvider reads OpenMetrics/Prometheus text exposition files
+// (*.prom) from Dir. Sample labels become dimensions and metric names are
+// prefixed with Prefix (default "openmetrics.").
+type OpenMetricsProvider struct {
+	Dir    string
+	Prefix string
+	AsOf   time.Time
+}
+
+func (p *OpenMetricsProvider) Name() string { return "openmetrics" }
+
+func (p *OpenMetricsProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	_ = ctx
+
+	if p.Dir == "" {
+		p.Dir = filepath.Join("metrics", "openmetrics")
+	}
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "openmetrics."
+	}
+
+	files, err := filepath.Glob(filepath.Join(p.Dir, "*.prom"))
+	if err != nil {
+		return nil, fmt.Errorf("scan openmetrics dir: %w", err)
+	}
+	sort.Strings(files)
+
+	ts := AsOfTimestamp(p.AsOf)
+	var points []MetricPoint
+	for _, path := range files {
+		samples, err := parseOpenMetricsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		evidence := "openmetrics:" + filepath.Base(path)
+		observed := fileObservedAt(path)
+		for _, sample := range samples {
+			points = append(points, MetricPoint{
+				Key:        prefix + sample.name,
+				Value:      sample.value,
+				Unit:       sample.unit,
+				Timestamp:  ts,
+				Source:     p.Name(),
+				Evidence:   []string{evidence},
+				Dimensions: sample.labels,
+				ObservedAt: observed,
+			})
+		}
+	}
+	return points, nil
+}
+
+type openMetricsSample struct {
+	name   string
+	labels []Dimension
+	value  float64
+	unit   string
+}
+
+func parseOpenMetricsFile(path string) ([]openMetricsSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read openmetrics file: %w", err)
+	}
+	defer f.Close()
+
+	units := make(map[string]string)
+	var samples []openMetricsSample
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[1] == "EOF" {
+				break
+			}
+			if len(fields) >= 4 && fields[1] == "UNIT" {
+				units[fields[2]] = fields[3]
+			}
+			continue
+		}
+		sample, ok, err := parseOpenMetricsSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if !ok {
+			continue
+		}
+		sample.unit = unitFor(units, sample.name)
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read openmetrics file: %w", err)
+	}
+	return samples, nil
+}
+
+// parseOpenMetricsSample parses `name{k="v",...} value [timestamp]`.
+// Non-finite values are skipped (ok=false) since snapshots are JSON.
+func parseOpenMetricsSample(line string) (openMetricsSample, bool, error) {
+	var sample openMetricsSample
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, false, fmt.Errorf("malformed sample %q", line)
+	}
+	sample.name = line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseOpenMetricsLabels(rest[1:])
+		if err != nil {
+			return sample, false, err
+		}
+		sample.labels = labels
+		rest = remaining
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, false, fmt.Errorf("sample %s has no value", sample.name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, false, fmt.Errorf("sample %s: parse value %q: %w", sample.name, fields[0], err)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return sample, false, nil
+	}
+	sample.value = value
+	return sample, true, nil
+}
+
+// parseOpenMetricsLabels parses label pairs up to the closing brace and
+// returns the text after it.
+func parseOpenMetricsLabels(s string) ([]Dimension, string, error) {
+	var labels []Dimension
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("malformed labels")
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("label %s: value must be quoted", key)
+		}
+		var b strings.Builder
+		i := 1
+		closed := false
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			if c == '"' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return nil, "", fmt.Errorf("label %s: unterminated value", key)
+		}
+		labels = append(labels, Dimension{Key: key, Value: b.String()})
+		s = s[i+1:]
+	}
+}
+
+// unitFor resolves a sample's unit from `# UNIT` metadata, matching the
+// metric family for suffixed samples such as _total or _count.
+func unitFor(units map[string]string, name string) string {
+	if unit, ok := units[name]; ok {
+		return unit
+	}
+	for _, suffix := range []string{"_total", "_count", "_sum", "_bucket", "_created"} {
+		if base := strings.TrimSuffix(name, suffix); base != name {
+			if unit, ok := units[base]; ok {
+				return unit
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenMetricsProviderCollect(t *testing.T) {
+	dir := t.TempDir()
+	export := `# HELP http_requests Requests served.
+# TYPE http_requests counter
+# UNIT http_requests requests
+http_requests_total{method="GET",path="/a\"b"} 1027 1700000000000
+http_requests_total{method="POST"} 3
+# TYPE build_info gauge
+build_info 1
+latency_seconds NaN
+# EOF
+ignored_after_eof 5
+`
+	if err := os.WriteFile(filepath.Join(dir, "app.prom"), []byte(export), 0o644); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a metric"), 0o644); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+
+	provider := &OpenMetricsProvider{Dir: dir, Prefix: "svc.", AsOf: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)}
+	points, err := provider.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("expected 3 points, got %d: %+v", len(points), points)
+	}
+
+	get := CanonicalizePoints(points)
+	if get[0].Key != "svc.build_info" || len(get[0].Dimensions) != 0 {
+		t.Errorf("unexpected first point %+v", get[0])
+	}
+	first := get[1]
+	if first.Key != "svc.http_requests_total" || first.Value != 1027 || first.Unit != "requests" {
+		t.Errorf("unexpected GET point %+v", first)
+	}
+	want := []Dimension{{Key: "method", Value: "GET"}, {Key: "path", Value: `/a"b`}}
+	if len(first.Dimensions) != 2 || first.Dimensions[0] != want[0] || first.Dimensions[1] != want[1] {
+		t.Errorf("unexpected dimensions %+v", first.Dimensions)
+	}
+	if first.Source != "openmetrics" || len(first.Evidence) != 1 || first.Evidence[0] != "openmetrics:app.prom" {
+		t.Errorf("unexpected provenance %+v", first)
+	}
+}
+
+func TestOpenMetricsProviderMalformed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.prom"), []byte("metric{label=unquoted} 1\n"), 0o644); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+	provider := &OpenMetricsProvider{Dir: dir}
+	if _, err := provider.Collect(context.Background()); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestOpenMetricsProviderMissingDir(t *testing.T) {
+	provider := &OpenMetricsProvider{Dir: filepath.Join(t.TempDir(), "missing")}
+	points, err := provider.Collect(context.Background())
+	if err != nil || len(points) != 0 {
+		t.Fatalf("expected no points and no error, got %d, %v", len(points), err)
+	}
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"okrchestra/internal/secrets"
+)
+
+// PrometheusConfigFileName is the workspace file mapping metric keys to PromQL.
+const PrometheusConfigFileName = "prometheus.yml"
+
+// PrometheusConfig is the contents of prometheus.yml:
+//
+//	endpoint: http://prometheus:9090
+//	token_secret: prometheus_token  # optional bearer token
+//	metrics:
+//	  api.latency_p95_ms:
+//	    query: histogram_quantile(0.95, sum(rate(http_request_duration_seconds_bucket[5m])) by (le)) * 1000
+//	    unit: ms
+//	  api.error_rate:
+//	    query: sum(rate(http_requests_total{code=~"5.."}[1d])) / sum(rate(http_requests_total[1d]))
+//	    endpoint: http://other-prometheus:9090
+//
+// A query's endpoint overrides the file's. LoadPrometheusConfig fills Token
+// from the named secret.
+type PrometheusConfig struct {
+	Endpoint    string                      `yaml:"endpoint"`
+	TokenSecret string                      `yaml:"token_secret"`
+	Metrics     map[string]PrometheusMetric `yaml:"metrics"`
+	Token       string                      `yaml:"-"`
+}
+
+// PrometheusMetric is one PromQL query reported under its metric key.
+type PrometheusMetric struct {
+	Query    string `yaml:"query"`
+	Unit     string `yaml:"unit"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// LoadPrometheusConfig reads <root>/prometheus.yml. A missing file
+// configures no queries.
+func LoadPrometheusConfig(root string) (PrometheusConfig, error) {
+	var cfg PrometheusConfig
+	data, err := os.ReadFile(filepath.Join(root, PrometheusConfigFileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", PrometheusConfigFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", PrometheusConfigFileName, err)
+	}
+	for key, metric := range cfg.Metrics {
+		if strings.TrimSpace(metric.Query) == "" {
+			return cfg, fmt.Errorf("%s: metrics.%s: query is required", PrometheusConfigFileName, key)
+		}
+		if metric.Endpoint == "" && cfg.Endpoint == "" {
+			return cfg, fmt.Errorf("%s: metrics.%s: no endpoint (set endpoint at the top level or on the metric)", PrometheusConfigFileName, key)
+		}
+	}
+	if cfg.TokenSecret != "" {
+		store, err := secrets.Default()
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", PrometheusConfigFileName, err)
+		}
+		if cfg.Token, err = store.Lookup(cfg.TokenSecret); err != nil {
+			return cfg, fmt.Errorf("%s: %w", PrometheusConfigFileName, err)
+		}
+	}
+	return cfg, nil
+}
+
+// PrometheusProvider runs instant PromQL queries at the end of the as-of day
+// (or now, if earlier). A vector result yields one point per series, with
+// its labels as dimensions; evidence is the query URL.
+type PrometheusProvider struct {
+	Config PrometheusConfig
+	Client *http.Client
+	AsOf   time.Time
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func (p *PrometheusProvider) Name() string { return "prometheus" }
+
+func (p *PrometheusProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	asOf := p.AsOf.UTC().Truncate(24 * time.Hour)
+	at := asOf.Add(24 * time.Hour)
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	if n := now().UTC(); n.Before(at) {
+		at = n
+	}
+	ts := AsOfTimestamp(asOf)
+
+	keys := make([]string, 0, len(p.Config.Metrics))
+	for key := range p.Config.Metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var points []MetricPoint
+	for _, key := range keys {
+		metric := p.Config.Metrics[key]
+		endpoint := metric.Endpoint
+		if endpoint == "" {
+			endpoint = p.Config.Endpoint
+		}
+		queryURL := prometheusQueryURL(endpoint, metric.Query, at)
+		samples, err := p.query(ctx, queryURL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		for _, sample := range samples {
+			points = append(points, MetricPoint{
+				Key:        key,
+				Value:      sample.value,
+				Unit:       metric.Unit,
+				Timestamp:  ts,
+				Source:     p.Name(),
+				Evidence:   []string{queryURL},
+				Dimensions: sample.dimensions,
+			})
+		}
+	}
+	return points, nil
+}
+
+func prometheusQueryURL(endpoint, query string, at time.Time) string {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", strconv.FormatInt(at.Unix(), 10))
+	return strings.TrimSuffix(endpoint, "/") + "/api/v1/query?" + params.Encode()
+}
+
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type prometheusSample struct {
+	value      float64
+	dimensions []Dimension
+}
+
+func (p *PrometheusProvider) query(ctx context.Context, queryURL string) ([]prometheusSample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.Config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Config.Token)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	var parsed prometheusResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("query returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if parsed.Status != "success" {
+		return nil, fmt.Errorf("query failed (%s): %s", parsed.ErrorType, parsed.Error)
+	}
+
+	switch parsed.Data.ResultType {
+	case "scalar":
+		var value [2]any
+		if err := json.Unmarshal(parsed.Data.Result, &value); err != nil {
+			return nil, fmt.Errorf("decode scalar: %w", err)
+		}
+		v, ok := prometheusValue(value)
+		if !ok {
+			return nil, nil
+		}
+		return []prometheusSample{{value: v}}, nil
+	case "vector":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]any            `json:"value"`
+		}
+		if err := json.Unmarshal(parsed.Data.Result, &series); err != nil {
+			return nil, fmt.Errorf("decode vector: %w", err)
+		}
+		var samples []prometheusSample
+		for _, s := range series {
+			v, ok := prometheusValue(s.Value)
+			if !ok {
+				continue
+			}
+			var dims []Dimension
+			for name, value := range s.Metric {
+				if name == "__name__" {
+					continue
+				}
+				dims = append(dims, Dimension{Key: name, Value: value})
+			}
+			samples = append(samples, prometheusSample{value: v, dimensions: CanonicalizeDimensions(dims)})
+		}
+		return samples, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q (use an instant vector or scalar query)", parsed.Data.ResultType)
+	}
+}
+
+// prometheusValue parses a [timestamp, "value"] pair, rejecting NaN and
+// infinities.
+func prometheusValue(pair [2]any) (float64, bool) {
+	raw, ok := pair[1].(string)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusProviderCollect(t *testing.T) {
+	var times []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		times = append(times, r.URL.Query().Get("time"))
+		switch r.URL.Query().Get("query") {
+		case "latency":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"lat","service":"api"},"value":[1736208000,"120.5"]},
+				{"metric":{"service":"web"},"value":[1736208000,"NaN"]}]}}`)
+		case "errors":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1736208000,"0.02"]}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+		}
+	}))
+	defer server.Close()
+
+	asOf := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	provider := &PrometheusProvider{
+		Config: PrometheusConfig{
+			Endpoint: server.URL,
+			Metrics: map[string]PrometheusMetric{
+				"api.latency_ms": {Query: "latency", Unit: "ms"},
+				"api.error_rate": {Query: "errors"},
+			},
+		},
+		AsOf: asOf,
+		Now:  func() time.Time { return asOf.Add(10 * time.Hour) },
+	}
+	points, err := provider.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %+v", points)
+	}
+	errorRate, latency := points[0], points[1]
+	if errorRate.Key != "api.error_rate" || errorRate.Value != 0.02 || len(errorRate.Dimensions) != 0 {
+		t.Fatalf("unexpected error rate point: %+v", errorRate)
+	}
+	if latency.Value != 120.5 || latency.Unit != "ms" || len(latency.Dimensions) != 1 || latency.Dimensions[0] != (Dimension{Key: "service", Value: "api"}) {
+		t.Fatalf("unexpected latency point: %+v", latency)
+	}
+	if len(latency.Evidence) != 1 || !strings.HasPrefix(latency.Evidence[0], server.URL+"/api/v1/query?query=latency") {
+		t.Fatalf("unexpected evidence: %v", latency.Evidence)
+	}
+	// Today's as-of date is queried at the current time, not end of day.
+	if want := fmt.Sprint(asOf.Add(10 * time.Hour).Unix()); times[0] != want {
+		t.Fatalf("query time = %s, want %s", times[0], want)
+	}
+
+	provider.Config.Metrics = map[string]PrometheusMetric{"broken": {Query: "nope"}}
+	if _, err := provider.Collect(context.Background()); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Fatalf("expected query error, got %v", err)
+	}
+}
+
+func TestLoadPrometheusConfig(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := LoadPrometheusConfig(root)
+	if err != nil || len(cfg.Metrics) != 0 {
+		t.Fatalf("missing file: %+v, %v", cfg, err)
+	}
+
+	path := filepath.Join(root, PrometheusConfigFileName)
+	if err := os.WriteFile(path, []byte("metrics:\n  api.latency_ms:\n    query: up\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadPrometheusConfig(root); err == nil || !strings.Contains(err.Error(), "no endpoint") {
+		t.Fatalf("expected missing endpoint error, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("endpoint: http://prom:9090\nmetrics:\n  api.latency_ms:\n    query: up\n    unit: ms\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err = LoadPrometheusConfig(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Metrics["api.latency_ms"].Unit != "ms" || cfg.Endpoint != "http://prom:9090" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
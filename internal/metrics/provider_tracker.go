@@ -0,0 +1,589 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"okrchestra/internal/secrets"
+)
+
+const (
+	// TrackerConfigFileName is the workspace file configuring the issue
+	// tracker provider.
+	TrackerConfigFileName = "tracker.yml"
+	// JiraTokenEnv supplies the Jira API token, taking precedence over
+	// tracker.yml.
+	JiraTokenEnv = "JIRA_API_TOKEN"
+	// LinearTokenEnv supplies the Linear API key, taking precedence over
+	// tracker.yml.
+	LinearTokenEnv = "LINEAR_API_KEY"
+	// DefaultLinearAPIURL is Linear's GraphQL endpoint.
+	DefaultLinearAPIURL = "https://api.linear.app/graphql"
+)
+
+// Tracker sources.
+const (
+	TrackerSourceExport = "export"
+	TrackerSourceJira   = "jira"
+	TrackerSourceLinear = "linear"
+)
+
+// TrackerConfig is the contents of tracker.yml:
+//
+//	source: jira              # export (default), jira, or linear
+//	projects: [WEB, API]      # Jira project or Linear team keys
+//	bug_types: [Bug, Defect]  # issue types or labels counted as bugs
+//	export: exports/issues.json
+//	jira:
+//	  url: https://acme.atlassian.net
+//	  email: bot@acme.com     # Jira Cloud; omit for a Server/DC personal access token
+//	  token_secret: jira_token
+//	linear:
+//	  token_secret: linear_token
+//
+// LoadTrackerConfig fills the token from JIRA_API_TOKEN, LINEAR_API_KEY, or
+// the named secret.
+type TrackerConfig struct {
+	Source   string        `yaml:"source"`
+	Projects []string      `yaml:"projects"`
+	BugTypes []string      `yaml:"bug_types"`
+	Export   string        `yaml:"export"`
+	Jira     TrackerJira   `yaml:"jira"`
+	Linear   TrackerLinear `yaml:"linear"`
+}
+
+// TrackerJira is the Jira connection of tracker.yml.
+type TrackerJira struct {
+	URL         string `yaml:"url"`
+	Email       string `yaml:"email"`
+	TokenSecret string `yaml:"token_secret"`
+	Token       string `yaml:"-"`
+}
+
+// TrackerLinear is the Linear connection of tracker.yml.
+type TrackerLinear struct {
+	APIURL      string `yaml:"api_url"`
+	TokenSecret string `yaml:"token_secret"`
+	Token       string `yaml:"-"`
+}
+
+// LoadTrackerConfig reads <root>/tracker.yml. A missing file reads the
+// export at <metrics-dir>/tracker.json, if there is one. A relative export
+// path is resolved against root.
+func LoadTrackerConfig(root string) (TrackerConfig, error) {
+	var cfg TrackerConfig
+	data, err := os.ReadFile(filepath.Join(root, TrackerConfigFileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", TrackerConfigFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", TrackerConfigFileName, err)
+	}
+	if cfg.Export != "" && !filepath.IsAbs(cfg.Export) {
+		cfg.Export = filepath.Join(root, cfg.Export)
+	}
+
+	var env, secret string
+	switch cfg.Source {
+	case "", TrackerSourceExport:
+		return cfg, nil
+	case TrackerSourceJira:
+		if cfg.Jira.URL == "" {
+			return cfg, fmt.Errorf("%s: jira.url is required", TrackerConfigFileName)
+		}
+		env, secret = JiraTokenEnv, cfg.Jira.TokenSecret
+	case TrackerSourceLinear:
+		env, secret = LinearTokenEnv, cfg.Linear.TokenSecret
+	default:
+		return cfg, fmt.Errorf("%s: source must be export, jira, or linear, got %q", TrackerConfigFileName, cfg.Source)
+	}
+	token := strings.TrimSpace(os.Getenv(env))
+	if token == "" && secret != "" {
+		store, err := secrets.Default()
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", TrackerConfigFileName, err)
+		}
+		if token, err = store.Lookup(secret); err != nil {
+			return cfg, fmt.Errorf("%s: %w", TrackerConfigFileName, err)
+		}
+	}
+	if token == "" {
+		return cfg, fmt.Errorf("%s: no %s token (set %s or token_secret)", TrackerConfigFileName, cfg.Source, env)
+	}
+	if cfg.Source == TrackerSourceJira {
+		cfg.Jira.Token = token
+	} else {
+		cfg.Linear.Token = token
+	}
+	return cfg, nil
+}
+
+// TrackerIssue is one issue in a tracker export, the format the Jira and
+// Linear clients also convert to:
+//
+//	{"issues": [{"key": "WEB-12", "project": "WEB", "type": "Bug",
+//	  "created_at": "2025-01-02T10:00:00Z", "started_at": "2025-01-03T09:00:00Z",
+//	  "resolved_at": "2025-01-06T17:00:00Z", "url": "https://..."}]}
+//
+// An issue without resolved_at is open unless canceled. started_at falls
+// back to created_at.
+type TrackerIssue struct {
+	Key        string     `json:"key"`
+	Project    string     `json:"project"`
+	Type       string     `json:"type,omitempty"`
+	Labels     []string   `json:"labels,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Canceled   bool       `json:"canceled,omitempty"`
+	URL        string     `json:"url,omitempty"`
+}
+
+// TrackerProvider reports issue tracker metrics over the 30 days ending at
+// AsOf, for all configured projects together and per project:
+//
+//	tracker.issues_closed_30d                issues resolved
+//	tracker.cycle_time_days_p50              median days from start to resolution
+//	tracker.bug_backlog                      open bugs
+//	tracker.<project>.issues_closed_30d      (and so on) for one project
+//
+// Project keys are lowercased in metric keys, e.g. tracker.web.bug_backlog.
+type TrackerProvider struct {
+	Config TrackerConfig
+	Client *http.Client
+	AsOf   time.Time
+}
+
+func (p *TrackerProvider) Name() string { return "tracker" }
+
+func (p *TrackerProvider) Collect(ctx context.Context) ([]MetricPoint, error) {
+	asOf := p.AsOf.UTC().Truncate(24 * time.Hour)
+	// Issues resolved during the as-of day count toward it.
+	end := asOf.Add(24 * time.Hour)
+	since := end.Add(-30 * 24 * time.Hour)
+
+	var (
+		issues []TrackerIssue
+		err    error
+	)
+	switch p.Config.Source {
+	case "", TrackerSourceExport:
+		issues, err = p.readExport()
+	case TrackerSourceJira:
+		issues, err = p.fetchJira(ctx, since)
+	case TrackerSourceLinear:
+		issues, err = p.fetchLinear(ctx, since)
+	default:
+		err = fmt.Errorf("unknown source %q", p.Config.Source)
+	}
+	if err != nil || issues == nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, project := range p.Config.Projects {
+		wanted[strings.ToUpper(project)] = true
+	}
+	byProject := map[string][]TrackerIssue{}
+	var all []TrackerIssue
+	for _, issue := range issues {
+		if len(wanted) > 0 && !wanted[strings.ToUpper(issue.Project)] {
+			continue
+		}
+		all = append(all, issue)
+		if issue.Project != "" {
+			byProject[issue.Project] = append(byProject[issue.Project], issue)
+		}
+	}
+
+	ts := AsOfTimestamp(asOf)
+	points := p.summarize("tracker.", all, since, end, ts)
+	projects := make([]string, 0, len(byProject))
+	for project := range byProject {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		points = append(points, p.summarize("tracker."+trackerKeyPart(project)+".", byProject[project], since, end, ts)...)
+	}
+	// An export is only as fresh as the file; the APIs are read live.
+	if p.Config.Source == "" || p.Config.Source == TrackerSourceExport {
+		observed := fileObservedAt(p.Config.Export)
+		for i := range points {
+			points[i].ObservedAt = observed
+		}
+	}
+	return points, nil
+}
+
+// summarize computes the points for one set of issues.
+func (p *TrackerProvider) summarize(prefix string, issues []TrackerIssue, since, end time.Time, ts string) []MetricPoint {
+	var (
+		closed, bugs []string
+		cycleDays    []float64
+	)
+	for _, issue := range issues {
+		ref := issue.URL
+		if ref == "" {
+			ref = issue.Key
+		}
+		if issue.Canceled || !issue.CreatedAt.Before(end) {
+			continue
+		}
+		// An issue resolved after the window was still open at its end.
+		if issue.ResolvedAt != nil && issue.ResolvedAt.Before(end) {
+			resolved := issue.ResolvedAt.UTC()
+			if resolved.Before(since) {
+				continue
+			}
+			closed = append(closed, ref)
+			start := issue.CreatedAt
+			if issue.StartedAt != nil {
+				start = *issue.StartedAt
+			}
+			if !resolved.Before(start) {
+				cycleDays = append(cycleDays, resolved.Sub(start).Hours()/24)
+			}
+			continue
+		}
+		if p.isBug(issue) {
+			bugs = append(bugs, ref)
+		}
+	}
+
+	points := []MetricPoint{
+		{Key: prefix + "issues_closed_30d", Value: float64(len(closed)), Unit: "count", Timestamp: ts, Source: p.Name(), Evidence: trackerEvidence(closed)},
+		{Key: prefix + "bug_backlog", Value: float64(len(bugs)), Unit: "count", Timestamp: ts, Source: p.Name(), Evidence: trackerEvidence(bugs)},
+	}
+	if len(cycleDays) > 0 {
+		points = append(points, MetricPoint{
+			Key:       prefix + "cycle_time_days_p50",
+			Value:     median(cycleDays),
+			Unit:      "days",
+			Timestamp: ts,
+			Source:    p.Name(),
+			Evidence:  trackerEvidence(closed),
+		})
+	}
+	return points
+}
+
+// trackerEvidenceLimit caps the issues cited per point.
+const trackerEvidenceLimit = 20
+
+func trackerEvidence(refs []string) []string {
+	sort.Strings(refs)
+	if len(refs) > trackerEvidenceLimit {
+		refs = append(refs[:trackerEvidenceLimit:trackerEvidenceLimit], fmt.Sprintf("and %d more", len(refs)-trackerEvidenceLimit))
+	}
+	return refs
+}
+
+func (p *TrackerProvider) isBug(issue TrackerIssue) bool {
+	types := p.Config.BugTypes
+	if len(types) == 0 {
+		types = []string{"bug"}
+	}
+	for _, t := range types {
+		if strings.EqualFold(issue.Type, t) {
+			return true
+		}
+		for _, label := range issue.Labels {
+			if strings.EqualFold(label, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func trackerKeyPart(project string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(project) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// readExport returns nil issues when the export does not exist.
+func (p *TrackerProvider) readExport() ([]TrackerIssue, error) {
+	path := p.Config.Export
+	if path == "" {
+		path = filepath.Join("metrics", "tracker.json")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read tracker export: %w", err)
+	}
+	var export struct {
+		Issues []TrackerIssue `json:"issues"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parse tracker export %s: %w", path, err)
+	}
+	if export.Issues == nil {
+		export.Issues = []TrackerIssue{}
+	}
+	return export.Issues, nil
+}
+
+type jiraSearchResult struct {
+	StartAt    int `json:"startAt"`
+	MaxResults int `json:"maxResults"`
+	Total      int `json:"total"`
+	Issues     []struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Project struct {
+				Key string `json:"key"`
+			} `json:"project"`
+			IssueType struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+			Labels         []string `json:"labels"`
+			Created        string   `json:"created"`
+			ResolutionDate string   `json:"resolutiondate"`
+		} `json:"fields"`
+		Changelog struct {
+			Histories []struct {
+				Created string `json:"created"`
+				Items   []struct {
+					Field string `json:"field"`
+				} `json:"items"`
+			} `json:"histories"`
+		} `json:"changelog"`
+	} `json:"issues"`
+}
+
+// jiraTimeLayout is the timestamp format of Jira's REST API.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// fetchJira searches for the issues resolved since the window start and
+// the unresolved bugs. The first status change in an issue's changelog is
+// taken as its start.
+func (p *TrackerProvider) fetchJira(ctx context.Context, since time.Time) ([]TrackerIssue, error) {
+	cfg := p.Config.Jira
+	bugTypes := p.Config.BugTypes
+	if len(bugTypes) == 0 {
+		bugTypes = []string{"Bug"}
+	}
+	quoted := make([]string, len(bugTypes))
+	for i, t := range bugTypes {
+		quoted[i] = fmt.Sprintf("%q", t)
+	}
+	bugList := strings.Join(quoted, ", ")
+	jql := fmt.Sprintf(`(resolutiondate >= "%s" OR (resolution IS EMPTY AND (issuetype IN (%s) OR labels IN (%s))))`, since.Format("2006-01-02"), bugList, bugList)
+	if len(p.Config.Projects) > 0 {
+		jql = fmt.Sprintf("project IN (%s) AND %s", strings.Join(p.Config.Projects, ", "), jql)
+	}
+	base := strings.TrimSuffix(cfg.URL, "/")
+	issues := []TrackerIssue{}
+	for startAt := 0; ; {
+		params := url.Values{}
+		params.Set("jql", jql)
+		params.Set("fields", "project,issuetype,labels,created,resolutiondate")
+		params.Set("expand", "changelog")
+		params.Set("startAt", fmt.Sprint(startAt))
+		params.Set("maxResults", "100")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/rest/api/2/search?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if cfg.Email != "" {
+			req.SetBasicAuth(cfg.Email, cfg.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+cfg.Token)
+		}
+		var result jiraSearchResult
+		if err := p.do(req, &result); err != nil {
+			return nil, fmt.Errorf("jira search: %w", err)
+		}
+		for _, raw := range result.Issues {
+			issue := TrackerIssue{
+				Key:     raw.Key,
+				Project: raw.Fields.Project.Key,
+				Type:    raw.Fields.IssueType.Name,
+				Labels:  raw.Fields.Labels,
+				URL:     base + "/browse/" + raw.Key,
+			}
+			if issue.CreatedAt, err = time.Parse(jiraTimeLayout, raw.Fields.Created); err != nil {
+				return nil, fmt.Errorf("jira issue %s: created: %w", raw.Key, err)
+			}
+			if raw.Fields.ResolutionDate != "" {
+				resolved, err := time.Parse(jiraTimeLayout, raw.Fields.ResolutionDate)
+				if err != nil {
+					return nil, fmt.Errorf("jira issue %s: resolutiondate: %w", raw.Key, err)
+				}
+				issue.ResolvedAt = &resolved
+			}
+			for _, history := range raw.Changelog.Histories {
+				changed, err := time.Parse(jiraTimeLayout, history.Created)
+				if err != nil {
+					continue
+				}
+				for _, item := range history.Items {
+					if item.Field == "status" && (issue.StartedAt == nil || changed.Before(*issue.StartedAt)) {
+						issue.StartedAt = &changed
+					}
+				}
+			}
+			issues = append(issues, issue)
+		}
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			return issues, nil
+		}
+	}
+}
+
+const linearIssuesQuery = `query Issues($filter: IssueFilter, $after: String) {
+  issues(first: 100, after: $after, filter: $filter) {
+    nodes { identifier url createdAt startedAt completedAt canceledAt team { key } labels { nodes { name } } }
+    pageInfo { hasNextPage endCursor }
+  }
+}`
+
+type linearIssuesResult struct {
+	Data struct {
+		Issues struct {
+			Nodes []struct {
+				Identifier  string     `json:"identifier"`
+				URL         string     `json:"url"`
+				CreatedAt   time.Time  `json:"createdAt"`
+				StartedAt   *time.Time `json:"startedAt"`
+				CompletedAt *time.Time `json:"completedAt"`
+				CanceledAt  *time.Time `json:"canceledAt"`
+				Team        struct {
+					Key string `json:"key"`
+				} `json:"team"`
+				Labels struct {
+					Nodes []struct {
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"labels"`
+			} `json:"nodes"`
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+		} `json:"issues"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// fetchLinear queries the issues completed since the window start and the
+// open bugs. Projects are team keys; bugs are found by label.
+func (p *TrackerProvider) fetchLinear(ctx context.Context, since time.Time) ([]TrackerIssue, error) {
+	cfg := p.Config.Linear
+	bugTypes := p.Config.BugTypes
+	if len(bugTypes) == 0 {
+		bugTypes = []string{"Bug", "bug"}
+	}
+	filter := map[string]any{
+		"or": []any{
+			map[string]any{"completedAt": map[string]any{"gte": since.Format(time.RFC3339)}},
+			map[string]any{
+				"completedAt": map[string]any{"null": true},
+				"canceledAt":  map[string]any{"null": true},
+				"labels":      map[string]any{"some": map[string]any{"name": map[string]any{"in": bugTypes}}},
+			},
+		},
+	}
+	if len(p.Config.Projects) > 0 {
+		filter["team"] = map[string]any{"key": map[string]any{"in": p.Config.Projects}}
+	}
+	endpoint := cfg.APIURL
+	if endpoint == "" {
+		endpoint = DefaultLinearAPIURL
+	}
+	issues := []TrackerIssue{}
+	var after *string
+	for {
+		body, err := json.Marshal(map[string]any{
+			"query":     linearIssuesQuery,
+			"variables": map[string]any{"filter": filter, "after": after},
+		})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", cfg.Token)
+		var result linearIssuesResult
+		if err := p.do(req, &result); err != nil {
+			return nil, fmt.Errorf("linear issues: %w", err)
+		}
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("linear issues: %s", result.Errors[0].Message)
+		}
+		for _, node := range result.Data.Issues.Nodes {
+			issue := TrackerIssue{
+				Key:        node.Identifier,
+				Project:    node.Team.Key,
+				CreatedAt:  node.CreatedAt,
+				StartedAt:  node.StartedAt,
+				ResolvedAt: node.CompletedAt,
+				Canceled:   node.CanceledAt != nil,
+				URL:        node.URL,
+			}
+			for _, label := range node.Labels.Nodes {
+				issue.Labels = append(issue.Labels, label.Name)
+			}
+			issues = append(issues, issue)
+		}
+		page := result.Data.Issues.PageInfo
+		if !page.HasNextPage || page.EndCursor == "" {
+			return issues, nil
+		}
+		cursor := page.EndCursor
+		after = &cursor
+	}
+}
+
+func (p *TrackerProvider) do(req *http.Request, out any) error {
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
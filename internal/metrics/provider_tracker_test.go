@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func trackerValues(t *testing.T, p *TrackerProvider) map[string]MetricPoint {
+	t.Helper()
+	points, err := p.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	values := map[string]MetricPoint{}
+	for _, point := range points {
+		values[point.Key] = point
+	}
+	return values
+}
+
+func TestTrackerProviderExport(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "tracker.json")
+	data := `{"issues": [
+		{"key": "WEB-1", "project": "WEB", "type": "Story", "created_at": "2025-01-01T00:00:00Z", "started_at": "2025-01-10T00:00:00Z", "resolved_at": "2025-01-12T00:00:00Z"},
+		{"key": "WEB-2", "project": "WEB", "type": "Bug", "created_at": "2025-01-20T00:00:00Z", "resolved_at": "2025-01-24T00:00:00Z"},
+		{"key": "WEB-3", "project": "WEB", "type": "Bug", "created_at": "2025-01-25T00:00:00Z"},
+		{"key": "WEB-4", "project": "WEB", "type": "Bug", "created_at": "2025-01-25T00:00:00Z", "canceled": true},
+		{"key": "WEB-5", "project": "WEB", "type": "Story", "created_at": "2024-11-01T00:00:00Z", "resolved_at": "2024-11-20T00:00:00Z"},
+		{"key": "API-1", "project": "API", "labels": ["bug"], "created_at": "2025-01-05T00:00:00Z", "resolved_at": "2025-02-03T00:00:00Z"},
+		{"key": "API-2", "project": "API", "type": "Task", "created_at": "2025-01-28T00:00:00Z", "resolved_at": "2025-01-30T12:00:00Z"},
+		{"key": "OPS-1", "project": "OPS", "type": "Bug", "created_at": "2025-01-28T00:00:00Z"}
+	]}`
+	if err := os.WriteFile(export, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := trackerValues(t, &TrackerProvider{
+		Config: TrackerConfig{Export: export, Projects: []string{"web", "API"}},
+		AsOf:   time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+	})
+
+	// Closed in the window: WEB-1 (2 days), WEB-2 (4 days), API-2 (2.5 days,
+	// resolved on the as-of day). API-1 was still open on 2025-01-30.
+	want := map[string]float64{
+		"tracker.issues_closed_30d":       3,
+		"tracker.cycle_time_days_p50":     2.5,
+		"tracker.bug_backlog":             2,
+		"tracker.web.issues_closed_30d":   2,
+		"tracker.web.cycle_time_days_p50": 3,
+		"tracker.web.bug_backlog":         1,
+		"tracker.api.issues_closed_30d":   1,
+		"tracker.api.bug_backlog":         1,
+	}
+	for key, value := range want {
+		point, ok := values[key]
+		if !ok || point.Value != value {
+			t.Errorf("%s = %+v, want %v", key, point, value)
+		}
+	}
+	if _, ok := values["tracker.ops.bug_backlog"]; ok {
+		t.Error("project outside projects was measured")
+	}
+	if evidence := values["tracker.web.bug_backlog"].Evidence; len(evidence) != 1 || evidence[0] != "WEB-3" {
+		t.Errorf("bug backlog evidence = %v", evidence)
+	}
+
+	if points, err := (&TrackerProvider{Config: TrackerConfig{Export: filepath.Join(t.TempDir(), "missing.json")}}).Collect(context.Background()); err != nil || points != nil {
+		t.Fatalf("missing export = %v, %v", points, err)
+	}
+}
+
+func TestTrackerProviderJira(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bot@acme.com" || pass != "jira-token" {
+			t.Errorf("basic auth = %q, %q", user, pass)
+		}
+		if r.URL.Path != "/rest/api/2/search" {
+			http.NotFound(w, r)
+			return
+		}
+		if jql := r.URL.Query().Get("jql"); !strings.HasPrefix(jql, "project IN (WEB) AND ") || !strings.Contains(jql, `resolutiondate >= "2025-01-01"`) {
+			t.Errorf("jql = %q", jql)
+		}
+		issue := func(key, typ, created, resolved string, statusChanges ...string) map[string]any {
+			var histories []any
+			for _, at := range statusChanges {
+				histories = append(histories, map[string]any{"created": at, "items": []any{map[string]any{"field": "status"}}})
+			}
+			return map[string]any{
+				"key": key,
+				"fields": map[string]any{
+					"project":        map[string]any{"key": "WEB"},
+					"issuetype":      map[string]any{"name": typ},
+					"created":        created,
+					"resolutiondate": resolved,
+				},
+				"changelog": map[string]any{"histories": histories},
+			}
+		}
+		// Two pages of one issue each.
+		page := []any{issue("WEB-1", "Story", "2025-01-01T09:00:00.000+0000", "2025-01-11T09:00:00.000+0000", "2025-01-09T09:00:00.000+0000", "2025-01-07T09:00:00.000+0000")}
+		if r.URL.Query().Get("startAt") == "1" {
+			page = []any{issue("WEB-2", "Bug", "2025-01-20T09:00:00.000+0000", "")}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"total": 2, "issues": page})
+	}))
+	defer server.Close()
+
+	values := trackerValues(t, &TrackerProvider{
+		Config: TrackerConfig{
+			Source:   TrackerSourceJira,
+			Projects: []string{"WEB"},
+			Jira:     TrackerJira{URL: server.URL, Email: "bot@acme.com", Token: "jira-token"},
+		},
+		AsOf: time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+	})
+	if got := values["tracker.web.cycle_time_days_p50"].Value; got != 4 {
+		t.Errorf("cycle time = %v, want 4 (from the first status change)", got)
+	}
+	if got := values["tracker.web.bug_backlog"]; got.Value != 1 || got.Evidence[0] != server.URL+"/browse/WEB-2" {
+		t.Errorf("bug backlog = %+v", got)
+	}
+}
+
+func TestTrackerProviderLinear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "lin-key" {
+			t.Errorf("authorization = %q", got)
+		}
+		var req struct {
+			Variables struct {
+				After *string `json:"after"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		nodes := []any{map[string]any{
+			"identifier": "ENG-1", "url": "https://linear.app/acme/issue/ENG-1",
+			"createdAt": "2025-01-02T00:00:00Z", "startedAt": "2025-01-04T00:00:00Z", "completedAt": "2025-01-05T12:00:00Z",
+			"team": map[string]any{"key": "ENG"}, "labels": map[string]any{"nodes": []any{}},
+		}}
+		pageInfo := map[string]any{"hasNextPage": true, "endCursor": "c1"}
+		if req.Variables.After != nil {
+			nodes = []any{map[string]any{
+				"identifier": "ENG-2", "url": "https://linear.app/acme/issue/ENG-2",
+				"createdAt": "2025-01-10T00:00:00Z",
+				"team":      map[string]any{"key": "ENG"}, "labels": map[string]any{"nodes": []any{map[string]any{"name": "Bug"}}},
+			}}
+			pageInfo = map[string]any{"hasNextPage": false}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"issues": map[string]any{"nodes": nodes, "pageInfo": pageInfo}}})
+	}))
+	defer server.Close()
+
+	values := trackerValues(t, &TrackerProvider{
+		Config: TrackerConfig{Source: TrackerSourceLinear, Linear: TrackerLinear{APIURL: server.URL, Token: "lin-key"}},
+		AsOf:   time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+	})
+	if got := values["tracker.eng.cycle_time_days_p50"].Value; got != 1.5 {
+		t.Errorf("cycle time = %v, want 1.5", got)
+	}
+	if got := values["tracker.bug_backlog"].Value; got != 1 {
+		t.Errorf("bug backlog = %v, want 1", got)
+	}
+}
+
+func TestLoadTrackerConfig(t *testing.T) {
+	root := t.TempDir()
+	if cfg, err := LoadTrackerConfig(root); err != nil || cfg.Source != "" {
+		t.Fatalf("missing tracker.yml = %+v, %v", cfg, err)
+	}
+	t.Setenv(JiraTokenEnv, "")
+	t.Setenv("OKRCHESTRA_SECRETS_FILE", filepath.Join(t.TempDir(), "secrets.yml"))
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, TrackerConfigFileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("source: jira\njira:\n  url: https://acme.atlassian.net\n")
+	if _, err := LoadTrackerConfig(root); err == nil || !strings.Contains(err.Error(), JiraTokenEnv) {
+		t.Fatalf("missing token error = %v", err)
+	}
+	t.Setenv(JiraTokenEnv, "from-env")
+	cfg, err := LoadTrackerConfig(root)
+	if err != nil || cfg.Jira.Token != "from-env" {
+		t.Fatalf("jira config = %+v, %v", cfg, err)
+	}
+
+	write("export: exports/issues.json\n")
+	if cfg, err = LoadTrackerConfig(root); err != nil || cfg.Export != filepath.Join(root, "exports", "issues.json") {
+		t.Fatalf("export config = %+v, %v", cfg, err)
+	}
+	write("source: asana\n")
+	if _, err := LoadTrackerConfig(root); err == nil {
+		t.Fatal("expected error for unknown source")
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyProducer is a provider that can tell, without collecting, which metric
+// keys it may produce. A key ending in "*" stands for every key with that
+// prefix, for providers whose keys depend on their input.
+type KeyProducer interface {
+	Provider
+	ProducedKeys() []string
+}
+
+func (p *GitProvider) ProducedKeys() []string {
+	return []string{"git.commits_30d", "git.merge_commits_30d", "git.todo_count"}
+}
+
+func (p *CIProvider) ProducedKeys() []string { return []string{"ci.*"} }
+
+func (p *CoverageProvider) ProducedKeys() []string {
+	return []string{"coverage.pct", "tests.count"}
+}
+
+// ProducedKeys lists the keys in the manual metrics file; an unreadable
+// file produces none.
+func (p *ManualProvider) ProducedKeys() []string {
+	points, _ := p.Collect(context.Background())
+	return pointKeys(points)
+}
+
+func (p *OpenMetricsProvider) ProducedKeys() []string {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "openmetrics."
+	}
+	return []string{prefix + "*"}
+}
+
+// ProducedKeys lists every key ever pushed, whatever its timestamp.
+func (p *IntakeProvider) ProducedKeys() []string {
+	all := *p
+	all.AsOf = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	points, _ := all.Collect(context.Background())
+	return pointKeys(points)
+}
+
+// ProducedKeys is empty unless a tracker is configured or its export is
+// present.
+func (p *TrackerProvider) ProducedKeys() []string {
+	if p.Config.Source == "" || p.Config.Source == TrackerSourceExport {
+		if _, err := os.Stat(p.Config.Export); err != nil {
+			return nil
+		}
+	}
+	return []string{"tracker.*"}
+}
+
+func (p *GitHubProvider) ProducedKeys() []string {
+	return []string{"github.prs_merged_30d", "github.pr_review_latency_p50_hours", "github.open_issues"}
+}
+
+func (p *PrometheusProvider) ProducedKeys() []string {
+	keys := make([]string, 0, len(p.Config.Metrics))
+	for key := range p.Config.Metrics {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ProducedKeys lists the derived metrics; an invalid spec produces none.
+func (p *DerivedProvider) ProducedKeys() []string {
+	defs, _ := LoadDerived(p.Path)
+	keys := make([]string, 0, len(defs))
+	for _, m := range defs {
+		keys = append(keys, m.Key)
+	}
+	return keys
+}
+
+func pointKeys(points []MetricPoint) []string {
+	keys := make([]string, 0, len(points))
+	for _, point := range points {
+		keys = append(keys, point.Key)
+	}
+	return keys
+}
+
+// KeyRegistry is the metric keys that some source is known to produce,
+// each with the source that produces it.
+type KeyRegistry struct {
+	keys     map[string]string
+	prefixes map[string]string
+}
+
+// NewKeyRegistry registers the keys of every KeyProducer in providers.
+func NewKeyRegistry(providers []Provider) *KeyRegistry {
+	r := &KeyRegistry{keys: map[string]string{}, prefixes: map[string]string{}}
+	for _, provider := range providers {
+		if producer, ok := provider.(KeyProducer); ok {
+			for _, key := range producer.ProducedKeys() {
+				r.Add(provider.Name(), key)
+			}
+		}
+	}
+	return r
+}
+
+// Add registers key, or with a trailing "*" a key prefix, as produced by
+// source. The first source to register a key keeps it.
+func (r *KeyRegistry) Add(source, key string) {
+	key = strings.TrimSpace(key)
+	if prefix, ok := strings.CutSuffix(key, "*"); ok {
+		if _, exists := r.prefixes[prefix]; !exists {
+			r.prefixes[prefix] = source
+		}
+		return
+	}
+	if _, exists := r.keys[key]; !exists && key != "" {
+		r.keys[key] = source
+	}
+}
+
+// Source returns the source that produces key, and false when none does.
+// Exact keys win over prefixes, and longer prefixes over shorter ones.
+func (r *KeyRegistry) Source(key string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	if source, ok := r.keys[key]; ok {
+		return source, true
+	}
+	best, source := -1, ""
+	for prefix, s := range r.prefixes {
+		if strings.HasPrefix(key, prefix) && len(prefix) > best {
+			best, source = len(prefix), s
+		}
+	}
+	return source, best >= 0
+}
+
+// Known reports whether some source produces key.
+func (r *KeyRegistry) Known(key string) bool {
+	_, ok := r.Source(key)
+	return ok
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyRegistry(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "manual.yml"), []byte("metrics:\n  - key: manual.nps\n    value: 40\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DerivedFileName), []byte("metrics:\n  - key: delivery.deploys_per_day\n    expr: ci.deploys_30d / 30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewKeyRegistry(DefaultProviders(ProviderConfig{
+		MetricsDir:        dir,
+		OpenMetricsPrefix: "svc.",
+		Prometheus:        PrometheusConfig{Metrics: map[string]PrometheusMetric{"api.error_rate": {Query: "up"}}},
+	}))
+	registry.Add("okrchestra.yml", "survey.*")
+
+	for key, want := range map[string]string{
+		"git.commits_30d":          "git",
+		"ci.deploys_30d":           "ci",
+		"coverage.pct":             "coverage",
+		"manual.nps":               "manual",
+		"svc.requests_total":       "openmetrics",
+		"api.error_rate":           "prometheus",
+		"delivery.deploys_per_day": "derived",
+		"survey.enps":              "okrchestra.yml",
+	} {
+		if got, ok := registry.Source(key); !ok || got != want {
+			t.Errorf("%s source = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	// Unconfigured providers produce nothing.
+	for _, key := range []string{"github.open_issues", "tracker.bug_backlog", "manual.other", "openmetrics.up"} {
+		if registry.Known(key) {
+			t.Errorf("%s is known", key)
+		}
+	}
+}
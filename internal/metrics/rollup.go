@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"okrchestra/internal/okrstore"
+)
+
+// ObjectiveRollup summarizes an objective's KRs from the OKR store and a
+// score report.
+type ObjectiveRollup struct {
+	Scope       string `json:"scope"`
+	ObjectiveID string `json:"objective_id"`
+	Objective   string `json:"objective"`
+	OwnerID     string `json:"owner_id,omitempty"`
+	// PercentToTarget averages the scored KRs' percent-to-target, weighted
+	// by confidence (equally when no KR has a confidence). It is nil when
+	// no KR has been scored.
+	PercentToTarget *float64       `json:"percent_to_target"`
+	StatusCounts    map[string]int `json:"status_counts"`
+	MissingMetrics  []string       `json:"missing_metrics,omitempty"`
+	KeyResults      []KRRollup     `json:"key_results"`
+	// AlignedKRIDs are the key results aligned to this objective from
+	// narrower scopes (see okrstore.Store.AlignedKeyResults), and
+	// RollupPercentToTarget averages them together with its own KRs.
+	AlignedKRIDs          []string `json:"aligned_kr_ids,omitempty"`
+	RollupPercentToTarget *float64 `json:"rollup_percent_to_target,omitempty"`
+}
+
+// KRRollup is one KR's line in an objective rollup.
+type KRRollup struct {
+	KRID            string   `json:"kr_id"`
+	Description     string   `json:"description"`
+	Status          string   `json:"status"`
+	Confidence      float64  `json:"confidence"`
+	MetricKey       string   `json:"metric_key,omitempty"`
+	Current         *float64 `json:"current,omitempty"`
+	Target          float64  `json:"target"`
+	PercentToTarget *float64 `json:"percent_to_target"`
+	ProjectedStatus string   `json:"projected_status,omitempty"`
+}
+
+// RollupObjectives rolls up every objective in scope (all scopes when
+// empty), in scope then objective order. report may be nil, in which case
+// only statuses are rolled up.
+func RollupObjectives(store *okrstore.Store, report *KRScoreReport, scope okrstore.Scope) []ObjectiveRollup {
+	scores := map[string]KRScore{}
+	if report != nil {
+		scores = scoresByKR(report.Results)
+	}
+
+	var rollups []ObjectiveRollup
+	for _, group := range []struct {
+		scope okrstore.Scope
+		docs  []okrstore.Document
+	}{
+		{okrstore.ScopeOrg, store.Org.Documents},
+		{okrstore.ScopeTeam, store.Team.Documents},
+		{okrstore.ScopePerson, store.Person.Documents},
+	} {
+		if scope != "" && group.scope != scope {
+			continue
+		}
+		for _, doc := range group.docs {
+			for _, obj := range doc.Objectives {
+				rollup := rollupObjective(group.scope, obj, scores, report != nil)
+				rollup.AlignedKRIDs, rollup.RollupPercentToTarget = rollupAlignment(store, group.scope, obj, scores)
+				rollups = append(rollups, rollup)
+			}
+		}
+	}
+	sort.SliceStable(rollups, func(i, j int) bool {
+		if rollups[i].Scope != rollups[j].Scope {
+			return rollups[i].Scope < rollups[j].Scope
+		}
+		return rollups[i].ObjectiveID < rollups[j].ObjectiveID
+	})
+	return rollups
+}
+
+func rollupObjective(scope okrstore.Scope, obj okrstore.Objective, scores map[string]KRScore, scored bool) ObjectiveRollup {
+	rollup := ObjectiveRollup{
+		Scope:        string(scope),
+		ObjectiveID:  obj.ID,
+		Objective:    obj.Objective,
+		OwnerID:      obj.OwnerID,
+		StatusCounts: map[string]int{},
+	}
+	var avg percentAverage
+	missing := map[string]struct{}{}
+	for _, kr := range obj.KeyResults {
+		line := KRRollup{
+			KRID:        kr.ID,
+			Description: kr.Description,
+			Status:      kr.Status,
+			Confidence:  kr.Confidence,
+			MetricKey:   kr.MetricKey,
+			Current:     kr.Current,
+			Target:      kr.Target,
+		}
+		rollup.StatusCounts[kr.Status]++
+		if score, ok := scores[obj.ID+"\x00"+kr.ID]; ok && score.Current != nil {
+			line.Current = score.Current
+			line.ProjectedStatus = score.ProjectedStatus
+			if !score.BaselinePending {
+				line.PercentToTarget = ptr(score.PercentToTarget)
+				avg.add(score.PercentToTarget, kr.Confidence)
+			}
+		} else if scored && kr.MetricKey != "" {
+			missing[kr.MetricKey] = struct{}{}
+		}
+		rollup.KeyResults = append(rollup.KeyResults, line)
+	}
+	rollup.PercentToTarget = avg.value()
+	for key := range missing {
+		rollup.MissingMetrics = append(rollup.MissingMetrics, key)
+	}
+	sort.Strings(rollup.MissingMetrics)
+	return rollup
+}
+
+// rollupAlignment returns the ids of the KRs aligned to obj and the
+// average percent-to-target of its own and those KRs. Both are nil when
+// nothing is aligned to obj.
+func rollupAlignment(store *okrstore.Store, scope okrstore.Scope, obj okrstore.Objective, scores map[string]KRScore) ([]string, *float64) {
+	aligned := store.AlignedKeyResults(scope, obj.ID)
+	if len(aligned) == 0 {
+		return nil, nil
+	}
+	var avg percentAverage
+	add := func(objectiveID string, kr okrstore.KeyResult) {
+		if score, ok := scores[objectiveID+"\x00"+kr.ID]; ok && score.Current != nil && !score.BaselinePending {
+			avg.add(score.PercentToTarget, kr.Confidence)
+		}
+	}
+	for _, kr := range obj.KeyResults {
+		add(obj.ID, kr)
+	}
+	ids := make([]string, 0, len(aligned))
+	for _, rec := range aligned {
+		ids = append(ids, rec.KeyResult.ID)
+		add(rec.Objective.ID, rec.KeyResult)
+	}
+	return ids, avg.value()
+}
+
+// percentAverage averages percent-to-target values weighted by
+// confidence, equally when none has a confidence.
+type percentAverage struct {
+	weighted, weights, plain float64
+	n                        int
+}
+
+func (a *percentAverage) add(pct, confidence float64) {
+	a.weighted += pct * confidence
+	a.weights += confidence
+	a.plain += pct
+	a.n++
+}
+
+// value returns the average, or nil when nothing was added.
+func (a percentAverage) value() *float64 {
+	switch {
+	case a.weights > 0:
+		return ptr(a.weighted / a.weights)
+	case a.n > 0:
+		return ptr(a.plain / float64(a.n))
+	}
+	return nil
+}
+
+// scoresByKR indexes score results by objective and KR id.
+func scoresByKR(results []KRScore) map[string]KRScore {
+	scores := make(map[string]KRScore, len(results))
+	for _, result := range results {
+		scores[result.ObjectiveID+"\x00"+result.KRID] = result
+	}
+	return scores
+}
+
+// LatestScoreReportPath returns the newest kr_score_<as-of>.json in
+// artifactsDir, or "" when there is none.
+func LatestScoreReportPath(artifactsDir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(artifactsDir, "kr_score_*.json"))
+	if err != nil {
+		return "", fmt.Errorf("find score reports: %w", err)
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+	// kr_score_YYYY-MM-DD.json sorts chronologically.
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// LoadScoreReport reads a report written by WriteScoreReport.
+func LoadScoreReport(path string) (*KRScoreReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read score report: %w", err)
+	}
+	var report KRScoreReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("decode score report %s: %w", filepath.Base(path), err)
+	}
+	if report.SchemaVersion != KRScoreSchemaVersion {
+		return nil, fmt.Errorf("unsupported score report schema_version %d", report.SchemaVersion)
+	}
+	if strings.TrimSpace(report.AsOf) == "" {
+		return nil, fmt.Errorf("score report missing as_of")
+	}
+	return &report, nil
+}
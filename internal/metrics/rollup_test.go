@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"okrchestra/internal/okrstore"
+)
+
+func TestRollupObjectives(t *testing.T) {
+	store := &okrstore.Store{
+		Org: okrstore.OrgOKRs{Documents: []okrstore.Document{{Objectives: []okrstore.Objective{{
+			ID: "OBJ-1",
+			KeyResults: []okrstore.KeyResult{
+				{ID: "KR-1", MetricKey: "a", Target: 10, Confidence: 0.9, Status: "in_progress"},
+				{ID: "KR-2", MetricKey: "b", Target: 10, Confidence: 0.1, Status: "achieved"},
+				{ID: "KR-3", MetricKey: "c", Target: 10, Confidence: 0.5, Status: "not_started"},
+			},
+		}}}}},
+		Team: okrstore.TeamOKRs{Documents: []okrstore.Document{{Objectives: []okrstore.Objective{{
+			ID:         "OBJ-T",
+			KeyResults: []okrstore.KeyResult{{ID: "KR-T", MetricKey: "a", Target: 10, Status: "in_progress"}},
+		}}}}},
+	}
+	report := &KRScoreReport{
+		SchemaVersion: KRScoreSchemaVersion,
+		AsOf:          "2025-01-10",
+		Results: []KRScore{
+			{ObjectiveID: "OBJ-1", KRID: "KR-1", Current: ptr(5), PercentToTarget: 50, ProjectedStatus: ProjectedAtRisk},
+			{ObjectiveID: "OBJ-1", KRID: "KR-2", Current: ptr(10), PercentToTarget: 100},
+			{ObjectiveID: "OBJ-1", KRID: "KR-3", MetricKey: "c"},
+			{ObjectiveID: "OBJ-T", KRID: "KR-T", Current: ptr(2), PercentToTarget: 20},
+		},
+	}
+
+	rollups := RollupObjectives(store, report, "")
+	if len(rollups) != 2 || rollups[0].ObjectiveID != "OBJ-1" || rollups[1].Scope != "team" {
+		t.Fatalf("unexpected rollups: %+v", rollups)
+	}
+	org := rollups[0]
+	// (50*0.9 + 100*0.1) / (0.9 + 0.1); KR-3 is missing its metric.
+	if org.PercentToTarget == nil || *org.PercentToTarget != 55 {
+		t.Fatalf("org percent = %v", org.PercentToTarget)
+	}
+	if len(org.MissingMetrics) != 1 || org.MissingMetrics[0] != "c" {
+		t.Fatalf("missing metrics = %v", org.MissingMetrics)
+	}
+	if org.StatusCounts["achieved"] != 1 || org.StatusCounts["in_progress"] != 1 || org.KeyResults[0].ProjectedStatus != ProjectedAtRisk {
+		t.Fatalf("unexpected KR lines: %+v", org)
+	}
+	// Without confidences the average is unweighted.
+	if team := rollups[1]; team.PercentToTarget == nil || *team.PercentToTarget != 20 {
+		t.Fatalf("team percent = %v", team.PercentToTarget)
+	}
+
+	if scoped := RollupObjectives(store, nil, okrstore.ScopeTeam); len(scoped) != 1 || scoped[0].PercentToTarget != nil || scoped[0].MissingMetrics != nil {
+		t.Fatalf("unscored team rollup = %+v", scoped)
+	}
+}
+
+func TestLatestScoreReport(t *testing.T) {
+	dir := t.TempDir()
+	if path, err := LatestScoreReportPath(dir); err != nil || path != "" {
+		t.Fatalf("empty dir = %q, %v", path, err)
+	}
+	for _, asOf := range []string{"2025-01-09", "2025-01-10"} {
+		report := &KRScoreReport{SchemaVersion: KRScoreSchemaVersion, AsOf: asOf}
+		if err := WriteScoreReport(filepath.Join(dir, "kr_score_"+asOf+".json"), report); err != nil {
+			t.Fatalf("write report: %v", err)
+		}
+	}
+	path, err := LatestScoreReportPath(dir)
+	if err != nil {
+		t.Fatalf("latest: %v", err)
+	}
+	report, err := LoadScoreReport(path)
+	if err != nil || report.AsOf != "2025-01-10" {
+		t.Fatalf("load latest = %+v, %v", report, err)
+	}
+}
+
+func TestRollupObjectivesIncludesAlignedKRs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"org.yml": `scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Org
+    owner_id: team-alpha
+    key_results:
+      - {kr_id: KR-1, description: d, owner_id: team-alpha, metric_key: a, baseline: 0, target: 10, confidence: 0.5, status: in_progress, evidence: []}
+`,
+		"team.yml": `scope: team
+objectives:
+  - objective_id: OBJ-T
+    objective: Team
+    owner_id: team-alpha
+    aligns_to: [OBJ-1]
+    key_results:
+      - {kr_id: KR-T, description: d, owner_id: team-alpha, metric_key: b, baseline: 0, target: 10, confidence: 0.5, status: in_progress, evidence: []}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	store, err := okrstore.LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	snapshot := &Snapshot{AsOf: "2025-01-10", Points: []MetricPoint{{Key: "a", Value: 2}, {Key: "b", Value: 8}}}
+	report, err := ScoreKRs(store, snapshot, "")
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if len(report.Rollups) != 1 || report.Rollups[0].ObjectiveID != "OBJ-1" || report.Rollups[0].PercentToTarget == nil ||
+		*report.Rollups[0].PercentToTarget != 50 {
+		t.Fatalf("report rollups = %+v", report.Rollups)
+	}
+
+	rollups := RollupObjectives(store, report, okrstore.ScopeOrg)
+	if len(rollups) != 1 || *rollups[0].PercentToTarget != 20 {
+		t.Fatalf("org rollup = %+v", rollups)
+	}
+	if got := rollups[0]; len(got.AlignedKRIDs) != 1 || got.AlignedKRIDs[0] != "KR-T" || *got.RollupPercentToTarget != 50 {
+		t.Fatalf("aligned rollup = %+v", got)
+	}
+}
@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"okrchestra/internal/okrstore"
@@ -20,6 +23,22 @@ type KRScore struct {
 	Current         *float64 `json:"current,omitempty"`
 	Unit            string   `json:"unit,omitempty"`
 	PercentToTarget float64  `json:"percent_to_target"`
+	// BaselinePending marks `baseline: null`; PercentToTarget stays 0 until
+	// a baseline is set.
+	BaselinePending bool `json:"baseline_pending,omitempty"`
+	// Annotations are human notes on this metric for the report's as-of date.
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// VelocityPerDay, ForecastDate, and ProjectedStatus come from the
+	// metric's recent history (see ApplyTrends); ForecastDate is empty when
+	// the target is met or out of reach at the current velocity.
+	VelocityPerDay  *float64 `json:"velocity_per_day,omitempty"`
+	ForecastDate    string   `json:"forecast_date,omitempty"`
+	Deadline        string   `json:"deadline,omitempty"`
+	ProjectedStatus string   `json:"projected_status,omitempty"`
+	// Stale marks a metric observed longer ago than its max age (see
+	// ApplyFreshness); ObservedAt is when it was observed.
+	Stale      bool   `json:"stale,omitempty"`
+	ObservedAt string `json:"observed_at,omitempty"`
 }
 
 type KRScoreReport struct {
@@ -28,6 +47,28 @@ type KRScoreReport struct {
 	SnapshotPath      string    `json:"snapshot_path"`
 	Results           []KRScore `json:"results"`
 	MissingMetricKeys []string  `json:"missing_metric_keys,omitempty"`
+	// StaleMetricKeys are the metrics of stale KRs.
+	StaleMetricKeys []string `json:"stale_metric_keys,omitempty"`
+	// ProviderErrors are carried over from the snapshot so a missing
+	// metric can be told apart from a failed provider.
+	ProviderErrors []ProviderError `json:"provider_errors,omitempty"`
+	// TrendWindowDays is the history window trends were fitted to.
+	TrendWindowDays int `json:"trend_window_days,omitempty"`
+	// Period is set when only KRs of one OKR period were scored.
+	Period string `json:"period,omitempty"`
+	// Rollups covers the objectives that have KRs aligned to them.
+	Rollups []AlignmentRollup `json:"rollups,omitempty"`
+}
+
+// AlignmentRollup is an objective's progress including the KRs aligned to
+// it from narrower scopes: PercentToTarget averages its own and the
+// aligned KRs' percent-to-target, weighted by confidence, and is nil when
+// none of them has been scored.
+type AlignmentRollup struct {
+	Scope           string   `json:"scope"`
+	ObjectiveID     string   `json:"objective_id"`
+	AlignedKRIDs    []string `json:"aligned_kr_ids"`
+	PercentToTarget *float64 `json:"percent_to_target"`
 }
 
 const KRScoreSchemaVersion = 1
@@ -64,19 +105,22 @@ func ScoreKRs(store *okrstore.Store, snapshot *Snapshot, snapshotPath string) (*
 			for _, obj := range doc.Objectives {
 				for _, kr := range obj.KeyResults {
 					score := KRScore{
-						Scope:       string(scope),
-						ObjectiveID: obj.ID,
-						Objective:   obj.Objective,
-						KRID:        kr.ID,
-						Description: kr.Description,
-						MetricKey:   kr.MetricKey,
-						Baseline:    kr.Baseline,
-						Target:      kr.Target,
+						Scope:           string(scope),
+						ObjectiveID:     obj.ID,
+						Objective:       obj.Objective,
+						KRID:            kr.ID,
+						Description:     kr.Description,
+						MetricKey:       kr.MetricKey,
+						Baseline:        kr.Baseline,
+						Target:          kr.Target,
+						BaselinePending: kr.BaselinePending,
 					}
 					if point, ok := metricValues[kr.MetricKey]; ok {
 						score.Current = ptr(point.Value)
 						score.Unit = point.Unit
-						score.PercentToTarget = percentToTarget(kr.Baseline, kr.Target, point.Value)
+						if !kr.BaselinePending {
+							score.PercentToTarget = percentToTarget(kr.Baseline, kr.Target, point.Value)
+						}
 					} else {
 						score.Current = nil
 						score.PercentToTarget = 0
@@ -118,9 +162,67 @@ func ScoreKRs(store *okrstore.Store, snapshot *Snapshot, snapshotPath string) (*
 		SnapshotPath:      snapshotPath,
 		Results:           results,
 		MissingMetricKeys: missingKeys,
+		ProviderErrors:    snapshot.ProviderErrors,
+		Rollups:           alignmentRollups(store, results),
 	}, nil
 }
 
+// alignmentRollups rolls up the objectives in store that have KRs aligned
+// to them, in scope then objective order.
+func alignmentRollups(store *okrstore.Store, results []KRScore) []AlignmentRollup {
+	scores := scoresByKR(results)
+	var rollups []AlignmentRollup
+	for _, group := range []struct {
+		scope okrstore.Scope
+		docs  []okrstore.Document
+	}{
+		{okrstore.ScopeOrg, store.Org.Documents},
+		{okrstore.ScopeTeam, store.Team.Documents},
+		{okrstore.ScopePerson, store.Person.Documents},
+	} {
+		for _, doc := range group.docs {
+			for _, obj := range doc.Objectives {
+				ids, pct := rollupAlignment(store, group.scope, obj, scores)
+				if ids == nil {
+					continue
+				}
+				rollups = append(rollups, AlignmentRollup{
+					Scope:           string(group.scope),
+					ObjectiveID:     obj.ID,
+					AlignedKRIDs:    ids,
+					PercentToTarget: pct,
+				})
+			}
+		}
+	}
+	sort.SliceStable(rollups, func(i, j int) bool {
+		if rollups[i].Scope != rollups[j].Scope {
+			return rollups[i].Scope < rollups[j].Scope
+		}
+		return rollups[i].ObjectiveID < rollups[j].ObjectiveID
+	})
+	return rollups
+}
+
+// WriteScoreReport writes the report as indented JSON, creating parent directories.
+func WriteScoreReport(path string, report *KRScoreReport) error {
+	if report == nil {
+		return fmt.Errorf("score report is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure score report dir: %w", err)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal score report: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write score report: %w", err)
+	}
+	return nil
+}
+
 func percentToTarget(baseline, target, current float64) float64 {
 	if baseline == target {
 		if current >= target {
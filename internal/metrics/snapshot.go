@@ -17,6 +17,9 @@ type Snapshot struct {
 	SchemaVersion int           `json:"schema_version"`
 	AsOf          string        `json:"as_of"`
 	Points        []MetricPoint `json:"points"`
+	// ProviderErrors lists providers skipped because they failed; their
+	// metrics are missing from Points.
+	ProviderErrors []ProviderError `json:"provider_errors,omitempty"`
 }
 
 func WriteSnapshot(path string, snapshot Snapshot) error {
@@ -88,9 +91,21 @@ func SnapshotPathForDate(dir string, asOf time.Time) string {
 }
 
 func LatestSnapshotPath(dir string) (string, error) {
+	candidates, err := SnapshotPaths(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no snapshots found in %s", dir)
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// SnapshotPaths returns the snapshot files in dir, oldest first.
+func SnapshotPaths(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return "", fmt.Errorf("read snapshots dir: %w", err)
+		return nil, fmt.Errorf("read snapshots dir: %w", err)
 	}
 	var candidates []string
 	for _, ent := range entries {
@@ -104,9 +119,19 @@ func LatestSnapshotPath(dir string) (string, error) {
 		// YYYY-MM-DD.json compares lexicographically in chronological order.
 		candidates = append(candidates, filepath.Join(dir, name))
 	}
-	if len(candidates) == 0 {
-		return "", fmt.Errorf("no snapshots found in %s", dir)
-	}
 	sort.Strings(candidates)
-	return candidates[len(candidates)-1], nil
+	return candidates, nil
+}
+
+// Value returns the undimensioned point for key, the value KRs are scored on.
+func (s *Snapshot) Value(key string) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	for _, point := range s.Points {
+		if point.Key == key && len(point.Dimensions) == 0 {
+			return point.Value, true
+		}
+	}
+	return 0, false
 }
@@ -4,36 +4,110 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
-
 	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
 )
 
 // StatusChange represents a change in KR status.
 type StatusChange struct {
-	KRID       string
-	OldStatus  string
-	NewStatus  string
-	Current    float64
-	Target     float64
-	Evidence   string
-	KRDesc     string
+	KRID        string
+	OldStatus   string
+	NewStatus   string
+	Current     float64
+	Baseline    float64
+	Target      float64
+	Evidence    string
+	KRDesc      string
 	ObjectiveID string
+	MetricKey   string
 }
 
+// StatusAgentID is the default agent that proposes KR status updates.
+const StatusAgentID = "okrchestra-status"
+
 // UpdateKRStatus updates KR status fields based on metric snapshots.
 // It returns a list of status changes for notification purposes.
 func UpdateKRStatus(okrsDir string, snapshot *Snapshot) ([]StatusChange, error) {
 	if okrsDir == "" {
 		okrsDir = "okrs"
 	}
+	changes, docs, err := statusChanges(okrsDir, snapshot)
+	if err != nil {
+		return nil, err
+	}
 
-	// Load current OKR store
+	// Write back every changed file
+	for _, doc := range docs {
+		rel, err := filepath.Rel(okrsDir, doc.Source)
+		if err != nil {
+			return changes, fmt.Errorf("history %s: %w", doc.Source, err)
+		}
+		if _, err := okrstore.RecordHistory(okrsDir, []string{filepath.ToSlash(rel)}, okrstore.HistoryChange{
+			Reason: okrstore.HistoryReasonStatusUpdate,
+		}); err != nil {
+			return changes, err
+		}
+		if err := okrstore.WriteDocument(doc, doc.Source); err != nil {
+			return changes, fmt.Errorf("write %s: %w", doc.Source, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// ProposeKRStatus computes the same changes as UpdateKRStatus but writes
+// the changed documents to an updates dir and proposes them as agentID
+// instead of editing okrs/. The proposal is nil when nothing changed.
+func ProposeKRStatus(ws *workspace.Workspace, snapshot *Snapshot, agentID string) ([]StatusChange, *okrstore.ProposalMetadata, error) {
+	changes, docs, err := statusChanges(ws.OKRsDir, snapshot)
+	if err != nil || len(docs) == 0 {
+		return changes, nil, err
+	}
+	updatesDir := filepath.Join(ws.ArtifactsDir, "status", time.Now().UTC().Format("20060102T150405Z"), "updates")
+	for _, doc := range docs {
+		rel, err := filepath.Rel(ws.OKRsDir, doc.Source)
+		if err != nil {
+			return changes, nil, fmt.Errorf("locate %s: %w", doc.Source, err)
+		}
+		dst := filepath.Join(updatesDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return changes, nil, fmt.Errorf("ensure status updates dir: %w", err)
+		}
+		if err := okrstore.WriteDocument(doc, dst); err != nil {
+			return changes, nil, err
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(ws.OKRsDir, "permissions.yml")); err == nil {
+		if err := os.WriteFile(filepath.Join(updatesDir, "permissions.yml"), data, 0o644); err != nil {
+			return changes, nil, fmt.Errorf("copy permissions: %w", err)
+		}
+	}
+
+	notes := make([]string, 0, len(changes))
+	for _, change := range changes {
+		notes = append(notes, fmt.Sprintf("%s %s -> %s (%s=%g)", change.KRID, change.OldStatus, change.NewStatus, change.MetricKey, change.Current))
+	}
+	note := fmt.Sprintf("KR status from the %s metric snapshot: %s", snapshot.AsOf, strings.Join(notes, ", "))
+	meta, err := okrstore.CreateProposalIn(ws.Root, agentID, updatesDir, ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "proposals"), note)
+	if err != nil {
+		return changes, nil, fmt.Errorf("propose kr status: %w", err)
+	}
+	return changes, meta, nil
+}
+
+// statusChanges loads the org OKRs and applies snapshot to their KR
+// statuses in memory. It returns the changes and the changed documents.
+func statusChanges(okrsDir string, snapshot *Snapshot) ([]StatusChange, []okrstore.Document, error) {
 	store, err := okrstore.LoadFromDir(okrsDir)
 	if err != nil {
-		return nil, fmt.Errorf("load okrs: %w", err)
+		return nil, nil, fmt.Errorf("load okrs: %w", err)
+	}
+	rules, err := okrstore.LoadRules(okrsDir)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Build map of metric_key -> current value
@@ -42,62 +116,56 @@ func UpdateKRStatus(okrsDir string, snapshot *Snapshot) ([]StatusChange, error)
 		metricValues[point.Key] = point.Value
 	}
 
-	// Track status changes
 	var changes []StatusChange
-
-	// Update status for each KR based on metrics
+	var docs []okrstore.Document
 	for _, doc := range store.Org.Documents {
 		updated := false
 		for objIdx := range doc.Objectives {
 			for krIdx := range doc.Objectives[objIdx].KeyResults {
 				kr := &doc.Objectives[objIdx].KeyResults[krIdx]
-				
+
 				// Check if we have a metric value for this KR
 				currentVal, hasMetric := metricValues[kr.MetricKey]
-				if !hasMetric {
+				if !hasMetric || kr.BaselinePending {
 					continue
 				}
 
 				// Determine new status based on progress
 				oldStatus := kr.Status
 				newStatus := determineStatus(currentVal, kr.Baseline, kr.Target, oldStatus)
-
-				// Update if status changed
-				if newStatus != oldStatus {
-					kr.Status = newStatus
-					kr.Current = &currentVal
-					kr.LastUpdated = time.Now().UTC().Format(time.RFC3339)
-					
-					// Add evidence reference to snapshot
-					evidencePath := fmt.Sprintf("metrics/snapshots/%s", filepath.Base(snapshot.AsOf))
-					if !contains(kr.Evidence, evidencePath) {
-						kr.Evidence = append(kr.Evidence, evidencePath)
-					}
-					
-					updated = true
-					changes = append(changes, StatusChange{
-						KRID:        kr.ID,
-						OldStatus:   oldStatus,
-						NewStatus:   newStatus,
-						Current:     currentVal,
-						Target:      kr.Target,
-						Evidence:    evidencePath,
-						KRDesc:      kr.Description,
-						ObjectiveID: doc.Objectives[objIdx].ID,
-					})
+				if newStatus == oldStatus {
+					continue
+				}
+				kr.Status = newStatus
+				kr.Current = &currentVal
+				kr.LastUpdated = time.Now().UTC().Format(time.RFC3339)
+
+				// Add evidence reference to snapshot
+				evidencePath := rules.EvidenceRef(okrstore.EvidenceSchemeSnapshot, fmt.Sprintf("metrics/snapshots/%s", filepath.Base(snapshot.AsOf)))
+				if !contains(kr.Evidence, evidencePath) {
+					kr.Evidence = append(kr.Evidence, evidencePath)
 				}
+
+				updated = true
+				changes = append(changes, StatusChange{
+					KRID:        kr.ID,
+					OldStatus:   oldStatus,
+					NewStatus:   newStatus,
+					Current:     currentVal,
+					Baseline:    kr.Baseline,
+					Target:      kr.Target,
+					Evidence:    evidencePath,
+					KRDesc:      kr.Description,
+					ObjectiveID: doc.Objectives[objIdx].ID,
+					MetricKey:   kr.MetricKey,
+				})
 			}
 		}
-
-		// Write back to file if any changes
 		if updated {
-			if err := writeDocumentToYAML(doc, doc.Source); err != nil {
-				return changes, fmt.Errorf("write %s: %w", doc.Source, err)
-			}
+			docs = append(docs, doc)
 		}
 	}
-
-	return changes, nil
+	return changes, docs, nil
 }
 
 // determineStatus calculates the appropriate status based on progress.
@@ -107,6 +175,11 @@ func determineStatus(current, baseline, target float64, oldStatus string) string
 		return oldStatus
 	}
 
+	// A target below the baseline means lower is better.
+	if target < baseline {
+		current, baseline, target = -current, -baseline, -target
+	}
+
 	// Check if achieved
 	if current >= target {
 		return "achieved"
@@ -129,100 +202,3 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
-
-// writeDocumentToYAML writes a Document back to its source YAML file.
-func writeDocumentToYAML(doc okrstore.Document, path string) error {
-	// Convert to raw format for YAML marshaling
-	type rawKeyResult struct {
-		ID          string   `yaml:"kr_id"`
-		Description string   `yaml:"description"`
-		OwnerID     string   `yaml:"owner_id"`
-		MetricKey   string   `yaml:"metric_key"`
-		Baseline    *float64 `yaml:"baseline"`
-		Target      *float64 `yaml:"target"`
-		Confidence  *float64 `yaml:"confidence"`
-		Status      string   `yaml:"status"`
-		Evidence    []string `yaml:"evidence"`
-		Current     *float64 `yaml:"current,omitempty"`
-		LastUpdated string   `yaml:"last_updated,omitempty"`
-	}
-
-	type rawObjective struct {
-		ID         string         `yaml:"objective_id"`
-		Title      string         `yaml:"objective"`
-		OwnerID    string         `yaml:"owner_id,omitempty"`
-		Notes      string         `yaml:"notes,omitempty"`
-		KeyResults []rawKeyResult `yaml:"key_results"`
-	}
-
-	type rawDocument struct {
-		Scope      string         `yaml:"scope"`
-		Objectives []rawObjective `yaml:"objectives"`
-	}
-
-	raw := rawDocument{
-		Scope:      string(doc.Scope),
-		Objectives: make([]rawObjective, len(doc.Objectives)),
-	}
-
-	for i, obj := range doc.Objectives {
-		rawObj := rawObjective{
-			ID:         obj.ID,
-			Title:      obj.Objective,
-			OwnerID:    obj.OwnerID,
-			Notes:      obj.Notes,
-			KeyResults: make([]rawKeyResult, len(obj.KeyResults)),
-		}
-
-		for j, kr := range obj.KeyResults {
-			rawKR := rawKeyResult{
-				ID:          kr.ID,
-				Description: kr.Description,
-				OwnerID:     kr.OwnerID,
-				MetricKey:   kr.MetricKey,
-				Baseline:    &kr.Baseline,
-				Target:      &kr.Target,
-				Confidence:  &kr.Confidence,
-				Status:      kr.Status,
-				Evidence:    kr.Evidence,
-				Current:     kr.Current,
-				LastUpdated: kr.LastUpdated,
-			}
-			rawObj.KeyResults[j] = rawKR
-		}
-
-		raw.Objectives[i] = rawObj
-	}
-
-	// Marshal to YAML
-	data, err := yaml.Marshal(&raw)
-	if err != nil {
-		return fmt.Errorf("marshal yaml: %w", err)
-	}
-
-	// Write atomically via temp file
-	dir := filepath.Dir(path)
-	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-	tmpPath := tmpFile.Name()
-	defer func() {
-		_ = os.Remove(tmpPath)
-	}()
-
-	if _, err := tmpFile.Write(data); err != nil {
-		_ = tmpFile.Close()
-		return fmt.Errorf("write temp file: %w", err)
-	}
-	if err := tmpFile.Close(); err != nil {
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	// Atomic rename
-	if err := os.Rename(tmpPath, path); err != nil {
-		return fmt.Errorf("rename temp file: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
+)
+
+func TestProposeKRStatus(t *testing.T) {
+	root := t.TempDir()
+	ws, err := workspace.Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ws.OKRsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	okrs := `scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Objective
+    key_results:
+      - kr_id: KR-1
+        description: Pass rate
+        owner_id: team
+        metric_key: ci.pass_rate
+        baseline: 0.5
+        target: 0.9
+        confidence: 0.5
+        status: not_started
+        evidence: []
+`
+	orgPath := filepath.Join(ws.OKRsDir, "org.yml")
+	if err := os.WriteFile(orgPath, []byte(okrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	perms := "permissions:\n  write: [delegated_explicitly]\ndelegations:\n  team: [" + StatusAgentID + "]\n"
+	if err := os.WriteFile(filepath.Join(ws.OKRsDir, "permissions.yml"), []byte(perms), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &Snapshot{AsOf: "2026-03-02", Points: []MetricPoint{{Key: "ci.pass_rate", Value: 0.7}}}
+	changes, meta, err := ProposeKRStatus(ws, snapshot, StatusAgentID)
+	if err != nil {
+		t.Fatalf("ProposeKRStatus: %v", err)
+	}
+	if len(changes) != 1 || changes[0].NewStatus != "in_progress" || meta == nil {
+		t.Fatalf("changes = %+v, proposal = %+v", changes, meta)
+	}
+	if !strings.Contains(meta.Note, "KR-1 not_started -> in_progress") {
+		t.Fatalf("note = %q", meta.Note)
+	}
+	data, err := os.ReadFile(filepath.Join(meta.ProposalDir, "org.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed, err := okrstore.ParseAndValidateDocument(data, "org.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr := proposed.Objectives[0].KeyResults[0]; kr.Status != "in_progress" || kr.Current == nil || *kr.Current != 0.7 {
+		t.Fatalf("proposed KR = %+v", kr)
+	}
+	if current, err := os.ReadFile(orgPath); err != nil || string(current) != okrs {
+		t.Fatalf("okrs/org.yml was modified: %v", err)
+	}
+
+	// Nothing to change means no proposal.
+	snapshot.Points[0].Value = 0.5
+	if changes, meta, err := ProposeKRStatus(ws, snapshot, StatusAgentID); err != nil || len(changes) != 0 || meta != nil {
+		t.Fatalf("unchanged: changes = %+v, proposal = %+v, err = %v", changes, meta, err)
+	}
+}
+
+func TestDetermineStatusLowerIsBetter(t *testing.T) {
+	cases := []struct {
+		current, baseline, target float64
+		want                      string
+	}{
+		{current: 40, baseline: 40, target: 20, want: "not_started"},
+		{current: 30, baseline: 40, target: 20, want: "in_progress"},
+		{current: 18, baseline: 40, target: 20, want: "achieved"},
+		{current: 50, baseline: 40, target: 20, want: "not_started"},
+		{current: 12, baseline: 10, target: 20, want: "in_progress"},
+	}
+	for _, tc := range cases {
+		if got := determineStatus(tc.current, tc.baseline, tc.target, "not_started"); got != tc.want {
+			t.Errorf("determineStatus(%v, %v, %v) = %s, want %s", tc.current, tc.baseline, tc.target, got, tc.want)
+		}
+	}
+}
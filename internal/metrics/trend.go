@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultTrendWindowDays is how many days of history a trend is fitted to.
+const DefaultTrendWindowDays = 30
+
+// Projected statuses, from a KR's trend toward its deadline.
+const (
+	ProjectedOnTrack  = "on_track"
+	ProjectedAtRisk   = "at_risk"
+	ProjectedOffTrack = "off_track"
+)
+
+// Trend is a linear fit of a metric's recent daily values.
+type Trend struct {
+	// VelocityPerDay is the fitted change per day.
+	VelocityPerDay float64
+	// Samples is the number of days the fit used.
+	Samples int
+	// ForecastDate is when the fit reaches the target (YYYY-MM-DD); empty
+	// when the target is already met or the metric is not moving toward it.
+	ForecastDate string
+	// Deadline is the date the forecast is judged against.
+	Deadline string
+	// ProjectedStatus is on_track when the target is met or forecast by the
+	// deadline, at_risk when forecast after it, and off_track when the
+	// metric is flat or moving away from the target.
+	ProjectedStatus string
+}
+
+// TrendDeadline is the date KRs are projected to: the last day of the
+// calendar quarter containing asOf.
+func TrendDeadline(asOf time.Time) time.Time {
+	asOf = asOf.UTC()
+	firstMonth := time.Month((int(asOf.Month())-1)/3*3 + 1)
+	return time.Date(asOf.Year(), firstMonth+3, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+}
+
+// Trend fits the undimensioned values of key over the windowDays ending at
+// asOf, with current standing in for asOf's value. It reports false when
+// fewer than two days have values.
+func (h *History) Trend(key string, baseline, target, current float64, asOf time.Time, windowDays int) (Trend, bool, error) {
+	if windowDays <= 0 {
+		windowDays = DefaultTrendWindowDays
+	}
+	asOf = asOf.UTC().Truncate(24 * time.Hour)
+	since := asOf.AddDate(0, 0, -(windowDays - 1))
+	points, err := h.Query(key, since.Format("2006-01-02"))
+	if err != nil {
+		return Trend{}, false, err
+	}
+	asOfDate := asOf.Format("2006-01-02")
+	var days, values []float64
+	for _, p := range points {
+		if p.Dimensions != "" || p.AsOf >= asOfDate {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", p.AsOf)
+		if err != nil {
+			return Trend{}, false, fmt.Errorf("history date %q: %w", p.AsOf, err)
+		}
+		days = append(days, date.Sub(since).Hours()/24)
+		values = append(values, p.Value)
+	}
+	days = append(days, asOf.Sub(since).Hours()/24)
+	values = append(values, current)
+	if len(days) < 2 {
+		return Trend{}, false, nil
+	}
+	return projectTrend(days, values, baseline, target, current, asOf), true, nil
+}
+
+func projectTrend(days, values []float64, baseline, target, current float64, asOf time.Time) Trend {
+	deadline := TrendDeadline(asOf)
+	trend := Trend{
+		VelocityPerDay: slope(days, values),
+		Samples:        len(days),
+		Deadline:       deadline.Format("2006-01-02"),
+	}
+	remaining := target - current
+	increasing := target >= baseline
+	if (increasing && remaining <= 0) || (!increasing && remaining >= 0) {
+		trend.ProjectedStatus = ProjectedOnTrack
+		return trend
+	}
+	if trend.VelocityPerDay == 0 || math.Signbit(trend.VelocityPerDay) != math.Signbit(remaining) {
+		trend.ProjectedStatus = ProjectedOffTrack
+		return trend
+	}
+	daysNeeded := math.Ceil(remaining / trend.VelocityPerDay)
+	if daysNeeded > 100*365 {
+		// Technically moving, but not within any planning horizon.
+		trend.ProjectedStatus = ProjectedOffTrack
+		return trend
+	}
+	forecast := asOf.AddDate(0, 0, int(daysNeeded))
+	trend.ForecastDate = forecast.Format("2006-01-02")
+	if forecast.After(deadline) {
+		trend.ProjectedStatus = ProjectedAtRisk
+	} else {
+		trend.ProjectedStatus = ProjectedOnTrack
+	}
+	return trend
+}
+
+// slope is the least-squares slope of ys over xs.
+func slope(xs, ys []float64) float64 {
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	n := float64(len(xs))
+	meanX /= n
+	meanY /= n
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - meanX) * (ys[i] - meanY)
+		den += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// ApplyTrends adds each scored KR's velocity, forecast, and projected status
+// from the history in snapshotsDir. KRs without a current value, with a
+// pending baseline, or with under two days of history are left without a
+// trend.
+func ApplyTrends(report *KRScoreReport, snapshotsDir string, windowDays int) error {
+	if report == nil {
+		return nil
+	}
+	if windowDays <= 0 {
+		windowDays = DefaultTrendWindowDays
+	}
+	asOf, err := time.Parse("2006-01-02", report.AsOf)
+	if err != nil {
+		return fmt.Errorf("parse report as_of: %w", err)
+	}
+	history, err := OpenHistory(snapshotsDir)
+	if err != nil {
+		return err
+	}
+	defer history.Close()
+
+	report.TrendWindowDays = windowDays
+	for i := range report.Results {
+		result := &report.Results[i]
+		if result.Current == nil || result.BaselinePending || result.MetricKey == "" {
+			continue
+		}
+		trend, ok, err := history.Trend(result.MetricKey, result.Baseline, result.Target, *result.Current, asOf, windowDays)
+		if err != nil {
+			return fmt.Errorf("%s trend: %w", result.KRID, err)
+		}
+		if !ok {
+			continue
+		}
+		result.VelocityPerDay = ptr(trend.VelocityPerDay)
+		result.ForecastDate = trend.ForecastDate
+		result.Deadline = trend.Deadline
+		result.ProjectedStatus = trend.ProjectedStatus
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"okrchestra/internal/okrstore"
+)
+
+func TestTrendDeadline(t *testing.T) {
+	for asOf, want := range map[string]string{
+		"2025-01-01": "2025-03-31",
+		"2025-05-15": "2025-06-30",
+		"2025-12-31": "2025-12-31",
+	} {
+		date, _ := time.Parse("2006-01-02", asOf)
+		if got := TrendDeadline(date).Format("2006-01-02"); got != want {
+			t.Errorf("TrendDeadline(%s) = %s, want %s", asOf, got, want)
+		}
+	}
+}
+
+func TestApplyTrends(t *testing.T) {
+	dir := t.TempDir()
+	// Coverage climbs 1 point a day from 60; latency is flat.
+	for i := 0; i < 10; i++ {
+		asOf := time.Date(2025, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		snapshot := Snapshot{
+			AsOf: asOf.Format("2006-01-02"),
+			Points: []MetricPoint{
+				{Key: "ci.coverage", Value: 60 + float64(i), Timestamp: AsOfTimestamp(asOf), Source: "ci"},
+				{Key: "api.latency_ms", Value: 300, Timestamp: AsOfTimestamp(asOf), Source: "prometheus"},
+				{Key: "ci.pass_rate", Value: 99, Timestamp: AsOfTimestamp(asOf), Source: "ci"},
+			},
+		}
+		if err := WriteSnapshot(SnapshotPathForDate(dir, asOf), snapshot); err != nil {
+			t.Fatalf("write snapshot: %v", err)
+		}
+	}
+	latest, err := LoadSnapshot(SnapshotPathForDate(dir, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("load snapshot: %v", err)
+	}
+
+	store := &okrstore.Store{Org: okrstore.OrgOKRs{Documents: []okrstore.Document{{
+		Scope: okrstore.ScopeOrg,
+		Objectives: []okrstore.Objective{{
+			ID: "OBJ-1",
+			KeyResults: []okrstore.KeyResult{
+				{ID: "KR-1", MetricKey: "ci.coverage", Baseline: 60, Target: 80},
+				{ID: "KR-2", MetricKey: "ci.coverage", Baseline: 60, Target: 200},
+				{ID: "KR-3", MetricKey: "api.latency_ms", Baseline: 400, Target: 200},
+				{ID: "KR-4", MetricKey: "ci.pass_rate", Baseline: 90, Target: 95},
+				{ID: "KR-5", MetricKey: "missing.metric", Baseline: 0, Target: 1},
+			},
+		}},
+	}}}}
+	report, err := ScoreKRs(store, latest, "snapshot.json")
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if err := ApplyTrends(report, dir, 0); err != nil {
+		t.Fatalf("apply trends: %v", err)
+	}
+	if report.TrendWindowDays != DefaultTrendWindowDays {
+		t.Fatalf("trend window = %d", report.TrendWindowDays)
+	}
+	byID := map[string]KRScore{}
+	for _, result := range report.Results {
+		byID[result.KRID] = result
+	}
+
+	kr1 := byID["KR-1"]
+	if kr1.VelocityPerDay == nil || *kr1.VelocityPerDay < 0.999 || *kr1.VelocityPerDay > 1.001 {
+		t.Fatalf("KR-1 velocity = %v", kr1.VelocityPerDay)
+	}
+	if kr1.ForecastDate != "2025-01-21" || kr1.ProjectedStatus != ProjectedOnTrack || kr1.Deadline != "2025-03-31" {
+		t.Fatalf("KR-1 projection = %+v", kr1)
+	}
+	if kr2 := byID["KR-2"]; kr2.ProjectedStatus != ProjectedAtRisk || kr2.ForecastDate != "2025-05-21" {
+		t.Fatalf("KR-2 projection = %+v", kr2)
+	}
+	if kr3 := byID["KR-3"]; kr3.ProjectedStatus != ProjectedOffTrack || kr3.ForecastDate != "" {
+		t.Fatalf("KR-3 projection = %+v", kr3)
+	}
+	if kr4 := byID["KR-4"]; kr4.ProjectedStatus != ProjectedOnTrack || kr4.ForecastDate != "" {
+		t.Fatalf("KR-4 projection = %+v", kr4)
+	}
+	if kr5 := byID["KR-5"]; kr5.ProjectedStatus != "" || kr5.VelocityPerDay != nil {
+		t.Fatalf("KR-5 should have no trend: %+v", kr5)
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -29,6 +30,34 @@ type MetricPoint struct {
 	Source     string      `json:"source" yaml:"source"`
 	Evidence   []string    `json:"evidence,omitempty" yaml:"evidence,omitempty"`
 	Dimensions []Dimension `json:"dimensions,omitempty" yaml:"dimensions,omitempty"`
+	// ObservedAt is when the underlying value was last observed (RFC3339),
+	// such as a report file's modification time, for providers reading
+	// inputs that may be older than the snapshot. Empty means Timestamp.
+	ObservedAt string `json:"observed_at,omitempty" yaml:"observed_at,omitempty"`
+}
+
+// Observed returns when the point's value was observed, and false when
+// neither ObservedAt nor Timestamp parses.
+func (p MetricPoint) Observed() (time.Time, bool) {
+	for _, s := range []string{p.ObservedAt, p.Timestamp} {
+		if s == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fileObservedAt returns a file's modification time as an ObservedAt
+// value, or "" when it cannot be read.
+func fileObservedAt(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return info.ModTime().UTC().Format(time.RFC3339)
 }
 
 // CanonicalizePoints sorts and normalizes metric points for deterministic output.
@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"okrchestra/internal/cycle"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+// PathChange records one artifact whose stored paths were (or would be) rewritten.
+type PathChange struct {
+	File   string
+	Fields []string
+}
+
+// pathOutputKeys are the cycle step outputs that hold workspace paths.
+var pathOutputKeys = map[string]bool{
+	"snapshot_path": true,
+	"score_report":  true,
+	"plan_path":     true,
+	"run_dir":       true,
+}
+
+// Paths rewrites absolute paths stored in workspace artifacts (proposals,
+// plans, score reports, cycle reports) to workspace-relative form. With
+// dryRun set, files are left untouched and the pending changes are returned.
+func Paths(ws *workspace.Workspace, dryRun bool) ([]PathChange, error) {
+	if ws == nil {
+		return nil, fmt.Errorf("workspace is required")
+	}
+	var changes []PathChange
+	record := func(path string, fields []string) {
+		if len(fields) > 0 {
+			changes = append(changes, PathChange{File: ws.RelPath(path), Fields: fields})
+		}
+	}
+
+	proposals, err := glob(filepath.Join(ws.ArtifactsDir, "proposals", "*", "proposal.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range proposals {
+		fields, err := rewrite(path, dryRun, false, func(meta *okrstore.ProposalMetadata) []string {
+			var fields []string
+			migrateField(ws, &meta.OKRsDir, "okrs_dir", &fields)
+			migrateField(ws, &meta.ProposalDir, "proposal_dir", &fields)
+			migrateField(ws, &meta.UpdatesDir, "updates_dir", &fields)
+			return fields
+		})
+		if err != nil {
+			return changes, err
+		}
+		record(path, fields)
+	}
+
+	plans, err := glob(filepath.Join(ws.ArtifactsDir, "plans", "*", "plan.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range plans {
+		fields, err := rewrite(path, dryRun, true, func(plan *planner.Plan) []string {
+			var fields []string
+			migrateField(ws, &plan.OKRsDir, "okrs_dir", &fields)
+			return fields
+		})
+		if err != nil {
+			return changes, err
+		}
+		record(path, fields)
+	}
+
+	var reports []string
+	for _, pattern := range []string{
+		filepath.Join(ws.ArtifactsDir, "kr_score_*.json"),
+		filepath.Join(ws.ArtifactsDir, "cycles", "*", "score_*.json"),
+	} {
+		matches, err := glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, matches...)
+	}
+	for _, path := range reports {
+		fields, err := rewrite(path, dryRun, true, func(report *metrics.KRScoreReport) []string {
+			var fields []string
+			migrateField(ws, &report.SnapshotPath, "snapshot_path", &fields)
+			return fields
+		})
+		if err != nil {
+			return changes, err
+		}
+		record(path, fields)
+	}
+
+	cycles, err := glob(filepath.Join(ws.ArtifactsDir, "cycles", "*", "cycle.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range cycles {
+		fields, err := rewrite(path, dryRun, true, func(report *cycle.Report) []string {
+			var fields []string
+			migrateField(ws, &report.PlanPath, "plan_path", &fields)
+			migrateField(ws, &report.RunDir, "run_dir", &fields)
+			for i := range report.Steps {
+				step := &report.Steps[i]
+				keys := make([]string, 0, len(step.Outputs))
+				for key := range step.Outputs {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					value, ok := step.Outputs[key].(string)
+					if !ok || !pathOutputKeys[key] {
+						continue
+					}
+					migrateField(ws, &value, fmt.Sprintf("steps[%s].%s", step.Name, key), &fields)
+					step.Outputs[key] = value
+				}
+			}
+			return fields
+		})
+		if err != nil {
+			return changes, err
+		}
+		record(path, fields)
+	}
+
+	return changes, nil
+}
+
+func migrateField(ws *workspace.Workspace, value *string, name string, fields *[]string) {
+	migrated := ws.MigratePath(*value)
+	if migrated != *value {
+		*value = migrated
+		*fields = append(*fields, name)
+	}
+}
+
+// rewrite decodes path into T, applies fn, and writes the result back when
+// fn reports changed fields and dryRun is false.
+func rewrite[T any](path string, dryRun, trailingNewline bool, fn func(*T) []string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	fields := fn(&value)
+	if len(fields) == 0 || dryRun {
+		return fields, nil
+	}
+	out, err := json.MarshalIndent(&value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", path, err)
+	}
+	if trailingNewline {
+		out = append(out, '\n')
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	return fields, nil
+}
+
+func glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/workspace"
+)
+
+func TestPathsRewritesAbsolutePaths(t *testing.T) {
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	oldRoot := "/home/someone/old-workspace"
+
+	planPath := filepath.Join(ws.ArtifactsDir, "plans", "2025-01-01", "plan.json")
+	writeJSON(t, planPath, `{"plan_id":"plan-1","as_of":"2025-01-01","generated_at":"2025-01-01T00:00:00Z","okrs_dir":"`+oldRoot+`/okrs","items":[]}`)
+	scorePath := filepath.Join(ws.ArtifactsDir, "kr_score_2025-01-01.json")
+	writeJSON(t, scorePath, `{"snapshot_path":"`+filepath.ToSlash(filepath.Join(ws.MetricsDir, "snapshots", "2025-01-01.json"))+`","results":[]}`)
+	cyclePath := filepath.Join(ws.ArtifactsDir, "cycles", "c1", "cycle.json")
+	writeJSON(t, cyclePath, `{"schema_version":1,"cycle_id":"c1","plan_path":"`+oldRoot+`/artifacts/plans/2025-01-01/plan.json","steps":[{"name":"measure","status":"succeeded","outputs":{"snapshot_path":"`+oldRoot+`/metrics/snapshots/2025-01-01.json","metric_count":3}}]}`)
+
+	changes, err := Paths(ws, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 pending changes, got %+v", changes)
+	}
+	if data, _ := os.ReadFile(planPath); !strings.Contains(string(data), oldRoot) {
+		t.Fatalf("dry run rewrote plan.json")
+	}
+
+	if _, err := Paths(ws, false); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for path, want := range map[string]string{
+		planPath:  `"okrs_dir": "okrs"`,
+		scorePath: `"snapshot_path": "metrics/snapshots/2025-01-01.json"`,
+		cyclePath: `"snapshot_path": "metrics/snapshots/2025-01-01.json"`,
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("%s: expected %s, got:\n%s", path, want, data)
+		}
+	}
+
+	changes, err = Paths(ws, false)
+	if err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected migration to be idempotent, got %+v", changes)
+	}
+}
+
+func writeJSON(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message categories. Recipients are listed per category in notify.yml;
+// uncategorized messages go to the default recipients.
+const (
+	CategoryPlanRuns  = "plan_runs"
+	CategoryKRChanges = "kr_changes"
+	CategoryDigest    = "digest"
+)
+
+// Email delivery modes.
+const (
+	EmailModeImmediate = "immediate"
+	EmailModeDigest    = "digest"
+)
+
+// EmailConfig configures the SMTP channel.
+type EmailConfig struct {
+	SMTPHost string `yaml:"smtp_host"`
+	SMTPPort int    `yaml:"smtp_port"`
+	Username string `yaml:"username"`
+	// PasswordEnv names the environment variable holding the SMTP password,
+	// so the password stays out of the workspace.
+	PasswordEnv string              `yaml:"password_env"`
+	From        string              `yaml:"from"`
+	Mode        string              `yaml:"mode"`
+	Recipients  map[string][]string `yaml:"recipients"`
+}
+
+func (c *EmailConfig) validate() error {
+	if c.SMTPHost == "" {
+		return errors.New("smtp_host is required")
+	}
+	if c.From == "" {
+		return errors.New("from is required")
+	}
+	switch c.Mode {
+	case "":
+		c.Mode = EmailModeImmediate
+	case EmailModeImmediate, EmailModeDigest:
+	default:
+		return fmt.Errorf("unknown mode %q (want %s or %s)", c.Mode, EmailModeImmediate, EmailModeDigest)
+	}
+	if c.SMTPPort == 0 {
+		c.SMTPPort = 587
+	}
+	return nil
+}
+
+// RecipientsFor returns the recipients for a message category, falling back
+// to the default list.
+func (c *EmailConfig) RecipientsFor(category string) []string {
+	if to := c.Recipients[category]; len(to) > 0 {
+		return to
+	}
+	return c.Recipients["default"]
+}
+
+// EmailSender sends messages over SMTP. In digest mode SendMessage queues
+// messages in DigestPath for SendDigest instead of sending them.
+type EmailSender struct {
+	Config     EmailConfig
+	DigestPath string
+
+	// sendMail is smtp.SendMail, replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailSender returns a sender for cfg queuing digest messages in
+// digestPath.
+func NewEmailSender(cfg EmailConfig, digestPath string) *EmailSender {
+	return &EmailSender{Config: cfg, DigestPath: digestPath, sendMail: smtp.SendMail}
+}
+
+// queuedMessage is one line of the digest queue.
+type queuedMessage struct {
+	QueuedAt time.Time `json:"queued_at"`
+	Message  Message   `json:"message"`
+}
+
+// SendMessage emails msg to its category's recipients, or queues it for the
+// digest in digest mode.
+func (s *EmailSender) SendMessage(msg Message) error {
+	if s.Config.Mode == EmailModeDigest {
+		return s.queue(msg)
+	}
+	to := s.Config.RecipientsFor(msg.Category)
+	if len(to) == 0 {
+		return nil
+	}
+	return s.send(to, msg.Title, FoldBody(msg))
+}
+
+func (s *EmailSender) queue(msg Message) error {
+	data, err := json.Marshal(queuedMessage{QueuedAt: time.Now().UTC(), Message: msg})
+	if err != nil {
+		return fmt.Errorf("marshal digest message: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.DigestPath), 0o755); err != nil {
+		return fmt.Errorf("create digest dir: %w", err)
+	}
+	f, err := os.OpenFile(s.DigestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open digest queue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("queue digest message: %w", err)
+	}
+	return nil
+}
+
+// QueuedMessages returns the messages waiting for the next digest.
+func (s *EmailSender) QueuedMessages() ([]Message, error) {
+	f, err := os.Open(s.DigestPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open digest queue: %w", err)
+	}
+	defer f.Close()
+	var messages []Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var queued queuedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &queued); err != nil {
+			return nil, fmt.Errorf("parse digest queue: %w", err)
+		}
+		messages = append(messages, queued.Message)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read digest queue: %w", err)
+	}
+	return messages, nil
+}
+
+// DigestJob is a finished daemon job listed in the digest.
+type DigestJob struct {
+	ID     string
+	Type   string
+	Status string
+	Error  string
+}
+
+// Digest is one day's summary email.
+type Digest struct {
+	Day  string
+	Jobs []DigestJob
+	// Messages are the notifications queued since the last digest.
+	Messages []Message
+}
+
+// DigestMessage renders a digest as a single message.
+func DigestMessage(d Digest) Message {
+	failed := 0
+	for _, job := range d.Jobs {
+		if job.Status == "failed" {
+			failed++
+		}
+	}
+	msg := Message{
+		Title:    "📬 OKRchestra Daily Digest " + d.Day,
+		Body:     fmt.Sprintf("%d job(s) finished (%d failed), %d notification(s)", len(d.Jobs), failed, len(d.Messages)),
+		Category: CategoryDigest,
+	}
+	if len(d.Jobs) > 0 {
+		msg.Details = append(msg.Details, "", "Job results:")
+		for _, job := range d.Jobs {
+			line := fmt.Sprintf("- %s %s: %s", job.Type, job.ID, job.Status)
+			if job.Error != "" {
+				line += " (" + job.Error + ")"
+			}
+			msg.Details = append(msg.Details, line)
+		}
+	}
+	for _, queued := range d.Messages {
+		msg.Details = append(msg.Details, "", queued.Title, FoldBody(queued))
+	}
+	return msg
+}
+
+// SendDigest emails the day's job results and queued messages to the digest
+// recipients and clears the queue. It reports false when there was nothing
+// to send.
+func (s *EmailSender) SendDigest(day string, jobs []DigestJob) (bool, error) {
+	messages, err := s.QueuedMessages()
+	if err != nil {
+		return false, err
+	}
+	to := s.Config.RecipientsFor(CategoryDigest)
+	if len(to) == 0 || (len(jobs) == 0 && len(messages) == 0) {
+		return false, nil
+	}
+	msg := DigestMessage(Digest{Day: day, Jobs: jobs, Messages: messages})
+	if err := s.send(to, msg.Title, FoldBody(msg)); err != nil {
+		return false, err
+	}
+	if err := os.Remove(s.DigestPath); err != nil && !os.IsNotExist(err) {
+		return true, fmt.Errorf("clear digest queue: %w", err)
+	}
+	return true, nil
+}
+
+func (s *EmailSender) send(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if s.Config.Username != "" {
+		auth = smtp.PlainAuth("", s.Config.Username, os.Getenv(s.Config.PasswordEnv), s.Config.SMTPHost)
+	}
+	addr := net.JoinHostPort(s.Config.SMTPHost, strconv.Itoa(s.Config.SMTPPort))
+	sendMail := s.sendMail
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+	if err := sendMail(addr, auth, s.Config.From, to, composeEmail(s.Config.From, to, subject, body)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// composeEmail builds a plain-text RFC 5322 message.
+func composeEmail(from string, to []string, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mimeHeader(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// mimeHeader Q-encodes non-ASCII header values such as emoji titles.
+func mimeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	return value
+}
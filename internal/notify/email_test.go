@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/locale"
+)
+
+type sentMail struct {
+	addr string
+	to   []string
+	body string
+}
+
+func testEmailSender(t *testing.T, mode string) (*EmailSender, *[]sentMail) {
+	t.Helper()
+	var sent []sentMail
+	s := NewEmailSender(EmailConfig{
+		SMTPHost: "smtp.example.com",
+		SMTPPort: 2525,
+		From:     "okrchestra@example.com",
+		Mode:     mode,
+		Recipients: map[string][]string{
+			"default":         {"team@example.com"},
+			CategoryKRChanges: {"leads@example.com"},
+		},
+	}, filepath.Join(t.TempDir(), "digest.jsonl"))
+	s.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentMail{addr: addr, to: to, body: string(msg)})
+		return nil
+	}
+	return s, &sent
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := LoadConfig(root)
+	if err != nil || cfg.Email != nil {
+		t.Fatalf("missing file: %+v, %v", cfg, err)
+	}
+
+	writeConfig := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("email:\n  smtp_host: smtp.example.com\n  from: a@example.com\n")
+	cfg, err = LoadConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Email.Mode != EmailModeImmediate || cfg.Email.SMTPPort != 587 {
+		t.Fatalf("defaults not applied: %+v", cfg.Email)
+	}
+
+	writeConfig("email:\n  smtp_host: smtp.example.com\n  from: a@example.com\n  mode: weekly\n")
+	if _, err := LoadConfig(root); err == nil || !strings.Contains(err.Error(), "unknown mode") {
+		t.Fatalf("expected mode error, got %v", err)
+	}
+}
+
+func TestEmailSenderImmediate(t *testing.T) {
+	s, sent := testEmailSender(t, EmailModeImmediate)
+	changes := GroupKRStatusChanges(locale.Canonical, "job-1", []KRChange{{KRID: "KR-1", OldStatus: "not_started", NewStatus: "in_progress", Current: 5, Target: 10}})
+	if err := s.SendMessage(changes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SendMessage(PlanCompleteMessage(PlanRun{PlanID: "plan-1", KRID: "KR-1", ItemsTotal: 1, ItemsSucceeded: 1})); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("sent %d emails, want 2", len(*sent))
+	}
+	if got := (*sent)[0]; got.addr != "smtp.example.com:2525" || got.to[0] != "leads@example.com" {
+		t.Fatalf("KR change email = %+v", got)
+	}
+	if got := (*sent)[1]; got.to[0] != "team@example.com" || !strings.Contains(got.body, "Subject: =?utf-8?q?") {
+		t.Fatalf("plan email = %+v", got)
+	}
+}
+
+func TestEmailSenderDigest(t *testing.T) {
+	s, sent := testEmailSender(t, EmailModeDigest)
+	if err := s.SendMessage(PlanCompleteMessage(PlanRun{PlanID: "plan-1", KRID: "KR-1", ItemsTotal: 2, ItemsFailed: 1})); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("digest mode sent %d emails immediately", len(*sent))
+	}
+
+	ok, err := s.SendDigest("2025-01-15", []DigestJob{{ID: "job-1", Type: "kr_measure", Status: "failed", Error: "boom"}})
+	if err != nil || !ok {
+		t.Fatalf("SendDigest = %v, %v", ok, err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(*sent))
+	}
+	body := (*sent)[0].body
+	for _, want := range []string{"1 job(s) finished (1 failed), 1 notification(s)", "kr_measure job-1: failed (boom)", "KR-1: 1/2 items failed"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("digest missing %q:\n%s", want, body)
+		}
+	}
+
+	if queued, err := s.QueuedMessages(); err != nil || len(queued) != 0 {
+		t.Fatalf("queue not cleared: %d, %v", len(queued), err)
+	}
+	if ok, err := s.SendDigest("2025-01-16", nil); err != nil || ok {
+		t.Fatalf("empty digest = %v, %v", ok, err)
+	}
+}
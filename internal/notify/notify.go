@@ -2,30 +2,43 @@ package notify
 
 import (
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+
+	"okrchestra/internal/locale"
 )
 
+// Sender delivers notification messages. Channels that can render links
+// natively use Message.Links; others fold them into the body.
+type Sender interface {
+	SendMessage(msg Message) error
+}
+
 // Notifier sends system notifications.
 type Notifier struct {
 	Enabled bool
 }
 
 // Send sends a system notification.
-// On macOS, uses osascript to display notifications.
-// On other platforms, this is a no-op.
+// On macOS, uses osascript to display notifications; on Windows, a toast
+// via PowerShell. On other platforms, this is a no-op.
 func (n *Notifier) Send(title, message string) error {
-	if !n.Enabled {
+	if n == nil || !n.Enabled {
 		return nil
 	}
 
-	if runtime.GOOS != "darwin" {
-		// Only macOS supported for now
+	switch runtime.GOOS {
+	case "darwin":
+		return sendMacOSNotification(title, message)
+	case "windows":
+		return sendWindowsToast(title, message)
+	default:
 		return nil
 	}
-
-	return sendMacOSNotification(title, message)
 }
 
 // sendMacOSNotification uses osascript to display a notification.
@@ -36,11 +49,36 @@ func sendMacOSNotification(title, message string) error {
 
 	script := fmt.Sprintf(`display notification "%s" with title "%s"`, message, title)
 	cmd := exec.Command("osascript", "-e", script)
-	
+
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("send notification: %w", err)
 	}
-	
+
+	return nil
+}
+
+// windowsToastScript shows a toast through the WinRT notification API. The
+// title and message come from the environment so they need no escaping.
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName("text")
+$text.Item(0).AppendChild($template.CreateTextNode($env:OKRCHESTRA_TOAST_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:OKRCHESTRA_TOAST_MESSAGE)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("OKRchestra").Show($toast)
+`
+
+// sendWindowsToast uses PowerShell to display a toast notification.
+func sendWindowsToast(title, message string) error {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+	cmd.Env = append(os.Environ(), "OKRCHESTRA_TOAST_TITLE="+title, "OKRCHESTRA_TOAST_MESSAGE="+message)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("send notification: %w", err)
+	}
+
 	return nil
 }
 
@@ -57,23 +95,237 @@ func FormatPlanComplete(planID string, itemsTotal, itemsSucceeded, itemsFailed i
 }
 
 // FormatKRAchieved formats a KR achievement notification message.
-func FormatKRAchieved(krID, description string, current, target float64) (title, message string) {
+func FormatKRAchieved(loc locale.Locale, krID, description string, current, target float64) (title, message string) {
 	title = "🎉 OKRchestra KR Achieved"
-	message = fmt.Sprintf("%s: %s (%.0f/%.0f)", krID, description, current, target)
+	message = fmt.Sprintf("%s: %s (%s/%s)", krID, description, loc.Fixed(current, 0), loc.Fixed(target, 0))
 	return title, message
 }
 
 // FormatKRStatusChange formats a KR status change notification message.
-func FormatKRStatusChange(krID, description, oldStatus, newStatus string, current, target float64) (title, message string) {
+func FormatKRStatusChange(loc locale.Locale, krID, description, oldStatus, newStatus string, current, target float64) (title, message string) {
 	switch newStatus {
 	case "achieved":
-		return FormatKRAchieved(krID, description, current, target)
+		return FormatKRAchieved(loc, krID, description, current, target)
 	case "in_progress":
 		title = "🚀 OKRchestra KR In Progress"
-		message = fmt.Sprintf("%s: %s (%.0f/%.0f)", krID, description, current, target)
+		message = fmt.Sprintf("%s: %s (%s/%s)", krID, description, loc.Fixed(current, 0), loc.Fixed(target, 0))
 	default:
 		title = "📊 OKRchestra KR Status Update"
 		message = fmt.Sprintf("%s: %s → %s", krID, oldStatus, newStatus)
 	}
 	return title, message
 }
+
+// Message is a notification with optional per-item details. Channels that
+// support threading post Details as replies under ThreadKey; others append
+// them to the body as a digest. Links point recipients at the artifacts the
+// message is about.
+type Message struct {
+	Title     string
+	Body      string
+	Details   []string
+	ThreadKey string
+	Links     []Link
+	// Category selects the email recipients and routing rules (see
+	// notify.yml).
+	Category string
+	// Severity is SeverityInfo when empty.
+	Severity string
+}
+
+// Link is a labeled deep link attached to a message.
+type Link struct {
+	Label string
+	URL   string
+}
+
+// PlanRun describes a finished plan run for PlanCompleteMessage.
+type PlanRun struct {
+	PlanID         string
+	RunID          string
+	KRID           string
+	ItemsTotal     int
+	ItemsSucceeded int
+	ItemsFailed    int
+	// RunDir is linked as a file:// URL.
+	RunDir string
+	// DashboardURL is the base URL of the served dashboard, if any.
+	DashboardURL string
+	// PRURLs are pull requests opened for the run's items.
+	PRURLs []string
+}
+
+// PlanCompleteMessage formats a plan completion message with links to the
+// run dir, the run's dashboard page, and any pull requests.
+func PlanCompleteMessage(run PlanRun) Message {
+	title, body := FormatPlanComplete(run.PlanID, run.ItemsTotal, run.ItemsSucceeded, run.ItemsFailed, run.KRID)
+	msg := Message{Title: title, Body: body, ThreadKey: run.RunID, Category: CategoryPlanRuns}
+	if run.ItemsFailed > 0 {
+		msg.Severity = SeverityWarning
+	}
+	if run.RunDir != "" {
+		msg.Links = append(msg.Links, Link{Label: "Run artifacts", URL: FileURL(run.RunDir)})
+	}
+	if base := strings.TrimRight(run.DashboardURL, "/"); base != "" && run.RunID != "" {
+		msg.Links = append(msg.Links, Link{Label: "Dashboard", URL: base + "/runs/" + url.PathEscape(run.RunID)})
+	}
+	for i, pr := range run.PRURLs {
+		label := "Pull request"
+		if len(run.PRURLs) > 1 {
+			label = fmt.Sprintf("Pull request %d", i+1)
+		}
+		msg.Links = append(msg.Links, Link{Label: label, URL: pr})
+	}
+	return msg
+}
+
+// FileURL returns a file:// URL for a local path.
+func FileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive paths: file:///C:/...
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// KRChange is a KR status transition to be reported.
+type KRChange struct {
+	KRID        string
+	Description string
+	OldStatus   string
+	NewStatus   string
+	Current     float64
+	Target      float64
+	// Notes are human annotations on the KR's metric for this cycle.
+	Notes []string
+	// ProjectedStatus and ForecastDate are the KR's trend projection;
+	// empty without enough metric history.
+	ProjectedStatus string
+	ForecastDate    string
+}
+
+// IsUrgent reports whether a transition to newStatus bypasses grouping.
+func IsUrgent(newStatus string) bool {
+	return newStatus == "achieved" || newStatus == "blocked"
+}
+
+// GroupKRStatusChanges turns the status changes from one measure cycle into
+// a single summary message with per-KR details, preceded by an individual
+// message for each urgent (achieved/blocked) transition. Values are
+// formatted for loc.
+func GroupKRStatusChanges(loc locale.Locale, cycleKey string, changes []KRChange) []Message {
+	if len(changes) == 0 {
+		return nil
+	}
+	var messages []Message
+	details := make([]string, 0, len(changes))
+	for _, change := range changes {
+		title, message := FormatKRStatusChange(loc, change.KRID, change.Description, change.OldStatus, change.NewStatus, change.Current, change.Target)
+		message = withNotes(withProjection(loc, message, change), change.Notes)
+		if IsUrgent(change.NewStatus) {
+			severity := SeverityInfo
+			if change.NewStatus == "blocked" {
+				title = "🛑 OKRchestra KR Blocked"
+				severity = SeverityCritical
+			}
+			messages = append(messages, Message{Title: title, Body: message, ThreadKey: cycleKey, Category: CategoryKRChanges, Severity: severity})
+		}
+		details = append(details, withNotes(withProjection(loc, fmt.Sprintf("%s: %s → %s (%s/%s)",
+			change.KRID, change.OldStatus, change.NewStatus, loc.Fixed(change.Current, 0), loc.Fixed(change.Target, 0)), change), change.Notes))
+	}
+	if len(changes) == 1 && len(messages) == 1 {
+		return messages
+	}
+
+	summary := Message{
+		Title:     "📊 OKRchestra KR Status Updates",
+		Body:      fmt.Sprintf("%d KR status change(s) in this cycle", len(changes)),
+		Details:   details,
+		ThreadKey: cycleKey,
+		Category:  CategoryKRChanges,
+	}
+	if len(changes) == 1 {
+		summary.Title, summary.Body = FormatKRStatusChange(loc, changes[0].KRID, changes[0].Description, changes[0].OldStatus, changes[0].NewStatus, changes[0].Current, changes[0].Target)
+		summary.Body = withNotes(withProjection(loc, summary.Body, changes[0]), changes[0].Notes)
+		summary.Details = nil
+	}
+	return append(messages, summary)
+}
+
+// StaleKR is a KR whose metric has not been observed recently enough to
+// score it by.
+type StaleKR struct {
+	KRID        string
+	Description string
+	MetricKey   string
+	// ObservedAt is when the metric was last observed (RFC3339).
+	ObservedAt string
+}
+
+// StaleKRsMessage is a warning listing the KRs that went stale in one
+// measure cycle, or false when there are none.
+func StaleKRsMessage(loc locale.Locale, cycleKey string, stale []StaleKR) (Message, bool) {
+	if len(stale) == 0 {
+		return Message{}, false
+	}
+	details := make([]string, 0, len(stale))
+	for _, kr := range stale {
+		details = append(details, fmt.Sprintf("%s: %s last observed %s", kr.KRID, kr.MetricKey, loc.DateString(kr.ObservedAt)))
+	}
+	msg := Message{
+		Title:     "⏳ OKRchestra Stale Metrics",
+		Body:      fmt.Sprintf("%d KR(s) are measured by metrics that have not been updated recently", len(stale)),
+		Details:   details,
+		ThreadKey: cycleKey,
+		Category:  CategoryKRChanges,
+		Severity:  SeverityWarning,
+	}
+	if len(stale) == 1 {
+		msg.Body = details[0]
+		msg.Details = nil
+	}
+	return msg, true
+}
+
+// withProjection appends a KR's projected status and forecast date to a
+// notification line.
+func withProjection(loc locale.Locale, line string, change KRChange) string {
+	if change.ProjectedStatus == "" {
+		return line
+	}
+	line += " 📈 projected " + change.ProjectedStatus
+	if change.ForecastDate != "" {
+		line += ", target by " + loc.DateString(change.ForecastDate)
+	}
+	return line
+}
+
+// withNotes appends annotation notes to a notification line.
+func withNotes(line string, notes []string) string {
+	if len(notes) == 0 {
+		return line
+	}
+	return line + " 📝 " + strings.Join(notes, "; ")
+}
+
+// SendMessage sends a message, folding its details and links into the body.
+func (n *Notifier) SendMessage(msg Message) error {
+	return n.Send(msg.Title, FoldBody(msg))
+}
+
+// FoldBody renders a message body with details and links appended as lines,
+// for channels without threading or link support.
+func FoldBody(msg Message) string {
+	body := msg.Body
+	if len(msg.Details) > 0 {
+		body = body + "\n" + strings.Join(msg.Details, "\n")
+	}
+	for _, link := range msg.Links {
+		body += fmt.Sprintf("\n🔗 %s: %s", link.Label, link.URL)
+	}
+	return body
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"okrchestra/internal/locale"
+)
+
+func TestGroupKRStatusChanges(t *testing.T) {
+	changes := []KRChange{
+		{KRID: "KR-1", OldStatus: "not_started", NewStatus: "in_progress", Current: 5, Target: 10},
+		{KRID: "KR-2", OldStatus: "in_progress", NewStatus: "achieved", Current: 10, Target: 10},
+		{KRID: "KR-3", OldStatus: "not_started", NewStatus: "in_progress", Current: 1, Target: 4},
+	}
+
+	messages := GroupKRStatusChanges(locale.Canonical, "kr_measure_2024-01-01T02:00:00", changes)
+	if len(messages) != 2 {
+		t.Fatalf("expected 1 urgent + 1 summary message, got %d", len(messages))
+	}
+	if messages[0].Title != "🎉 OKRchestra KR Achieved" {
+		t.Errorf("expected achieved bypass first, got %q", messages[0].Title)
+	}
+	summary := messages[1]
+	if len(summary.Details) != 3 {
+		t.Errorf("expected 3 summary details, got %d", len(summary.Details))
+	}
+	if summary.ThreadKey != "kr_measure_2024-01-01T02:00:00" {
+		t.Errorf("unexpected thread key %q", summary.ThreadKey)
+	}
+}
+
+func TestGroupKRStatusChangesSingle(t *testing.T) {
+	if got := GroupKRStatusChanges(locale.Canonical, "k", nil); got != nil {
+		t.Fatalf("expected no messages, got %d", len(got))
+	}
+
+	urgent := GroupKRStatusChanges(locale.Canonical, "k", []KRChange{{KRID: "KR-1", OldStatus: "in_progress", NewStatus: "blocked"}})
+	if len(urgent) != 1 || urgent[0].Title != "🛑 OKRchestra KR Blocked" {
+		t.Fatalf("expected single blocked message, got %+v", urgent)
+	}
+
+	normal := GroupKRStatusChanges(locale.Canonical, "k", []KRChange{{KRID: "KR-1", OldStatus: "not_started", NewStatus: "in_progress"}})
+	if len(normal) != 1 || len(normal[0].Details) != 0 {
+		t.Fatalf("expected single plain message, got %+v", normal)
+	}
+}
+
+func TestGroupKRStatusChangesNotes(t *testing.T) {
+	changes := []KRChange{
+		{KRID: "KR-1", OldStatus: "in_progress", NewStatus: "at_risk", Notes: []string{"flaky suite quarantined"}},
+		{KRID: "KR-2", OldStatus: "not_started", NewStatus: "in_progress"},
+	}
+	messages := GroupKRStatusChanges(locale.Canonical, "k", changes)
+	if len(messages) != 1 {
+		t.Fatalf("expected one summary, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].Details[0], "flaky suite quarantined") {
+		t.Errorf("expected annotation in details, got %q", messages[0].Details[0])
+	}
+	if strings.Contains(messages[0].Details[1], "📝") {
+		t.Errorf("unexpected note on unannotated KR: %q", messages[0].Details[1])
+	}
+}
+
+func TestGroupKRStatusChangesProjection(t *testing.T) {
+	changes := []KRChange{
+		{KRID: "KR-1", OldStatus: "not_started", NewStatus: "in_progress", ProjectedStatus: "at_risk", ForecastDate: "2025-05-01"},
+	}
+	messages := GroupKRStatusChanges(locale.Canonical, "k", changes)
+	if len(messages) != 1 || !strings.HasSuffix(messages[0].Body, "📈 projected at_risk, target by 2025-05-01") {
+		t.Fatalf("expected projection in body, got %+v", messages)
+	}
+}
+
+func TestPlanCompleteMessageLinks(t *testing.T) {
+	msg := PlanCompleteMessage(PlanRun{
+		PlanID:         "PLAN-2026-03-01",
+		RunID:          "20260301T020000Z",
+		KRID:           "KR-1",
+		ItemsTotal:     2,
+		ItemsSucceeded: 2,
+		RunDir:         "/ws/artifacts/runs/20260301T020000Z",
+		DashboardURL:   "http://localhost:8080/",
+		PRURLs:         []string{"https://github.com/o/r/pull/1"},
+	})
+	want := []Link{
+		{Label: "Run artifacts", URL: "file:///ws/artifacts/runs/20260301T020000Z"},
+		{Label: "Dashboard", URL: "http://localhost:8080/runs/20260301T020000Z"},
+		{Label: "Pull request", URL: "https://github.com/o/r/pull/1"},
+	}
+	if len(msg.Links) != len(want) {
+		t.Fatalf("links = %+v, want %+v", msg.Links, want)
+	}
+	for i := range want {
+		if msg.Links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, msg.Links[i], want[i])
+		}
+	}
+	if body := FoldBody(msg); !strings.Contains(body, "🔗 Dashboard: http://localhost:8080/runs/20260301T020000Z") {
+		t.Errorf("folded body missing dashboard link:\n%s", body)
+	}
+
+	bare := PlanCompleteMessage(PlanRun{PlanID: "P", KRID: "KR-1", ItemsTotal: 1, ItemsFailed: 1})
+	if len(bare.Links) != 0 {
+		t.Errorf("expected no links without run dir or dashboard, got %+v", bare.Links)
+	}
+}
+
+func TestStaleKRsMessage(t *testing.T) {
+	if _, ok := StaleKRsMessage(locale.Canonical, "k", nil); ok {
+		t.Fatal("expected no message without stale KRs")
+	}
+	msg, ok := StaleKRsMessage(locale.Canonical, "k", []StaleKR{
+		{KRID: "KR-1", MetricKey: "coverage.pct", ObservedAt: "2025-01-02T10:00:00Z"},
+		{KRID: "KR-2", MetricKey: "manual.nps", ObservedAt: "2024-12-20T00:00:00Z"},
+	})
+	if !ok || msg.Severity != SeverityWarning || msg.Category != CategoryKRChanges || len(msg.Details) != 2 {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg.Details[0] != "KR-1: coverage.pct last observed 2025-01-02" {
+		t.Errorf("unexpected detail %q", msg.Details[0])
+	}
+	single, _ := StaleKRsMessage(locale.Canonical, "k", []StaleKR{{KRID: "KR-1", MetricKey: "coverage.pct", ObservedAt: "2025-01-02T10:00:00Z"}})
+	if len(single.Details) != 0 || !strings.HasPrefix(single.Body, "KR-1:") {
+		t.Fatalf("unexpected single message %+v", single)
+	}
+}
@@ -0,0 +1,10 @@
+package notify
+
+import "testing"
+
+func TestFileURLWindows(t *testing.T) {
+	got := FileURL(`C:\Users\me\ws\artifacts\runs\run 1`)
+	if want := "file:///C:/Users/me/ws/artifacts/runs/run%201"; got != want {
+		t.Fatalf("FileURL = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the workspace file configuring notification channels
+// and routing.
+const ConfigFileName = "notify.yml"
+
+// Channel names used in routing rules.
+const (
+	ChannelDesktop = "desktop"
+	ChannelEmail   = "email"
+)
+
+// Message severities, lowest first.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var severityRank = map[string]int{
+	"":               0,
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// Config is the contents of notify.yml:
+//
+//	timezone: America/Chicago
+//	routes:
+//	  - events: [kr_changes]
+//	    channels: [desktop, email]
+//	  - events: [plan_runs]
+//	    channels: [email]
+//	    min_severity: warning
+//	quiet_hours:
+//	  start: "22:00"
+//	  end: "07:00"
+//	email:
+//	  smtp_host: smtp.example.com
+//	  smtp_port: 587
+//	  username: okrchestra@example.com
+//	  password_env: OKRCHESTRA_SMTP_PASSWORD
+//	  from: okrchestra@example.com
+//	  mode: digest
+//	  recipients:
+//	    default: [team@example.com]
+//	    kr_changes: [leads@example.com]
+//
+// Events are message categories; "*" matches any. The first route matching
+// a message decides its channels, and messages no route matches are
+// dropped. Without routes every message goes to every channel.
+//
+// In email digest mode messages are queued and the daemon's notify_digest
+// job sends them with the day's job results as one email to the digest
+// recipients.
+type Config struct {
+	TimeZone   string       `yaml:"timezone"`
+	Routes     []Route      `yaml:"routes"`
+	QuietHours *QuietHours  `yaml:"quiet_hours"`
+	Email      *EmailConfig `yaml:"email"`
+}
+
+// Route sends messages of the listed events at or above MinSeverity to
+// Channels.
+type Route struct {
+	Events      []string `yaml:"events"`
+	Channels    []string `yaml:"channels"`
+	MinSeverity string   `yaml:"min_severity"`
+}
+
+// QuietHours holds back messages below MinSeverity (default critical)
+// between Start and End, in the notify.yml timezone. End before Start spans
+// midnight.
+type QuietHours struct {
+	Start       string `yaml:"start"`
+	End         string `yaml:"end"`
+	MinSeverity string `yaml:"min_severity"`
+
+	start, end int
+}
+
+// LoadConfig reads <root>/notify.yml. A missing file yields an empty config.
+func LoadConfig(root string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(filepath.Join(root, ConfigFileName))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("read %s: %w", ConfigFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", ConfigFileName, err)
+	}
+	if cfg.Email != nil {
+		if err := cfg.Email.validate(); err != nil {
+			return cfg, fmt.Errorf("%s: email: %w", ConfigFileName, err)
+		}
+	}
+	for i, route := range cfg.Routes {
+		if err := route.validate(cfg.Email != nil); err != nil {
+			return cfg, fmt.Errorf("%s: routes[%d]: %w", ConfigFileName, i, err)
+		}
+	}
+	if cfg.QuietHours != nil {
+		if err := cfg.QuietHours.validate(); err != nil {
+			return cfg, fmt.Errorf("%s: quiet_hours: %w", ConfigFileName, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (r Route) validate(emailConfigured bool) error {
+	if len(r.Events) == 0 {
+		return fmt.Errorf("events is required")
+	}
+	for _, channel := range r.Channels {
+		switch channel {
+		case ChannelDesktop:
+		case ChannelEmail:
+			if !emailConfigured {
+				return fmt.Errorf("channel email needs an email section")
+			}
+		default:
+			return fmt.Errorf("unknown channel %q (want %s or %s)", channel, ChannelDesktop, ChannelEmail)
+		}
+	}
+	return validateSeverity(r.MinSeverity)
+}
+
+func (q *QuietHours) validate() error {
+	var err error
+	if q.start, err = parseClock(q.Start); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	if q.end, err = parseClock(q.End); err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	if q.MinSeverity == "" {
+		q.MinSeverity = SeverityCritical
+	}
+	return validateSeverity(q.MinSeverity)
+}
+
+func validateSeverity(severity string) error {
+	if _, ok := severityRank[severity]; !ok {
+		return fmt.Errorf("unknown severity %q (want %s, %s, or %s)", severity, SeverityInfo, SeverityWarning, SeverityCritical)
+	}
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("want HH:MM, got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t falls in the quiet hours.
+func (q *QuietHours) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return minute >= q.start && minute < q.end
+	}
+	return minute >= q.start || minute < q.end
+}
+
+// Router delivers messages to channels according to notify.yml.
+type Router struct {
+	Channels   map[string]Sender
+	Routes     []Route
+	QuietHours *QuietHours
+	// Location is the timezone quiet hours are read in.
+	Location *time.Location
+
+	now func() time.Time
+}
+
+// RouterOptions supplies what notify.yml does not configure.
+type RouterOptions struct {
+	// Desktop is the desktop channel, typically a *Notifier.
+	Desktop Sender
+	// DigestPath is where email digest mode queues messages.
+	DigestPath string
+	// Location is used when notify.yml sets no timezone; nil means local
+	// time.
+	Location *time.Location
+}
+
+// LoadRouter builds the router for the workspace at root from notify.yml.
+func LoadRouter(root string, opts RouterOptions) (*Router, error) {
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{
+		Channels:   map[string]Sender{},
+		Routes:     cfg.Routes,
+		QuietHours: cfg.QuietHours,
+		Location:   opts.Location,
+	}
+	if cfg.TimeZone != "" {
+		if r.Location, err = time.LoadLocation(cfg.TimeZone); err != nil {
+			return nil, fmt.Errorf("%s: load timezone %s: %w", ConfigFileName, cfg.TimeZone, err)
+		}
+	}
+	if opts.Desktop != nil {
+		r.Channels[ChannelDesktop] = opts.Desktop
+	}
+	if cfg.Email != nil {
+		r.Channels[ChannelEmail] = NewEmailSender(*cfg.Email, opts.DigestPath)
+	}
+	return r, nil
+}
+
+// SendMessage sends msg to the channels its route selects, unless quiet
+// hours hold it back. It tries every channel and returns the first error.
+func (r *Router) SendMessage(msg Message) error {
+	if r.quiet(msg) {
+		return nil
+	}
+	var first error
+	for _, name := range r.route(msg) {
+		sender, ok := r.Channels[name]
+		if !ok || sender == nil {
+			continue
+		}
+		if err := sender.SendMessage(msg); err != nil && first == nil {
+			first = fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return first
+}
+
+// route returns the channel names for msg.
+func (r *Router) route(msg Message) []string {
+	if len(r.Routes) == 0 {
+		return []string{ChannelDesktop, ChannelEmail}
+	}
+	for _, route := range r.Routes {
+		if !route.matches(msg.Category) {
+			continue
+		}
+		if severityRank[msg.Severity] < severityRank[route.MinSeverity] {
+			return nil
+		}
+		return route.Channels
+	}
+	return nil
+}
+
+func (r Route) matches(category string) bool {
+	for _, event := range r.Events {
+		if event == "*" || event == category {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) quiet(msg Message) bool {
+	if r.QuietHours == nil || severityRank[msg.Severity] >= severityRank[r.QuietHours.MinSeverity] {
+		return false
+	}
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	loc := r.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return r.QuietHours.contains(now().In(loc))
+}
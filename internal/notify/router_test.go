@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingSender struct {
+	sent []Message
+}
+
+func (r *recordingSender) SendMessage(msg Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestLoadConfigRoutes(t *testing.T) {
+	root := t.TempDir()
+	writeConfig := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, ConfigFileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig("routes:\n  - events: [kr_changes]\n    channels: [pager]\n")
+	if _, err := LoadConfig(root); err == nil || !strings.Contains(err.Error(), "unknown channel") {
+		t.Fatalf("expected channel error, got %v", err)
+	}
+	writeConfig("routes:\n  - events: [kr_changes]\n    channels: [email]\n")
+	if _, err := LoadConfig(root); err == nil || !strings.Contains(err.Error(), "email section") {
+		t.Fatalf("expected email section error, got %v", err)
+	}
+	writeConfig("routes:\n  - events: [kr_changes]\n    channels: [desktop]\n    min_severity: loud\n")
+	if _, err := LoadConfig(root); err == nil || !strings.Contains(err.Error(), "unknown severity") {
+		t.Fatalf("expected severity error, got %v", err)
+	}
+	writeConfig("quiet_hours:\n  start: \"10pm\"\n  end: \"07:00\"\n")
+	if _, err := LoadConfig(root); err == nil || !strings.Contains(err.Error(), "want HH:MM") {
+		t.Fatalf("expected clock error, got %v", err)
+	}
+
+	writeConfig("timezone: Not/AZone\n")
+	if _, err := LoadRouter(root, RouterOptions{}); err == nil || !strings.Contains(err.Error(), "timezone") {
+		t.Fatalf("expected timezone error, got %v", err)
+	}
+}
+
+func TestRouterRoutes(t *testing.T) {
+	desktop, email := &recordingSender{}, &recordingSender{}
+	r := &Router{
+		Channels: map[string]Sender{ChannelDesktop: desktop, ChannelEmail: email},
+		Routes: []Route{
+			{Events: []string{CategoryKRChanges}, Channels: []string{ChannelDesktop, ChannelEmail}},
+			{Events: []string{CategoryPlanRuns}, Channels: []string{ChannelEmail}, MinSeverity: SeverityWarning},
+		},
+	}
+	for _, msg := range []Message{
+		{Title: "kr", Category: CategoryKRChanges},
+		{Title: "plan ok", Category: CategoryPlanRuns},
+		{Title: "plan failed", Category: CategoryPlanRuns, Severity: SeverityWarning},
+		{Title: "other", Category: "other"},
+	} {
+		if err := r.SendMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(desktop.sent) != 1 || desktop.sent[0].Title != "kr" {
+		t.Fatalf("desktop got %+v", desktop.sent)
+	}
+	if len(email.sent) != 2 || email.sent[1].Title != "plan failed" {
+		t.Fatalf("email got %+v", email.sent)
+	}
+
+	// Without routes every message goes to every channel.
+	r.Routes = nil
+	if err := r.SendMessage(Message{Title: "other", Category: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(desktop.sent) != 2 || len(email.sent) != 3 {
+		t.Fatalf("default routing: desktop %d, email %d", len(desktop.sent), len(email.sent))
+	}
+}
+
+func TestRouterQuietHours(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	quiet := &QuietHours{Start: "22:00", End: "07:00"}
+	if err := quiet.validate(); err != nil {
+		t.Fatal(err)
+	}
+	desktop := &recordingSender{}
+	r := &Router{
+		Channels:   map[string]Sender{ChannelDesktop: desktop},
+		QuietHours: quiet,
+		Location:   chicago,
+		// 04:00 UTC is 23:00 in Chicago (CDT).
+		now: func() time.Time { return time.Date(2026, 6, 2, 4, 0, 0, 0, time.UTC) },
+	}
+	_ = r.SendMessage(Message{Title: "info", Severity: SeverityWarning})
+	_ = r.SendMessage(Message{Title: "blocked", Severity: SeverityCritical})
+	if len(desktop.sent) != 1 || desktop.sent[0].Title != "blocked" {
+		t.Fatalf("quiet hours: got %+v", desktop.sent)
+	}
+
+	r.now = func() time.Time { return time.Date(2026, 6, 2, 18, 0, 0, 0, time.UTC) }
+	_ = r.SendMessage(Message{Title: "info"})
+	if len(desktop.sent) != 2 {
+		t.Fatalf("outside quiet hours: got %+v", desktop.sent)
+	}
+}
@@ -0,0 +1,176 @@
+package okrstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// alignKey identifies an alignment target: an objective within a scope
+// (objective ids are only unique per scope), or a key result by its id.
+type alignKey struct {
+	scope     Scope
+	objective string
+	kr        string
+}
+
+// scopeRank orders scopes from broadest to narrowest.
+func scopeRank(scope Scope) int {
+	switch scope {
+	case ScopeOrg:
+		return 0
+	case ScopeTeam:
+		return 1
+	case ScopePerson:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// alignIndex resolves aligns_to references.
+type alignIndex struct {
+	objectives map[Scope]map[string]bool
+	krScopes   map[string]Scope
+}
+
+func newAlignIndex(docs []Document) alignIndex {
+	index := alignIndex{objectives: map[Scope]map[string]bool{}, krScopes: map[string]Scope{}}
+	for _, doc := range docs {
+		if index.objectives[doc.Scope] == nil {
+			index.objectives[doc.Scope] = map[string]bool{}
+		}
+		for _, obj := range doc.Objectives {
+			index.objectives[doc.Scope][obj.ID] = true
+			for _, kr := range obj.KeyResults {
+				index.krScopes[kr.ID] = doc.Scope
+			}
+		}
+	}
+	return index
+}
+
+// resolve finds what id refers to when aligned from scope: the objective
+// with that id in the nearest broader scope, or else the key result with
+// that id if it is in a broader scope.
+func (ix alignIndex) resolve(from Scope, id string) (alignKey, string, error) {
+	for _, scope := range []Scope{ScopePerson, ScopeTeam, ScopeOrg} {
+		if scopeRank(scope) < scopeRank(from) && ix.objectives[scope][id] {
+			return alignKey{scope: scope, objective: id}, "", nil
+		}
+	}
+	if scope, ok := ix.krScopes[id]; ok && scopeRank(scope) < scopeRank(from) {
+		return alignKey{kr: id}, "", nil
+	}
+	if _, ok := ix.krScopes[id]; ok {
+		return alignKey{}, CodeAlignsToScope, fmt.Errorf("aligns_to %q must reference a scope broader than %s", id, from)
+	}
+	for scope := range ix.objectives {
+		if ix.objectives[scope][id] {
+			return alignKey{}, CodeAlignsToScope, fmt.Errorf("aligns_to %q must reference a scope broader than %s", id, from)
+		}
+	}
+	return alignKey{}, CodeAlignsToUnknown, fmt.Errorf("aligns_to %q matches no objective or key result", id)
+}
+
+// validateAlignment checks that every aligns_to reference resolves to an
+// objective or key result in a broader scope.
+func validateAlignment(docs []Document) ValidationErrors {
+	index := newAlignIndex(docs)
+	var errs ValidationErrors
+	check := func(doc Document, field string, ids []string) {
+		for i, id := range ids {
+			if _, code, err := index.resolve(doc.Scope, id); err != nil {
+				errs = append(errs, ValidationError{
+					File:    doc.Source,
+					Field:   fmt.Sprintf("%s.aligns_to[%d]", field, i),
+					Code:    code,
+					Message: err.Error(),
+				})
+			}
+		}
+	}
+	for _, doc := range docs {
+		for objIdx, obj := range doc.Objectives {
+			objPath := fmt.Sprintf("objectives[%d]", objIdx)
+			check(doc, objPath, obj.AlignsTo)
+			for krIdx, kr := range obj.KeyResults {
+				check(doc, fmt.Sprintf("%s.key_results[%d]", objPath, krIdx), kr.AlignsTo)
+			}
+		}
+	}
+	return errs
+}
+
+// addAlignment records child as aligned to each reference that resolves;
+// stores built from a filtered set of documents may not hold every parent.
+func (s *Store) addAlignment(index alignIndex, scope Scope, child alignKey, ids []string) {
+	for _, id := range ids {
+		if parent, _, err := index.resolve(scope, id); err == nil {
+			s.aligned[parent] = append(s.aligned[parent], child)
+		}
+	}
+}
+
+// AlignedKeyResults returns the key results that roll up into an
+// objective from narrower scopes, sorted by id: those aligned to it or to
+// one of its key results, plus the key results of objectives aligned to
+// it, and so on down. The objective's own key results are not included.
+func (s *Store) AlignedKeyResults(scope Scope, objectiveID string) []KeyResultRecord {
+	if s == nil {
+		return nil
+	}
+	root := alignKey{scope: scope, objective: objectiveID}
+	seen := map[alignKey]bool{root: true}
+	found := map[string]bool{}
+	var visit func(key alignKey)
+	visit = func(key alignKey) {
+		for _, child := range s.aligned[key] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			if child.kr != "" {
+				found[child.kr] = true
+			} else {
+				for _, id := range s.ownKRs[child] {
+					found[id] = true
+					seen[alignKey{kr: id}] = true
+					visit(alignKey{kr: id})
+				}
+			}
+			visit(child)
+		}
+	}
+	for _, id := range s.ownKRs[root] {
+		seen[alignKey{kr: id}] = true
+		visit(alignKey{kr: id})
+	}
+	visit(root)
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	records := make([]KeyResultRecord, 0, len(ids))
+	for _, id := range ids {
+		if rec, ok := s.keyResults[id]; ok {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func trimAll(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
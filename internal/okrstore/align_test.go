@@ -0,0 +1,83 @@
+package okrstore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func alignTestDoc(scope, objID, krID, objAligns, krAligns string) string {
+	doc := "scope: " + scope + `
+objectives:
+  - objective_id: ` + objID + `
+    objective: Objective
+    owner_id: team-alpha
+`
+	if objAligns != "" {
+		doc += "    aligns_to: [" + objAligns + "]\n"
+	}
+	doc += `    key_results:
+      - kr_id: ` + krID + `
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: 2
+        confidence: 0.5
+        status: in_progress
+        evidence: []
+`
+	if krAligns != "" {
+		doc += "        aligns_to: [" + krAligns + "]\n"
+	}
+	return doc
+}
+
+func TestAlignedKeyResults(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "org.yml"), alignTestDoc("org", "OBJ-1", "KR-ORG", "", ""))
+	writeFile(t, filepath.Join(dir, "team.yml"), alignTestDoc("team", "OBJ-1", "KR-TEAM", "OBJ-1", ""))
+	writeFile(t, filepath.Join(dir, "person.yml"), alignTestDoc("person", "OBJ-P", "KR-P", "", "KR-TEAM"))
+
+	store, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	// KR-P reaches org OBJ-1 through KR-TEAM and team OBJ-1.
+	aligned := store.AlignedKeyResults(ScopeOrg, "OBJ-1")
+	if len(aligned) != 2 || aligned[0].KeyResult.ID != "KR-P" || aligned[1].KeyResult.ID != "KR-TEAM" {
+		t.Fatalf("org aligned = %+v", aligned)
+	}
+	if aligned := store.AlignedKeyResults(ScopeTeam, "OBJ-1"); len(aligned) != 1 || aligned[0].KeyResult.ID != "KR-P" {
+		t.Fatalf("team aligned = %+v", aligned)
+	}
+	if aligned := store.AlignedKeyResults(ScopePerson, "OBJ-P"); len(aligned) != 0 {
+		t.Fatalf("person aligned = %+v", aligned)
+	}
+}
+
+func TestAlignmentValidation(t *testing.T) {
+	cases := []struct {
+		name      string
+		objAligns string
+		krAligns  string
+		wantCode  string
+	}{
+		{name: "unknown", objAligns: "OBJ-404", wantCode: CodeAlignsToUnknown},
+		{name: "same scope", krAligns: "KR-T2", wantCode: CodeAlignsToScope},
+		{name: "narrower scope", objAligns: "OBJ-P", wantCode: CodeAlignsToScope},
+	}
+	for _, tc := range cases {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "org.yml"), alignTestDoc("org", "OBJ-1", "KR-ORG", "", ""))
+		writeFile(t, filepath.Join(dir, "team.yml"), alignTestDoc("team", "OBJ-T", "KR-T", tc.objAligns, tc.krAligns))
+		writeFile(t, filepath.Join(dir, "team2.yml"), alignTestDoc("team", "OBJ-T2", "KR-T2", "", ""))
+		writeFile(t, filepath.Join(dir, "person.yml"), alignTestDoc("person", "OBJ-P", "KR-P", "", ""))
+
+		_, err := LoadFromDir(dir)
+		var vErrs ValidationErrors
+		if !errors.As(err, &vErrs) || len(vErrs) != 1 || vErrs[0].Code != tc.wantCode {
+			t.Fatalf("%s: expected %s, got %v", tc.name, tc.wantCode, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package okrstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// cacheSchemaVersion must be bumped whenever Document fields change.
+const cacheSchemaVersion = 5
+
+type cacheFile struct {
+	SchemaVersion int        `json:"schema_version"`
+	OKRsDir       string     `json:"okrs_dir"`
+	DirHash       string     `json:"dir_hash"`
+	Documents     []Document `json:"documents"`
+}
+
+// LoadFromDirCached behaves like LoadFromDir but reuses parsed documents
+// persisted under cacheDir when the OKR files are unchanged. An empty
+// cacheDir disables caching. Cache read/write failures fall back to a
+// full load; they never fail the call.
+func LoadFromDirCached(okrsDir string, cacheDir string) (*Store, error) {
+	if cacheDir == "" {
+		return LoadFromDir(okrsDir)
+	}
+	if okrsDir == "" {
+		okrsDir = "okrs"
+	}
+	absDir, err := filepath.Abs(okrsDir)
+	if err != nil {
+		return LoadFromDir(okrsDir)
+	}
+	dirHash, err := DirHash(absDir)
+	if err != nil {
+		return LoadFromDir(okrsDir)
+	}
+	cachePath := cachePathFor(cacheDir, absDir)
+
+	if cached, ok := readCache(cachePath); ok && cached.DirHash == dirHash && cached.OKRsDir == absDir {
+		return buildStore(cached.Documents), nil
+	}
+
+	store, err := LoadFromDir(okrsDir)
+	if err != nil {
+		return nil, err
+	}
+	_ = writeCache(cachePath, cacheFile{
+		SchemaVersion: cacheSchemaVersion,
+		OKRsDir:       absDir,
+		DirHash:       dirHash,
+		Documents:     store.documents(),
+	})
+	return store, nil
+}
+
+// DirHash returns a content hash over the OKR files LoadFromDir reads.
+// Each file contributes its okrs-relative path and the SHA256 of its
+// contents, the same per-file hash the daemon watcher records. The layout
+// file is hashed too, since it carries validation rules.
+func DirHash(okrsDir string) (string, error) {
+	files, err := OKRFiles(okrsDir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, path := range files {
+		fileHash, err := HashFile(path)
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(okrsDir, path)
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", path, err)
+		}
+		fmt.Fprintf(h, "%s\x00%s\n", filepath.ToSlash(rel), fileHash)
+	}
+	if layoutHash, err := HashFile(filepath.Join(okrsDir, LayoutFileName)); err == nil {
+		fmt.Fprintf(h, "%s\x00%s\n", LayoutFileName, layoutHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFile returns the hex SHA256 of a file's contents.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func cachePathFor(cacheDir, absOKRsDir string) string {
+	sum := sha256.Sum256([]byte(absOKRsDir))
+	return filepath.Join(cacheDir, fmt.Sprintf("okrstore-%x.json", sum[:4]))
+}
+
+func readCache(path string) (cacheFile, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheFile{}, false
+	}
+	var cached cacheFile
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return cacheFile{}, false
+	}
+	if cached.SchemaVersion != cacheSchemaVersion {
+		return cacheFile{}, false
+	}
+	return cached, true
+}
+
+func writeCache(path string, cached cacheFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// documents returns the loaded documents in scope order.
+func (s *Store) documents() []Document {
+	var docs []Document
+	docs = append(docs, s.Org.Documents...)
+	docs = append(docs, s.Team.Documents...)
+	docs = append(docs, s.Person.Documents...)
+	return docs
+}
@@ -0,0 +1,95 @@
+package okrstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cacheTestOrg = `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Cached objective
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: m1
+        baseline: 0
+        target: 10
+        confidence: 0.5
+        status: not_started
+        evidence: ["seed"]
+`
+
+func TestLoadFromDirCached(t *testing.T) {
+	root := t.TempDir()
+	okrsDir := filepath.Join(root, "okrs")
+	cacheDir := filepath.Join(root, "artifacts", "cache")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatalf("mkdir okrs: %v", err)
+	}
+	orgPath := filepath.Join(okrsDir, "org.yml")
+	writeFile(t, orgPath, cacheTestOrg)
+
+	store, err := LoadFromDirCached(okrsDir, cacheDir)
+	if err != nil {
+		t.Fatalf("first load: %v", err)
+	}
+	if _, ok := store.KeyResultLookup("KR-1"); !ok {
+		t.Fatalf("expected KR-1 after first load")
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one cache file, got %v (err %v)", entries, err)
+	}
+	cachePath := filepath.Join(cacheDir, entries[0].Name())
+
+	// Tamper with the cached documents: a cache hit must return them as-is.
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("read cache: %v", err)
+	}
+	writeFile(t, cachePath, strings.Replace(string(data), "Cached objective", "From cache", 1))
+	store, err = LoadFromDirCached(okrsDir, cacheDir)
+	if err != nil {
+		t.Fatalf("cached load: %v", err)
+	}
+	if rec, _ := store.ObjectiveLookup("OBJ-1"); rec.Objective.Objective != "From cache" {
+		t.Fatalf("expected cache hit, got %q", rec.Objective.Objective)
+	}
+
+	// Editing a file invalidates the cache.
+	writeFile(t, orgPath, strings.Replace(cacheTestOrg, "target: 10", "target: 20", 1))
+	store, err = LoadFromDirCached(okrsDir, cacheDir)
+	if err != nil {
+		t.Fatalf("load after edit: %v", err)
+	}
+	kr, _ := store.KeyResultLookup("KR-1")
+	if kr.KeyResult.Target != 20 || kr.Objective.Objective != "Cached objective" {
+		t.Fatalf("expected reload after edit, got target %v objective %q", kr.KeyResult.Target, kr.Objective.Objective)
+	}
+
+	// permissions.yml is not part of the store and does not affect the hash.
+	before, err := DirHash(okrsDir)
+	if err != nil {
+		t.Fatalf("dir hash: %v", err)
+	}
+	writeFile(t, filepath.Join(okrsDir, "permissions.yml"), "permissions: {}\n")
+	after, err := DirHash(okrsDir)
+	if err != nil {
+		t.Fatalf("dir hash: %v", err)
+	}
+	if before != after {
+		t.Fatalf("permissions.yml should not change dir hash")
+	}
+
+	// Invalid YAML is still reported even if a cache exists.
+	writeFile(t, orgPath, "scope: org\nobjectives: [")
+	if _, err := LoadFromDirCached(okrsDir, cacheDir); err == nil {
+		t.Fatalf("expected parse error after breaking org.yml")
+	}
+}
@@ -0,0 +1,211 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// baseDirName holds, inside a proposal dir, the okrs/ files the proposal
+// was made against. A dot directory, so loading the proposal skips it.
+const baseDirName = ".base"
+
+// ApplyConflict is a file that changed in okrs/ after the proposal touching
+// it was created.
+type ApplyConflict struct {
+	File string `json:"file"`
+	// Objectives and KeyResults are the ids changed both in okrs/ and by
+	// the proposal. Both are empty when the two changed different parts of
+	// the file; applying would still discard the okrs/ edits.
+	Objectives []string `json:"objectives,omitempty"`
+	KeyResults []string `json:"key_results,omitempty"`
+}
+
+// ConflictError reports that okrs/ drifted from the base a proposal was
+// created against.
+type ConflictError struct {
+	ProposalID string
+	Conflicts  []ApplyConflict
+}
+
+func (e *ConflictError) Error() string {
+	parts := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		var ids []string
+		for _, id := range c.Objectives {
+			ids = append(ids, "objective "+id)
+		}
+		for _, id := range c.KeyResults {
+			ids = append(ids, "key result "+id)
+		}
+		if len(ids) == 0 {
+			parts = append(parts, c.File+" (changed elsewhere in the file)")
+		} else {
+			parts = append(parts, c.File+" ("+strings.Join(ids, ", ")+")")
+		}
+	}
+	return fmt.Sprintf("proposal %s conflicts with changes made to okrs/ since it was created: %s; re-propose against the current okrs/ or use --overwrite",
+		e.ProposalID, strings.Join(parts, "; "))
+}
+
+// recordBase hashes and copies the okrs/ version of each proposal file into
+// the proposal's base dir. Files absent from okrs/ get an empty hash.
+func recordBase(okrsDir string, files []string, proposalDir string) (map[string]string, error) {
+	hashes := make(map[string]string, len(files))
+	for _, file := range files {
+		src := filepath.Join(okrsDir, filepath.FromSlash(file))
+		hash, err := HashFile(src)
+		if os.IsNotExist(err) {
+			hashes[file] = ""
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hash %s: %w", file, err)
+		}
+		if err := copyFile(src, filepath.Join(proposalDir, baseDirName, filepath.FromSlash(file))); err != nil {
+			return nil, fmt.Errorf("record base of %s: %w", file, err)
+		}
+		hashes[file] = hash
+	}
+	return hashes, nil
+}
+
+// detectConflicts compares each proposal file's okrs/ version with the
+// recorded base. A file conflicts when okrs/ changed it and it does not
+// already match the proposal. Proposals without a recorded base are never
+// in conflict.
+func detectConflicts(meta *ProposalMetadata) ([]ApplyConflict, error) {
+	if meta.BaseHashes == nil {
+		return nil, nil
+	}
+	var conflicts []ApplyConflict
+	for _, file := range meta.Files {
+		base, ok := meta.BaseHashes[file]
+		if !ok {
+			continue
+		}
+		current, err := HashFile(filepath.Join(meta.OKRsDir, filepath.FromSlash(file)))
+		if os.IsNotExist(err) {
+			current, err = "", nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hash %s: %w", file, err)
+		}
+		if current == base {
+			continue
+		}
+		proposed, err := HashFile(filepath.Join(meta.ProposalDir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, fmt.Errorf("hash proposal %s: %w", file, err)
+		}
+		if current == proposed {
+			continue
+		}
+		conflicts = append(conflicts, conflictIn(meta, file))
+	}
+	return conflicts, nil
+}
+
+// conflictIn names the objectives and key results in file that okrs/ and
+// the proposal both changed, differently, relative to the base.
+func conflictIn(meta *ProposalMetadata, file string) ApplyConflict {
+	conflict := ApplyConflict{File: file}
+	rel := filepath.FromSlash(file)
+	base := readOKRItems(filepath.Join(meta.ProposalDir, baseDirName, rel))
+	current := readOKRItems(filepath.Join(meta.OKRsDir, rel))
+	proposed := readOKRItems(filepath.Join(meta.ProposalDir, rel))
+
+	for _, key := range sortedItemKeys(current, proposed) {
+		b, c, p := base[key].fields, current[key].fields, proposed[key].fields
+		if reflect.DeepEqual(c, b) || reflect.DeepEqual(p, b) || reflect.DeepEqual(c, p) {
+			continue
+		}
+		if key.kr {
+			conflict.KeyResults = append(conflict.KeyResults, key.id)
+		} else {
+			conflict.Objectives = append(conflict.Objectives, key.id)
+		}
+	}
+	return conflict
+}
+
+type okrItemKey struct {
+	kr bool
+	id string
+}
+
+// okrItem is a loosely parsed objective (without its key results) or key
+// result.
+type okrItem struct {
+	scope  Scope
+	fields map[string]any
+}
+
+// readOKRItems loosely parses an OKR file into its objectives and key
+// results, keyed by id. Unreadable or invalid files yield no items.
+func readOKRItems(path string) map[okrItemKey]okrItem {
+	items := map[okrItemKey]okrItem{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return items
+	}
+	var doc struct {
+		Scope      Scope            `yaml:"scope"`
+		Objectives []map[string]any `yaml:"objectives"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return items
+	}
+	for _, obj := range doc.Objectives {
+		id, _ := obj["objective_id"].(string)
+		krs, _ := obj["key_results"].([]any)
+		delete(obj, "key_results")
+		if id != "" {
+			items[okrItemKey{id: id}] = okrItem{scope: doc.Scope, fields: obj}
+		}
+		for _, raw := range krs {
+			kr, _ := raw.(map[string]any)
+			if krID, _ := kr["kr_id"].(string); krID != "" {
+				items[okrItemKey{kr: true, id: krID}] = okrItem{scope: doc.Scope, fields: kr}
+			}
+		}
+	}
+	return items
+}
+
+// readOKRItemsIn merges readOKRItems over the given files of dir.
+func readOKRItemsIn(dir string, files []string) map[okrItemKey]okrItem {
+	items := map[okrItemKey]okrItem{}
+	for _, file := range files {
+		for key, item := range readOKRItems(filepath.Join(dir, filepath.FromSlash(file))) {
+			items[key] = item
+		}
+	}
+	return items
+}
+
+// sortedItemKeys returns the keys of all maps, objectives first, by id.
+func sortedItemKeys(maps ...map[okrItemKey]okrItem) []okrItemKey {
+	seen := map[okrItemKey]bool{}
+	var keys []okrItemKey
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kr != keys[j].kr {
+			return !keys[i].kr
+		}
+		return keys[i].id < keys[j].id
+	})
+	return keys
+}
@@ -0,0 +1,77 @@
+package okrstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyProposalDetectsConflicts(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+	writeFile(t, filepath.Join(updatesDir, "team.yml"), lockTestObjective("OBJ-2", "2"))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if meta.BaseHashes["org.yml"] == "" {
+		t.Fatalf("expected base hash for org.yml, got %v", meta.BaseHashes)
+	}
+	approveProposal(t, "", meta.ProposalDir)
+
+	// okrs/ changes the same KR after the proposal was made.
+	drifted := lockTestObjective("OBJ-1", "9")
+	writeFile(t, filepath.Join(okrsDir, "org.yml"), drifted)
+
+	_, err = ApplyProposal(meta.ProposalDir, true)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0].File != "org.yml" ||
+		strings.Join(conflict.Conflicts[0].KeyResults, ",") != "KR-OBJ-1" || len(conflict.Conflicts[0].Objectives) != 0 {
+		t.Fatalf("conflicts = %+v", conflict.Conflicts)
+	}
+	if !strings.Contains(err.Error(), "org.yml (key result KR-OBJ-1)") {
+		t.Fatalf("error should name the conflicting KR: %v", err)
+	}
+	if org, _ := os.ReadFile(filepath.Join(okrsDir, "org.yml")); string(org) != drifted {
+		t.Fatalf("conflicting apply must not write: %s", org)
+	}
+
+	if _, err := ApplyProposalIn("", meta.ProposalDir, ApplyOptions{Confirm: true, Overwrite: true}); err != nil {
+		t.Fatalf("apply with overwrite: %v", err)
+	}
+	if org, _ := os.ReadFile(filepath.Join(okrsDir, "org.yml")); !strings.Contains(string(org), "target: 5") {
+		t.Fatalf("overwrite should apply the proposal: %s", org)
+	}
+}
+
+func TestConflictInReportsDisjointEdits(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+
+	// okrs/ only renames the objective; the proposal only changes the KR.
+	writeFile(t, filepath.Join(okrsDir, "org.yml"),
+		strings.Replace(lockTestObjective("OBJ-1", "2"), "objective: Baseline", "objective: Renamed", 1))
+	conflicts, err := detectConflicts(meta)
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if len(conflicts) != 1 || len(conflicts[0].Objectives)+len(conflicts[0].KeyResults) != 0 {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+
+	// A proposal without a recorded base predates conflict detection.
+	meta.BaseHashes = nil
+	if conflicts, err := detectConflicts(meta); err != nil || len(conflicts) != 0 {
+		t.Fatalf("legacy proposal conflicts = %v, %v", conflicts, err)
+	}
+}
@@ -0,0 +1,260 @@
+package okrstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// HistoryDirName is where prior versions of OKR files are kept, inside the
+// okrs dir. A dot directory, so the loader never reads them.
+const HistoryDirName = ".history"
+
+const historyMetaFile = "version.json"
+
+// Reasons recorded on history versions.
+const (
+	HistoryReasonApply        = "apply"
+	HistoryReasonStatusUpdate = "status_update"
+	HistoryReasonRollover     = "rollover"
+	HistoryReasonRollback     = "rollback"
+)
+
+// HistoryVersion is the state of some OKR files just before a change.
+type HistoryVersion struct {
+	Version    string        `json:"version"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Reason     string        `json:"reason"`
+	Actor      string        `json:"actor,omitempty"`
+	ProposalID string        `json:"proposal_id,omitempty"`
+	Files      []HistoryFile `json:"files"`
+	// Dir is the version's directory; it is not stored.
+	Dir string `json:"-"`
+}
+
+// HistoryFile is one file saved in a version. Existed is false for files
+// the change created; rolling back removes them.
+type HistoryFile struct {
+	Path    string `json:"path"`
+	Existed bool   `json:"existed"`
+}
+
+// HistoryChange describes the change a version is recorded for.
+type HistoryChange struct {
+	Reason     string
+	Actor      string
+	ProposalID string
+}
+
+// RecordHistory saves the current version of files (okrs-relative,
+// slash-separated) under <okrsDir>/.history/<version>/ before they are
+// changed.
+func RecordHistory(okrsDir string, files []string, change HistoryChange) (*HistoryVersion, error) {
+	now := time.Now().UTC()
+	root := filepath.Join(okrsDir, HistoryDirName)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create history dir: %w", err)
+	}
+	base := now.Format("20060102T150405Z")
+	version := base
+	var dir string
+	for i := 2; ; i++ {
+		dir = filepath.Join(root, version)
+		if err := os.Mkdir(dir, 0o755); err == nil {
+			break
+		} else if !os.IsExist(err) {
+			return nil, fmt.Errorf("create history version: %w", err)
+		}
+		version = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	v := &HistoryVersion{
+		Version:    version,
+		CreatedAt:  now,
+		Reason:     change.Reason,
+		Actor:      change.Actor,
+		ProposalID: change.ProposalID,
+		Dir:        dir,
+	}
+	for _, file := range files {
+		if err := checkProposalFile(file); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+		src := filepath.Join(okrsDir, filepath.FromSlash(file))
+		existed := true
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			existed = false
+		} else if err := copyFile(src, filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("save %s: %w", file, err)
+		}
+		v.Files = append(v.Files, HistoryFile{Path: file, Existed: existed})
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("encode %s: %w", historyMetaFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, historyMetaFile), append(data, '\n'), 0o644); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("write %s: %w", historyMetaFile, err)
+	}
+	return v, nil
+}
+
+// Discard removes a version, for changes that were rolled back.
+func (v *HistoryVersion) Discard() {
+	_ = os.RemoveAll(v.Dir)
+}
+
+// ListHistory returns the recorded versions, newest first. Versions
+// without readable metadata are skipped.
+func ListHistory(okrsDir string) ([]*HistoryVersion, error) {
+	root := filepath.Join(okrsDir, HistoryDirName)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history dir: %w", err)
+	}
+	var versions []*HistoryVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, err := ReadHistory(okrsDir, entry.Name())
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		if !versions[i].CreatedAt.Equal(versions[j].CreatedAt) {
+			return versions[i].CreatedAt.After(versions[j].CreatedAt)
+		}
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+// ReadHistory reads one version.
+func ReadHistory(okrsDir, version string) (*HistoryVersion, error) {
+	if version == "" || strings.ContainsAny(version, `/\`) || version == "." || version == ".." {
+		return nil, fmt.Errorf("invalid history version %q", version)
+	}
+	dir := filepath.Join(okrsDir, HistoryDirName, version)
+	data, err := os.ReadFile(filepath.Join(dir, historyMetaFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("history version %s not found", version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history version %s: %w", version, err)
+	}
+	var v HistoryVersion
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parse history version %s: %w", version, err)
+	}
+	v.Dir = dir
+	return &v, nil
+}
+
+// DiffHistory renders a unified diff from a version's files to their
+// current state in okrsDir.
+func DiffHistory(okrsDir string, v *HistoryVersion) (string, error) {
+	var parts []string
+	for _, file := range v.Files {
+		var old []byte
+		if file.Existed {
+			data, err := os.ReadFile(filepath.Join(v.Dir, filepath.FromSlash(file.Path)))
+			if err != nil {
+				return "", fmt.Errorf("read %s@%s: %w", file.Path, v.Version, err)
+			}
+			old = data
+		}
+		current, err := os.ReadFile(filepath.Join(okrsDir, filepath.FromSlash(file.Path)))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("read %s: %w", file.Path, err)
+		}
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        diffLines(old),
+			B:        diffLines(current),
+			FromFile: path.Join(v.Version, file.Path),
+			ToFile:   path.Join("okrs", file.Path),
+			Context:  3,
+		})
+		if err != nil {
+			return "", fmt.Errorf("diff %s: %w", file.Path, err)
+		}
+		if strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// RollbackHistory restores the files of a version into okrsDir, removing
+// those that did not exist then. The state it replaces is recorded as a
+// new version first, so a rollback can itself be rolled back. If the
+// restored OKRs fail validation, the replaced state is put back.
+func RollbackHistory(okrsDir, version, actor string) (*HistoryVersion, error) {
+	v, err := ReadHistory(okrsDir, version)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(v.Files))
+	targets := make([]string, 0, len(v.Files))
+	for _, file := range v.Files {
+		files = append(files, file.Path)
+		targets = append(targets, filepath.Join(okrsDir, filepath.FromSlash(file.Path)))
+	}
+	locks, err := lockFiles(targets)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+
+	saved, err := RecordHistory(okrsDir, files, HistoryChange{Reason: HistoryReasonRollback, Actor: actor})
+	if err != nil {
+		return nil, err
+	}
+	if err := restoreHistory(okrsDir, v); err != nil {
+		return nil, undoRollback(okrsDir, saved, err)
+	}
+	if _, err := LoadFromDir(okrsDir); err != nil {
+		return nil, undoRollback(okrsDir, saved, fmt.Errorf("rolled back okrs failed validation: %w", err))
+	}
+	return saved, nil
+}
+
+func restoreHistory(okrsDir string, v *HistoryVersion) error {
+	for _, file := range v.Files {
+		dst := filepath.Join(okrsDir, filepath.FromSlash(file.Path))
+		if !file.Existed {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove %s: %w", file.Path, err)
+			}
+			continue
+		}
+		if err := copyFile(filepath.Join(v.Dir, filepath.FromSlash(file.Path)), dst); err != nil {
+			return fmt.Errorf("restore %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+func undoRollback(okrsDir string, saved *HistoryVersion, cause error) error {
+	if err := restoreHistory(okrsDir, saved); err != nil {
+		return fmt.Errorf("%w; undoing the rollback failed: %v (prior state in %s)", cause, err, saved.Dir)
+	}
+	saved.Discard()
+	return fmt.Errorf("%w; okrs left unchanged", cause)
+}
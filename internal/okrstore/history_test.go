@@ -0,0 +1,73 @@
+package okrstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryRecordsApplyAndRollsBack(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+	writeFile(t, filepath.Join(updatesDir, "new.yml"), lockTestObjective("OBJ-3", "4"))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	approveProposal(t, "", meta.ProposalDir)
+	if _, err := ApplyProposal(meta.ProposalDir, true); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	versions, err := ListHistory(okrsDir)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Reason != HistoryReasonApply || versions[0].ProposalID != meta.ID {
+		t.Fatalf("versions = %+v", versions)
+	}
+	applied := versions[0]
+
+	diff, err := DiffHistory(okrsDir, applied)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	for _, want := range []string{"-        target: 2", "+        target: 5", "+++ okrs/new.yml"} {
+		if !strings.Contains(diff, want) {
+			t.Fatalf("diff missing %q:\n%s", want, diff)
+		}
+	}
+
+	saved, err := RollbackHistory(okrsDir, applied.Version, "tester")
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if org, _ := os.ReadFile(filepath.Join(okrsDir, "org.yml")); string(org) != lockTestObjective("OBJ-1", "2") {
+		t.Fatalf("org.yml not restored: %s", org)
+	}
+	if _, err := os.Stat(filepath.Join(okrsDir, "new.yml")); !os.IsNotExist(err) {
+		t.Fatalf("expected new.yml to be removed, stat err %v", err)
+	}
+
+	versions, err = ListHistory(okrsDir)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != saved.Version || versions[0].Reason != HistoryReasonRollback {
+		t.Fatalf("versions after rollback = %+v", versions)
+	}
+
+	// Rolling back the rollback re-applies the proposal's files.
+	if _, err := RollbackHistory(okrsDir, saved.Version, "tester"); err != nil {
+		t.Fatalf("undo rollback: %v", err)
+	}
+	if org, _ := os.ReadFile(filepath.Join(okrsDir, "org.yml")); string(org) != lockTestObjective("OBJ-1", "5") {
+		t.Fatalf("org.yml not re-applied: %s", org)
+	}
+
+	if _, err := ReadHistory(okrsDir, "../okrs"); err == nil {
+		t.Fatalf("expected a path as version to be refused")
+	}
+}
@@ -0,0 +1,171 @@
+package okrstore
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutFileName is the optional file in an okrs dir that selects which
+// files are loaded. Like other dotfiles it is never loaded as OKRs.
+const LayoutFileName = ".okrs.yml"
+
+// Layout selects OKR files by their slash-separated path relative to the
+// okrs dir. Patterns use path.Match syntax plus "**" for any number of
+// directories, e.g. "teams/**/*.yaml".
+type Layout struct {
+	// Include replaces the default patterns when set.
+	Include []string `yaml:"include"`
+	// Exclude removes matching files from the included set.
+	Exclude []string `yaml:"exclude"`
+}
+
+// DefaultInclude loads every .yml and .yaml file at any depth.
+var DefaultInclude = []string{"**/*.yml", "**/*.yaml"}
+
+// LoadLayout reads the layout file in okrsDir. A missing file yields the
+// default layout.
+func LoadLayout(okrsDir string) (Layout, error) {
+	layout := Layout{Include: DefaultInclude}
+	data, err := os.ReadFile(filepath.Join(okrsDir, LayoutFileName))
+	if os.IsNotExist(err) {
+		return layout, nil
+	}
+	if err != nil {
+		return layout, fmt.Errorf("read %s: %w", LayoutFileName, err)
+	}
+	var file Layout
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return layout, fmt.Errorf("parse %s: %w", LayoutFileName, err)
+	}
+	if len(file.Include) > 0 {
+		layout.Include = file.Include
+	}
+	layout.Exclude = file.Exclude
+	for _, pattern := range append(append([]string{}, layout.Include...), layout.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return layout, fmt.Errorf("%s: invalid pattern %q: %w", LayoutFileName, pattern, err)
+		}
+	}
+	return layout, nil
+}
+
+// Matches reports whether the okrs-relative path rel is an OKR file under
+// this layout. permissions.yml at the top level, hidden files, and files in
+// hidden directories never match.
+func (l Layout) Matches(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if rel == "permissions.yml" {
+		return false
+	}
+	for _, segment := range strings.Split(rel, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+	included := false
+	for _, pattern := range l.Include {
+		if matchGlob(pattern, rel) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range l.Exclude {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+// OKRFiles returns the OKR files LoadFromDir reads from okrsDir, walking
+// subdirectories, sorted by relative path.
+func OKRFiles(okrsDir string) ([]string, error) {
+	layout, err := LoadLayout(okrsDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = filepath.WalkDir(okrsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == okrsDir && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(okrsDir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if layout.Matches(rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan okr dir: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return filepath.ToSlash(files[i]) < filepath.ToSlash(files[j])
+	})
+	return files, nil
+}
+
+// IsOKRFile reports whether path (inside okrsDir) would be loaded as OKRs.
+func IsOKRFile(okrsDir, p string) bool {
+	rel, err := filepath.Rel(okrsDir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	layout, err := LoadLayout(okrsDir)
+	if err != nil {
+		return false
+	}
+	return layout.Matches(rel)
+}
+
+// matchGlob matches a slash-separated path against a pattern in which a
+// "**" segment matches zero or more directories.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
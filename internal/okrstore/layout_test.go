@@ -0,0 +1,146 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const layoutDoc = `
+scope: %s
+objectives:
+  - objective_id: %s
+    objective: Objective %s
+    owner_id: %s
+    key_results:
+      - kr_id: %s-KR
+        description: desc
+        owner_id: %s
+        metric_key: m.%s
+        baseline: 0
+        target: 1
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+
+func writeLayoutDoc(t *testing.T, dir, rel, scope, objID, owner string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, path, fmt.Sprintf(layoutDoc, scope, objID, objID, owner, objID, owner, objID))
+}
+
+func TestLoadFromDirRecursiveAndYAMLExtension(t *testing.T) {
+	okrsDir := t.TempDir()
+	writeLayoutDoc(t, okrsDir, "org.yml", "org", "OBJ-ORG", "org")
+	writeLayoutDoc(t, okrsDir, "platform/team.yaml", "team", "OBJ-TEAM", "platform")
+	writeLayoutDoc(t, okrsDir, "platform/people/alice.yaml", "person", "OBJ-ALICE", "alice")
+	writeLayoutDoc(t, okrsDir, ".drafts/wip.yml", "org", "OBJ-DRAFT", "org")
+	writeFile(t, filepath.Join(okrsDir, "permissions.yml"), "permissions:\n  read: [\"all\"]\n")
+
+	store, err := LoadFromDir(okrsDir)
+	if err != nil {
+		t.Fatalf("LoadFromDir: %v", err)
+	}
+	for _, id := range []string{"OBJ-ORG", "OBJ-TEAM", "OBJ-ALICE"} {
+		if _, ok := store.ObjectiveLookup(id); !ok {
+			t.Errorf("objective %s not loaded", id)
+		}
+	}
+	if _, ok := store.ObjectiveLookup("OBJ-DRAFT"); ok {
+		t.Errorf("hidden directory should be skipped")
+	}
+	rec, _ := store.ObjectiveLookup("OBJ-ALICE")
+	if !strings.HasSuffix(filepath.ToSlash(rec.Source), "platform/people/alice.yaml") {
+		t.Errorf("source = %s", rec.Source)
+	}
+}
+
+func TestLayoutIncludeExclude(t *testing.T) {
+	okrsDir := t.TempDir()
+	writeLayoutDoc(t, okrsDir, "org.yml", "org", "OBJ-ORG", "org")
+	writeLayoutDoc(t, okrsDir, "teams/platform.yaml", "team", "OBJ-PLATFORM", "platform")
+	writeLayoutDoc(t, okrsDir, "teams/archive/old.yaml", "team", "OBJ-OLD", "platform")
+	writeLayoutDoc(t, okrsDir, "notes/scratch.yml", "org", "OBJ-SCRATCH", "org")
+	writeFile(t, filepath.Join(okrsDir, LayoutFileName), `
+include: ["*.yml", "teams/**/*.yaml"]
+exclude: ["**/archive/**"]
+`)
+
+	files, err := OKRFiles(okrsDir)
+	if err != nil {
+		t.Fatalf("OKRFiles: %v", err)
+	}
+	var rels []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(okrsDir, f)
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	if got := strings.Join(rels, ","); got != "org.yml,teams/platform.yaml" {
+		t.Fatalf("files = %s", got)
+	}
+	if !IsOKRFile(okrsDir, filepath.Join(okrsDir, "teams", "new.yaml")) {
+		t.Errorf("teams/new.yaml should match the layout")
+	}
+	if IsOKRFile(okrsDir, filepath.Join(okrsDir, "teams", "archive", "x.yaml")) {
+		t.Errorf("archived file should be excluded")
+	}
+
+	writeFile(t, filepath.Join(okrsDir, LayoutFileName), "include: [\"[\"]\n")
+	if _, err := OKRFiles(okrsDir); err == nil {
+		t.Errorf("expected invalid pattern error")
+	}
+}
+
+func TestProposalPreservesSubpaths(t *testing.T) {
+	root := t.TempDir()
+	okrsDir := filepath.Join(root, "okrs")
+	updatesDir := filepath.Join(root, "updates")
+	writeLayoutDoc(t, okrsDir, "org.yml", "org", "OBJ-ORG", "team-alpha")
+	writeLayoutDoc(t, okrsDir, "platform/alice.yaml", "person", "OBJ-ALICE", "team-alpha")
+	writeLayoutDoc(t, updatesDir, "platform/alice.yaml", "person", "OBJ-ALICE", "team-alpha")
+	perm := "permissions:\n  read: [\"all\"]\n  write: [\"owner_id_match\"]\n"
+	writeFile(t, filepath.Join(okrsDir, "permissions.yml"), perm)
+	writeFile(t, filepath.Join(updatesDir, "permissions.yml"), perm)
+	updated := filepath.Join(updatesDir, "platform", "alice.yaml")
+	data, err := os.ReadFile(updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, updated, strings.Replace(string(data), "target: 1", "target: 9", 1))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, filepath.Join(root, "proposals"), "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if got := strings.Join(meta.Files, ","); got != "permissions.yml,platform/alice.yaml" {
+		t.Fatalf("files = %s", got)
+	}
+	diff, err := os.ReadFile(filepath.Join(meta.ProposalDir, meta.DiffFile))
+	if err != nil {
+		t.Fatalf("read diff: %v", err)
+	}
+	if !strings.Contains(string(diff), "okrs/platform/alice.yaml") {
+		t.Fatalf("diff should name the subpath:\n%s", diff)
+	}
+
+	approveProposal(t, "", meta.ProposalDir)
+	if _, err := ApplyProposal(meta.ProposalDir, true); err != nil {
+		t.Fatalf("apply proposal: %v", err)
+	}
+	applied, err := os.ReadFile(filepath.Join(okrsDir, "platform", "alice.yaml"))
+	if err != nil {
+		t.Fatalf("read applied: %v", err)
+	}
+	if !strings.Contains(string(applied), "target: 9") {
+		t.Fatalf("change not applied to subpath:\n%s", applied)
+	}
+	if _, err := os.Stat(filepath.Join(okrsDir, "alice.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("file should not be flattened into okrs/: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package okrstore
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Finding codes. They are stable so CI scripts can match on them; a code
+// is never reused for a different check.
+const (
+	CodeYAMLInvalid              = "yaml_invalid"
+	CodeScopeInvalid             = "scope_invalid"
+	CodeObjectivesMissing        = "objectives_missing"
+	CodeObjectiveIDMissing       = "objective_id_missing"
+	CodeObjectiveIDDuplicate     = "objective_id_duplicate"
+	CodeObjectiveTextMissing     = "objective_text_missing"
+	CodeWeightNegative           = "weight_negative"
+	CodeKeyResultsMissing        = "key_results_missing"
+	CodeKRIDMissing              = "kr_id_missing"
+	CodeKRIDDuplicate            = "kr_id_duplicate"
+	CodeDescriptionMissing       = "description_missing"
+	CodeOwnerIDMissing           = "owner_id_missing"
+	CodeMetricKeyMissing         = "metric_key_missing"
+	CodeBaselineMissing          = "baseline_missing"
+	CodeTargetMissing            = "target_missing"
+	CodeTargetEqualsBaseline     = "target_equals_baseline"
+	CodeTargetDirection          = "target_direction"
+	CodeConfidenceMissing        = "confidence_missing"
+	CodeConfidenceRange          = "confidence_out_of_range"
+	CodeStatusMissing            = "status_missing"
+	CodeStatusInvalid            = "status_invalid"
+	CodeEvidenceMissing          = "evidence_missing"
+	CodeEvidenceEmpty            = "evidence_empty"
+	CodeEvidenceNotURI           = "evidence_not_uri"
+	CodeEvidenceScheme           = "evidence_scheme"
+	CodeLastUpdatedInvalid       = "last_updated_invalid"
+	CodeLastUpdatedFuture        = "last_updated_future"
+	CodeLastUpdatedOutsidePeriod = "last_updated_outside_period"
+	CodePeriodInvalid            = "period_invalid"
+	CodeAlignsToUnknown          = "aligns_to_unknown"
+	CodeAlignsToScope            = "aligns_to_scope"
+	CodeLoadFailed               = "load_failed"
+
+	// Lint warnings.
+	CodeMetricUnknown   = "metric_unknown"
+	CodeOwnerOrphan     = "owner_orphan"
+	CodeBaselinePending = "baseline_pending"
+)
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is one validation error or lint warning.
+type Finding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// LintOptions supplies the context some lint rules need.
+type LintOptions struct {
+	// MetricKnown reports whether some provider produces a metric key.
+	// When set, KRs whose metric_key it does not know are reported.
+	MetricKnown func(key string) bool
+}
+
+// Lint validates the OKRs in okrsDir and, when they are valid, applies the
+// semantic lint rules. Validation problems are errors and stop linting;
+// lint findings are warnings. Findings are sorted by file, then in file
+// order.
+func Lint(okrsDir string, opts LintOptions) ([]Finding, error) {
+	store, err := LoadFromDir(okrsDir)
+	if err != nil {
+		var vErrs ValidationErrors
+		if !errors.As(err, &vErrs) {
+			return []Finding{{Code: CodeLoadFailed, Severity: SeverityError, File: okrsDir, Message: err.Error()}}, nil
+		}
+		findings := make([]Finding, 0, len(vErrs))
+		for _, e := range vErrs {
+			findings = append(findings, Finding{Code: e.Code, Severity: SeverityError, File: e.File, Field: e.Field, Message: e.Message})
+		}
+		sortFindings(findings)
+		return findings, nil
+	}
+	rules, err := LoadRules(okrsDir)
+	if err != nil {
+		return nil, err
+	}
+	roster := map[string]bool{}
+	for _, owner := range rules.Owners {
+		roster[owner] = true
+	}
+
+	var findings []Finding
+	warn := func(code, file, field, message string) {
+		findings = append(findings, Finding{Code: code, Severity: SeverityWarning, File: file, Field: field, Message: message})
+	}
+	for _, doc := range store.documents() {
+		for objIdx, obj := range doc.Objectives {
+			objPath := fmt.Sprintf("objectives[%d]", objIdx)
+			if len(roster) > 0 && obj.OwnerID != "" && !roster[obj.OwnerID] {
+				warn(CodeOwnerOrphan, doc.Source, objPath+".owner_id",
+					fmt.Sprintf("owner %q of objective %s is not in the owners roster", obj.OwnerID, obj.ID))
+			}
+			for krIdx, kr := range obj.KeyResults {
+				krPath := fmt.Sprintf("%s.key_results[%d]", objPath, krIdx)
+				if len(roster) > 0 && !roster[kr.OwnerID] {
+					warn(CodeOwnerOrphan, doc.Source, krPath+".owner_id",
+						fmt.Sprintf("owner %q of key result %s is not in the owners roster", kr.OwnerID, kr.ID))
+				}
+				if opts.MetricKnown != nil && !opts.MetricKnown(kr.MetricKey) {
+					warn(CodeMetricUnknown, doc.Source, krPath+".metric_key",
+						fmt.Sprintf("no metric provider or catalog entry produces %q for key result %s", kr.MetricKey, kr.ID))
+				}
+				if kr.BaselinePending {
+					warn(CodeBaselinePending, doc.Source, krPath+".baseline",
+						fmt.Sprintf("baseline of key result %s has not been measured yet", kr.ID))
+				}
+			}
+		}
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].File < findings[j].File
+	})
+}
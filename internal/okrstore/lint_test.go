@@ -0,0 +1,54 @@
+package okrstore
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintReportsErrorsWithCodes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "org.yml"), fmt.Sprintf(rulesTestKR, "5", "5", "done", `"seed"`, "2025-01-01"))
+
+	findings, err := Lint(dir, LintOptions{})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	codes := map[string]string{}
+	for _, f := range findings {
+		codes[f.Code] = f.Severity
+	}
+	if codes[CodeTargetEqualsBaseline] != SeverityError || codes[CodeStatusInvalid] != SeverityError || len(findings) != 2 {
+		t.Fatalf("findings = %+v", findings)
+	}
+}
+
+func TestLintWarnings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "org.yml"), fmt.Sprintf(rulesTestKR, "null", "3", "not_started", `"seed"`, "2025-01-01"))
+	writeFile(t, filepath.Join(dir, LayoutFileName), "owners: [team-beta]\n")
+
+	findings, err := Lint(dir, LintOptions{MetricKnown: func(key string) bool { return key == "other.metric" }})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	want := []string{CodeOwnerOrphan, CodeMetricUnknown, CodeBaselinePending}
+	if len(findings) != len(want) {
+		t.Fatalf("findings = %+v", findings)
+	}
+	for i, f := range findings {
+		if f.Code != want[i] || f.Severity != SeverityWarning {
+			t.Fatalf("finding %d = %+v, want %s warning", i, f, want[i])
+		}
+	}
+
+	// Without a roster or known metrics those rules stay quiet.
+	writeFile(t, filepath.Join(dir, LayoutFileName), "evidence_uris: false\n")
+	findings, err = Lint(dir, LintOptions{})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Code != CodeBaselinePending {
+		t.Fatalf("findings = %+v", findings)
+	}
+}
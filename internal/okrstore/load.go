@@ -3,34 +3,28 @@ package okrstore
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 )
 
-// LoadFromDir loads and validates all OKR YAML files from the provided directory.
+// LoadFromDir loads and validates all OKR YAML files from the provided
+// directory and its subdirectories, as selected by its layout (see Layout).
 func LoadFromDir(okrsDir string) (*Store, error) {
 	if okrsDir == "" {
 		okrsDir = "okrs"
 	}
 
-	files, err := filepath.Glob(filepath.Join(okrsDir, "*.yml"))
+	files, err := OKRFiles(okrsDir)
 	if err != nil {
-		return nil, fmt.Errorf("scan okr dir: %w", err)
+		return nil, err
 	}
 	if len(files) == 0 {
 		return nil, fmt.Errorf("no OKR YAML files found in %s", okrsDir)
 	}
-	sort.Strings(files)
 
 	var docs []Document
 	var vErrs ValidationErrors
 
 	for _, path := range files {
-		base := filepath.Base(path)
-		if base == "permissions.yml" {
-			// handled by permissions loader
-			continue
-		}
 		data, readErr := os.ReadFile(path)
 		if readErr != nil {
 			return nil, fmt.Errorf("read %s: %w", path, readErr)
@@ -57,6 +51,17 @@ func LoadFromDir(okrsDir string) (*Store, error) {
 	if len(duplicateErrs) > 0 {
 		return nil, duplicateErrs
 	}
+	if alignErrs := validateAlignment(docs); len(alignErrs) > 0 {
+		return nil, alignErrs
+	}
+
+	rules, err := LoadRules(okrsDir)
+	if err != nil {
+		return nil, err
+	}
+	if ruleErrs := validateRules(docs, rules); len(ruleErrs) > 0 {
+		return nil, ruleErrs
+	}
 
 	return buildStore(docs), nil
 }
@@ -83,6 +88,7 @@ func validateCrossDocumentUniqueness(docs []Document) ValidationErrors {
 					errs = append(errs, ValidationError{
 						File:    doc.Source,
 						Field:   fmt.Sprintf("objectives[%d].objective_id", objIdx),
+						Code:    CodeObjectiveIDDuplicate,
 						Message: fmt.Sprintf("objective_id %q duplicates another in scope %s", obj.ID, doc.Scope),
 					})
 				} else {
@@ -98,6 +104,7 @@ func validateCrossDocumentUniqueness(docs []Document) ValidationErrors {
 					errs = append(errs, ValidationError{
 						File:    doc.Source,
 						Field:   fmt.Sprintf("objectives[%d].key_results[%d].kr_id", objIdx, krIdx),
+						Code:    CodeKRIDDuplicate,
 						Message: fmt.Sprintf("kr_id %q already defined in %s (%s objective %s)", kr.ID, origin.file, origin.scope, origin.objID),
 					})
 					continue
@@ -121,7 +128,10 @@ func buildStore(docs []Document) *Store {
 		Person:     PersonOKRs{},
 		objectives: make(map[string]ObjectiveRecord),
 		keyResults: make(map[string]KeyResultRecord),
+		aligned:    make(map[alignKey][]alignKey),
+		ownKRs:     make(map[alignKey][]string),
 	}
+	index := newAlignIndex(docs)
 
 	for _, doc := range docs {
 		switch doc.Scope {
@@ -148,7 +158,11 @@ func buildStore(docs []Document) *Store {
 			}
 			store.objectives[obj.ID] = objRec
 
+			objKey := alignKey{scope: doc.Scope, objective: obj.ID}
+			store.addAlignment(index, doc.Scope, objKey, obj.AlignsTo)
 			for _, kr := range obj.KeyResults {
+				store.ownKRs[objKey] = append(store.ownKRs[objKey], kr.ID)
+				store.addAlignment(index, doc.Scope, alignKey{kr: kr.ID}, kr.AlignsTo)
 				krRec := KeyResultRecord{
 					KeyResult: kr,
 					Objective: objCopy,
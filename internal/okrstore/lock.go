@@ -0,0 +1,79 @@
+package okrstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Lock timing for OKR file locks. Applying a proposal takes milliseconds, so
+// a lock older than lockStaleAfter was left behind by a crashed process.
+var (
+	lockTimeout    = 10 * time.Second
+	lockStaleAfter = time.Minute
+	lockPoll       = 50 * time.Millisecond
+)
+
+// fileLocks holds lock files for a set of OKR files.
+type fileLocks struct {
+	paths []string
+}
+
+// lockPath is the lock file for an OKR file: a dotfile next to it, which the
+// loader never reads.
+func lockPath(file string) string {
+	return filepath.Join(filepath.Dir(file), "."+filepath.Base(file)+".lock")
+}
+
+// lockFiles locks each file, in sorted order so that concurrent callers
+// locking overlapping sets cannot deadlock. It waits up to lockTimeout for
+// locks held by others.
+func lockFiles(files []string) (*fileLocks, error) {
+	sorted := append([]string{}, files...)
+	sort.Strings(sorted)
+	locks := &fileLocks{}
+	for _, file := range sorted {
+		path := lockPath(file)
+		if err := acquireLock(path); err != nil {
+			locks.release()
+			return nil, fmt.Errorf("lock %s: %w", file, err)
+		}
+		locks.paths = append(locks.paths, path)
+	}
+	return locks, nil
+}
+
+func acquireLock(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			return f.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("another apply holds %s; remove it if no apply is running", path)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+func (l *fileLocks) release() {
+	for _, path := range l.paths {
+		_ = os.Remove(path)
+	}
+	l.paths = nil
+}
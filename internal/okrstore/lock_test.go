@@ -0,0 +1,154 @@
+package okrstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const lockTestPerm = `
+permissions:
+  read: ["all"]
+  write: ["owner_id_match", "delegated_explicitly"]
+`
+
+func lockTestObjective(id, target string) string {
+	return `
+scope: org
+objectives:
+  - objective_id: ` + id + `
+    objective: Baseline
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-` + id + `
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: ` + target + `
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+}
+
+func setupLockTestWorkspace(t *testing.T) (okrsDir, updatesDir, proposalsDir string) {
+	t.Helper()
+	root := t.TempDir()
+	okrsDir = filepath.Join(root, "okrs")
+	updatesDir = filepath.Join(root, "updates")
+	proposalsDir = filepath.Join(root, "artifacts", "proposals")
+	for _, dir := range []string{okrsDir, updatesDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+	writeFile(t, filepath.Join(okrsDir, "permissions.yml"), lockTestPerm)
+	writeFile(t, filepath.Join(updatesDir, "permissions.yml"), lockTestPerm)
+	writeFile(t, filepath.Join(okrsDir, "org.yml"), lockTestObjective("OBJ-1", "2"))
+	writeFile(t, filepath.Join(okrsDir, "team.yml"), lockTestObjective("OBJ-2", "2"))
+	return okrsDir, updatesDir, proposalsDir
+}
+
+func TestApplyProposalRollsBackInvalidMerge(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+
+	// Valid on its own, but OBJ-2 collides with team.yml once merged.
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5")+
+		`  - objective_id: OBJ-2
+    objective: Duplicate
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-X
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: 3
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`)
+	writeFile(t, filepath.Join(updatesDir, "new.yml"), lockTestObjective("OBJ-3", "4"))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	approveProposal(t, "", meta.ProposalDir)
+	_, err = ApplyProposal(meta.ProposalDir, true)
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected rolled back validation error, got %v", err)
+	}
+
+	org, err := os.ReadFile(filepath.Join(okrsDir, "org.yml"))
+	if err != nil {
+		t.Fatalf("read org.yml: %v", err)
+	}
+	if string(org) != lockTestObjective("OBJ-1", "2") {
+		t.Fatalf("org.yml not restored: %s", org)
+	}
+	if _, err := os.Stat(filepath.Join(okrsDir, "new.yml")); !os.IsNotExist(err) {
+		t.Fatalf("expected new.yml to be removed, stat err %v", err)
+	}
+	if _, err := LoadFromDir(okrsDir); err != nil {
+		t.Fatalf("okrs dir invalid after rollback: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(okrsDir, ".org.yml.lock")); !os.IsNotExist(err) {
+		t.Fatalf("expected lock to be released, stat err %v", err)
+	}
+
+	// The backup left in the proposal must not affect a retry.
+	if _, err := ApplyProposal(meta.ProposalDir, true); err == nil || !strings.Contains(err.Error(), "failed validation") {
+		t.Fatalf("expected retry to fail merged validation again, got %v", err)
+	}
+}
+
+func TestApplyProposalWaitsForLock(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+
+	approveProposal(t, "", meta.ProposalDir)
+
+	prevTimeout := lockTimeout
+	lockTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = prevTimeout })
+
+	held, err := lockFiles([]string{filepath.Join(okrsDir, "org.yml")})
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := ApplyProposal(meta.ProposalDir, true); err == nil || !strings.Contains(err.Error(), "another apply holds") {
+		t.Fatalf("expected lock contention error, got %v", err)
+	}
+	if org, _ := os.ReadFile(filepath.Join(okrsDir, "org.yml")); strings.Contains(string(org), "target: 5") {
+		t.Fatalf("apply should not write while the lock is held")
+	}
+
+	held.release()
+	if _, err := ApplyProposal(meta.ProposalDir, true); err != nil {
+		t.Fatalf("apply after release: %v", err)
+	}
+}
+
+func TestStaleLockIsReclaimed(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "org.yml")
+	writeFile(t, lockPath(file), "12345\n")
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(lockPath(file), old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	locks, err := lockFiles([]string{file})
+	if err != nil {
+		t.Fatalf("expected stale lock to be reclaimed: %v", err)
+	}
+	locks.release()
+}
@@ -1,6 +1,7 @@
 package okrstore
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -68,6 +69,47 @@ objectives:
 	}
 }
 
+func TestParseAndValidateDocumentNullBaseline(t *testing.T) {
+	kr := `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Test objective
+    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: m1
+%s        target: 1
+        confidence: 0.5
+        status: not_started
+        evidence: []
+`
+	doc, err := ParseAndValidateDocument([]byte(fmt.Sprintf(kr, "        baseline: null\n")), "test.yml")
+	if err != nil {
+		t.Fatalf("explicit null baseline should validate: %v", err)
+	}
+	if !doc.Objectives[0].KeyResults[0].BaselinePending {
+		t.Fatalf("expected BaselinePending for baseline: null")
+	}
+
+	path := filepath.Join(t.TempDir(), "org.yml")
+	if err := WriteDocument(doc, path); err != nil {
+		t.Fatalf("WriteDocument: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "baseline: null") {
+		t.Fatalf("written document lost the null baseline:\n%s", data)
+	}
+
+	if _, err := ParseAndValidateDocument([]byte(fmt.Sprintf(kr, "")), "test.yml"); err == nil || !strings.Contains(err.Error(), "baseline is required") {
+		t.Fatalf("missing baseline should still fail, got %v", err)
+	}
+}
+
 func TestLoadFromDirAndLookup(t *testing.T) {
 	dir := t.TempDir()
 
@@ -179,18 +221,16 @@ delegations:
 	writeFile(t, permPath, perm)
 
 	oldPath := defaultPermissionsPath
-	oldOnce := permOnce
-	oldCache := permCache
-	oldErr := permErr
 	defaultPermissionsPath = permPath
 	permOnce = sync.Once{}
 	permCache = nil
 	permErr = nil
 	t.Cleanup(func() {
+		// Reset rather than restore: sync.Once must not be copied.
 		defaultPermissionsPath = oldPath
-		permOnce = oldOnce
-		permCache = oldCache
-		permErr = oldErr
+		permOnce = sync.Once{}
+		permCache = nil
+		permErr = nil
 	})
 
 	if !CanPropose("owner-a", "owner-a") {
@@ -273,6 +313,7 @@ objectives:
 		t.Fatalf("expected files listed in metadata")
 	}
 
+	approveProposal(t, "", meta.ProposalDir)
 	if _, err := ApplyProposal(meta.ProposalDir, true); err != nil {
 		t.Fatalf("apply proposal: %v", err)
 	}
@@ -286,6 +327,73 @@ objectives:
 	}
 }
 
+func TestProposalPathsSurviveWorkspaceMove(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "ws")
+	okrsDir := filepath.Join(root, "okrs")
+	updatesDir := filepath.Join(root, "updates")
+	for _, dir := range []string{okrsDir, updatesDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	doc := `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Baseline
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: %d
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+	perm := `
+permissions:
+  read: ["all"]
+  write: ["owner_id_match"]
+`
+	writeFile(t, filepath.Join(okrsDir, "permissions.yml"), perm)
+	writeFile(t, filepath.Join(updatesDir, "permissions.yml"), perm)
+	writeFile(t, filepath.Join(okrsDir, "org.yml"), fmt.Sprintf(doc, 2))
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), fmt.Sprintf(doc, 5))
+
+	meta, err := CreateProposalIn(root, "team-alpha", updatesDir, okrsDir, filepath.Join(root, "artifacts", "proposals"), "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(meta.ProposalDir, "proposal.json"))
+	if err != nil {
+		t.Fatalf("read proposal.json: %v", err)
+	}
+	if strings.Contains(string(data), root) {
+		t.Fatalf("proposal.json should not contain absolute workspace paths:\n%s", data)
+	}
+
+	moved := filepath.Join(base, "moved")
+	if err := os.Rename(root, moved); err != nil {
+		t.Fatalf("move workspace: %v", err)
+	}
+	proposalDir := filepath.Join(moved, "artifacts", "proposals", filepath.Base(meta.ProposalDir))
+	approveProposal(t, moved, proposalDir)
+	if _, err := ApplyProposalIn(moved, proposalDir, ApplyOptions{Confirm: true}); err != nil {
+		t.Fatalf("apply proposal after move: %v", err)
+	}
+	applied, err := os.ReadFile(filepath.Join(moved, "okrs", "org.yml"))
+	if err != nil {
+		t.Fatalf("read applied okrs: %v", err)
+	}
+	if !strings.Contains(string(applied), "target: 5") {
+		t.Fatalf("proposal changes not applied: %s", string(applied))
+	}
+}
+
 func TestApplyProposalRequiresConfirmation(t *testing.T) {
 	if _, err := ApplyProposal("some/path", false); err == nil {
 		t.Fatalf("expected error for missing confirmation")
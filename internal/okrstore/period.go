@@ -0,0 +1,183 @@
+package okrstore
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const periodDateLayout = "2006-01-02"
+
+// Period is the OKR cycle an objective belongs to, such as 2025-Q3. Start
+// and End are inclusive YYYY-MM-DD dates. For quarter (2025-Q3), half-year
+// (2025-H2), and year (2025) names they default to the calendar bounds;
+// other names need both set. The zero Period means none was declared.
+type Period struct {
+	Name  string `json:"name,omitempty"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+var (
+	quarterPattern = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+	halfPattern    = regexp.MustCompile(`^(\d{4})-H([12])$`)
+	yearPattern    = regexp.MustCompile(`^(\d{4})$`)
+)
+
+// ParsePeriod builds a Period from its name and optional explicit dates,
+// filling in the calendar bounds of well-known names.
+func ParsePeriod(name, start, end string) (Period, error) {
+	p := Period{Name: name, Start: start, End: end}
+	if name == "" {
+		if start != "" || end != "" {
+			return Period{}, fmt.Errorf("period_start and period_end need a period name")
+		}
+		return p, nil
+	}
+	if defStart, defEnd, ok := calendarBounds(name); ok {
+		if p.Start == "" {
+			p.Start = defStart.Format(periodDateLayout)
+		}
+		if p.End == "" {
+			p.End = defEnd.Format(periodDateLayout)
+		}
+	}
+	if p.Start == "" || p.End == "" {
+		return Period{}, fmt.Errorf("period %q is not a quarter (2025-Q3), half (2025-H2), or year; set period_start and period_end", name)
+	}
+	s, err := time.Parse(periodDateLayout, p.Start)
+	if err != nil {
+		return Period{}, fmt.Errorf("period_start %q must be YYYY-MM-DD", p.Start)
+	}
+	e, err := time.Parse(periodDateLayout, p.End)
+	if err != nil {
+		return Period{}, fmt.Errorf("period_end %q must be YYYY-MM-DD", p.End)
+	}
+	if e.Before(s) {
+		return Period{}, fmt.Errorf("period %s ends (%s) before it starts (%s)", name, p.End, p.Start)
+	}
+	return p, nil
+}
+
+// calendarBounds returns the first and last day of a quarter, half-year,
+// or year name.
+func calendarBounds(name string) (time.Time, time.Time, bool) {
+	var year, firstMonth, months int
+	if m := quarterPattern.FindStringSubmatch(name); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		firstMonth, months = 3*(q-1)+1, 3
+	} else if m := halfPattern.FindStringSubmatch(name); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		h, _ := strconv.Atoi(m[2])
+		firstMonth, months = 6*(h-1)+1, 6
+	} else if m := yearPattern.FindStringSubmatch(name); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		firstMonth, months = 1, 12
+	} else {
+		return time.Time{}, time.Time{}, false
+	}
+	start := time.Date(year, time.Month(firstMonth), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, months, -1), true
+}
+
+// explicitBounds returns the dates that must be written out for the
+// period to parse back the same: those differing from the calendar bounds
+// of its name.
+func (p Period) explicitBounds() (string, string) {
+	start, end := p.Start, p.End
+	if defStart, defEnd, ok := calendarBounds(p.Name); ok {
+		if start == defStart.Format(periodDateLayout) {
+			start = ""
+		}
+		if end == defEnd.Format(periodDateLayout) {
+			end = ""
+		}
+	}
+	return start, end
+}
+
+// IsZero reports whether no period was declared.
+func (p Period) IsZero() bool { return p.Name == "" }
+
+// Contains reports whether t falls on a day within the period. Every time
+// is within the zero Period.
+func (p Period) Contains(t time.Time) bool {
+	if p.IsZero() {
+		return true
+	}
+	day := t.UTC().Format(periodDateLayout)
+	return day >= p.Start && day <= p.End
+}
+
+// Ended reports whether the period's last day is before now.
+func (p Period) Ended(now time.Time) bool {
+	return !p.IsZero() && now.UTC().Format(periodDateLayout) > p.End
+}
+
+// Next returns the period following a quarter, half-year, or year.
+func (p Period) Next() (Period, error) {
+	_, end, ok := calendarBounds(p.Name)
+	if !ok {
+		return Period{}, fmt.Errorf("cannot derive the period after %q; name the next period explicitly", p.Name)
+	}
+	next := end.AddDate(0, 0, 1)
+	var name string
+	switch {
+	case quarterPattern.MatchString(p.Name):
+		name = fmt.Sprintf("%d-Q%d", next.Year(), (int(next.Month())-1)/3+1)
+	case halfPattern.MatchString(p.Name):
+		name = fmt.Sprintf("%d-H%d", next.Year(), (int(next.Month())-1)/6+1)
+	default:
+		name = strconv.Itoa(next.Year())
+	}
+	return ParsePeriod(name, "", "")
+}
+
+func (p Period) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s to %s)", p.Name, p.Start, p.End)
+}
+
+// InPeriod returns a store holding only the objectives in the named
+// period. An empty name returns s unchanged.
+func (s *Store) InPeriod(name string) *Store {
+	if name == "" {
+		return s
+	}
+	var docs []Document
+	for _, doc := range s.documents() {
+		var objectives []Objective
+		for _, obj := range doc.Objectives {
+			if obj.Period.Name == name {
+				objectives = append(objectives, obj)
+			}
+		}
+		if len(objectives) > 0 {
+			doc.Objectives = objectives
+			docs = append(docs, doc)
+		}
+	}
+	return buildStore(docs)
+}
+
+// Periods returns the names of the periods objectives in s belong to,
+// sorted.
+func (s *Store) Periods() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, doc := range s.documents() {
+		for _, obj := range doc.Objectives {
+			if name := obj.Period.Name; name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,150 @@
+package okrstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	cases := []struct {
+		name, start, end   string
+		wantStart, wantEnd string
+		wantNext, wantErr  string
+	}{
+		{name: "2025-Q3", wantStart: "2025-07-01", wantEnd: "2025-09-30", wantNext: "2025-Q4"},
+		{name: "2025-Q4", wantStart: "2025-10-01", wantEnd: "2025-12-31", wantNext: "2026-Q1"},
+		{name: "2025-H1", wantStart: "2025-01-01", wantEnd: "2025-06-30", wantNext: "2025-H2"},
+		{name: "2025", wantStart: "2025-01-01", wantEnd: "2025-12-31", wantNext: "2026"},
+		{name: "2025-Q3", end: "2025-09-15", wantStart: "2025-07-01", wantEnd: "2025-09-15", wantNext: "2025-Q4"},
+		{name: "sprint-7", start: "2025-03-01", end: "2025-03-14", wantStart: "2025-03-01", wantEnd: "2025-03-14"},
+		{name: "sprint-7", wantErr: "set period_start and period_end"},
+		{name: "2025-Q3", start: "2025-09-01", end: "2025-08-01", wantErr: "before it starts"},
+	}
+	for _, tc := range cases {
+		p, err := ParsePeriod(tc.name, tc.start, tc.end)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("%s: expected %q, got %v", tc.name, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || p.Start != tc.wantStart || p.End != tc.wantEnd {
+			t.Fatalf("%s = %+v, %v", tc.name, p, err)
+		}
+		next, err := p.Next()
+		if tc.wantNext == "" {
+			if err == nil {
+				t.Fatalf("%s: expected no derivable next period, got %v", tc.name, next)
+			}
+			continue
+		}
+		if err != nil || next.Name != tc.wantNext {
+			t.Fatalf("%s next = %+v, %v", tc.name, next, err)
+		}
+	}
+}
+
+func periodTestDoc(period, objPeriod, lastUpdated string) string {
+	doc := "scope: org\n"
+	if period != "" {
+		doc += "period: " + period + "\n"
+	}
+	doc += `objectives:
+  - objective_id: OBJ-1
+    objective: Ship it
+    owner_id: team-alpha
+`
+	if objPeriod != "" {
+		doc += "    period: " + objPeriod + "\n"
+	}
+	doc += `    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: m
+        baseline: 1
+        target: 5
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+        current: 3
+        last_updated: "` + lastUpdated + `"
+`
+	return doc
+}
+
+func TestPeriodValidationAndFilter(t *testing.T) {
+	doc, err := ParseAndValidateDocument([]byte(periodTestDoc("2025-Q3", "", "2025-08-01")), "org.yml")
+	if err != nil {
+		t.Fatalf("valid doc: %v", err)
+	}
+	if doc.Period.Name != "2025-Q3" || doc.Objectives[0].Period != doc.Period {
+		t.Fatalf("objective should inherit the document period: %+v", doc.Objectives[0].Period)
+	}
+
+	_, err = ParseAndValidateDocument([]byte(periodTestDoc("2025-Q3", "", "2025-10-02")), "org.yml")
+	vErrs, ok := err.(ValidationErrors)
+	if !ok || len(vErrs) != 1 || vErrs[0].Code != CodeLastUpdatedOutsidePeriod {
+		t.Fatalf("expected last_updated outside period, got %v", err)
+	}
+
+	// An objective-level period overrides the document's.
+	doc, err = ParseAndValidateDocument([]byte(periodTestDoc("2025-Q3", "2025-Q4", "2025-10-02")), "org.yml")
+	if err != nil {
+		t.Fatalf("objective period: %v", err)
+	}
+	store := buildStore([]Document{doc})
+	if got := store.Periods(); len(got) != 1 || got[0] != "2025-Q4" {
+		t.Fatalf("periods = %v", got)
+	}
+	if _, ok := store.InPeriod("2025-Q3").ObjectiveLookup("OBJ-1"); ok {
+		t.Fatalf("OBJ-1 belongs to 2025-Q4")
+	}
+	if _, ok := store.InPeriod("2025-Q4").KeyResultLookup("KR-1"); !ok {
+		t.Fatalf("KR-1 missing from 2025-Q4")
+	}
+}
+
+func TestRollover(t *testing.T) {
+	dir := t.TempDir()
+	orig := periodTestDoc("2025-Q3", "", "2025-08-01")
+	writeFile(t, filepath.Join(dir, "org.yml"), orig)
+	writeFile(t, filepath.Join(dir, "team.yml"), strings.NewReplacer("OBJ-1", "OBJ-2", "KR-1", "KR-2", "scope: org", "scope: team").
+		Replace(periodTestDoc("2025-Q4", "", "2025-11-01")))
+
+	opts := RolloverOptions{From: "2025-Q3", Now: time.Date(2025, 9, 30, 12, 0, 0, 0, time.UTC)}
+	if _, err := Rollover(dir, opts); err == nil || !strings.Contains(err.Error(), "has not ended") {
+		t.Fatalf("expected early rollover to be refused, got %v", err)
+	}
+
+	opts.Now = opts.Now.AddDate(0, 0, 1)
+	result, err := Rollover(dir, opts)
+	if err != nil {
+		t.Fatalf("rollover: %v", err)
+	}
+	if result.To.Name != "2025-Q4" || len(result.Files) != 1 || result.Files[0] != "org.yml" || result.KeyResults != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if archived, _ := os.ReadFile(filepath.Join(dir, ArchiveDirName, "2025-Q3", "org.yml")); string(archived) != orig {
+		t.Fatalf("archive = %s", archived)
+	}
+
+	store, err := LoadFromDir(dir)
+	if err != nil {
+		t.Fatalf("load after rollover: %v", err)
+	}
+	rec, ok := store.KeyResultLookup("KR-1")
+	if !ok || rec.Objective.Period.Name != "2025-Q4" {
+		t.Fatalf("KR-1 = %+v", rec)
+	}
+	kr := rec.KeyResult
+	if kr.Baseline != 3 || kr.Status != "not_started" || kr.Current != nil || kr.LastUpdated != "" || len(kr.Evidence) != 0 {
+		t.Fatalf("KR-1 not reset: %+v", kr)
+	}
+	if _, err := Rollover(dir, opts); err == nil {
+		t.Fatalf("expected a second rollover of 2025-Q3 to fail")
+	}
+}
@@ -19,6 +19,34 @@ type PermissionConfig struct {
 
 	// Delegations optionally maps owner_id -> list of agent_ids allowed to write.
 	Delegations map[string][]string `yaml:"delegations"`
+
+	// Roles maps a role name to the agent_ids holding it.
+	Roles map[string][]string `yaml:"roles"`
+	// Rules grant write access beyond owner_id_match and delegations.
+	Rules []PermissionRule `yaml:"rules"`
+}
+
+// PermissionRule grants the agents it names (by role or agent_id) write
+// access to OKRs, optionally narrowed to scopes, owners, and fields.
+type PermissionRule struct {
+	Role  string `yaml:"role"`
+	Agent string `yaml:"agent"`
+	// Scopes limits the rule to org, team, or person OKRs; empty means all.
+	Scopes []string `yaml:"scopes"`
+	// Owners limits the rule to OKRs with these owner_ids; empty means all.
+	Owners []string `yaml:"owners"`
+	// Fields limits the rule to changing these objective and key result
+	// fields; empty means all. A field-limited rule cannot add or remove
+	// objectives or key results.
+	Fields []string `yaml:"fields"`
+}
+
+// permissionFields are the OKR YAML fields a rule may restrict edits to.
+var permissionFields = map[string]bool{
+	"objective": true, "notes": true, "weight": true, "owner_id": true,
+	"description": true, "metric_key": true, "baseline": true, "target": true,
+	"confidence": true, "status": true, "evidence": true, "current": true,
+	"last_updated": true, "aligns_to": true,
 }
 
 var (
@@ -38,9 +66,36 @@ func LoadPermissionConfig(path string) (*PermissionConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse permissions file: %w", err)
 	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("permissions file: %w", err)
+	}
 	return &cfg, nil
 }
 
+func (c *PermissionConfig) validate() error {
+	for i, rule := range c.Rules {
+		switch {
+		case rule.Role == "" && rule.Agent == "":
+			return fmt.Errorf("rules[%d]: role or agent is required", i)
+		case rule.Role != "" && rule.Agent != "":
+			return fmt.Errorf("rules[%d]: set role or agent, not both", i)
+		case rule.Role != "" && c.Roles[rule.Role] == nil:
+			return fmt.Errorf("rules[%d]: unknown role %q", i, rule.Role)
+		}
+		for _, scope := range rule.Scopes {
+			if _, err := parseScope(scope); err != nil {
+				return fmt.Errorf("rules[%d]: %w", i, err)
+			}
+		}
+		for _, field := range rule.Fields {
+			if !permissionFields[field] {
+				return fmt.Errorf("rules[%d]: unknown field %q", i, field)
+			}
+		}
+	}
+	return nil
+}
+
 func loadDefaultPermissions() (*PermissionConfig, error) {
 	permOnce.Do(func() {
 		cfg, err := LoadPermissionConfig(defaultPermissionsPath)
@@ -103,6 +158,64 @@ func canProposeWithConfig(cfg *PermissionConfig, agentID, targetOwnerID string)
 	return false
 }
 
+// writeGrant is what an agent may change on one objective or key result.
+type writeGrant struct {
+	// allFields is set when some grant does not restrict fields.
+	allFields bool
+	fields    map[string]bool
+}
+
+// grantFor returns the agent's write access to an OKR owned by ownerID in
+// scope, and whether it has any.
+func (c *PermissionConfig) grantFor(agentID, ownerID string, scope Scope) (writeGrant, bool) {
+	if c == nil {
+		return writeGrant{}, false
+	}
+	if canProposeWithConfig(c, agentID, ownerID) {
+		return writeGrant{allFields: true}, true
+	}
+	grant := writeGrant{fields: map[string]bool{}}
+	granted := false
+	for _, rule := range c.Rules {
+		if !rule.matches(c, agentID, ownerID, scope) {
+			continue
+		}
+		granted = true
+		if len(rule.Fields) == 0 {
+			grant.allFields = true
+		}
+		for _, field := range rule.Fields {
+			grant.fields[field] = true
+		}
+	}
+	return grant, granted
+}
+
+func (r PermissionRule) matches(c *PermissionConfig, agentID, ownerID string, scope Scope) bool {
+	if r.Agent != "" && strings.TrimSpace(r.Agent) != agentID {
+		return false
+	}
+	if r.Role != "" && !containsTrimmed(c.Roles[r.Role], agentID) {
+		return false
+	}
+	if len(r.Scopes) > 0 && !containsTrimmed(r.Scopes, string(scope)) {
+		return false
+	}
+	if len(r.Owners) > 0 && !containsTrimmed(r.Owners, ownerID) {
+		return false
+	}
+	return true
+}
+
+func containsTrimmed(values []string, want string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) == want {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *PermissionConfig) isDelegated(agentID, ownerID string) bool {
 	if c == nil || len(c.Delegations) == 0 {
 		return false
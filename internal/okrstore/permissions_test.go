@@ -0,0 +1,84 @@
+package okrstore
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rulesTestPerm = lockTestPerm + `
+roles:
+  okr_editor: [editor-bot]
+  reporter: [metrics-bot]
+rules:
+  - role: okr_editor
+    scopes: [team]
+  - role: reporter
+    owners: [team-alpha]
+    fields: [current, status, evidence, last_updated]
+`
+
+func TestPermissionRules(t *testing.T) {
+	cases := []struct {
+		name    string
+		agent   string
+		scope   string
+		target  string
+		status  string
+		wantErr string
+	}{
+		{name: "owner", agent: "team-alpha", scope: "org", target: "9", status: "in_progress"},
+		{name: "editor in team scope", agent: "editor-bot", scope: "team", target: "9", status: "in_progress"},
+		{name: "editor in org scope", agent: "editor-bot", scope: "org", target: "9", status: "in_progress",
+			wantErr: "not permitted to modify owner team-alpha"},
+		{name: "reporter status", agent: "metrics-bot", scope: "org", target: "2", status: "at_risk"},
+		{name: "reporter target", agent: "metrics-bot", scope: "org", target: "9", status: "at_risk",
+			wantErr: "may not change target of key result KR-OBJ-1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+			base := strings.Replace(lockTestObjective("OBJ-1", "2"), "scope: org", "scope: "+tc.scope, 1)
+			writeFile(t, filepath.Join(okrsDir, "org.yml"), base)
+			writeFile(t, filepath.Join(updatesDir, "permissions.yml"), rulesTestPerm)
+			updated := strings.Replace(lockTestObjective("OBJ-1", tc.target), "scope: org", "scope: "+tc.scope, 1)
+			updated = strings.Replace(updated, "status: in_progress", "status: "+tc.status, 1)
+			writeFile(t, filepath.Join(updatesDir, "org.yml"), updated)
+
+			_, err := CreateProposal(tc.agent, updatesDir, okrsDir, proposalsDir, "")
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("create proposal: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("expected %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFieldLimitedRuleCannotAddKeyResults(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "permissions.yml"), rulesTestPerm)
+	writeFile(t, filepath.Join(updatesDir, "new.yml"), lockTestObjective("OBJ-3", "4"))
+
+	_, err := CreateProposal("metrics-bot", updatesDir, okrsDir, proposalsDir, "")
+	if err == nil || !strings.Contains(err.Error(), "may not add objective OBJ-3") {
+		t.Fatalf("expected add to be refused, got %v", err)
+	}
+}
+
+func TestLoadPermissionConfigValidatesRules(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct{ rules, wantErr string }{
+		{"rules:\n  - scopes: [team]\n", "role or agent is required"},
+		{"rules:\n  - role: ghost\n", `unknown role "ghost"`},
+		{"rules:\n  - agent: a\n    scopes: [galaxy]\n", "galaxy"},
+		{"rules:\n  - agent: a\n    fields: [budget]\n", `unknown field "budget"`},
+	} {
+		path := filepath.Join(dir, "permissions.yml")
+		writeFile(t, path, tc.rules)
+		if _, err := LoadPermissionConfig(path); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("rules %q: expected %q, got %v", tc.rules, tc.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Proposal statuses. A proposal starts pending, is approved or rejected by
+// a reviewer, and becomes applied once its files are copied into okrs/.
+// Proposals written before review existed have no status and read as
+// pending.
+const (
+	ProposalPending  = "pending"
+	ProposalApproved = "approved"
+	ProposalRejected = "rejected"
+	ProposalApplied  = "applied"
+)
+
+// ProposalReview is one reviewer decision on a proposal.
+type ProposalReview struct {
+	Reviewer string    `json:"reviewer"`
+	Decision string    `json:"decision"`
+	Comment  string    `json:"comment,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// ApplyOptions controls ApplyProposalIn.
+type ApplyOptions struct {
+	// Confirm must be set; it mirrors the CLI's --i-understand.
+	Confirm bool
+	// Force applies a proposal that is not approved, including a rejected
+	// or already applied one.
+	Force bool
+	// Overwrite applies even if okrs/ changed since the proposal was
+	// created, discarding those changes.
+	Overwrite bool
+}
+
+// ReadProposal reads proposal.json from proposalDir. Relative paths in it
+// are resolved against root when root is set.
+func ReadProposal(root, proposalDir string) (*ProposalMetadata, error) {
+	return readProposalMetadata(proposalDir, root)
+}
+
+// ListProposals reads every proposal under proposalsRoot, oldest first.
+// Directories without a readable proposal.json are skipped. A missing
+// proposalsRoot yields no proposals.
+func ListProposals(root, proposalsRoot string) ([]*ProposalMetadata, error) {
+	entries, err := os.ReadDir(proposalsRoot)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read proposals dir: %w", err)
+	}
+	var proposals []*ProposalMetadata
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		meta, err := readProposalMetadata(filepath.Join(proposalsRoot, entry.Name()), root)
+		if err != nil {
+			continue
+		}
+		proposals = append(proposals, meta)
+	}
+	sort.SliceStable(proposals, func(i, j int) bool {
+		if !proposals[i].CreatedAt.Equal(proposals[j].CreatedAt) {
+			return proposals[i].CreatedAt.Before(proposals[j].CreatedAt)
+		}
+		return proposals[i].ID < proposals[j].ID
+	})
+	return proposals, nil
+}
+
+// ReviewProposal records an approve or reject decision on the proposal in
+// proposalDir and moves it to the matching status. A decision may be
+// revised until the proposal is applied. Agents cannot review their own
+// proposals.
+func ReviewProposal(root, proposalDir string, approve bool, reviewer, comment string) (*ProposalMetadata, error) {
+	reviewer = strings.TrimSpace(reviewer)
+	if reviewer == "" {
+		return nil, fmt.Errorf("reviewer is required")
+	}
+	meta, err := readProposalMetadata(proposalDir, root)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Status == ProposalApplied {
+		return nil, fmt.Errorf("proposal %s is already applied", meta.ID)
+	}
+	if reviewer == meta.AgentID {
+		return nil, fmt.Errorf("proposal %s cannot be reviewed by its author %s", meta.ID, reviewer)
+	}
+
+	decision := ProposalRejected
+	if approve {
+		decision = ProposalApproved
+	}
+	meta.Reviews = append(meta.Reviews, ProposalReview{
+		Reviewer: reviewer,
+		Decision: decision,
+		Comment:  strings.TrimSpace(comment),
+		At:       time.Now().UTC(),
+	})
+	meta.Status = decision
+	if err := writeProposalMetadata(meta, root); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// checkApplyStatus enforces that only approved proposals are applied
+// unless forced.
+func checkApplyStatus(meta *ProposalMetadata, force bool) error {
+	if meta.Status == ProposalApproved || force {
+		return nil
+	}
+	switch meta.Status {
+	case ProposalApplied:
+		return fmt.Errorf("proposal %s is already applied (use --force to apply it again)", meta.ID)
+	case ProposalRejected:
+		return fmt.Errorf("proposal %s was rejected (use --force to apply it anyway)", meta.ID)
+	default:
+		return fmt.Errorf("proposal %s is %s; approve it with okr review first (or use --force)", meta.ID, meta.Status)
+	}
+}
@@ -0,0 +1,110 @@
+package okrstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// approveProposal marks the proposal in dir approved so it can be applied.
+func approveProposal(t *testing.T, root, dir string) {
+	t.Helper()
+	if _, err := ReviewProposal(root, dir, true, "reviewer", ""); err != nil {
+		t.Fatalf("approve proposal: %v", err)
+	}
+}
+
+func TestProposalReviewLifecycle(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+
+	meta, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if meta.Status != ProposalPending {
+		t.Fatalf("new proposal status = %q", meta.Status)
+	}
+	if _, err := ApplyProposal(meta.ProposalDir, true); err == nil || !strings.Contains(err.Error(), "is pending") {
+		t.Fatalf("expected pending proposal to be refused, got %v", err)
+	}
+	if _, err := ReviewProposal("", meta.ProposalDir, true, "team-alpha", ""); err == nil {
+		t.Fatal("expected author review to be refused")
+	}
+
+	rejected, err := ReviewProposal("", meta.ProposalDir, false, "alice", "target too high")
+	if err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if rejected.Status != ProposalRejected || len(rejected.Reviews) != 1 || rejected.Reviews[0].Comment != "target too high" {
+		t.Fatalf("rejected = %+v", rejected)
+	}
+	if _, err := ApplyProposal(meta.ProposalDir, true); err == nil || !strings.Contains(err.Error(), "was rejected") {
+		t.Fatalf("expected rejected proposal to be refused, got %v", err)
+	}
+
+	approveProposal(t, "", meta.ProposalDir)
+	applied, err := ApplyProposal(meta.ProposalDir, true)
+	if err != nil {
+		t.Fatalf("apply approved proposal: %v", err)
+	}
+	if applied.Status != ProposalApplied || applied.AppliedAt == nil {
+		t.Fatalf("applied = %+v", applied)
+	}
+	stored, err := ReadProposal("", meta.ProposalDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != ProposalApplied || len(stored.Reviews) != 2 {
+		t.Fatalf("stored = %+v", stored)
+	}
+	if _, err := ReviewProposal("", meta.ProposalDir, false, "alice", ""); err == nil {
+		t.Fatal("expected review of an applied proposal to be refused")
+	}
+	if _, err := ApplyProposal(meta.ProposalDir, true); err == nil || !strings.Contains(err.Error(), "already applied") {
+		t.Fatalf("expected re-apply to be refused, got %v", err)
+	}
+	if _, err := ApplyProposalIn("", meta.ProposalDir, ApplyOptions{Confirm: true, Force: true}); err != nil {
+		t.Fatalf("forced re-apply: %v", err)
+	}
+}
+
+func TestListProposals(t *testing.T) {
+	okrsDir, updatesDir, proposalsDir := setupLockTestWorkspace(t)
+	if proposals, err := ListProposals("", proposalsDir); err != nil || len(proposals) != 0 {
+		t.Fatalf("empty list = %v, %v", proposals, err)
+	}
+	writeFile(t, filepath.Join(updatesDir, "org.yml"), lockTestObjective("OBJ-1", "5"))
+	first, err := CreateProposal("team-alpha", updatesDir, okrsDir, proposalsDir, "first")
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	approveProposal(t, "", first.ProposalDir)
+	// A proposal written before review existed has no status.
+	legacyDir := filepath.Join(proposalsDir, "20250101-000000-team-beta")
+	for _, dir := range []string{legacyDir, filepath.Join(proposalsDir, "stray")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(legacyDir, "proposal.json"),
+		`{"id": "20250101-000000-team-beta", "agent_id": "team-beta", "created_at": "2025-01-01T00:00:00Z"}`)
+
+	proposals, err := ListProposals("", proposalsDir)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(proposals) != 2 || proposals[0].ID != "20250101-000000-team-beta" || proposals[0].Status != ProposalPending ||
+		proposals[1].ID != first.ID || proposals[1].Status != ProposalApproved {
+		t.Fatalf("proposals = %+v", proposals)
+	}
+
+	diff, err := os.ReadFile(filepath.Join(first.ProposalDir, first.DiffFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(diff), "\n-") || !strings.Contains(string(diff), "\n+") {
+		t.Fatalf("diff should have one change per line:\n%s", diff)
+	}
+}
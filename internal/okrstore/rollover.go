@@ -0,0 +1,194 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveDirName is where ended periods are archived, inside the okrs dir.
+// A dot directory, so the loader never reads archived OKRs.
+const ArchiveDirName = ".archive"
+
+// RolloverOptions controls Rollover.
+type RolloverOptions struct {
+	// From is the name of the period to close.
+	From string
+	// To is the period to seed; the zero Period means the one after From.
+	To Period
+	// Now is used to check that From has ended (default time.Now()).
+	Now time.Time
+	// Force rolls over a period that has not ended yet.
+	Force bool
+	// DropAchieved leaves achieved key results, and objectives left with
+	// none, out of the next period.
+	DropAchieved bool
+}
+
+// RolloverResult describes a completed rollover.
+type RolloverResult struct {
+	From       Period   `json:"from"`
+	To         Period   `json:"to"`
+	ArchiveDir string   `json:"archive_dir"`
+	Files      []string `json:"files"`
+	Objectives int      `json:"objectives"`
+	KeyResults int      `json:"key_results"`
+	Dropped    []string `json:"dropped,omitempty"`
+}
+
+// Rollover archives the files holding objectives of an ended period under
+// <okrsDir>/.archive/<period>/ and rewrites those objectives for the next
+// period: each key result starts not_started from its last current value,
+// with evidence, current, and last_updated cleared. Objectives of other
+// periods are left alone. If the result fails validation, the original
+// files are restored.
+func Rollover(okrsDir string, opts RolloverOptions) (*RolloverResult, error) {
+	if opts.From == "" {
+		return nil, fmt.Errorf("period to roll over is required")
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	store, err := LoadFromDir(okrsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var from Period
+	var docs []Document
+	for _, doc := range store.documents() {
+		for _, obj := range doc.Objectives {
+			if obj.Period.Name == opts.From {
+				from = obj.Period
+				docs = append(docs, doc)
+				break
+			}
+		}
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no objectives in period %s", opts.From)
+	}
+	if !from.Ended(opts.Now) && !opts.Force {
+		return nil, fmt.Errorf("period %s has not ended (it runs until %s); use --force to roll over early", from.Name, from.End)
+	}
+	to := opts.To
+	if to.IsZero() {
+		if to, err = from.Next(); err != nil {
+			return nil, err
+		}
+	}
+	if to.Name == from.Name {
+		return nil, fmt.Errorf("next period must differ from %s", from.Name)
+	}
+
+	archiveDir := filepath.Join(okrsDir, ArchiveDirName, from.Name)
+	if _, err := os.Stat(archiveDir); err == nil {
+		return nil, fmt.Errorf("period %s is already archived in %s", from.Name, archiveDir)
+	}
+
+	result := &RolloverResult{From: from, To: to, ArchiveDir: archiveDir}
+	type rewrite struct {
+		rel string
+		doc Document
+	}
+	var rewrites []rewrite
+	for _, doc := range docs {
+		rel, err := filepath.Rel(okrsDir, doc.Source)
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %w", doc.Source, err)
+		}
+		if err := copyFile(doc.Source, filepath.Join(archiveDir, rel)); err != nil {
+			return nil, fmt.Errorf("archive %s: %w", rel, err)
+		}
+		rewrites = append(rewrites, rewrite{rel: filepath.ToSlash(rel), doc: rolloverDocument(doc, from, to, opts.DropAchieved, result)})
+	}
+
+	files := make([]string, 0, len(rewrites))
+	for _, rw := range rewrites {
+		files = append(files, rw.rel)
+	}
+	version, err := RecordHistory(okrsDir, files, HistoryChange{Reason: HistoryReasonRollover})
+	if err != nil {
+		_ = os.RemoveAll(archiveDir)
+		return nil, err
+	}
+
+	restore := func(cause error) error {
+		version.Discard()
+		for _, rw := range rewrites {
+			if err := copyFile(filepath.Join(archiveDir, filepath.FromSlash(rw.rel)), rw.doc.Source); err != nil {
+				return fmt.Errorf("%w; restoring %s failed (original in %s)", cause, rw.rel, archiveDir)
+			}
+		}
+		_ = os.RemoveAll(archiveDir)
+		return fmt.Errorf("%w; restored", cause)
+	}
+	for _, rw := range rewrites {
+		var err error
+		if len(rw.doc.Objectives) == 0 {
+			err = os.Remove(rw.doc.Source)
+		} else {
+			err = WriteDocument(rw.doc, rw.doc.Source)
+		}
+		if err != nil {
+			return nil, restore(fmt.Errorf("rewrite %s: %w", rw.rel, err))
+		}
+		result.Files = append(result.Files, rw.rel)
+	}
+	if _, err := LoadFromDir(okrsDir); err != nil {
+		return nil, restore(fmt.Errorf("rolled over okrs failed validation: %w", err))
+	}
+	return result, nil
+}
+
+// rolloverDocument returns doc with its objectives in from moved to to.
+func rolloverDocument(doc Document, from, to Period, dropAchieved bool, result *RolloverResult) Document {
+	next := doc
+	if doc.Period == from {
+		next.Period = to
+	}
+	next.Objectives = nil
+	for _, obj := range doc.Objectives {
+		if obj.Period != from {
+			next.Objectives = append(next.Objectives, obj)
+			continue
+		}
+		obj.Period = to
+		var krs []KeyResult
+		for _, kr := range obj.KeyResults {
+			if dropAchieved && kr.Status == "achieved" {
+				result.Dropped = append(result.Dropped, kr.ID)
+				continue
+			}
+			krs = append(krs, rolloverKeyResult(kr))
+		}
+		if len(krs) == 0 {
+			result.Dropped = append(result.Dropped, obj.ID)
+			continue
+		}
+		obj.KeyResults = krs
+		next.Objectives = append(next.Objectives, obj)
+		result.Objectives++
+		result.KeyResults += len(krs)
+	}
+	return next
+}
+
+// rolloverKeyResult starts a key result over: its last current value
+// becomes the baseline. A KR that already reached its target gets a
+// pending baseline, since baseline and target must differ.
+func rolloverKeyResult(kr KeyResult) KeyResult {
+	if kr.Current != nil {
+		if *kr.Current == kr.Target {
+			kr.Baseline, kr.BaselinePending = 0, true
+		} else {
+			kr.Baseline, kr.BaselinePending = *kr.Current, false
+		}
+	}
+	kr.Status = "not_started"
+	kr.Evidence = []string{}
+	kr.Current = nil
+	kr.LastUpdated = ""
+	return kr
+}
@@ -0,0 +1,153 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are the opt-in validation rules read from the layout file
+// (.okrs.yml) next to the OKRs:
+//
+//	evidence_uris: true
+//	evidence_schemes: [monitoring, tracing, jira]
+//	metrics:
+//	  api.latency.p95_ms:
+//	    direction: decrease
+//	    max_age: 72h
+//	owners: [team-platform, alice]
+type Rules struct {
+	// EvidenceURIs requires every evidence entry to be a URI of the form
+	// scheme:reference.
+	EvidenceURIs bool `yaml:"evidence_uris"`
+	// EvidenceSchemes, when set, limits the accepted schemes. The schemes
+	// okrchestra itself writes (see BuiltinEvidenceSchemes) are always
+	// accepted.
+	EvidenceSchemes []string `yaml:"evidence_schemes"`
+	// Metrics is the metric catalog, keyed by metric_key.
+	Metrics map[string]MetricSpec `yaml:"metrics"`
+	// Owners is the roster of known owner_ids. When set, okr validate
+	// warns about owners missing from it.
+	Owners []string `yaml:"owners"`
+}
+
+// MetricSpec describes a metric in the catalog. Direction is "increase" or
+// "decrease": the way the metric moves when things get better. MaxAge,
+// when set, overrides metrics.max_age in okrchestra.yml for this metric.
+type MetricSpec struct {
+	Direction string        `yaml:"direction"`
+	MaxAge    time.Duration `yaml:"max_age"`
+}
+
+// Evidence schemes used for evidence okrchestra appends itself.
+const (
+	EvidenceSchemeSnapshot = "snapshot"
+	EvidenceSchemeRun      = "run"
+)
+
+// BuiltinEvidenceSchemes are always accepted by the evidence_uris rule.
+var BuiltinEvidenceSchemes = []string{EvidenceSchemeSnapshot, EvidenceSchemeRun}
+
+var evidenceURIPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*):(\S.*)$`)
+
+// LoadRules reads the validation rules from the layout file in okrsDir. A
+// missing file yields no rules.
+func LoadRules(okrsDir string) (Rules, error) {
+	var rules Rules
+	data, err := os.ReadFile(filepath.Join(okrsDir, LayoutFileName))
+	if os.IsNotExist(err) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, fmt.Errorf("read %s: %w", LayoutFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("parse %s: %w", LayoutFileName, err)
+	}
+	for key, spec := range rules.Metrics {
+		if spec.Direction != "increase" && spec.Direction != "decrease" {
+			return rules, fmt.Errorf("%s: metrics.%s.direction must be \"increase\" or \"decrease\"", LayoutFileName, key)
+		}
+		if spec.MaxAge < 0 {
+			return rules, fmt.Errorf("%s: metrics.%s.max_age must not be negative", LayoutFileName, key)
+		}
+	}
+	return rules, nil
+}
+
+// EvidenceRef formats evidence okrchestra appends to a KR: scheme:ref when
+// the evidence_uris rule is on, ref as-is otherwise.
+func (r Rules) EvidenceRef(scheme, ref string) string {
+	if !r.EvidenceURIs {
+		return ref
+	}
+	return scheme + ":" + ref
+}
+
+func (r Rules) evidenceSchemeAllowed(scheme string) bool {
+	if len(r.EvidenceSchemes) == 0 {
+		return true
+	}
+	for _, allowed := range append(append([]string{}, BuiltinEvidenceSchemes...), r.EvidenceSchemes...) {
+		if strings.EqualFold(allowed, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRules applies the opt-in rules to loaded documents. Field paths
+// match the ones ParseAndValidateDocument reports.
+func validateRules(docs []Document, rules Rules) ValidationErrors {
+	var errs ValidationErrors
+	for _, doc := range docs {
+		for objIdx, obj := range doc.Objectives {
+			for krIdx, kr := range obj.KeyResults {
+				krPath := fmt.Sprintf("objectives[%d].key_results[%d]", objIdx, krIdx)
+				if rules.EvidenceURIs {
+					for i, ev := range kr.Evidence {
+						m := evidenceURIPattern.FindStringSubmatch(strings.TrimSpace(ev))
+						switch {
+						case m == nil:
+							errs = append(errs, ValidationError{
+								File:    doc.Source,
+								Field:   fmt.Sprintf("%s.evidence[%d]", krPath, i),
+								Code:    CodeEvidenceNotURI,
+								Message: fmt.Sprintf("evidence %q must be a URI (scheme:reference)", ev),
+							})
+						case !rules.evidenceSchemeAllowed(m[1]):
+							errs = append(errs, ValidationError{
+								File:    doc.Source,
+								Field:   fmt.Sprintf("%s.evidence[%d]", krPath, i),
+								Code:    CodeEvidenceScheme,
+								Message: fmt.Sprintf("evidence scheme %q is not one of %s", m[1], strings.Join(rules.EvidenceSchemes, ", ")),
+							})
+						}
+					}
+				}
+				spec, ok := rules.Metrics[kr.MetricKey]
+				if !ok || kr.BaselinePending || kr.Target == kr.Baseline {
+					continue
+				}
+				direction := "increase"
+				if kr.Target < kr.Baseline {
+					direction = "decrease"
+				}
+				if direction != spec.Direction {
+					errs = append(errs, ValidationError{
+						File:    doc.Source,
+						Field:   krPath + ".target",
+						Code:    CodeTargetDirection,
+						Message: fmt.Sprintf("target %g would %s %s from baseline %g, but the metric catalog says it should %s", kr.Target, direction, kr.MetricKey, kr.Baseline, spec.Direction),
+					})
+				}
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,104 @@
+package okrstore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rulesTestKR = `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Test objective
+    key_results:
+      - kr_id: KR-1
+        description: desc
+        owner_id: team-alpha
+        metric_key: api.latency.p95_ms
+        baseline: %s
+        target: %s
+        confidence: 0.5
+        status: %s
+        evidence: [%s]
+        last_updated: "%s"
+`
+
+func TestParseAndValidateDocumentCrossField(t *testing.T) {
+	cases := []struct {
+		name      string
+		baseline  string
+		target    string
+		status    string
+		updated   string
+		wantField string
+	}{
+		{name: "target equals baseline", baseline: "5", target: "5", status: "in_progress", updated: "2025-01-01", wantField: "objectives[0].key_results[0].target"},
+		{name: "unknown status", baseline: "5", target: "3", status: "done", updated: "2025-01-01", wantField: "objectives[0].key_results[0].status"},
+		{name: "future last_updated", baseline: "5", target: "3", status: "in_progress", updated: "2999-01-01", wantField: "objectives[0].key_results[0].last_updated"},
+		{name: "null baseline", baseline: "null", target: "3", status: "not_started", updated: "2025-01-01"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			yml := fmt.Sprintf(rulesTestKR, tc.baseline, tc.target, tc.status, `"seed"`, tc.updated)
+			_, err := ParseAndValidateDocument([]byte(yml), "test.yml")
+			if tc.wantField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			ves, ok := err.(ValidationErrors)
+			if !ok || len(ves) != 1 {
+				t.Fatalf("expected one ValidationError, got %v", err)
+			}
+			if ves[0].Field != tc.wantField {
+				t.Fatalf("Field = %q, want %q (%v)", ves[0].Field, tc.wantField, ves[0])
+			}
+		})
+	}
+}
+
+func TestLoadFromDirRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, LayoutFileName), `
+evidence_uris: true
+evidence_schemes: [tracing]
+metrics:
+  api.latency.p95_ms:
+    direction: decrease
+`)
+
+	writeFile(t, filepath.Join(dir, "org.yml"), fmt.Sprintf(rulesTestKR, "500", "350", "in_progress", `"tracing:p95-dashboard", "snapshot:metrics/snapshots/2025-01-01.json"`, "2025-01-01"))
+	if _, err := LoadFromDir(dir); err != nil {
+		t.Fatalf("expected valid OKRs, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "org.yml"), fmt.Sprintf(rulesTestKR, "350", "500", "in_progress", `"seed", "jira:OPS-1"`, "2025-01-01"))
+	_, err := LoadFromDir(dir)
+	ves, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	var fields []string
+	for _, ve := range ves {
+		fields = append(fields, ve.Field)
+	}
+	want := []string{
+		"objectives[0].key_results[0].evidence[0]",
+		"objectives[0].key_results[0].evidence[1]",
+		"objectives[0].key_results[0].target",
+	}
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Fatalf("fields = %v, want %v", fields, want)
+	}
+}
+
+func TestLoadRulesRejectsUnknownDirection(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, LayoutFileName), "metrics:\n  m:\n    direction: sideways\n")
+	if _, err := LoadRules(dir); err == nil {
+		t.Fatal("expected error for unknown direction")
+	}
+}
@@ -12,34 +12,47 @@ const (
 // Document is a normalized OKR document loaded from YAML.
 type Document struct {
 	Scope      Scope
+	Period     Period
 	Objectives []Objective
 	Source     string
 }
 
-// Objective represents a single objective and its key results.
+// Objective represents a single objective and its key results. Weight is the
+// optional strategic weight used by portfolio planning (nil means 1).
+// Period is the objective's own period if it declares one, otherwise its
+// document's. AlignsTo lists the ids of objectives or key results in a
+// broader scope that this objective contributes to.
 type Objective struct {
 	ID            string
 	Objective     string
 	OwnerID       string
 	Notes         string
+	Weight        *float64
+	Period        Period
+	AlignsTo      []string
 	KeyResults    []KeyResult
 	SourceFile    string
 	DocumentScope Scope
 }
 
-// KeyResult captures a single key result.
+// KeyResult captures a single key result. BaselinePending is set for
+// `baseline: null`: the baseline has not been measured yet and Baseline is 0
+// until `kr baseline detect` proposes one from the latest snapshot.
+// AlignsTo is as for Objective.
 type KeyResult struct {
-	ID          string
-	Description string
-	OwnerID     string
-	MetricKey   string
-	Baseline    float64
-	Target      float64
-	Confidence  float64
-	Status      string
-	Evidence    []string
-	Current     *float64
-	LastUpdated string
+	ID              string
+	Description     string
+	OwnerID         string
+	MetricKey       string
+	Baseline        float64
+	BaselinePending bool
+	Target          float64
+	Confidence      float64
+	Status          string
+	Evidence        []string
+	Current         *float64
+	LastUpdated     string
+	AlignsTo        []string
 }
 
 // OrgOKRs groups organization-level objectives.
@@ -82,6 +95,10 @@ type Store struct {
 
 	objectives map[string]ObjectiveRecord
 	keyResults map[string]KeyResultRecord
+	// aligned maps each objective or key result to those aligned to it;
+	// ownKRs lists the key result ids of each objective.
+	aligned map[alignKey][]alignKey
+	ownKRs  map[alignKey][]string
 }
 
 // ObjectiveLookup returns the objective record for the given id, if present.
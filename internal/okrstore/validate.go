@@ -9,16 +9,24 @@ import (
 )
 
 type rawDocument struct {
-	Scope      string         `yaml:"scope"`
-	Objectives []rawObjective `yaml:"objectives"`
+	Scope       string         `yaml:"scope"`
+	Period      string         `yaml:"period"`
+	PeriodStart string         `yaml:"period_start"`
+	PeriodEnd   string         `yaml:"period_end"`
+	Objectives  []rawObjective `yaml:"objectives"`
 }
 
 type rawObjective struct {
-	ID         string         `yaml:"objective_id"`
-	Title      string         `yaml:"objective"`
-	OwnerID    string         `yaml:"owner_id"`
-	Notes      string         `yaml:"notes"`
-	KeyResults []rawKeyResult `yaml:"key_results"`
+	ID          string         `yaml:"objective_id"`
+	Title       string         `yaml:"objective"`
+	OwnerID     string         `yaml:"owner_id"`
+	Notes       string         `yaml:"notes"`
+	Weight      *float64       `yaml:"weight"`
+	Period      string         `yaml:"period"`
+	PeriodStart string         `yaml:"period_start"`
+	PeriodEnd   string         `yaml:"period_end"`
+	AlignsTo    []string       `yaml:"aligns_to"`
+	KeyResults  []rawKeyResult `yaml:"key_results"`
 }
 
 type rawKeyResult struct {
@@ -33,12 +41,31 @@ type rawKeyResult struct {
 	Evidence    []string `yaml:"evidence"`
 	Current     *float64 `yaml:"current"`
 	LastUpdated string   `yaml:"last_updated"`
+	AlignsTo    []string `yaml:"aligns_to"`
+	// BaselineNull records an explicit `baseline: null`, which yaml.v3
+	// otherwise decodes the same as a missing key.
+	BaselineNull bool `yaml:"-"`
 }
 
-// ValidationError captures a single field-specific validation issue.
+func (r *rawKeyResult) UnmarshalYAML(node *yaml.Node) error {
+	type plain rawKeyResult
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "baseline" && node.Content[i+1].ShortTag() == "!!null" {
+			r.BaselineNull = true
+		}
+	}
+	return nil
+}
+
+// ValidationError captures a single field-specific validation issue. Code
+// is one of the Code* constants.
 type ValidationError struct {
 	File    string
 	Field   string
+	Code    string
 	Message string
 }
 
@@ -67,6 +94,7 @@ func ParseAndValidateDocument(data []byte, source string) (Document, error) {
 		return Document{}, ValidationErrors{{
 			File:    source,
 			Field:   "yaml",
+			Code:    CodeYAMLInvalid,
 			Message: err.Error(),
 		}}
 	}
@@ -81,14 +109,26 @@ func validateRawDocument(raw rawDocument, source string) (Document, error) {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   "scope",
+			Code:    CodeScopeInvalid,
 			Message: scopeErr.Error(),
 		})
 	}
 
+	period, periodErr := ParsePeriod(strings.TrimSpace(raw.Period), strings.TrimSpace(raw.PeriodStart), strings.TrimSpace(raw.PeriodEnd))
+	if periodErr != nil {
+		errs = append(errs, ValidationError{
+			File:    source,
+			Field:   "period",
+			Code:    CodePeriodInvalid,
+			Message: periodErr.Error(),
+		})
+	}
+
 	if len(raw.Objectives) == 0 {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   "objectives",
+			Code:    CodeObjectivesMissing,
 			Message: "must contain at least one objective",
 		})
 	}
@@ -98,7 +138,7 @@ func validateRawDocument(raw rawDocument, source string) (Document, error) {
 
 	for idx, rawObj := range raw.Objectives {
 		objPath := fmt.Sprintf("objectives[%d]", idx)
-		obj, objErrs := validateObjective(rawObj, objPath, scope, source)
+		obj, objErrs := validateObjective(rawObj, objPath, scope, period, source)
 		errs = append(errs, objErrs...)
 
 		if obj.ID != "" {
@@ -106,6 +146,7 @@ func validateRawDocument(raw rawDocument, source string) (Document, error) {
 				errs = append(errs, ValidationError{
 					File:    source,
 					Field:   objPath + ".objective_id",
+					Code:    CodeObjectiveIDDuplicate,
 					Message: fmt.Sprintf("duplicate objective_id %q within scope", obj.ID),
 				})
 			} else {
@@ -121,18 +162,35 @@ func validateRawDocument(raw rawDocument, source string) (Document, error) {
 
 	return Document{
 		Scope:      scope,
+		Period:     period,
 		Objectives: normalizedObjectives,
 		Source:     source,
 	}, nil
 }
 
-func validateObjective(raw rawObjective, fieldPath string, scope Scope, source string) (Objective, ValidationErrors) {
+func validateObjective(raw rawObjective, fieldPath string, scope Scope, docPeriod Period, source string) (Objective, ValidationErrors) {
 	var errs ValidationErrors
 
+	period := docPeriod
+	if raw.Period != "" || raw.PeriodStart != "" || raw.PeriodEnd != "" {
+		own, err := ParsePeriod(strings.TrimSpace(raw.Period), strings.TrimSpace(raw.PeriodStart), strings.TrimSpace(raw.PeriodEnd))
+		if err != nil {
+			errs = append(errs, ValidationError{
+				File:    source,
+				Field:   fieldPath + ".period",
+				Code:    CodePeriodInvalid,
+				Message: err.Error(),
+			})
+		} else {
+			period = own
+		}
+	}
+
 	if strings.TrimSpace(raw.ID) == "" {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".objective_id",
+			Code:    CodeObjectiveIDMissing,
 			Message: "objective_id is required",
 		})
 	}
@@ -140,13 +198,23 @@ func validateObjective(raw rawObjective, fieldPath string, scope Scope, source s
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".objective",
+			Code:    CodeObjectiveTextMissing,
 			Message: "objective text is required",
 		})
 	}
+	if raw.Weight != nil && *raw.Weight < 0 {
+		errs = append(errs, ValidationError{
+			File:    source,
+			Field:   fieldPath + ".weight",
+			Code:    CodeWeightNegative,
+			Message: "weight must be >= 0",
+		})
+	}
 	if len(raw.KeyResults) == 0 {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".key_results",
+			Code:    CodeKeyResultsMissing,
 			Message: "must contain at least one key result",
 		})
 	}
@@ -156,7 +224,7 @@ func validateObjective(raw rawObjective, fieldPath string, scope Scope, source s
 
 	for krIdx, rawKR := range raw.KeyResults {
 		krPath := fmt.Sprintf("%s.key_results[%d]", fieldPath, krIdx)
-		kr, krErrs := validateKeyResult(rawKR, krPath, source)
+		kr, krErrs := validateKeyResult(rawKR, krPath, period, source)
 		errs = append(errs, krErrs...)
 
 		if kr.ID != "" {
@@ -164,6 +232,7 @@ func validateObjective(raw rawObjective, fieldPath string, scope Scope, source s
 				errs = append(errs, ValidationError{
 					File:    source,
 					Field:   krPath + ".kr_id",
+					Code:    CodeKRIDDuplicate,
 					Message: fmt.Sprintf("duplicate kr_id %q within objective", kr.ID),
 				})
 			} else {
@@ -178,6 +247,9 @@ func validateObjective(raw rawObjective, fieldPath string, scope Scope, source s
 		Objective:     strings.TrimSpace(raw.Title),
 		OwnerID:       strings.TrimSpace(raw.OwnerID),
 		Notes:         strings.TrimSpace(raw.Notes),
+		Weight:        raw.Weight,
+		Period:        period,
+		AlignsTo:      trimAll(raw.AlignsTo),
 		KeyResults:    normalizedKRs,
 		SourceFile:    source,
 		DocumentScope: scope,
@@ -186,13 +258,14 @@ func validateObjective(raw rawObjective, fieldPath string, scope Scope, source s
 	return obj, errs
 }
 
-func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyResult, ValidationErrors) {
+func validateKeyResult(raw rawKeyResult, fieldPath string, period Period, source string) (KeyResult, ValidationErrors) {
 	var errs ValidationErrors
 
 	if strings.TrimSpace(raw.ID) == "" {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".kr_id",
+			Code:    CodeKRIDMissing,
 			Message: "kr_id is required",
 		})
 	}
@@ -200,6 +273,7 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".description",
+			Code:    CodeDescriptionMissing,
 			Message: "description is required",
 		})
 	}
@@ -207,6 +281,7 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".owner_id",
+			Code:    CodeOwnerIDMissing,
 			Message: "owner_id is required",
 		})
 	}
@@ -214,20 +289,23 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".metric_key",
+			Code:    CodeMetricKeyMissing,
 			Message: "metric_key is required",
 		})
 	}
-	if raw.Baseline == nil {
+	if raw.Baseline == nil && !raw.BaselineNull {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".baseline",
-			Message: "baseline is required",
+			Code:    CodeBaselineMissing,
+			Message: "baseline is required (use null to detect it from the latest snapshot)",
 		})
 	}
 	if raw.Target == nil {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".target",
+			Code:    CodeTargetMissing,
 			Message: "target is required",
 		})
 	}
@@ -235,26 +313,45 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".confidence",
+			Code:    CodeConfidenceMissing,
 			Message: "confidence is required",
 		})
 	} else if *raw.Confidence < 0.0 || *raw.Confidence > 1.0 {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".confidence",
+			Code:    CodeConfidenceRange,
 			Message: "must be between 0.0 and 1.0",
 		})
 	}
-	if strings.TrimSpace(raw.Status) == "" {
+	if status := strings.TrimSpace(raw.Status); status == "" {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".status",
+			Code:    CodeStatusMissing,
 			Message: "status is required",
 		})
+	} else if !isValidStatus(status) {
+		errs = append(errs, ValidationError{
+			File:    source,
+			Field:   fieldPath + ".status",
+			Code:    CodeStatusInvalid,
+			Message: fmt.Sprintf("invalid status %q (expected %s)", status, strings.Join(Statuses, ", ")),
+		})
+	}
+	if raw.Baseline != nil && raw.Target != nil && *raw.Baseline == *raw.Target {
+		errs = append(errs, ValidationError{
+			File:    source,
+			Field:   fieldPath + ".target",
+			Code:    CodeTargetEqualsBaseline,
+			Message: fmt.Sprintf("target must differ from baseline (both %g)", *raw.Target),
+		})
 	}
 	if raw.Evidence == nil {
 		errs = append(errs, ValidationError{
 			File:    source,
 			Field:   fieldPath + ".evidence",
+			Code:    CodeEvidenceMissing,
 			Message: "evidence list is required",
 		})
 	} else {
@@ -263,6 +360,7 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 				errs = append(errs, ValidationError{
 					File:    source,
 					Field:   fmt.Sprintf("%s.evidence[%d]", fieldPath, i),
+					Code:    CodeEvidenceEmpty,
 					Message: "evidence entries cannot be empty",
 				})
 			}
@@ -270,12 +368,27 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 	}
 
 	if raw.LastUpdated != "" {
-		if _, parseErr := parseISO8601(raw.LastUpdated); parseErr != nil {
+		if ts, parseErr := parseISO8601(raw.LastUpdated); parseErr != nil {
 			errs = append(errs, ValidationError{
 				File:    source,
 				Field:   fieldPath + ".last_updated",
+				Code:    CodeLastUpdatedInvalid,
 				Message: "must be ISO-8601 date or datetime",
 			})
+		} else if ts.After(time.Now()) {
+			errs = append(errs, ValidationError{
+				File:    source,
+				Field:   fieldPath + ".last_updated",
+				Code:    CodeLastUpdatedFuture,
+				Message: "must not be in the future",
+			})
+		} else if !period.Contains(ts) {
+			errs = append(errs, ValidationError{
+				File:    source,
+				Field:   fieldPath + ".last_updated",
+				Code:    CodeLastUpdatedOutsidePeriod,
+				Message: fmt.Sprintf("%s is outside period %s", raw.LastUpdated, period),
+			})
 		}
 	}
 
@@ -288,10 +401,13 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 		Evidence:    append([]string{}, raw.Evidence...),
 		Current:     raw.Current,
 		LastUpdated: strings.TrimSpace(raw.LastUpdated),
+		AlignsTo:    trimAll(raw.AlignsTo),
 	}
 
 	if raw.Baseline != nil {
 		kr.Baseline = *raw.Baseline
+	} else {
+		kr.BaselinePending = raw.BaselineNull
 	}
 	if raw.Target != nil {
 		kr.Target = *raw.Target
@@ -307,6 +423,18 @@ func validateKeyResult(raw rawKeyResult, fieldPath string, source string) (KeyRe
 	return kr, errs
 }
 
+// Statuses are the allowed key result statuses.
+var Statuses = []string{"not_started", "in_progress", "at_risk", "achieved", "blocked"}
+
+func isValidStatus(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 func parseScope(value string) (Scope, error) {
 	switch Scope(strings.TrimSpace(value)) {
 	case ScopeOrg:
@@ -0,0 +1,129 @@
+package okrstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WriteDocument writes a Document as YAML to path, atomically.
+func WriteDocument(doc Document, path string) error {
+	// Convert to raw format for YAML marshaling
+	type rawKeyResult struct {
+		ID          string   `yaml:"kr_id"`
+		Description string   `yaml:"description"`
+		OwnerID     string   `yaml:"owner_id"`
+		MetricKey   string   `yaml:"metric_key"`
+		Baseline    *float64 `yaml:"baseline"`
+		Target      *float64 `yaml:"target"`
+		Confidence  *float64 `yaml:"confidence"`
+		Status      string   `yaml:"status"`
+		Evidence    []string `yaml:"evidence"`
+		Current     *float64 `yaml:"current,omitempty"`
+		LastUpdated string   `yaml:"last_updated,omitempty"`
+		AlignsTo    []string `yaml:"aligns_to,omitempty"`
+	}
+
+	type rawObjective struct {
+		ID          string         `yaml:"objective_id"`
+		Title       string         `yaml:"objective"`
+		OwnerID     string         `yaml:"owner_id,omitempty"`
+		Notes       string         `yaml:"notes,omitempty"`
+		Weight      *float64       `yaml:"weight,omitempty"`
+		Period      string         `yaml:"period,omitempty"`
+		PeriodStart string         `yaml:"period_start,omitempty"`
+		PeriodEnd   string         `yaml:"period_end,omitempty"`
+		AlignsTo    []string       `yaml:"aligns_to,omitempty"`
+		KeyResults  []rawKeyResult `yaml:"key_results"`
+	}
+
+	type rawDocument struct {
+		Scope       string         `yaml:"scope"`
+		Period      string         `yaml:"period,omitempty"`
+		PeriodStart string         `yaml:"period_start,omitempty"`
+		PeriodEnd   string         `yaml:"period_end,omitempty"`
+		Objectives  []rawObjective `yaml:"objectives"`
+	}
+
+	raw := rawDocument{
+		Scope:      string(doc.Scope),
+		Period:     doc.Period.Name,
+		Objectives: make([]rawObjective, len(doc.Objectives)),
+	}
+	raw.PeriodStart, raw.PeriodEnd = doc.Period.explicitBounds()
+
+	for i, obj := range doc.Objectives {
+		rawObj := rawObjective{
+			ID:         obj.ID,
+			Title:      obj.Objective,
+			OwnerID:    obj.OwnerID,
+			Notes:      obj.Notes,
+			Weight:     obj.Weight,
+			AlignsTo:   obj.AlignsTo,
+			KeyResults: make([]rawKeyResult, len(obj.KeyResults)),
+		}
+		// Objectives only spell out a period that differs from the document's.
+		if obj.Period != doc.Period {
+			rawObj.Period = obj.Period.Name
+			rawObj.PeriodStart, rawObj.PeriodEnd = obj.Period.explicitBounds()
+		}
+
+		for j, kr := range obj.KeyResults {
+			baseline := &kr.Baseline
+			if kr.BaselinePending {
+				baseline = nil
+			}
+			rawKR := rawKeyResult{
+				ID:          kr.ID,
+				Description: kr.Description,
+				OwnerID:     kr.OwnerID,
+				MetricKey:   kr.MetricKey,
+				Baseline:    baseline,
+				Target:      &kr.Target,
+				Confidence:  &kr.Confidence,
+				Status:      kr.Status,
+				Evidence:    kr.Evidence,
+				Current:     kr.Current,
+				LastUpdated: kr.LastUpdated,
+				AlignsTo:    kr.AlignsTo,
+			}
+			rawObj.KeyResults[j] = rawKR
+		}
+
+		raw.Objectives[i] = rawObj
+	}
+
+	// Marshal to YAML
+	data, err := yaml.Marshal(&raw)
+	if err != nil {
+		return fmt.Errorf("marshal yaml: %w", err)
+	}
+
+	// Write atomically via temp file
+	dir := filepath.Dir(path)
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	// Atomic rename
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/pmezard/go-difflib/difflib"
+
+	"okrchestra/internal/workspace"
 )
 
 // ProposalMetadata describes a stored OKR proposal.
@@ -24,10 +28,26 @@ type ProposalMetadata struct {
 	Files       []string  `json:"files"`
 	DiffFile    string    `json:"diff_file,omitempty"`
 	Note        string    `json:"note,omitempty"`
+	// Status is one of ProposalPending, ProposalApproved, ProposalRejected,
+	// or ProposalApplied.
+	Status    string           `json:"status"`
+	Reviews   []ProposalReview `json:"reviews,omitempty"`
+	AppliedAt *time.Time       `json:"applied_at,omitempty"`
+	// BaseHashes maps each file to the SHA256 of its okrs/ version when the
+	// proposal was created ("" if it did not exist), so apply can tell
+	// whether okrs/ changed underneath the proposal.
+	BaseHashes map[string]string `json:"base_hashes,omitempty"`
 }
 
 // CreateProposal validates updated OKRs, enforces permissions, and writes a proposal package.
 func CreateProposal(agentID, updatesDir, okrsDir, proposalsRoot, note string) (*ProposalMetadata, error) {
+	return CreateProposalIn("", agentID, updatesDir, okrsDir, proposalsRoot, note)
+}
+
+// CreateProposalIn is CreateProposal for a workspace rooted at root: paths
+// in the written proposal.json are stored relative to root so the proposal
+// survives the workspace moving. The returned metadata keeps absolute paths.
+func CreateProposalIn(root, agentID, updatesDir, okrsDir, proposalsRoot, note string) (*ProposalMetadata, error) {
 	agentID = strings.TrimSpace(agentID)
 	if agentID == "" {
 		return nil, fmt.Errorf("agent id is required")
@@ -52,7 +72,7 @@ func CreateProposal(agentID, updatesDir, okrsDir, proposalsRoot, note string) (*
 		return nil, fmt.Errorf("updates directory must differ from okrs directory; direct edits to okrs/ are not allowed")
 	}
 
-	if err := enforcePermissions(agentID, updatesDir); err != nil {
+	if err := enforcePermissions(agentID, updatesDir, okrsDir); err != nil {
 		return nil, err
 	}
 
@@ -83,14 +103,22 @@ func CreateProposal(agentID, updatesDir, okrsDir, proposalsRoot, note string) (*
 
 	var copied []string
 	for _, src := range updateFiles {
-		dst := filepath.Join(proposalDir, filepath.Base(src))
+		rel, err := filepath.Rel(updatesDir, src)
+		if err != nil {
+			return nil, fmt.Errorf("copy %s: %w", src, err)
+		}
+		dst := filepath.Join(proposalDir, rel)
 		if copyErr := copyFile(src, dst); copyErr != nil {
 			return nil, fmt.Errorf("copy %s: %w", src, copyErr)
 		}
-		copied = append(copied, filepath.Base(src))
+		copied = append(copied, filepath.ToSlash(rel))
 	}
 
-	diffPath, err := renderDiff(updateFiles, okrsDir, proposalDir)
+	diffPath, err := renderDiff(updatesDir, copied, okrsDir, proposalDir)
+	if err != nil {
+		return nil, err
+	}
+	baseHashes, err := recordBase(okrsDir, copied, proposalDir)
 	if err != nil {
 		return nil, err
 	}
@@ -105,9 +133,11 @@ func CreateProposal(agentID, updatesDir, okrsDir, proposalsRoot, note string) (*
 		Files:       copied,
 		DiffFile:    diffPath,
 		Note:        strings.TrimSpace(note),
+		Status:      ProposalPending,
+		BaseHashes:  baseHashes,
 	}
 
-	if err := writeProposalMetadata(meta); err != nil {
+	if err := writeProposalMetadata(meta, root); err != nil {
 		return nil, err
 	}
 
@@ -115,21 +145,32 @@ func CreateProposal(agentID, updatesDir, okrsDir, proposalsRoot, note string) (*
 	return meta, nil
 }
 
-// ApplyProposal applies a validated proposal to the target okrs directory.
+// ApplyProposal applies a validated, approved proposal to the target okrs
+// directory.
 func ApplyProposal(proposalDir string, confirm bool) (*ProposalMetadata, error) {
-	if !confirm {
+	return ApplyProposalIn("", proposalDir, ApplyOptions{Confirm: confirm})
+}
+
+// ApplyProposalIn is ApplyProposal for a workspace rooted at root; relative
+// paths recorded in proposal.json are resolved against root. On success the
+// proposal is marked applied.
+func ApplyProposalIn(root, proposalDir string, opts ApplyOptions) (*ProposalMetadata, error) {
+	if !opts.Confirm {
 		return nil, fmt.Errorf("apply requires --i-understand confirmation")
 	}
 	if proposalDir == "" {
 		return nil, fmt.Errorf("proposal path is required")
 	}
 
-	meta, err := readProposalMetadata(proposalDir)
+	meta, err := readProposalMetadata(proposalDir, root)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkApplyStatus(meta, opts.Force); err != nil {
+		return nil, err
+	}
 
-	if err := enforcePermissions(meta.AgentID, proposalDir); err != nil {
+	if err := enforcePermissions(meta.AgentID, proposalDir, meta.OKRsDir); err != nil {
 		return nil, err
 	}
 
@@ -148,50 +189,250 @@ func ApplyProposal(proposalDir string, confirm bool) (*ProposalMetadata, error)
 		return nil, fmt.Errorf("ensure okrs dir: %w", err)
 	}
 
+	targets := make([]string, 0, len(meta.Files))
 	for _, file := range meta.Files {
-		src := filepath.Join(proposalDir, file)
-		dst := filepath.Join(meta.OKRsDir, file)
+		if err := checkProposalFile(file); err != nil {
+			return nil, err
+		}
+		targets = append(targets, filepath.Join(meta.OKRsDir, filepath.FromSlash(file)))
+	}
+
+	// Hold every target file's lock from backup to re-validation so that
+	// concurrent applies touching the same file cannot interleave.
+	locks, err := lockFiles(targets)
+	if err != nil {
+		return nil, err
+	}
+	defer locks.release()
+
+	// Checked under the locks so that the okrs/ state compared against the
+	// base is the one about to be overwritten.
+	if !opts.Overwrite {
+		conflicts, err := detectConflicts(meta)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			return nil, &ConflictError{ProposalID: meta.ID, Conflicts: conflicts}
+		}
+	}
+
+	// A dot directory, so re-validating the proposal never loads the backup.
+	backupDir := filepath.Join(proposalDir, ".backup")
+	backup, err := backupFiles(meta.OKRsDir, meta.Files, backupDir)
+	if err != nil {
+		return nil, err
+	}
+	version, err := RecordHistory(meta.OKRsDir, meta.Files, HistoryChange{
+		Reason:     HistoryReasonApply,
+		Actor:      meta.AgentID,
+		ProposalID: meta.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range meta.Files {
+		src := filepath.Join(proposalDir, filepath.FromSlash(file))
+		dst := filepath.Join(meta.OKRsDir, filepath.FromSlash(file))
 		if copyErr := copyFile(src, dst); copyErr != nil {
-			return nil, fmt.Errorf("apply %s: %w", file, copyErr)
+			version.Discard()
+			return nil, rollback(backup, fmt.Errorf("apply %s: %w", file, copyErr))
 		}
 	}
 
+	// The proposal was valid on its own; the merged okrs dir must be too
+	// (e.g. no objective or KR ids duplicated across files).
+	if _, err := LoadFromDir(meta.OKRsDir); err != nil {
+		version.Discard()
+		return nil, rollback(backup, fmt.Errorf("applied okrs failed validation: %w", err))
+	}
+
+	appliedAt := time.Now().UTC()
+	meta.Status = ProposalApplied
+	meta.AppliedAt = &appliedAt
+	if err := writeProposalMetadata(meta, root); err != nil {
+		return nil, fmt.Errorf("proposal applied, but recording its status failed: %w", err)
+	}
 	return meta, nil
 }
 
-func enforcePermissions(agentID, okrDir string) error {
-	store, err := LoadFromDir(okrDir)
-	if err != nil {
+// applyBackup records the pre-apply state of the files a proposal touches.
+type applyBackup struct {
+	okrsDir   string
+	backupDir string
+	// existed maps each file to whether it existed before the apply.
+	existed map[string]bool
+}
+
+// backupFiles copies the current version of each file into backupDir.
+func backupFiles(okrsDir string, files []string, backupDir string) (*applyBackup, error) {
+	if err := os.RemoveAll(backupDir); err != nil {
+		return nil, fmt.Errorf("reset backup: %w", err)
+	}
+	backup := &applyBackup{okrsDir: okrsDir, backupDir: backupDir, existed: map[string]bool{}}
+	for _, file := range files {
+		src := filepath.Join(okrsDir, filepath.FromSlash(file))
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			backup.existed[file] = false
+			continue
+		}
+		if err := copyFile(src, filepath.Join(backupDir, filepath.FromSlash(file))); err != nil {
+			return nil, fmt.Errorf("back up %s: %w", file, err)
+		}
+		backup.existed[file] = true
+	}
+	return backup, nil
+}
+
+// rollback restores the backed-up files, removes files the apply created,
+// and returns cause annotated with the outcome.
+func rollback(backup *applyBackup, cause error) error {
+	var failed []string
+	for file, existed := range backup.existed {
+		dst := filepath.Join(backup.okrsDir, filepath.FromSlash(file))
+		var err error
+		if existed {
+			err = copyFile(filepath.Join(backup.backupDir, filepath.FromSlash(file)), dst)
+		} else if err = os.Remove(dst); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			failed = append(failed, file)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%w; rollback failed for %s (originals in %s)", cause, strings.Join(failed, ", "), backup.backupDir)
+	}
+	return fmt.Errorf("%w; rolled back", cause)
+}
+
+// enforcePermissions checks that agentID may write every objective and key
+// result in the OKR files of dir, compared with their current versions in
+// okrsDir: field-limited grants may only change the fields they list, and
+// objectives or key results the files drop need an unrestricted grant.
+func enforcePermissions(agentID, dir, okrsDir string) error {
+	if _, err := LoadFromDir(dir); err != nil {
 		return fmt.Errorf("validate okrs: %w", err)
 	}
 
-	permCfg, err := loadPermissionsForDir(okrDir)
+	permCfg, err := loadPermissionsForDir(dir)
 	if err != nil {
 		return fmt.Errorf("load permissions: %w", err)
 	}
 
-	for _, obj := range store.objectives {
-		if obj.Objective.OwnerID != "" && !canProposeWithConfig(permCfg, agentID, obj.Objective.OwnerID) {
-			return fmt.Errorf("agent %s is not permitted to modify owner %s", agentID, obj.Objective.OwnerID)
+	files, err := relOKRFiles(dir)
+	if err != nil {
+		return err
+	}
+	after := readOKRItemsIn(dir, files)
+	touched := readOKRItemsIn(okrsDir, files)
+	before := map[okrItemKey]okrItem{}
+	if okrsDir != "" {
+		all, err := relOKRFiles(okrsDir)
+		if err != nil {
+			return err
+		}
+		before = readOKRItemsIn(okrsDir, all)
+	}
+
+	for _, key := range sortedItemKeys(after, touched) {
+		item, exists := after[key]
+		old, existed := before[key]
+		if !exists {
+			// Dropped from a file the proposal replaces, unless it moved.
+			item = touched[key]
+		}
+		owner, _ := item.fields["owner_id"].(string)
+		if owner == "" && !key.kr {
+			continue
+		}
+		grant, ok := permCfg.grantFor(agentID, owner, item.scope)
+		if !ok {
+			return fmt.Errorf("agent %s is not permitted to modify owner %s", agentID, owner)
 		}
-		for _, kr := range obj.Objective.KeyResults {
-			if !canProposeWithConfig(permCfg, agentID, kr.OwnerID) {
-				return fmt.Errorf("agent %s is not permitted to modify owner %s", agentID, kr.OwnerID)
+		if grant.allFields {
+			continue
+		}
+		kind := "objective"
+		if key.kr {
+			kind = "key result"
+		}
+		switch {
+		case !exists:
+			return fmt.Errorf("agent %s may not remove %s %s", agentID, kind, key.id)
+		case !existed:
+			return fmt.Errorf("agent %s may not add %s %s", agentID, kind, key.id)
+		}
+		for _, field := range changedFields(old.fields, item.fields) {
+			if !grant.fields[field] {
+				return fmt.Errorf("agent %s may not change %s of %s %s", agentID, field, kind, key.id)
 			}
 		}
 	}
 	return nil
 }
 
+// relOKRFiles lists the OKR files of dir relative to it, slash-separated.
+func relOKRFiles(dir string) ([]string, error) {
+	files, err := OKRFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", dir, err)
+	}
+	rel := make([]string, 0, len(files))
+	for _, file := range files {
+		r, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel, nil
+}
+
+// changedFields returns the sorted field names whose values differ.
+func changedFields(old, updated map[string]any) []string {
+	var changed []string
+	for field, value := range updated {
+		if !reflect.DeepEqual(old[field], value) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range old {
+		if _, ok := updated[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// collectYAMLFiles returns the OKR files under dir, keeping subdirectories,
+// plus a top-level permissions.yml, which permission checks read from the
+// proposal dir.
 func collectYAMLFiles(dir string) ([]string, error) {
-	files, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	files, err := OKRFiles(dir)
 	if err != nil {
 		return nil, fmt.Errorf("scan %s: %w", dir, err)
 	}
-	sort.Strings(files)
+	perm := filepath.Join(dir, "permissions.yml")
+	if info, err := os.Stat(perm); err == nil && !info.IsDir() {
+		files = append(files, perm)
+		sort.Strings(files)
+	}
 	return files, nil
 }
 
+// checkProposalFile rejects proposal file entries that would escape the okrs dir.
+func checkProposalFile(file string) error {
+	clean := path.Clean(file)
+	if file == "" || path.IsAbs(clean) || filepath.IsAbs(file) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("proposal file %q must be a path inside the okrs dir", file)
+	}
+	return nil
+}
+
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
@@ -201,6 +442,9 @@ func copyFile(src, dst string) error {
 		_ = in.Close()
 	}()
 
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
 	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
 	if err != nil {
 		return err
@@ -215,28 +459,28 @@ func copyFile(src, dst string) error {
 	return nil
 }
 
-func renderDiff(updateFiles []string, okrsDir, proposalDir string) (string, error) {
+func renderDiff(updatesDir string, files []string, okrsDir, proposalDir string) (string, error) {
 	var diffStrings []string
 
-	for _, src := range updateFiles {
-		baseName := filepath.Base(src)
+	for _, rel := range files {
+		src := filepath.Join(updatesDir, filepath.FromSlash(rel))
 		newBytes, err := os.ReadFile(src)
 		if err != nil {
 			return "", fmt.Errorf("read %s: %w", src, err)
 		}
-		oldPath := filepath.Join(okrsDir, baseName)
+		oldPath := filepath.Join(okrsDir, filepath.FromSlash(rel))
 		oldBytes, _ := os.ReadFile(oldPath)
 
 		diff := difflib.UnifiedDiff{
-			A:        strings.Split(string(oldBytes), "\n"),
-			B:        strings.Split(string(newBytes), "\n"),
-			FromFile: filepath.Join("okrs", baseName),
-			ToFile:   filepath.Join("proposal", baseName),
+			A:        diffLines(oldBytes),
+			B:        diffLines(newBytes),
+			FromFile: path.Join("okrs", rel),
+			ToFile:   path.Join("proposal", rel),
 			Context:  3,
 		}
 		diffText, err := difflib.GetUnifiedDiffString(diff)
 		if err != nil {
-			return "", fmt.Errorf("diff %s: %w", baseName, err)
+			return "", fmt.Errorf("diff %s: %w", rel, err)
 		}
 		if strings.TrimSpace(diffText) != "" {
 			diffStrings = append(diffStrings, diffText)
@@ -254,8 +498,24 @@ func renderDiff(updateFiles []string, okrsDir, proposalDir string) (string, erro
 	return filepath.Base(diffPath), nil
 }
 
-func writeProposalMetadata(meta *ProposalMetadata) error {
-	data, err := json.MarshalIndent(meta, "", "  ")
+// diffLines splits data into lines that keep their line endings, as
+// difflib expects.
+func diffLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return difflib.SplitLines(string(data))
+}
+
+func writeProposalMetadata(meta *ProposalMetadata, root string) error {
+	stored := *meta
+	if root != "" {
+		ws := &workspace.Workspace{Root: root}
+		stored.OKRsDir = ws.RelPath(meta.OKRsDir)
+		stored.ProposalDir = ws.RelPath(meta.ProposalDir)
+		stored.UpdatesDir = ws.RelPath(meta.UpdatesDir)
+	}
+	data, err := json.MarshalIndent(&stored, "", "  ")
 	if err != nil {
 		return fmt.Errorf("encode proposal.json: %w", err)
 	}
@@ -266,7 +526,7 @@ func writeProposalMetadata(meta *ProposalMetadata) error {
 	return nil
 }
 
-func readProposalMetadata(proposalDir string) (*ProposalMetadata, error) {
+func readProposalMetadata(proposalDir, root string) (*ProposalMetadata, error) {
 	path := filepath.Join(proposalDir, "proposal.json")
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -282,12 +542,28 @@ func readProposalMetadata(proposalDir string) (*ProposalMetadata, error) {
 	if meta.OKRsDir == "" {
 		meta.OKRsDir = "okrs"
 	}
+	if meta.Status == "" {
+		meta.Status = ProposalPending
+	}
+	if root != "" {
+		meta.OKRsDir = resolveIn(root, meta.OKRsDir)
+		meta.ProposalDir = resolveIn(root, meta.ProposalDir)
+		meta.UpdatesDir = resolveIn(root, meta.UpdatesDir)
+	}
 	if meta.AgentID == "" || meta.ID == "" {
 		return nil, fmt.Errorf("proposal metadata is missing required fields")
 	}
 	return &meta, nil
 }
 
+// resolveIn joins a stored relative path onto root; absolute paths pass through.
+func resolveIn(root, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, filepath.FromSlash(path))
+}
+
 func sanitize(value string) string {
 	safe := strings.Map(func(r rune) rune {
 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
@@ -0,0 +1,398 @@
+// Package outcomes tracks whether executed plans met their success criteria
+// and keeps the results in an append-only plan outcomes ledger.
+package outcomes
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// EvidenceAgentID is the agent that proposes evidence for successful runs.
+// KR owners must delegate to it in okrs/permissions.yml for the proposal to
+// pass permission checks.
+const EvidenceAgentID = "okrchestra-outcomes"
+
+// Outcome is one ledger entry. The ledger is append-only; the latest entry
+// for a run is its current state.
+type Outcome struct {
+	PlanID        string                  `json:"plan_id"`
+	PlanPath      string                  `json:"plan_path"`
+	RunID         string                  `json:"run_id"`
+	RunDir        string                  `json:"run_dir"`
+	Criteria      planner.SuccessCriteria `json:"criteria"`
+	StartedOn     string                  `json:"started_on"`
+	Deadline      string                  `json:"deadline"`
+	Status        string                  `json:"status"`
+	Items         []ItemOutcome           `json:"items"`
+	ProposalID    string                  `json:"proposal_id,omitempty"`
+	EvidenceError string                  `json:"evidence_error,omitempty"`
+	RecordedAt    string                  `json:"recorded_at"`
+}
+
+// ItemOutcome tracks one plan item's metric against the criteria.
+type ItemOutcome struct {
+	ItemID     string   `json:"item_id"`
+	KRID       string   `json:"kr_id"`
+	MetricKey  string   `json:"metric_key"`
+	Direction  string   `json:"direction"`
+	Baseline   float64  `json:"baseline"`
+	Observed   *float64 `json:"observed,omitempty"`
+	ObservedOn string   `json:"observed_on,omitempty"`
+	Met        bool     `json:"met"`
+}
+
+// LedgerPath returns the plan outcomes ledger for a workspace.
+func LedgerPath(ws *workspace.Workspace) string {
+	return filepath.Join(ws.ArtifactsDir, "outcomes.jsonl")
+}
+
+// Track records a pending outcome for a completed plan run. Plans without
+// success criteria are not tracked and yield nil.
+func Track(ws *workspace.Workspace, planPath string, result *planner.RunResult) (*Outcome, error) {
+	if result == nil || result.Plan.SuccessCriteria == nil {
+		return nil, nil
+	}
+	criteria := *result.Plan.SuccessCriteria
+	started := result.StartedAt
+	if started.IsZero() {
+		started = time.Now().UTC()
+	}
+	startedOn := started.UTC().Format("2006-01-02")
+
+	// Items are measured from the latest snapshot taken before the run.
+	var before *metrics.Snapshot
+	snapshotsDir := filepath.Join(ws.MetricsDir, "snapshots")
+	if paths, err := metrics.SnapshotPaths(snapshotsDir); err == nil {
+		for i := len(paths) - 1; i >= 0; i-- {
+			if snapshotDate(paths[i]) <= startedOn {
+				before, _ = metrics.LoadSnapshot(paths[i])
+				break
+			}
+		}
+	}
+
+	outcome := &Outcome{
+		PlanID:    result.Plan.ID,
+		PlanPath:  ws.RelPath(planPath),
+		RunID:     result.RunID,
+		RunDir:    ws.RelPath(result.RunDir),
+		Criteria:  criteria,
+		StartedOn: startedOn,
+		Deadline:  started.UTC().AddDate(0, 0, criteria.WithinDays).Format("2006-01-02"),
+		Status:    StatusPending,
+	}
+	for _, item := range result.Plan.Items {
+		change := item.ExpectedMetricChange
+		baseline := change.Baseline
+		if v, ok := before.Value(change.MetricKey); ok {
+			baseline = v
+		}
+		outcome.Items = append(outcome.Items, ItemOutcome{
+			ItemID:    item.ID,
+			KRID:      item.KRID,
+			MetricKey: change.MetricKey,
+			Direction: change.Direction,
+			Baseline:  baseline,
+		})
+	}
+	if err := appendLedger(LedgerPath(ws), outcome); err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+// Load returns the current state of every tracked run, in the order runs
+// were first tracked. A missing ledger yields no outcomes.
+func Load(ws *workspace.Workspace) ([]Outcome, error) {
+	f, err := os.Open(LedgerPath(ws))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open outcomes ledger: %w", err)
+	}
+	defer f.Close()
+
+	latest := map[string]int{}
+	var out []Outcome
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(data))) > 0 {
+			var outcome Outcome
+			if jsonErr := json.Unmarshal(data, &outcome); jsonErr != nil {
+				return nil, fmt.Errorf("parse outcomes ledger line %d: %w", line, jsonErr)
+			}
+			if idx, ok := latest[outcome.RunID]; ok {
+				out[idx] = outcome
+			} else {
+				latest[outcome.RunID] = len(out)
+				out = append(out, outcome)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read outcomes ledger: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// PendingRunIDs returns the runs whose outcome is still pending, which
+// must be kept until it is decided.
+func PendingRunIDs(ws *workspace.Workspace) (map[string]bool, error) {
+	tracked, err := Load(ws)
+	if err != nil {
+		return nil, err
+	}
+	pending := map[string]bool{}
+	for _, outcome := range tracked {
+		if outcome.Status == StatusPending {
+			pending[outcome.RunID] = true
+		}
+	}
+	return pending, nil
+}
+
+// Check evaluates pending outcomes against metric snapshots as of now. Runs
+// whose criteria are met are marked succeeded and their evidence proposed
+// to the KRs by agentID; runs past their deadline are marked failed. The
+// outcomes whose status changed are returned.
+func Check(ws *workspace.Workspace, now time.Time, agentID string) ([]Outcome, error) {
+	tracked, err := Load(ws)
+	if err != nil {
+		return nil, err
+	}
+	if agentID == "" {
+		agentID = EvidenceAgentID
+	}
+	today := now.UTC().Format("2006-01-02")
+
+	var snapshots []*metrics.Snapshot
+	loaded := false
+	var changed []Outcome
+	for _, outcome := range tracked {
+		if outcome.Status != StatusPending {
+			continue
+		}
+		if !loaded {
+			snapshots, err = loadSnapshots(filepath.Join(ws.MetricsDir, "snapshots"))
+			if err != nil {
+				return changed, err
+			}
+			loaded = true
+		}
+
+		met := evaluate(&outcome, snapshots, today)
+		switch {
+		case met:
+			outcome.Status = StatusSucceeded
+			proposalID, err := attachEvidence(ws, outcome, agentID)
+			if err != nil {
+				outcome.EvidenceError = err.Error()
+			}
+			outcome.ProposalID = proposalID
+		case today > outcome.Deadline:
+			outcome.Status = StatusFailed
+		default:
+			continue
+		}
+		outcome.RecordedAt = ""
+		if err := appendLedger(LedgerPath(ws), &outcome); err != nil {
+			return changed, err
+		}
+		changed = append(changed, outcome)
+	}
+	return changed, nil
+}
+
+// evaluate updates each item from snapshots dated within the run's window
+// and reports whether every item met the criteria.
+func evaluate(outcome *Outcome, snapshots []*metrics.Snapshot, today string) bool {
+	end := outcome.Deadline
+	if today < end {
+		end = today
+	}
+	all := len(outcome.Items) > 0
+	for i := range outcome.Items {
+		item := &outcome.Items[i]
+		item.Met = false
+		for _, snap := range snapshots {
+			if snap.AsOf < outcome.StartedOn || snap.AsOf > end {
+				continue
+			}
+			v, ok := snap.Value(item.MetricKey)
+			if !ok {
+				continue
+			}
+			observed := v
+			item.Observed = &observed
+			item.ObservedOn = snap.AsOf
+			if moved(item.Direction, item.Baseline, v) >= outcome.Criteria.MinDelta {
+				item.Met = true
+				break
+			}
+		}
+		all = all && item.Met
+	}
+	return all
+}
+
+// moved is the change from baseline to v in the expected direction.
+func moved(direction string, baseline, v float64) float64 {
+	if direction == "decrease" {
+		return baseline - v
+	}
+	return v - baseline
+}
+
+// attachEvidence proposes adding the run to each met KR's evidence list and
+// returns the proposal ID. Updated files keep their okrs-relative paths.
+func attachEvidence(ws *workspace.Workspace, outcome Outcome, agentID string) (string, error) {
+	rules, err := okrstore.LoadRules(ws.OKRsDir)
+	if err != nil {
+		return "", err
+	}
+	evidence := map[string]string{}
+	for _, item := range outcome.Items {
+		if !item.Met || item.Observed == nil {
+			continue
+		}
+		evidence[item.KRID] = rules.EvidenceRef(okrstore.EvidenceSchemeRun, fmt.Sprintf("%s: %s %g → %g by %s (plan %s)",
+			outcome.RunDir, item.MetricKey, item.Baseline, *item.Observed, item.ObservedOn, outcome.PlanID))
+	}
+
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return "", fmt.Errorf("load okrs: %w", err)
+	}
+	updatesDir := filepath.Join(ws.ArtifactsDir, "outcomes", outcome.RunID, "updates")
+	if err := os.RemoveAll(updatesDir); err != nil {
+		return "", fmt.Errorf("reset evidence updates: %w", err)
+	}
+
+	var docs []okrstore.Document
+	docs = append(docs, store.Org.Documents...)
+	docs = append(docs, store.Team.Documents...)
+	docs = append(docs, store.Person.Documents...)
+	written := 0
+	for _, doc := range docs {
+		touched := false
+		for i := range doc.Objectives {
+			for j := range doc.Objectives[i].KeyResults {
+				kr := &doc.Objectives[i].KeyResults[j]
+				line, ok := evidence[kr.ID]
+				if !ok || containsString(kr.Evidence, line) {
+					continue
+				}
+				kr.Evidence = append(append([]string{}, kr.Evidence...), line)
+				touched = true
+			}
+		}
+		if !touched {
+			continue
+		}
+		rel, err := filepath.Rel(ws.OKRsDir, doc.Source)
+		if err != nil {
+			return "", fmt.Errorf("locate %s: %w", doc.Source, err)
+		}
+		dst := filepath.Join(updatesDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", fmt.Errorf("ensure evidence updates dir: %w", err)
+		}
+		if err := okrstore.WriteDocument(doc, dst); err != nil {
+			return "", err
+		}
+		written++
+	}
+	if written == 0 {
+		return "", nil
+	}
+	if data, err := os.ReadFile(filepath.Join(ws.OKRsDir, "permissions.yml")); err == nil {
+		if err := os.WriteFile(filepath.Join(updatesDir, "permissions.yml"), data, 0o644); err != nil {
+			return "", fmt.Errorf("copy permissions: %w", err)
+		}
+	}
+
+	note := fmt.Sprintf("Evidence from run %s: plan %s met its success criteria.", outcome.RunID, outcome.PlanID)
+	meta, err := okrstore.CreateProposalIn(ws.Root, agentID, updatesDir, ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "proposals"), note)
+	if err != nil {
+		return "", fmt.Errorf("propose evidence: %w", err)
+	}
+	return meta.ID, nil
+}
+
+func loadSnapshots(dir string) ([]*metrics.Snapshot, error) {
+	paths, err := metrics.SnapshotPaths(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*metrics.Snapshot
+	for _, path := range paths {
+		snap, err := metrics.LoadSnapshot(path)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", filepath.Base(path), err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots, nil
+}
+
+func appendLedger(path string, outcome *Outcome) error {
+	if outcome.RecordedAt == "" {
+		outcome.RecordedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("marshal outcome: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure outcomes ledger dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open outcomes ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write outcomes ledger: %w", err)
+	}
+	return nil
+}
+
+func snapshotDate(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".json")
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package outcomes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+const outcomeOKRs = `
+scope: team
+objectives:
+  - objective_id: OBJ-1
+    objective: Ship reliably
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: Raise pass rate
+        owner_id: team-alpha
+        metric_key: ci.pass_rate
+        baseline: 0.8
+        target: 0.95
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+
+const outcomePermissions = `
+permissions:
+  read: ["all"]
+  write: ["owner_id_match", "delegated_explicitly"]
+delegations:
+  team-alpha:
+    - okrchestra-outcomes
+`
+
+func setupWorkspace(t *testing.T) *workspace.Workspace {
+	t.Helper()
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatalf("ensure dirs: %v", err)
+	}
+	if err := os.MkdirAll(ws.OKRsDir, 0o755); err != nil {
+		t.Fatalf("mkdir okrs: %v", err)
+	}
+	for name, content := range map[string]string{
+		"team.yml":        outcomeOKRs,
+		"permissions.yml": outcomePermissions,
+	} {
+		if err := os.WriteFile(filepath.Join(ws.OKRsDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return ws
+}
+
+func writeSnapshot(t *testing.T, ws *workspace.Workspace, date string, value float64) {
+	t.Helper()
+	path := filepath.Join(ws.MetricsDir, "snapshots", date+".json")
+	err := metrics.WriteSnapshot(path, metrics.Snapshot{
+		AsOf:   date,
+		Points: []metrics.MetricPoint{{Key: "ci.pass_rate", Value: value, Timestamp: date + "T00:00:00Z", Source: "test"}},
+	})
+	if err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+}
+
+func trackedRun(t *testing.T, ws *workspace.Workspace, runID string) *Outcome {
+	t.Helper()
+	result := &planner.RunResult{
+		RunID:  runID,
+		RunDir: filepath.Join(ws.ArtifactsDir, "runs", runID),
+		Plan: planner.Plan{
+			ID:              "plan-" + runID,
+			SuccessCriteria: &planner.SuccessCriteria{MinDelta: 0.05, WithinDays: 7},
+			Items: []planner.PlanItem{{
+				ID:   "item-1",
+				KRID: "KR-1",
+				ExpectedMetricChange: planner.ExpectedMetricChange{
+					MetricKey: "ci.pass_rate",
+					Baseline:  0.8,
+					Direction: "increase",
+				},
+			}},
+		},
+		StartedAt: time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC),
+	}
+	outcome, err := Track(ws, filepath.Join(ws.ArtifactsDir, "plans", "plan.json"), result)
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	return outcome
+}
+
+func TestTrackUsesSnapshotBaseline(t *testing.T) {
+	ws := setupWorkspace(t)
+	writeSnapshot(t, ws, "2025-01-09", 0.82)
+	writeSnapshot(t, ws, "2025-01-11", 0.9)
+
+	outcome := trackedRun(t, ws, "run-a")
+	if outcome.Status != StatusPending || outcome.Deadline != "2025-01-17" {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+	if outcome.Items[0].Baseline != 0.82 {
+		t.Fatalf("baseline = %v, want latest snapshot before the run", outcome.Items[0].Baseline)
+	}
+
+	if got, err := Track(ws, "plan.json", &planner.RunResult{RunID: "no-criteria"}); err != nil || got != nil {
+		t.Fatalf("plans without criteria should not be tracked: %v %v", got, err)
+	}
+}
+
+func TestCheckAttachesEvidenceWhenMet(t *testing.T) {
+	ws := setupWorkspace(t)
+	writeSnapshot(t, ws, "2025-01-09", 0.8)
+	trackedRun(t, ws, "run-a")
+
+	writeSnapshot(t, ws, "2025-01-12", 0.83)
+	changed, err := Check(ws, time.Date(2025, 1, 12, 9, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("delta below criteria should stay pending: %+v", changed)
+	}
+
+	writeSnapshot(t, ws, "2025-01-14", 0.86)
+	changed, err = Check(ws, time.Date(2025, 1, 14, 9, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(changed) != 1 || changed[0].Status != StatusSucceeded {
+		t.Fatalf("changed = %+v", changed)
+	}
+	if changed[0].EvidenceError != "" || changed[0].ProposalID == "" {
+		t.Fatalf("evidence proposal = %q, error %q", changed[0].ProposalID, changed[0].EvidenceError)
+	}
+
+	proposalDir := filepath.Join(ws.ArtifactsDir, "proposals", changed[0].ProposalID)
+	if _, err := okrstore.ApplyProposalIn(ws.Root, proposalDir, okrstore.ApplyOptions{Confirm: true, Force: true}); err != nil {
+		t.Fatalf("apply proposal: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(ws.OKRsDir, "team.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "artifacts/runs/run-a") {
+		t.Fatalf("evidence not attached:\n%s", data)
+	}
+
+	tracked, err := Load(ws)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(tracked) != 1 || tracked[0].Status != StatusSucceeded || !tracked[0].Items[0].Met {
+		t.Fatalf("ledger = %+v", tracked)
+	}
+}
+
+func TestCheckFailsAfterDeadline(t *testing.T) {
+	ws := setupWorkspace(t)
+	writeSnapshot(t, ws, "2025-01-09", 0.8)
+	trackedRun(t, ws, "run-a")
+	// Past the deadline: movement after it does not count.
+	writeSnapshot(t, ws, "2025-01-20", 0.9)
+
+	changed, err := Check(ws, time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(changed) != 1 || changed[0].Status != StatusFailed {
+		t.Fatalf("changed = %+v", changed)
+	}
+}
@@ -0,0 +1,50 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Plan statuses. Generated plans start as drafts; the daemon's plan_execute
+// skips them until they are approved, unless auto-approval is configured.
+const (
+	PlanDraft    = "draft"
+	PlanApproved = "approved"
+)
+
+// Approved reports whether the plan may be executed unattended. Plans
+// without a status, such as ones written by hand or by another tool, count
+// as drafts.
+func (p Plan) Approved() bool {
+	return p.Status == PlanApproved
+}
+
+// ApprovePlan marks the plan at path approved by approver and rewrites it.
+func ApprovePlan(path, approver string, now time.Time) (Plan, error) {
+	plan, err := LoadPlan(path)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan.Status = PlanApproved
+	plan.ApprovedBy = approver
+	plan.ApprovedAt = now.UTC().Format(time.RFC3339)
+	if err := WritePlan(path, plan); err != nil {
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+// WritePlan writes plan to path as indented JSON.
+func WritePlan(path string, plan Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal plan: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write plan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package planner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApprovePlan(t *testing.T) {
+	if (Plan{}).Approved() {
+		t.Fatal("plans without a status must count as drafts")
+	}
+	plan := Plan{ID: "plan-1", AsOf: "2025-03-01", Status: PlanDraft, Items: []PlanItem{
+		{ID: "ITEM-1", ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do it", AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "m1", Direction: "increase", Target: 10}},
+	}}
+	if plan.Approved() {
+		t.Fatal("draft plan reported approved")
+	}
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := WritePlan(path, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+	if _, err := ApprovePlan(path, "alice", now); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Approved() || loaded.Status != PlanApproved || loaded.ApprovedBy != "alice" || loaded.ApprovedAt != "2025-03-02T09:00:00Z" {
+		t.Fatalf("approved plan = %+v", loaded)
+	}
+	if len(loaded.Items) != 1 || loaded.Items[0].Task != "do it" {
+		t.Fatalf("approval changed items: %+v", loaded.Items)
+	}
+
+	plan.Status = "pending"
+	if err := ValidatePlan(plan); err == nil {
+		t.Fatal("unknown status accepted")
+	}
+}
@@ -0,0 +1,291 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// planGeneratePrompt is the template rendered for adapter-assisted plan
+// generation.
+const planGeneratePrompt = "plan_generate"
+
+// Limits on the context handed to the agent, to keep the prompt small.
+const (
+	assistCultureBytes = 4000
+	assistRecentRuns   = 10
+)
+
+// planProposalSchema is the JSON schema the agent's proposed items follow.
+const planProposalSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["items"],
+  "properties": {
+    "items": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["kr_id", "hypothesis", "task", "evidence_plan"],
+        "properties": {
+          "kr_id": { "type": "string" },
+          "hypothesis": { "type": "string" },
+          "task": { "type": "string" },
+          "evidence_plan": { "type": "array", "items": { "type": "string" } }
+        }
+      }
+    }
+  }
+}
+`
+
+// planProposal is the result an agent writes for plan generation.
+type planProposal struct {
+	Items []proposedItem `json:"items"`
+}
+
+type proposedItem struct {
+	KRID         string   `json:"kr_id"`
+	Hypothesis   string   `json:"hypothesis"`
+	Task         string   `json:"task"`
+	EvidencePlan []string `json:"evidence_plan"`
+}
+
+// assistData is what the plan_generate template renders.
+type assistData struct {
+	AsOf        string
+	Objectives  []okrstore.Objective
+	Drafts      []PlanItem
+	Culture     []assistDoc
+	MetricsAsOf string
+	Metrics     []metrics.MetricPoint
+	Runs        []assistRun
+	ResultPath  string
+}
+
+type assistDoc struct {
+	Name    string
+	Content string
+}
+
+// assistRun is a recent run item and how it went.
+type assistRun struct {
+	RunID    string
+	Item     PlanItem
+	Summary  string
+	Decision string
+	Comment  string
+}
+
+// assistItems asks opts.Adapter to rewrite the template drafts into
+// concrete plan items. The agent keeps to the drafts' KRs and may propose
+// up to one item per draft; each item takes the expected metric change,
+// agent role, and avoided tactics of the draft for its KR. The prompt,
+// transcript, and result are kept in <out-dir>/<as-of>/generate.
+func assistItems(ctx context.Context, store *okrstore.Store, drafts []PlanItem, opts GenerateOptions) ([]PlanItem, error) {
+	dir, err := filepath.Abs(filepath.Join(opts.OutputBaseDir, opts.AsOf.UTC().Format("2006-01-02"), "generate"))
+	if err != nil {
+		return nil, fmt.Errorf("resolve generate dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure generate dir: %w", err)
+	}
+	data, err := assistContext(store, drafts, opts)
+	if err != nil {
+		return nil, err
+	}
+	data.ResultPath = filepath.Join(dir, "result.json")
+
+	tmpl, err := loadPromptTemplate(planGeneratePrompt, opts.Language, opts.PromptDir)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("render %s prompt: %w", tmpl.Name(), err)
+	}
+	promptPath := filepath.Join(dir, "prompt.md")
+	if err := os.WriteFile(promptPath, []byte(b.String()), 0o644); err != nil {
+		return nil, fmt.Errorf("write generate prompt: %w", err)
+	}
+
+	result, err := opts.Adapter.Run(ctx, adapters.RunConfig{
+		PromptPath:   promptPath,
+		WorkDir:      dir,
+		ArtifactsDir: dir,
+		Timeout:      opts.AdapterTimeout,
+		Language:     opts.Language,
+		ResultSchema: planProposalSchema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s adapter: %w", opts.Adapter.Name(), err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("%s adapter exited with code %d; see %s", opts.Adapter.Name(), result.ExitCode, result.TranscriptPath)
+	}
+	resultPath := result.SummaryPath
+	if resultPath == "" {
+		resultPath = data.ResultPath
+	}
+	proposal, err := loadPlanProposal(resultPath)
+	if err != nil {
+		return nil, err
+	}
+	return proposedItems(proposal, drafts)
+}
+
+// assistContext gathers what the agent plans from: the org objectives, the
+// drafts, the culture docs, the latest metric snapshot, and recent runs.
+func assistContext(store *okrstore.Store, drafts []PlanItem, opts GenerateOptions) (assistData, error) {
+	data := assistData{AsOf: opts.AsOf.UTC().Format("2006-01-02"), Drafts: drafts}
+	for _, doc := range store.Org.Documents {
+		data.Objectives = append(data.Objectives, doc.Objectives...)
+	}
+
+	if opts.CultureDir != "" {
+		paths, err := filepath.Glob(filepath.Join(opts.CultureDir, "*.md"))
+		if err != nil {
+			return data, fmt.Errorf("find culture docs: %w", err)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return data, fmt.Errorf("read culture doc: %w", err)
+			}
+			text := strings.TrimSpace(string(content))
+			if len(text) > assistCultureBytes {
+				text = text[:assistCultureBytes] + "\n…"
+			}
+			data.Culture = append(data.Culture, assistDoc{Name: filepath.Base(path), Content: text})
+		}
+	}
+
+	if opts.SnapshotsDir != "" {
+		path, err := metrics.LatestSnapshotPath(opts.SnapshotsDir)
+		if err != nil {
+			return data, err
+		}
+		if path != "" {
+			snapshot, err := metrics.LoadSnapshot(path)
+			if err != nil {
+				return data, err
+			}
+			data.MetricsAsOf, data.Metrics = snapshot.AsOf, snapshot.Points
+		}
+	}
+
+	if opts.RunsDir != "" {
+		runs, err := recentRunItems(opts.RunsDir, assistRecentRuns)
+		if err != nil {
+			return data, err
+		}
+		data.Runs = runs
+	}
+	return data, nil
+}
+
+// recentRunItems returns the newest n run items under runsDir, newest
+// first, with their result summary and review.
+func recentRunItems(runsDir string, n int) ([]assistRun, error) {
+	itemDirs, err := filepath.Glob(filepath.Join(runsDir, "*", "item-*"))
+	if err != nil {
+		return nil, fmt.Errorf("scan runs dir: %w", err)
+	}
+	sort.Strings(itemDirs)
+	var runs []assistRun
+	for i := len(itemDirs) - 1; i >= 0 && len(runs) < n; i-- {
+		item, err := LoadRunItem(itemDirs[i])
+		if err != nil {
+			// runs recorded before item.json existed have nothing to show
+			continue
+		}
+		run := assistRun{
+			RunID:   filepath.Base(filepath.Dir(itemDirs[i])),
+			Item:    item,
+			Summary: resultSummary(itemDirs[i]),
+		}
+		review, err := LoadReview(itemDirs[i])
+		if err != nil {
+			return nil, err
+		}
+		if review != nil {
+			run.Decision, run.Comment = review.Decision, review.Comment
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func loadPlanProposal(path string) (*planProposal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read plan proposal: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var proposal planProposal
+	if err := dec.Decode(&proposal); err != nil {
+		return nil, fmt.Errorf("parse plan proposal %s: %w", path, err)
+	}
+	return &proposal, nil
+}
+
+// proposedItems validates the agent's items against the drafts and builds
+// plan items from them, numbered in proposal order.
+func proposedItems(proposal *planProposal, drafts []PlanItem) ([]PlanItem, error) {
+	if len(proposal.Items) == 0 {
+		return nil, fmt.Errorf("plan proposal has no items")
+	}
+	if len(proposal.Items) > len(drafts) {
+		return nil, fmt.Errorf("plan proposal has %d items; at most %d were asked for", len(proposal.Items), len(drafts))
+	}
+	byKR := make(map[string]PlanItem, len(drafts))
+	for _, draft := range drafts {
+		if _, ok := byKR[draft.KRID]; !ok {
+			byKR[draft.KRID] = draft
+		}
+	}
+	items := make([]PlanItem, 0, len(proposal.Items))
+	for i, proposed := range proposal.Items {
+		draft, ok := byKR[strings.TrimSpace(proposed.KRID)]
+		if !ok {
+			return nil, fmt.Errorf("plan proposal item %d targets kr_id %q, which is not in the plan", i+1, proposed.KRID)
+		}
+		hypothesis, task := strings.TrimSpace(proposed.Hypothesis), strings.TrimSpace(proposed.Task)
+		if hypothesis == "" || task == "" {
+			return nil, fmt.Errorf("plan proposal item %d needs a hypothesis and a task", i+1)
+		}
+		item := draft
+		item.ID = fmt.Sprintf("ITEM-%d", i+1)
+		item.Hypothesis = hypothesis
+		item.Task = task
+		if evidence := trimNonEmpty(proposed.EvidencePlan); len(evidence) > 0 {
+			item.EvidencePlan = evidence
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func trimNonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
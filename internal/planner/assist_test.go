@@ -0,0 +1,84 @@
+package planner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+)
+
+// proposalAdapter writes proposal as the agent's result.
+func proposalAdapter(t *testing.T, proposal string, prompt *string) *stubAdapter {
+	return &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		data, err := os.ReadFile(cfg.PromptPath)
+		if err != nil {
+			t.Fatalf("read prompt: %v", err)
+		}
+		*prompt = string(data)
+		if !strings.Contains(cfg.ResultSchema, `"evidence_plan"`) {
+			t.Fatalf("result schema = %s", cfg.ResultSchema)
+		}
+		resultPath := filepath.Join(cfg.ArtifactsDir, "result.json")
+		if err := os.WriteFile(resultPath, []byte(proposal), 0o644); err != nil {
+			t.Fatalf("write result: %v", err)
+		}
+		return &adapters.RunResult{SummaryPath: resultPath}, nil
+	}}
+}
+
+func TestGeneratePlanWithAdapter(t *testing.T) {
+	okrsDir, plansDir := setupStrategyTest(t)
+	cultureDir := filepath.Join(filepath.Dir(okrsDir), "culture")
+	if err := os.MkdirAll(cultureDir, 0o755); err != nil {
+		t.Fatalf("mkdir culture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cultureDir, "values.md"), []byte("Ship small changes.\n"), 0o644); err != nil {
+		t.Fatalf("write values.md: %v", err)
+	}
+
+	var prompt string
+	opts := GenerateOptions{
+		OKRsDir:       okrsDir,
+		OutputBaseDir: plansDir,
+		AsOf:          time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Strategy:      StrategyRoundRobin,
+		Items:         2,
+		CultureDir:    cultureDir,
+		Adapter: proposalAdapter(t, `{"items": [
+			{"kr_id": "KR-2", "hypothesis": "Caching m2 lookups raises m2.", "task": "Add a cache to the m2 path.", "evidence_plan": ["Benchmark before and after."]}
+		]}`, &prompt),
+	}
+	res, err := GeneratePlan(opts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	for _, want := range []string{"KR-1", "KR-2", "Ship small changes."} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if res.Plan.GeneratedBy != "stub" || len(res.Plan.Items) != 1 {
+		t.Fatalf("plan = %+v", res.Plan)
+	}
+	item := res.Plan.Items[0]
+	if item.ID != "ITEM-1" || item.KRID != "KR-2" || item.Task != "Add a cache to the m2 path." ||
+		item.ExpectedMetricChange.MetricKey != "m2" || len(item.EvidencePlan) != 1 {
+		t.Fatalf("item = %+v", item)
+	}
+
+	for name, proposal := range map[string]string{
+		"unknown kr":    `{"items": [{"kr_id": "KR-9", "hypothesis": "h", "task": "t", "evidence_plan": []}]}`,
+		"empty task":    `{"items": [{"kr_id": "KR-1", "hypothesis": "h", "task": " ", "evidence_plan": []}]}`,
+		"too many":      `{"items": [{"kr_id": "KR-1", "hypothesis": "h", "task": "t", "evidence_plan": []}, {"kr_id": "KR-1", "hypothesis": "h", "task": "t", "evidence_plan": []}, {"kr_id": "KR-2", "hypothesis": "h", "task": "t", "evidence_plan": []}]}`,
+		"unknown field": `{"items": [{"kr_id": "KR-1", "hypothesis": "h", "task": "t", "evidence_plan": [], "owner": "x"}]}`,
+	} {
+		opts.Adapter = proposalAdapter(t, proposal, &prompt)
+		if _, err := GeneratePlan(opts); err == nil {
+			t.Fatalf("%s: expected the proposal to be rejected", name)
+		}
+	}
+}
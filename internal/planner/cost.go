@@ -0,0 +1,115 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+// Cost report groupings.
+const (
+	CostByObjective = "objective"
+	CostByKR        = "kr"
+)
+
+// CostOptions selects the plan_item_finished events a cost report covers.
+type CostOptions struct {
+	AuditDB string
+	// Since and Until bound the event time; zero means unbounded.
+	Since time.Time
+	Until time.Time
+	// By is CostByObjective (default) or CostByKR.
+	By string
+}
+
+// CostReport totals agent usage recorded on plan_item_finished events.
+type CostReport struct {
+	By    string         `json:"by"`
+	Since string         `json:"since,omitempty"`
+	Until string         `json:"until,omitempty"`
+	Rows  []CostRow      `json:"rows"`
+	Items int            `json:"items"`
+	Total adapters.Usage `json:"total"`
+}
+
+// CostRow is the usage of one objective or KR. KRID is empty when grouping
+// by objective.
+type CostRow struct {
+	ObjectiveID string         `json:"objective_id"`
+	KRID        string         `json:"kr_id,omitempty"`
+	Items       int            `json:"items"`
+	Usage       adapters.Usage `json:"usage"`
+}
+
+// BuildCostReport aggregates item usage per objective or KR, most tokens
+// first. Items run before usage was recorded are not counted.
+func BuildCostReport(opts CostOptions) (*CostReport, error) {
+	by := opts.By
+	if by == "" {
+		by = CostByObjective
+	}
+	if by != CostByObjective && by != CostByKR {
+		return nil, fmt.Errorf("unknown cost grouping %q (expected %s or %s)", by, CostByObjective, CostByKR)
+	}
+	events, err := audit.ReadEvents(opts.AuditDB, audit.Query{Since: opts.Since, Until: opts.Until, Contains: `"usage"`})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CostReport{By: by, Rows: []CostRow{}}
+	if !opts.Since.IsZero() {
+		report.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+	if !opts.Until.IsZero() {
+		report.Until = opts.Until.UTC().Format(time.RFC3339)
+	}
+	rows := map[string]*CostRow{}
+	for _, ev := range events {
+		if ev.Type != "plan_item_finished" {
+			continue
+		}
+		var payload struct {
+			ObjectiveID string          `json:"objective_id"`
+			KRID        string          `json:"kr_id"`
+			Usage       *adapters.Usage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(ev.PayloadJSON), &payload); err != nil || payload.Usage == nil {
+			continue
+		}
+		key := payload.ObjectiveID
+		if by == CostByKR {
+			key += "\x00" + payload.KRID
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &CostRow{ObjectiveID: payload.ObjectiveID}
+			if by == CostByKR {
+				row.KRID = payload.KRID
+			}
+			rows[key] = row
+		}
+		row.Items++
+		row.Usage.Add(*payload.Usage)
+		report.Items++
+		report.Total.Add(*payload.Usage)
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Usage.TotalTokens != b.Usage.TotalTokens {
+			return a.Usage.TotalTokens > b.Usage.TotalTokens
+		}
+		if a.ObjectiveID != b.ObjectiveID {
+			return a.ObjectiveID < b.ObjectiveID
+		}
+		return a.KRID < b.KRID
+	})
+	return report, nil
+}
@@ -0,0 +1,80 @@
+package planner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+func TestBuildCostReport(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(root, "audit.sqlite")
+	logger := audit.NewLogger(dbPath)
+
+	// Two runs of the test plan record usage on plan_item_finished.
+	for _, tokens := range []int64{1000, 500} {
+		usage := adapters.Usage{InputTokens: tokens - 100, OutputTokens: 100, TotalTokens: tokens, DurationSeconds: 30}
+		adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+			res, err := (&adapters.MockAdapter{}).Run(ctx, cfg)
+			if res != nil {
+				res.Usage = &usage
+			}
+			return res, err
+		}}
+		result, err := RunPlan(context.Background(), RunOptions{
+			PlanPath:    writeTestPlan(t, root),
+			WorkDir:     root,
+			Adapter:     adapter,
+			Timeout:     time.Minute,
+			AuditLogger: logger,
+			RunBaseDir:  filepath.Join(root, "artifacts", "runs"),
+		})
+		if err != nil {
+			t.Fatalf("RunPlan: %v", err)
+		}
+		if result.Usage.TotalTokens != tokens || result.ItemRuns[0].Usage == nil {
+			t.Fatalf("run usage = %+v, item usage = %v", result.Usage, result.ItemRuns[0].Usage)
+		}
+	}
+	if err := logger.LogEvent("scheduler", "plan_item_finished", map[string]any{
+		"objective_id": "OBJ-2",
+		"kr_id":        "KR-9",
+		"usage":        adapters.Usage{TotalTokens: 4000, DurationSeconds: 60},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := BuildCostReport(CostOptions{AuditDB: dbPath})
+	if err != nil {
+		t.Fatalf("BuildCostReport: %v", err)
+	}
+	if report.Items != 3 || report.Total.TotalTokens != 5500 || report.Total.DurationSeconds != 120 {
+		t.Fatalf("totals = %d items, %+v", report.Items, report.Total)
+	}
+	if len(report.Rows) != 2 || report.Rows[0].ObjectiveID != "OBJ-2" || report.Rows[1].ObjectiveID != "OBJ-1" {
+		t.Fatalf("rows = %+v, want OBJ-2 then OBJ-1", report.Rows)
+	}
+	if obj1 := report.Rows[1]; obj1.Items != 2 || obj1.Usage.TotalTokens != 1500 || obj1.Usage.InputTokens != 1300 {
+		t.Fatalf("OBJ-1 = %+v", obj1)
+	}
+
+	byKR, err := BuildCostReport(CostOptions{AuditDB: dbPath, By: CostByKR})
+	if err != nil {
+		t.Fatalf("BuildCostReport by kr: %v", err)
+	}
+	if len(byKR.Rows) != 2 || byKR.Rows[1].KRID != "KR-1" {
+		t.Fatalf("rows by kr = %+v", byKR.Rows)
+	}
+
+	future, err := BuildCostReport(CostOptions{AuditDB: dbPath, Since: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if future.Items != 0 {
+		t.Fatalf("expected no items after --since, got %d", future.Items)
+	}
+}
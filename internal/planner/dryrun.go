@@ -0,0 +1,160 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"okrchestra/internal/okrstore"
+)
+
+// DryRunOptions configures DryRunPlan.
+type DryRunOptions struct {
+	PlanPath string
+	// Adapter is the name of the adapter a real run would use.
+	Adapter string
+	// DryRunBaseDir is where the dry run's item directories are prepared;
+	// default <plan dir>/dry-runs. It is kept apart from the runs dir so a
+	// dry run is never mistaken for, or resumed as, a real run.
+	DryRunBaseDir string
+
+	// Store, when set, is checked for each item's objective and KR.
+	Store *okrstore.Store
+	// MetricKnown, when set, reports whether some provider or the metric
+	// catalog produces a metric key; items measuring other keys are
+	// reported.
+	MetricKnown func(key string) bool
+
+	Language        string
+	PromptDir       string
+	RoleTemplateDir string
+}
+
+// DryRunItem is what a plan run would do for one item.
+type DryRunItem struct {
+	ItemID      string   `json:"item_id"`
+	ObjectiveID string   `json:"objective_id"`
+	KRID        string   `json:"kr_id"`
+	MetricKey   string   `json:"metric_key"`
+	AgentRole   string   `json:"agent_role"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+	ScopePaths  []string `json:"scope_paths,omitempty"`
+	ItemDir     string   `json:"item_dir"`
+	PromptPath  string   `json:"prompt_path"`
+	// Problems are reasons the item no longer matches the OKRs.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// DryRunResult describes a plan run that was prepared but not executed.
+type DryRunResult struct {
+	PlanID   string       `json:"plan_id"`
+	PlanPath string       `json:"plan_path"`
+	Adapter  string       `json:"adapter"`
+	DryRunID string       `json:"dry_run_id"`
+	Dir      string       `json:"dir"`
+	Items    []DryRunItem `json:"items"`
+}
+
+// ProblemCount returns how many problems were found across all items.
+func (r *DryRunResult) ProblemCount() int {
+	n := 0
+	for _, item := range r.Items {
+		n += len(item.Problems)
+	}
+	return n
+}
+
+// DryRunPlan validates a plan against the current OKRs and renders every
+// item's prompt into a fresh directory, as RunPlan would, without invoking
+// the adapter or writing run.json, results, or audit events.
+func DryRunPlan(opts DryRunOptions) (*DryRunResult, error) {
+	planPath, err := ResolvePlanPath(opts.PlanPath)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := LoadPlan(planPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRunID := time.Now().UTC().Format("20060102T150405Z")
+	baseDir := opts.DryRunBaseDir
+	if baseDir == "" {
+		baseDir = filepath.Join(filepath.Dir(planPath), "dry-runs")
+	}
+	dir := filepath.Join(baseDir, dryRunID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure dry run dir: %w", err)
+	}
+
+	result := &DryRunResult{
+		PlanID:   plan.ID,
+		PlanPath: planPath,
+		Adapter:  opts.Adapter,
+		DryRunID: dryRunID,
+		Dir:      dir,
+	}
+	for idx, item := range plan.Items {
+		itemDir := filepath.Join(dir, fmt.Sprintf("item-%04d", idx+1))
+		if err := os.MkdirAll(itemDir, 0o755); err != nil {
+			return nil, fmt.Errorf("ensure item dir: %w", err)
+		}
+		itemData, err := json.MarshalIndent(item, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal item: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(itemDir, "item.json"), append(itemData, '\n'), 0o644); err != nil {
+			return nil, fmt.Errorf("write item: %w", err)
+		}
+		prompt, err := renderPrompt(item, itemDir, opts.Language, opts.PromptDir, opts.RoleTemplateDir)
+		if err != nil {
+			return nil, fmt.Errorf("render prompt for item %s: %w", item.ID, err)
+		}
+		promptPath := filepath.Join(itemDir, "prompt.md")
+		if err := os.WriteFile(promptPath, []byte(prompt), 0o644); err != nil {
+			return nil, fmt.Errorf("write prompt: %w", err)
+		}
+
+		result.Items = append(result.Items, DryRunItem{
+			ItemID:      item.ID,
+			ObjectiveID: item.ObjectiveID,
+			KRID:        item.KRID,
+			MetricKey:   item.ExpectedMetricChange.MetricKey,
+			AgentRole:   item.AgentRole,
+			DependsOn:   item.DependsOn,
+			ScopePaths:  item.ScopePaths,
+			ItemDir:     itemDir,
+			PromptPath:  promptPath,
+			Problems:    itemProblems(item, opts.Store, opts.MetricKnown),
+		})
+	}
+	return result, nil
+}
+
+// itemProblems reports how item has drifted from the OKRs in store since
+// the plan was generated.
+func itemProblems(item PlanItem, store *okrstore.Store, metricKnown func(string) bool) []string {
+	var problems []string
+	metricKey := item.ExpectedMetricChange.MetricKey
+	if store != nil {
+		if _, ok := store.ObjectiveLookup(item.ObjectiveID); !ok {
+			problems = append(problems, fmt.Sprintf("objective %s no longer exists", item.ObjectiveID))
+		}
+		if rec, ok := store.KeyResultLookup(item.KRID); !ok {
+			problems = append(problems, fmt.Sprintf("key result %s no longer exists", item.KRID))
+		} else {
+			if rec.Objective.ID != item.ObjectiveID {
+				problems = append(problems, fmt.Sprintf("key result %s now belongs to objective %s", item.KRID, rec.Objective.ID))
+			}
+			if rec.KeyResult.MetricKey != metricKey {
+				problems = append(problems, fmt.Sprintf("key result %s now measures %s, not %s", item.KRID, rec.KeyResult.MetricKey, metricKey))
+			}
+		}
+	}
+	if metricKnown != nil && !metricKnown(metricKey) {
+		problems = append(problems, fmt.Sprintf("no metric provider or catalog entry produces %s", metricKey))
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+package planner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/okrstore"
+)
+
+func TestDryRunPlan(t *testing.T) {
+	okrsDir, plansDir := setupStrategyTest(t)
+	store, err := okrstore.LoadFromDir(okrsDir)
+	if err != nil {
+		t.Fatalf("load okrs: %v", err)
+	}
+	plan := Plan{ID: "plan-dry", AsOf: "2025-03-01", Items: []PlanItem{
+		{ID: "ITEM-1", ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do it", AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "m1", Direction: "increase", Target: 10}},
+		{ID: "ITEM-2", ObjectiveID: "OBJ-1", KRID: "KR-9", Task: "gone", AgentRole: "engineer", DependsOn: []string{"ITEM-1"},
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "m9", Direction: "increase", Target: 10}},
+	}}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(plansDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(plansDir, "plan.json")
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := DryRunPlan(DryRunOptions{
+		PlanPath:    plansDir,
+		Adapter:     "mock",
+		Store:       store,
+		MetricKnown: func(key string) bool { return key == "m1" },
+	})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if res.PlanID != "plan-dry" || res.Adapter != "mock" || len(res.Items) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	if got := res.Items[0].Problems; len(got) != 0 {
+		t.Fatalf("ITEM-1 problems = %v", got)
+	}
+	problems := strings.Join(res.Items[1].Problems, "; ")
+	if res.ProblemCount() != 2 || !strings.Contains(problems, "KR-9 no longer exists") || !strings.Contains(problems, "produces m9") {
+		t.Fatalf("ITEM-2 problems = %v", res.Items[1].Problems)
+	}
+
+	prompt, err := os.ReadFile(res.Items[0].PromptPath)
+	if err != nil || !strings.Contains(string(prompt), "do it") {
+		t.Fatalf("prompt = %q (err %v)", prompt, err)
+	}
+	if _, err := os.Stat(filepath.Join(res.Items[0].ItemDir, "item.json")); err != nil {
+		t.Fatalf("item.json: %v", err)
+	}
+	if filepath.Dir(res.Dir) != filepath.Join(plansDir, "dry-runs") {
+		t.Fatalf("dry run dir = %s", res.Dir)
+	}
+	if _, err := os.Stat(filepath.Join(res.Dir, "run.json")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote run.json")
+	}
+	if _, err := os.Stat(filepath.Join(plansDir, "runs")); !os.IsNotExist(err) {
+		t.Fatalf("dry run created a runs dir")
+	}
+}
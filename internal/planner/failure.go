@@ -0,0 +1,146 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"okrchestra/internal/adapters"
+)
+
+// FailureClass categorizes why a plan item failed so reports and retry
+// policies can treat adapter crashes differently from bad agent output.
+type FailureClass string
+
+const (
+	// FailureAdapterError means the adapter failed and left no valid result.json.
+	FailureAdapterError FailureClass = "adapter_error"
+	// FailureTimeout means the adapter was stopped by the run timeout.
+	FailureTimeout FailureClass = "timeout"
+	// FailureResultInvalid means the agent exited cleanly but result.json is missing or malformed.
+	FailureResultInvalid FailureClass = "result_invalid"
+	// FailureGuardrailViolation means the agent broke a guardrail, such as editing okrs/.
+	FailureGuardrailViolation FailureClass = "guardrail_violation"
+	// FailureVerificationFailed means the agent's evidence did not hold up on verification.
+	FailureVerificationFailed FailureClass = "verification_failed"
+	// FailureHookFailed means a pre_item or post_item hook with the abort policy failed.
+	FailureHookFailed FailureClass = "hook_failed"
+	// FailureSetupFailed means the item could not be prepared before its agent ran.
+	FailureSetupFailed FailureClass = "setup_failed"
+)
+
+// FailureClasses lists every class in report order.
+var FailureClasses = []FailureClass{
+	FailureAdapterError,
+	FailureTimeout,
+	FailureResultInvalid,
+	FailureGuardrailViolation,
+	FailureVerificationFailed,
+	FailureHookFailed,
+	FailureSetupFailed,
+}
+
+const FailureSchemaVersion = 1
+
+// ItemFailure is the failure record stored as failure.json in the item dir.
+type ItemFailure struct {
+	SchemaVersion int          `json:"schema_version"`
+	Class         FailureClass `json:"class"`
+	RunID         string       `json:"run_id"`
+	ItemDir       string       `json:"item_dir"`
+	PlanItemID    string       `json:"plan_item_id"`
+	KRID          string       `json:"kr_id,omitempty"`
+	Message       string       `json:"message"`
+	FailedAt      string       `json:"failed_at"`
+}
+
+// ItemError is returned by RunPlan when a plan item fails. Use ClassifyFailure
+// to recover the class from a wrapped error.
+type ItemError struct {
+	Class   FailureClass
+	ItemID  string
+	ItemDir string
+	Err     error
+}
+
+func (e *ItemError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyFailure returns the failure class carried by err, if any.
+func ClassifyFailure(err error) (FailureClass, bool) {
+	var itemErr *ItemError
+	if errors.As(err, &itemErr) {
+		return itemErr.Class, true
+	}
+	return "", false
+}
+
+// classifyAdapterFailure distinguishes timeouts from other adapter errors.
+func classifyAdapterFailure(result *adapters.RunResult, err error) FailureClass {
+	if errors.Is(err, context.DeadlineExceeded) || (result != nil && result.ExitCode == 124) {
+		return FailureTimeout
+	}
+	return FailureAdapterError
+}
+
+// WriteFailure records a failure in itemDir and returns the failure path.
+func WriteFailure(itemDir string, failure ItemFailure) (string, error) {
+	if failure.SchemaVersion == 0 {
+		failure.SchemaVersion = FailureSchemaVersion
+	}
+	if failure.FailedAt == "" {
+		failure.FailedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	path := filepath.Join(itemDir, "failure.json")
+	data, err := json.MarshalIndent(failure, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal failure: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write failure: %w", err)
+	}
+	return path, nil
+}
+
+// LoadFailures reads every failure.json under runsDir (<run>/<item>/failure.json),
+// ordered by run then item.
+func LoadFailures(runsDir string) ([]ItemFailure, error) {
+	paths, err := filepath.Glob(filepath.Join(runsDir, "*", "item-*", "failure.json"))
+	if err != nil {
+		return nil, fmt.Errorf("scan runs: %w", err)
+	}
+	sort.Strings(paths)
+	var failures []ItemFailure
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		var failure ItemFailure
+		if err := json.Unmarshal(data, &failure); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		failures = append(failures, failure)
+	}
+	return failures, nil
+}
+
+// CountFailures tallies failures by class.
+func CountFailures(failures []ItemFailure) map[FailureClass]int {
+	counts := map[FailureClass]int{}
+	for _, failure := range failures {
+		counts[failure.Class]++
+	}
+	return counts
+}
@@ -0,0 +1,200 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+// stubAdapter runs fn in place of an agent.
+type stubAdapter struct {
+	fn func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error)
+}
+
+func (a *stubAdapter) Name() string { return "stub" }
+
+func (a *stubAdapter) Run(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+	return a.fn(ctx, cfg)
+}
+
+func TestRunPlanClassifiesFailures(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error)
+		want FailureClass
+	}{
+		{
+			name: "adapter crash",
+			fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				return &adapters.RunResult{ExitCode: 2}, errors.New("exit status 2")
+			},
+			want: FailureAdapterError,
+		},
+		{
+			name: "timeout",
+			fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				return &adapters.RunResult{ExitCode: 124}, fmt.Errorf("timed out: %w", context.DeadlineExceeded)
+			},
+			want: FailureTimeout,
+		},
+		{
+			name: "missing result",
+			fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				return &adapters.RunResult{}, nil
+			},
+			want: FailureResultInvalid,
+		},
+		{
+			name: "okrs edit",
+			fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				if err := os.WriteFile(filepath.Join(cfg.WorkDir, "okrs", "org.yml"), []byte("tampered\n"), 0o644); err != nil {
+					return nil, err
+				}
+				return &adapters.RunResult{}, nil
+			},
+			want: FailureGuardrailViolation,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(root, "okrs", "org.yml"), []byte("scope: org\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			planPath := writeTestPlan(t, root)
+			runsDir := filepath.Join(root, "artifacts", "runs")
+
+			result, err := RunPlan(context.Background(), RunOptions{
+				PlanPath:    planPath,
+				WorkDir:     root,
+				Adapter:     &stubAdapter{fn: tc.fn},
+				Timeout:     time.Minute,
+				AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+				RunBaseDir:  runsDir,
+			})
+			if err == nil {
+				t.Fatal("expected RunPlan to fail")
+			}
+			class, ok := ClassifyFailure(fmt.Errorf("wrapped: %w", err))
+			if !ok || class != tc.want {
+				t.Fatalf("class = %q (ok=%v), want %q; err: %v", class, ok, tc.want, err)
+			}
+			if len(result.Failures) != 1 || result.Failures[0].Class != tc.want {
+				t.Fatalf("result failures = %+v", result.Failures)
+			}
+
+			failures, err := LoadFailures(runsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failures) != 1 || failures[0].PlanItemID != "ITEM-1" || failures[0].ItemDir != "item-0001" {
+				t.Fatalf("stored failures = %+v", failures)
+			}
+			if counts := CountFailures(failures); counts[tc.want] != 1 {
+				t.Fatalf("counts = %v", counts)
+			}
+		})
+	}
+}
+
+func writeTestPlan(t *testing.T, root string, edits ...func(*PlanItem)) string {
+	t.Helper()
+	plan := Plan{
+		ID:   "plan-test",
+		AsOf: "2025-01-15",
+		Items: []PlanItem{{
+			ID:          "ITEM-1",
+			ObjectiveID: "OBJ-1",
+			KRID:        "KR-1",
+			Task:        "do the thing",
+			AgentRole:   "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{
+				MetricKey: "ci.pass_rate",
+				Direction: "increase",
+				Target:    1,
+			},
+		}},
+	}
+	for _, edit := range edits {
+		edit(&plan.Items[0])
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "plan.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// lockedBuffer is a bytes.Buffer safe for a transcript follower to write to.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunPlanSetupFailureStopsFollowing(t *testing.T) {
+	root := t.TempDir()
+	planPath := writeTestPlan(t, root)
+	roleDir := filepath.Join(root, "roles")
+	writeFile(t, filepath.Join(roleDir, "engineer.tmpl"), "{{ .Broken\n")
+
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		return &adapters.RunResult{}, nil
+	}}
+	follow := &lockedBuffer{}
+	_, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:          planPath,
+		WorkDir:           root,
+		Adapter:           adapter,
+		Timeout:           time.Minute,
+		AuditLogger:       audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:        filepath.Join(root, "artifacts", "runs"),
+		RoleTemplateDir:   roleDir,
+		FollowTranscripts: true,
+		FollowWriter:      follow,
+	})
+	var itemErr *ItemError
+	if !errors.As(err, &itemErr) || itemErr.Class != FailureSetupFailed {
+		t.Fatalf("err = %v, want a %s item error", err, FailureSetupFailed)
+	}
+	if _, err := os.Stat(filepath.Join(itemErr.ItemDir, "failure.json")); err != nil {
+		t.Fatalf("failure not recorded: %v", err)
+	}
+
+	// A follower left running would pick up a transcript written now.
+	writeFile(t, filepath.Join(itemErr.ItemDir, "transcript.log"), "late output\n")
+	time.Sleep(300 * time.Millisecond)
+	if strings.Contains(follow.String(), "late output") {
+		t.Fatalf("transcript still followed after the item failed:\n%s", follow.String())
+	}
+}
@@ -1,13 +1,17 @@
 package planner
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/metrics"
 	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
 )
 
 type GenerateOptions struct {
@@ -17,6 +21,43 @@ type GenerateOptions struct {
 	ObjectiveID   string
 	KRID          string
 	AgentRole     string
+	// CacheDir enables the okrstore load cache when set.
+	CacheDir string
+	// RunsDir, when set, is scanned for rejected run items; each pending
+	// rejection becomes a retry item carrying the reviewer's comment.
+	RunsDir string
+	// SnapshotsDir is where the baseline preflight looks up the current
+	// value of KRs with `baseline: null`.
+	SnapshotsDir string
+	// WorkspaceRoot, when set, stores OKRsDir in the plan relative to it.
+	WorkspaceRoot string
+	// Portfolio spreads Items plan items across org objectives by strategic
+	// weight and remaining progress instead of targeting a single KR.
+	Portfolio bool
+	// Items is the plan size for portfolio mode and for the at_risk and
+	// round_robin strategies (default DefaultPortfolioItems).
+	Items int
+	// Strategy is StrategyFirst (the default), StrategyAtRisk, or
+	// StrategyRoundRobin; see strategy.go.
+	Strategy string
+	// ScoreReportPath is the KR score report StrategyAtRisk ranks by.
+	ScoreReportPath string
+	// SuccessCriteria is recorded on the plan for outcome tracking.
+	SuccessCriteria *SuccessCriteria
+	// Period, when set, limits planning to objectives in that OKR period.
+	Period string
+	// Adapter, when set, is asked to rewrite the template items into
+	// concrete ones from the OKRs, culture docs, latest metrics, and recent
+	// runs; see assist.go.
+	Adapter adapters.AgentAdapter
+	// AdapterTimeout limits the Adapter run (0 means no limit).
+	AdapterTimeout time.Duration
+	// CultureDir holds the culture docs shown to Adapter.
+	CultureDir string
+	// Language and PromptDir select the Adapter prompt template, as for
+	// RunOptions.
+	Language  string
+	PromptDir string
 }
 
 type GenerateResult struct {
@@ -37,59 +78,95 @@ func GeneratePlan(opts GenerateOptions) (GenerateResult, error) {
 	if opts.AgentRole == "" {
 		opts.AgentRole = "software_engineer"
 	}
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = StrategyFirst
+	case StrategyFirst, StrategyAtRisk, StrategyRoundRobin:
+	default:
+		return GenerateResult{}, fmt.Errorf("unknown plan strategy %q (expected first, at_risk, or round_robin)", opts.Strategy)
+	}
+	if opts.Strategy != StrategyFirst && (opts.Portfolio || opts.KRID != "") {
+		return GenerateResult{}, fmt.Errorf("the %s strategy cannot be combined with portfolio planning or a KR target", opts.Strategy)
+	}
 
-	store, err := okrstore.LoadFromDir(opts.OKRsDir)
+	store, err := okrstore.LoadFromDirCached(opts.OKRsDir, opts.CacheDir)
 	if err != nil {
 		return GenerateResult{}, err
 	}
+	if opts.Period != "" {
+		store = store.InPeriod(opts.Period)
+		if len(store.Periods()) == 0 {
+			return GenerateResult{}, fmt.Errorf("no objectives in period %s", opts.Period)
+		}
+	}
 
-	obj, kr, err := selectOrgKR(store, opts.ObjectiveID, opts.KRID)
+	var items []PlanItem
+	var allocation *Allocation
+	var prioritization *Prioritization
+	if opts.Portfolio {
+		if opts.ObjectiveID != "" || opts.KRID != "" {
+			return GenerateResult{}, fmt.Errorf("portfolio planning cannot be combined with an objective or KR target")
+		}
+		items, allocation, err = allocatePortfolio(store, opts.Items, opts.AgentRole)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+	} else if opts.Strategy != StrategyFirst {
+		items, prioritization, err = strategyPlanItems(store, opts)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+	} else {
+		obj, kr, err := selectOrgKR(store, opts.ObjectiveID, opts.KRID)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+		if kr.MetricKey == "" {
+			return GenerateResult{}, fmt.Errorf("selected KR %s has no metric_key", kr.ID)
+		}
+		items = []PlanItem{krItem("ITEM-1", obj, kr, opts.AgentRole)}
+	}
+	if err := preflightBaselines(store, items, opts.SnapshotsDir); err != nil {
+		return GenerateResult{}, err
+	}
+	tactics, err := LoadUnsuccessfulTactics(opts.OutputBaseDir)
 	if err != nil {
 		return GenerateResult{}, err
 	}
-	if kr.MetricKey == "" {
-		return GenerateResult{}, fmt.Errorf("selected KR %s has no metric_key", kr.ID)
+	for i := range items {
+		items[i].AvoidTactics = tactics[items[i].KRID]
 	}
-
-	direction := "increase"
-	if kr.Target < kr.Baseline {
-		direction = "decrease"
+	generatedBy := ""
+	if opts.Adapter != nil {
+		if items, err = assistItems(context.Background(), store, items, opts); err != nil {
+			return GenerateResult{}, err
+		}
+		generatedBy = opts.Adapter.Name()
 	}
-	delta := kr.Target - kr.Baseline
 
 	asOfStr := opts.AsOf.UTC().Format("2006-01-02")
 	plan := Plan{
-		ID:          fmt.Sprintf("PLAN-%s", asOfStr),
-		AsOf:        asOfStr,
-		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
-		OKRsDir:     opts.OKRsDir,
-		Items: []PlanItem{
-			{
-				ID:          "ITEM-1",
-				ObjectiveID: obj.ID,
-				KRID:        kr.ID,
-				Hypothesis: fmt.Sprintf(
-					"If we execute this task, %s will %s from %g toward %g (Δ %g).",
-					kr.MetricKey, direction, kr.Baseline, kr.Target, delta,
-				),
-				Task:      fmt.Sprintf("Deliver work that advances KR %s: %s", kr.ID, kr.Description),
-				AgentRole: opts.AgentRole,
-				ExpectedMetricChange: ExpectedMetricChange{
-					MetricKey:  kr.MetricKey,
-					Direction:  direction,
-					Baseline:   kr.Baseline,
-					Target:     kr.Target,
-					Delta:      delta,
-					Rationale:  kr.Description,
-					Confidence: kr.Confidence,
-				},
-				EvidencePlan: []string{
-					fmt.Sprintf("Capture evidence for %s and attach references in result.json.", kr.MetricKey),
-					"Run `okrchestra kr measure` to record a fresh metric snapshot.",
-					"Run `okrchestra kr score` to verify progress against baseline/target.",
-				},
-			},
-		},
+		ID:              fmt.Sprintf("PLAN-%s", asOfStr),
+		AsOf:            asOfStr,
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
+		GeneratedBy:     generatedBy,
+		OKRsDir:         okrsDirForPlan(opts),
+		Items:           items,
+		Allocation:      allocation,
+		Prioritization:  prioritization,
+		SuccessCriteria: opts.SuccessCriteria,
+		Status:          PlanDraft,
+	}
+
+	if opts.RunsDir != "" {
+		rejections, err := PendingRejections(opts.RunsDir)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+		for _, rejection := range rejections {
+			id := fmt.Sprintf("ITEM-%d", len(plan.Items)+1)
+			plan.Items = append(plan.Items, retryItem(id, rejection))
+		}
 	}
 
 	if err := ValidatePlan(plan); err != nil {
@@ -100,16 +177,90 @@ func GeneratePlan(opts GenerateOptions) (GenerateResult, error) {
 	if err := os.MkdirAll(filepath.Dir(planPath), 0o755); err != nil {
 		return GenerateResult{}, fmt.Errorf("ensure plan dir: %w", err)
 	}
-	data, err := json.MarshalIndent(plan, "", "  ")
+	if err := WritePlan(planPath, plan); err != nil {
+		return GenerateResult{}, err
+	}
+
+	return GenerateResult{Plan: plan, PlanPath: planPath}, nil
+}
+
+// krItem builds the plan item that works toward a single KR.
+func krItem(id string, obj okrstore.Objective, kr okrstore.KeyResult, agentRole string) PlanItem {
+	direction := "increase"
+	if kr.Target < kr.Baseline {
+		direction = "decrease"
+	}
+	delta := kr.Target - kr.Baseline
+
+	return PlanItem{
+		ID:          id,
+		ObjectiveID: obj.ID,
+		KRID:        kr.ID,
+		Hypothesis: fmt.Sprintf(
+			"If we execute this task, %s will %s from %g toward %g (Δ %g).",
+			kr.MetricKey, direction, kr.Baseline, kr.Target, delta,
+		),
+		Task:      fmt.Sprintf("Deliver work that advances KR %s: %s", kr.ID, kr.Description),
+		AgentRole: agentRole,
+		ExpectedMetricChange: ExpectedMetricChange{
+			MetricKey:  kr.MetricKey,
+			Direction:  direction,
+			Baseline:   kr.Baseline,
+			Target:     kr.Target,
+			Delta:      delta,
+			Rationale:  kr.Description,
+			Confidence: kr.Confidence,
+		},
+		EvidencePlan: []string{
+			fmt.Sprintf("Capture evidence for %s and attach references in result.json.", kr.MetricKey),
+			"Run `okrchestra kr measure` to record a fresh metric snapshot.",
+			"Run `okrchestra kr score` to verify progress against baseline/target.",
+		},
+	}
+}
+
+// preflightBaselines refuses to plan against KRs whose baseline is still
+// null: a guessed 0 would distort the hypothesis, delta, and percent-to-target.
+// The error carries the detected value so it can be proposed as-is.
+func preflightBaselines(store *okrstore.Store, items []PlanItem, snapshotsDir string) error {
+	pending := map[string]bool{}
+	for _, item := range items {
+		if rec, ok := store.KeyResultLookup(item.KRID); ok && rec.KeyResult.BaselinePending {
+			pending[item.KRID] = true
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	detections, err := metrics.DetectBaselines(store, snapshotsDir)
 	if err != nil {
-		return GenerateResult{}, fmt.Errorf("marshal plan: %w", err)
+		return fmt.Errorf("detect baselines: %w", err)
 	}
-	data = append(data, '\n')
-	if err := os.WriteFile(planPath, data, 0o644); err != nil {
-		return GenerateResult{}, fmt.Errorf("write plan: %w", err)
+	var parts []string
+	for _, d := range detections {
+		if !pending[d.KRID] {
+			continue
+		}
+		if d.Found {
+			parts = append(parts, fmt.Sprintf("%s (latest %s = %g as of %s)", d.KRID, d.MetricKey, d.Value, d.AsOf))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (no snapshot records %s yet; run `okrchestra kr measure`)", d.KRID, d.MetricKey))
+		}
 	}
+	return fmt.Errorf("baseline is null for %s; run `okrchestra kr baseline detect` to propose it", strings.Join(parts, ", "))
+}
 
-	return GenerateResult{Plan: plan, PlanPath: planPath}, nil
+func okrsDirForPlan(opts GenerateOptions) string {
+	return relPathForPlan(opts, opts.OKRsDir)
+}
+
+// relPathForPlan returns path relative to the workspace root, when set.
+func relPathForPlan(opts GenerateOptions, path string) string {
+	if opts.WorkspaceRoot == "" {
+		return path
+	}
+	ws := &workspace.Workspace{Root: opts.WorkspaceRoot}
+	return ws.RelPath(path)
 }
 
 func selectOrgKR(store *okrstore.Store, objectiveID string, krID string) (okrstore.Objective, okrstore.KeyResult, error) {
@@ -0,0 +1,248 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// BranchPrefix starts the branch each plan item is committed to.
+const BranchPrefix = "okr/"
+
+// GitOptions commits each succeeded plan item to its own branch and
+// optionally opens a pull request for it.
+type GitOptions struct {
+	// Branches runs each item on okr/<plan-id>/<item-id>, branched from
+	// the commit checked out when the run started, and commits the agent's
+	// changes there.
+	Branches bool
+	// PullRequests also pushes each committed branch to Remote and opens a
+	// pull request against Base with gh. It implies Branches.
+	PullRequests bool
+	// Remote is the remote item branches are pushed to (default origin).
+	Remote string
+	// Base is the branch pull requests target; empty means the branch
+	// checked out when the run started.
+	Base string
+}
+
+// Enabled reports whether items run on their own branches.
+func (g GitOptions) Enabled() bool {
+	return g.Branches || g.PullRequests
+}
+
+// GitResult is where a plan item's changes were committed.
+type GitResult struct {
+	Branch string `json:"branch"`
+	// Commit is empty when the agent changed nothing.
+	Commit string `json:"commit,omitempty"`
+	PRURL  string `json:"pr_url,omitempty"`
+	// PRError is why no pull request was opened for a commit, when one was
+	// asked for. It does not fail the item.
+	PRError string `json:"pr_error,omitempty"`
+}
+
+var unsafeRefChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ItemBranch returns the branch a plan item is committed to. Characters
+// git does not allow in ref names are replaced with dashes.
+func ItemBranch(planID, itemID string) string {
+	clean := func(s string) string {
+		s = strings.Trim(unsafeRefChars.ReplaceAllString(s, "-"), ".-")
+		s = strings.ReplaceAll(s, "..", "-")
+		if s == "" {
+			return "item"
+		}
+		return s
+	}
+	return BranchPrefix + clean(planID) + "/" + clean(itemID)
+}
+
+// gitRun is the repository state a plan run branches its items from.
+type gitRun struct {
+	opts     GitOptions
+	repoRoot string
+	// startRef is the branch checked out when the run started, or its
+	// commit when HEAD was detached; unscoped items return to it.
+	startRef   string
+	detached   bool
+	baseCommit string
+	// exclude is the runs dir relative to the repository root, kept out of
+	// commits when it is inside the repository.
+	exclude string
+}
+
+// startGitRun checks that workDir is in a git repository without
+// uncommitted changes to tracked files, which would otherwise end up in an
+// item's commit, and records the commit items branch from.
+func startGitRun(ctx context.Context, opts GitOptions, workDir, runDir string) (*gitRun, error) {
+	top, err := gitOutput(ctx, workDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("branches require a git repository: %w", err)
+	}
+	g := &gitRun{opts: opts, repoRoot: strings.TrimSpace(top)}
+	if g.opts.Remote == "" {
+		g.opts.Remote = "origin"
+	}
+	status, err := gitOutput(ctx, g.repoRoot, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(status) != "" {
+		return nil, fmt.Errorf("%s has uncommitted changes; commit or stash them before running items on branches", g.repoRoot)
+	}
+	head, err := gitOutput(ctx, g.repoRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	g.baseCommit = strings.TrimSpace(head)
+	if branch, err := gitOutput(ctx, g.repoRoot, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		g.startRef = strings.TrimSpace(branch)
+	} else {
+		g.startRef, g.detached = g.baseCommit, true
+	}
+	if g.opts.PullRequests && g.opts.Base == "" {
+		if g.detached {
+			return nil, fmt.Errorf("pull requests need a base branch: HEAD is detached")
+		}
+		g.opts.Base = g.startRef
+	}
+
+	repoRoot := g.repoRoot
+	if resolved, err := filepath.EvalSymlinks(repoRoot); err == nil {
+		repoRoot = resolved
+	}
+	runsDir := filepath.Dir(runDir)
+	if resolved, err := filepath.EvalSymlinks(runsDir); err == nil {
+		runsDir = resolved
+	}
+	if rel, err := filepath.Rel(repoRoot, runsDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// git add refuses to exclude an ignored path by name.
+		if _, err := gitOutput(ctx, g.repoRoot, "check-ignore", "--quiet", rel); err != nil {
+			g.exclude = filepath.ToSlash(rel)
+		}
+	}
+	return g, nil
+}
+
+// startItem checks out a fresh branch at the base commit in dir, the
+// repository root or an item's scoped worktree. An existing branch of the
+// same name, from an earlier attempt, is reset.
+func (g *gitRun) startItem(ctx context.Context, dir, branch string) error {
+	if _, err := gitOutput(ctx, dir, "switch", "--quiet", "-C", branch, g.baseCommit); err != nil {
+		return fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// finishItem returns the repository root to the branch the run started
+// on. A failed item's leftover changes are stashed first, so the next item
+// starts clean; they are never committed.
+func (g *gitRun) finishItem(ctx context.Context, failed bool, label string) error {
+	if failed {
+		status, err := gitOutput(ctx, g.repoRoot, append([]string{"status", "--porcelain", "--"}, g.pathspec()...)...)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(status) != "" {
+			args := append([]string{"stash", "push", "--quiet", "--include-untracked", "-m", label, "--"}, g.pathspec()...)
+			if _, err := gitOutput(ctx, g.repoRoot, args...); err != nil {
+				return fmt.Errorf("stash failed item changes: %w", err)
+			}
+		}
+	}
+	args := []string{"switch", "--quiet", g.startRef}
+	if g.detached {
+		args = []string{"switch", "--quiet", "--detach", g.startRef}
+	}
+	if _, err := gitOutput(ctx, g.repoRoot, args...); err != nil {
+		return fmt.Errorf("return to %s: %w", g.startRef, err)
+	}
+	return nil
+}
+
+// pathspec is every path in the repository but the runs dir.
+func (g *gitRun) pathspec() []string {
+	spec := []string{":/"}
+	if g.exclude != "" {
+		spec = append(spec, ":(top,exclude)"+g.exclude)
+	}
+	return spec
+}
+
+// commitItem commits everything the agent changed in dir, the repository
+// root or the item's scoped worktree, with a message naming the item's
+// objective and KR. It returns an empty commit when nothing changed.
+func (g *gitRun) commitItem(ctx context.Context, dir string, plan Plan, item PlanItem, runID string) (string, error) {
+	if _, err := gitOutput(ctx, dir, append([]string{"add", "--all", "--"}, g.pathspec()...)...); err != nil {
+		return "", fmt.Errorf("stage item changes: %w", err)
+	}
+	staged, err := gitOutput(ctx, dir, "diff", "--cached", "--name-only")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(staged) == "" {
+		return "", nil
+	}
+	subject, body := commitMessage(plan, item, runID)
+	if _, err := gitOutput(ctx, dir, "commit", "--quiet", "-m", subject, "-m", body); err != nil {
+		return "", fmt.Errorf("commit item changes: %w", err)
+	}
+	head, err := gitOutput(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(head), nil
+}
+
+// openPullRequest pushes branch and opens a pull request for it with gh,
+// returning its URL.
+func (g *gitRun) openPullRequest(ctx context.Context, dir, branch string, plan Plan, item PlanItem, runID string) (string, error) {
+	if _, err := gitOutput(ctx, dir, "push", "--quiet", "--force-with-lease", "--set-upstream", g.opts.Remote, branch); err != nil {
+		return "", fmt.Errorf("push %s: %w", branch, err)
+	}
+	title, trailers := commitMessage(plan, item, runID)
+	body := strings.TrimSpace(item.Task)
+	if item.Hypothesis != "" {
+		body += "\n\nHypothesis: " + item.Hypothesis
+	}
+	body += "\n\n" + trailers
+	cmd := exec.CommandContext(ctx, "gh", "pr", "create", "--head", branch, "--base", g.opts.Base, "--title", title, "--body", body)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gh pr create: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	// gh prints the new pull request's URL last.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// commitMessage returns the subject and trailer block of an item's commit.
+func commitMessage(plan Plan, item PlanItem, runID string) (string, string) {
+	task := strings.TrimSpace(item.Task)
+	if line, _, ok := strings.Cut(task, "\n"); ok {
+		task = strings.TrimSpace(line)
+	}
+	subject := item.KRID + ": " + task
+	if runes := []rune(subject); len(runes) > 72 {
+		subject = string(runes[:71]) + "…"
+	}
+	change := item.ExpectedMetricChange
+	trailers := []string{
+		"Objective: " + item.ObjectiveID,
+		"Key-Result: " + item.KRID,
+	}
+	if change.MetricKey != "" {
+		trailers = append(trailers, fmt.Sprintf("Metric: %s (%s to %g)", change.MetricKey, change.Direction, change.Target))
+	}
+	trailers = append(trailers,
+		"Plan: "+plan.ID,
+		"Plan-Item: "+item.ID,
+		"Run: "+runID,
+	)
+	return subject, strings.Join(trailers, "\n")
+}
@@ -0,0 +1,196 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+func TestItemBranch(t *testing.T) {
+	for _, tc := range []struct{ plan, item, want string }{
+		{"plan-2025-01-15", "ITEM-1", "okr/plan-2025-01-15/ITEM-1"},
+		{"q1 plan", "fix: flaky..test", "okr/q1-plan/fix-flaky-test"},
+		{"", "..", "okr/item/item"},
+	} {
+		if got := ItemBranch(tc.plan, tc.item); got != tc.want {
+			t.Errorf("ItemBranch(%q, %q) = %q, want %q", tc.plan, tc.item, got, tc.want)
+		}
+	}
+}
+
+func TestRunPlanCommitsItemsToBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gh is an sh script")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	root := t.TempDir()
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	for name, data := range map[string]string{
+		"okrs/org.yml": "objectives: []\n",
+		"README.md":    "readme\n",
+		".gitignore":   "audit.sqlite*\nplan.json\n",
+	} {
+		writeFile(t, filepath.Join(root, name), data)
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git(root, "init", "-q", "--bare", remote)
+	git(root, "init", "-q", "-b", "main")
+	git(root, "add", "-A")
+	git(root, "commit", "-q", "-m", "init")
+	git(root, "remote", "add", "origin", remote)
+
+	// gh records its arguments and prints a pull request URL.
+	bin := t.TempDir()
+	ghArgs := filepath.Join(bin, "args")
+	writeFile(t, filepath.Join(bin, "gh"), "#!/bin/sh\necho \"$@\" > "+ghArgs+"\necho https://github.com/example/app/pull/7\n")
+	if err := os.Chmod(filepath.Join(bin, "gh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	planPath := writeHookPlan(t, root, "ITEM-1", "ITEM-2")
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		if cfg.Env["OKRCHESTRA_GIT_BRANCH"] != ItemBranch("plan-hooks", cfg.Env["OKRCHESTRA_PLAN_ITEM_ID"]) {
+			t.Errorf("OKRCHESTRA_GIT_BRANCH = %q", cfg.Env["OKRCHESTRA_GIT_BRANCH"])
+		}
+		if cfg.Env["OKRCHESTRA_PLAN_ITEM_ID"] == "ITEM-2" {
+			writeFile(t, filepath.Join(cfg.WorkDir, "half-done.txt"), "wip\n")
+			return &adapters.RunResult{}, errors.New("agent crashed")
+		}
+		writeFile(t, filepath.Join(cfg.WorkDir, "feature.txt"), "feature\n")
+		return &adapters.RunResult{}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+	}}
+
+	res, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:        planPath,
+		WorkDir:         root,
+		Adapter:         adapter,
+		Timeout:         time.Minute,
+		AuditLogger:     audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:      filepath.Join(root, "runs"),
+		ContinueOnError: true,
+		Git:             GitOptions{PullRequests: true},
+	})
+	if err == nil {
+		t.Fatal("expected ITEM-2 to fail")
+	}
+	if len(res.Failures) != 1 {
+		t.Fatalf("err = %v, failures = %+v", err, res.Failures)
+	}
+	if len(res.ItemRuns) != 1 || res.ItemRuns[0].Git == nil {
+		t.Fatalf("item runs = %+v, err = %v", res.ItemRuns, err)
+	}
+	got := res.ItemRuns[0].Git
+	if got.Branch != "okr/plan-hooks/ITEM-1" || got.Commit == "" || got.PRURL != "https://github.com/example/app/pull/7" || got.PRError != "" {
+		t.Fatalf("git result = %+v", got)
+	}
+
+	message := git(root, "log", "-1", "--format=%B", got.Branch)
+	for _, want := range []string{"KR-1: do ITEM-1", "Objective: OBJ-1", "Key-Result: KR-1", "Plan-Item: ITEM-1", "Run: " + res.RunID} {
+		if !strings.Contains(message, want) {
+			t.Errorf("commit message lacks %q:\n%s", want, message)
+		}
+	}
+	if files := git(root, "show", "--format=", "--name-only", got.Commit); files != "feature.txt" {
+		t.Errorf("committed files = %q, want feature.txt", files)
+	}
+	if pushed := git(root, "--git-dir", remote, "rev-parse", got.Branch); pushed != got.Commit {
+		t.Errorf("remote branch at %s, want %s", pushed, got.Commit)
+	}
+	args, err := os.ReadFile(ghArgs)
+	if err != nil || !strings.Contains(string(args), "--head okr/plan-hooks/ITEM-1 --base main") {
+		t.Errorf("gh args = %q, %v", args, err)
+	}
+
+	// The work tree is back on main, with the failed item's changes stashed
+	// and nothing committed for it.
+	if branch := git(root, "branch", "--show-current"); branch != "main" {
+		t.Errorf("work tree on %s, want main", branch)
+	}
+	for _, name := range []string{"feature.txt", "half-done.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left in the work tree: %v", name, err)
+		}
+	}
+	if stash := git(root, "stash", "list"); !strings.Contains(stash, "ITEM-2") {
+		t.Errorf("stash list = %q", stash)
+	}
+	if head := git(root, "rev-parse", "okr/plan-hooks/ITEM-2"); head != git(root, "rev-parse", "main") {
+		t.Errorf("failed item branch moved to %s", head)
+	}
+
+	state, err := LoadRunState(res.RunDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Items[0].Git == nil || state.Items[0].Git.PRURL != got.PRURL || state.Items[1].Git != nil {
+		t.Errorf("run.json git = %+v, %+v", state.Items[0].Git, state.Items[1].Git)
+	}
+}
+
+func TestRunPlanBranchesNeedCleanWorkTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "README.md"), "readme\n")
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeFile(t, filepath.Join(root, "README.md"), "edited\n")
+	planPath := writeHookPlan(t, root, "ITEM-1")
+	_, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     &stubAdapter{},
+		AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Git:         GitOptions{Branches: true},
+	})
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("err = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "runs")); len(entries) != 0 {
+		t.Errorf("run dir left behind: %v", entries)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,125 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dependencyIndexes maps each item to the indexes of the items in its
+// depends_on, rejecting unknown, ambiguous, and cyclic references.
+func dependencyIndexes(items []PlanItem) ([][]int, error) {
+	byID := map[string][]int{}
+	for idx, item := range items {
+		byID[item.ID] = append(byID[item.ID], idx)
+	}
+	deps := make([][]int, len(items))
+	for idx, item := range items {
+		for _, dep := range item.DependsOn {
+			dep = strings.TrimSpace(dep)
+			matches := byID[dep]
+			switch {
+			case dep == "":
+				return nil, fmt.Errorf("plan item %d: depends_on entries must not be empty", idx)
+			case dep == item.ID:
+				return nil, fmt.Errorf("plan item %d: item %q depends on itself", idx, dep)
+			case len(matches) == 0:
+				return nil, fmt.Errorf("plan item %d: depends_on %q does not match any item id", idx, dep)
+			case len(matches) > 1:
+				return nil, fmt.Errorf("plan item %d: depends_on %q matches more than one item", idx, dep)
+			}
+			deps[idx] = append(deps[idx], matches[0])
+		}
+	}
+
+	// Depth-first search for cycles: 1 = on the current path, 2 = done.
+	state := make([]int, len(items))
+	var visit func(idx int, path []string) error
+	visit = func(idx int, path []string) error {
+		path = append(path, items[idx].ID)
+		switch state[idx] {
+		case 1:
+			return fmt.Errorf("depends_on cycle: %s", strings.Join(path, " -> "))
+		case 2:
+			return nil
+		}
+		state[idx] = 1
+		for _, dep := range deps[idx] {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[idx] = 2
+		return nil
+	}
+	for idx := range items {
+		if err := visit(idx, nil); err != nil {
+			return nil, err
+		}
+	}
+	return deps, nil
+}
+
+// runGraph calls run for every item, at most workers at a time, starting an
+// item only once all of its dependencies succeeded. Ready items start in plan
+// order, so workers <= 1 runs the plan serially. After a failure no new items
+// start unless continueOnError is set, in which case only the failed item's
+// dependents are held back. Running items finish and the failure of the
+// earliest failed item is returned.
+func runGraph(items []PlanItem, workers int, continueOnError bool, run func(idx int) error) error {
+	deps, err := dependencyIndexes(items)
+	if err != nil {
+		return err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	waiting := make([]int, len(items))
+	dependents := make([][]int, len(items))
+	for idx, ds := range deps {
+		waiting[idx] = len(ds)
+		for _, dep := range ds {
+			dependents[dep] = append(dependents[dep], idx)
+		}
+	}
+
+	type finished struct {
+		idx int
+		err error
+	}
+	done := make(chan finished)
+	started := make([]bool, len(items))
+	errs := make([]error, len(items))
+	running := 0
+	failed := false
+	for {
+		for idx := 0; (!failed || continueOnError) && idx < len(items) && running < workers; idx++ {
+			if started[idx] || waiting[idx] > 0 {
+				continue
+			}
+			started[idx] = true
+			running++
+			go func(idx int) {
+				done <- finished{idx: idx, err: run(idx)}
+			}(idx)
+		}
+		if running == 0 {
+			break
+		}
+		f := <-done
+		running--
+		if f.err != nil {
+			errs[f.idx] = f.err
+			failed = true
+			continue
+		}
+		for _, next := range dependents[f.idx] {
+			waiting[next]--
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package planner
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func graphItems(deps map[string][]string, ids ...string) []PlanItem {
+	items := make([]PlanItem, len(ids))
+	for i, id := range ids {
+		items[i] = PlanItem{ID: id, DependsOn: deps[id]}
+	}
+	return items
+}
+
+func TestRunGraphRespectsDependencies(t *testing.T) {
+	// C depends on A and B; D is independent.
+	items := graphItems(map[string][]string{"C": {"A", "B"}}, "C", "A", "B", "D")
+
+	var mu sync.Mutex
+	finished := map[string]bool{}
+	running, maxRunning := 0, 0
+	err := runGraph(items, 3, false, func(idx int) error {
+		mu.Lock()
+		for _, dep := range items[idx].DependsOn {
+			if !finished[dep] {
+				t.Errorf("%s started before %s finished", items[idx].ID, dep)
+			}
+		}
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		finished[items[idx].ID] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runGraph: %v", err)
+	}
+	if len(finished) != 4 {
+		t.Fatalf("finished = %v, want all items", finished)
+	}
+	if maxRunning < 2 || maxRunning > 3 {
+		t.Fatalf("max concurrent items = %d, want 2..3", maxRunning)
+	}
+}
+
+func TestRunGraphStopsAfterFailure(t *testing.T) {
+	items := graphItems(map[string][]string{"B": {"A"}}, "A", "B", "C")
+
+	var ran []string
+	err := runGraph(items, 1, false, func(idx int) error {
+		ran = append(ran, items[idx].ID)
+		if items[idx].ID == "A" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if strings.Join(ran, ",") != "A" {
+		t.Fatalf("ran = %v, want only A", ran)
+	}
+}
+
+func TestRunGraphContinuesPastFailure(t *testing.T) {
+	// B depends on A, which fails; C and D still run.
+	items := graphItems(map[string][]string{"B": {"A"}}, "A", "B", "C", "D")
+
+	var ran []string
+	err := runGraph(items, 1, true, func(idx int) error {
+		ran = append(ran, items[idx].ID)
+		if items[idx].ID == "A" || items[idx].ID == "C" {
+			return errors.New(items[idx].ID + " failed")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "A failed" {
+		t.Fatalf("err = %v, want A's failure", err)
+	}
+	if strings.Join(ran, ",") != "A,C,D" {
+		t.Fatalf("ran = %v, want A,C,D", ran)
+	}
+}
+
+func TestValidatePlanDependsOn(t *testing.T) {
+	cases := []struct {
+		deps map[string][]string
+		want string
+	}{
+		{map[string][]string{"A": {"B"}, "B": {"A"}}, "cycle: A -> B -> A"},
+		{map[string][]string{"A": {"A"}}, "depends on itself"},
+		{map[string][]string{"A": {"Z"}}, `"Z" does not match`},
+	}
+	for _, tc := range cases {
+		plan := Plan{ID: "p", AsOf: "2025-01-15", Items: graphItems(tc.deps, "A", "B")}
+		for i := range plan.Items {
+			plan.Items[i].ObjectiveID = "OBJ-1"
+			plan.Items[i].KRID = "KR-1"
+			plan.Items[i].Task = "task"
+			plan.Items[i].AgentRole = "engineer"
+			plan.Items[i].ExpectedMetricChange = ExpectedMetricChange{MetricKey: "m", Direction: "increase"}
+		}
+		err := ValidatePlan(plan)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("deps %v: err = %v, want %q", tc.deps, err, tc.want)
+		}
+	}
+}
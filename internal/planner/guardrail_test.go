@@ -0,0 +1,274 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/guardrails"
+)
+
+func TestRunPlanOKRsGuardrail(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	for _, keep := range []bool{false, true} {
+		name := "revert"
+		if keep {
+			name = "keep"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(root, "okrs", "org.yml"), []byte("original\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("artifacts/\naudit.sqlite\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			git := func(args ...string) {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+				cmd.Dir = root
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+			git("init", "-q")
+			git("add", "-A")
+			git("commit", "-q", "-m", "init")
+			// An uncommitted draft from before the run must survive a revert.
+			draft := filepath.Join(root, "okrs", "draft.yml")
+			if err := os.WriteFile(draft, []byte("draft\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			planPath := writeTestPlan(t, root)
+			adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				if err := os.WriteFile(filepath.Join(root, "okrs", "org.yml"), []byte("edited\n"), 0o644); err != nil {
+					return nil, err
+				}
+				if err := os.WriteFile(filepath.Join(root, "okrs", "new.yml"), []byte("new\n"), 0o644); err != nil {
+					return nil, err
+				}
+				return (&adapters.MockAdapter{}).Run(ctx, cfg)
+			}}
+			auditDB := filepath.Join(root, "audit.sqlite")
+			result, err := RunPlan(context.Background(), RunOptions{
+				PlanPath:      planPath,
+				WorkDir:       root,
+				Adapter:       adapter,
+				Timeout:       time.Minute,
+				AuditLogger:   audit.NewLogger(auditDB),
+				RunBaseDir:    filepath.Join(root, "artifacts", "runs"),
+				KeepOKRsEdits: keep,
+			})
+			if class, _ := ClassifyFailure(err); class != FailureGuardrailViolation {
+				t.Fatalf("class = %q, want %q (err: %v)", class, FailureGuardrailViolation, err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(result.RunDir, "item-0001", "violation.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var violation struct {
+				Details struct {
+					ChangedFiles []string                `json:"changed_files"`
+					Changes      []guardrails.FileChange `json:"changes"`
+					Reverted     bool                    `json:"reverted"`
+				} `json:"details"`
+			}
+			if err := json.Unmarshal(data, &violation); err != nil {
+				t.Fatal(err)
+			}
+			want := []guardrails.FileChange{{Path: "new.yml", Change: guardrails.FileAdded}, {Path: "org.yml", Change: guardrails.FileModified}}
+			if len(violation.Details.Changes) != 2 || violation.Details.Changes[0] != want[0] || violation.Details.Changes[1] != want[1] {
+				t.Fatalf("changes = %+v", violation.Details.Changes)
+			}
+			if violation.Details.ChangedFiles[1] != "okrs/org.yml" || violation.Details.Reverted == keep {
+				t.Fatalf("violation = %+v", violation.Details)
+			}
+
+			org, _ := os.ReadFile(filepath.Join(root, "okrs", "org.yml"))
+			_, newErr := os.Stat(filepath.Join(root, "okrs", "new.yml"))
+			if keep {
+				if string(org) != "edited\n" || newErr != nil {
+					t.Fatalf("edits not kept: org.yml=%q, new.yml err=%v", org, newErr)
+				}
+			} else if string(org) != "original\n" || !os.IsNotExist(newErr) {
+				t.Fatalf("edits not reverted: org.yml=%q, new.yml err=%v", org, newErr)
+			}
+			if _, err := os.Stat(draft); err != nil {
+				t.Fatalf("pre-existing draft lost: %v", err)
+			}
+			if events, _ := audit.ReadEvents(auditDB, audit.Query{Type: "guardrail_violation"}); len(events) != 1 {
+				t.Fatalf("expected one guardrail_violation event, got %d", len(events))
+			}
+		})
+	}
+}
+
+func TestRunPlanRedactsSecrets(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	planPath := writeTestPlan(t, root)
+	awsKey := "AKIA" + "IOSFODNN7EXAMPLE"
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		transcript := filepath.Join(cfg.ArtifactsDir, "transcript.log")
+		if err := os.WriteFile(transcript, []byte("exporting "+awsKey+"\n"), 0o644); err != nil {
+			return nil, err
+		}
+		result := `{"schema_version":"1.0","summary":"set key ` + awsKey + `","proposed_changes":[],"kr_targets":[],"kr_impact_claim":"None"}`
+		return &adapters.RunResult{TranscriptPath: transcript}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(result), 0o644)
+	}}
+	auditDB := filepath.Join(root, "audit.sqlite")
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		AuditLogger: audit.NewLogger(auditDB),
+		RunBaseDir:  filepath.Join(root, "runs"),
+	})
+	if err != nil {
+		t.Fatalf("RunPlan: %v", err)
+	}
+
+	itemDir := result.ItemRuns[0].ItemDir
+	for _, name := range []string{"transcript.log", "result.json"} {
+		data, err := os.ReadFile(filepath.Join(itemDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), awsKey) || !strings.Contains(string(data), "[REDACTED:aws_access_key_id]") {
+			t.Fatalf("%s not redacted:\n%s", name, data)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(itemDir, guardrails.SecretsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), awsKey) || !strings.Contains(string(data), `"kind": "aws_access_key_id"`) {
+		t.Fatalf("unexpected %s:\n%s", guardrails.SecretsFileName, data)
+	}
+	events, _ := audit.ReadEvents(auditDB, audit.Query{Type: "guardrail_violation"})
+	if len(events) != 1 || !strings.Contains(events[0].PayloadJSON, `"secret_leak"`) || strings.Contains(events[0].PayloadJSON, awsKey) {
+		t.Fatalf("unexpected guardrail events: %+v", events)
+	}
+}
+
+func TestRunPlanSandbox(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		write     string
+		wantClass FailureClass
+	}{
+		{name: "inside", write: "repo/src/main.go"},
+		{name: "outside", write: "sibling/notes.txt", wantClass: FailureGuardrailViolation},
+		{name: "denied", write: "repo/.env", wantClass: FailureGuardrailViolation},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			base := t.TempDir()
+			root := filepath.Join(base, "repo")
+			if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			planPath := writeTestPlan(t, root)
+			adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				path := filepath.Join(base, tc.write)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					return nil, err
+				}
+				if err := os.WriteFile(path, []byte("change\n"), 0o644); err != nil {
+					return nil, err
+				}
+				return &adapters.RunResult{}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+			}}
+			auditDB := filepath.Join(root, "audit.sqlite")
+			result, err := RunPlan(context.Background(), RunOptions{
+				PlanPath:      planPath,
+				WorkDir:       root,
+				Adapter:       adapter,
+				AuditLogger:   audit.NewLogger(auditDB),
+				RunBaseDir:    filepath.Join(root, "runs"),
+				Sandbox:       &adapters.SandboxConfig{Watch: []string{".."}, Deny: []string{".env"}},
+				SandboxIgnore: []string{auditDB},
+			})
+			if tc.wantClass == "" {
+				if err != nil {
+					t.Fatalf("RunPlan: %v", err)
+				}
+				return
+			}
+			if class, _ := ClassifyFailure(err); class != tc.wantClass || !strings.Contains(err.Error(), filepath.Join(base, tc.write)) {
+				t.Fatalf("err = %v (class %q)", err, class)
+			}
+			data, err := os.ReadFile(filepath.Join(result.RunDir, "item-0001", "violation.json"))
+			if err != nil || !strings.Contains(string(data), `"sandbox_violation"`) {
+				t.Fatalf("violation.json = %s, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestRunPlanAgentEnv(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	planPath := writeTestPlan(t, root)
+	token := "tok-0123456789abcdef"
+	t.Setenv("TEST_AGENT_TOKEN", token)
+	writeFile(t, filepath.Join(root, adapters.EnvFileName), "env:\n  LOG_LEVEL: {value: debug}\nroles:\n  engineer:\n    AGENT_TOKEN: {env: TEST_AGENT_TOKEN}\n")
+	envCfg, err := adapters.LoadEnv(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		if cfg.Env["LOG_LEVEL"] != "debug" || cfg.Env["OKRCHESTRA_PLAN_ITEM_ID"] != "ITEM-1" {
+			t.Errorf("agent env = %v", cfg.Env)
+		}
+		writeFile(t, filepath.Join(cfg.ArtifactsDir, "transcript.log"), "calling the API with "+cfg.Env["AGENT_TOKEN"]+"\n")
+		return &adapters.RunResult{}, errors.New("401 for token " + cfg.Env["AGENT_TOKEN"])
+	}}
+	auditDB := filepath.Join(root, "audit.sqlite")
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		AuditLogger: audit.NewLogger(auditDB),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Env:         envCfg,
+	})
+	if class, _ := ClassifyFailure(err); class != FailureAdapterError {
+		t.Fatalf("err = %v", err)
+	}
+
+	transcript, err := os.ReadFile(filepath.Join(result.RunDir, "item-0001", "transcript.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := audit.ReadEvents(auditDB, audit.Query{Type: "plan_item_finished"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+	for name, data := range map[string]string{
+		"transcript.log":     string(transcript),
+		"plan_item_finished": events[0].PayloadJSON,
+	} {
+		if strings.Contains(data, token) || !strings.Contains(data, "[REDACTED:AGENT_TOKEN]") {
+			t.Errorf("%s not scrubbed:\n%s", name, data)
+		}
+	}
+}
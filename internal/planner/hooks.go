@@ -0,0 +1,229 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HooksFileName is the workspace file defining plan run hooks.
+const HooksFileName = "hooks.yml"
+
+// HooksLogName is the file in a run or item dir that hook output is
+// appended to.
+const HooksLogName = "hooks.log"
+
+// DefaultHookTimeout bounds a hook that sets no timeout.
+const DefaultHookTimeout = 10 * time.Minute
+
+// Hook phases, in the order a run reaches them.
+const (
+	HookPreRun   = "pre_run"
+	HookPreItem  = "pre_item"
+	HookPostItem = "post_item"
+	HookPostRun  = "post_run"
+)
+
+// Hook failure policies.
+const (
+	// HookAbort fails the item (item hooks) or the run (run hooks) and
+	// skips the phase's remaining hooks. It is the default.
+	HookAbort = "abort"
+	// HookWarn records the failure and carries on.
+	HookWarn = "warn"
+)
+
+// Hook is a command run at one point of a plan run.
+type Hook struct {
+	// Name labels the hook in logs; it defaults to the command.
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+	// OnFailure is HookAbort (the default) or HookWarn.
+	OnFailure string        `yaml:"on_failure"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// Hooks is the contents of hooks.yml:
+//
+//	pre_run:
+//	  - name: branch
+//	    command: ["sh", "-c", "git switch -c okrchestra/$OKRCHESTRA_RUN_ID"]
+//	pre_item:
+//	  - command: ["make", "lint"]
+//	    on_failure: warn
+//	post_item:
+//	  - name: tests
+//	    command: ["go", "test", "./..."]
+//	    timeout: 15m
+//	post_run:
+//	  - command: ["git", "push", "-u", "origin", "HEAD"]
+//
+// Hooks run in order in the work dir (an item's worktree for scoped items)
+// with the item's OKRCHESTRA_* variables, and their output is appended to
+// hooks.log in the run or item dir. Post hooks run whenever the matching
+// pre hooks ran, after a failure too.
+type Hooks struct {
+	PreRun   []Hook `yaml:"pre_run"`
+	PreItem  []Hook `yaml:"pre_item"`
+	PostItem []Hook `yaml:"post_item"`
+	PostRun  []Hook `yaml:"post_run"`
+}
+
+// LoadHooks reads <root>/hooks.yml. A missing file has no hooks.
+func LoadHooks(root string) (Hooks, error) {
+	var hooks Hooks
+	data, err := os.ReadFile(filepath.Join(root, HooksFileName))
+	if os.IsNotExist(err) {
+		return hooks, nil
+	}
+	if err != nil {
+		return hooks, fmt.Errorf("read %s: %w", HooksFileName, err)
+	}
+	if err := yaml.Unmarshal(data, &hooks); err != nil {
+		return hooks, fmt.Errorf("parse %s: %w", HooksFileName, err)
+	}
+	if err := hooks.Validate(); err != nil {
+		return hooks, fmt.Errorf("%s: %w", HooksFileName, err)
+	}
+	return hooks, nil
+}
+
+// Validate checks every hook's command, failure policy, and timeout.
+func (h Hooks) Validate() error {
+	for _, phase := range []string{HookPreRun, HookPreItem, HookPostItem, HookPostRun} {
+		for i, hook := range h.Phase(phase) {
+			if len(hook.Command) == 0 || strings.TrimSpace(hook.Command[0]) == "" {
+				return fmt.Errorf("%s[%d]: command is required", phase, i)
+			}
+			switch hook.OnFailure {
+			case "", HookAbort, HookWarn:
+			default:
+				return fmt.Errorf("%s[%d]: on_failure must be %s or %s, got %q", phase, i, HookAbort, HookWarn, hook.OnFailure)
+			}
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%s[%d]: timeout must be >= 0", phase, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Phase returns the hooks of a phase.
+func (h Hooks) Phase(phase string) []Hook {
+	switch phase {
+	case HookPreRun:
+		return h.PreRun
+	case HookPreItem:
+		return h.PreItem
+	case HookPostItem:
+		return h.PostItem
+	case HookPostRun:
+		return h.PostRun
+	}
+	return nil
+}
+
+// Empty reports whether no hooks are configured.
+func (h Hooks) Empty() bool {
+	return len(h.PreRun)+len(h.PreItem)+len(h.PostItem)+len(h.PostRun) == 0
+}
+
+// HookResult is the outcome of one hook, logged as a plan_hook_finished
+// event.
+type HookResult struct {
+	Phase      string   `json:"phase"`
+	Name       string   `json:"name"`
+	Command    []string `json:"command"`
+	OnFailure  string   `json:"on_failure"`
+	ExitCode   int      `json:"exit_code"`
+	DurationMS int64    `json:"duration_ms"`
+	Error      string   `json:"error,omitempty"`
+	Log        string   `json:"log"`
+}
+
+// runHooks runs a phase's hooks in workDir with env added to the process
+// environment, appending their output to hooks.log in logDir. It returns
+// every hook's result and, when a hook with the abort policy fails, an
+// error naming it; the phase's later hooks are then not run.
+func runHooks(ctx context.Context, phase string, hooks []Hook, workDir, logDir string, env map[string]string) ([]HookResult, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure hook log dir: %w", err)
+	}
+	logPath := filepath.Join(logDir, HooksLogName)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open hook log: %w", err)
+	}
+	defer logFile.Close()
+
+	environ := os.Environ()
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		environ = append(environ, key+"="+env[key])
+	}
+	environ = append(environ, "OKRCHESTRA_HOOK_PHASE="+phase)
+
+	var results []HookResult
+	for _, hook := range hooks {
+		res := HookResult{
+			Phase:     phase,
+			Name:      hook.Name,
+			Command:   hook.Command,
+			OnFailure: hook.OnFailure,
+			Log:       logPath,
+		}
+		if res.Name == "" {
+			res.Name = strings.Join(hook.Command, " ")
+		}
+		if res.OnFailure == "" {
+			res.OnFailure = HookAbort
+		}
+		timeout := hook.Timeout
+		if timeout == 0 {
+			timeout = DefaultHookTimeout
+		}
+
+		fmt.Fprintf(logFile, "--- %s %s: %s (%s)\n", phase, res.Name, strings.Join(hook.Command, " "), time.Now().UTC().Format(time.RFC3339))
+		hookCtx, cancel := context.WithTimeout(tailContext(ctx), timeout)
+		cmd := exec.CommandContext(hookCtx, hook.Command[0], hook.Command[1:]...)
+		cmd.Dir = workDir
+		cmd.Env = environ
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		// A hook's children may hold the log open after it is killed.
+		cmd.WaitDelay = 5 * time.Second
+		started := time.Now()
+		runErr := cmd.Run()
+		res.DurationMS = time.Since(started).Milliseconds()
+		if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+			runErr = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		res.ExitCode = cmd.ProcessState.ExitCode()
+		if runErr != nil {
+			res.Error = runErr.Error()
+		}
+		fmt.Fprintf(logFile, "--- exit %d after %s\n", res.ExitCode, time.Duration(res.DurationMS)*time.Millisecond)
+		results = append(results, res)
+
+		if runErr != nil && res.OnFailure == HookAbort {
+			return results, fmt.Errorf("%s hook %q failed (see %s): %w", phase, res.Name, logPath, runErr)
+		}
+	}
+	return results, nil
+}
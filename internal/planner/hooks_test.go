@@ -0,0 +1,141 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+func TestLoadHooks(t *testing.T) {
+	root := t.TempDir()
+	hooks, err := LoadHooks(root)
+	if err != nil || !hooks.Empty() {
+		t.Fatalf("missing hooks.yml = %+v, %v", hooks, err)
+	}
+	for _, bad := range []string{
+		"pre_item:\n  - command: []\n",
+		"post_run:\n  - command: [\"true\"]\n    on_failure: ignore\n",
+		"pre_run:\n  - command: [\"true\"]\n    timeout: -1s\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, HooksFileName), []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadHooks(root); err == nil {
+			t.Errorf("LoadHooks(%q) succeeded", bad)
+		}
+	}
+}
+
+func writeHookPlan(t *testing.T, root string, ids ...string) string {
+	t.Helper()
+	plan := Plan{ID: "plan-hooks", AsOf: "2025-01-15"}
+	for _, id := range ids {
+		plan.Items = append(plan.Items, PlanItem{
+			ID: id, ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do " + id, AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase", Target: 1},
+		})
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(root, "plan.json")
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return planPath
+}
+
+func TestRunPlanHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are sh scripts")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	planPath := writeHookPlan(t, root, "ITEM-1", "ITEM-2")
+	record := func(line string) Hook {
+		return Hook{Command: []string{"sh", "-c", "echo " + line + " >> order.txt"}}
+	}
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		return &adapters.RunResult{}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+	}}
+	opts := RunOptions{
+		PlanPath:        planPath,
+		WorkDir:         root,
+		Adapter:         adapter,
+		Timeout:         time.Minute,
+		AuditLogger:     audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:      filepath.Join(root, "runs"),
+		ContinueOnError: true,
+		Hooks: Hooks{
+			PreRun:  []Hook{record("pre_run")},
+			PreItem: []Hook{record("pre_item:$OKRCHESTRA_PLAN_ITEM_ID")},
+			PostItem: []Hook{
+				record("post_item:$OKRCHESTRA_PLAN_ITEM_ID:$OKRCHESTRA_ITEM_STATUS"),
+				{Name: "lint", Command: []string{"sh", "-c", "echo lint output; exit 3"}, OnFailure: HookWarn},
+				{Name: "tests", Command: []string{"sh", "-c", `test "$OKRCHESTRA_PLAN_ITEM_ID" != ITEM-2`}},
+			},
+			PostRun: []Hook{record("post_run:$OKRCHESTRA_RUN_STATUS")},
+		},
+	}
+
+	res, err := RunPlan(context.Background(), opts)
+	if err == nil {
+		t.Fatal("expected the tests hook to fail ITEM-2")
+	}
+	data, readErr := os.ReadFile(filepath.Join(root, "order.txt"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	want := "pre_run\npre_item:ITEM-1\npost_item:ITEM-1:succeeded\npre_item:ITEM-2\npost_item:ITEM-2:succeeded\npost_run:failed\n"
+	if string(data) != want {
+		t.Fatalf("hook order =\n%s\nwant\n%s", data, want)
+	}
+	if len(res.ItemRuns) != 1 || res.ItemRuns[0].ItemID != "ITEM-1" {
+		t.Fatalf("item runs = %+v", res.ItemRuns)
+	}
+	if len(res.Failures) != 1 || res.Failures[0].Class != FailureHookFailed {
+		t.Fatalf("failures = %+v", res.Failures)
+	}
+	hookLog, readErr := os.ReadFile(filepath.Join(res.RunDir, "item-0001", HooksLogName))
+	if readErr != nil || !strings.Contains(string(hookLog), "lint output") || !strings.Contains(string(hookLog), "--- exit 3") {
+		t.Fatalf("item hooks.log = %q, %v", hookLog, readErr)
+	}
+}
+
+func TestRunPlanPreRunHookAborts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are sh scripts")
+	}
+	root := t.TempDir()
+	planPath := writeHookPlan(t, root, "ITEM-1")
+	ran := false
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		ran = true
+		return &adapters.RunResult{}, nil
+	}}
+	res, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Hooks:       Hooks{PreRun: []Hook{{Name: "branch", Command: []string{"false"}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), `pre_run hook "branch" failed`) {
+		t.Fatalf("err = %v", err)
+	}
+	if ran || len(res.Skipped) != 1 {
+		t.Fatalf("ran = %v, skipped = %v", ran, res.Skipped)
+	}
+}
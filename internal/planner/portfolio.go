@@ -0,0 +1,184 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"okrchestra/internal/okrstore"
+)
+
+// DefaultPortfolioItems is the plan size used by portfolio mode when none is given.
+const DefaultPortfolioItems = 3
+
+const portfolioFormula = "score = weight × mean((1 − progress) × confidence) over runnable KRs; items split by score using largest remainder"
+
+// Allocation records how portfolio mode distributed plan items.
+type Allocation struct {
+	Mode       string                `json:"mode"`
+	Items      int                   `json:"items"`
+	Formula    string                `json:"formula"`
+	Objectives []ObjectiveAllocation `json:"objectives"`
+}
+
+// ObjectiveAllocation is one objective's share of a portfolio plan.
+type ObjectiveAllocation struct {
+	ObjectiveID string   `json:"objective_id"`
+	Weight      float64  `json:"weight"`
+	Progress    float64  `json:"progress"`
+	Confidence  float64  `json:"confidence"`
+	Need        float64  `json:"need"`
+	Score       float64  `json:"score"`
+	Share       float64  `json:"share"`
+	Items       int      `json:"items"`
+	KRIDs       []string `json:"kr_ids,omitempty"`
+	Rationale   string   `json:"rationale"`
+}
+
+type portfolioCandidate struct {
+	obj   okrstore.Objective
+	krs   []okrstore.KeyResult
+	alloc ObjectiveAllocation
+}
+
+// allocatePortfolio spreads n items across org objectives in proportion to
+// their scores and returns the items alongside the allocation rationale.
+func allocatePortfolio(store *okrstore.Store, n int, agentRole string) ([]PlanItem, *Allocation, error) {
+	if n <= 0 {
+		n = DefaultPortfolioItems
+	}
+
+	var candidates []portfolioCandidate
+	total := 0.0
+	runnable := 0
+	for _, doc := range store.Org.Documents {
+		for _, obj := range doc.Objectives {
+			c := portfolioCandidate{obj: obj}
+			c.alloc.ObjectiveID = obj.ID
+			c.alloc.Weight = 1
+			if obj.Weight != nil {
+				c.alloc.Weight = *obj.Weight
+			}
+			for _, kr := range obj.KeyResults {
+				if kr.MetricKey == "" || kr.Status == "achieved" {
+					continue
+				}
+				c.krs = append(c.krs, kr)
+			}
+			if len(c.krs) > 0 {
+				var progress, confidence, need float64
+				for _, kr := range c.krs {
+					p := krProgress(kr)
+					progress += p
+					confidence += kr.Confidence
+					need += (1 - p) * kr.Confidence
+				}
+				count := float64(len(c.krs))
+				c.alloc.Progress = round3(progress / count)
+				c.alloc.Confidence = round3(confidence / count)
+				c.alloc.Need = round3(need / count)
+				c.alloc.Score = round3(c.alloc.Weight * c.alloc.Need)
+				total += c.alloc.Score
+				runnable++
+				// Most need-weighted KRs get the objective's items first.
+				sort.SliceStable(c.krs, func(i, j int) bool {
+					return (1-krProgress(c.krs[i]))*c.krs[i].Confidence > (1-krProgress(c.krs[j]))*c.krs[j].Confidence
+				})
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	if runnable == 0 {
+		return nil, nil, fmt.Errorf("no runnable org key results found")
+	}
+
+	// With every score at zero there is no signal; split evenly instead.
+	even := total == 0
+	quotas := make([]float64, len(candidates))
+	for i, c := range candidates {
+		switch {
+		case len(c.krs) == 0:
+			quotas[i] = 0
+		case even:
+			quotas[i] = float64(n) / float64(runnable)
+		default:
+			quotas[i] = float64(n) * c.alloc.Score / total
+		}
+	}
+	counts := largestRemainder(quotas, n)
+
+	alloc := &Allocation{Mode: "portfolio", Items: n, Formula: portfolioFormula}
+	var items []PlanItem
+	for i := range candidates {
+		c := &candidates[i]
+		c.alloc.Items = counts[i]
+		c.alloc.Share = round3(quotas[i] / float64(n))
+		switch {
+		case len(c.krs) == 0:
+			c.alloc.Rationale = "no runnable KRs (achieved or missing metric_key)"
+		case even:
+			c.alloc.Rationale = fmt.Sprintf("all scores are 0; split evenly across %d objectives", runnable)
+		case c.alloc.Weight == 0:
+			c.alloc.Rationale = "weight 0; excluded from allocation"
+		default:
+			c.alloc.Rationale = fmt.Sprintf("weight %g × need %.3f (progress %.0f%%, confidence %.2f) = score %.3f of %.3f total",
+				c.alloc.Weight, c.alloc.Need, c.alloc.Progress*100, c.alloc.Confidence, c.alloc.Score, total)
+		}
+		for j := 0; j < c.alloc.Items; j++ {
+			kr := c.krs[j%len(c.krs)]
+			if j < len(c.krs) {
+				c.alloc.KRIDs = append(c.alloc.KRIDs, kr.ID)
+			}
+			items = append(items, krItem(fmt.Sprintf("ITEM-%d", len(items)+1), c.obj, kr, agentRole))
+		}
+		alloc.Objectives = append(alloc.Objectives, c.alloc)
+	}
+	return items, alloc, nil
+}
+
+// krProgress is the fraction of the baseline→target distance covered by
+// the KR's current value, clamped to [0, 1]. KRs without a current value
+// count as no progress.
+func krProgress(kr okrstore.KeyResult) float64 {
+	if kr.Current == nil {
+		return 0
+	}
+	span := kr.Target - kr.Baseline
+	if span == 0 {
+		return 1
+	}
+	p := (*kr.Current - kr.Baseline) / span
+	return math.Max(0, math.Min(1, p))
+}
+
+// largestRemainder rounds quotas to integers summing to n, giving leftover
+// units to the largest fractional parts (earlier entries win ties).
+func largestRemainder(quotas []float64, n int) []int {
+	counts := make([]int, len(quotas))
+	assigned := 0
+	for i, q := range quotas {
+		counts[i] = int(math.Floor(q))
+		assigned += counts[i]
+	}
+	order := make([]int, len(quotas))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa := quotas[order[a]] - math.Floor(quotas[order[a]])
+		fb := quotas[order[b]] - math.Floor(quotas[order[b]])
+		return fa > fb
+	})
+	for k := 0; assigned < n && k < len(order); k++ {
+		if quotas[order[k]] == 0 {
+			continue
+		}
+		counts[order[k]]++
+		assigned++
+	}
+	return counts
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,124 @@
+package planner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGeneratePortfolioPlan(t *testing.T) {
+	root := t.TempDir()
+	okrsDir := filepath.Join(root, "okrs")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatalf("mkdir okrs: %v", err)
+	}
+	// OBJ-A is weighted 3 and untouched; OBJ-B is half done; OBJ-C is
+	// fully achieved and must receive nothing.
+	org := `
+scope: org
+objectives:
+  - objective_id: OBJ-A
+    objective: Reliability
+    weight: 3
+    key_results:
+      - kr_id: KR-A1
+        description: a1
+        owner_id: o
+        metric_key: a1
+        baseline: 0
+        target: 10
+        confidence: 0.8
+        status: not_started
+        evidence: []
+      - kr_id: KR-A2
+        description: a2
+        owner_id: o
+        metric_key: a2
+        baseline: 0
+        target: 10
+        confidence: 0.4
+        status: not_started
+        evidence: []
+  - objective_id: OBJ-B
+    objective: Speed
+    key_results:
+      - kr_id: KR-B1
+        description: b1
+        owner_id: o
+        metric_key: b1
+        baseline: 0
+        target: 10
+        current: 5
+        confidence: 0.6
+        status: in_progress
+        evidence: []
+  - objective_id: OBJ-C
+    objective: Done
+    key_results:
+      - kr_id: KR-C1
+        description: c1
+        owner_id: o
+        metric_key: c1
+        baseline: 0
+        target: 1
+        confidence: 1
+        status: achieved
+        evidence: []
+`
+	if err := os.WriteFile(filepath.Join(okrsDir, "org.yml"), []byte(org), 0o644); err != nil {
+		t.Fatalf("write org.yml: %v", err)
+	}
+
+	res, err := GeneratePlan(GenerateOptions{
+		OKRsDir:       okrsDir,
+		OutputBaseDir: filepath.Join(root, "plans"),
+		AsOf:          time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		Portfolio:     true,
+		Items:         5,
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	// Scores: A = 3 × mean(0.8, 0.4) = 1.8, B = 1 × 0.5 × 0.6 = 0.3.
+	// Quotas of 5 items: A 4.29, B 0.71 -> A 4, B 1.
+	perObjective := map[string]int{}
+	perKR := map[string]int{}
+	for _, item := range res.Plan.Items {
+		perObjective[item.ObjectiveID]++
+		perKR[item.KRID]++
+	}
+	if perObjective["OBJ-A"] != 4 || perObjective["OBJ-B"] != 1 || perObjective["OBJ-C"] != 0 {
+		t.Fatalf("unexpected allocation: %v", perObjective)
+	}
+	if perKR["KR-A1"] != 2 || perKR["KR-A2"] != 2 {
+		t.Fatalf("expected OBJ-A items split across its KRs, got %v", perKR)
+	}
+	if res.Plan.Items[0].KRID != "KR-A1" {
+		t.Fatalf("expected highest-need KR first, got %s", res.Plan.Items[0].KRID)
+	}
+
+	alloc := res.Plan.Allocation
+	if alloc == nil || alloc.Mode != "portfolio" || len(alloc.Objectives) != 3 {
+		t.Fatalf("unexpected allocation record: %+v", alloc)
+	}
+	for _, obj := range alloc.Objectives {
+		if obj.Rationale == "" {
+			t.Fatalf("objective %s missing rationale", obj.ObjectiveID)
+		}
+	}
+
+	data, err := os.ReadFile(res.PlanPath)
+	if err != nil {
+		t.Fatalf("read plan: %v", err)
+	}
+	var onDisk Plan
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("parse plan: %v", err)
+	}
+	if onDisk.Allocation == nil || onDisk.Allocation.Objectives[0].Score != 1.8 {
+		t.Fatalf("allocation not persisted in plan.json: %+v", onDisk.Allocation)
+	}
+}
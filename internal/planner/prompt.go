@@ -0,0 +1,125 @@
+package planner
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"okrchestra/internal/locale"
+)
+
+// PromptDirName is the workspace directory whose templates override the
+// built-in prompts.
+const PromptDirName = "prompts"
+
+// RoleTemplateDirName is the workspace directory of per-agent-role plan
+// item templates, named <agent_role>.tmpl or <agent_role>.<language>.tmpl.
+const RoleTemplateDirName = "plan_templates"
+
+// planItemPrompt is the template rendered into each item's prompt.md.
+const planItemPrompt = "plan_item"
+
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// promptData is what prompt templates render.
+type promptData struct {
+	Item       PlanItem
+	ResultPath string
+}
+
+var promptFuncs = template.FuncMap{
+	"num":  func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) },
+	"join": strings.Join,
+}
+
+// renderPrompt renders the plan item prompt in language. A template for
+// the item's agent role in roleDir (when set) wins; otherwise templates are
+// named <name>.<language>.tmpl and looked up in promptDir (when set) before
+// the built-in set, falling back to English when neither has the language.
+func renderPrompt(item PlanItem, itemDir, language, promptDir, roleDir string) (string, error) {
+	tmpl, err := loadRoleTemplate(item.AgentRole, language, roleDir)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		if tmpl, err = loadPromptTemplate(planItemPrompt, language, promptDir); err != nil {
+			return "", err
+		}
+	}
+	var b strings.Builder
+	data := promptData{Item: item, ResultPath: filepath.Join(itemDir, "result.json")}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render %s prompt: %w", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// loadRoleTemplate returns roleDir's template for role in language, else
+// its language-neutral one, or nil when roleDir has neither.
+func loadRoleTemplate(role, language, roleDir string) (*template.Template, error) {
+	if roleDir == "" || !roleNamePattern.MatchString(role) {
+		return nil, nil
+	}
+	if language == "" {
+		language = locale.DefaultLanguage
+	}
+	for _, file := range []string{role + "." + language + ".tmpl", role + ".tmpl"} {
+		data, err := os.ReadFile(filepath.Join(roleDir, file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read plan template: %w", err)
+		}
+		tmpl, err := template.New(file).Funcs(promptFuncs).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse plan template %s: %w", file, err)
+		}
+		return tmpl, nil
+	}
+	return nil, nil
+}
+
+// roleNamePattern matches agent roles usable as template file names.
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+func loadPromptTemplate(name, language, promptDir string) (*template.Template, error) {
+	languages := []string{locale.DefaultLanguage}
+	if language != "" && language != locale.DefaultLanguage {
+		languages = []string{language, locale.DefaultLanguage}
+	}
+	for _, lang := range languages {
+		file := name + "." + lang + ".tmpl"
+		var data []byte
+		var err error
+		if promptDir != "" {
+			data, err = os.ReadFile(filepath.Join(promptDir, file))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("read prompt template: %w", err)
+			}
+		}
+		if data == nil {
+			data, err = builtinPrompts.ReadFile("prompts/" + file)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read built-in prompt template: %w", err)
+			}
+		}
+		tmpl, err := template.New(file).Funcs(promptFuncs).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse prompt template %s: %w", file, err)
+		}
+		return tmpl, nil
+	}
+	return nil, fmt.Errorf("no %s prompt template", name)
+}
@@ -0,0 +1,101 @@
+package planner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderPromptLanguages(t *testing.T) {
+	item := PlanItem{
+		ID:          "ITEM-1",
+		ObjectiveID: "OBJ-1",
+		KRID:        "KR-1",
+		Task:        "Fix flaky tests",
+		Hypothesis:  "Fewer flakes raise the pass rate",
+		AgentRole:   "engineer",
+		ScopePaths:  []string{"services/api"},
+		ExpectedMetricChange: ExpectedMetricChange{
+			MetricKey: "ci.pass_rate",
+			Direction: "increase",
+			Baseline:  0.8,
+			Target:    0.95,
+			Delta:     0.15,
+		},
+	}
+
+	overrides := t.TempDir()
+	if err := os.WriteFile(filepath.Join(overrides, "plan_item.fr.tmpl"), []byte("Tâche : {{.Item.Task}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		language  string
+		promptDir string
+		want      []string
+	}{
+		{"english", "en", "", []string{"## Task\nFix flaky tests\n", "## Scope\n", "- baseline: 0.8\n"}},
+		{"empty is english", "", "", []string{"## Task\n"}},
+		{"german", "de", "", []string{"## Aufgabe\nFix flaky tests\n", "## Umfang\n", "- services/api\n", "`schema_version`"}},
+		{"untranslated falls back", "ja", "", []string{"## Task\n"}},
+		{"workspace override", "fr", overrides, []string{"Tâche : Fix flaky tests\n"}},
+		{"override dir without language", "de", overrides, []string{"## Aufgabe\n"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			prompt, err := renderPrompt(item, "/runs/item", tc.language, tc.promptDir, "")
+			if err != nil {
+				t.Fatalf("renderPrompt: %v", err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(prompt, want) {
+					t.Fatalf("prompt missing %q:\n%s", want, prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPromptRejectsBadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plan_item.en.tmpl"), []byte("{{.Item.Nope}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renderPrompt(PlanItem{}, "/runs/item", "en", dir, ""); err == nil {
+		t.Fatal("expected error for unknown template field")
+	}
+}
+
+func TestRenderPromptRoleTemplates(t *testing.T) {
+	roles := t.TempDir()
+	if err := os.WriteFile(filepath.Join(roles, "sre.tmpl"), []byte("SRE runbook for {{.Item.KRID}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(roles, "sre.de.tmpl"), []byte("SRE-Runbook für {{.Item.KRID}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		role     string
+		language string
+		want     string
+	}{
+		{"sre", "en", "SRE runbook for KR-1\n"},
+		{"sre", "de", "SRE-Runbook für KR-1\n"},
+		{"sre", "fr", "SRE runbook for KR-1\n"},
+		{"data_analyst", "en", "## Task\n"},
+		{"../sre", "en", "## Task\n"},
+	}
+	for _, tc := range cases {
+		item := PlanItem{ID: "ITEM-1", KRID: "KR-1", AgentRole: tc.role}
+		prompt, err := renderPrompt(item, "/runs/item", tc.language, "", roles)
+		if err != nil {
+			t.Fatalf("%s/%s: renderPrompt: %v", tc.role, tc.language, err)
+		}
+		if !strings.Contains(prompt, tc.want) {
+			t.Fatalf("%s/%s: prompt missing %q:\n%s", tc.role, tc.language, tc.want, prompt)
+		}
+	}
+}
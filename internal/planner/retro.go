@@ -0,0 +1,460 @@
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/artifacts"
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+)
+
+// Retro verdicts for a plan item, from its runs, reviews, and metric.
+const (
+	VerdictImproved = "improved"
+	VerdictNoEffect = "no_effect"
+	VerdictFailed   = "failed"
+	VerdictRejected = "rejected"
+	VerdictPending  = "pending"
+)
+
+const RetroSchemaVersion = 1
+
+// Retro is the structured retrospective of a plan, stored as retro.json next
+// to plan.json. Plan generation reads it to avoid unsuccessful tactics.
+type Retro struct {
+	SchemaVersion int         `json:"schema_version"`
+	PlanID        string      `json:"plan_id"`
+	PlanAsOf      string      `json:"plan_as_of"`
+	GeneratedAt   string      `json:"generated_at"`
+	Runs          []RetroRun  `json:"runs"`
+	Items         []RetroItem `json:"items"`
+	Cost          RetroCost   `json:"cost"`
+}
+
+// RetroRun summarizes one run of the plan.
+type RetroRun struct {
+	RunID        string  `json:"run_id"`
+	Items        int     `json:"items"`
+	Failures     int     `json:"failures"`
+	AgentSeconds float64 `json:"agent_seconds"`
+}
+
+// RetroCost totals the effort spent on the plan. Agent time is measured
+// from each item's files: item.json is written when the item starts.
+type RetroCost struct {
+	Runs         int     `json:"runs"`
+	ItemAttempts int     `json:"item_attempts"`
+	AgentSeconds float64 `json:"agent_seconds"`
+}
+
+// RetroItem is the outcome of one plan item across all runs.
+type RetroItem struct {
+	ItemID    string `json:"item_id"`
+	KRID      string `json:"kr_id"`
+	MetricKey string `json:"metric_key"`
+	Direction string `json:"direction"`
+	Task      string `json:"task"`
+	Attempts  int    `json:"attempts"`
+	// Failures lists the failure class of each failed attempt.
+	Failures []FailureClass `json:"failures,omitempty"`
+	Reviews  []RetroReview  `json:"reviews,omitempty"`
+	// Summaries are the agents' result.json summaries: what was tried.
+	Summaries []string `json:"summaries,omitempty"`
+	// Baseline is the metric in the latest snapshot before the first run
+	// (the plan's baseline when none exists); Observed is the latest
+	// snapshot on or after the last run.
+	Baseline     float64  `json:"baseline"`
+	Observed     *float64 `json:"observed,omitempty"`
+	ObservedOn   string   `json:"observed_on,omitempty"`
+	Delta        *float64 `json:"delta,omitempty"`
+	Verdict      string   `json:"verdict"`
+	AgentSeconds float64  `json:"agent_seconds"`
+}
+
+// RetroReview is a review comment left on one attempt.
+type RetroReview struct {
+	RunID    string `json:"run_id"`
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+	Reviewer string `json:"reviewer,omitempty"`
+}
+
+// Unsuccessful reports whether the item's tactic should not be repeated.
+func (i RetroItem) Unsuccessful() bool {
+	switch i.Verdict {
+	case VerdictNoEffect, VerdictFailed, VerdictRejected:
+		return true
+	}
+	return false
+}
+
+type RetroOptions struct {
+	PlanPath     string
+	ArtifactsDir string
+	SnapshotsDir string
+	Now          time.Time
+}
+
+// BuildRetro gathers every indexed run of the plan with its failures,
+// reviews, agent summaries, and observed metric deltas.
+func BuildRetro(opts RetroOptions) (*Retro, error) {
+	planPath, err := ResolvePlanPath(opts.PlanPath)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := LoadPlan(planPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	runIDs, err := planRunIDs(opts.ArtifactsDir, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	retro := &Retro{
+		SchemaVersion: RetroSchemaVersion,
+		PlanID:        plan.ID,
+		PlanAsOf:      plan.AsOf,
+		GeneratedAt:   opts.Now.UTC().Format(time.RFC3339),
+		Runs:          []RetroRun{},
+	}
+	byID := map[string]*RetroItem{}
+	for _, item := range plan.Items {
+		retro.Items = append(retro.Items, RetroItem{
+			ItemID:    item.ID,
+			KRID:      item.KRID,
+			MetricKey: item.ExpectedMetricChange.MetricKey,
+			Direction: item.ExpectedMetricChange.Direction,
+			Task:      item.Task,
+			Baseline:  item.ExpectedMetricChange.Baseline,
+		})
+	}
+	for i := range retro.Items {
+		byID[retro.Items[i].ItemID] = &retro.Items[i]
+	}
+
+	for _, runID := range runIDs {
+		runDir := filepath.Join(opts.ArtifactsDir, "runs", runID)
+		itemDirs, err := filepath.Glob(filepath.Join(runDir, "item-*"))
+		if err != nil {
+			return nil, fmt.Errorf("scan run %s: %w", runID, err)
+		}
+		sort.Strings(itemDirs)
+		run := RetroRun{RunID: runID}
+		for _, dir := range itemDirs {
+			planItem, err := LoadRunItem(dir)
+			if err != nil {
+				continue
+			}
+			item, ok := byID[planItem.ID]
+			if !ok {
+				continue
+			}
+			run.Items++
+			item.Attempts++
+			seconds := itemSeconds(dir)
+			item.AgentSeconds += seconds
+			run.AgentSeconds += seconds
+			if failure, err := loadItemFailure(dir); err != nil {
+				return nil, err
+			} else if failure != nil {
+				run.Failures++
+				item.Failures = append(item.Failures, failure.Class)
+			}
+			review, err := LoadReview(dir)
+			if err != nil {
+				return nil, err
+			}
+			if review != nil {
+				item.Reviews = append(item.Reviews, RetroReview{RunID: runID, Decision: review.Decision, Comment: review.Comment, Reviewer: review.Reviewer})
+			}
+			if summary := resultSummary(dir); summary != "" {
+				item.Summaries = append(item.Summaries, summary)
+			}
+		}
+		retro.Runs = append(retro.Runs, run)
+		retro.Cost.Runs++
+		retro.Cost.ItemAttempts += run.Items
+		retro.Cost.AgentSeconds += run.AgentSeconds
+	}
+
+	if err := observeDeltas(retro, runIDs, opts.SnapshotsDir); err != nil {
+		return nil, err
+	}
+	for i := range retro.Items {
+		retro.Items[i].Verdict = verdict(retro.Items[i])
+	}
+	return retro, nil
+}
+
+// WriteRetro writes retro.json and retro.md into planDir and returns their
+// paths.
+func WriteRetro(planDir string, retro *Retro, loc locale.Locale) (string, string, error) {
+	data, err := json.MarshalIndent(retro, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("marshal retro: %w", err)
+	}
+	jsonPath := filepath.Join(planDir, "retro.json")
+	if err := os.WriteFile(jsonPath, append(data, '\n'), 0o644); err != nil {
+		return "", "", fmt.Errorf("write retro: %w", err)
+	}
+	mdPath := filepath.Join(planDir, "retro.md")
+	if err := os.WriteFile(mdPath, []byte(RenderRetro(retro, loc)), 0o644); err != nil {
+		return "", "", fmt.Errorf("write retro: %w", err)
+	}
+	return jsonPath, mdPath, nil
+}
+
+// RenderRetro formats a retrospective as Markdown.
+func RenderRetro(retro *Retro, loc locale.Locale) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Retrospective: %s\n\n", retro.PlanID)
+	fmt.Fprintf(&b, "- plan as of: %s\n", loc.DateString(retro.PlanAsOf))
+	fmt.Fprintf(&b, "- runs: %s\n", loc.Int(int64(retro.Cost.Runs)))
+	fmt.Fprintf(&b, "- item attempts: %s\n", loc.Int(int64(retro.Cost.ItemAttempts)))
+	fmt.Fprintf(&b, "- agent time: %s\n\n", time.Duration(retro.Cost.AgentSeconds*float64(time.Second)).Round(time.Second))
+
+	if len(retro.Runs) > 0 {
+		b.WriteString("## Runs\n\n| run | items | failures | agent time |\n|---|---|---|---|\n")
+		for _, run := range retro.Runs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", run.RunID, loc.Int(int64(run.Items)), loc.Int(int64(run.Failures)),
+				time.Duration(run.AgentSeconds*float64(time.Second)).Round(time.Second))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Items\n\n")
+	for _, item := range retro.Items {
+		fmt.Fprintf(&b, "### %s (%s): %s\n\n", item.ItemID, item.KRID, item.Verdict)
+		fmt.Fprintf(&b, "- task: %s\n", item.Task)
+		fmt.Fprintf(&b, "- attempts: %s\n", loc.Int(int64(item.Attempts)))
+		if item.Observed != nil {
+			fmt.Fprintf(&b, "- %s: %s → %s by %s (%s %s)\n", item.MetricKey, loc.Number(item.Baseline), loc.Number(*item.Observed),
+				loc.DateString(item.ObservedOn), signed(loc, *item.Delta), item.Direction)
+		} else {
+			fmt.Fprintf(&b, "- %s: no snapshot since the last run\n", item.MetricKey)
+		}
+		for _, class := range item.Failures {
+			fmt.Fprintf(&b, "- failed: %s\n", class)
+		}
+		for _, review := range item.Reviews {
+			if review.Comment != "" {
+				fmt.Fprintf(&b, "- review (%s, run %s): %s\n", review.Decision, review.RunID, review.Comment)
+			} else {
+				fmt.Fprintf(&b, "- review (%s, run %s)\n", review.Decision, review.RunID)
+			}
+		}
+		for _, summary := range item.Summaries {
+			fmt.Fprintf(&b, "- agent summary: %s\n", summary)
+		}
+		b.WriteString("\n")
+	}
+
+	var avoid []string
+	for _, item := range retro.Items {
+		if item.Unsuccessful() {
+			avoid = append(avoid, fmt.Sprintf("- %s (%s): %s", item.KRID, item.Verdict, item.Task))
+		}
+	}
+	if len(avoid) > 0 {
+		b.WriteString("## Do Not Repeat\n\nFuture plans for these KRs are told to try a different approach:\n\n")
+		b.WriteString(strings.Join(avoid, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func signed(loc locale.Locale, v float64) string {
+	if v > 0 {
+		return "+" + loc.Number(v)
+	}
+	return loc.Number(v)
+}
+
+// LoadUnsuccessfulTactics returns, per KR, what earlier plans tried without
+// success according to the retro.json files under plansDir.
+func LoadUnsuccessfulTactics(plansDir string) (map[string][]string, error) {
+	paths, err := filepath.Glob(filepath.Join(plansDir, "*", "retro.json"))
+	if err != nil {
+		return nil, fmt.Errorf("scan retros: %w", err)
+	}
+	sort.Strings(paths)
+	tactics := map[string][]string{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		var retro Retro
+		if err := json.Unmarshal(data, &retro); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		for _, item := range retro.Items {
+			if !item.Unsuccessful() {
+				continue
+			}
+			tactic := item.Task
+			if len(item.Summaries) > 0 {
+				tactic = item.Summaries[len(item.Summaries)-1]
+			}
+			tactics[item.KRID] = append(tactics[item.KRID], fmt.Sprintf("%s (%s, plan %s)", tactic, item.Verdict, retro.PlanID))
+		}
+	}
+	return tactics, nil
+}
+
+// planRunIDs returns the runs the artifacts index attributes to planID.
+func planRunIDs(artifactsDir, planID string) ([]string, error) {
+	idx, err := artifacts.Open(artifactsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Close()
+	entries, err := idx.Find(artifacts.Filter{PlanID: planID, Kind: "item"})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var ids []string
+	for _, e := range entries {
+		if !seen[e.RunID] {
+			seen[e.RunID] = true
+			ids = append(ids, e.RunID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// observeDeltas fills each item's baseline and observed metric from the
+// snapshots around the plan's runs.
+func observeDeltas(retro *Retro, runIDs []string, snapshotsDir string) error {
+	if len(runIDs) == 0 {
+		return nil
+	}
+	paths, err := metrics.SnapshotPaths(snapshotsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	firstDay := runDay(runIDs[0])
+	lastDay := runDay(runIDs[len(runIDs)-1])
+	var before, after *metrics.Snapshot
+	for _, path := range paths {
+		day := strings.TrimSuffix(filepath.Base(path), ".json")
+		if day < firstDay {
+			if before, err = metrics.LoadSnapshot(path); err != nil {
+				return err
+			}
+		}
+		if day >= lastDay {
+			if after, err = metrics.LoadSnapshot(path); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range retro.Items {
+		item := &retro.Items[i]
+		if v, ok := before.Value(item.MetricKey); ok {
+			item.Baseline = v
+		}
+		v, ok := after.Value(item.MetricKey)
+		if !ok {
+			continue
+		}
+		observed := v
+		delta := v - item.Baseline
+		if item.Direction == "decrease" {
+			delta = item.Baseline - v
+		}
+		item.Observed = &observed
+		item.ObservedOn = after.AsOf
+		item.Delta = &delta
+	}
+	return nil
+}
+
+func verdict(item RetroItem) string {
+	approved := false
+	for _, review := range item.Reviews {
+		approved = approved || review.Decision == ReviewApproved
+	}
+	switch {
+	case len(item.Reviews) > 0 && !approved:
+		return VerdictRejected
+	case item.Attempts > 0 && len(item.Failures) == item.Attempts:
+		return VerdictFailed
+	case item.Attempts == 0 || item.Delta == nil:
+		return VerdictPending
+	case *item.Delta > 0:
+		return VerdictImproved
+	}
+	return VerdictNoEffect
+}
+
+// runDay is the YYYY-MM-DD a run started, from its ID (20060102T150405Z).
+func runDay(runID string) string {
+	t, err := time.Parse("20060102T150405Z", runID)
+	if err != nil {
+		return runID
+	}
+	return t.Format("2006-01-02")
+}
+
+// itemSeconds estimates an attempt's agent time as the span from item.json
+// to the item's newest file.
+func itemSeconds(itemDir string) float64 {
+	start, err := os.Stat(filepath.Join(itemDir, "item.json"))
+	if err != nil {
+		return 0
+	}
+	end := start.ModTime()
+	for _, name := range []string{"transcript.log", "transcript.log.gz", "result.json", "failure.json", "violation.json"} {
+		if info, err := os.Stat(filepath.Join(itemDir, name)); err == nil && info.ModTime().After(end) {
+			end = info.ModTime()
+		}
+	}
+	return end.Sub(start.ModTime()).Seconds()
+}
+
+func loadItemFailure(itemDir string) (*ItemFailure, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, "failure.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read failure: %w", err)
+	}
+	var failure ItemFailure
+	if err := json.Unmarshal(data, &failure); err != nil {
+		return nil, fmt.Errorf("parse failure: %w", err)
+	}
+	return &failure, nil
+}
+
+func resultSummary(itemDir string) string {
+	data, err := os.ReadFile(filepath.Join(itemDir, "result.json"))
+	if err != nil {
+		return ""
+	}
+	var result struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(result.Summary)
+}
@@ -0,0 +1,86 @@
+package planner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+)
+
+func TestBuildRetro(t *testing.T) {
+	root := t.TempDir()
+	planPath := writeTestPlan(t, root)
+	artifactsDir := filepath.Join(root, "artifacts")
+	snapshotsDir := filepath.Join(root, "metrics", "snapshots")
+
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:          planPath,
+		WorkDir:           root,
+		Adapter:           &adapters.MockAdapter{},
+		Timeout:           time.Minute,
+		AuditLogger:       audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:        filepath.Join(artifactsDir, "runs"),
+		IndexArtifactsDir: artifactsDir,
+	})
+	if err != nil {
+		t.Fatalf("RunPlan: %v", err)
+	}
+	if _, err := WriteReview(result.ItemRuns[0].ItemDir, Review{RunID: result.RunID, ItemDir: "item-0001", Decision: ReviewApproved, Comment: "looks fine"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The metric did not move between the day before the run and the run day.
+	runDate := result.StartedAt.UTC()
+	for _, day := range []time.Time{runDate.AddDate(0, 0, -1), runDate} {
+		snapshot := metrics.Snapshot{
+			AsOf:   day.Format("2006-01-02"),
+			Points: []metrics.MetricPoint{{Key: "ci.pass_rate", Value: 0.8, Timestamp: day.Format(time.RFC3339), Source: "test"}},
+		}
+		if err := metrics.WriteSnapshot(metrics.SnapshotPathForDate(snapshotsDir, day), snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	retro, err := BuildRetro(RetroOptions{PlanPath: planPath, ArtifactsDir: artifactsDir, SnapshotsDir: snapshotsDir})
+	if err != nil {
+		t.Fatalf("BuildRetro: %v", err)
+	}
+	if retro.Cost.Runs != 1 || retro.Cost.ItemAttempts != 1 {
+		t.Fatalf("cost = %+v, want 1 run and 1 attempt", retro.Cost)
+	}
+	item := retro.Items[0]
+	if item.Verdict != VerdictNoEffect || item.Delta == nil || *item.Delta != 0 {
+		t.Fatalf("item = %+v, want no_effect with zero delta", item)
+	}
+	if len(item.Reviews) != 1 || item.Reviews[0].Comment != "looks fine" || len(item.Summaries) != 1 {
+		t.Fatalf("item reviews/summaries = %+v / %v", item.Reviews, item.Summaries)
+	}
+
+	_, mdPath, err := WriteRetro(root, retro, locale.Canonical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "## Do Not Repeat") {
+		t.Fatalf("retro.md missing Do Not Repeat section:\n%s", md)
+	}
+
+	// retro.json lives in <plans>/<date>/, so the plans dir is root's parent.
+	tactics, err := LoadUnsuccessfulTactics(filepath.Dir(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tactics["KR-1"]; len(got) != 1 || !strings.Contains(got[0], "no_effect, plan plan-test") {
+		t.Fatalf("tactics = %v", tactics)
+	}
+}
@@ -0,0 +1,178 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+const ReviewSchemaVersion = 1
+
+// Review is a human decision on one run item's output, stored as review.json in the item dir.
+type Review struct {
+	SchemaVersion int    `json:"schema_version"`
+	RunID         string `json:"run_id"`
+	ItemDir       string `json:"item_dir"`
+	PlanItemID    string `json:"plan_item_id,omitempty"`
+	ObjectiveID   string `json:"objective_id,omitempty"`
+	KRID          string `json:"kr_id,omitempty"`
+	Decision      string `json:"decision"`
+	Comment       string `json:"comment,omitempty"`
+	Reviewer      string `json:"reviewer,omitempty"`
+	ReviewedAt    string `json:"reviewed_at"`
+}
+
+// RetryKey identifies the reviewed item as <run-id>/<item-dir>.
+func (r Review) RetryKey() string {
+	return r.RunID + "/" + r.ItemDir
+}
+
+// Rejection pairs a rejected review with the plan item that produced the output.
+type Rejection struct {
+	Review Review
+	Item   PlanItem
+}
+
+// ResolveRunItemDir finds an item directory in runDir by directory name
+// ("item-0001"), 1-based index ("1"), or plan item ID.
+func ResolveRunItemDir(runDir, item string) (string, error) {
+	item = strings.TrimSpace(item)
+	if item == "" {
+		return "", fmt.Errorf("item is required")
+	}
+	if info, err := os.Stat(filepath.Join(runDir, item)); err == nil && info.IsDir() {
+		return filepath.Join(runDir, item), nil
+	}
+	if n, err := strconv.Atoi(item); err == nil && n > 0 {
+		dir := filepath.Join(runDir, fmt.Sprintf("item-%04d", n))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	dirs, err := filepath.Glob(filepath.Join(runDir, "item-*"))
+	if err != nil {
+		return "", fmt.Errorf("scan run dir: %w", err)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		planItem, err := LoadRunItem(dir)
+		if err != nil {
+			continue
+		}
+		if planItem.ID == item {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("item %q not found in %s", item, runDir)
+}
+
+// LoadRunItem reads the plan item recorded in a run item dir.
+func LoadRunItem(itemDir string) (PlanItem, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, "item.json"))
+	if err != nil {
+		return PlanItem{}, fmt.Errorf("read item.json: %w", err)
+	}
+	var item PlanItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return PlanItem{}, fmt.Errorf("parse item.json: %w", err)
+	}
+	return item, nil
+}
+
+// WriteReview records a review in itemDir and returns the review path.
+func WriteReview(itemDir string, review Review) (string, error) {
+	if review.Decision != ReviewApproved && review.Decision != ReviewRejected {
+		return "", fmt.Errorf("review decision must be %q or %q", ReviewApproved, ReviewRejected)
+	}
+	if review.SchemaVersion == 0 {
+		review.SchemaVersion = ReviewSchemaVersion
+	}
+	if review.ReviewedAt == "" {
+		review.ReviewedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	path := filepath.Join(itemDir, "review.json")
+	data, err := json.MarshalIndent(review, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal review: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write review: %w", err)
+	}
+	return path, nil
+}
+
+// LoadReview reads review.json from itemDir, returning nil if the item is unreviewed.
+func LoadReview(itemDir string) (*Review, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, "review.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read review: %w", err)
+	}
+	var review Review
+	if err := json.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("parse review: %w", err)
+	}
+	return &review, nil
+}
+
+// PendingRejections returns rejected items under runsDir that no later run
+// has retried, oldest first.
+func PendingRejections(runsDir string) ([]Rejection, error) {
+	itemDirs, err := filepath.Glob(filepath.Join(runsDir, "*", "item-*"))
+	if err != nil {
+		return nil, fmt.Errorf("scan runs dir: %w", err)
+	}
+	sort.Strings(itemDirs)
+
+	retried := make(map[string]bool)
+	var rejections []Rejection
+	for _, dir := range itemDirs {
+		item, err := LoadRunItem(dir)
+		if err != nil {
+			// runs recorded before item.json existed cannot be retried
+			continue
+		}
+		if item.RetryOf != "" {
+			retried[item.RetryOf] = true
+		}
+		review, err := LoadReview(dir)
+		if err != nil {
+			return nil, err
+		}
+		if review == nil || review.Decision != ReviewRejected {
+			continue
+		}
+		rejections = append(rejections, Rejection{Review: *review, Item: item})
+	}
+
+	pending := rejections[:0]
+	for _, rejection := range rejections {
+		if !retried[rejection.Review.RetryKey()] {
+			pending = append(pending, rejection)
+		}
+	}
+	return pending, nil
+}
+
+// retryItem builds a plan item that re-attempts a rejected item with the reviewer's feedback.
+func retryItem(id string, rejection Rejection) PlanItem {
+	item := rejection.Item
+	item.ID = id
+	item.RetryOf = rejection.Review.RetryKey()
+	item.ReviewFeedback = rejection.Review.Comment
+	item.Task = fmt.Sprintf("Retry rejected item %s (run %s): %s", rejection.Item.ID, rejection.Review.RunID, rejection.Item.Task)
+	return item
+}
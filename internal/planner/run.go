@@ -3,16 +3,22 @@ package planner
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"okrchestra/internal/adapters"
+	"okrchestra/internal/artifacts"
 	"okrchestra/internal/audit"
 	"okrchestra/internal/guardrails"
+	"okrchestra/internal/metrics"
 )
 
 type RunOptions struct {
@@ -26,6 +32,105 @@ type RunOptions struct {
 	FollowTranscripts bool
 	FollowLines       int
 	FollowWriter      io.Writer
+
+	// Progress, when set, is called as each item starts and finishes.
+	Progress func(Progress)
+
+	// Parallel is the number of items run at once (default 1). Items only
+	// start once every item in their depends_on has succeeded. Above 1,
+	// every item needs scope_paths.
+	Parallel int
+
+	// ContinueOnError keeps running items after one fails. Only items that
+	// depend on a failed item are skipped, and RunPlan still returns an
+	// error when any item failed.
+	ContinueOnError bool
+
+	// IndexArtifactsDir, when set, is the artifacts dir whose index records
+	// the run's files once the run ends, whether or not it succeeded.
+	IndexArtifactsDir string
+
+	// Language is the workspace language (see locale.LoadLanguage). Prompts
+	// are rendered in it when a template exists, and it is passed to the
+	// adapter. Empty means English.
+	Language string
+	// PromptDir, when set, holds workspace prompt templates that take
+	// precedence over the built-in ones.
+	PromptDir string
+	// RoleTemplateDir, when set, holds per-agent-role plan item templates
+	// that take precedence over PromptDir and the built-in ones.
+	RoleTemplateDir string
+
+	// Measure, when set, is called before and after each item to verify
+	// the item's KR metric moved as expected (see verify.go). A failed
+	// measurement leaves the item unverified; it does not fail the item.
+	Measure MeasureFunc
+
+	// ResumeDir, when set, continues the run in that dir instead of
+	// starting a new one: items its run.json records as succeeded are
+	// skipped and the rest run again. PlanPath defaults to the run's plan.
+	ResumeDir string
+
+	// KeepOKRsEdits leaves an agent's direct edits to okrs/ in place for
+	// inspection instead of reverting them with git. The item still fails
+	// with a guardrail violation.
+	KeepOKRsEdits bool
+
+	// KeepWorktrees leaves scoped items' worktrees in place for
+	// inspection. By default each is removed once its item's hooks and
+	// commit are done, after its changes are saved to ChangesFileName in
+	// the item dir.
+	KeepWorktrees bool
+
+	// Pricing estimates each item's cost from its token usage when the
+	// adapter does not report one.
+	Pricing adapters.Pricing
+	// Budget, when positive, is the most this invocation may spend in
+	// estimated USD. Once the cost of finished items passes it no new items
+	// start, items already running finish, and RunPlan returns an error
+	// wrapping ErrBudgetExceeded.
+	Budget float64
+
+	// Hooks are commands run before and after the run and each item (see
+	// hooks.yml).
+	Hooks Hooks
+
+	// Sandbox, when set, fails an item whose agent changed files the
+	// sandbox does not allow as a guardrail violation. Its paths are
+	// relative to the item's work dir. The run dir and SandboxIgnore,
+	// okrchestra's own state such as the audit DB, are not checked.
+	Sandbox       *adapters.SandboxConfig
+	SandboxIgnore []string
+
+	// Env adds env.yml variables to each item's agent environment, for the
+	// adapter and the item's agent role. Their secret values are added to
+	// Scrubber, which scrubs them from transcripts, results, and audit
+	// events; RunPlan creates one when it is nil.
+	Env      *adapters.EnvConfig
+	Scrubber *adapters.Scrubber
+
+	// Git, when enabled, runs each item on its own branch and commits a
+	// succeeded item's changes there (see git.go).
+	Git GitOptions
+}
+
+// ErrBudgetExceeded is wrapped by the error RunPlan returns when a run
+// stopped at its budget.
+var ErrBudgetExceeded = errors.New("plan run budget exceeded")
+
+// errBudgetStop is returned for items not started because the budget ran
+// out; they stay pending in run.json and are reported as skipped.
+var errBudgetStop = errors.New("not started: budget exceeded")
+
+// Progress describes how far a plan run has advanced.
+type Progress struct {
+	RunID         string
+	PlanID        string
+	ItemIndex     int
+	ItemID        string
+	ItemsDone     int
+	ItemsTotal    int
+	ItemStartedAt time.Time
 }
 
 type RunResult struct {
@@ -33,21 +138,53 @@ type RunResult struct {
 	RunDir    string
 	Plan      Plan
 	ItemRuns  []ItemRunResult
+	Failures  []ItemFailure
 	StartedAt time.Time
 	EndedAt   time.Time
+	// Usage totals what the adapter reported across every item run in this
+	// invocation, including failed ones.
+	Usage adapters.Usage
+	// Skipped lists the IDs of items that never ran, because the run
+	// stopped at a failure or an item they depend on failed.
+	Skipped []string
+}
+
+// Counts returns how many plan items succeeded, failed, and were skipped.
+func (r *RunResult) Counts() (succeeded, failed, skipped int) {
+	succeeded = len(r.ItemRuns)
+	skipped = len(r.Skipped)
+	failed = len(r.Plan.Items) - succeeded - skipped
+	return succeeded, failed, skipped
 }
 
 type ItemRunResult struct {
 	ItemID     string
 	ItemDir    string
 	ResultPath string
+	// Worktree is the sparse worktree holding the agent's changes for
+	// items with scope_paths, when RunOptions.KeepWorktrees is set.
+	Worktree string
+	// Usage is nil when the adapter does not report it.
+	Usage *adapters.Usage
+	// Verification is nil unless RunOptions.Measure is set.
+	Verification *Verification
+	// Git is the item's branch and commit when RunOptions.Git is enabled.
+	Git *GitResult
 }
 
 func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 	if opts.Adapter == nil {
 		return nil, fmt.Errorf("adapter is required")
 	}
+	if opts.Scrubber == nil {
+		opts.Scrubber = adapters.NewScrubber()
+	}
+	// mu guards result and serializes audit writes across parallel items.
+	var mu sync.Mutex
 	logEvent := func(actor string, eventType string, payload any) {
+		payload = opts.Scrubber.Payload(payload)
+		mu.Lock()
+		defer mu.Unlock()
 		if opts.AuditLogger != nil {
 			if err := opts.AuditLogger.LogEvent(actor, eventType, payload); err != nil {
 				return
@@ -56,6 +193,16 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 		}
 		_ = audit.LogEvent(actor, eventType, payload)
 	}
+	var state *RunState
+	if opts.ResumeDir != "" {
+		var err error
+		if state, err = LoadRunState(opts.ResumeDir); err != nil {
+			return nil, err
+		}
+		if opts.PlanPath == "" {
+			opts.PlanPath = state.PlanPath
+		}
+	}
 	planPath, err := ResolvePlanPath(opts.PlanPath)
 	if err != nil {
 		return nil, err
@@ -64,36 +211,237 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Items without scope_paths share the work tree, where the okrs/
+	// integrity check, secret scan, and verification of one item would
+	// see another's edits.
+	if opts.Parallel > 1 {
+		for _, item := range plan.Items {
+			if len(item.ScopePaths) == 0 {
+				return nil, fmt.Errorf("item %s has no scope_paths: unscoped items share the work tree, so they need parallel 1", item.ID)
+			}
+		}
+	}
 
-	runID := time.Now().UTC().Format("20060102T150405Z")
-	runBase := opts.RunBaseDir
-	if runBase == "" {
-		planDir := filepath.Dir(planPath)
-		runBase = filepath.Join(planDir, "runs")
+	now := time.Now().UTC()
+	var runID, runDir string
+	resuming := state != nil
+	if resuming {
+		if err := checkResumable(state, plan); err != nil {
+			return nil, err
+		}
+		if runDir, err = filepath.Abs(opts.ResumeDir); err != nil {
+			return nil, fmt.Errorf("resolve run dir: %w", err)
+		}
+		runID = state.RunID
+		state.Resumes = append(state.Resumes, now.Format(time.RFC3339))
+	} else {
+		runID = now.Format("20060102T150405Z")
+		runBase := opts.RunBaseDir
+		if runBase == "" {
+			planDir := filepath.Dir(planPath)
+			runBase = filepath.Join(planDir, "runs")
+		}
+		runDir = filepath.Join(runBase, runID)
+		if err := os.MkdirAll(runDir, 0o755); err != nil {
+			return nil, fmt.Errorf("ensure run dir: %w", err)
+		}
+		state = &RunState{
+			SchemaVersion: RunStateSchemaVersion,
+			RunID:         runID,
+			PlanID:        plan.ID,
+			PlanPath:      planPath,
+			Adapter:       opts.Adapter.Name(),
+			StartedAt:     now.Format(time.RFC3339),
+		}
+		for i, item := range plan.Items {
+			state.Items = append(state.Items, RunItemState{
+				ItemID:  item.ID,
+				ItemDir: fmt.Sprintf("item-%04d", i+1),
+				Status:  ItemPending,
+			})
+		}
+	}
+	var gitState *gitRun
+	if opts.Git.Enabled() {
+		if gitState, err = startGitRun(tailContext(ctx), opts.Git, opts.WorkDir, runDir); err != nil {
+			if !resuming {
+				_ = os.Remove(runDir)
+			}
+			return nil, err
+		}
 	}
-	runDir := filepath.Join(runBase, runID)
-	if err := os.MkdirAll(runDir, 0o755); err != nil {
-		return nil, fmt.Errorf("ensure run dir: %w", err)
+	state.Status, state.FinishedAt, state.Error, state.Counts = RunRunning, "", "", nil
+	state.UpdatedAt = now.Format(time.RFC3339)
+	if err := WriteRunState(runDir, state); err != nil {
+		return nil, err
 	}
 
 	result := &RunResult{
 		RunID:     runID,
 		RunDir:    runDir,
 		Plan:      plan,
-		StartedAt: time.Now().UTC(),
+		StartedAt: now,
+	}
+	if opts.IndexArtifactsDir != "" {
+		defer indexRun(opts.IndexArtifactsDir, result)
+	}
+
+	// Items that succeeded before a resume keep their results.
+	skipped := map[int]bool{}
+	if resuming {
+		var skippedIDs, resumedIDs []string
+		for i, itemState := range state.Items {
+			resultPath := filepath.Join(runDir, itemState.ItemDir, "result.json")
+			if itemState.Status == ItemSucceeded && guardrails.ValidateResultJSON(resultPath) == nil {
+				skipped[i] = true
+				skippedIDs = append(skippedIDs, itemState.ItemID)
+			} else {
+				resumedIDs = append(resumedIDs, itemState.ItemID)
+			}
+		}
+		logEvent("scheduler", "plan_run_resumed", map[string]any{
+			"run_id":        runID,
+			"run_dir":       runDir,
+			"plan_id":       plan.ID,
+			"skipped_items": skippedIDs,
+			"resumed_items": resumedIDs,
+		})
+	}
+
+	// runEnv is what every hook sees besides the item's variables.
+	runEnv := map[string]string{
+		"OKRCHESTRA_RUN_ID":    runID,
+		"OKRCHESTRA_RUN_DIR":   runDir,
+		"OKRCHESTRA_PLAN_ID":   plan.ID,
+		"OKRCHESTRA_PLAN_PATH": planPath,
+	}
+	// hook runs a phase's hooks and logs each one's outcome.
+	hook := func(phase, workDir, logDir string, env map[string]string, item *PlanItem) error {
+		hooks := opts.Hooks.Phase(phase)
+		if len(hooks) == 0 {
+			return nil
+		}
+		merged := map[string]string{}
+		for k, v := range runEnv {
+			merged[k] = v
+		}
+		for k, v := range env {
+			merged[k] = v
+		}
+		results, err := runHooks(ctx, phase, hooks, workDir, logDir, merged)
+		for _, res := range results {
+			payload := map[string]any{
+				"run_id":  runID,
+				"plan_id": plan.ID,
+				"hook":    res,
+			}
+			if item != nil {
+				payload["plan_item_id"] = item.ID
+			}
+			logEvent("scheduler", "plan_hook_finished", payload)
+		}
+		return err
 	}
 
-	for idx, item := range plan.Items {
+	budgetExceeded := false
+
+	// checkpoint records an item starting, or finishing with itemErr, in
+	// run.json.
+	checkpoint := func(idx int, starting bool, itemErr error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		itemState := &state.Items[idx]
+		ts := time.Now().UTC().Format(time.RFC3339)
+		switch {
+		case starting:
+			itemState.Status = ItemRunning
+			itemState.Attempts++
+			itemState.StartedAt = ts
+			itemState.FinishedAt = ""
+			itemState.FailureClass = ""
+			itemState.Error = ""
+			itemState.Usage = nil
+			itemState.ExitCode = nil
+			itemState.ResultPath = ""
+			itemState.Verification = nil
+			itemState.Git = nil
+		case itemErr != nil:
+			itemState.Status = ItemFailed
+			itemState.FinishedAt = ts
+			itemState.FailureClass, _ = ClassifyFailure(itemErr)
+			itemState.Error = itemErr.Error()
+		default:
+			itemState.Status = ItemSucceeded
+			itemState.FinishedAt = ts
+		}
+		state.UpdatedAt = ts
+		return WriteRunState(runDir, state)
+	}
+
+	reportProgress := func(idx int, itemID string, itemStarted time.Time) {
+		if opts.Progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		opts.Progress(Progress{
+			RunID:         runID,
+			PlanID:        plan.ID,
+			ItemIndex:     idx,
+			ItemID:        itemID,
+			ItemsDone:     len(result.ItemRuns),
+			ItemsTotal:    len(plan.Items),
+			ItemStartedAt: itemStarted,
+		})
+	}
+
+	// fail records a classified item failure in failure.json and the result.
+	fail := func(item PlanItem, itemDir string, class FailureClass, err error) error {
+		failure := ItemFailure{
+			Class:      class,
+			RunID:      runID,
+			ItemDir:    filepath.Base(itemDir),
+			PlanItemID: item.ID,
+			KRID:       item.KRID,
+			Message:    err.Error(),
+		}
+		if _, writeErr := WriteFailure(itemDir, failure); writeErr != nil {
+			return fmt.Errorf("%w (record failure: %v)", err, writeErr)
+		}
+		mu.Lock()
+		result.Failures = append(result.Failures, failure)
+		mu.Unlock()
+		return &ItemError{Class: class, ItemID: item.ID, ItemDir: itemDir, Err: err}
+	}
+
+	// runItem runs one item and returns its result once it succeeded.
+	runItem := func(idx int, itemStarted time.Time) (itemRun *ItemRunResult, err error) {
+		item := plan.Items[idx]
 		itemDir := filepath.Join(runDir, fmt.Sprintf("item-%04d", idx+1))
 		if err := os.MkdirAll(itemDir, 0o755); err != nil {
-			return result, fmt.Errorf("ensure item dir: %w", err)
+			return nil, fmt.Errorf("ensure item dir: %w", err)
+		}
+		reportProgress(idx+1, item.ID, itemStarted)
+
+		// Secrets are resolved first, so following the transcript already
+		// scrubs them.
+		envVars, err := opts.Env.Resolve(tailContext(ctx), opts.Adapter.Name(), item.AgentRole, opts.Scrubber)
+		if err != nil {
+			return nil, fmt.Errorf("agent environment for item %s: %w", item.ID, err)
 		}
 
 		transcriptPath := filepath.Join(itemDir, "transcript.log")
-		var stopFollow func()
+		stopFollow := func() {}
 		if opts.FollowTranscripts && opts.FollowWriter != nil {
-			stopFollow = followTranscript(tailContext(ctx), transcriptPath, opts.FollowLines, opts.FollowWriter, item.ID)
+			followWriter := opts.Scrubber.Writer(opts.FollowWriter)
+			stopTranscript := FollowTranscript(tailContext(ctx), transcriptPath, opts.FollowLines, followWriter, item.ID)
+			stopFollow = sync.OnceFunc(func() {
+				stopTranscript()
+				_ = followWriter.Close()
+			})
 		}
+		// Following stops once the agent exits, or on any earlier return.
+		defer stopFollow()
 
 		startPayload := map[string]any{
 			"run_id":       runID,
@@ -108,62 +456,262 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 			"workdir":      opts.WorkDir,
 			"item_dir":     itemDir,
 		}
+		if attempt := state.Items[idx].Attempts; attempt > 1 {
+			startPayload["attempt"] = attempt
+		}
 		logEvent("scheduler", "plan_item_started", startPayload)
 
+		itemData, err := json.MarshalIndent(item, "", "  ")
+		if err != nil {
+			return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("marshal item: %w", err))
+		}
+		if err := os.WriteFile(filepath.Join(itemDir, "item.json"), append(itemData, '\n'), 0o644); err != nil {
+			return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("write item: %w", err))
+		}
+
+		agentWorkDir := opts.WorkDir
+		var worktree *scopedWorktree
+		if len(item.ScopePaths) > 0 {
+			// A retried item gets a fresh worktree.
+			if err := removeWorktree(tailContext(ctx), opts.WorkDir, filepath.Join(itemDir, "worktree")); err != nil {
+				return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("prepare scope for item %s: %w", item.ID, err))
+			}
+			worktree, err = prepareScopedWorktree(tailContext(ctx), opts.WorkDir, itemDir, item.ScopePaths)
+			if err != nil {
+				return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("prepare scope for item %s: %w", item.ID, err))
+			}
+			agentWorkDir = worktree.WorkDir
+			if !opts.KeepWorktrees {
+				// Registered first, so it runs after the post_item hooks
+				// and commit.
+				defer func() {
+					cleanupCtx := context.WithoutCancel(tailContext(ctx))
+					cleanupErr := worktree.SaveChanges(cleanupCtx, filepath.Join(itemDir, ChangesFileName))
+					if cleanupErr == nil {
+						cleanupErr = removeWorktree(cleanupCtx, opts.WorkDir, worktree.Dir)
+					}
+					if cleanupErr != nil && err == nil {
+						itemRun, err = nil, fmt.Errorf("item %s: %w", item.ID, cleanupErr)
+					}
+				}()
+			}
+		}
+
+		prompt, err := renderPrompt(item, itemDir, opts.Language, opts.PromptDir, opts.RoleTemplateDir)
+		if err != nil {
+			return nil, fail(item, itemDir, FailureSetupFailed, err)
+		}
 		promptPath := filepath.Join(itemDir, "prompt.md")
-		if err := os.WriteFile(promptPath, []byte(renderPrompt(item, itemDir)), 0o644); err != nil {
-			return result, fmt.Errorf("write prompt: %w", err)
+		if err := os.WriteFile(promptPath, []byte(prompt), 0o644); err != nil {
+			return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("write prompt: %w", err))
+		}
+
+		itemEnv := map[string]string{
+			"OKRCHESTRA_PLAN_ID":         plan.ID,
+			"OKRCHESTRA_PLAN_ITEM_ID":    item.ID,
+			"OKRCHESTRA_PLAN_ITEM_DIR":   itemDir,
+			"OKRCHESTRA_AGENT_RESULT":    filepath.Join(itemDir, "result.json"),
+			"OKRCHESTRA_OBJECTIVE_ID":    item.ObjectiveID,
+			"OKRCHESTRA_KR_ID":           item.KRID,
+			"OKRCHESTRA_METRIC_KEY":      item.ExpectedMetricChange.MetricKey,
+			"OKRCHESTRA_METRIC_TARGET":   fmt.Sprintf("%g", item.ExpectedMetricChange.Target),
+			"OKRCHESTRA_METRIC_BASELINE": fmt.Sprintf("%g", item.ExpectedMetricChange.Baseline),
+		}
+		if worktree != nil {
+			itemEnv["OKRCHESTRA_WORKTREE"] = worktree.Dir
+			itemEnv["OKRCHESTRA_SCOPE_PATHS"] = strings.Join(item.ScopePaths, string(os.PathListSeparator))
+		}
+
+		// Items on branches start from the run's base commit, in the
+		// repository root or their scoped worktree.
+		branch, gitDir := "", ""
+		if gitState != nil {
+			branch, gitDir = ItemBranch(plan.ID, item.ID), gitState.repoRoot
+			if worktree != nil {
+				gitDir = worktree.Dir
+			}
+			if err := gitState.startItem(tailContext(ctx), gitDir, branch); err != nil {
+				return nil, err
+			}
+			itemEnv["OKRCHESTRA_GIT_BRANCH"] = branch
+		}
+		secretsFound := 0
+
+		// post_item hooks run once pre_item hooks have, however the item
+		// ends; an abort failure fails an item that had succeeded. A
+		// succeeded item is then committed to its branch.
+		defer func() {
+			env := map[string]string{"OKRCHESTRA_ITEM_STATUS": ItemSucceeded}
+			if err != nil {
+				env["OKRCHESTRA_ITEM_STATUS"] = ItemFailed
+			}
+			for k, v := range itemEnv {
+				env[k] = v
+			}
+			if hookErr := hook(HookPostItem, agentWorkDir, itemDir, env, &item); hookErr != nil && err == nil {
+				itemRun, err = nil, fail(item, itemDir, FailureHookFailed, hookErr)
+			}
+			if gitState == nil {
+				return
+			}
+			var gitErr error
+			if err == nil {
+				gitRes := &GitResult{Branch: branch}
+				gitRes.Commit, gitErr = gitState.commitItem(tailContext(ctx), gitDir, plan, item, runID)
+				if gitErr == nil && gitRes.Commit != "" && gitState.opts.PullRequests {
+					if secretsFound > 0 {
+						gitRes.PRError = fmt.Sprintf("not pushed: secrets found in the agent's changes (see %s)", filepath.Join(itemDir, guardrails.SecretsFileName))
+					} else if url, prErr := gitState.openPullRequest(tailContext(ctx), gitDir, branch, plan, item, runID); prErr != nil {
+						gitRes.PRError = prErr.Error()
+					} else {
+						gitRes.PRURL = url
+					}
+				}
+				if gitErr == nil {
+					itemRun.Git = gitRes
+					mu.Lock()
+					state.Items[idx].Git = gitRes
+					mu.Unlock()
+					logEvent("scheduler", "plan_item_committed", map[string]any{
+						"run_id":       runID,
+						"plan_id":      plan.ID,
+						"plan_item_id": item.ID,
+						"objective_id": item.ObjectiveID,
+						"kr_id":        item.KRID,
+						"item_dir":     itemDir,
+						"git":          gitRes,
+					})
+				}
+			}
+			// The work tree goes back to the starting branch even when the
+			// run was canceled, so the next run starts where this one did.
+			if worktree == nil {
+				label := fmt.Sprintf("okrchestra %s %s (failed)", runID, item.ID)
+				if finishErr := gitState.finishItem(context.WithoutCancel(tailContext(ctx)), err != nil || gitErr != nil, label); finishErr != nil && gitErr == nil {
+					gitErr = finishErr
+				}
+			}
+			if gitErr != nil && err == nil {
+				itemRun, err = nil, fmt.Errorf("item %s: %w", item.ID, gitErr)
+			}
+		}()
+		// Hooks run before the guardrail baselines below, so their edits
+		// are not blamed on the agent.
+		if err := hook(HookPreItem, agentWorkDir, itemDir, itemEnv, &item); err != nil {
+			return nil, fail(item, itemDir, FailureHookFailed, err)
 		}
 
 		// Capture OKRs directory state before adapter run
 		wsRoot, err := guardrails.NormalizeWorkDir(opts.WorkDir)
 		if err != nil {
-			return result, fmt.Errorf("normalize work dir: %w", err)
+			return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("normalize work dir: %w", err))
 		}
 		integrityCheck, err := guardrails.NewIntegrityCheck(wsRoot)
 		if err != nil {
-			return result, fmt.Errorf("create integrity check: %w", err)
+			return nil, fail(item, itemDir, FailureSetupFailed, fmt.Errorf("create integrity check: %w", err))
+		}
+		// Secrets already in the work tree are not the agent's doing
+		secretBaseline, _ := guardrails.ScanGitDiff(agentWorkDir)
+
+		var before *metrics.Snapshot
+		var measureErr error
+		if opts.Measure != nil {
+			before, measureErr = opts.Measure(tailContext(ctx))
 		}
 
+		agentEnv := make(map[string]string, len(envVars)+len(itemEnv))
+		for k, v := range envVars {
+			agentEnv[k] = v
+		}
+		for k, v := range itemEnv {
+			agentEnv[k] = v
+		}
 		cfg := adapters.RunConfig{
 			PromptPath:   promptPath,
-			WorkDir:      opts.WorkDir,
+			WorkDir:      agentWorkDir,
 			ArtifactsDir: itemDir,
-			Env: map[string]string{
-				"OKRCHESTRA_PLAN_ID":         plan.ID,
-				"OKRCHESTRA_PLAN_ITEM_ID":    item.ID,
-				"OKRCHESTRA_PLAN_ITEM_DIR":   itemDir,
-				"OKRCHESTRA_AGENT_RESULT":    filepath.Join(itemDir, "result.json"),
-				"OKRCHESTRA_OBJECTIVE_ID":    item.ObjectiveID,
-				"OKRCHESTRA_KR_ID":           item.KRID,
-				"OKRCHESTRA_METRIC_KEY":      item.ExpectedMetricChange.MetricKey,
-				"OKRCHESTRA_METRIC_TARGET":   fmt.Sprintf("%g", item.ExpectedMetricChange.Target),
-				"OKRCHESTRA_METRIC_BASELINE": fmt.Sprintf("%g", item.ExpectedMetricChange.Baseline),
-			},
-			Timeout: opts.Timeout,
+			Env:          agentEnv,
+			Timeout:      opts.Timeout,
+			Language:     opts.Language,
+			Scrubber:     opts.Scrubber,
 		}
 
-		adapterResult, runErr := opts.Adapter.Run(ctx, cfg)
-		if stopFollow != nil {
-			stopFollow()
+		if opts.Sandbox != nil {
+			if cfg.Sandbox, err = opts.Sandbox.Resolve(agentWorkDir, append([]string{runDir}, opts.SandboxIgnore...)...); err != nil {
+				return nil, fmt.Errorf("resolve sandbox for item %s: %w", item.ID, err)
+			}
+		}
+
+		adapterResult, runErr := adapters.Run(ctx, opts.Adapter, cfg)
+		stopFollow()
+		var usage *adapters.Usage
+		if adapterResult != nil && adapterResult.Usage != nil {
+			usage = adapterResult.Usage
+			opts.Pricing.Apply(usage)
+			mu.Lock()
+			result.Usage.Add(*usage)
+			state.Usage.Add(*usage)
+			state.Items[idx].Usage = usage
+			overBudget := opts.Budget > 0 && result.Usage.CostUSD > opts.Budget && !budgetExceeded
+			if overBudget {
+				budgetExceeded = true
+			}
+			spent := result.Usage.CostUSD
+			mu.Unlock()
+			if overBudget {
+				logEvent("scheduler", "plan_run_budget_exceeded", map[string]any{
+					"run_id":       runID,
+					"plan_id":      plan.ID,
+					"plan_item_id": item.ID,
+					"budget_usd":   opts.Budget,
+					"spent_usd":    spent,
+				})
+			}
+		}
+
+		// Redact secrets from stored outputs before anything else reads them
+		secretFindings, err := scanItemSecrets(itemDir, agentWorkDir, secretBaseline)
+		if err != nil {
+			return nil, fmt.Errorf("scan item %s for secrets: %w", item.ID, err)
+		}
+		secretsFound = len(secretFindings)
+		if len(secretFindings) > 0 {
+			if err := guardrails.WriteSecretFindings(itemDir, secretFindings); err != nil {
+				return nil, err
+			}
+			logEvent("daemon", "guardrail_violation", map[string]any{
+				"violation_type": "secret_leak",
+				"run_id":         runID,
+				"plan_id":        plan.ID,
+				"plan_item_id":   item.ID,
+				"item_dir":       itemDir,
+				"findings":       secretFindings,
+			})
 		}
 
 		// Check for unauthorized OKRs directory modifications
 		if err := integrityCheck.CaptureAfter(); err != nil {
-			return result, fmt.Errorf("capture post-run snapshot: %w", err)
+			return nil, fmt.Errorf("capture post-run snapshot: %w", err)
 		}
 
 		if integrityCheck.HasChanges() {
 			changedFiles, _ := integrityCheck.GetChangedFiles()
-			
-			// Attempt to revert the unauthorized changes
-			revertErr := guardrails.RevertOKRs(wsRoot)
-			
+
+			// Attempt to revert the unauthorized changes, unless they are
+			// being kept for inspection
+			var revertErr error
+			reverted := false
+			if !opts.KeepOKRsEdits {
+				revertErr = integrityCheck.Revert(wsRoot)
+				reverted = revertErr == nil
+			}
+
 			// Build violation record
 			violation := guardrails.BuildViolation("okrs_direct_edit", map[string]any{
 				"message":       "Agent directly modified okrs/ directory, which is prohibited by AGENTS.md",
 				"changed_files": changedFiles,
-				"reverted":      revertErr == nil,
+				"changes":       integrityCheck.Changes(),
+				"reverted":      reverted,
 				"revert_error":  guardrails.SanitizeErrorForJSON(revertErr),
 				"item_id":       item.ID,
 				"run_id":        runID,
@@ -171,7 +719,7 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 
 			// Write violation.json
 			if err := guardrails.WriteViolation(itemDir, violation); err != nil {
-				return result, fmt.Errorf("write violation record: %w", err)
+				return nil, fmt.Errorf("write violation record: %w", err)
 			}
 
 			// Log audit event
@@ -182,10 +730,75 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 				"plan_item_id":   item.ID,
 				"item_dir":       itemDir,
 				"changed_files":  changedFiles,
-				"reverted":       revertErr == nil,
+				"reverted":       reverted,
+				"failure_class":  FailureGuardrailViolation,
+			})
+
+			return nil, fail(item, itemDir, FailureGuardrailViolation, fmt.Errorf("guardrail violation: agent modified okrs/ directory (see %s/violation.json)", itemDir))
+		}
+
+		// Sandboxed agents may only change files their sandbox allows.
+		var sandboxViolation *adapters.SandboxViolation
+		if errors.As(runErr, &sandboxViolation) {
+			violation := guardrails.BuildViolation("sandbox_violation", map[string]any{
+				"message":     "Agent changed files outside its sandbox",
+				"changes":     sandboxViolation.Changes,
+				"sandbox":     sandboxViolation.Sandbox,
+				"tree_before": sandboxViolation.TreeBefore,
+				"tree_after":  sandboxViolation.TreeAfter,
+				"agent_error": guardrails.SanitizeErrorForJSON(sandboxViolation.Err),
+				"item_id":     item.ID,
+				"run_id":      runID,
+			})
+			if err := guardrails.WriteViolation(itemDir, violation); err != nil {
+				return nil, fmt.Errorf("write violation record: %w", err)
+			}
+			logEvent("daemon", "guardrail_violation", map[string]any{
+				"violation_type": "sandbox_violation",
+				"run_id":         runID,
+				"plan_id":        plan.ID,
+				"plan_item_id":   item.ID,
+				"item_dir":       itemDir,
+				"changes":        sandboxViolation.Changes,
+				"failure_class":  FailureGuardrailViolation,
 			})
+			return nil, fail(item, itemDir, FailureGuardrailViolation, fmt.Errorf("guardrail violation: %s (see %s/violation.json)", sandboxViolation.Error(), itemDir))
+		}
 
-			return result, fmt.Errorf("guardrail violation: agent modified okrs/ directory (see %s/violation.json)", itemDir)
+		// Scoped items may only change files inside their scope paths.
+		if worktree != nil {
+			outside, err := worktree.OutOfScope(tailContext(ctx))
+			if err != nil {
+				return nil, err
+			}
+			if len(outside) > 0 {
+				details := map[string]any{
+					"message":       "Agent changed files outside the item's scope_paths",
+					"changed_files": outside,
+					"scope_paths":   item.ScopePaths,
+					"item_id":       item.ID,
+					"run_id":        runID,
+				}
+				if opts.KeepWorktrees {
+					details["worktree"] = worktree.Dir
+				} else {
+					details["changes"] = filepath.Join(itemDir, ChangesFileName)
+				}
+				violation := guardrails.BuildViolation("out_of_scope_edit", details)
+				if err := guardrails.WriteViolation(itemDir, violation); err != nil {
+					return nil, fmt.Errorf("write violation record: %w", err)
+				}
+				logEvent("daemon", "guardrail_violation", map[string]any{
+					"violation_type": "out_of_scope_edit",
+					"run_id":         runID,
+					"plan_id":        plan.ID,
+					"plan_item_id":   item.ID,
+					"item_dir":       itemDir,
+					"changed_files":  outside,
+					"failure_class":  FailureGuardrailViolation,
+				})
+				return nil, fail(item, itemDir, FailureGuardrailViolation, fmt.Errorf("guardrail violation: agent changed files outside scope_paths (see %s/violation.json)", itemDir))
+			}
 		}
 
 		finishPayload := map[string]any{
@@ -199,9 +812,25 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 			"adapter":      opts.Adapter.Name(),
 			"item_dir":     itemDir,
 		}
+		if worktree != nil {
+			if opts.KeepWorktrees {
+				finishPayload["worktree"] = worktree.Dir
+			}
+			finishPayload["scope_paths"] = item.ScopePaths
+		}
+		if len(secretFindings) > 0 {
+			finishPayload["secrets_found"] = len(secretFindings)
+		}
 		if adapterResult != nil {
+			exitCode := adapterResult.ExitCode
+			mu.Lock()
+			state.Items[idx].ExitCode = &exitCode
+			mu.Unlock()
 			finishPayload["exit_code"] = adapterResult.ExitCode
 			finishPayload["transcript"] = adapterResult.TranscriptPath
+			if usage != nil {
+				finishPayload["usage"] = usage
+			}
 		}
 
 		resultPath := filepath.Join(itemDir, "result.json")
@@ -210,73 +839,195 @@ func RunPlan(ctx context.Context, opts RunOptions) (*RunResult, error) {
 			if validateErr == nil {
 				finishPayload["adapter_error"] = runErr.Error()
 			} else {
+				class := classifyAdapterFailure(adapterResult, runErr)
 				finishPayload["error"] = runErr.Error()
 				finishPayload["result_error"] = validateErr.Error()
+				finishPayload["failure_class"] = class
 				logEvent("scheduler", "plan_item_finished", finishPayload)
 				if adapterResult != nil && adapterResult.TranscriptPath != "" {
-					return result, fmt.Errorf("agent run failed for item %s (see %s): %w", item.ID, adapterResult.TranscriptPath, runErr)
+					return nil, fail(item, itemDir, class, fmt.Errorf("agent run failed for item %s (see %s): %w", item.ID, adapterResult.TranscriptPath, runErr))
 				}
-				return result, fmt.Errorf("agent run failed for item %s: %w", item.ID, runErr)
+				return nil, fail(item, itemDir, class, fmt.Errorf("agent run failed for item %s: %w", item.ID, runErr))
 			}
 		}
 		if validateErr != nil {
 			finishPayload["error"] = validateErr.Error()
+			finishPayload["failure_class"] = FailureResultInvalid
 			logEvent("scheduler", "plan_item_finished", finishPayload)
-			return result, fmt.Errorf("agent result invalid for item %s: %w", item.ID, validateErr)
+			return nil, fail(item, itemDir, FailureResultInvalid, fmt.Errorf("agent result invalid for item %s: %w", item.ID, validateErr))
 		}
 
 		finishPayload["result_json"] = resultPath
 		logEvent("scheduler", "plan_item_finished", finishPayload)
 
-		result.ItemRuns = append(result.ItemRuns, ItemRunResult{
+		itemRun = &ItemRunResult{
 			ItemID:     item.ID,
 			ItemDir:    itemDir,
 			ResultPath: resultPath,
-		})
+			Usage:      usage,
+		}
+		mu.Lock()
+		state.Items[idx].ResultPath = filepath.ToSlash(filepath.Join(state.Items[idx].ItemDir, "result.json"))
+		mu.Unlock()
+		if opts.Measure != nil {
+			var after *metrics.Snapshot
+			if measureErr == nil {
+				after, measureErr = opts.Measure(tailContext(ctx))
+			}
+			verification := verifyItem(item, before, after, measureErr)
+			if err := WriteVerification(itemDir, verification); err != nil {
+				return nil, err
+			}
+			itemRun.Verification = &verification
+			mu.Lock()
+			state.Items[idx].Verification = &verification
+			mu.Unlock()
+			logEvent("scheduler", "plan_item_verified", map[string]any{
+				"run_id":       runID,
+				"plan_id":      plan.ID,
+				"plan_item_id": item.ID,
+				"item_dir":     itemDir,
+				"verification": verification,
+			})
+		}
+		if worktree != nil && opts.KeepWorktrees {
+			itemRun.Worktree = worktree.Dir
+		}
+		return itemRun, nil
+	}
+
+	runTracked := func(idx int) error {
+		item := plan.Items[idx]
+		itemDir := filepath.Join(runDir, state.Items[idx].ItemDir)
+		if skipped[idx] {
+			logEvent("scheduler", "plan_item_skipped", map[string]any{
+				"run_id":       runID,
+				"plan_id":      plan.ID,
+				"plan_item_id": item.ID,
+				"item_dir":     itemDir,
+				"reason":       "succeeded before resume",
+			})
+			mu.Lock()
+			result.ItemRuns = append(result.ItemRuns, ItemRunResult{
+				ItemID:       item.ID,
+				ItemDir:      itemDir,
+				ResultPath:   filepath.Join(itemDir, "result.json"),
+				Verification: state.Items[idx].Verification,
+				Git:          state.Items[idx].Git,
+			})
+			mu.Unlock()
+			return nil
+		}
+		mu.Lock()
+		stop := budgetExceeded
+		mu.Unlock()
+		if stop {
+			return errBudgetStop
+		}
+		if state.Items[idx].Attempts > 0 {
+			// Drop the previous attempt's outcome so it cannot be mistaken
+			// for this one's.
+			for _, name := range []string{"result.json", "failure.json", "violation.json", VerificationFileName, guardrails.SecretsFileName} {
+				if err := os.Remove(filepath.Join(itemDir, name)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("clear previous attempt of item %s: %w", item.ID, err)
+				}
+			}
+		}
+		if err := checkpoint(idx, true, nil); err != nil {
+			return err
+		}
+		itemStarted := time.Now().UTC()
+		itemRun, err := runItem(idx, itemStarted)
+		if checkpointErr := checkpoint(idx, false, err); err == nil {
+			err = checkpointErr
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		result.ItemRuns = append(result.ItemRuns, *itemRun)
+		mu.Unlock()
+		reportProgress(idx+1, item.ID, itemStarted)
+		return nil
 	}
 
+	// A failed pre_run hook runs no items; post_run hooks run either way.
+	var runErr error
+	hookErr := hook(HookPreRun, opts.WorkDir, runDir, nil, nil)
+	if hookErr == nil {
+		runErr = runGraph(plan.Items, opts.Parallel, opts.ContinueOnError, runTracked)
+	}
+	runStatus := ItemSucceeded
+	if runErr != nil || hookErr != nil {
+		runStatus = ItemFailed
+	}
+	if err := hook(HookPostRun, opts.WorkDir, runDir, map[string]string{"OKRCHESTRA_RUN_STATUS": runStatus}, nil); err != nil && hookErr == nil {
+		hookErr = err
+	}
+	// Items finish in any order when run in parallel; report them in plan
+	// order so results stay deterministic.
+	sort.Slice(result.ItemRuns, func(i, j int) bool { return result.ItemRuns[i].ItemDir < result.ItemRuns[j].ItemDir })
+	sort.Slice(result.Failures, func(i, j int) bool { return result.Failures[i].ItemDir < result.Failures[j].ItemDir })
+	for _, itemState := range state.Items {
+		if itemState.Status == ItemPending {
+			result.Skipped = append(result.Skipped, itemState.ItemID)
+		}
+	}
 	result.EndedAt = time.Now().UTC()
-	return result, nil
-}
+	err = nil
+	status := RunFailed
+	switch {
+	case errors.Is(runErr, errBudgetStop):
+		status = RunBudgetExceeded
+		err = fmt.Errorf("%w: spent an estimated $%.2f of $%.2f; %d of %d items not run",
+			ErrBudgetExceeded, result.Usage.CostUSD, opts.Budget, len(result.Skipped), len(plan.Items))
+	case runErr != nil && opts.ContinueOnError:
+		_, failed, skippedCount := result.Counts()
+		logEvent("scheduler", "plan_run_items_failed", map[string]any{
+			"run_id":        runID,
+			"plan_id":       plan.ID,
+			"items_total":   len(plan.Items),
+			"items_failed":  failed,
+			"items_skipped": skippedCount,
+			"skipped_items": result.Skipped,
+		})
+		err = fmt.Errorf("%d of %d plan items failed (first: %w)", failed, len(plan.Items), runErr)
+	case runErr != nil:
+		err = runErr
+	case hookErr != nil:
+		err = hookErr
+	default:
+		status = RunSucceeded
+	}
 
-func renderPrompt(item PlanItem, itemDir string) string {
-	var b strings.Builder
-	b.WriteString("# OKRchestra Plan Item\n\n")
-	b.WriteString("You are executing a single plan item for OKR-driven work.\n\n")
-	fmt.Fprintf(&b, "- objective_id: %s\n", item.ObjectiveID)
-	fmt.Fprintf(&b, "- kr_id: %s\n", item.KRID)
-	fmt.Fprintf(&b, "- agent_role: %s\n\n", item.AgentRole)
-	fmt.Fprintf(&b, "## Task\n%s\n\n", item.Task)
-	fmt.Fprintf(&b, "## Hypothesis\n%s\n\n", item.Hypothesis)
-	fmt.Fprintf(&b, "## Expected Metric Change\n- metric_key: %s\n- direction: %s\n- baseline: %g\n- target: %g\n- delta: %g\n\n",
-		item.ExpectedMetricChange.MetricKey,
-		item.ExpectedMetricChange.Direction,
-		item.ExpectedMetricChange.Baseline,
-		item.ExpectedMetricChange.Target,
-		item.ExpectedMetricChange.Delta,
-	)
-	if len(item.EvidencePlan) > 0 {
-		b.WriteString("## Evidence Plan\n")
-		for _, step := range item.EvidencePlan {
-			fmt.Fprintf(&b, "- %s\n", step)
-		}
-		b.WriteString("\n")
-	}
-
-	b.WriteString("## Required Output\n")
-	b.WriteString("Write `result.json` to the artifacts directory for this item:\n\n")
-	fmt.Fprintf(&b, "- %s\n\n", filepath.Join(itemDir, "result.json"))
-	b.WriteString("The file must be valid JSON and include these fields:\n")
-	b.WriteString("- `schema_version` (string, must be \"1.0\")\n")
-	b.WriteString("- `summary` (string)\n")
-	b.WriteString("- `proposed_changes` (array of strings)\n")
-	b.WriteString("- `kr_targets` (array of strings, KR IDs affected)\n")
-	b.WriteString("- `kr_impact_claim` (string)\n\n")
-	b.WriteString("Do not include additional top-level keys.\n\n")
-	b.WriteString("If you made no code changes, keep `proposed_changes` empty but explain why in `summary`.\n")
-	return b.String()
+	// The final run.json summarizes the run.
+	mu.Lock()
+	state.Status = status
+	state.FinishedAt = result.EndedAt.Format(time.RFC3339)
+	state.UpdatedAt = state.FinishedAt
+	counts := state.CountItems()
+	state.Counts = &counts
+	if err != nil {
+		state.Error = err.Error()
+	}
+	stateErr := WriteRunState(runDir, state)
+	mu.Unlock()
+	if stateErr != nil && err == nil {
+		err = stateErr
+	}
+	return result, err
 }
 
+// indexRun records a run's files in the artifacts index. Indexing is
+// best-effort: the index can be rebuilt, so a failure must not fail the run.
+func indexRun(artifactsDir string, result *RunResult) {
+	idx, err := artifacts.Open(artifactsDir)
+	if err != nil {
+		return
+	}
+	defer idx.Close()
+	_, _ = idx.IndexRun(result.RunDir, result.Plan.ID)
+}
 
 func tailContext(ctx context.Context) context.Context {
 	if ctx == nil {
@@ -285,7 +1036,11 @@ func tailContext(ctx context.Context) context.Context {
 	return ctx
 }
 
-func followTranscript(ctx context.Context, path string, lines int, w io.Writer, label string) func() {
+// FollowTranscript copies the transcript at path to w as it grows, starting
+// from its last lines lines (0 for the whole file) once it exists, under a
+// header naming label. The returned func stops following after the last
+// write has been copied.
+func FollowTranscript(ctx context.Context, path string, lines int, w io.Writer, label string) func() {
 	stopCh := make(chan struct{})
 	doneCh := make(chan struct{})
 
@@ -365,6 +1120,24 @@ func followTranscript(ctx context.Context, path string, lines int, w io.Writer,
 	}
 }
 
+// TailTranscript returns the last lines lines of the transcript at path, or
+// all of it when lines is 0.
+func TailTranscript(path string, lines int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	off, err := startOffsetForLastLines(f, lines)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
 func startOffsetForLastLines(f *os.File, lines int) (int64, error) {
 	if lines <= 0 {
 		return 0, nil
@@ -0,0 +1,184 @@
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"okrchestra/internal/adapters"
+)
+
+// RunStateFileName is the checkpoint RunPlan keeps in each run dir.
+const RunStateFileName = "run.json"
+
+const RunStateSchemaVersion = 1
+
+// Item statuses recorded in run.json. An item left running by an
+// interrupted run is treated like a failed one when resuming.
+const (
+	ItemPending   = "pending"
+	ItemRunning   = "running"
+	ItemSucceeded = "succeeded"
+	ItemFailed    = "failed"
+)
+
+// Run statuses recorded in run.json. A run still marked running when no
+// okrchestra process is running it was interrupted.
+const (
+	RunRunning        = "running"
+	RunSucceeded      = "succeeded"
+	RunFailed         = "failed"
+	RunBudgetExceeded = "budget_exceeded"
+)
+
+// RunState is the per-item progress of a plan run, rewritten as each item
+// starts and finishes so a failed or interrupted run can be resumed. Once
+// the run ends it is also the run's summary: its status, counts, and cost.
+type RunState struct {
+	SchemaVersion int    `json:"schema_version"`
+	RunID         string `json:"run_id"`
+	PlanID        string `json:"plan_id"`
+	PlanPath      string `json:"plan_path"`
+	Adapter       string `json:"adapter"`
+	// Status is empty in run.json files written before it was recorded.
+	Status     string `json:"status,omitempty"`
+	StartedAt  string `json:"started_at"`
+	UpdatedAt  string `json:"updated_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	// Error is why a failed run failed.
+	Error string `json:"error,omitempty"`
+	// Resumes records when the run was resumed.
+	Resumes []string `json:"resumes,omitempty"`
+	// Counts is set when the run ends.
+	Counts *RunCounts `json:"counts,omitempty"`
+	// Usage totals every attempt of every item, across resumes; its
+	// cost_usd is the run's total estimated cost.
+	Usage adapters.Usage `json:"usage"`
+	Items []RunItemState `json:"items"`
+}
+
+// RunCounts counts a run's items by status, and succeeded items by
+// verification outcome when the run verified them.
+type RunCounts struct {
+	Total      int `json:"total"`
+	Succeeded  int `json:"succeeded"`
+	Failed     int `json:"failed"`
+	Pending    int `json:"pending"`
+	Running    int `json:"running,omitempty"`
+	Verified   int `json:"verified,omitempty"`
+	Unverified int `json:"unverified,omitempty"`
+	Regressed  int `json:"regressed,omitempty"`
+}
+
+// CountItems counts the run's items as they stand, also for runs still
+// going and runs recorded before Counts was.
+func (s *RunState) CountItems() RunCounts {
+	counts := RunCounts{Total: len(s.Items)}
+	for _, item := range s.Items {
+		switch item.Status {
+		case ItemSucceeded:
+			counts.Succeeded++
+		case ItemPending:
+			counts.Pending++
+		case ItemRunning:
+			counts.Running++
+		default:
+			counts.Failed++
+		}
+		if item.Status != ItemSucceeded || item.Verification == nil {
+			continue
+		}
+		switch item.Verification.Status {
+		case VerificationVerified:
+			counts.Verified++
+		case VerificationRegressed:
+			counts.Regressed++
+		default:
+			counts.Unverified++
+		}
+	}
+	return counts
+}
+
+// RunItemState is one plan item's entry in run.json, in plan order.
+type RunItemState struct {
+	ItemID       string       `json:"item_id"`
+	ItemDir      string       `json:"item_dir"`
+	Status       string       `json:"status"`
+	Attempts     int          `json:"attempts"`
+	StartedAt    string       `json:"started_at,omitempty"`
+	FinishedAt   string       `json:"finished_at,omitempty"`
+	FailureClass FailureClass `json:"failure_class,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	// ExitCode is the agent's exit code in the latest attempt, when the
+	// adapter returned a result.
+	ExitCode *int `json:"exit_code,omitempty"`
+	// ResultPath is the item's validated result.json, relative to the run
+	// dir, once the item succeeded.
+	ResultPath string `json:"result_path,omitempty"`
+	// Usage is what the item's latest attempt consumed, when the adapter
+	// reports it.
+	Usage *adapters.Usage `json:"usage,omitempty"`
+	// Verification is the item's metric check, when the run verifies items.
+	Verification *Verification `json:"verification,omitempty"`
+	// Git is the item's branch and commit, when the run commits items to
+	// branches.
+	Git *GitResult `json:"git,omitempty"`
+}
+
+// LoadRunState reads run.json from a run dir. Runs made before run.json was
+// written return an error wrapping os.ErrNotExist.
+func LoadRunState(runDir string) (*RunState, error) {
+	data, err := os.ReadFile(filepath.Join(runDir, RunStateFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s has no %s: %w", runDir, RunStateFileName, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read run state: %w", err)
+	}
+	var state RunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", RunStateFileName, err)
+	}
+	if state.SchemaVersion != RunStateSchemaVersion {
+		return nil, fmt.Errorf("unsupported %s schema_version %d", RunStateFileName, state.SchemaVersion)
+	}
+	return &state, nil
+}
+
+// WriteRunState replaces run.json in runDir atomically, so a crash never
+// leaves a half-written checkpoint.
+func WriteRunState(runDir string, state *RunState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal run state: %w", err)
+	}
+	path := filepath.Join(runDir, RunStateFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write run state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write run state: %w", err)
+	}
+	return nil
+}
+
+// checkResumable reports whether plan is still the plan state was recorded
+// for, item for item.
+func checkResumable(state *RunState, plan Plan) error {
+	if state.PlanID != plan.ID {
+		return fmt.Errorf("run %s is for plan %q, not %q", state.RunID, state.PlanID, plan.ID)
+	}
+	if len(state.Items) != len(plan.Items) {
+		return fmt.Errorf("plan %s changed since run %s: %d items, run has %d", plan.ID, state.RunID, len(plan.Items), len(state.Items))
+	}
+	for i, item := range plan.Items {
+		if state.Items[i].ItemID != item.ID {
+			return fmt.Errorf("plan %s changed since run %s: item %d is %s, run has %s", plan.ID, state.RunID, i+1, item.ID, state.Items[i].ItemID)
+		}
+	}
+	return nil
+}
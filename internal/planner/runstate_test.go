@@ -0,0 +1,251 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+const validResult = `{"schema_version":"1.0","summary":"done","proposed_changes":[],"kr_targets":[],"kr_impact_claim":"None"}`
+
+func TestRunPlanResumeSkipsSucceededItems(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{ID: "plan-resume", AsOf: "2025-01-15"}
+	for _, id := range []string{"ITEM-1", "ITEM-2", "ITEM-3"} {
+		plan.Items = append(plan.Items, PlanItem{
+			ID: id, ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do " + id, AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase", Target: 1},
+		})
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(root, "plan.json")
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ran := map[string]int{}
+	failItem2 := true
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		id := cfg.Env["OKRCHESTRA_PLAN_ITEM_ID"]
+		ran[id]++
+		if id == "ITEM-2" && failItem2 {
+			return &adapters.RunResult{ExitCode: 1}, errors.New("exit status 1")
+		}
+		return &adapters.RunResult{}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+	}}
+	auditDB := filepath.Join(root, "audit.sqlite")
+	opts := RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		Timeout:     time.Minute,
+		AuditLogger: audit.NewLogger(auditDB),
+		RunBaseDir:  filepath.Join(root, "runs"),
+	}
+
+	first, err := RunPlan(context.Background(), opts)
+	if err == nil {
+		t.Fatal("expected first run to fail")
+	}
+	state, err := LoadRunState(first.RunDir)
+	if err != nil {
+		t.Fatalf("load run state: %v", err)
+	}
+	statuses := []string{state.Items[0].Status, state.Items[1].Status, state.Items[2].Status}
+	if statuses[0] != ItemSucceeded || statuses[1] != ItemFailed || statuses[2] != ItemPending {
+		t.Fatalf("statuses after failure = %v", statuses)
+	}
+	if state.Items[1].FailureClass != FailureAdapterError {
+		t.Fatalf("failure class = %q", state.Items[1].FailureClass)
+	}
+	if state.Status != RunFailed || state.Error == "" || state.FinishedAt == "" {
+		t.Fatalf("run status = %q, error = %q, finished = %q", state.Status, state.Error, state.FinishedAt)
+	}
+	if *state.Counts != (RunCounts{Total: 3, Succeeded: 1, Failed: 1, Pending: 1}) {
+		t.Fatalf("counts = %+v", *state.Counts)
+	}
+	if code := state.Items[1].ExitCode; code == nil || *code != 1 || state.Items[1].ResultPath != "" {
+		t.Fatalf("failed item = %+v", state.Items[1])
+	}
+	if state.Items[0].ResultPath != "item-0001/result.json" {
+		t.Fatalf("result path = %q", state.Items[0].ResultPath)
+	}
+
+	failItem2 = false
+	second, err := RunPlan(context.Background(), RunOptions{
+		WorkDir:     root,
+		Adapter:     adapter,
+		Timeout:     time.Minute,
+		AuditLogger: opts.AuditLogger,
+		ResumeDir:   first.RunDir,
+	})
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if second.RunID != first.RunID || len(second.ItemRuns) != 3 {
+		t.Fatalf("resumed result = %+v", second)
+	}
+	if ran["ITEM-1"] != 1 || ran["ITEM-2"] != 2 || ran["ITEM-3"] != 1 {
+		t.Fatalf("item runs = %v", ran)
+	}
+	if _, err := os.Stat(filepath.Join(first.RunDir, "item-0002", "failure.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale failure.json to be removed, got %v", err)
+	}
+	state, err = LoadRunState(first.RunDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Resumes) != 1 || state.Items[1].Status != ItemSucceeded || state.Items[1].Attempts != 2 || state.Items[1].Error != "" {
+		t.Fatalf("state after resume = %+v", state)
+	}
+	if state.Status != RunSucceeded || state.Error != "" || *state.Counts != (RunCounts{Total: 3, Succeeded: 3}) {
+		t.Fatalf("run after resume = %q, %q, %+v", state.Status, state.Error, *state.Counts)
+	}
+	if code := state.Items[1].ExitCode; code == nil || *code != 0 {
+		t.Fatalf("exit code after resume = %v", code)
+	}
+
+	events, err := audit.ReadEvents(auditDB, audit.Query{Type: "plan_item_skipped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected ITEM-1 to be logged as skipped, got %d events", len(events))
+	}
+	if events, _ := audit.ReadEvents(auditDB, audit.Query{Type: "plan_run_resumed"}); len(events) != 1 {
+		t.Fatalf("expected a plan_run_resumed event, got %d", len(events))
+	}
+
+	// A changed plan cannot be resumed into the old run.
+	plan.Items = plan.Items[:2]
+	data, _ = json.Marshal(plan)
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunPlan(context.Background(), RunOptions{WorkDir: root, Adapter: adapter, ResumeDir: first.RunDir}); err == nil {
+		t.Fatal("expected resume with a changed plan to fail")
+	}
+}
+
+func TestRunPlanContinueOnError(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{ID: "plan-continue", AsOf: "2025-01-15"}
+	for _, id := range []string{"ITEM-1", "ITEM-2", "ITEM-3"} {
+		plan.Items = append(plan.Items, PlanItem{
+			ID: id, ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do " + id, AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase", Target: 1},
+		})
+	}
+	plan.Items[1].DependsOn = []string{"ITEM-1"}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(root, "plan.json")
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		if cfg.Env["OKRCHESTRA_PLAN_ITEM_ID"] == "ITEM-1" {
+			return &adapters.RunResult{ExitCode: 1}, errors.New("exit status 1")
+		}
+		return &adapters.RunResult{}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+	}}
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:        planPath,
+		WorkDir:         root,
+		Adapter:         adapter,
+		AuditLogger:     audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:      filepath.Join(root, "runs"),
+		ContinueOnError: true,
+	})
+	if err == nil {
+		t.Fatal("expected an error when an item fails")
+	}
+	if class, ok := ClassifyFailure(err); !ok || class != FailureAdapterError {
+		t.Fatalf("class = %q (ok=%v)", class, ok)
+	}
+	succeeded, failed, skipped := result.Counts()
+	if succeeded != 1 || failed != 1 || skipped != 1 {
+		t.Fatalf("counts = %d/%d/%d, want 1/1/1", succeeded, failed, skipped)
+	}
+	if result.ItemRuns[0].ItemID != "ITEM-3" || result.Skipped[0] != "ITEM-2" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunPlanStopsAtBudget(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := Plan{ID: "plan-budget", AsOf: "2025-01-15"}
+	for _, id := range []string{"ITEM-1", "ITEM-2", "ITEM-3"} {
+		plan.Items = append(plan.Items, PlanItem{
+			ID: id, ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do " + id, AgentRole: "engineer",
+			ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase", Target: 1},
+		})
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planPath := filepath.Join(root, "plan.json")
+	if err := os.WriteFile(planPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each item uses 1M output tokens, priced at $2.
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		usage := &adapters.Usage{Model: "m", OutputTokens: 1_000_000, TotalTokens: 1_000_000}
+		return &adapters.RunResult{Usage: usage}, os.WriteFile(cfg.Env["OKRCHESTRA_AGENT_RESULT"], []byte(validResult), 0o644)
+	}}
+	auditDB := filepath.Join(root, "audit.sqlite")
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		AuditLogger: audit.NewLogger(auditDB),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Pricing:     adapters.Pricing{"m": {OutputPerMillion: 2}},
+		Budget:      3,
+	})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	succeeded, failed, skipped := result.Counts()
+	if succeeded != 2 || failed != 0 || skipped != 1 || result.Usage.CostUSD != 4 {
+		t.Fatalf("counts = %d/%d/%d, cost %v", succeeded, failed, skipped, result.Usage.CostUSD)
+	}
+
+	state, err := LoadRunState(result.RunDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Usage.CostUSD != 4 || state.Items[0].Usage == nil || state.Items[0].Usage.CostUSD != 2 || state.Items[2].Status != ItemPending {
+		t.Fatalf("run state = %+v", state)
+	}
+	if state.Status != RunBudgetExceeded || state.Counts == nil || state.Counts.Pending != 1 {
+		t.Fatalf("run status = %q, counts = %+v", state.Status, state.Counts)
+	}
+	if events, _ := audit.ReadEvents(auditDB, audit.Query{Type: "plan_run_budget_exceeded"}); len(events) != 1 {
+		t.Fatalf("expected a plan_run_budget_exceeded event, got %d", len(events))
+	}
+}
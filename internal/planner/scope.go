@@ -0,0 +1,199 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ChangesFileName is the item dir file holding a scoped item's changes once
+// its worktree is removed.
+const ChangesFileName = "changes.diff"
+
+// scopedWorktree is a sparse git worktree holding only an item's scope_paths.
+type scopedWorktree struct {
+	// Dir is the worktree root, inside the item dir.
+	Dir string
+	// WorkDir is the agent's working directory: the run work dir's
+	// counterpart inside the worktree.
+	WorkDir string
+	// prefix is the work dir relative to the repository root, slash-separated.
+	prefix string
+	scope  []string
+	// base is the commit the worktree was created at.
+	base string
+}
+
+// ValidateScopePaths checks that scope paths are relative directories that
+// stay inside the work dir.
+func ValidateScopePaths(paths []string) error {
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return fmt.Errorf("scope_paths entries must not be empty")
+		}
+		if filepath.IsAbs(p) || strings.HasPrefix(p, "/") {
+			return fmt.Errorf("scope path %q must be relative to the work dir", p)
+		}
+		clean := path.Clean(filepath.ToSlash(p))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("scope path %q must name a directory inside the work dir", p)
+		}
+	}
+	return nil
+}
+
+// prepareScopedWorktree checks out HEAD of workDir's repository into
+// <itemDir>/worktree with sparse-checkout limited to scope (relative to
+// workDir). Uncommitted changes in workDir are not carried over.
+func prepareScopedWorktree(ctx context.Context, workDir, itemDir string, scope []string) (*scopedWorktree, error) {
+	absWork, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve work dir: %w", err)
+	}
+	top, err := gitOutput(ctx, absWork, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("scope_paths require a git repository: %w", err)
+	}
+	repoRoot := strings.TrimSpace(top)
+	// Resolve symlinks on both sides so the prefix is computed like git does.
+	if resolved, err := filepath.EvalSymlinks(absWork); err == nil {
+		absWork = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(repoRoot); err == nil {
+		repoRoot = resolved
+	}
+	rel, err := filepath.Rel(repoRoot, absWork)
+	if err != nil {
+		return nil, fmt.Errorf("resolve work dir in repository: %w", err)
+	}
+
+	wt := &scopedWorktree{
+		Dir:    filepath.Join(itemDir, "worktree"),
+		prefix: filepath.ToSlash(rel),
+	}
+	if wt.prefix == "." {
+		wt.prefix = ""
+	}
+	for _, p := range scope {
+		wt.scope = append(wt.scope, path.Join(wt.prefix, path.Clean(filepath.ToSlash(strings.TrimSpace(p)))))
+	}
+
+	if _, err := gitOutput(ctx, repoRoot, "worktree", "add", "--detach", "--no-checkout", wt.Dir, "HEAD"); err != nil {
+		return nil, fmt.Errorf("create scoped worktree: %w", err)
+	}
+	base, err := gitOutput(ctx, wt.Dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve scoped worktree base: %w", err)
+	}
+	wt.base = strings.TrimSpace(base)
+	if _, err := gitOutput(ctx, wt.Dir, append([]string{"sparse-checkout", "set", "--cone"}, wt.scope...)...); err != nil {
+		return nil, fmt.Errorf("configure sparse checkout: %w", err)
+	}
+	if _, err := gitOutput(ctx, wt.Dir, "checkout", "--detach", "--quiet"); err != nil {
+		return nil, fmt.Errorf("populate scoped worktree: %w", err)
+	}
+	wt.WorkDir = filepath.Join(wt.Dir, filepath.FromSlash(wt.prefix))
+	return wt, nil
+}
+
+// SaveChanges writes everything changed in the worktree since it was
+// created, committed or not, to path as a binary git diff. Nothing is
+// written when there are no changes.
+func (w *scopedWorktree) SaveChanges(ctx context.Context, path string) error {
+	if _, err := gitOutput(ctx, w.Dir, "add", "--all", "--sparse"); err != nil {
+		return fmt.Errorf("stage worktree changes: %w", err)
+	}
+	diff, err := gitOutput(ctx, w.Dir, "diff", "--cached", "--binary", w.base)
+	if err != nil {
+		return fmt.Errorf("diff worktree changes: %w", err)
+	}
+	if diff == "" {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(diff), 0o644); err != nil {
+		return fmt.Errorf("write worktree changes: %w", err)
+	}
+	return nil
+}
+
+// removeWorktree removes the git worktree at dir, if there is one, from
+// workDir's repository. A worktree git no longer knows how to remove is
+// deleted and pruned instead.
+func removeWorktree(ctx context.Context, workDir, dir string) error {
+	if _, err := os.Lstat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := gitOutput(ctx, workDir, "worktree", "remove", "--force", dir); err == nil {
+		return nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("remove worktree: %w", err)
+	}
+	if _, err := gitOutput(ctx, workDir, "worktree", "prune"); err != nil {
+		return fmt.Errorf("prune worktrees: %w", err)
+	}
+	return nil
+}
+
+// OutOfScope returns files the agent changed or created in the worktree
+// outside its scope paths. Files under okrs/ are always out of scope.
+func (w *scopedWorktree) OutOfScope(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, w.Dir, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, fmt.Errorf("inspect scoped worktree: %w", err)
+	}
+	okrs := path.Join(w.prefix, "okrs")
+	var outside []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		status, file := entry[:2], entry[3:]
+		// Renames and copies are followed by the source path.
+		if status[0] == 'R' || status[0] == 'C' {
+			i++
+		}
+		if underDir(file, okrs) || !w.inScope(file) {
+			outside = append(outside, file)
+		}
+	}
+	return outside, nil
+}
+
+func (w *scopedWorktree) inScope(file string) bool {
+	for _, dir := range w.scope {
+		if underDir(file, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func underDir(file, dir string) bool {
+	return dir == "" || file == dir || strings.HasPrefix(file, dir+"/")
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return "", fmt.Errorf("git %s: %s: %w", strings.Join(args, " "), msg, err)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
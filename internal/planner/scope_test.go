@@ -0,0 +1,233 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+)
+
+func TestRunPlanScopePaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	for _, tc := range []struct {
+		name      string
+		write     string
+		keep      bool
+		wantClass FailureClass
+	}{
+		{name: "in scope", write: "services/api/handler.go"},
+		{name: "kept worktree", write: "services/api/handler.go", keep: true},
+		{name: "out of scope", write: "services/web/app.js", wantClass: FailureGuardrailViolation},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, file := range []string{"okrs/org.yml", "services/api/main.go", "services/web/index.html", "README.md"} {
+				path := filepath.Join(root, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(file+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("artifacts/\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			git := func(args ...string) string {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+				cmd.Dir = root
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+				return string(out)
+			}
+			git("init", "-q")
+			git("add", "-A")
+			git("commit", "-q", "-m", "init")
+
+			planPath := writeTestPlan(t, root, func(item *PlanItem) {
+				item.ScopePaths = []string{"services/api"}
+			})
+
+			var sawWeb bool
+			adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+				_, err := os.Stat(filepath.Join(cfg.WorkDir, "services", "web"))
+				sawWeb = err == nil
+				path := filepath.Join(cfg.WorkDir, tc.write)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					return nil, err
+				}
+				if err := os.WriteFile(path, []byte("change\n"), 0o644); err != nil {
+					return nil, err
+				}
+				return (&adapters.MockAdapter{}).Run(ctx, cfg)
+			}}
+
+			result, err := RunPlan(context.Background(), RunOptions{
+				PlanPath:      planPath,
+				WorkDir:       root,
+				Adapter:       adapter,
+				Timeout:       time.Minute,
+				AuditLogger:   audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+				RunBaseDir:    filepath.Join(root, "artifacts", "runs"),
+				KeepWorktrees: tc.keep,
+			})
+			if sawWeb {
+				t.Fatal("out-of-scope directory was checked out")
+			}
+			if tc.wantClass == "" {
+				if err != nil {
+					t.Fatalf("RunPlan: %v", err)
+				}
+				itemDir := result.ItemRuns[0].ItemDir
+				if tc.keep {
+					wt := result.ItemRuns[0].Worktree
+					if _, err := os.Stat(filepath.Join(wt, "services", "api", "handler.go")); err != nil {
+						t.Fatalf("change not in worktree: %v", err)
+					}
+				} else {
+					if result.ItemRuns[0].Worktree != "" {
+						t.Fatalf("worktree = %q, want it removed", result.ItemRuns[0].Worktree)
+					}
+					if _, err := os.Stat(filepath.Join(itemDir, "worktree")); !os.IsNotExist(err) {
+						t.Fatalf("worktree left behind: %v", err)
+					}
+					if list := strings.TrimSpace(git("worktree", "list")); strings.Contains(list, "\n") {
+						t.Fatalf("git worktree list:\n%s", list)
+					}
+					changes, err := os.ReadFile(filepath.Join(itemDir, ChangesFileName))
+					if err != nil || !strings.Contains(string(changes), "+++ b/services/api/handler.go") {
+						t.Fatalf("changes = %q (err %v)", changes, err)
+					}
+				}
+				if _, err := os.Stat(filepath.Join(root, "services", "api", "handler.go")); !os.IsNotExist(err) {
+					t.Fatalf("change leaked into the work dir: %v", err)
+				}
+				prompt, err := os.ReadFile(filepath.Join(itemDir, "prompt.md"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(prompt), "## Scope\n") || !strings.Contains(string(prompt), "- services/api\n") {
+					t.Fatalf("prompt missing scope:\n%s", prompt)
+				}
+				return
+			}
+			if class, _ := ClassifyFailure(err); class != tc.wantClass {
+				t.Fatalf("class = %q, want %q (err: %v)", class, tc.wantClass, err)
+			}
+			if !strings.Contains(err.Error(), "outside scope_paths") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// initScopedRepo commits a small repository with services/api and
+// services/web and returns its root.
+func initScopedRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, file := range []string{"okrs/org.yml", "services/api/main.go", "services/web/index.html", ".gitignore"} {
+		writeFile(t, filepath.Join(root, file), file+"\n")
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "artifacts/\n")
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return root
+}
+
+func TestRunPlanResumeScopedItem(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := initScopedRepo(t)
+	planPath := writeTestPlan(t, root, func(item *PlanItem) {
+		item.ScopePaths = []string{"services/api"}
+	})
+	failAgent := true
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		if err := os.WriteFile(filepath.Join(cfg.WorkDir, "services", "api", "handler.go"), []byte("change\n"), 0o644); err != nil {
+			return nil, err
+		}
+		if failAgent {
+			return &adapters.RunResult{ExitCode: 1}, errors.New("exit status 1")
+		}
+		return (&adapters.MockAdapter{}).Run(ctx, cfg)
+	}}
+	opts := RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		Timeout:     time.Minute,
+		AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:  filepath.Join(root, "artifacts", "runs"),
+	}
+	first, err := RunPlan(context.Background(), opts)
+	if class, _ := ClassifyFailure(err); class != FailureAdapterError {
+		t.Fatalf("first run: class %q, err %v", class, err)
+	}
+
+	failAgent = false
+	opts.ResumeDir = first.RunDir
+	if _, err := RunPlan(context.Background(), opts); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	state, err := LoadRunState(first.RunDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Items[0].Status != ItemSucceeded || state.Items[0].Attempts != 2 {
+		t.Fatalf("item after resume = %+v", state.Items[0])
+	}
+}
+
+func TestRunPlanParallelNeedsScopePaths(t *testing.T) {
+	root := t.TempDir()
+	planPath := writeHookPlan(t, root, "ITEM-1", "ITEM-2")
+	ran := false
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		ran = true
+		return &adapters.RunResult{}, nil
+	}}
+	_, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Parallel:    2,
+	})
+	if err == nil || !strings.Contains(err.Error(), "item ITEM-1 has no scope_paths") {
+		t.Fatalf("err = %v", err)
+	}
+	if ran {
+		t.Fatal("an item ran")
+	}
+}
+
+func TestValidateScopePaths(t *testing.T) {
+	if err := ValidateScopePaths([]string{"services/api", "docs"}); err != nil {
+		t.Fatalf("valid paths rejected: %v", err)
+	}
+	for _, bad := range []string{"", "/etc", "..", "../other", "."} {
+		if err := ValidateScopePaths([]string{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package planner
+
+import (
+	"path/filepath"
+
+	"okrchestra/internal/guardrails"
+)
+
+// scanItemSecrets redacts secrets from an item's result.json and
+// transcript.log, then scans the agent's changes to workDir for secrets
+// that were not already there (baseline). Work tree files are reported but
+// left as they are. The diff scan is best-effort: if git fails, only the
+// artifact findings are returned.
+func scanItemSecrets(itemDir, workDir string, baseline []guardrails.SecretFinding) ([]guardrails.SecretFinding, error) {
+	var findings []guardrails.SecretFinding
+	for _, name := range []string{"result.json", "transcript.log"} {
+		found, err := guardrails.RedactFile(filepath.Join(itemDir, name), name)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	if after, err := guardrails.ScanGitDiff(workDir); err == nil {
+		findings = append(findings, guardrails.NewSecretFindings(baseline, after)...)
+	}
+	return findings, nil
+}
@@ -0,0 +1,246 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// Plan strategies choose which org KRs a non-portfolio plan targets.
+const (
+	// StrategyFirst targets the first runnable KR in file order.
+	StrategyFirst = "first"
+	// StrategyAtRisk ranks runnable KRs by a score report, least progress
+	// on the most confident KRs nearest their deadline first.
+	StrategyAtRisk = "at_risk"
+	// StrategyRoundRobin continues after the KRs the previous plan targeted.
+	StrategyRoundRobin = "round_robin"
+)
+
+const atRiskFormula = "priority = (1 − percent_to_target/100) × confidence × 30 / (30 + days_remaining)"
+
+// Prioritization records how a plan strategy chose its KRs.
+type Prioritization struct {
+	Strategy string `json:"strategy"`
+	Formula  string `json:"formula,omitempty"`
+	// ScoreReport is the report at_risk ranked against.
+	ScoreReport string `json:"score_report,omitempty"`
+	// PreviousPlan is the plan round_robin continued from.
+	PreviousPlan string       `json:"previous_plan,omitempty"`
+	KeyResults   []KRPriority `json:"key_results"`
+}
+
+// KRPriority is one runnable KR's place in a prioritization, in rank
+// order. The score fields are only set by at_risk.
+type KRPriority struct {
+	KRID            string  `json:"kr_id"`
+	ObjectiveID     string  `json:"objective_id"`
+	PercentToTarget float64 `json:"percent_to_target,omitempty"`
+	Confidence      float64 `json:"confidence,omitempty"`
+	Deadline        string  `json:"deadline,omitempty"`
+	DaysRemaining   int     `json:"days_remaining,omitempty"`
+	Priority        float64 `json:"priority,omitempty"`
+	Selected        bool    `json:"selected"`
+}
+
+type strategyCandidate struct {
+	obj okrstore.Objective
+	kr  okrstore.KeyResult
+}
+
+// runnableOrgKRs returns the org KRs a plan can target, in file order,
+// limited to one objective when objectiveID is set.
+func runnableOrgKRs(store *okrstore.Store, objectiveID string) ([]strategyCandidate, error) {
+	if objectiveID != "" {
+		rec, ok := store.ObjectiveLookup(objectiveID)
+		if !ok {
+			return nil, fmt.Errorf("unknown objective_id: %s", objectiveID)
+		}
+		if rec.Scope != okrstore.ScopeOrg {
+			return nil, fmt.Errorf("objective_id %s is not in org scope", objectiveID)
+		}
+	}
+	var candidates []strategyCandidate
+	for _, doc := range store.Org.Documents {
+		for _, obj := range doc.Objectives {
+			if objectiveID != "" && obj.ID != objectiveID {
+				continue
+			}
+			for _, kr := range obj.KeyResults {
+				if kr.MetricKey == "" || kr.Status == "achieved" {
+					continue
+				}
+				candidates = append(candidates, strategyCandidate{obj: obj, kr: kr})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		if objectiveID != "" {
+			return nil, fmt.Errorf("objective_id %s has no runnable org key results", objectiveID)
+		}
+		return nil, fmt.Errorf("no runnable org key results found")
+	}
+	return candidates, nil
+}
+
+// prioritizeAtRisk ranks candidates by the at_risk formula against report.
+// KRs the report has no score for count as no progress. Days remaining run
+// to the objective's period end, or else to the end of asOf's quarter.
+func prioritizeAtRisk(candidates []strategyCandidate, report *metrics.KRScoreReport, asOf time.Time) ([]strategyCandidate, []KRPriority) {
+	percents := map[string]float64{}
+	for _, result := range report.Results {
+		percents[result.ObjectiveID+"\x00"+result.KRID] = result.PercentToTarget
+	}
+	type ranked struct {
+		candidate strategyCandidate
+		priority  KRPriority
+	}
+	rows := make([]ranked, 0, len(candidates))
+	for _, c := range candidates {
+		deadline := metrics.TrendDeadline(asOf)
+		if !c.obj.Period.IsZero() {
+			if end, err := time.Parse("2006-01-02", c.obj.Period.End); err == nil {
+				deadline = end
+			}
+		}
+		days := int(math.Max(0, math.Ceil(deadline.Sub(asOf.UTC().Truncate(24*time.Hour)).Hours()/24)))
+		pct := percents[c.obj.ID+"\x00"+c.kr.ID]
+		rows = append(rows, ranked{candidate: c, priority: KRPriority{
+			KRID:            c.kr.ID,
+			ObjectiveID:     c.obj.ID,
+			PercentToTarget: round3(pct),
+			Confidence:      c.kr.Confidence,
+			Deadline:        deadline.Format("2006-01-02"),
+			DaysRemaining:   days,
+			Priority:        round3((1 - pct/100) * c.kr.Confidence * 30 / (30 + float64(days))),
+		}})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].priority.Priority > rows[j].priority.Priority
+	})
+	ordered := make([]strategyCandidate, len(rows))
+	priorities := make([]KRPriority, len(rows))
+	for i, row := range rows {
+		ordered[i], priorities[i] = row.candidate, row.priority
+	}
+	return ordered, priorities
+}
+
+// prioritizeRoundRobin rotates candidates to start after the KR of the
+// last item in the newest plan in plansDir dated before asOf. It returns
+// that plan's path, or "" when there is none.
+func prioritizeRoundRobin(candidates []strategyCandidate, plansDir string, asOf time.Time) ([]strategyCandidate, string, error) {
+	previous, err := previousPlanPath(plansDir, asOf.UTC().Format("2006-01-02"))
+	if err != nil || previous == "" {
+		return candidates, "", err
+	}
+	plan, err := LoadPlan(previous)
+	if err != nil {
+		return nil, "", fmt.Errorf("read previous plan: %w", err)
+	}
+	last := ""
+	for _, item := range plan.Items {
+		if item.RetryOf == "" {
+			last = item.KRID
+		}
+	}
+	start := 0
+	for i, c := range candidates {
+		if c.kr.ID == last {
+			start = (i + 1) % len(candidates)
+		}
+	}
+	rotated := append(append([]strategyCandidate{}, candidates[start:]...), candidates[:start]...)
+	return rotated, previous, nil
+}
+
+// previousPlanPath returns the plan.json of the newest YYYY-MM-DD dir in
+// plansDir before asOf.
+func previousPlanPath(plansDir, asOf string) (string, error) {
+	entries, err := os.ReadDir(plansDir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read plans dir: %w", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		name := entries[i].Name()
+		if !entries[i].IsDir() || name >= asOf {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", name); err != nil {
+			continue
+		}
+		path := filepath.Join(plansDir, name, "plan.json")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// strategyItems builds plan items for the first n candidates and marks
+// them selected in priorities, which lists every candidate in rank order
+// (built here when the strategy computed none).
+func strategyItems(candidates []strategyCandidate, priorities []KRPriority, n int, agentRole string) ([]PlanItem, []KRPriority) {
+	if priorities == nil {
+		for _, c := range candidates {
+			priorities = append(priorities, KRPriority{KRID: c.kr.ID, ObjectiveID: c.obj.ID})
+		}
+	}
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	items := make([]PlanItem, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, krItem(fmt.Sprintf("ITEM-%d", i+1), candidates[i].obj, candidates[i].kr, agentRole))
+		priorities[i].Selected = true
+	}
+	return items, priorities
+}
+
+// strategyPlanItems builds the items of a non-portfolio plan with the
+// at_risk or round_robin strategy.
+func strategyPlanItems(store *okrstore.Store, opts GenerateOptions) ([]PlanItem, *Prioritization, error) {
+	candidates, err := runnableOrgKRs(store, opts.ObjectiveID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &Prioritization{Strategy: opts.Strategy}
+	var priorities []KRPriority
+	switch opts.Strategy {
+	case StrategyAtRisk:
+		if opts.ScoreReportPath == "" {
+			return nil, nil, fmt.Errorf("the at_risk strategy needs a score report; run `okrchestra kr score` first")
+		}
+		report, err := metrics.LoadScoreReport(opts.ScoreReportPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		candidates, priorities = prioritizeAtRisk(candidates, report, opts.AsOf)
+		p.Formula = atRiskFormula
+		p.ScoreReport = relPathForPlan(opts, opts.ScoreReportPath)
+	case StrategyRoundRobin:
+		var previous string
+		if candidates, previous, err = prioritizeRoundRobin(candidates, opts.OutputBaseDir, opts.AsOf); err != nil {
+			return nil, nil, err
+		}
+		if previous != "" {
+			p.PreviousPlan = relPathForPlan(opts, previous)
+		}
+	}
+	n := opts.Items
+	if n <= 0 {
+		n = DefaultPortfolioItems
+	}
+	var items []PlanItem
+	items, p.KeyResults = strategyItems(candidates, priorities, n, opts.AgentRole)
+	return items, p, nil
+}
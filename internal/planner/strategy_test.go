@@ -0,0 +1,141 @@
+package planner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/metrics"
+)
+
+const strategyTestOKRs = `
+scope: org
+period: 2025-Q1
+objectives:
+  - objective_id: OBJ-1
+    objective: Reliability
+    key_results:
+      - kr_id: KR-1
+        description: one
+        owner_id: o
+        metric_key: m1
+        baseline: 0
+        target: 10
+        confidence: 0.9
+        status: in_progress
+        evidence: []
+      - kr_id: KR-2
+        description: two
+        owner_id: o
+        metric_key: m2
+        baseline: 0
+        target: 10
+        confidence: 0.9
+        status: in_progress
+        evidence: []
+      - kr_id: KR-3
+        description: three
+        owner_id: o
+        metric_key: m3
+        baseline: 0
+        target: 10
+        confidence: 0.3
+        status: not_started
+        evidence: []
+`
+
+func setupStrategyTest(t *testing.T) (okrsDir, plansDir string) {
+	t.Helper()
+	root := t.TempDir()
+	okrsDir = filepath.Join(root, "okrs")
+	if err := os.MkdirAll(okrsDir, 0o755); err != nil {
+		t.Fatalf("mkdir okrs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(okrsDir, "org.yml"), []byte(strategyTestOKRs), 0o644); err != nil {
+		t.Fatalf("write org.yml: %v", err)
+	}
+	return okrsDir, filepath.Join(root, "plans")
+}
+
+func planKRIDs(plan Plan) string {
+	var ids []string
+	for _, item := range plan.Items {
+		ids = append(ids, item.KRID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestGeneratePlanAtRiskStrategy(t *testing.T) {
+	okrsDir, plansDir := setupStrategyTest(t)
+	reportPath := filepath.Join(filepath.Dir(okrsDir), "kr_score_2025-03-01.json")
+	report := &metrics.KRScoreReport{
+		SchemaVersion: metrics.KRScoreSchemaVersion,
+		AsOf:          "2025-03-01",
+		Results: []metrics.KRScore{
+			{ObjectiveID: "OBJ-1", KRID: "KR-1", PercentToTarget: 80},
+			{ObjectiveID: "OBJ-1", KRID: "KR-2", PercentToTarget: 10},
+			{ObjectiveID: "OBJ-1", KRID: "KR-3", PercentToTarget: 0},
+		},
+	}
+	if err := metrics.WriteScoreReport(reportPath, report); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+
+	opts := GenerateOptions{
+		OKRsDir:       okrsDir,
+		OutputBaseDir: plansDir,
+		AsOf:          time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Strategy:      StrategyAtRisk,
+		Items:         2,
+	}
+	if _, err := GeneratePlan(opts); err == nil || !strings.Contains(err.Error(), "needs a score report") {
+		t.Fatalf("expected a missing report error, got %v", err)
+	}
+
+	opts.ScoreReportPath = reportPath
+	res, err := GeneratePlan(opts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	// KR-2: 0.9 × 0.9 ≈ 0.81, KR-3: 1 × 0.3, KR-1: 0.2 × 0.9, each scaled
+	// by the 30 days left in 2025-Q1.
+	if got := planKRIDs(res.Plan); got != "KR-2,KR-3" {
+		t.Fatalf("items = %s", got)
+	}
+	p := res.Plan.Prioritization
+	if p == nil || p.Strategy != StrategyAtRisk || len(p.KeyResults) != 3 || p.KeyResults[2].KRID != "KR-1" || p.KeyResults[2].Selected {
+		t.Fatalf("prioritization = %+v", p)
+	}
+	if first := p.KeyResults[0]; first.DaysRemaining != 30 || first.Deadline != "2025-03-31" || first.Priority != 0.405 {
+		t.Fatalf("KR-2 priority = %+v", first)
+	}
+}
+
+func TestGeneratePlanRoundRobinStrategy(t *testing.T) {
+	okrsDir, plansDir := setupStrategyTest(t)
+	opts := GenerateOptions{
+		OKRsDir:       okrsDir,
+		OutputBaseDir: plansDir,
+		AsOf:          time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Strategy:      StrategyRoundRobin,
+		Items:         2,
+	}
+	want := []string{"KR-1,KR-2", "KR-3,KR-1", "KR-2,KR-3"}
+	for day, ids := range want {
+		opts.AsOf = time.Date(2025, 3, 1+day, 0, 0, 0, 0, time.UTC)
+		res, err := GeneratePlan(opts)
+		if err != nil {
+			t.Fatalf("day %d: %v", day, err)
+		}
+		if got := planKRIDs(res.Plan); got != ids {
+			t.Fatalf("day %d: items = %s, want %s", day, got, ids)
+		}
+	}
+
+	opts.Portfolio = true
+	if _, err := GeneratePlan(opts); err == nil {
+		t.Fatalf("expected round_robin with portfolio to be refused")
+	}
+}
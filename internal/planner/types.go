@@ -6,6 +6,30 @@ type Plan struct {
 	GeneratedAt string     `json:"generated_at"`
 	OKRsDir     string     `json:"okrs_dir"`
 	Items       []PlanItem `json:"items"`
+	// GeneratedBy names the adapter that wrote the items, when one did.
+	GeneratedBy string `json:"generated_by,omitempty"`
+	// Allocation is set for portfolio plans and explains the item split.
+	Allocation *Allocation `json:"allocation,omitempty"`
+	// Prioritization is set for at_risk and round_robin plans and records
+	// how their KRs were ranked.
+	Prioritization *Prioritization `json:"prioritization,omitempty"`
+	// SuccessCriteria, when set, is checked after the plan runs; see
+	// internal/outcomes.
+	SuccessCriteria *SuccessCriteria `json:"success_criteria,omitempty"`
+	// Status is PlanDraft or PlanApproved; the daemon only executes
+	// approved plans (see approve.go). Plans without one predate approval
+	// and count as approved.
+	Status     string `json:"status,omitempty"`
+	ApprovedBy string `json:"approved_by,omitempty"`
+	ApprovedAt string `json:"approved_at,omitempty"`
+}
+
+// SuccessCriteria defines when a plan run counts as successful: every item's
+// metric must move at least MinDelta in its expected direction within
+// WithinDays of the run.
+type SuccessCriteria struct {
+	MinDelta   float64 `json:"min_delta"`
+	WithinDays int     `json:"within_days"`
 }
 
 type PlanItem struct {
@@ -17,6 +41,17 @@ type PlanItem struct {
 	AgentRole            string               `json:"agent_role"`
 	ExpectedMetricChange ExpectedMetricChange `json:"expected_metric_change"`
 	EvidencePlan         []string             `json:"evidence_plan"`
+	RetryOf              string               `json:"retry_of,omitempty"`
+	ReviewFeedback       string               `json:"review_feedback,omitempty"`
+	// ScopePaths limits the agent to these directories (relative to the run
+	// work dir) via a sparse git worktree.
+	ScopePaths []string `json:"scope_paths,omitempty"`
+	// DependsOn lists the ids of items that must succeed before this one
+	// starts.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// AvoidTactics lists what earlier plans tried for this KR without
+	// success, from their retro.json.
+	AvoidTactics []string `json:"avoid_tactics,omitempty"`
 }
 
 type ExpectedMetricChange struct {
@@ -20,6 +20,20 @@ func ValidatePlan(plan Plan) error {
 			return fmt.Errorf("plan item %d: %w", idx, err)
 		}
 	}
+	if _, err := dependencyIndexes(plan.Items); err != nil {
+		return err
+	}
+	if plan.Status != "" && plan.Status != PlanDraft && plan.Status != PlanApproved {
+		return fmt.Errorf("plan status must be %q or %q", PlanDraft, PlanApproved)
+	}
+	if c := plan.SuccessCriteria; c != nil {
+		if c.MinDelta <= 0 {
+			return fmt.Errorf("success_criteria.min_delta must be > 0")
+		}
+		if c.WithinDays <= 0 {
+			return fmt.Errorf("success_criteria.within_days must be > 0")
+		}
+	}
 	return nil
 }
 
@@ -47,5 +61,8 @@ func ValidatePlanItem(item PlanItem) error {
 	if direction != "increase" && direction != "decrease" {
 		return fmt.Errorf("expected_metric_change.direction must be \"increase\" or \"decrease\"")
 	}
+	if err := ValidateScopePaths(item.ScopePaths); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,145 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"okrchestra/internal/metrics"
+)
+
+// VerificationFileName is written to each succeeded item's dir when a run
+// verifies items.
+const VerificationFileName = "verification.json"
+
+// Verification statuses: whether the item's KR metric moved the way the
+// plan expected between the measurements taken before and after it ran.
+const (
+	VerificationVerified   = "verified"
+	VerificationUnverified = "unverified"
+	VerificationRegressed  = "regressed"
+)
+
+// MeasureFunc collects a fresh metric snapshot, as `kr measure` does.
+type MeasureFunc func(ctx context.Context) (*metrics.Snapshot, error)
+
+// Verification records how an item's KR metric changed across its run.
+type Verification struct {
+	Status    string   `json:"status"`
+	KRID      string   `json:"kr_id"`
+	MetricKey string   `json:"metric_key"`
+	Direction string   `json:"direction"`
+	Before    *float64 `json:"before,omitempty"`
+	After     *float64 `json:"after,omitempty"`
+	Delta     *float64 `json:"delta,omitempty"`
+	// Reason explains an unverified status.
+	Reason     string `json:"reason,omitempty"`
+	VerifiedAt string `json:"verified_at"`
+}
+
+// verifyItem compares item's metric in the before and after snapshots.
+// A failed measurement or a missing value leaves the item unverified.
+func verifyItem(item PlanItem, before, after *metrics.Snapshot, measureErr error) Verification {
+	change := item.ExpectedMetricChange
+	v := Verification{
+		Status:     VerificationUnverified,
+		KRID:       item.KRID,
+		MetricKey:  change.MetricKey,
+		Direction:  change.Direction,
+		VerifiedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if measureErr != nil {
+		v.Reason = fmt.Sprintf("measure failed: %v", measureErr)
+		return v
+	}
+	if value, ok := before.Value(change.MetricKey); ok {
+		v.Before = &value
+	}
+	if value, ok := after.Value(change.MetricKey); ok {
+		v.After = &value
+	}
+	if v.Before == nil || v.After == nil {
+		v.Reason = fmt.Sprintf("%s was not measured before and after the item", change.MetricKey)
+		return v
+	}
+	delta := *v.After - *v.Before
+	v.Delta = &delta
+	if change.Direction == "decrease" {
+		delta = -delta
+	}
+	switch {
+	case delta > 0:
+		v.Status = VerificationVerified
+	case delta < 0:
+		v.Status = VerificationRegressed
+	default:
+		v.Reason = fmt.Sprintf("%s did not change", change.MetricKey)
+	}
+	return v
+}
+
+// WriteVerification writes verification.json to an item dir.
+func WriteVerification(itemDir string, v Verification) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal verification: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(itemDir, VerificationFileName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write verification: %w", err)
+	}
+	return nil
+}
+
+// LoadVerification reads an item's verification.json, returning nil when
+// the item was not verified.
+func LoadVerification(itemDir string) (*Verification, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, VerificationFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read verification: %w", err)
+	}
+	var v Verification
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parse verification: %w", err)
+	}
+	return &v, nil
+}
+
+// VerificationCounts returns how many of the run's items were verified,
+// left unverified, and regressed.
+func (r *RunResult) VerificationCounts() (verified, unverified, regressed int) {
+	for _, run := range r.ItemRuns {
+		if run.Verification == nil {
+			continue
+		}
+		switch run.Verification.Status {
+		case VerificationVerified:
+			verified++
+		case VerificationRegressed:
+			regressed++
+		default:
+			unverified++
+		}
+	}
+	return verified, unverified, regressed
+}
+
+// MeasureWorkspace returns a MeasureFunc that measures with cfg into
+// snapshotsDir, dated the day it is called. Calls are serialized, since
+// parallel items share the day's snapshot.
+func MeasureWorkspace(cfg metrics.ProviderConfig, snapshotsDir string) MeasureFunc {
+	var mu sync.Mutex
+	return func(ctx context.Context) (*metrics.Snapshot, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		cfg := cfg
+		cfg.AsOf = time.Now().UTC().Truncate(24 * time.Hour)
+		return metrics.Measure(ctx, cfg, snapshotsDir, false)
+	}
+}
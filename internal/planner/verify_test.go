@@ -0,0 +1,91 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+)
+
+func passRateSnapshot(value float64) *metrics.Snapshot {
+	return &metrics.Snapshot{AsOf: "2025-01-15", Points: []metrics.MetricPoint{{Key: "ci.pass_rate", Value: value}}}
+}
+
+func TestVerifyItem(t *testing.T) {
+	increase := PlanItem{KRID: "KR-1", ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase"}}
+	decrease := PlanItem{KRID: "KR-1", ExpectedMetricChange: ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "decrease"}}
+	cases := []struct {
+		name          string
+		item          PlanItem
+		before, after *metrics.Snapshot
+		err           error
+		want          string
+	}{
+		{"increased", increase, passRateSnapshot(0.8), passRateSnapshot(0.9), nil, VerificationVerified},
+		{"decreased", increase, passRateSnapshot(0.8), passRateSnapshot(0.7), nil, VerificationRegressed},
+		{"decrease expected", decrease, passRateSnapshot(0.8), passRateSnapshot(0.7), nil, VerificationVerified},
+		{"unchanged", increase, passRateSnapshot(0.8), passRateSnapshot(0.8), nil, VerificationUnverified},
+		{"not measured", increase, &metrics.Snapshot{}, passRateSnapshot(0.9), nil, VerificationUnverified},
+		{"measure failed", increase, nil, nil, errors.New("boom"), VerificationUnverified},
+	}
+	for _, tc := range cases {
+		if got := verifyItem(tc.item, tc.before, tc.after, tc.err); got.Status != tc.want {
+			t.Fatalf("%s: status = %s, want %s (%+v)", tc.name, got.Status, tc.want, got)
+		}
+	}
+}
+
+func TestRunPlanVerifiesItems(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "okrs", "org.yml"), []byte("scope: org\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	planPath := writeTestPlan(t, root)
+
+	values := []float64{0.8, 0.85}
+	measure := func(ctx context.Context) (*metrics.Snapshot, error) {
+		value := values[0]
+		values = values[1:]
+		return passRateSnapshot(value), nil
+	}
+	adapter := &stubAdapter{fn: func(ctx context.Context, cfg adapters.RunConfig) (*adapters.RunResult, error) {
+		result := `{"schema_version": "1.0", "summary": "done", "proposed_changes": [], "kr_targets": ["KR-1"], "kr_impact_claim": "raises pass rate"}`
+		return &adapters.RunResult{}, os.WriteFile(filepath.Join(cfg.ArtifactsDir, "result.json"), []byte(result), 0o644)
+	}}
+
+	result, err := RunPlan(context.Background(), RunOptions{
+		PlanPath:    planPath,
+		WorkDir:     root,
+		Adapter:     adapter,
+		Timeout:     time.Minute,
+		AuditLogger: audit.NewLogger(filepath.Join(root, "audit.sqlite")),
+		RunBaseDir:  filepath.Join(root, "runs"),
+		Measure:     measure,
+	})
+	if err != nil {
+		t.Fatalf("RunPlan: %v", err)
+	}
+	if verified, unverified, regressed := result.VerificationCounts(); verified != 1 || unverified != 0 || regressed != 0 {
+		t.Fatalf("counts = %d/%d/%d", verified, unverified, regressed)
+	}
+	stored, err := LoadVerification(result.ItemRuns[0].ItemDir)
+	if err != nil || stored == nil || stored.Delta == nil || *stored.Delta < 0.049 || *stored.Delta > 0.051 {
+		t.Fatalf("verification.json = %+v, %v", stored, err)
+	}
+	state, err := LoadRunState(result.RunDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := state.Items[0].Verification; v == nil || v.Status != VerificationVerified {
+		t.Fatalf("run.json verification = %+v", v)
+	}
+}
@@ -0,0 +1,328 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+)
+
+// Write stores the report as <stem>.json and <stem>.md in dir, plus
+// <stem>.html when withHTML is set, and returns the paths written.
+func Write(dir string, r *Report, loc locale.Locale, withHTML bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure reports dir: %w", err)
+	}
+	generated, err := time.Parse(time.RFC3339, r.GeneratedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse generated_at: %w", err)
+	}
+	stem := filepath.Join(dir, FileStem(generated))
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal report: %w", err)
+	}
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{stem + ".json", append(data, '\n')},
+		{stem + ".md", []byte(RenderMarkdown(r, loc))},
+	}
+	if withHTML {
+		html, err := RenderHTML(r, loc)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, struct {
+			path string
+			data []byte
+		}{stem + ".html", []byte(html)})
+	}
+	var paths []string
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.data, 0o644); err != nil {
+			return paths, fmt.Errorf("write report: %w", err)
+		}
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
+
+// RenderMarkdown formats a report as Markdown.
+func RenderMarkdown(r *Report, loc locale.Locale) string {
+	v := newView(r, loc)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# OKR Review: %s – %s\n\n", v.Since, v.Until)
+	if r.AsOf != "" {
+		fmt.Fprintf(&b, "Scores as of %s (`%s`).\n\n", v.AsOf, r.ScoreReport)
+	} else {
+		b.WriteString("No KRs have been scored yet; run `okrchestra kr score`.\n\n")
+	}
+
+	b.WriteString("## Objectives\n\n")
+	if len(v.Objectives) == 0 {
+		b.WriteString("No objectives.\n\n")
+	}
+	for _, o := range v.Objectives {
+		fmt.Fprintf(&b, "### %s: %s — %s\n\n", o.ID, o.Title, o.Percent)
+		fmt.Fprintf(&b, "%s\n\n", o.Statuses)
+		b.WriteString("| KR | Status | Progress | Current / Target | Projected | Description |\n")
+		b.WriteString("|----|--------|----------|------------------|-----------|-------------|\n")
+		for _, kr := range o.KRs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s / %s | %s | %s |\n", kr.ID, kr.Status, kr.Percent, kr.Current, kr.Target,
+				kr.Projected, strings.ReplaceAll(kr.Description, "|", `\|`))
+		}
+		if o.Missing != "" {
+			fmt.Fprintf(&b, "\nMissing metrics: %s\n", o.Missing)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Changes Since Last Report\n\n")
+	switch {
+	case r.PreviousReport == "":
+		b.WriteString("No earlier report to compare with.\n\n")
+	case len(v.Deltas) == 0:
+		fmt.Fprintf(&b, "No KR changed since `%s`.\n\n", r.PreviousReport)
+	default:
+		b.WriteString("| KR | Objective | Progress | Status |\n|----|-----------|----------|--------|\n")
+		for _, d := range v.Deltas {
+			fmt.Fprintf(&b, "| %s | %s | %s → %s | %s |\n", d.KRID, d.ObjectiveID, d.PreviousPercent, d.Percent, d.Status)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Plan Runs\n\n")
+	if len(v.Runs) == 0 {
+		b.WriteString("No plan runs in this period.\n\n")
+	} else {
+		b.WriteString("| Run | Started | Plan | Items | Failures |\n|-----|---------|------|-------|----------|\n")
+		for _, run := range v.Runs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", run.ID, run.Started, run.Plan, run.Items, run.Failures)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Notable Events\n\n")
+	if len(v.Events) == 0 {
+		b.WriteString("No notable events in this period.\n")
+	}
+	for _, ev := range v.Events {
+		fmt.Fprintf(&b, "- %s `%s` %s\n", ev.TS, ev.Type, ev.Summary)
+	}
+	return b.String()
+}
+
+// RenderHTML formats a report as a standalone HTML page.
+func RenderHTML(r *Report, loc locale.Locale) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, newView(r, loc)); err != nil {
+		return "", fmt.Errorf("render report html: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// view is a report with every value formatted for display, shared by the
+// Markdown and HTML renderers.
+type view struct {
+	Since, Until, AsOf string
+	ScoreReport        string
+	PreviousReport     string
+	Objectives         []objectiveView
+	Deltas             []deltaView
+	Runs               []runView
+	Events             []eventView
+}
+
+type objectiveView struct {
+	ID, Title, Percent, Statuses, Missing string
+	KRs                                   []krView
+}
+
+type krView struct {
+	ID, Status, Percent, Current, Target, Projected, Description string
+}
+
+type deltaView struct {
+	KRID, ObjectiveID, PreviousPercent, Percent, Status string
+}
+
+type runView struct {
+	ID, Started, Plan, Items, Failures string
+}
+
+type eventView struct {
+	TS, Type, Summary string
+}
+
+func newView(r *Report, loc locale.Locale) view {
+	v := view{
+		Since:          formatDate(loc, r.Since),
+		Until:          formatDate(loc, r.Until),
+		AsOf:           loc.DateString(r.AsOf),
+		ScoreReport:    r.ScoreReport,
+		PreviousReport: r.PreviousReport,
+	}
+	for _, o := range r.Objectives {
+		ov := objectiveView{
+			ID:       o.ObjectiveID,
+			Title:    o.Objective,
+			Percent:  percent(loc, o.PercentToTarget),
+			Statuses: statusCounts(o),
+			Missing:  strings.Join(o.MissingMetrics, ", "),
+		}
+		for _, kr := range o.KeyResults {
+			current := "-"
+			if kr.Current != nil {
+				current = loc.Number(*kr.Current)
+			}
+			projected := kr.ProjectedStatus
+			if projected == "" {
+				projected = "-"
+			}
+			ov.KRs = append(ov.KRs, krView{
+				ID:          kr.KRID,
+				Status:      kr.Status,
+				Percent:     percent(loc, kr.PercentToTarget),
+				Current:     current,
+				Target:      loc.Number(kr.Target),
+				Projected:   projected,
+				Description: kr.Description,
+			})
+		}
+		v.Objectives = append(v.Objectives, ov)
+	}
+	for _, d := range r.Deltas {
+		status := d.Status
+		if d.PreviousStatus != d.Status {
+			status = d.PreviousStatus + " → " + d.Status
+		}
+		v.Deltas = append(v.Deltas, deltaView{
+			KRID:            d.KRID,
+			ObjectiveID:     d.ObjectiveID,
+			PreviousPercent: percent(loc, d.PreviousPercent),
+			Percent:         percent(loc, d.Percent),
+			Status:          status,
+		})
+	}
+	for _, run := range r.Runs {
+		plan := run.PlanID
+		if plan == "" {
+			plan = "-"
+		}
+		v.Runs = append(v.Runs, runView{
+			ID:       run.RunID,
+			Started:  formatDateTime(loc, run.StartedAt),
+			Plan:     plan,
+			Items:    loc.Int(int64(run.Items)),
+			Failures: loc.Int(int64(run.Failures)),
+		})
+	}
+	for _, ev := range r.Events {
+		v.Events = append(v.Events, eventView{TS: formatDateTime(loc, ev.TS), Type: ev.Type, Summary: ev.Summary})
+	}
+	return v
+}
+
+func percent(loc locale.Locale, pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return loc.Fixed(*pct, 0) + "%"
+}
+
+// statusCounts renders an objective's KR statuses as "2 achieved, 1 at_risk".
+func statusCounts(o metrics.ObjectiveRollup) string {
+	statuses := make([]string, 0, len(o.StatusCounts))
+	for status := range o.StatusCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		label := status
+		if label == "" {
+			label = "no status"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", o.StatusCounts[status], label))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatDate(loc locale.Locale, ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return loc.FormatDate(t)
+}
+
+func formatDateTime(loc locale.Locale, ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return loc.FormatDateTime(t)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>OKR Review: {{.Since}} – {{.Until}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2em auto; color: #1f2328; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; }
+th { background: #f6f8fa; }
+.muted { color: #656d76; }
+</style>
+</head>
+<body>
+<h1>OKR Review: {{.Since}} – {{.Until}}</h1>
+{{if .ScoreReport}}<p class="muted">Scores as of {{.AsOf}} ({{.ScoreReport}}).</p>{{else}}<p class="muted">No KRs have been scored yet.</p>{{end}}
+<h2>Objectives</h2>
+{{range .Objectives}}
+<h3>{{.ID}}: {{.Title}} — {{.Percent}}</h3>
+<p class="muted">{{.Statuses}}</p>
+<table>
+<tr><th>KR</th><th>Status</th><th>Progress</th><th>Current / Target</th><th>Projected</th><th>Description</th></tr>
+{{range .KRs}}<tr><td>{{.ID}}</td><td>{{.Status}}</td><td>{{.Percent}}</td><td>{{.Current}} / {{.Target}}</td><td>{{.Projected}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{if .Missing}}<p>Missing metrics: {{.Missing}}</p>{{end}}
+{{else}}<p>No objectives.</p>
+{{end}}
+<h2>Changes Since Last Report</h2>
+{{if not .PreviousReport}}<p>No earlier report to compare with.</p>
+{{else if not .Deltas}}<p>No KR changed since {{.PreviousReport}}.</p>
+{{else}}<table>
+<tr><th>KR</th><th>Objective</th><th>Progress</th><th>Status</th></tr>
+{{range .Deltas}}<tr><td>{{.KRID}}</td><td>{{.ObjectiveID}}</td><td>{{.PreviousPercent}} → {{.Percent}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Plan Runs</h2>
+{{if .Runs}}<table>
+<tr><th>Run</th><th>Started</th><th>Plan</th><th>Items</th><th>Failures</th></tr>
+{{range .Runs}}<tr><td>{{.ID}}</td><td>{{.Started}}</td><td>{{.Plan}}</td><td>{{.Items}}</td><td>{{.Failures}}</td></tr>
+{{end}}</table>
+{{else}}<p>No plan runs in this period.</p>
+{{end}}
+<h2>Notable Events</h2>
+{{if .Events}}<ul>
+{{range .Events}}<li>{{.TS}} <code>{{.Type}}</code> {{.Summary}}</li>
+{{end}}</ul>
+{{else}}<p>No notable events in this period.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,327 @@
+// Package report builds the periodic OKR review report: objective rollups,
+// KR scores and their change since the previous report, the plan runs in
+// the period, and notable audit events.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/artifacts"
+	"okrchestra/internal/audit"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/workspace"
+)
+
+const SchemaVersion = 1
+
+// DefaultDays is the period a report covers when none is given.
+const DefaultDays = 7
+
+// NotableEventTypes are the audit events a report lists.
+var NotableEventTypes = []string{
+	"kr_status_auto_updated",
+	"okr_apply_finished",
+	"kr_baseline_proposed",
+	"metric_annotated",
+	"plan_generate_finished",
+	"plan_outcome_resolved",
+	"plan_retro_written",
+	"run_item_reviewed",
+	"job_failed",
+}
+
+// Report is the data behind one generated report, stored as JSON next to
+// the rendered Markdown so the next report can compute deltas.
+type Report struct {
+	SchemaVersion int    `json:"schema_version"`
+	GeneratedAt   string `json:"generated_at"`
+	Since         string `json:"since"`
+	Until         string `json:"until"`
+	// OKRPeriod is set when the report was limited to one OKR period.
+	OKRPeriod string `json:"okr_period,omitempty"`
+	// AsOf and ScoreReport identify the kr score report rolled up; both
+	// are empty when no KRs have been scored.
+	AsOf           string                    `json:"as_of,omitempty"`
+	ScoreReport    string                    `json:"score_report,omitempty"`
+	PreviousReport string                    `json:"previous_report,omitempty"`
+	Objectives     []metrics.ObjectiveRollup `json:"objectives"`
+	Deltas         []KRDelta                 `json:"deltas,omitempty"`
+	Runs           []RunSummary              `json:"runs"`
+	Events         []EventSummary            `json:"events"`
+}
+
+// KRDelta is a KR whose progress or status moved since the previous report.
+type KRDelta struct {
+	ObjectiveID     string   `json:"objective_id"`
+	KRID            string   `json:"kr_id"`
+	PreviousPercent *float64 `json:"previous_percent,omitempty"`
+	Percent         *float64 `json:"percent,omitempty"`
+	PreviousStatus  string   `json:"previous_status"`
+	Status          string   `json:"status"`
+}
+
+// RunSummary is one plan run started in the period.
+type RunSummary struct {
+	RunID     string `json:"run_id"`
+	StartedAt string `json:"started_at"`
+	PlanID    string `json:"plan_id,omitempty"`
+	Items     int    `json:"items"`
+	Failures  int    `json:"failures"`
+}
+
+// EventSummary is a notable audit event in the period.
+type EventSummary struct {
+	TS      string `json:"ts"`
+	Type    string `json:"type"`
+	Summary string `json:"summary"`
+}
+
+// Options configures Build.
+type Options struct {
+	Workspace *workspace.Workspace
+	// Days is the period covered, ending at Now (default DefaultDays).
+	Days  int
+	Scope okrstore.Scope
+	// OKRPeriod limits the report to objectives in one OKR period, such
+	// as 2025-Q3.
+	OKRPeriod string
+	Now       time.Time
+}
+
+// Dir returns where reports are written for an artifacts dir.
+func Dir(artifactsDir string) string {
+	return filepath.Join(artifactsDir, "reports")
+}
+
+// Build gathers a report for the period ending at opts.Now.
+func Build(opts Options) (*Report, error) {
+	ws := opts.Workspace
+	if ws == nil {
+		return nil, fmt.Errorf("workspace is required")
+	}
+	if opts.Days <= 0 {
+		opts.Days = DefaultDays
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	until := opts.Now.UTC()
+	since := until.AddDate(0, 0, -opts.Days)
+
+	store, err := okrstore.LoadFromDirCached(ws.OKRsDir, filepath.Join(ws.ArtifactsDir, "cache"))
+	if err != nil {
+		return nil, err
+	}
+	store = store.InPeriod(opts.OKRPeriod)
+	r := &Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   until.Format(time.RFC3339),
+		Since:         since.Format(time.RFC3339),
+		Until:         until.Format(time.RFC3339),
+		OKRPeriod:     opts.OKRPeriod,
+		Runs:          []RunSummary{},
+		Events:        []EventSummary{},
+	}
+
+	var score *metrics.KRScoreReport
+	scorePath, err := metrics.LatestScoreReportPath(ws.ArtifactsDir)
+	if err != nil {
+		return nil, err
+	}
+	if scorePath != "" {
+		if score, err = metrics.LoadScoreReport(scorePath); err != nil {
+			return nil, err
+		}
+		r.AsOf = score.AsOf
+		r.ScoreReport = ws.RelPath(scorePath)
+	}
+	r.Objectives = metrics.RollupObjectives(store, score, opts.Scope)
+	if r.Objectives == nil {
+		r.Objectives = []metrics.ObjectiveRollup{}
+	}
+
+	previous, previousPath, err := loadPrevious(Dir(ws.ArtifactsDir), until)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		r.PreviousReport = ws.RelPath(previousPath)
+		r.Deltas = deltas(previous.Objectives, r.Objectives)
+	}
+
+	if r.Runs, err = runsBetween(ws.ArtifactsDir, since, until); err != nil {
+		return nil, err
+	}
+	if r.Events, err = notableEvents(ws.AuditDBPath, since, until); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FileStem is the name, without extension, of the report for a day.
+func FileStem(now time.Time) string {
+	return "okr_review_" + now.UTC().Format("2006-01-02")
+}
+
+// loadPrevious returns the newest report from a day before now.
+func loadPrevious(dir string, now time.Time) (*Report, string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "okr_review_*.json"))
+	if err != nil {
+		return nil, "", fmt.Errorf("find previous reports: %w", err)
+	}
+	sort.Strings(matches)
+	current := FileStem(now) + ".json"
+	for i := len(matches) - 1; i >= 0; i-- {
+		if filepath.Base(matches[i]) >= current {
+			continue
+		}
+		data, err := os.ReadFile(matches[i])
+		if err != nil {
+			return nil, "", fmt.Errorf("read previous report: %w", err)
+		}
+		var previous Report
+		if err := json.Unmarshal(data, &previous); err != nil {
+			return nil, "", fmt.Errorf("decode %s: %w", filepath.Base(matches[i]), err)
+		}
+		return &previous, matches[i], nil
+	}
+	return nil, "", nil
+}
+
+// deltas lists KRs whose percent-to-target or status changed.
+func deltas(previous, current []metrics.ObjectiveRollup) []KRDelta {
+	before := map[string]metrics.KRRollup{}
+	for _, obj := range previous {
+		for _, kr := range obj.KeyResults {
+			before[obj.ObjectiveID+"\x00"+kr.KRID] = kr
+		}
+	}
+	var out []KRDelta
+	for _, obj := range current {
+		for _, kr := range obj.KeyResults {
+			prev, ok := before[obj.ObjectiveID+"\x00"+kr.KRID]
+			if !ok || (prev.Status == kr.Status && samePercent(prev.PercentToTarget, kr.PercentToTarget)) {
+				continue
+			}
+			out = append(out, KRDelta{
+				ObjectiveID:     obj.ObjectiveID,
+				KRID:            kr.KRID,
+				PreviousPercent: prev.PercentToTarget,
+				Percent:         kr.PercentToTarget,
+				PreviousStatus:  prev.Status,
+				Status:          kr.Status,
+			})
+		}
+	}
+	return out
+}
+
+func samePercent(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// runsBetween summarizes the run dirs whose IDs (their start times) fall in
+// the period, oldest first.
+func runsBetween(artifactsDir string, since, until time.Time) ([]RunSummary, error) {
+	runsDir := filepath.Join(artifactsDir, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return []RunSummary{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read runs dir: %w", err)
+	}
+	// The index only supplies plan IDs, so a missing one is not an error.
+	idx, _ := artifacts.Open(artifactsDir)
+	if idx != nil {
+		defer idx.Close()
+	}
+
+	runs := []RunSummary{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		started, err := time.Parse("20060102T150405Z", entry.Name())
+		if err != nil || started.Before(since) || started.After(until) {
+			continue
+		}
+		run := RunSummary{RunID: entry.Name(), StartedAt: started.Format(time.RFC3339)}
+		items, _ := filepath.Glob(filepath.Join(runsDir, entry.Name(), "item-*"))
+		for _, item := range items {
+			run.Items++
+			if _, err := os.Stat(filepath.Join(item, "failure.json")); err == nil {
+				run.Failures++
+			}
+		}
+		if idx != nil {
+			if found, err := idx.Find(artifacts.Filter{RunID: run.RunID}); err == nil {
+				for _, e := range found {
+					if e.PlanID != "" {
+						run.PlanID = e.PlanID
+						break
+					}
+				}
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func notableEvents(auditDB string, since, until time.Time) ([]EventSummary, error) {
+	events, err := audit.ReadEvents(auditDB, audit.Query{Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+	notable := map[string]bool{}
+	for _, t := range NotableEventTypes {
+		notable[t] = true
+	}
+	out := []EventSummary{}
+	for _, ev := range events {
+		if !notable[ev.Type] {
+			continue
+		}
+		out = append(out, EventSummary{
+			TS:      ev.TS.UTC().Format(time.RFC3339),
+			Type:    ev.Type,
+			Summary: SummarizePayload(ev.PayloadJSON),
+		})
+	}
+	return out, nil
+}
+
+// summaryFields are the payload fields worth showing, in display order.
+var summaryFields = []string{"kr_id", "old_status", "new_status", "job_type", "plan_id", "run_id", "proposal", "proposal_id", "status", "key", "note", "decision", "comment", "error"}
+
+// SummarizePayload renders the notable fields of an audit event payload as
+// "key=value" pairs, for one-line event listings.
+func SummarizePayload(payloadJSON string) string {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, field := range summaryFields {
+		value, ok := payload[field]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		if path, isString := value.(string); isString && filepath.IsAbs(path) {
+			value = filepath.Base(path)
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", field, value))
+	}
+	return strings.Join(parts, " ")
+}
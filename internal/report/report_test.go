@@ -0,0 +1,157 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/audit"
+	"okrchestra/internal/locale"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/workspace"
+)
+
+const testOKRs = `
+scope: org
+objectives:
+  - objective_id: OBJ-1
+    objective: Ship reliably
+    owner_id: team-alpha
+    key_results:
+      - kr_id: KR-1
+        description: Raise pass rate
+        owner_id: team-alpha
+        metric_key: ci.pass_rate
+        baseline: 50
+        target: 100
+        confidence: 0.5
+        status: in_progress
+        evidence: ["seed"]
+`
+
+func TestBuildAndWrite(t *testing.T) {
+	ws, err := workspace.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve workspace: %v", err)
+	}
+	if err := ws.EnsureDirs(); err != nil {
+		t.Fatalf("ensure dirs: %v", err)
+	}
+	if err := os.MkdirAll(ws.OKRsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.OKRsDir, "org.yml"), []byte(testOKRs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	score := metrics.KRScoreReport{
+		SchemaVersion: metrics.KRScoreSchemaVersion,
+		AsOf:          now.Format("2006-01-02"),
+		Results: []metrics.KRScore{{
+			ObjectiveID: "OBJ-1", KRID: "KR-1", MetricKey: "ci.pass_rate",
+			Current: ptr(80), PercentToTarget: 60, ProjectedStatus: metrics.ProjectedOnTrack,
+		}},
+	}
+	writeJSON(t, filepath.Join(ws.ArtifactsDir, "kr_score_"+score.AsOf+".json"), score)
+
+	// Last week's report had KR-1 at 20%.
+	lastWeek := now.AddDate(0, 0, -7)
+	previous := Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   lastWeek.Format(time.RFC3339),
+		Objectives: []metrics.ObjectiveRollup{{
+			ObjectiveID: "OBJ-1",
+			KeyResults:  []metrics.KRRollup{{KRID: "KR-1", Status: "not_started", PercentToTarget: ptr(20)}},
+		}},
+	}
+	writeJSON(t, filepath.Join(Dir(ws.ArtifactsDir), FileStem(lastWeek)+".json"), previous)
+
+	// One run in the period with a failed item, and one before it.
+	for _, runID := range []string{now.AddDate(0, 0, -1).Format("20060102T150405Z"), now.AddDate(0, 0, -30).Format("20060102T150405Z")} {
+		for _, item := range []string{"item-0001", "item-0002"} {
+			if err := os.MkdirAll(filepath.Join(ws.ArtifactsDir, "runs", runID, item), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(ws.ArtifactsDir, "runs", runID, "item-0002", "failure.json"), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := audit.NewLogger(ws.AuditDBPath)
+	if err := logger.LogEvent("daemon", "kr_status_auto_updated", map[string]any{"kr_id": "KR-1", "old_status": "not_started", "new_status": "in_progress"}); err != nil {
+		t.Fatalf("log event: %v", err)
+	}
+	if err := logger.LogEvent("cli", "plan_run_started", map[string]any{"run_id": "ignored"}); err != nil {
+		t.Fatalf("log event: %v", err)
+	}
+
+	r, err := Build(Options{Workspace: ws, Now: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(r.Objectives) != 1 || r.Objectives[0].PercentToTarget == nil || *r.Objectives[0].PercentToTarget != 60 {
+		t.Fatalf("unexpected objectives: %+v", r.Objectives)
+	}
+	if len(r.Deltas) != 1 || *r.Deltas[0].PreviousPercent != 20 || r.Deltas[0].Status != "in_progress" {
+		t.Fatalf("unexpected deltas: %+v", r.Deltas)
+	}
+	if len(r.Runs) != 1 || r.Runs[0].Items != 2 || r.Runs[0].Failures != 1 {
+		t.Fatalf("unexpected runs: %+v", r.Runs)
+	}
+	if len(r.Events) != 1 || r.Events[0].Summary != "kr_id=KR-1 old_status=not_started new_status=in_progress" {
+		t.Fatalf("unexpected events: %+v", r.Events)
+	}
+
+	paths, err := Write(Dir(ws.ArtifactsDir), r, locale.Canonical, true)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("paths = %v", paths)
+	}
+	md, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"### OBJ-1: Ship reliably — 60%", "| KR-1 | in_progress | 60% | 80 / 100 | on_track |", "| KR-1 | OBJ-1 | 20% → 60% | not_started → in_progress |", "`kr_status_auto_updated`"} {
+		if !strings.Contains(string(md), want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	html, err := os.ReadFile(paths[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "<td>20% → 60%</td>") {
+		t.Fatalf("unexpected html:\n%s", html)
+	}
+
+	// Regenerating the same day still compares against last week.
+	again, err := Build(Options{Workspace: ws, Now: now.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if !strings.HasSuffix(again.PreviousReport, FileStem(lastWeek)+".json") {
+		t.Fatalf("previous report = %q", again.PreviousReport)
+	}
+}
+
+func writeJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
@@ -0,0 +1,249 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// ImportOptions configures DraftFromRepo.
+type ImportOptions struct {
+	RepoDir string
+	// CIReportPath is a CI report in the ci provider's format. Empty
+	// looks for metrics/ci_report.json and ci_report.json in the repo.
+	CIReportPath string
+	// OwnerID owns the drafted objectives; default team-<repo name>.
+	OwnerID string
+	AsOf    time.Time
+}
+
+// Import is an org OKR document drafted from a repository's current
+// measurements.
+type Import struct {
+	RepoDir      string
+	CIReportPath string
+	// Measurements are the metric values the baselines were taken from.
+	Measurements map[string]float64
+	Document     okrstore.Document
+	// Notes explain KRs that were left out or need attention.
+	Notes []string
+}
+
+// ciConfigPaths are files whose presence shows a repository runs CI.
+var ciConfigPaths = []string{".github/workflows", ".gitlab-ci.yml", ".circleci", "Jenkinsfile", ".buildkite", "azure-pipelines.yml"}
+
+// DraftFromRepo measures commit cadence, CI results, and open TODOs in an
+// existing git repository and drafts objectives and KRs whose baselines are
+// today's values. Targets are suggestions to review before planning.
+func DraftFromRepo(ctx context.Context, opts ImportOptions) (*Import, error) {
+	repoDir, err := filepath.Abs(opts.RepoDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve repo dir: %w", err)
+	}
+	if info, err := os.Stat(repoDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("repo dir %s is not a directory", repoDir)
+	}
+	asOf := opts.AsOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = asOf.UTC().Truncate(24 * time.Hour)
+
+	imp := &Import{RepoDir: repoDir, Measurements: map[string]float64{}}
+	gitPoints, err := (&metrics.GitProvider{RepoDir: repoDir, AsOf: asOf}).Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(gitPoints) == 0 {
+		return nil, fmt.Errorf("%s is not a git repository with commits", repoDir)
+	}
+	for _, p := range gitPoints {
+		imp.Measurements[p.Key] = p.Value
+	}
+
+	imp.CIReportPath = opts.CIReportPath
+	if imp.CIReportPath == "" {
+		for _, candidate := range []string{filepath.Join("metrics", "ci_report.json"), "ci_report.json"} {
+			if _, err := os.Stat(filepath.Join(repoDir, candidate)); err == nil {
+				imp.CIReportPath = filepath.Join(repoDir, candidate)
+				break
+			}
+		}
+	}
+	if imp.CIReportPath != "" {
+		ciPoints, err := (&metrics.CIProvider{ReportPath: imp.CIReportPath, AsOf: asOf}).Collect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ciPoints {
+			imp.Measurements[p.Key] = p.Value
+		}
+	}
+
+	owner := opts.OwnerID
+	if owner == "" {
+		owner = "team-" + slug(filepath.Base(repoDir))
+	}
+	evidence := func(key string) []string {
+		return []string{fmt.Sprintf("import:%s=%s@%s", key, formatValue(imp.Measurements[key]), asOf.Format("2006-01-02"))}
+	}
+	note := fmt.Sprintf("Drafted by init --from-existing from %s on %s; review targets before planning.", filepath.Base(repoDir), asOf.Format("2006-01-02"))
+
+	delivery := okrstore.Objective{
+		ID:        "OBJ-DELIVERY",
+		Objective: "Ship changes at a steady, sustainable cadence.",
+		OwnerID:   owner,
+		Notes:     note,
+	}
+	commits := imp.Measurements["git.commits_30d"]
+	delivery.KeyResults = append(delivery.KeyResults, okrstore.KeyResult{
+		ID:          "KR-COMMIT-CADENCE",
+		Description: fmt.Sprintf("Raise commits per 30 days from %s to %s.", formatValue(commits), formatValue(cadenceTarget(commits))),
+		OwnerID:     owner,
+		MetricKey:   "git.commits_30d",
+		Baseline:    commits,
+		Target:      cadenceTarget(commits),
+		Confidence:  0.5,
+		Status:      "not_started",
+		Evidence:    evidence("git.commits_30d"),
+	})
+
+	quality := okrstore.Objective{
+		ID:        "OBJ-QUALITY",
+		Objective: "Keep the codebase healthy as it grows.",
+		OwnerID:   owner,
+		Notes:     note,
+	}
+	if pass, ok := imp.Measurements["ci.pass_rate_30d"]; ok {
+		if target, ok := passRateTarget(pass); ok {
+			quality.KeyResults = append(quality.KeyResults, okrstore.KeyResult{
+				ID:          "KR-CI-PASS-RATE",
+				Description: fmt.Sprintf("Raise the CI pass rate from %s to %s.", formatValue(pass), formatValue(target)),
+				OwnerID:     owner,
+				MetricKey:   "ci.pass_rate_30d",
+				Baseline:    pass,
+				Target:      target,
+				Confidence:  0.5,
+				Status:      "not_started",
+				Evidence:    evidence("ci.pass_rate_30d"),
+			})
+		} else {
+			imp.Notes = append(imp.Notes, fmt.Sprintf("CI pass rate is already %s; no KR drafted for it.", formatValue(pass)))
+		}
+	} else if ci := detectCI(repoDir); ci != "" {
+		// The repository runs CI but its results are not exported yet:
+		// leave the baseline for `kr baseline detect`.
+		quality.KeyResults = append(quality.KeyResults, okrstore.KeyResult{
+			ID:              "KR-CI-PASS-RATE",
+			Description:     "Keep the CI pass rate at or above 95%.",
+			OwnerID:         owner,
+			MetricKey:       "ci.pass_rate_30d",
+			BaselinePending: true,
+			Target:          0.95,
+			Confidence:      0.5,
+			Status:          "not_started",
+			Evidence:        []string{"import:" + ci},
+		})
+		imp.Notes = append(imp.Notes, fmt.Sprintf("CI is configured (%s) but no ci_report.json was found; export pass_rate_30d to metrics/ci_report.json, then run kr baseline detect.", ci))
+	} else {
+		imp.Notes = append(imp.Notes, "No CI configuration or report found; no CI KR drafted.")
+	}
+	if todos := imp.Measurements["git.todo_count"]; todos > 0 {
+		target := math.Floor(todos / 2)
+		quality.KeyResults = append(quality.KeyResults, okrstore.KeyResult{
+			ID:          "KR-TODO-BURN-DOWN",
+			Description: fmt.Sprintf("Resolve half of the open TODO and FIXME markers (%s to %s).", formatValue(todos), formatValue(target)),
+			OwnerID:     owner,
+			MetricKey:   "git.todo_count",
+			Baseline:    todos,
+			Target:      target,
+			Confidence:  0.6,
+			Status:      "not_started",
+			Evidence:    evidence("git.todo_count"),
+		})
+	} else {
+		imp.Notes = append(imp.Notes, "No TODO or FIXME markers found; no TODO KR drafted.")
+	}
+
+	// The draft covers the current quarter.
+	period, err := okrstore.ParsePeriod(fmt.Sprintf("%d-Q%d", asOf.Year(), (int(asOf.Month())+2)/3), "", "")
+	if err != nil {
+		return nil, err
+	}
+	imp.Document = okrstore.Document{Scope: okrstore.ScopeOrg, Period: period}
+	for _, obj := range []okrstore.Objective{delivery, quality} {
+		if len(obj.KeyResults) > 0 {
+			obj.Period = period
+			imp.Document.Objectives = append(imp.Document.Objectives, obj)
+		}
+	}
+	return imp, nil
+}
+
+// cadenceTarget suggests a 25% increase in commits, at least five more.
+func cadenceTarget(commits float64) float64 {
+	return math.Max(math.Ceil(commits*1.25), commits+5)
+}
+
+// passRateTarget suggests closing half the gap to a perfect pass rate, for
+// rates given as a ratio (0..1) or a percentage.
+func passRateTarget(pass float64) (float64, bool) {
+	max := 1.0
+	if pass > 1 {
+		max = 100
+	}
+	if pass >= max*0.99 {
+		return 0, false
+	}
+	return math.Round((pass+(max-pass)/2)*1000) / 1000, true
+}
+
+func detectCI(repoDir string) string {
+	for _, path := range ciConfigPaths {
+		if _, err := os.Stat(filepath.Join(repoDir, filepath.FromSlash(path))); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// SortedMeasurements returns the measurement keys in order, for output.
+func (imp *Import) SortedMeasurements() []string {
+	keys := make([]string, 0, len(imp.Measurements))
+	for key := range imp.Measurements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", v), "0"), ".")
+}
+
+func slug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteByte('-')
+			}
+		}
+	}
+	s := strings.TrimSuffix(b.String(), "-")
+	if s == "" {
+		return "repo"
+	}
+	return s
+}
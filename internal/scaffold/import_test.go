@@ -0,0 +1,104 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"okrchestra/internal/okrstore"
+)
+
+func TestDraftFromRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	files := map[string]string{
+		"main.go":                  "package main\n\n// TODO: flags\n// FIXME: exit code\nfunc main() {}\n",
+		"notes.md":                 "TODO: docs\nTODOS are not markers\n",
+		"metrics/ci_report.json":   `{"pass_rate_30d": 0.8}`,
+		".github/workflows/ci.yml": "on: push\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "first")
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+
+	imp, err := DraftFromRepo(context.Background(), ImportOptions{RepoDir: repo, AsOf: time.Now()})
+	if err != nil {
+		t.Fatalf("draft: %v", err)
+	}
+	if imp.Measurements["git.commits_30d"] != 2 || imp.Measurements["git.todo_count"] != 3 || imp.Measurements["ci.pass_rate_30d"] != 0.8 {
+		t.Fatalf("measurements = %v", imp.Measurements)
+	}
+
+	krs := map[string]okrstore.KeyResult{}
+	for _, obj := range imp.Document.Objectives {
+		for _, kr := range obj.KeyResults {
+			krs[kr.ID] = kr
+		}
+	}
+	want := map[string][2]float64{
+		"KR-COMMIT-CADENCE": {2, 7},
+		"KR-CI-PASS-RATE":   {0.8, 0.9},
+		"KR-TODO-BURN-DOWN": {3, 1},
+	}
+	for id, bt := range want {
+		kr, ok := krs[id]
+		if !ok {
+			t.Fatalf("%s not drafted: %+v", id, imp.Document)
+		}
+		if kr.Baseline != bt[0] || kr.Target != bt[1] {
+			t.Errorf("%s baseline/target = %v/%v, want %v/%v", id, kr.Baseline, kr.Target, bt[0], bt[1])
+		}
+	}
+
+	// The draft must load like any other org file.
+	dir := t.TempDir()
+	if err := okrstore.WriteDocument(imp.Document, filepath.Join(dir, "org.yml")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := okrstore.LoadFromDir(dir); err != nil {
+		t.Fatalf("load draft: %v", err)
+	}
+
+	// Without a report, configured CI leaves the baseline pending.
+	if err := os.Remove(filepath.Join(repo, "metrics", "ci_report.json")); err != nil {
+		t.Fatal(err)
+	}
+	imp, err = DraftFromRepo(context.Background(), ImportOptions{RepoDir: repo})
+	if err != nil {
+		t.Fatalf("draft without report: %v", err)
+	}
+	for _, obj := range imp.Document.Objectives {
+		for _, kr := range obj.KeyResults {
+			if kr.ID == "KR-CI-PASS-RATE" && !kr.BaselinePending {
+				t.Fatalf("CI KR baseline not pending: %+v", kr)
+			}
+		}
+	}
+
+	if _, err := DraftFromRepo(context.Background(), ImportOptions{RepoDir: t.TempDir()}); err == nil {
+		t.Fatal("expected error for a directory that is not a repository")
+	}
+}
@@ -0,0 +1,188 @@
+// Package scaffold writes the starting files of a new workspace from a
+// built-in template, a template directory, or a git repository holding one.
+//
+// A template mirrors the workspace root: okrs/, culture/, metrics/, and
+// workspace files such as schedules.yml or adapters.yml are copied as-is;
+// artifacts/ and audit/ are skipped.
+package scaffold
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed all:templates
+var builtinFS embed.FS
+
+// DefaultTemplate is the template `init` uses without --template.
+const DefaultTemplate = "minimal"
+
+// Template describes a built-in template.
+type Template struct {
+	Name        string
+	Description string
+}
+
+// Builtin lists the built-in templates.
+var Builtin = []Template{
+	{Name: "minimal", Description: "One objective and KR measured from manual.yml"},
+	{Name: "solo", Description: "A single owner shipping a project: commit cadence, CI, first users"},
+	{Name: "startup", Description: "Company and product team OKRs, weekly planning, metric catalog"},
+	{Name: "platform-team", Description: "Reliability and developer experience OKRs fed by OpenMetrics exports, SRE team OKRs, daemon workers"},
+}
+
+// Result lists the files a template wrote and those it left alone because
+// they already existed, as slash-separated paths relative to the root.
+type Result struct {
+	Source  string
+	Written []string
+	Skipped []string
+}
+
+// Apply copies the template named by source into the workspace at root.
+// Source is a built-in template name, a directory, or a git URL, which is
+// cloned (depth 1) with the git CLI. Existing files are never overwritten.
+func Apply(ctx context.Context, root, source string) (*Result, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		source = DefaultTemplate
+	}
+	var (
+		fsys fs.FS
+		err  error
+	)
+	switch {
+	case isBuiltin(source):
+		fsys, err = fs.Sub(builtinFS, "templates/"+source)
+	case isGitURL(source):
+		var dir string
+		dir, err = clone(ctx, source)
+		if dir != "" {
+			defer os.RemoveAll(dir)
+			fsys = os.DirFS(dir)
+		}
+	default:
+		fsys, err = dirFS(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Source: source}
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Workspace state is never part of a template, even when the
+			// template directory is itself a workspace.
+			if d.Name() == ".git" || name == "artifacts" || name == "audit" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		dest := filepath.Join(root, filepath.FromSlash(name))
+		if _, err := os.Stat(dest); err == nil {
+			res.Skipped = append(res.Skipped, name)
+			return nil
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("stat %s: %w", dest, err)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("read template file %s: %w", name, err)
+		}
+		mode := os.FileMode(0o644)
+		if info, err := d.Info(); err == nil && info.Mode()&0o111 != 0 {
+			mode = 0o755
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("ensure dir for %s: %w", dest, err)
+		}
+		if err := os.WriteFile(dest, data, mode); err != nil {
+			return fmt.Errorf("write %s: %w", dest, err)
+		}
+		res.Written = append(res.Written, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Written)+len(res.Skipped) == 0 {
+		return nil, fmt.Errorf("template %s has no files", source)
+	}
+	return res, nil
+}
+
+func isBuiltin(name string) bool {
+	for _, t := range Builtin {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isGitURL reports whether source names a remote repository rather than
+// a local directory.
+func isGitURL(source string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(source, ".git") && !isDir(source)
+}
+
+func dirFS(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			names := make([]string, len(Builtin))
+			for i, t := range Builtin {
+				names[i] = t.Name
+			}
+			return nil, fmt.Errorf("unknown template %q: not a built-in template (%s), directory, or git URL", dir, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("stat template: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// clone fetches a shallow copy of url into a temporary directory. A URL
+// may end in #ref to check out a branch or tag.
+func clone(ctx context.Context, url string) (string, error) {
+	dir, err := os.MkdirTemp("", "okrchestra-template-")
+	if err != nil {
+		return "", fmt.Errorf("create template dir: %w", err)
+	}
+	args := []string{"clone", "--depth", "1", "--quiet"}
+	if base, ref, ok := strings.Cut(url, "#"); ok && ref != "" {
+		url = base
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, url, dir)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("git clone %s: %w: %s", url, err, strings.TrimSpace(string(out)))
+	}
+	return dir, nil
+}
@@ -0,0 +1,161 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/adapters"
+	"okrchestra/internal/config"
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+)
+
+// TestBuiltinTemplatesAreUsable scaffolds each built-in template and loads
+// everything it seeds the way the CLI and daemon would.
+func TestBuiltinTemplatesAreUsable(t *testing.T) {
+	for _, tmpl := range Builtin {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			root := t.TempDir()
+			res, err := Apply(context.Background(), root, tmpl.Name)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if len(res.Written) == 0 || len(res.Skipped) != 0 {
+				t.Fatalf("result = %+v", res)
+			}
+
+			store, err := okrstore.LoadFromDir(filepath.Join(root, "okrs"))
+			if err != nil {
+				t.Fatalf("load okrs: %v", err)
+			}
+			if _, err := okrstore.LoadPermissionConfig(filepath.Join(root, "okrs", "permissions.yml")); err != nil {
+				t.Fatalf("load permissions: %v", err)
+			}
+			if _, err := daemon.LoadSchedules(root); err != nil {
+				t.Fatalf("load schedules: %v", err)
+			}
+			if _, err := daemon.LoadPoolConfig(root); err != nil {
+				t.Fatalf("load daemon.yml: %v", err)
+			}
+			if _, err := adapters.LoadExecConfigs(root); err != nil {
+				t.Fatalf("load adapters: %v", err)
+			}
+			if _, err := config.Load(root); err != nil {
+				t.Fatalf("load config: %v", err)
+			}
+
+			// Every KR must be measurable from the seeded inputs, apart from
+			// git metrics, which need a repository.
+			providerCfg := metrics.ProviderConfig{RepoDir: root, MetricsDir: filepath.Join(root, "metrics")}
+			if err := providerCfg.LoadWorkspaceConfig(root); err != nil {
+				t.Fatalf("provider config: %v", err)
+			}
+			points, _, err := metrics.Collect(context.Background(), metrics.DefaultProviders(providerCfg), false)
+			if err != nil {
+				t.Fatalf("collect: %v", err)
+			}
+			measured := map[string]bool{}
+			for _, p := range points {
+				measured[p.Key] = true
+			}
+			var docs []okrstore.Document
+			docs = append(docs, store.Org.Documents...)
+			docs = append(docs, store.Team.Documents...)
+			docs = append(docs, store.Person.Documents...)
+			for _, doc := range docs {
+				for _, obj := range doc.Objectives {
+					for _, kr := range obj.KeyResults {
+						if !measured[kr.MetricKey] && !strings.HasPrefix(kr.MetricKey, "git.") {
+							t.Errorf("%s: metric %s is not produced by the template's inputs", kr.ID, kr.MetricKey)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDirectoryKeepsExistingFiles(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"okrs/org.yml":         "scope: org\nobjectives: []\n",
+		"culture/values.md":    "# Ours\n",
+		"schedules.yml":        "schedules: []\n",
+		"artifacts/runs/x.txt": "state",
+		".git/HEAD":            "ref: refs/heads/main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "culture"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "culture", "values.md"), []byte("# Mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Apply(context.Background(), root, src)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if strings.Join(res.Written, ",") != "okrs/org.yml,schedules.yml" || strings.Join(res.Skipped, ",") != "culture/values.md" {
+		t.Fatalf("result = %+v", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "culture", "values.md")); string(data) != "# Mine\n" {
+		t.Fatalf("existing file overwritten: %q", data)
+	}
+	for _, name := range []string{"artifacts", ".git"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s copied from template", name)
+		}
+	}
+
+	if _, err := Apply(context.Background(), root, filepath.Join(src, "missing")); err == nil || !strings.Contains(err.Error(), "minimal") {
+		t.Fatalf("missing template error = %v", err)
+	}
+}
+
+func TestApplyGitURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "okrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "okrs", "org.yml"), []byte("scope: org\nobjectives: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "template"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	root := t.TempDir()
+	res, err := Apply(context.Background(), root, "file://"+repo)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if strings.Join(res.Written, ",") != "okrs/org.yml" {
+		t.Fatalf("result = %+v", res)
+	}
+}
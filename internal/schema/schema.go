@@ -0,0 +1,287 @@
+// Package schema holds the canonical JSON Schemas of the files okrchestra
+// exchanges with other tools, and validates documents against them.
+//
+// The validator implements the subset of JSON Schema draft-07 the schemas
+// use: type, enum, const, pattern, minItems, minimum, exclusiveMinimum,
+// required, properties, additionalProperties, items, and local $ref.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed schemas/*.schema.json
+var schemaFS embed.FS
+
+// Schema types.
+const (
+	TypePlan     = "plan"
+	TypeResult   = "result"
+	TypeSnapshot = "snapshot"
+	TypeScore    = "score"
+)
+
+// Types lists the schema types in the order they are documented.
+var Types = []string{TypePlan, TypeResult, TypeSnapshot, TypeScore}
+
+// Export returns the JSON Schema for typ.
+func Export(typ string) ([]byte, error) {
+	data, err := schemaFS.ReadFile("schemas/" + typ + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema type %q (want %s)", typ, strings.Join(Types, ", "))
+	}
+	return data, nil
+}
+
+// Error is one way a document does not match its schema. Path locates the
+// offending value, e.g. $.items[2].expected_metric_change.direction.
+type Error struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks data against the schema for typ. It returns the schema
+// errors in document order, or an error when data is not JSON.
+func Validate(typ string, data []byte) ([]Error, error) {
+	raw, err := Export(typ)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]any
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("parse %s schema: %w", typ, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	v := &validator{root: root}
+	v.check(root, doc, "$")
+	return v.errs, nil
+}
+
+type validator struct {
+	root map[string]any
+	errs []Error
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(node map[string]any, value any, path string) {
+	if ref, ok := node["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		node = target
+	}
+
+	if types := schemaTypes(node["type"]); len(types) > 0 {
+		actual := jsonType(value)
+		if !typeAllowed(types, actual) {
+			v.fail(path, "must be %s, got %s", strings.Join(types, " or "), actual)
+			return
+		}
+	}
+	if want, ok := node["const"]; ok && !jsonEqual(want, value) {
+		v.fail(path, "must be %s", encode(want))
+	}
+	if enum, ok := node["enum"].([]any); ok {
+		found := false
+		for _, want := range enum {
+			if jsonEqual(want, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(enum))
+			for i, want := range enum {
+				options[i] = encode(want)
+			}
+			v.fail(path, "must be one of %s, got %s", strings.Join(options, ", "), encode(value))
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if pattern, ok := node["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				v.fail(path, "invalid schema pattern %q: %v", pattern, err)
+			} else if !re.MatchString(value) {
+				if pattern == `\S` {
+					v.fail(path, "must not be blank")
+				} else {
+					v.fail(path, "must match %q", pattern)
+				}
+			}
+		}
+	case json.Number:
+		n, _ := value.Float64()
+		if min, ok := node["minimum"].(float64); ok && n < min {
+			v.fail(path, "must be >= %g, got %s", min, value)
+		}
+		if min, ok := node["exclusiveMinimum"].(float64); ok && n <= min {
+			v.fail(path, "must be > %g, got %s", min, value)
+		}
+	case []any:
+		if min, ok := node["minItems"].(float64); ok && float64(len(value)) < min {
+			v.fail(path, "must have at least %g item(s), got %d", min, len(value))
+		}
+		if items, ok := node["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.check(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case map[string]any:
+		v.checkObject(node, value, path)
+	}
+}
+
+func (v *validator) checkObject(node map[string]any, value map[string]any, path string) {
+	if required, ok := node["required"].([]any); ok {
+		for _, name := range required {
+			if key, _ := name.(string); key != "" {
+				if _, present := value[key]; !present {
+					v.fail(childPath(path, key), "is required")
+				}
+			}
+		}
+	}
+	properties, _ := node["properties"].(map[string]any)
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if prop, ok := properties[key].(map[string]any); ok {
+			v.check(prop, value[key], childPath(path, key))
+			continue
+		}
+		switch extra := node["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(childPath(path, key), "is not allowed")
+			}
+		case map[string]any:
+			v.check(extra, value[key], childPath(path, key))
+		}
+	}
+}
+
+// resolve looks up a local reference such as #/definitions/item.
+func (v *validator) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	var node any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved schema reference %q", ref)
+		}
+		node = obj[part]
+	}
+	target, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolved schema reference %q", ref)
+	}
+	return target, nil
+}
+
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeAllowed(types []string, actual string) bool {
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names value's JSON Schema type; whole numbers are "integer".
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if n, err := value.Float64(); err == nil && n == math.Trunc(n) && !strings.ContainsAny(value.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares a schema value (numbers as float64) with a document
+// value (numbers as json.Number).
+func jsonEqual(want, got any) bool {
+	if n, ok := got.(json.Number); ok {
+		f, err := n.Float64()
+		w, isNum := want.(float64)
+		return err == nil && isNum && f == w
+	}
+	return encode(want) == encode(got)
+}
+
+func encode(value any) string {
+	if n, ok := value.(json.Number); ok {
+		return n.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func childPath(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return path + "." + key
+}
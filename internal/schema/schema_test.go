@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"okrchestra/internal/guardrails"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/planner"
+)
+
+func TestValidatePlan(t *testing.T) {
+	plan := planner.Plan{ID: "plan-1", AsOf: "2025-01-15", Items: []planner.PlanItem{{
+		ID: "ITEM-1", ObjectiveID: "OBJ-1", KRID: "KR-1", Task: "do it", AgentRole: "engineer",
+		ExpectedMetricChange: planner.ExpectedMetricChange{MetricKey: "ci.pass_rate", Direction: "increase", Target: 1},
+	}}}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := Validate(TypePlan, data)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("valid plan: %v (err %v)", errs, err)
+	}
+
+	bad := `{"id": "p", "as_of": " ", "extra": 1, "items": [
+		{"objective_id": "OBJ-1", "kr_id": "KR-1", "task": "t", "agent_role": "a",
+		 "expected_metric_change": {"metric_key": "m", "direction": "sideways"}},
+		{"objective_id": "OBJ-1", "kr_id": 7, "agent_role": "a", "expected_metric_change": {"metric_key": "m", "direction": "increase"}}
+	], "success_criteria": {"min_delta": 0, "within_days": 1.5}}`
+	errs, err = Validate(TypePlan, []byte(bad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		"$.as_of: must not be blank",
+		"$.extra: is not allowed",
+		`$.items[0].expected_metric_change.direction: must be one of "increase", "decrease", got "sideways"`,
+		"$.items[1].task: is required",
+		"$.items[1].kr_id: must be string, got integer",
+		"$.success_criteria.min_delta: must be > 0, got 0",
+		"$.success_criteria.within_days: must be integer, got number",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Validate(TypePlan, []byte("{")); err == nil {
+		t.Fatal("invalid JSON accepted")
+	}
+	if _, err := Export("nope"); err == nil {
+		t.Fatal("unknown type exported")
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	valid := `{"schema_version":"1.0","summary":"done","proposed_changes":[],"kr_targets":["KR-1"],"kr_impact_claim":"None"}`
+	if errs, err := Validate(TypeResult, []byte(valid)); err != nil || len(errs) != 0 {
+		t.Fatalf("valid result: %v (err %v)", errs, err)
+	}
+	errs, err := Validate(TypeResult, []byte(`{"schema_version":"2.0","summary":"","proposed_changes":null,"kr_impact_claim":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 4 || errs[0].Path != "$.kr_targets" {
+		t.Fatalf("errors = %v", errs)
+	}
+}
+
+func TestValidateSnapshotAndScore(t *testing.T) {
+	snapshot := metrics.Snapshot{SchemaVersion: metrics.SnapshotSchemaVersion, AsOf: "2025-01-15", Points: []metrics.MetricPoint{
+		{Key: "ci.pass_rate", Value: 0.9, Timestamp: "2025-01-15T00:00:00Z", Source: "ci", Dimensions: []metrics.Dimension{{Key: "repo", Value: "a"}}},
+	}}
+	data, _ := json.Marshal(snapshot)
+	if errs, err := Validate(TypeSnapshot, data); err != nil || len(errs) != 0 {
+		t.Fatalf("valid snapshot: %v (err %v)", errs, err)
+	}
+
+	current := 5.0
+	report := metrics.KRScoreReport{SchemaVersion: metrics.KRScoreSchemaVersion, AsOf: "2025-01-15", Results: []metrics.KRScore{
+		{ObjectiveID: "OBJ-1", KRID: "KR-1", MetricKey: "m", Target: 10, Current: &current, PercentToTarget: 50},
+	}, Rollups: []metrics.AlignmentRollup{{ObjectiveID: "OBJ-1"}}}
+	data, _ = json.Marshal(report)
+	if errs, err := Validate(TypeScore, data); err != nil || len(errs) != 0 {
+		t.Fatalf("valid score report: %v (err %v)", errs, err)
+	}
+}
+
+// TestSchemasCoverStructs keeps the schemas in step with the Go types that
+// read and write the files: every JSON field must be described.
+func TestSchemasCoverStructs(t *testing.T) {
+	cases := []struct {
+		typ  string
+		path string
+		v    any
+	}{
+		{TypePlan, "", planner.Plan{}},
+		{TypePlan, "definitions.item", planner.PlanItem{}},
+		{TypePlan, "definitions.expectedMetricChange", planner.ExpectedMetricChange{}},
+		{TypePlan, "properties.success_criteria", planner.SuccessCriteria{}},
+		{TypeResult, "", guardrails.ResultSchema{}},
+		{TypeSnapshot, "", metrics.Snapshot{}},
+		{TypeSnapshot, "definitions.point", metrics.MetricPoint{}},
+		{TypeScore, "", metrics.KRScoreReport{}},
+		{TypeScore, "definitions.score", metrics.KRScore{}},
+		{TypeScore, "definitions.annotation", metrics.Annotation{}},
+		{TypeScore, "definitions.rollup", metrics.AlignmentRollup{}},
+	}
+	for _, tc := range cases {
+		raw, err := Export(tc.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var node map[string]any
+		if err := json.Unmarshal(raw, &node); err != nil {
+			t.Fatalf("%s schema: %v", tc.typ, err)
+		}
+		if tc.path != "" {
+			for _, part := range strings.Split(tc.path, ".") {
+				node, _ = node[part].(map[string]any)
+			}
+		}
+		properties, _ := node["properties"].(map[string]any)
+		typ := reflect.TypeOf(tc.v)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if _, ok := properties[name]; !ok {
+				t.Errorf("%s schema %s does not describe %s.%s (%q)", tc.typ, tc.path, typ.Name(), typ.Field(i).Name, name)
+			}
+		}
+	}
+}
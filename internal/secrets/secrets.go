@@ -0,0 +1,284 @@
+// Package secrets resolves {{secret:name}} references at execution time so
+// raw secret values are never stored in job payloads, results, or audit
+// events.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv overrides the secrets file location.
+const FileEnv = "OKRCHESTRA_SECRETS_FILE"
+
+// EnvPrefix names environment variables that supply secrets:
+// OKRCHESTRA_SECRET_GITHUB_TOKEN provides {{secret:github_token}}.
+const EnvPrefix = "OKRCHESTRA_SECRET_"
+
+var refPattern = regexp.MustCompile(`\{\{\s*secret:([A-Za-z0-9_.-]+)\s*\}\}`)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Resolver looks up secret values by name.
+type Resolver interface {
+	Lookup(name string) (string, error)
+}
+
+// Store reads secrets from the environment, then from a YAML file of
+// name: value pairs kept outside the workspace.
+type Store struct {
+	Path string
+}
+
+// DefaultPath is the secrets file used when FileEnv is unset.
+func DefaultPath() (string, error) {
+	if p := strings.TrimSpace(os.Getenv(FileEnv)); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve config dir: %w", err)
+	}
+	return filepath.Join(dir, "okrchestra", "secrets.yml"), nil
+}
+
+// Default returns the store at DefaultPath.
+func Default() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Path: path}, nil
+}
+
+// Lookup returns the named secret.
+func (s *Store) Lookup(name string) (string, error) {
+	if v, ok := os.LookupEnv(EnvPrefix + envName(name)); ok {
+		return v, nil
+	}
+	values, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := values[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found (set %s%s or run `okrchestra secrets set %s`)", name, EnvPrefix, envName(name), name)
+	}
+	return v, nil
+}
+
+// Names returns the secret names in the file, sorted.
+func (s *Store) Names() ([]string, error) {
+	values, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set stores a secret in the file, creating it with owner-only permissions.
+func (s *Store) Set(name, value string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (use letters, digits, '_', '.', '-')", name)
+	}
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return s.save(values)
+}
+
+// Delete removes a secret from the file.
+func (s *Store) Delete(name string) error {
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return fmt.Errorf("secret %q not found", name)
+	}
+	delete(values, name)
+	return s.save(values)
+}
+
+func (s *Store) load() (map[string]string, error) {
+	values := map[string]string{}
+	info, err := os.Stat(s.Path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat secrets file: %w", err)
+	}
+	// Like ssh keys, a secrets file readable by others is refused.
+	if info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("secrets file %s must not be accessible by group or others (chmod 600)", s.Path)
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parse secrets file: %w", err)
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	return values, nil
+}
+
+func (s *Store) save(values map[string]string) error {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("marshal secrets: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("ensure secrets dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("create temp secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close secrets file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("replace secrets file: %w", err)
+	}
+	return nil
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// Refs returns the secret names referenced in s, in order of appearance.
+func Refs(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range refPattern.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Resolved is a payload with its secret references expanded.
+type Resolved struct {
+	PayloadJSON string
+	// values maps each resolved secret value to its name for Redact.
+	values map[string]string
+}
+
+// ResolvePayload expands references inside the JSON string values of
+// payloadJSON. Payloads without references are returned unchanged.
+func ResolvePayload(payloadJSON string, r Resolver) (*Resolved, error) {
+	out := &Resolved{PayloadJSON: payloadJSON, values: map[string]string{}}
+	if len(Refs(payloadJSON)) == 0 {
+		return out, nil
+	}
+	if r == nil {
+		return nil, fmt.Errorf("payload references secrets but no secrets store is configured")
+	}
+	var payload any
+	if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil {
+		return nil, fmt.Errorf("parse payload: %w", err)
+	}
+	var resolveErr error
+	payload = walkStrings(payload, func(s string) string {
+		return refPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			name := refPattern.FindStringSubmatch(ref)[1]
+			value, err := r.Lookup(name)
+			if err != nil {
+				if resolveErr == nil {
+					resolveErr = err
+				}
+				return ref
+			}
+			if value != "" {
+				out.values[value] = name
+			}
+			return value
+		})
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	out.PayloadJSON = string(data)
+	return out, nil
+}
+
+// Redact replaces every resolved secret value in s with its reference.
+func (r *Resolved) Redact(s string) string {
+	if r == nil || len(r.values) == 0 {
+		return s
+	}
+	// Longest values first so a secret containing another is fully masked.
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, "{{secret:"+r.values[v]+"}}")
+		// Values also appear JSON-escaped inside marshaled results.
+		if escaped, err := json.Marshal(v); err == nil {
+			inner := string(escaped[1 : len(escaped)-1])
+			if inner != v {
+				s = strings.ReplaceAll(s, inner, "{{secret:"+r.values[v]+"}}")
+			}
+		}
+	}
+	return s
+}
+
+// RedactValue marshals v to JSON with secret values redacted.
+func (r *Resolved) RedactValue(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(r.Redact(string(data))), nil
+}
+
+func walkStrings(v any, fn func(string) string) any {
+	switch t := v.(type) {
+	case string:
+		return fn(t)
+	case []any:
+		for i := range t {
+			t[i] = walkStrings(t[i], fn)
+		}
+		return t
+	case map[string]any:
+		for k, val := range t {
+			t[k] = walkStrings(val, fn)
+		}
+		return t
+	}
+	return v
+}
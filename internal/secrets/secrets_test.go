@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type mapResolver map[string]string
+
+func (m mapResolver) Lookup(name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return v, nil
+}
+
+func TestResolvePayloadAndRedact(t *testing.T) {
+	payload := `{"repo":"acme/app","headers":["Authorization: token {{secret:github_token}}"],"nested":{"key":"{{ secret:api.key }}"}}`
+	if got := strings.Join(Refs(payload), ","); got != "github_token,api.key" {
+		t.Fatalf("Refs = %s", got)
+	}
+
+	resolved, err := ResolvePayload(payload, mapResolver{"github_token": "ghp_abc<123>", "api.key": "k-9"})
+	if err != nil {
+		t.Fatalf("ResolvePayload: %v", err)
+	}
+	if !strings.Contains(resolved.PayloadJSON, "token ghp_abc\\u003c123\\u003e") || !strings.Contains(resolved.PayloadJSON, `"key":"k-9"`) {
+		t.Fatalf("payload = %s", resolved.PayloadJSON)
+	}
+
+	result, err := resolved.RedactValue(map[string]any{"echo": "used ghp_abc<123> and k-9"})
+	if err != nil {
+		t.Fatalf("RedactValue: %v", err)
+	}
+	if strings.Contains(string(result), "ghp_abc") || strings.Contains(string(result), "k-9") {
+		t.Fatalf("secret leaked into result: %s", result)
+	}
+	if !strings.Contains(string(result), "{{secret:github_token}}") {
+		t.Fatalf("result should name the secret: %s", result)
+	}
+
+	if _, err := ResolvePayload(`{"t":"{{secret:missing}}"}`, mapResolver{}); err == nil {
+		t.Fatalf("expected error for missing secret")
+	}
+	plain, err := ResolvePayload(`{"adapter":"mock"}`, nil)
+	if err != nil || plain.PayloadJSON != `{"adapter":"mock"}` {
+		t.Fatalf("payload without refs should pass through: %v %v", plain, err)
+	}
+}
+
+func TestStoreFileAndEnv(t *testing.T) {
+	store := &Store{Path: filepath.Join(t.TempDir(), "okrchestra", "secrets.yml")}
+	if err := store.Set("github_token", "from-file"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	info, err := os.Stat(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("secrets file mode = %v", info.Mode().Perm())
+	}
+	if v, err := store.Lookup("github_token"); err != nil || v != "from-file" {
+		t.Fatalf("Lookup = %q, %v", v, err)
+	}
+
+	t.Setenv(EnvPrefix+"GITHUB_TOKEN", "from-env")
+	if v, _ := store.Lookup("github_token"); v != "from-env" {
+		t.Fatalf("env should override the file, got %q", v)
+	}
+
+	if err := os.Chmod(store.Path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Names(); err == nil {
+		t.Fatalf("world-readable secrets file should be refused")
+	}
+	if err := store.Set("bad name", "x"); err == nil {
+		t.Fatalf("expected invalid name error")
+	}
+}
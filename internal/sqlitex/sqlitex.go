@@ -0,0 +1,202 @@
+// Package sqlitex opens the workspace's SQLite databases with shared
+// settings and applies numbered schema migrations to them.
+package sqlitex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// BusyTimeout is how long a connection waits for another writer's lock
+// before failing with SQLITE_BUSY.
+const BusyTimeout = 5 * time.Second
+
+// Open opens the SQLite database at path in WAL mode, so readers do not
+// block the writer, with a busy timeout on every connection. Transactions
+// begin IMMEDIATE: a deferred transaction that reads and then writes fails
+// without waiting if another connection wrote in between.
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_txlock=immediate", path, BusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// journal_mode is applied when the first connection opens; surface a
+	// locked or unreadable file here rather than on the first query.
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Querier is implemented by *sql.Conn and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Migration is one numbered schema change. SQL runs first, then Apply when
+// set; both run in the transaction that records the version.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Apply   func(ctx context.Context, q Querier) error
+}
+
+// VersionTable records applied migrations, one row per version.
+const VersionTable = "schema_version"
+
+// Migrate applies the migrations newer than the database's schema version,
+// in order, each in its own write transaction. It fails if the database
+// was migrated by a newer binary than this one.
+func Migrate(db *sql.DB, migrations []Migration) error {
+	ctx := context.Background()
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version <= 0 || (i > 0 && migrations[i-1].Version == m.Version) {
+			return fmt.Errorf("migration %d (%s): versions must be positive and unique", m.Version, m.Name)
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+VersionTable+` (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create %s: %w", VersionTable, err)
+	}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	for _, m := range migrations {
+		if err := apply(ctx, conn, m); err != nil {
+			return err
+		}
+	}
+	current, err := Version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", current, latest)
+	}
+	return nil
+}
+
+// apply runs m unless the database is already at or past its version. The
+// version is read again under BEGIN IMMEDIATE so two processes opening the
+// same database do not both apply it.
+func apply(ctx context.Context, conn *sql.Conn, m Migration) (err error) {
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("migration %d (%s): begin: %w", m.Version, m.Name, err)
+	}
+	defer func() {
+		if err != nil {
+			_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	current, err := Version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if current >= m.Version {
+		_, err = conn.ExecContext(ctx, "COMMIT")
+		return err
+	}
+	if m.SQL != "" {
+		if _, err := conn.ExecContext(ctx, m.SQL); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	if m.Apply != nil {
+		if err := m.Apply(ctx, conn); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx,
+		`INSERT INTO `+VersionTable+` (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("migration %d (%s): record version: %w", m.Version, m.Name, err)
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return fmt.Errorf("migration %d (%s): commit: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+// Version returns the highest applied migration version, 0 for none.
+func Version(ctx context.Context, q Querier) (int, error) {
+	rows, err := q.QueryContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+VersionTable)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	defer rows.Close()
+	version := 0
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return 0, fmt.Errorf("read schema version: %w", err)
+		}
+	}
+	return version, rows.Err()
+}
+
+// Column is a column definition for AddMissingColumns.
+type Column struct {
+	Name string
+	Def  string
+}
+
+// AddMissingColumns adds the columns table lacks. Migrations use it for
+// tables that older binaries may already have created with some of them,
+// before schema versions were recorded.
+func AddMissingColumns(ctx context.Context, q Querier, table string, columns []Column) error {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("inspect %s: %w", table, err)
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	rows.Close()
+
+	for _, col := range columns {
+		if existing[strings.ToLower(col.Name)] {
+			continue
+		}
+		if _, err := q.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col.Name, col.Def)); err != nil {
+			return fmt.Errorf("add %s.%s: %w", table, col.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package sqlitex
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenEnablesWAL(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Fatalf("journal_mode = %s, want wal", mode)
+	}
+	var timeout int
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatal(err)
+	}
+	if timeout != int(BusyTimeout.Milliseconds()) {
+		t.Fatalf("busy_timeout = %d", timeout)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	applied := 0
+	migrations := []Migration{
+		{Version: 2, Name: "add b", Apply: func(ctx context.Context, q Querier) error {
+			applied++
+			return AddMissingColumns(ctx, q, "things", []Column{{Name: "a", Def: "TEXT"}, {Name: "b", Def: "TEXT"}})
+		}},
+		{Version: 1, Name: "create things", SQL: "CREATE TABLE things (id INTEGER PRIMARY KEY, a TEXT)"},
+	}
+	if err := Migrate(db, migrations); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := Migrate(db, migrations); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+	if applied != 1 {
+		t.Fatalf("version 2 applied %d times, want 1", applied)
+	}
+	if _, err := db.Exec("INSERT INTO things (a, b) VALUES ('x', 'y')"); err != nil {
+		t.Fatalf("insert into migrated table: %v", err)
+	}
+	if version, err := Version(context.Background(), db); err != nil || version != 2 {
+		t.Fatalf("Version = %d, %v", version, err)
+	}
+
+	// A failed migration leaves the version and schema unchanged
+	failing := append(migrations, Migration{Version: 3, Name: "broken", SQL: "CREATE TABLE other (id INTEGER); NOT SQL"})
+	if err := Migrate(db, failing); err == nil || !strings.Contains(err.Error(), "migration 3 (broken)") {
+		t.Fatalf("broken migration: got %v", err)
+	}
+	if version, _ := Version(context.Background(), db); version != 2 {
+		t.Fatalf("version after failed migration = %d, want 2", version)
+	}
+
+	// An older binary refuses a database migrated past what it knows
+	if err := Migrate(db, migrations[1:]); err == nil || !strings.Contains(err.Error(), "newer than this binary") {
+		t.Fatalf("older migrations: got %v", err)
+	}
+
+	if err := Migrate(db, []Migration{{Version: 1}, {Version: 1}}); err == nil {
+		t.Fatal("duplicate versions accepted")
+	}
+}
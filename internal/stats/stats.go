@@ -0,0 +1,258 @@
+// Package stats keeps local-only usage counters in the workspace DB so
+// workspace admins can see which commands and features their team uses.
+// Nothing is ever transmitted; set OKRCHESTRA_NO_STATS=1 to stop recording.
+package stats
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DisableEnv turns off recording when set to a non-empty value other than "0".
+const DisableEnv = "OKRCHESTRA_NO_STATS"
+
+// Invocation is one CLI command run. Only command and flag names are kept,
+// never flag values or positional arguments.
+type Invocation struct {
+	Command  string
+	Features []string
+	User     string
+	Failed   bool
+	At       time.Time
+}
+
+// CommandUsage aggregates invocations of one command by one user.
+type CommandUsage struct {
+	Command     string    `json:"command"`
+	User        string    `json:"user"`
+	Invocations int       `json:"invocations"`
+	Failures    int       `json:"failures"`
+	FirstUsed   time.Time `json:"first_used"`
+	LastUsed    time.Time `json:"last_used"`
+}
+
+// FeatureUsage counts how often a flag was passed to a command.
+type FeatureUsage struct {
+	Command  string    `json:"command"`
+	Feature  string    `json:"feature"`
+	Uses     int       `json:"uses"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Summary is the recorded usage in a workspace DB.
+type Summary struct {
+	Commands []CommandUsage `json:"commands"`
+	Features []FeatureUsage `json:"features"`
+}
+
+// Disabled reports whether recording is turned off by DisableEnv.
+func Disabled() bool {
+	v := strings.TrimSpace(os.Getenv(DisableEnv))
+	return v != "" && v != "0"
+}
+
+// groupCommands take a subcommand as their first argument.
+var groupCommands = map[string]bool{
+	"agent":     true,
+	"artifacts": true,
+	"cost":      true,
+	"cycle":     true,
+	"daemon":    true,
+	"kr":        true,
+	"metrics":   true,
+	"migrate":   true,
+	"okr":       true,
+	"plan":      true,
+	"runs":      true,
+	"secrets":   true,
+}
+
+// ParseInvocation derives the command ("plan generate") and the flag names
+// used from CLI args (without the program name or --workspace).
+func ParseInvocation(args []string) Invocation {
+	inv := Invocation{}
+	if len(args) == 0 {
+		return inv
+	}
+	inv.Command = args[0]
+	rest := args[1:]
+	if groupCommands[args[0]] && len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		inv.Command += " " + rest[0]
+		rest = rest[1:]
+	}
+	seen := map[string]bool{}
+	for _, arg := range rest {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		inv.Features = append(inv.Features, name)
+	}
+	sort.Strings(inv.Features)
+	return inv
+}
+
+// Record adds an invocation to the counters in dbPath.
+func Record(dbPath string, inv Invocation) error {
+	if strings.TrimSpace(inv.Command) == "" {
+		return nil
+	}
+	if inv.At.IsZero() {
+		inv.At = time.Now()
+	}
+	if inv.User == "" {
+		inv.User = "unknown"
+	}
+	at := inv.At.UTC().Format(time.RFC3339)
+	failures := 0
+	if inv.Failed {
+		failures = 1
+	}
+
+	db, err := open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin stats update: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`
+		INSERT INTO usage_commands (command, user, invocations, failures, first_used, last_used)
+		VALUES (?, ?, 1, ?, ?, ?)
+		ON CONFLICT(command, user) DO UPDATE SET
+			invocations = invocations + 1,
+			failures = failures + excluded.failures,
+			last_used = excluded.last_used
+	`, inv.Command, inv.User, failures, at, at); err != nil {
+		return fmt.Errorf("record command usage: %w", err)
+	}
+	for _, feature := range inv.Features {
+		if _, err := tx.Exec(`
+			INSERT INTO usage_features (command, feature, uses, last_used)
+			VALUES (?, ?, 1, ?)
+			ON CONFLICT(command, feature) DO UPDATE SET
+				uses = uses + 1,
+				last_used = excluded.last_used
+		`, inv.Command, feature, at); err != nil {
+			return fmt.Errorf("record feature usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit stats update: %w", err)
+	}
+	return nil
+}
+
+// Load returns recorded usage, most used first.
+func Load(dbPath string) (*Summary, error) {
+	db, err := open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	summary := &Summary{}
+	rows, err := db.Query(`
+		SELECT command, user, invocations, failures, first_used, last_used
+		FROM usage_commands
+		ORDER BY invocations DESC, command, user
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query command usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c CommandUsage
+		var first, last string
+		if err := rows.Scan(&c.Command, &c.User, &c.Invocations, &c.Failures, &first, &last); err != nil {
+			return nil, fmt.Errorf("scan command usage: %w", err)
+		}
+		c.FirstUsed, _ = time.Parse(time.RFC3339, first)
+		c.LastUsed, _ = time.Parse(time.RFC3339, last)
+		summary.Commands = append(summary.Commands, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read command usage: %w", err)
+	}
+
+	featureRows, err := db.Query(`
+		SELECT command, feature, uses, last_used
+		FROM usage_features
+		ORDER BY uses DESC, command, feature
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query feature usage: %w", err)
+	}
+	defer featureRows.Close()
+	for featureRows.Next() {
+		var f FeatureUsage
+		var last string
+		if err := featureRows.Scan(&f.Command, &f.Feature, &f.Uses, &last); err != nil {
+			return nil, fmt.Errorf("scan feature usage: %w", err)
+		}
+		f.LastUsed, _ = time.Parse(time.RFC3339, last)
+		summary.Features = append(summary.Features, f)
+	}
+	if err := featureRows.Err(); err != nil {
+		return nil, fmt.Errorf("read feature usage: %w", err)
+	}
+	return summary, nil
+}
+
+func open(dbPath string) (*sql.DB, error) {
+	if strings.TrimSpace(dbPath) == "" {
+		return nil, fmt.Errorf("stats db path is required")
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open stats db: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS usage_commands (
+			command TEXT NOT NULL,
+			user TEXT NOT NULL,
+			invocations INTEGER NOT NULL,
+			failures INTEGER NOT NULL,
+			first_used TEXT NOT NULL,
+			last_used TEXT NOT NULL,
+			PRIMARY KEY (command, user)
+		);
+		CREATE TABLE IF NOT EXISTS usage_features (
+			command TEXT NOT NULL,
+			feature TEXT NOT NULL,
+			uses INTEGER NOT NULL,
+			last_used TEXT NOT NULL,
+			PRIMARY KEY (command, feature)
+		);
+	`); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create stats schema: %w", err)
+	}
+	return db, nil
+}
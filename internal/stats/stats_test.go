@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInvocation(t *testing.T) {
+	cases := []struct {
+		args     []string
+		command  string
+		features []string
+	}{
+		{[]string{"plan", "generate", "--portfolio", "--items=4", "--as-of", "2025-01-01"}, "plan generate", []string{"as-of", "items", "portfolio"}},
+		{[]string{"runs", "review", "RUN", "1", "--approve", "--comment", "secret text"}, "runs review", []string{"approve", "comment"}},
+		{[]string{"init", "--template", "minimal"}, "init", []string{"template"}},
+		{[]string{"stats"}, "stats", nil},
+	}
+	for _, tc := range cases {
+		inv := ParseInvocation(tc.args)
+		if inv.Command != tc.command || !reflect.DeepEqual(inv.Features, tc.features) {
+			t.Errorf("ParseInvocation(%v) = %q %v, want %q %v", tc.args, inv.Command, inv.Features, tc.command, tc.features)
+		}
+	}
+}
+
+func TestRecordAndLoad(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.sqlite")
+	day := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	record := func(user string, failed bool, args ...string) {
+		inv := ParseInvocation(args)
+		inv.User = user
+		inv.Failed = failed
+		inv.At = day
+		if err := Record(dbPath, inv); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		day = day.Add(time.Hour)
+	}
+	record("ana", false, "plan", "run", "--adapter", "mock")
+	record("ana", true, "plan", "run", "--adapter", "codex")
+	record("bo", false, "plan", "run")
+	record("bo", false, "kr", "score", "--badges")
+
+	summary, err := Load(dbPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(summary.Commands) != 3 {
+		t.Fatalf("commands = %+v", summary.Commands)
+	}
+	first := summary.Commands[0]
+	if first.Command != "plan run" || first.User != "ana" || first.Invocations != 2 || first.Failures != 1 {
+		t.Fatalf("top command = %+v", first)
+	}
+	if !first.FirstUsed.Equal(time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)) || !first.LastUsed.Equal(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first/last used = %v / %v", first.FirstUsed, first.LastUsed)
+	}
+	if len(summary.Features) != 2 || summary.Features[0].Feature != "adapter" || summary.Features[0].Uses != 2 {
+		t.Fatalf("features = %+v", summary.Features)
+	}
+}
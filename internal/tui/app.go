@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultRefresh is how often Run reloads the workspace.
+const DefaultRefresh = 2 * time.Second
+
+// Run shows the UI on the terminal of in and out until the user quits or
+// ctx is done, reloading src every refresh.
+func Run(ctx context.Context, src Source, in, out *os.File, refresh time.Duration) error {
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+	// Alternate screen, hidden cursor; undone on exit.
+	io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	readErr := make(chan error, 1)
+	go readKeys(in, keys, readErr)
+
+	model := NewModel(src.Load(time.Now()))
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	for {
+		if width, height, err := terminalSize(int(out.Fd())); err == nil && width > 0 && height > 0 {
+			model.Width, model.Height = width, height
+		}
+		draw(out, model)
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case <-ticker.C:
+			model.SetData(src.Load(time.Now()))
+		case key := <-keys:
+			if key == KeyRefresh {
+				model.SetData(src.Load(time.Now()))
+				continue
+			}
+			if model.Key(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// RenderOnce renders the OKR, job, and run screens to w in full, for
+// terminals Run does not support and for scripts.
+func RenderOnce(w io.Writer, src Source, width int) error {
+	model := NewModel(src.Load(time.Now()))
+	model.Width = width
+	for i, screen := range []string{"1", "2", "3"} {
+		model.Key(screen)
+		// Header, blank, column header, rows, blank, footer.
+		model.Height = model.length() + 5
+		frame := model.Render()
+		if i > 0 {
+			frame = "\n" + frame
+		}
+		// Highlighting is for the interactive UI only.
+		frame = strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(frame)
+		lines := strings.Split(frame, "\n")
+		for i := range lines {
+			lines[i] = strings.TrimRight(lines[i], " ")
+		}
+		frame = strings.Join(lines, "\n")
+		if _, err := io.WriteString(w, strings.TrimRight(frame, "\n ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func draw(out io.Writer, model *Model) {
+	frame := strings.ReplaceAll(model.Render(), "\n", "\x1b[K\r\n")
+	io.WriteString(out, "\x1b[H"+frame+"\x1b[K\x1b[J")
+}
+
+// readKeys sends the keys read from in until it fails.
+func readKeys(in io.Reader, keys chan<- string, errs chan<- error) {
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+		if err != nil {
+			errs <- err
+			return
+		}
+	}
+}
+
+// parseKeys maps the bytes of one terminal read to keys; unknown input is
+// dropped.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			if len(b) == 1 {
+				keys = append(keys, KeyBack)
+				return keys
+			}
+			seq := string(b)
+			matched := false
+			for prefix, key := range escapeKeys {
+				if strings.HasPrefix(seq, prefix) {
+					keys = append(keys, key)
+					b = b[len(prefix):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// Skip an unknown sequence up to its final byte.
+				i := 1
+				for i < len(b) && (b[i] == '[' || b[i] == 'O' || (b[i] >= '0' && b[i] <= '9') || b[i] == ';') {
+					i++
+				}
+				if i < len(b) {
+					i++
+				}
+				b = b[i:]
+			}
+			continue
+		}
+		if key, ok := byteKeys[b[0]]; ok {
+			keys = append(keys, key)
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+var escapeKeys = map[string]string{
+	"\x1b[A":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOA":  KeyUp,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+	"\x1b[H":  KeyTop,
+	"\x1b[F":  KeyBottom,
+	"\x1b[D":  KeyBack,
+	"\x1b[C":  KeyEnter,
+}
+
+var byteKeys = map[byte]string{
+	'q':  KeyQuit,
+	3:    KeyQuit, // Ctrl-C
+	'\r': KeyEnter,
+	'\n': KeyEnter,
+	'\t': KeyTab,
+	127:  KeyBack,
+	'h':  KeyBack,
+	'l':  KeyEnter,
+	'k':  KeyUp,
+	'j':  KeyDown,
+	'g':  KeyTop,
+	'G':  KeyBottom,
+	' ':  KeyPageDown,
+	'b':  KeyPageUp,
+	'r':  KeyRefresh,
+	'1':  "1",
+	'2':  "2",
+	'3':  "3",
+}
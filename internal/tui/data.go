@@ -0,0 +1,219 @@
+// Package tui is the `okrchestra ui` terminal browser: KRs scored against
+// the latest snapshot, daemon jobs, recent plan runs, and their agent
+// transcripts.
+package tui
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"okrchestra/internal/daemon"
+	"okrchestra/internal/metrics"
+	"okrchestra/internal/okrstore"
+	"okrchestra/internal/planner"
+	"okrchestra/internal/workspace"
+)
+
+// Default list sizes of a Source.
+const (
+	DefaultRunLimit = 50
+	DefaultJobLimit = 20
+)
+
+// Data is one load of everything the UI shows.
+type Data struct {
+	LoadedAt time.Time
+	// AsOf is the as-of date of the snapshot the scores come from.
+	AsOf   string
+	Scores []metrics.KRScore
+	// Jobs are the running jobs, then the queued ones in claim order, then
+	// the most recently finished.
+	Jobs []daemon.Job
+	// Runs are the newest plan runs first.
+	Runs []PlanRun
+	// Problems are parts that failed to load; the rest is still shown.
+	Problems []string
+}
+
+// PlanRun is a plan run as recorded in its run.json.
+type PlanRun struct {
+	Dir   string
+	State planner.RunState
+}
+
+// Counts returns how many of the run's items are in each status.
+func (r PlanRun) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, item := range r.State.Items {
+		counts[item.Status]++
+	}
+	return counts
+}
+
+// Source loads Data from a workspace.
+type Source struct {
+	Workspace    *workspace.Workspace
+	OKRsDir      string
+	MetricsDir   string
+	ArtifactsDir string
+	RunLimit     int
+	JobLimit     int
+}
+
+// Load reads the workspace. Failures are recorded in Data.Problems rather
+// than returned, so a workspace without a daemon or snapshots still shows.
+func (s Source) Load(now time.Time) *Data {
+	data := &Data{LoadedAt: now}
+	if err := s.loadScores(data); err != nil {
+		data.Problems = append(data.Problems, "scores: "+err.Error())
+	}
+	if err := s.loadJobs(data); err != nil {
+		data.Problems = append(data.Problems, "jobs: "+err.Error())
+	}
+	if err := s.loadRuns(data); err != nil {
+		data.Problems = append(data.Problems, "runs: "+err.Error())
+	}
+	return data
+}
+
+func (s Source) loadScores(data *Data) error {
+	store, err := okrstore.LoadFromDirCached(s.OKRsDir, filepath.Join(s.ArtifactsDir, "cache"))
+	if err != nil {
+		return err
+	}
+	path, err := metrics.LatestSnapshotPath(filepath.Join(s.MetricsDir, "snapshots"))
+	if err != nil {
+		return fmt.Errorf("%w; run kr measure", err)
+	}
+	snapshot, err := metrics.LoadSnapshot(path)
+	if err != nil {
+		return err
+	}
+	report, err := metrics.ScoreKRs(store, snapshot, path)
+	if err != nil {
+		return err
+	}
+	if s.Workspace != nil {
+		policy, err := metrics.LoadFreshnessPolicy(s.Workspace.Root, s.OKRsDir)
+		if err != nil {
+			return err
+		}
+		if err := metrics.ApplyFreshness(report, store, snapshot, policy); err != nil {
+			return err
+		}
+	}
+	data.AsOf = report.AsOf
+	data.Scores = report.Results
+	return nil
+}
+
+func (s Source) loadJobs(data *Data) error {
+	if s.Workspace == nil {
+		return nil
+	}
+	// The UI only reads; without a daemon store there are no jobs.
+	if _, err := os.Stat(s.Workspace.StateDBPath); os.IsNotExist(err) {
+		return nil
+	}
+	store, err := daemon.Open(s.Workspace.StateDBPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	limit := s.JobLimit
+	if limit <= 0 {
+		limit = DefaultJobLimit
+	}
+	running, err := store.ListRunning()
+	if err != nil {
+		return err
+	}
+	queued, err := store.ListQueued(limit)
+	if err != nil {
+		return err
+	}
+	finished, err := store.ListRecentCompleted(limit)
+	if err != nil {
+		return err
+	}
+	data.Jobs = append(append(append(data.Jobs, running...), queued...), finished...)
+	return nil
+}
+
+func (s Source) loadRuns(data *Data) error {
+	runsDir := filepath.Join(s.ArtifactsDir, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	// Run IDs are UTC timestamps, so the newest sort last.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	limit := s.RunLimit
+	if limit <= 0 {
+		limit = DefaultRunLimit
+	}
+	for _, name := range names {
+		if len(data.Runs) == limit {
+			break
+		}
+		dir := filepath.Join(runsDir, name)
+		state, err := planner.LoadRunState(dir)
+		if err != nil {
+			// Runs from before run.json, and dry runs, have nothing to show.
+			continue
+		}
+		data.Runs = append(data.Runs, PlanRun{Dir: dir, State: *state})
+	}
+	return nil
+}
+
+// Transcript returns the lines of an item's transcript.log, or of its
+// transcript.log.gz once gc has compressed it. A missing transcript has no
+// lines.
+func Transcript(runDir, itemDir string) ([]string, error) {
+	path := filepath.Join(runDir, itemDir, "transcript.log")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = readGzip(path + ".gz")
+	}
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+func readGzip(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
@@ -0,0 +1,482 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Screen is one of the UI's screens.
+type Screen int
+
+const (
+	ScreenOKRs Screen = iota
+	ScreenJobs
+	ScreenRuns
+	// ScreenItems lists the items of the selected run.
+	ScreenItems
+	// ScreenTranscript shows the selected item's transcript.
+	ScreenTranscript
+)
+
+// Keys understood by Model.Key, besides the screen numbers "1" to "3".
+// KeyRefresh is left to the caller, which reloads the data.
+const (
+	KeyUp       = "up"
+	KeyDown     = "down"
+	KeyPageUp   = "pgup"
+	KeyPageDown = "pgdn"
+	KeyTop      = "top"
+	KeyBottom   = "bottom"
+	KeyEnter    = "enter"
+	KeyBack     = "back"
+	KeyTab      = "tab"
+	KeyRefresh  = "refresh"
+	KeyQuit     = "quit"
+)
+
+// Model is the UI state: the loaded data, the screen, and the selection on
+// each screen. It renders to a string of Width by Height cells.
+type Model struct {
+	Data   *Data
+	Width  int
+	Height int
+	// LoadTranscript reads an item's transcript; Transcript by default.
+	LoadTranscript func(runDir, itemDir string) ([]string, error)
+
+	screen     Screen
+	cursor     map[Screen]int
+	transcript []string
+	// follow keeps the transcript scrolled to its end as it grows.
+	follow  bool
+	scroll  int
+	message string
+}
+
+// NewModel returns a model on the OKR screen.
+func NewModel(data *Data) *Model {
+	return &Model{Data: data, Width: 100, Height: 30, LoadTranscript: Transcript, cursor: map[Screen]int{}}
+}
+
+// Screen returns the current screen.
+func (m *Model) Screen() Screen { return m.screen }
+
+// SetData replaces the loaded data, keeping selections in range, and
+// rereads the open transcript.
+func (m *Model) SetData(data *Data) {
+	m.Data = data
+	for screen := range m.cursor {
+		m.clampCursor(screen)
+	}
+	if m.screen == ScreenTranscript {
+		m.openTranscript()
+	}
+}
+
+// Key applies a key and reports whether the UI should quit.
+func (m *Model) Key(key string) bool {
+	m.message = ""
+	switch key {
+	case KeyQuit:
+		return true
+	case "1":
+		m.screen = ScreenOKRs
+	case "2":
+		m.screen = ScreenJobs
+	case "3":
+		m.screen = ScreenRuns
+	case KeyTab:
+		switch m.screen {
+		case ScreenOKRs:
+			m.screen = ScreenJobs
+		case ScreenJobs:
+			m.screen = ScreenRuns
+		default:
+			m.screen = ScreenOKRs
+		}
+	case KeyEnter:
+		m.enter()
+	case KeyBack:
+		switch m.screen {
+		case ScreenItems:
+			m.screen = ScreenRuns
+		case ScreenTranscript:
+			m.screen = ScreenItems
+		}
+	case KeyUp:
+		m.move(-1)
+	case KeyDown:
+		m.move(1)
+	case KeyPageUp:
+		m.move(-m.pageSize())
+	case KeyPageDown:
+		m.move(m.pageSize())
+	case KeyTop:
+		m.move(-m.length())
+	case KeyBottom:
+		m.move(m.length())
+	}
+	return false
+}
+
+func (m *Model) enter() {
+	switch m.screen {
+	case ScreenRuns:
+		if _, ok := m.selectedRun(); ok {
+			m.screen = ScreenItems
+			m.cursor[ScreenItems] = 0
+		}
+	case ScreenItems:
+		if _, _, ok := m.selectedItem(); ok {
+			m.screen = ScreenTranscript
+			m.follow = true
+			m.openTranscript()
+		}
+	}
+}
+
+func (m *Model) openTranscript() {
+	run, item, ok := m.selectedItem()
+	if !ok {
+		m.transcript = nil
+		return
+	}
+	lines, err := m.LoadTranscript(run.Dir, item)
+	if err != nil {
+		m.message = err.Error()
+	}
+	m.transcript = lines
+	if m.follow {
+		m.scroll = len(m.transcript)
+	}
+	m.clampCursor(ScreenTranscript)
+}
+
+func (m *Model) move(delta int) {
+	if m.screen == ScreenTranscript {
+		m.scroll += delta
+		m.clampCursor(ScreenTranscript)
+		m.follow = m.scroll >= m.maxScroll()
+		return
+	}
+	m.cursor[m.screen] += delta
+	m.clampCursor(m.screen)
+}
+
+func (m *Model) clampCursor(screen Screen) {
+	if screen == ScreenTranscript {
+		m.scroll = clamp(m.scroll, 0, m.maxScroll())
+		return
+	}
+	m.cursor[screen] = clamp(m.cursor[screen], 0, m.lengthOf(screen)-1)
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+func (m *Model) length() int {
+	if m.screen == ScreenTranscript {
+		return len(m.transcript)
+	}
+	return m.lengthOf(m.screen)
+}
+
+func (m *Model) lengthOf(screen Screen) int {
+	if m.Data == nil {
+		return 0
+	}
+	switch screen {
+	case ScreenOKRs:
+		return len(m.Data.Scores)
+	case ScreenJobs:
+		return len(m.Data.Jobs)
+	case ScreenRuns:
+		return len(m.Data.Runs)
+	case ScreenItems:
+		if run, ok := m.selectedRun(); ok {
+			return len(run.State.Items)
+		}
+	}
+	return 0
+}
+
+// bodyHeight is the rows between the header and the footer.
+func (m *Model) bodyHeight() int {
+	if h := m.Height - 4; h > 1 {
+		return h
+	}
+	return 1
+}
+
+func (m *Model) pageSize() int {
+	return m.bodyHeight() - 1
+}
+
+func (m *Model) maxScroll() int {
+	if n := len(m.transcript) - m.pageSize(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (m *Model) selectedRun() (PlanRun, bool) {
+	if m.Data == nil {
+		return PlanRun{}, false
+	}
+	i := m.cursor[ScreenRuns]
+	if i < 0 || i >= len(m.Data.Runs) {
+		return PlanRun{}, false
+	}
+	return m.Data.Runs[i], true
+}
+
+func (m *Model) selectedItem() (PlanRun, string, bool) {
+	run, ok := m.selectedRun()
+	if !ok {
+		return PlanRun{}, "", false
+	}
+	i := m.cursor[ScreenItems]
+	if i < 0 || i >= len(run.State.Items) {
+		return PlanRun{}, "", false
+	}
+	return run, run.State.Items[i].ItemDir, true
+}
+
+// Render draws the screen as Height lines of at most Width cells.
+func (m *Model) Render() string {
+	lines := []string{m.header(), ""}
+	var body []string
+	selected := -1
+	switch m.screen {
+	case ScreenOKRs:
+		body, selected = m.okrLines()
+	case ScreenJobs:
+		body, selected = m.jobLines()
+	case ScreenRuns:
+		body, selected = m.runLines()
+	case ScreenItems:
+		body, selected = m.itemLines()
+	case ScreenTranscript:
+		body = m.transcriptLines()
+	}
+	// The first body line is a title or column header; the rows below it
+	// scroll to keep the selected one in view.
+	height := m.bodyHeight()
+	start := 0
+	if selected >= height-1 {
+		start = selected - (height - 2)
+	}
+	for i := 0; i < height; i++ {
+		row := start + i - 1
+		idx := row + 1
+		if i == 0 {
+			idx = 0
+		}
+		line := ""
+		if idx < len(body) {
+			line = truncate(body[idx], m.Width)
+		}
+		if i > 0 && row == selected {
+			line = "\x1b[7m" + pad(line, m.Width) + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", truncate(m.footer(), m.Width))
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) header() string {
+	tabs := []string{"1 OKRs", "2 Jobs", "3 Runs"}
+	active := int(m.screen)
+	if m.screen >= ScreenRuns {
+		active = int(ScreenRuns)
+	}
+	for i := range tabs {
+		if i == active {
+			tabs[i] = "[" + tabs[i] + "]"
+		} else {
+			tabs[i] = " " + tabs[i] + " "
+		}
+	}
+	line := "okrchestra ui  " + strings.Join(tabs, " ")
+	if m.Data != nil {
+		if m.Data.AsOf != "" {
+			line += "  scores as of " + m.Data.AsOf
+		}
+		line += "  updated " + m.Data.LoadedAt.Local().Format("15:04:05")
+	}
+	return truncate(line, m.Width)
+}
+
+func (m *Model) footer() string {
+	if m.message != "" {
+		return m.message
+	}
+	if m.Data != nil && len(m.Data.Problems) > 0 {
+		return "! " + strings.Join(m.Data.Problems, "; ")
+	}
+	switch m.screen {
+	case ScreenRuns:
+		return "↑/↓ select  enter items  tab/1-3 screens  r refresh  q quit"
+	case ScreenItems:
+		return "↑/↓ select  enter transcript  esc back  r refresh  q quit"
+	case ScreenTranscript:
+		return "↑/↓ pgup/pgdn g/G scroll (G follows)  esc back  q quit"
+	}
+	return "↑/↓ select  tab/1-3 screens  r refresh  q quit"
+}
+
+func (m *Model) okrLines() ([]string, int) {
+	lines := []string{fmt.Sprintf("%-8s %-10s %-32s %-26s %9s %9s %8s", "SCOPE", "KR", "DESCRIPTION", "METRIC", "CURRENT", "TARGET", "PROGRESS")}
+	if m.Data == nil {
+		return lines, -1
+	}
+	for _, kr := range m.Data.Scores {
+		current := "-"
+		if kr.Current != nil {
+			current = formatNumber(*kr.Current)
+		}
+		progress := fmt.Sprintf("%.0f%%", kr.PercentToTarget)
+		if kr.Current == nil || kr.BaselinePending {
+			progress = "-"
+		}
+		line := fmt.Sprintf("%-8s %-10s %-32s %-26s %9s %9s %8s", kr.Scope, truncate(kr.KRID, 10), truncate(kr.Description, 32),
+			truncate(kr.MetricKey, 26), current, formatNumber(kr.Target), progress)
+		if kr.Stale {
+			line += "  stale"
+		}
+		if kr.ProjectedStatus != "" {
+			line += "  " + kr.ProjectedStatus
+		}
+		lines = append(lines, line)
+	}
+	return lines, m.selection(ScreenOKRs)
+}
+
+func (m *Model) jobLines() ([]string, int) {
+	lines := []string{fmt.Sprintf("%-10s %-18s %-9s %-16s %-16s %s", "ID", "TYPE", "STATUS", "SCHEDULED", "FINISHED", "ATTEMPTS")}
+	if m.Data == nil {
+		return lines, -1
+	}
+	for _, job := range m.Data.Jobs {
+		finished := "-"
+		if job.FinishedAt != nil {
+			finished = formatTime(*job.FinishedAt)
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %-18s %-9s %-16s %-16s %d/%d", truncate(job.ID, 10), truncate(job.Type, 18), job.Status,
+			formatTime(job.ScheduledAt), finished, job.Attempts, job.MaxAttempts))
+	}
+	return lines, m.selection(ScreenJobs)
+}
+
+func (m *Model) runLines() ([]string, int) {
+	lines := []string{fmt.Sprintf("%-18s %-24s %-10s %-20s %s", "RUN", "PLAN", "ADAPTER", "STARTED", "ITEMS")}
+	if m.Data == nil {
+		return lines, -1
+	}
+	for _, run := range m.Data.Runs {
+		lines = append(lines, fmt.Sprintf("%-18s %-24s %-10s %-20s %s", run.State.RunID, truncate(run.State.PlanID, 24),
+			truncate(run.State.Adapter, 10), run.State.StartedAt, formatCounts(run.Counts())))
+	}
+	return lines, m.selection(ScreenRuns)
+}
+
+func (m *Model) itemLines() ([]string, int) {
+	run, ok := m.selectedRun()
+	if !ok {
+		return []string{"no run selected"}, -1
+	}
+	lines := []string{fmt.Sprintf("%-10s %-16s %-10s %-9s %-20s %s", "ITEM", "ID", "STATUS", "ATTEMPTS", "FINISHED", "DETAIL")}
+	for _, item := range run.State.Items {
+		detail := string(item.FailureClass)
+		if item.Error != "" {
+			detail = strings.TrimSpace(detail + " " + item.Error)
+		}
+		if item.Verification != nil {
+			detail = strings.TrimSpace(item.Verification.Status + " " + detail)
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %-16s %-10s %-9d %-20s %s", item.ItemDir, truncate(item.ItemID, 16), item.Status,
+			item.Attempts, item.FinishedAt, detail))
+	}
+	return lines, m.selection(ScreenItems)
+}
+
+func (m *Model) transcriptLines() []string {
+	run, item, _ := m.selectedItem()
+	title := fmt.Sprintf("%s/%s transcript (%d lines)", run.State.RunID, item, len(m.transcript))
+	if len(m.transcript) == 0 {
+		return []string{title, "(no transcript yet)"}
+	}
+	end := m.scroll + m.bodyHeight() - 1
+	if end > len(m.transcript) {
+		end = len(m.transcript)
+	}
+	return append([]string{title}, m.transcript[m.scroll:end]...)
+}
+
+func (m *Model) selection(screen Screen) int {
+	if m.lengthOf(screen) == 0 {
+		return -1
+	}
+	return m.cursor[screen]
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[k], k))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// truncate cuts s to width runes, marking the cut with "…".
+func truncate(s string, width int) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		// Drops control characters, including the escape sequences of
+		// colored agent output.
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
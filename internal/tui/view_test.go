@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"okrchestra/internal/planner"
+)
+
+func testData() *Data {
+	return &Data{
+		LoadedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Runs: []PlanRun{
+			{Dir: "/runs/20250601T110000Z", State: planner.RunState{RunID: "20250601T110000Z", PlanID: "plan-b", Items: []planner.RunItemState{
+				{ItemID: "write-docs", ItemDir: "item-0001", Status: planner.ItemSucceeded},
+				{ItemID: "fix-tests", ItemDir: "item-0002", Status: planner.ItemFailed, Error: "exit status 1"},
+			}}},
+			{Dir: "/runs/20250531T090000Z", State: planner.RunState{RunID: "20250531T090000Z", PlanID: "plan-a"}},
+		},
+	}
+}
+
+func TestModelNavigatesToTranscript(t *testing.T) {
+	m := NewModel(testData())
+	var loaded string
+	m.LoadTranscript = func(runDir, itemDir string) ([]string, error) {
+		loaded = filepath.Join(runDir, itemDir)
+		var lines []string
+		for i := 1; i <= 100; i++ {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+		return lines, nil
+	}
+
+	m.Key(KeyTab)
+	m.Key(KeyTab)
+	if m.Screen() != ScreenRuns {
+		t.Fatalf("screen after two tabs = %v", m.Screen())
+	}
+	m.Key(KeyEnter)
+	m.Key(KeyDown)
+	m.Key(KeyEnter)
+	if m.Screen() != ScreenTranscript || loaded != "/runs/20250601T110000Z/item-0002" {
+		t.Fatalf("screen = %v, loaded %q", m.Screen(), loaded)
+	}
+	// The transcript opens at its end.
+	frame := m.Render()
+	if !strings.Contains(frame, "line 100") || strings.Contains(frame, "line 1\n") {
+		t.Fatalf("transcript not at its end:\n%s", frame)
+	}
+	m.Key(KeyTop)
+	if frame := m.Render(); !strings.Contains(frame, "line 1\n") || strings.Contains(frame, "line 100") {
+		t.Fatalf("transcript not at its start:\n%s", frame)
+	}
+
+	m.Key(KeyBack)
+	if frame := m.Render(); m.Screen() != ScreenItems || !strings.Contains(frame, "exit status 1") {
+		t.Fatalf("items screen:\n%s", frame)
+	}
+	m.Key(KeyBack)
+	if m.Screen() != ScreenRuns {
+		t.Fatalf("screen after back = %v", m.Screen())
+	}
+	if !m.Key(KeyQuit) {
+		t.Fatalf("quit key did not quit")
+	}
+}
+
+func TestModelKeepsSelectionInRange(t *testing.T) {
+	m := NewModel(testData())
+	m.Key("3")
+	m.Key(KeyBottom)
+	data := testData()
+	data.Runs = data.Runs[:1]
+	m.SetData(data)
+	m.Key(KeyEnter)
+	if m.Screen() != ScreenItems {
+		t.Fatalf("enter on the clamped selection: screen = %v", m.Screen())
+	}
+	m.SetData(&Data{})
+	m.Key(KeyEnter)
+	if m.Screen() != ScreenItems {
+		t.Fatalf("screen = %v", m.Screen())
+	}
+	if frame := m.Render(); !strings.Contains(frame, "no run selected") {
+		t.Fatalf("empty items screen:\n%s", frame)
+	}
+}
+
+func TestRenderFitsScreen(t *testing.T) {
+	m := NewModel(testData())
+	m.Width, m.Height = 40, 10
+	m.Key("3")
+	lines := strings.Split(m.Render(), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("rendered %d lines, want 10", len(lines))
+	}
+	for _, line := range lines {
+		line = strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(line)
+		if n := len([]rune(line)); n > 40 {
+			t.Fatalf("line of %d cells: %q", n, line)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("jk\x1b[A\x1b[6~\x1b[1;5Cq\r\x1b"))
+	want := []string{KeyDown, KeyUp, KeyUp, KeyPageDown, KeyQuit, KeyEnter, KeyBack}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseKeys = %v, want %v", got, want)
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	runDir := t.TempDir()
+	itemDir := filepath.Join(runDir, "item-0001")
+	if err := os.MkdirAll(itemDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := Transcript(runDir, "item-0001")
+	if err != nil || lines != nil {
+		t.Fatalf("missing transcript = %v, %v", lines, err)
+	}
+	if err := os.WriteFile(filepath.Join(itemDir, "transcript.log"), []byte("one\r\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, err = Transcript(runDir, "item-0001")
+	if err != nil || !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("transcript = %q, %v", lines, err)
+	}
+}
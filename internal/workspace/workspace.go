@@ -63,6 +63,17 @@ func (w *Workspace) EnsureDirs() error {
 	return nil
 }
 
+// StatePaths are the directories okrchestra itself writes to while a plan
+// runs, which agent sandboxes do not check.
+func (w *Workspace) StatePaths() []string {
+	return []string{
+		w.ArtifactsDir,
+		w.AuditDir,
+		filepath.Dir(w.AuditDBPath),
+		filepath.Join(w.MetricsDir, "snapshots"),
+	}
+}
+
 // ResolvePath returns an absolute path, resolving relative paths from the workspace root.
 func (w *Workspace) ResolvePath(path string) (string, error) {
 	if w == nil {
@@ -127,3 +138,44 @@ func expandHome(path string) (string, error) {
 	}
 	return "", fmt.Errorf("unsupported home expansion: %s", path)
 }
+
+// topLevelDirs are the workspace directories recognised when relativizing
+// paths recorded under a different (moved) workspace root.
+var topLevelDirs = []string{"okrs", "culture", "metrics", "artifacts", "audit"}
+
+// RelPath returns path relative to the workspace root using forward slashes
+// when it lies inside the workspace. Other paths are returned cleaned but
+// otherwise unchanged.
+func (w *Workspace) RelPath(path string) string {
+	if w == nil || strings.TrimSpace(path) == "" {
+		return path
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Clean(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// MigratePath is RelPath for paths recorded by older versions, which may
+// point into the same workspace at a previous location. An absolute path
+// outside the root is rewritten from its first standard top-level directory
+// (okrs, metrics, artifacts, ...) onward; anything else is left unchanged.
+func (w *Workspace) MigratePath(path string) string {
+	rel := w.RelPath(path)
+	if !filepath.IsAbs(rel) {
+		return rel
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		for _, dir := range topLevelDirs {
+			if parts[i] == dir {
+				return strings.Join(parts[i:], "/")
+			}
+		}
+	}
+	return rel
+}